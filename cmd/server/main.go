@@ -66,9 +66,19 @@ func main() {
 	var kimiLogin bool
 	var projectID string
 	var vertexImport string
+	var migrateAPIKeys bool
 	var configPath string
 	var password string
 
+	// "auth inspect" runs the auth inspection once and exits; its flags share
+	// the global flag set so config and token store setup match the server.
+	authInspect := len(os.Args) > 2 && os.Args[1] == "auth" && os.Args[2] == "inspect"
+	var authInspectOpts cmd.AuthInspectOptions
+	if authInspect {
+		cmd.RegisterAuthInspectFlags(flag.CommandLine, &authInspectOpts)
+		os.Args = append(os.Args[:1], os.Args[3:]...)
+	}
+
 	// Define command-line flags for different operation modes.
 	flag.BoolVar(&login, "login", false, "Login Google Account")
 	flag.BoolVar(&codexLogin, "codex-login", false, "Login to Codex using OAuth")
@@ -83,6 +93,7 @@ func main() {
 	flag.StringVar(&projectID, "project_id", "", "Project ID (Gemini only, not required)")
 	flag.StringVar(&configPath, "config", DefaultConfigPath, "Configure File Path")
 	flag.StringVar(&vertexImport, "vertex-import", "", "Import Vertex service account key JSON file")
+	flag.BoolVar(&migrateAPIKeys, "migrate-api-keys", false, "Hash plaintext api-keys in the config file (writes a backup first)")
 	flag.StringVar(&password, "password", "", "")
 
 	flag.CommandLine.Usage = func() {
@@ -110,6 +121,9 @@ func main() {
 			}
 			_, _ = fmt.Fprint(out, s+"\n")
 		})
+		if !authInspect {
+			_, _ = fmt.Fprintf(out, "\nSubcommands:\n  auth inspect [flags]\n    Verify auth files once without starting the server (see %s auth inspect -h)\n", os.Args[0])
+		}
 	}
 
 	// Parse the command-line flags.
@@ -449,9 +463,13 @@ func main() {
 
 	// Handle different command modes based on the provided flags.
 
-	if vertexImport != "" {
+	if authInspect {
+		os.Exit(cmd.DoAuthInspect(cfg, authInspectOpts))
+	} else if vertexImport != "" {
 		// Handle Vertex service account import
 		cmd.DoVertexImport(cfg, vertexImport)
+	} else if migrateAPIKeys {
+		cmd.DoMigrateAPIKeys(cfg, configFilePath)
 	} else if login {
 		// Handle Google/Gemini login
 		cmd.DoLogin(cfg, projectID, options)
@@ -2,9 +2,13 @@ package configaccess
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"net/http"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 )
@@ -31,6 +35,8 @@ func Register(cfg *sdkconfig.SDKConfig) {
 type provider struct {
 	name string
 	keys map[string]struct{}
+	// hashed holds the digests of "sha256:" entries, compared in constant time.
+	hashed [][]byte
 }
 
 func newProvider(name string, keys []string) *provider {
@@ -39,10 +45,27 @@ func newProvider(name string, keys []string) *provider {
 		providerName = sdkaccess.DefaultAccessProviderName
 	}
 	keySet := make(map[string]struct{}, len(keys))
+	var hashed [][]byte
 	for _, key := range keys {
+		if hash, _, ok := config.ParseHashedAPIKey(key); ok {
+			digest, _ := hex.DecodeString(strings.TrimPrefix(hash, config.APIKeyHashPrefix))
+			hashed = append(hashed, digest)
+			continue
+		}
 		keySet[key] = struct{}{}
 	}
-	return &provider{name: providerName, keys: keySet}
+	return &provider{name: providerName, keys: keySet, hashed: hashed}
+}
+
+// matchHashed reports whether key matches a hashed entry. Every entry is
+// compared so timing does not reveal which one matched.
+func (p *provider) matchHashed(key string) bool {
+	sum := sha256.Sum256([]byte(key))
+	matched := 0
+	for _, digest := range p.hashed {
+		matched |= subtle.ConstantTimeCompare(sum[:], digest)
+	}
+	return matched == 1
 }
 
 func (p *provider) Identifier() string {
@@ -56,7 +79,7 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 	if p == nil {
 		return nil, sdkaccess.NewNotHandledError()
 	}
-	if len(p.keys) == 0 {
+	if len(p.keys) == 0 && len(p.hashed) == 0 {
 		return nil, sdkaccess.NewNotHandledError()
 	}
 	authHeader := r.Header.Get("Authorization")
@@ -89,10 +112,17 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 		if candidate.value == "" {
 			continue
 		}
+		principal := ""
 		if _, ok := p.keys[candidate.value]; ok {
+			principal = candidate.value
+		} else if len(p.hashed) > 0 && p.matchHashed(candidate.value) {
+			// Usage tracking keys off the hash so the plaintext never leaves this function.
+			principal = config.HashAPIKey(candidate.value)
+		}
+		if principal != "" {
 			return &sdkaccess.Result{
 				Provider:  p.Identifier(),
-				Principal: candidate.value,
+				Principal: principal,
 				Metadata: map[string]string{
 					"source": candidate.source,
 				},
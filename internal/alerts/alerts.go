@@ -0,0 +1,380 @@
+// Package alerts evaluates threshold rules against snapshots of auth state and
+// keeps an in-memory history of firing and resolved alerts.
+//
+// Rules are dispatched by type through a registry of Evaluator functions, so new
+// alert kinds (error rates, disk usage, ...) only need to register an evaluator.
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// RuleTypeInvalidRatio fires when invalid/total auths for a provider reaches the threshold.
+	RuleTypeInvalidRatio = "invalid-ratio"
+
+	// StateFiring marks an alert that crossed its threshold.
+	StateFiring = "firing"
+	// StateResolved marks an alert that recovered below its resolve threshold.
+	StateResolved = "resolved"
+
+	defaultHistoryLimit = 200
+	// defaultResolveFactor derives the resolve threshold when a rule does not set one.
+	defaultResolveFactor = 0.9
+)
+
+// ProviderCounts holds auth totals for one provider.
+type ProviderCounts struct {
+	Total   int `json:"total"`
+	Invalid int `json:"invalid"`
+}
+
+// Snapshot is the state a rule is evaluated against.
+type Snapshot struct {
+	// Source describes what triggered the evaluation, e.g. "inspection" or "periodic".
+	Source    string
+	At        time.Time
+	Providers map[string]ProviderCounts
+}
+
+// Observation is the value an evaluator computed for a rule.
+type Observation struct {
+	Value   float64
+	Details map[string]any
+}
+
+// Evaluator computes the observed value for rule. It returns false when the
+// rule cannot be evaluated against snap (for example too few auths).
+type Evaluator func(rule config.AlertRule, snap Snapshot) (Observation, bool)
+
+var (
+	evaluatorsMu sync.RWMutex
+	evaluators   = map[string]Evaluator{
+		RuleTypeInvalidRatio: evaluateInvalidRatio,
+	}
+)
+
+// RegisterEvaluator installs fn as the evaluator for ruleType, replacing any existing one.
+func RegisterEvaluator(ruleType string, fn Evaluator) {
+	ruleType = strings.ToLower(strings.TrimSpace(ruleType))
+	if ruleType == "" || fn == nil {
+		return
+	}
+	evaluatorsMu.Lock()
+	evaluators[ruleType] = fn
+	evaluatorsMu.Unlock()
+}
+
+// KnownRuleType reports whether an evaluator is registered for ruleType.
+func KnownRuleType(ruleType string) bool {
+	return lookupEvaluator(ruleType) != nil
+}
+
+func lookupEvaluator(ruleType string) Evaluator {
+	evaluatorsMu.RLock()
+	defer evaluatorsMu.RUnlock()
+	return evaluators[strings.ToLower(strings.TrimSpace(ruleType))]
+}
+
+func evaluateInvalidRatio(rule config.AlertRule, snap Snapshot) (Observation, bool) {
+	counts := ProviderCounts{}
+	provider := strings.ToLower(strings.TrimSpace(rule.Provider))
+	for name, item := range snap.Providers {
+		if provider != "" && name != provider {
+			continue
+		}
+		counts.Total += item.Total
+		counts.Invalid += item.Invalid
+	}
+	if counts.Total == 0 || counts.Total < rule.MinTotal {
+		return Observation{}, false
+	}
+	return Observation{
+		Value: float64(counts.Invalid) / float64(counts.Total),
+		Details: map[string]any{
+			"total":   counts.Total,
+			"invalid": counts.Invalid,
+		},
+	}, true
+}
+
+// Record is one entry in the alert history.
+type Record struct {
+	ID               int64          `json:"id"`
+	Rule             string         `json:"rule"`
+	Type             string         `json:"type"`
+	Provider         string         `json:"provider,omitempty"`
+	State            string         `json:"state"`
+	Value            float64        `json:"value"`
+	Threshold        float64        `json:"threshold"`
+	ResolveThreshold float64        `json:"resolve_threshold"`
+	Previous         float64        `json:"previous"`
+	Delta            float64        `json:"delta"`
+	Details          map[string]any `json:"details,omitempty"`
+	Message          string         `json:"message"`
+	Source           string         `json:"source,omitempty"`
+	At               time.Time      `json:"at"`
+	NotifyError      string         `json:"notify_error,omitempty"`
+}
+
+type ruleState struct {
+	firing    bool
+	hasValue  bool
+	lastValue float64
+	lastAt    time.Time
+	current   Record
+}
+
+// SendFunc delivers a notification event.
+type SendFunc func(ctx context.Context, cfg config.NotificationsConfig, event notify.Event) error
+
+// Engine evaluates alert rules and tracks their state between evaluations.
+type Engine struct {
+	mu           sync.Mutex
+	states       map[string]*ruleState
+	history      []Record
+	nextID       int64
+	historyLimit int
+	send         SendFunc
+}
+
+// NewEngine returns an engine that delivers notifications through notify.Send.
+func NewEngine() *Engine {
+	return &Engine{
+		states:       make(map[string]*ruleState),
+		historyLimit: defaultHistoryLimit,
+		send:         notify.Send,
+	}
+}
+
+// SetSender overrides how notifications are delivered.
+func (e *Engine) SetSender(fn SendFunc) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	e.send = fn
+	e.mu.Unlock()
+}
+
+// RuleKey returns the identity used to track rule state across evaluations.
+func RuleKey(rule config.AlertRule) string {
+	if name := strings.TrimSpace(rule.Name); name != "" {
+		return name
+	}
+	provider := strings.ToLower(strings.TrimSpace(rule.Provider))
+	if provider == "" {
+		provider = "all"
+	}
+	return strings.ToLower(strings.TrimSpace(rule.Type)) + ":" + provider
+}
+
+// ResolveThreshold returns the effective resolve threshold for rule.
+func ResolveThreshold(rule config.AlertRule) float64 {
+	if rule.ResolveThreshold > 0 && rule.ResolveThreshold < rule.Threshold {
+		return rule.ResolveThreshold
+	}
+	return rule.Threshold * defaultResolveFactor
+}
+
+// Evaluate runs every configured rule against snap, records state transitions
+// in the history, and sends notifications for them. It returns the new records.
+func (e *Engine) Evaluate(ctx context.Context, cfg *config.Config, snap Snapshot) []Record {
+	if e == nil || cfg == nil || !cfg.Alerts.Enabled {
+		return nil
+	}
+	if snap.At.IsZero() {
+		snap.At = time.Now()
+	}
+
+	var transitions []Record
+	e.mu.Lock()
+	seen := make(map[string]struct{}, len(cfg.Alerts.Rules))
+	for _, rule := range cfg.Alerts.Rules {
+		key := RuleKey(rule)
+		seen[key] = struct{}{}
+		evaluator := lookupEvaluator(rule.Type)
+		if evaluator == nil {
+			log.Warnf("alerts: unknown rule type %q for rule %s", rule.Type, key)
+			continue
+		}
+		obs, ok := evaluator(rule, snap)
+		if !ok {
+			continue
+		}
+		state := e.states[key]
+		if state == nil {
+			state = &ruleState{}
+			e.states[key] = state
+		}
+		previous := obs.Value
+		if state.hasValue {
+			previous = state.lastValue
+		}
+		resolveAt := ResolveThreshold(rule)
+
+		nextState := ""
+		switch {
+		case !state.firing && obs.Value >= rule.Threshold:
+			nextState = StateFiring
+		case state.firing && obs.Value < resolveAt:
+			nextState = StateResolved
+		}
+
+		state.hasValue = true
+		state.lastValue = obs.Value
+		state.lastAt = snap.At
+		if nextState == "" {
+			if state.firing {
+				state.current.Value = obs.Value
+				state.current.Details = obs.Details
+			}
+			continue
+		}
+
+		e.nextID++
+		record := Record{
+			ID:               e.nextID,
+			Rule:             key,
+			Type:             strings.ToLower(strings.TrimSpace(rule.Type)),
+			Provider:         strings.ToLower(strings.TrimSpace(rule.Provider)),
+			State:            nextState,
+			Value:            obs.Value,
+			Threshold:        rule.Threshold,
+			ResolveThreshold: resolveAt,
+			Previous:         previous,
+			Delta:            obs.Value - previous,
+			Details:          obs.Details,
+			Source:           strings.TrimSpace(snap.Source),
+			At:               snap.At,
+		}
+		record.Message = describe(record)
+		state.firing = nextState == StateFiring
+		state.current = record
+		transitions = append(transitions, record)
+	}
+	for key := range e.states {
+		if _, ok := seen[key]; !ok {
+			delete(e.states, key)
+		}
+	}
+	send := e.send
+	e.mu.Unlock()
+
+	for i := range transitions {
+		if send != nil && notify.HasChannels(cfg.Notifications) {
+			if errSend := send(ctx, cfg.Notifications, eventForRecord(transitions[i])); errSend != nil {
+				transitions[i].NotifyError = errSend.Error()
+				log.Warnf("alerts: failed to notify for rule %s: %v", transitions[i].Rule, errSend)
+			}
+		}
+		log.Infof("alerts: %s", transitions[i].Message)
+	}
+	if len(transitions) > 0 {
+		e.mu.Lock()
+		e.history = append(e.history, transitions...)
+		if over := len(e.history) - e.historyLimit; over > 0 {
+			e.history = append([]Record(nil), e.history[over:]...)
+		}
+		e.mu.Unlock()
+	}
+	return transitions
+}
+
+// History returns up to limit records, newest first. A limit <= 0 returns all.
+func (e *Engine) History(limit int) []Record {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]Record, 0, len(e.history))
+	for i := len(e.history) - 1; i >= 0; i-- {
+		out = append(out, e.history[i])
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	return out
+}
+
+// Active returns the currently firing alerts sorted by rule key.
+func (e *Engine) Active() []Record {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]Record, 0)
+	for _, state := range e.states {
+		if state.firing {
+			out = append(out, state.current)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Rule < out[j].Rule })
+	return out
+}
+
+func describe(record Record) string {
+	scope := record.Provider
+	if scope == "" {
+		scope = "all providers"
+	}
+	verb := "crossed"
+	if record.State == StateResolved {
+		verb = "recovered below"
+	}
+	msg := fmt.Sprintf("%s %s for %s: %.1f%% %s threshold %.1f%% (previous %.1f%%, delta %+.1f%%)",
+		record.Type, record.State, scope, record.Value*100, verb, thresholdFor(record)*100, record.Previous*100, record.Delta*100)
+	if total, ok := record.Details["total"]; ok {
+		msg += fmt.Sprintf(", invalid %v of %v", record.Details["invalid"], total)
+	}
+	return msg
+}
+
+func thresholdFor(record Record) float64 {
+	if record.State == StateResolved {
+		return record.ResolveThreshold
+	}
+	return record.Threshold
+}
+
+func eventForRecord(record Record) notify.Event {
+	severity := "warning"
+	title := fmt.Sprintf("[ALERT] %s", record.Rule)
+	if record.State == StateResolved {
+		severity = "info"
+		title = fmt.Sprintf("[RESOLVED] %s", record.Rule)
+	}
+	data := map[string]any{
+		"rule":              record.Rule,
+		"rule_type":         record.Type,
+		"provider":          record.Provider,
+		"state":             record.State,
+		"value":             record.Value,
+		"threshold":         record.Threshold,
+		"resolve_threshold": record.ResolveThreshold,
+		"previous":          record.Previous,
+		"delta":             record.Delta,
+	}
+	for key, value := range record.Details {
+		data[key] = value
+	}
+	return notify.Event{
+		Type:      "alert." + record.State,
+		Severity:  severity,
+		Title:     title,
+		Message:   record.Message,
+		Data:      data,
+		Timestamp: record.At.UTC(),
+	}
+}
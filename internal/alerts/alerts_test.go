@@ -0,0 +1,98 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
+)
+
+func invalidRatioConfig() *config.Config {
+	return &config.Config{
+		Notifications: config.NotificationsConfig{
+			Webhooks: []config.WebhookNotification{{URL: "http://example.invalid/hook"}},
+		},
+		Alerts: config.AlertsConfig{
+			Enabled: true,
+			Rules: []config.AlertRule{{
+				Type:             RuleTypeInvalidRatio,
+				Provider:         "codex",
+				Threshold:        0.2,
+				ResolveThreshold: 0.1,
+			}},
+		},
+	}
+}
+
+func snapshotWith(total, invalid int) Snapshot {
+	return Snapshot{
+		Source: "test",
+		Providers: map[string]ProviderCounts{
+			"codex":  {Total: total, Invalid: invalid},
+			"gemini": {Total: 10, Invalid: 10},
+		},
+	}
+}
+
+func TestEngineInvalidRatioHysteresis(t *testing.T) {
+	cfg := invalidRatioConfig()
+	engine := NewEngine()
+	var sent []notify.Event
+	engine.SetSender(func(_ context.Context, _ config.NotificationsConfig, event notify.Event) error {
+		sent = append(sent, event)
+		return nil
+	})
+
+	steps := []struct {
+		invalid   int
+		wantState string
+	}{
+		{invalid: 1},                         // 10%: below threshold
+		{invalid: 2, wantState: StateFiring}, // 20%: fires
+		{invalid: 3},                         // 30%: still firing, no new record
+		{invalid: 1},                         // 10%: not below resolve threshold yet
+		{invalid: 0, wantState: StateResolved},
+		{invalid: 1}, // 10%: below threshold again, no record
+	}
+	for i, step := range steps {
+		records := engine.Evaluate(context.Background(), cfg, snapshotWith(10, step.invalid))
+		if step.wantState == "" {
+			if len(records) != 0 {
+				t.Fatalf("step %d: expected no transition, got %+v", i, records)
+			}
+			continue
+		}
+		if len(records) != 1 || records[0].State != step.wantState {
+			t.Fatalf("step %d: expected %s transition, got %+v", i, step.wantState, records)
+		}
+	}
+
+	history := engine.History(0)
+	if len(history) != 2 {
+		t.Fatalf("expected 2 history records, got %d", len(history))
+	}
+	if history[0].State != StateResolved || history[1].State != StateFiring {
+		t.Fatalf("expected newest-first history, got %+v", history)
+	}
+	if history[1].Previous != 0.1 || history[1].Delta <= 0 {
+		t.Fatalf("expected firing record to carry delta from previous run, got %+v", history[1])
+	}
+	if len(sent) != 2 || sent[0].Type != "alert.firing" || sent[1].Type != "alert.resolved" {
+		t.Fatalf("unexpected notifications: %+v", sent)
+	}
+	if len(engine.Active()) != 0 {
+		t.Fatalf("expected no active alerts after resolution")
+	}
+}
+
+func TestEngineSkipsRuleBelowMinTotal(t *testing.T) {
+	cfg := invalidRatioConfig()
+	cfg.Alerts.Rules[0].MinTotal = 20
+	engine := NewEngine()
+	engine.SetSender(nil)
+
+	if records := engine.Evaluate(context.Background(), cfg, snapshotWith(10, 10)); len(records) != 0 {
+		t.Fatalf("expected rule to be skipped below min-total, got %+v", records)
+	}
+}
@@ -0,0 +1,208 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/alerts"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const (
+	minAlertEvaluateIntervalSeconds = 60
+	alertEvaluatorTick              = 10 * time.Second
+	defaultAlertHistoryLimit        = 50
+	alertNotifyTimeout              = 30 * time.Second
+)
+
+func (h *Handler) alertEngine() *alerts.Engine {
+	h.alertsOnce.Do(func() {
+		if h.alertsEngine == nil {
+			h.alertsEngine = alerts.NewEngine()
+		}
+	})
+	return h.alertsEngine
+}
+
+// startAlertEvaluator launches the optional periodic evaluation of alert rules
+// against live auth state. Evaluation after inspection runs does not depend on it.
+func (h *Handler) startAlertEvaluator() {
+	h.life.goWorker(func() {
+		ticker := time.NewTicker(alertEvaluatorTick)
+		defer ticker.Stop()
+		var last time.Time
+		for {
+			select {
+			case <-h.life.stopping():
+				return
+			case <-ticker.C:
+			}
+			if h.cfg == nil || !h.cfg.Alerts.Enabled || h.cfg.Alerts.EvaluateIntervalSeconds <= 0 {
+				continue
+			}
+			interval := time.Duration(h.cfg.Alerts.EvaluateIntervalSeconds) * time.Second
+			if !last.IsZero() && time.Since(last) < interval {
+				continue
+			}
+			last = time.Now()
+			h.evaluateAlerts(context.Background(), "periodic")
+		}
+	})
+}
+
+// authAlertSnapshot counts file-backed auths and invalid auths per provider.
+func (h *Handler) authAlertSnapshot(source string) alerts.Snapshot {
+	snap := alerts.Snapshot{
+		Source:    source,
+		At:        time.Now(),
+		Providers: make(map[string]alerts.ProviderCounts),
+	}
+	if h == nil || h.authManager == nil {
+		return snap
+	}
+	for _, auth := range h.authManager.List() {
+		if auth == nil || isRuntimeOnlyAuth(auth) {
+			continue
+		}
+		provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+		counts := snap.Providers[provider]
+		counts.Total++
+		if invalid, _ := tokenInvalidState(auth); invalid {
+			counts.Invalid++
+		}
+		snap.Providers[provider] = counts
+	}
+	return snap
+}
+
+func (h *Handler) evaluateAlerts(ctx context.Context, source string) []alerts.Record {
+	if h == nil || h.cfg == nil || !h.cfg.Alerts.Enabled || len(h.cfg.Alerts.Rules) == 0 {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, alertNotifyTimeout)
+	defer cancel()
+	return h.alertEngine().Evaluate(ctx, h.cfg, h.authAlertSnapshot(source))
+}
+
+func validateAlertsConfig(cfg config.AlertsConfig) error {
+	if cfg.EvaluateIntervalSeconds < 0 || (cfg.EvaluateIntervalSeconds > 0 && cfg.EvaluateIntervalSeconds < minAlertEvaluateIntervalSeconds) {
+		return fmt.Errorf("evaluate-interval-seconds must be 0 or at least %d", minAlertEvaluateIntervalSeconds)
+	}
+	keys := make(map[string]struct{}, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		if !alerts.KnownRuleType(rule.Type) {
+			return fmt.Errorf("rules[%d]: unknown type %q", i, rule.Type)
+		}
+		if rule.Threshold <= 0 || rule.Threshold > 1 {
+			return fmt.Errorf("rules[%d]: threshold must be in (0, 1]", i)
+		}
+		if rule.ResolveThreshold < 0 || (rule.ResolveThreshold > 0 && rule.ResolveThreshold >= rule.Threshold) {
+			return fmt.Errorf("rules[%d]: resolve-threshold must be below threshold", i)
+		}
+		if rule.MinTotal < 0 {
+			return fmt.Errorf("rules[%d]: min-total must not be negative", i)
+		}
+		key := alerts.RuleKey(rule)
+		if _, dup := keys[key]; dup {
+			return fmt.Errorf("rules[%d]: duplicate rule %q", i, key)
+		}
+		keys[key] = struct{}{}
+	}
+	return nil
+}
+
+func (h *Handler) saveAlertsConfig(c *gin.Context, next config.AlertsConfig) {
+	if err := validateAlertsConfig(next); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.mu.Lock()
+	oldCfg := h.cfg.Alerts
+	h.cfg.Alerts = next
+	h.cfg.SanitizeAlerts()
+	saved := h.cfg.Alerts
+	errSave := config.SaveConfigPreserveComments(h.configFilePath, h.cfg)
+	if errSave != nil {
+		h.cfg.Alerts = oldCfg
+	}
+	h.mu.Unlock()
+	if errSave != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", errSave)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "alerts": saved})
+}
+
+// GetAlerts returns currently firing alerts and the alert history, newest first.
+func (h *Handler) GetAlerts(c *gin.Context) {
+	limit := parsePositiveInt(c.Query("limit"), defaultAlertHistoryLimit, 1, 1000)
+	engine := h.alertEngine()
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"active":  engine.Active(),
+		"history": engine.History(limit),
+	})
+}
+
+// GetAlertsConfig returns the alerts section of the config.
+func (h *Handler) GetAlertsConfig(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "config unavailable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"alerts": h.cfg.Alerts})
+}
+
+// PutAlertsConfig replaces the alerts section of the config.
+func (h *Handler) PutAlertsConfig(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "config unavailable"})
+		return
+	}
+	var req config.AlertsConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	h.saveAlertsConfig(c, req)
+}
+
+// PatchAlertsConfig updates only the provided fields of the alerts section.
+func (h *Handler) PatchAlertsConfig(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "config unavailable"})
+		return
+	}
+	var req struct {
+		Enabled                 *bool               `json:"enabled"`
+		EvaluateIntervalSeconds *int                `json:"evaluate-interval-seconds"`
+		Rules                   *[]config.AlertRule `json:"rules"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if req.Enabled == nil && req.EvaluateIntervalSeconds == nil && req.Rules == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no config field provided"})
+		return
+	}
+	next := h.cfg.Alerts
+	next.Rules = append([]config.AlertRule(nil), next.Rules...)
+	if req.Enabled != nil {
+		next.Enabled = *req.Enabled
+	}
+	if req.EvaluateIntervalSeconds != nil {
+		next.EvaluateIntervalSeconds = *req.EvaluateIntervalSeconds
+	}
+	if req.Rules != nil {
+		next.Rules = *req.Rules
+	}
+	h.saveAlertsConfig(c, next)
+}
@@ -0,0 +1,84 @@
+package management
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "sk-" + hex.EncodeToString(buf), nil
+}
+
+// CreateAPIKey adds a client API key stored only as its hash. When no key is
+// supplied one is generated; the plaintext is only returned in this response.
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	var body struct {
+		Key string `json:"key"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+	key := strings.TrimSpace(body.Key)
+	if key == "" {
+		generated, err := generateAPIKey()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to generate key: %v", err)})
+			return
+		}
+		key = generated
+	}
+	if config.IsHashedAPIKey(key) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key must be plaintext"})
+		return
+	}
+	entry := config.HashedAPIKeyEntry(key)
+	hash := config.HashAPIKey(key)
+
+	h.mu.Lock()
+	for _, existing := range h.cfg.APIKeys {
+		existingHash, _, hashed := config.ParseHashedAPIKey(existing)
+		if existing == key || (hashed && existingHash == hash) {
+			h.mu.Unlock()
+			c.JSON(http.StatusConflict, gin.H{"error": "api key already exists"})
+			return
+		}
+	}
+	oldKeys := h.cfg.APIKeys
+	h.cfg.APIKeys = append(append([]string(nil), oldKeys...), entry)
+	errSave := config.SaveConfigPreserveComments(h.configFilePath, h.cfg)
+	if errSave != nil {
+		h.cfg.APIKeys = oldKeys
+	}
+	h.mu.Unlock()
+	if errSave != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", errSave)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "key": key, "entry": entry})
+}
+
+// MigrateAPIKeys replaces plaintext api-keys entries with hashed entries after
+// writing a timestamped backup of the config file.
+func (h *Handler) MigrateAPIKeys(c *gin.Context) {
+	h.mu.Lock()
+	converted, backup, err := config.MigrateAPIKeysFile(h.configFilePath, h.cfg)
+	h.mu.Unlock()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to migrate api keys: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "migrated": converted, "backup": backup})
+}
@@ -12,7 +12,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	claudeauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/claude"
 	codexauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/codex"
+	qwenauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/qwen"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/geminicli"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
@@ -41,6 +43,10 @@ const (
 
 var antigravityOAuthTokenURL = "https://oauth2.googleapis.com/token"
 
+var qwenOAuthTokenURL = qwenauth.QwenOAuthTokenEndpoint
+
+var codexOAuthTokenURL = codexauth.TokenURL
+
 type apiCallRequest struct {
 	AuthIndexSnake  *string           `json:"auth_index"`
 	AuthIndexCamel  *string           `json:"authIndex"`
@@ -227,16 +233,11 @@ func (h *Handler) syncTokenInvalidStateFromAPICall(ctx context.Context, auth *co
 	}
 
 	if statusCode == http.StatusUnauthorized {
-		reason := normalizeTokenInvalidReason(strings.TrimSpace(fmt.Sprintf("401 %s", body)))
-		setTokenInvalidState(auth, true, reason)
-		auth.UpdatedAt = time.Now()
-		_, _ = h.authManager.Update(ctx, auth)
+		_, _ = h.markAuthInvalid(ctx, auth.ID, strings.TrimSpace(fmt.Sprintf("401 %s", body)))
 		return
 	}
 	if statusCode >= 200 && statusCode < 300 {
-		setTokenInvalidState(auth, false, "")
-		auth.UpdatedAt = time.Now()
-		_, _ = h.authManager.Update(ctx, auth)
+		_, _, _ = h.clearAuthInvalid(ctx, auth.ID)
 	}
 }
 
@@ -276,6 +277,10 @@ func (h *Handler) resolveTokenForAuth(ctx context.Context, auth *coreauth.Auth)
 	if auth == nil {
 		return "", nil
 	}
+	// A frozen auth is never refreshed; use the token it already has.
+	if coreauth.IsFrozen(auth) {
+		return tokenValueForAuth(auth), nil
+	}
 
 	provider := strings.ToLower(strings.TrimSpace(auth.Provider))
 	if provider == "gemini-cli" {
@@ -290,6 +295,14 @@ func (h *Handler) resolveTokenForAuth(ctx context.Context, auth *coreauth.Auth)
 		token, errToken := h.refreshCodexOAuthAccessToken(ctx, auth)
 		return token, errToken
 	}
+	if provider == "claude" {
+		token, errToken := h.refreshClaudeOAuthAccessToken(ctx, auth)
+		return token, errToken
+	}
+	if provider == "qwen" {
+		token, errToken := h.refreshQwenOAuthAccessToken(ctx, auth)
+		return token, errToken
+	}
 
 	return tokenValueForAuth(auth), nil
 }
@@ -522,6 +535,7 @@ func (h *Handler) refreshCodexOAuthAccessToken(ctx context.Context, auth *coreau
 	}
 
 	svc := codexauth.NewCodexAuth(h.cfg)
+	svc.SetTokenURL(codexOAuthTokenURL)
 	tokenData, err := svc.RefreshTokensWithRetry(ctx, refreshToken, 3)
 	if err != nil {
 		return "", err
@@ -557,6 +571,157 @@ func (h *Handler) refreshCodexOAuthAccessToken(ctx context.Context, auth *coreau
 	return strings.TrimSpace(tokenData.AccessToken), nil
 }
 
+// refreshClaudeOAuthAccessToken returns a claude auth's access token,
+// refreshing it first when it expires within 30 seconds.
+func (h *Handler) refreshClaudeOAuthAccessToken(ctx context.Context, auth *coreauth.Auth) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if auth == nil {
+		return "", nil
+	}
+	metadata := auth.Metadata
+	if len(metadata) == 0 {
+		return "", fmt.Errorf("claude oauth metadata missing")
+	}
+
+	currentAccessToken := strings.TrimSpace(stringValue(metadata, "access_token"))
+	refreshToken := strings.TrimSpace(stringValue(metadata, "refresh_token"))
+	if refreshToken == "" {
+		if currentAccessToken == "" {
+			return "", fmt.Errorf("claude access token missing")
+		}
+		if codexTokenNeedsRefresh(metadata) {
+			return "", fmt.Errorf("claude token expired and refresh token missing")
+		}
+		return currentAccessToken, nil
+	}
+	if currentAccessToken != "" && !codexTokenNeedsRefresh(metadata) {
+		return currentAccessToken, nil
+	}
+	if h == nil || h.cfg == nil {
+		return "", fmt.Errorf("claude config unavailable")
+	}
+
+	svc := claudeauth.NewClaudeAuth(h.cfg)
+	tokenData, err := svc.RefreshTokensWithRetry(ctx, refreshToken, 3)
+	if err != nil {
+		return "", err
+	}
+
+	auth.Metadata["type"] = "claude"
+	auth.Metadata["access_token"] = tokenData.AccessToken
+	if tokenData.RefreshToken != "" {
+		auth.Metadata["refresh_token"] = tokenData.RefreshToken
+	}
+	if tokenData.Email != "" {
+		auth.Metadata["email"] = tokenData.Email
+	}
+	if tokenData.Expire != "" {
+		auth.Metadata["expired"] = tokenData.Expire
+	}
+	auth.Metadata["last_refresh"] = time.Now().UTC().Format(time.RFC3339)
+
+	if h.authManager != nil {
+		auth.LastRefreshedAt = time.Now()
+		auth.UpdatedAt = time.Now()
+		_, _ = h.authManager.Update(ctx, auth)
+	}
+	return strings.TrimSpace(tokenData.AccessToken), nil
+}
+
+// refreshQwenOAuthAccessToken returns a qwen auth's access token, refreshing
+// it first when it expires within 30 seconds. The refreshed token is saved
+// through the auth manager's store. A failed request is returned as the
+// *url.Error of the client.
+func (h *Handler) refreshQwenOAuthAccessToken(ctx context.Context, auth *coreauth.Auth) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if auth == nil {
+		return "", nil
+	}
+	metadata := auth.Metadata
+	if len(metadata) == 0 {
+		return "", fmt.Errorf("qwen oauth metadata missing")
+	}
+
+	current := strings.TrimSpace(stringValue(metadata, "access_token"))
+	if current != "" && !codexTokenNeedsRefresh(metadata) {
+		return current, nil
+	}
+	refreshToken := stringValue(metadata, "refresh_token")
+	if refreshToken == "" {
+		if current == "" {
+			return "", fmt.Errorf("qwen access token missing")
+		}
+		return "", fmt.Errorf("qwen token expired and refresh token missing")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+	form.Set("client_id", qwenauth.QwenOAuthClientID)
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, qwenOAuthTokenURL, strings.NewReader(form.Encode()))
+	if errReq != nil {
+		return "", errReq
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := &http.Client{
+		Timeout:   defaultAPICallTimeout,
+		Transport: h.apiCallTransport(auth),
+	}
+	resp, errDo := httpClient.Do(req)
+	if errDo != nil {
+		return "", errDo
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("response body close error: %v", errClose)
+		}
+	}()
+
+	bodyBytes, errRead := io.ReadAll(resp.Body)
+	if errRead != nil {
+		return "", errRead
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return "", fmt.Errorf("qwen oauth token refresh failed: status %d: %s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
+	}
+
+	var tokenResp qwenauth.QwenTokenResponse
+	if errUnmarshal := json.Unmarshal(bodyBytes, &tokenResp); errUnmarshal != nil {
+		return "", errUnmarshal
+	}
+	if strings.TrimSpace(tokenResp.AccessToken) == "" {
+		return "", fmt.Errorf("qwen oauth token refresh returned empty access_token")
+	}
+
+	now := time.Now()
+	auth.Metadata["access_token"] = strings.TrimSpace(tokenResp.AccessToken)
+	if strings.TrimSpace(tokenResp.RefreshToken) != "" {
+		auth.Metadata["refresh_token"] = strings.TrimSpace(tokenResp.RefreshToken)
+	}
+	if strings.TrimSpace(tokenResp.ResourceURL) != "" {
+		auth.Metadata["resource_url"] = strings.TrimSpace(tokenResp.ResourceURL)
+	}
+	auth.Metadata["expired"] = now.Add(time.Duration(tokenResp.ExpiresIn) * time.Second).Format(time.RFC3339)
+	auth.Metadata["last_refresh"] = now.Format(time.RFC3339)
+	auth.Metadata["type"] = "qwen"
+
+	if h.authManager != nil {
+		auth.LastRefreshedAt = now
+		auth.UpdatedAt = now
+		_, _ = h.authManager.Update(ctx, auth)
+	}
+	return strings.TrimSpace(tokenResp.AccessToken), nil
+}
+
+// codexTokenNeedsRefresh reports whether the token whose RFC 3339 expiry is
+// stored under "expired", as codex and claude auths keep it, expires within
+// 30 seconds or has no readable expiry.
 func codexTokenNeedsRefresh(metadata map[string]any) bool {
 	if len(metadata) == 0 {
 		return true
@@ -747,12 +912,22 @@ func (h *Handler) authByIndex(authIndex string) *coreauth.Auth {
 	return nil
 }
 
+// apiCallTransport returns a transport going through the auth's proxy, taken
+// from ProxyURL or its proxy_url attribute or metadata, or else through the
+// global proxy. Probes and token refreshes share it.
 func (h *Handler) apiCallTransport(auth *coreauth.Auth) http.RoundTripper {
 	var proxyCandidates []string
 	if auth != nil {
 		if proxyStr := strings.TrimSpace(auth.ProxyURL); proxyStr != "" {
 			proxyCandidates = append(proxyCandidates, proxyStr)
 		}
+		// Auth files carry their proxy in proxy_url rather than in ProxyURL.
+		if proxyStr := strings.TrimSpace(auth.Attributes["proxy_url"]); proxyStr != "" {
+			proxyCandidates = append(proxyCandidates, proxyStr)
+		}
+		if proxyStr := stringValue(auth.Metadata, "proxy_url"); proxyStr != "" {
+			proxyCandidates = append(proxyCandidates, proxyStr)
+		}
 	}
 	if h != nil && h.cfg != nil {
 		if proxyStr := strings.TrimSpace(h.cfg.ProxyURL); proxyStr != "" {
@@ -0,0 +1,133 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	auditLogCapacity     = 1000
+	defaultAuditLogLimit = 100
+)
+
+// auditEntry records one security-relevant management action.
+type auditEntry struct {
+	Time       time.Time `json:"time"`
+	Actor      string    `json:"actor"`
+	Role       string    `json:"role,omitempty"`
+	Source     string    `json:"source,omitempty"`
+	ClientIP   string    `json:"client_ip"`
+	ClientCert string    `json:"client_cert,omitempty"`
+	Method     string    `json:"method,omitempty"`
+	Path       string    `json:"path,omitempty"`
+	Status     int       `json:"status,omitempty"`
+	Action     string    `json:"action"`
+	Detail     string    `json:"detail,omitempty"`
+}
+
+// auditLog is a bounded in-memory ring of audit entries.
+type auditLog struct {
+	mu      sync.Mutex
+	entries []auditEntry
+	next    int
+	full    bool
+}
+
+func (a *auditLog) add(entry auditEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.entries == nil {
+		a.entries = make([]auditEntry, auditLogCapacity)
+	}
+	a.entries[a.next] = entry
+	a.next = (a.next + 1) % len(a.entries)
+	if a.next == 0 {
+		a.full = true
+	}
+}
+
+// list returns up to limit entries, newest first.
+func (a *auditLog) list(limit int) []auditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	count := a.next
+	if a.full {
+		count = len(a.entries)
+	}
+	if limit <= 0 || limit > count {
+		limit = count
+	}
+	out := make([]auditEntry, 0, limit)
+	for i := 0; i < limit; i++ {
+		idx := (a.next - 1 - i + len(a.entries)) % len(a.entries)
+		out = append(out, a.entries[idx])
+	}
+	return out
+}
+
+// recordAudit stores entry and mirrors it to the application log.
+func (h *Handler) recordAudit(entry auditEntry) {
+	if h == nil {
+		return
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	if entry.Actor == "" {
+		entry.Actor = "anonymous"
+	}
+	h.audit.add(entry)
+	log.WithFields(log.Fields{
+		"actor":  entry.Actor,
+		"role":   entry.Role,
+		"ip":     entry.ClientIP,
+		"cert":   entry.ClientCert,
+		"method": entry.Method,
+		"path":   entry.Path,
+		"status": entry.Status,
+		"detail": entry.Detail,
+	}).Info("management audit: " + entry.Action)
+}
+
+// auditRequest records a completed management request made by principal.
+// Read-only requests are not recorded.
+func (h *Handler) auditRequest(c *gin.Context, principal managementPrincipal) {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return
+	}
+	h.recordAudit(auditEntry{
+		Actor:      principal.Name,
+		Role:       string(principal.Role),
+		Source:     principal.Source,
+		ClientIP:   h.managementClientIP(c),
+		ClientCert: principal.ClientCert,
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		Status:     c.Writer.Status(),
+		Action:     "request",
+	})
+}
+
+// GetAuditLog returns recent audit entries, newest first. Supports ?limit= and ?actor=.
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	limit := parsePositiveInt(c.Query("limit"), defaultAuditLogLimit, 1, auditLogCapacity)
+	actor := strings.TrimSpace(c.Query("actor"))
+	entries := h.audit.list(0)
+	out := make([]auditEntry, 0, limit)
+	for _, entry := range entries {
+		if actor != "" && entry.Actor != actor {
+			continue
+		}
+		out = append(out, entry)
+		if len(out) >= limit {
+			break
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": out})
+}
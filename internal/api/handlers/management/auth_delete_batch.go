@@ -0,0 +1,136 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// Outcomes of one auth of a batch deletion.
+const (
+	batchDeleteDeleted        = "deleted"
+	batchDeleteNotFound       = "not_found"
+	batchDeleteOutsideAuthDir = "skipped_outside_authdir"
+	batchDeleteError          = "error"
+)
+
+// batchDeleteResult is the outcome of deleting one auth named in a batch,
+// reported under the ID or name the request gave.
+type batchDeleteResult struct {
+	ID     string `json:"id"`
+	Name   string `json:"name,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// batchDeleteRequest is the JSON body of DELETE /auth-files naming the auths
+// to delete by ID or file name.
+type batchDeleteRequest struct {
+	IDs   *[]string `json:"ids"`
+	Names *[]string `json:"names"`
+}
+
+// readBatchDeleteRequest reads the auths named in the body of a DELETE
+// /auth-files request. ok is false when there is no body, so the query
+// parameters select the auths instead.
+func readBatchDeleteRequest(c *gin.Context) (refs []string, ok bool, err error) {
+	var req batchDeleteRequest
+	if errBind := c.ShouldBindJSON(&req); errBind != nil {
+		if errors.Is(errBind, io.EOF) {
+			return nil, false, nil
+		}
+		return nil, true, fmt.Errorf("invalid request body")
+	}
+	if req.IDs == nil && req.Names == nil {
+		return nil, true, fmt.Errorf("ids or names is required")
+	}
+	seen := make(map[string]struct{})
+	for _, list := range []*[]string{req.IDs, req.Names} {
+		if list == nil {
+			continue
+		}
+		for _, ref := range *list {
+			ref = strings.TrimSpace(ref)
+			if _, dup := seen[ref]; ref == "" || dup {
+				continue
+			}
+			seen[ref] = struct{}{}
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) == 0 {
+		return nil, true, fmt.Errorf("ids or names is empty")
+	}
+	return refs, true, nil
+}
+
+// deleteAuthFilesByRef deletes the auths named by refs, each an ID or file
+// name, and reports every one's outcome. Each file is removed and its auth
+// deregistered under authDeleteMu, so concurrent deletions never handle the
+// same file twice; a failure is reported and the rest are still deleted.
+// Files are moved to the trash unless purge is set.
+func (h *Handler) deleteAuthFilesByRef(c *gin.Context, refs []string, purge bool) {
+	ctx := c.Request.Context()
+	results := make([]batchDeleteResult, 0, len(refs))
+	counts := make(map[string]int)
+	for _, ref := range refs {
+		result := h.deleteAuthFileByRef(ctx, ref, "ids", purge)
+		counts[result.Status]++
+		results = append(results, result)
+	}
+	h.inspectionMetrics.filesDeleted(counts[batchDeleteDeleted])
+	c.JSON(http.StatusOK, gin.H{
+		"status":                  "ok",
+		"deleted":                 counts[batchDeleteDeleted],
+		"not_found":               counts[batchDeleteNotFound],
+		"skipped_outside_authdir": counts[batchDeleteOutsideAuthDir],
+		"errors":                  counts[batchDeleteError],
+		"results":                 results,
+	})
+}
+
+// deleteAuthFileByRef deletes the auth named by ref, an ID or file name, on
+// behalf of the deletion described by filter.
+func (h *Handler) deleteAuthFileByRef(ctx context.Context, ref, filter string, purge bool) batchDeleteResult {
+	h.authDeleteMu.Lock()
+	defer h.authDeleteMu.Unlock()
+	result := batchDeleteResult{ID: ref}
+	target := h.findAuthByNameOrID(ref)
+	if target == nil || h.authManager.Removed(target.ID) {
+		result.Status = batchDeleteNotFound
+		return result
+	}
+	if isRuntimeOnlyAuth(target) {
+		result.Status = batchDeleteError
+		result.Error = "runtime-only auths have no file to delete"
+		return result
+	}
+	path, ok := h.resolveAuthFilePath(target)
+	if !ok {
+		result.Status = batchDeleteOutsideAuthDir
+		return result
+	}
+	result.Name = filepath.Base(path)
+	if err := h.discardAuthFile(target, path, filter, purge); err != nil && !os.IsNotExist(err) {
+		result.Status = batchDeleteError
+		result.Error = fmt.Sprintf("failed to remove file: %v", err)
+		return result
+	}
+	if err := h.deleteTokenRecord(ctx, path); err != nil {
+		log.Warnf("auth batch delete: removed %s but not its token record: %v", path, err)
+		result.Status = batchDeleteError
+		result.Error = err.Error()
+		return result
+	}
+	h.authManager.MarkRemoved(ctx, target.ID, "removed via management API")
+	result.Status = batchDeleteDeleted
+	return result
+}
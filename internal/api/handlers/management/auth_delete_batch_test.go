@@ -0,0 +1,104 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+type batchDeleteResponse struct {
+	Deleted  int                 `json:"deleted"`
+	NotFound int                 `json:"not_found"`
+	Outside  int                 `json:"skipped_outside_authdir"`
+	Errors   int                 `json:"errors"`
+	Results  []batchDeleteResult `json:"results"`
+}
+
+func TestDeleteAuthFile_BatchByID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	paths := registerInspectionFixtures(t, manager, authDir, "codex", 6)
+	outside := filepath.Join(t.TempDir(), "outside.json")
+	if err := os.WriteFile(outside, []byte(`{"type":"codex"}`), 0o600); err != nil {
+		t.Fatalf("write outside file: %v", err)
+	}
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: "outside-id", FileName: "outside.json", Provider: "codex", Attributes: map[string]string{"path": outside}}); err != nil {
+		t.Fatalf("register outside auth: %v", err)
+	}
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager, tokenStore: store}
+	del := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodDelete, "/v0/management/auth-files", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.DeleteAuthFile(c)
+		return rec
+	}
+
+	for _, body := range []string{`{"ids":[]}`, `{"names":[" "]}`, `{}`, `{"ids":"codex-00.json"}`} {
+		if rec := del(body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d body=%s", body, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := del(`{"ids":["codex-00.json","missing.json","outside-id","codex-00.json"],"names":["codex-01.json"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp batchDeleteResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	want := map[string]string{"codex-00.json": "deleted", "missing.json": "not_found", "outside-id": "skipped_outside_authdir", "codex-01.json": "deleted"}
+	if resp.Deleted != 2 || resp.NotFound != 1 || resp.Outside != 1 || resp.Errors != 0 || len(resp.Results) != len(want) {
+		t.Fatalf("response = %s", rec.Body.String())
+	}
+	for _, result := range resp.Results {
+		if want[result.ID] != result.Status {
+			t.Errorf("%s: status %q, want %q", result.ID, result.Status, want[result.ID])
+		}
+	}
+	for i, path := range paths[:2] {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("file %d kept: %v", i, err)
+		}
+		if auth, _ := manager.GetByID(filepath.Base(path)); !manager.Removed(auth.ID) {
+			t.Fatalf("%s still registered", auth.ID)
+		}
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Fatalf("outside file removed: %v", err)
+	}
+
+	// Concurrent batches naming the same auths delete each exactly once.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	deleted := 0
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var got batchDeleteResponse
+			_ = json.Unmarshal(del(`{"ids":["codex-02.json","codex-03.json","codex-04.json","codex-05.json"]}`).Body.Bytes(), &got)
+			mu.Lock()
+			deleted += got.Deleted
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if deleted != 4 {
+		t.Fatalf("concurrent batches deleted %d, want 4", deleted)
+	}
+}
@@ -0,0 +1,74 @@
+package management
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// DisableAuthFile takes the auth named by :id out of rotation, with the
+// optional "reason" of the JSON body or query. The auth keeps its file but is
+// not routed to, inspected or deleted automatically until EnableAuthFile.
+func (h *Handler) DisableAuthFile(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		reason = strings.TrimSpace(c.Query("reason"))
+	}
+	target := h.findAuthByNameOrID(strings.TrimSpace(c.Param("id")))
+	if target == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+		return
+	}
+	auth, err := h.authManager.AdminDisable(c.Request.Context(), target.ID, reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable auth: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, adminDisablePayload(auth))
+}
+
+// EnableAuthFile returns the auth named by :id to rotation.
+func (h *Handler) EnableAuthFile(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	target := h.findAuthByNameOrID(strings.TrimSpace(c.Param("id")))
+	if target == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+		return
+	}
+	auth, _, err := h.authManager.AdminEnable(c.Request.Context(), target.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable auth: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, adminDisablePayload(auth))
+}
+
+func adminDisablePayload(auth *coreauth.Auth) gin.H {
+	disabled, at, reason := coreauth.AdminDisabledState(auth)
+	payload := gin.H{"status": "ok", "id": auth.ID, "disabled_by_admin": disabled, "unavailable": auth.Unavailable}
+	if disabled && !at.IsZero() {
+		payload["disabled_by_admin_at"] = at
+	}
+	if reason != "" {
+		payload["disabled_by_admin_reason"] = reason
+	}
+	return payload
+}
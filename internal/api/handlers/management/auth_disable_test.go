@@ -0,0 +1,75 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestDisableAuthFile_SkipsInspectionAndShowsInListing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, authDir, "codex", 3)
+	var probed []string
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		probed = append(probed, auth.ID)
+		return true, "401 revoked", nil
+	}))
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex", Concurrency: 1}}
+	cfg.AuthInspection.AutoDeleteInvalid = true
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+
+	if rec := callFreezeEndpoint(h.DisableAuthFile, "missing.json", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing auth: status %d", rec.Code)
+	}
+	rec := callFreezeEndpoint(h.DisableAuthFile, "codex-00.json", `{"reason":"shared with staging"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("disable: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp["disabled_by_admin"] != true || resp["unavailable"] != true || resp["disabled_by_admin_reason"] != "shared with staging" || resp["disabled_by_admin_at"] == nil {
+		t.Fatalf("disable response = %s", rec.Body.String())
+	}
+
+	auth, _ := manager.GetByID("codex-00.json")
+	entry := h.buildAuthFileEntry(auth)
+	if entry["disabled_by_admin"] != true || entry["disabled_by_admin_reason"] != "shared with staging" || entry["unavailable"] != true {
+		t.Fatalf("listing entry = %+v", entry)
+	}
+
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+	for _, id := range probed {
+		if id == "codex-00.json" {
+			t.Fatal("admin-disabled auth was probed")
+		}
+	}
+	if len(probed) != 2 {
+		t.Fatalf("probed = %v", probed)
+	}
+	if got := h.findAuthByNameOrID("codex-00.json"); got == nil || got.Disabled {
+		t.Fatal("admin-disabled auth was auto-deleted")
+	}
+
+	rec = callFreezeEndpoint(h.EnableAuthFile, "codex-00.json", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("enable: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	resp = nil
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp["disabled_by_admin"] != false || resp["unavailable"] != false || resp["disabled_by_admin_reason"] != nil {
+		t.Fatalf("enable response = %s", rec.Body.String())
+	}
+	if auth, _ := manager.GetByID("codex-00.json"); coreauth.IsAdminDisabled(auth) || auth.Unavailable {
+		t.Fatalf("auth still disabled after enable: %+v", auth)
+	}
+}
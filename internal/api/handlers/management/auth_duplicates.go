@@ -0,0 +1,240 @@
+package management
+
+import (
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// duplicateResolveKeepNewest keeps the most recently refreshed file of each
+// duplicate group and deletes the others.
+const duplicateResolveKeepNewest = "keep_newest"
+
+// authIdentityKeys derives, per provider, the key identifying the upstream
+// account an auth signs in as. Auths sharing a key draw on the same quota.
+// Providers without an entry are identified by their email.
+var authIdentityKeys = map[string]func(auth *coreauth.Auth) string{
+	// A ChatGPT workspace account is shared by its members, so the email
+	// tells the members apart.
+	"codex": func(auth *coreauth.Auth) string {
+		return joinIdentity(codexAccountID(auth), authEmail(auth))
+	},
+	"gemini-cli":  geminiIdentity,
+	"gemini":      geminiIdentity,
+	"antigravity": geminiIdentity,
+	"vertex": func(auth *coreauth.Auth) string {
+		email := stringValue(auth.Metadata, "client_email")
+		if email == "" {
+			email = authEmail(auth)
+		}
+		return joinIdentity(stringValue(auth.Metadata, "project_id"), email)
+	},
+	"claude": func(auth *coreauth.Auth) string {
+		if accountID := claudeMetadataValue(auth, "account_uuid", "account_id"); accountID != "" {
+			return accountID
+		}
+		return authEmail(auth)
+	},
+}
+
+func geminiIdentity(auth *coreauth.Auth) string {
+	return joinIdentity(authEmail(auth), stringValue(auth.Metadata, "project_id"))
+}
+
+// joinIdentity joins the parts of an identity key, lowercased. The key is
+// empty unless the first part is set.
+func joinIdentity(parts ...string) string {
+	if len(parts) == 0 || strings.TrimSpace(parts[0]) == "" {
+		return ""
+	}
+	for i, part := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(part))
+	}
+	return strings.Join(parts, "/")
+}
+
+// authIdentity returns the identity key of auth, or "" when its file does
+// not say which account it signs in as.
+func authIdentity(auth *coreauth.Auth) string {
+	if auth == nil {
+		return ""
+	}
+	if identity, ok := authIdentityKeys[strings.ToLower(strings.TrimSpace(auth.Provider))]; ok {
+		return identity(auth)
+	}
+	return joinIdentity(authEmail(auth))
+}
+
+// duplicateGroup is a set of auth files signing in as the same account,
+// most recently refreshed first.
+type duplicateGroup struct {
+	provider string
+	identity string
+	members  []*coreauth.Auth
+}
+
+// duplicateAuthGroups groups the file-backed auths of providers, or of every
+// provider when none is given, by identity and returns the groups of more
+// than one member, ordered by provider and identity.
+func (h *Handler) duplicateAuthGroups(providers []string) []duplicateGroup {
+	byKey := make(map[string]*duplicateGroup)
+	for _, auth := range h.authManager.List() {
+		if isRuntimeOnlyAuth(auth) || h.authManager.Removed(auth.ID) || strings.TrimSpace(authAttribute(auth, "path")) == "" {
+			continue
+		}
+		provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+		if len(providers) > 0 && !slices.Contains(providers, provider) {
+			continue
+		}
+		identity := authIdentity(auth)
+		if identity == "" {
+			continue
+		}
+		key := provider + "\x00" + identity
+		group, ok := byKey[key]
+		if !ok {
+			group = &duplicateGroup{provider: provider, identity: identity}
+			byKey[key] = group
+		}
+		group.members = append(group.members, auth)
+	}
+	groups := make([]duplicateGroup, 0)
+	for _, group := range byKey {
+		if len(group.members) < 2 {
+			continue
+		}
+		sort.Slice(group.members, func(i, j int) bool {
+			return newerAuth(group.members[i], group.members[j])
+		})
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].provider != groups[j].provider {
+			return groups[i].provider < groups[j].provider
+		}
+		return groups[i].identity < groups[j].identity
+	})
+	return groups
+}
+
+// newerAuth reports whether a was refreshed more recently than b, falling
+// back to the last update and then the ID so the order is total.
+func newerAuth(a, b *coreauth.Auth) bool {
+	if !a.LastRefreshedAt.Equal(b.LastRefreshedAt) {
+		return a.LastRefreshedAt.After(b.LastRefreshedAt)
+	}
+	if !a.UpdatedAt.Equal(b.UpdatedAt) {
+		return a.UpdatedAt.After(b.UpdatedAt)
+	}
+	return a.ID < b.ID
+}
+
+func duplicateMemberPayload(auth *coreauth.Auth) gin.H {
+	name := strings.TrimSpace(auth.FileName)
+	if name == "" {
+		name = filepath.Base(auth.ID)
+	}
+	tokenInvalid, _ := tokenInvalidState(auth)
+	member := gin.H{
+		"id":            auth.ID,
+		"name":          name,
+		"status":        auth.Status,
+		"disabled":      auth.Disabled,
+		"unavailable":   auth.Unavailable,
+		"token_invalid": tokenInvalid,
+	}
+	if at, outcome := coreauth.LastVerification(auth); !at.IsZero() {
+		member["last_verified_at"] = at
+		member["last_verified_outcome"] = outcome
+	}
+	if !auth.LastRefreshedAt.IsZero() {
+		member["last_refresh"] = auth.LastRefreshedAt
+	}
+	return member
+}
+
+func duplicateGroupPayload(group duplicateGroup) gin.H {
+	members := make([]gin.H, 0, len(group.members))
+	for _, auth := range group.members {
+		members = append(members, duplicateMemberPayload(auth))
+	}
+	return gin.H{"provider": group.provider, "identity": group.identity, "count": len(group.members), "members": members}
+}
+
+// duplicateProviders reads the provider selector of a duplicates request.
+func duplicateProviders(c *gin.Context) []string {
+	providers := queryList(c.QueryArray("provider"))
+	for i, provider := range providers {
+		if alias, ok := inspectionProviderAliases[provider]; ok {
+			providers[i] = alias
+		}
+	}
+	return providers
+}
+
+// ListDuplicateAuthFiles lists the groups of auth files signing in as the same
+// account, optionally of the given providers. Members are ordered most
+// recently refreshed first.
+func (h *Handler) ListDuplicateAuthFiles(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	groups := h.duplicateAuthGroups(duplicateProviders(c))
+	payload := make([]gin.H, 0, len(groups))
+	files := 0
+	for _, group := range groups {
+		payload = append(payload, duplicateGroupPayload(group))
+		files += len(group.members)
+	}
+	c.JSON(http.StatusOK, gin.H{"groups": payload, "total_groups": len(groups), "total_files": files})
+}
+
+// ResolveDuplicateAuthFiles applies the resolve action to every duplicate
+// group, optionally of the given providers. keep_newest keeps each group's
+// most recently refreshed file and deletes the others as a batch deletion by
+// ID does, to the trash unless purge is set.
+func (h *Handler) ResolveDuplicateAuthFiles(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	if resolve := strings.ToLower(strings.TrimSpace(c.Query("resolve"))); resolve != duplicateResolveKeepNewest {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "resolve must be " + duplicateResolveKeepNewest})
+		return
+	}
+	ctx := c.Request.Context()
+	purge := queryTruthy(c.Query("purge"))
+	h.purgeTrash()
+	groups := h.duplicateAuthGroups(duplicateProviders(c))
+	payload := make([]gin.H, 0, len(groups))
+	counts := make(map[string]int)
+	for _, group := range groups {
+		kept := group.members[0]
+		results := make([]batchDeleteResult, 0, len(group.members)-1)
+		for _, auth := range group.members[1:] {
+			result := h.deleteAuthFileByRef(ctx, auth.ID, "duplicates "+duplicateResolveKeepNewest, purge)
+			counts[result.Status]++
+			results = append(results, result)
+		}
+		entry := duplicateGroupPayload(group)
+		entry["kept"] = duplicateMemberPayload(kept)
+		entry["results"] = results
+		payload = append(payload, entry)
+	}
+	h.inspectionMetrics.filesDeleted(counts[batchDeleteDeleted])
+	c.JSON(http.StatusOK, gin.H{
+		"status":                  "ok",
+		"resolve":                 duplicateResolveKeepNewest,
+		"groups":                  payload,
+		"deleted":                 counts[batchDeleteDeleted],
+		"not_found":               counts[batchDeleteNotFound],
+		"skipped_outside_authdir": counts[batchDeleteOutsideAuthDir],
+		"errors":                  counts[batchDeleteError],
+	})
+}
@@ -0,0 +1,106 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestDuplicateAuthFiles_ListAndKeepNewest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	codexPaths := registerInspectionFixtures(t, manager, authDir, "codex", 4)
+	geminiPaths := registerInspectionFixtures(t, manager, authDir, "gemini-cli", 2)
+	now := time.Now()
+	identify := func(id string, metadata map[string]any, refreshed time.Time) {
+		t.Helper()
+		if _, err := manager.Edit(context.Background(), id, func(auth *coreauth.Auth) error {
+			auth.Metadata = metadata
+			auth.LastRefreshedAt = refreshed
+			return nil
+		}); err != nil {
+			t.Fatalf("edit %s: %v", id, err)
+		}
+	}
+	// codex-00..02 sign in as one account, codex-01 refreshed last; codex-03
+	// is another member of the same workspace.
+	identify("codex-00.json", map[string]any{"account_id": "acct-1", "email": "a@example.com"}, now.Add(-2*time.Hour))
+	identify("codex-01.json", map[string]any{"chatgpt_account_id": "acct-1", "email": "A@example.com"}, now.Add(-time.Hour))
+	identify("codex-02.json", map[string]any{"account_id": "acct-1", "email": "a@example.com"}, now.Add(-3*time.Hour))
+	identify("codex-03.json", map[string]any{"account_id": "acct-1", "email": "b@example.com"}, now)
+	identify("gemini-cli-00.json", map[string]any{"email": "g@example.com", "project_id": "p1"}, now)
+	identify("gemini-cli-01.json", map[string]any{"email": "g@example.com", "project_id": "p2"}, now)
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager, tokenStore: store}
+	call := func(handler gin.HandlerFunc, method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(method, target, nil)
+		handler(c)
+		return rec
+	}
+
+	rec := call(h.ListDuplicateAuthFiles, http.MethodGet, "/v0/management/auth-files/duplicates")
+	var listed struct {
+		Groups []struct {
+			Provider string `json:"provider"`
+			Identity string `json:"identity"`
+			Members  []struct {
+				ID string `json:"id"`
+			} `json:"members"`
+		} `json:"groups"`
+		TotalFiles int `json:"total_files"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("list: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if len(listed.Groups) != 1 || listed.Groups[0].Identity != "acct-1/a@example.com" || listed.TotalFiles != 3 {
+		t.Fatalf("groups = %s", rec.Body.String())
+	}
+	var order []string
+	for _, member := range listed.Groups[0].Members {
+		order = append(order, member.ID)
+	}
+	if strings.Join(order, ",") != "codex-01.json,codex-00.json,codex-02.json" {
+		t.Fatalf("members ordered %v", order)
+	}
+	if rec = call(h.ListDuplicateAuthFiles, http.MethodGet, "/v0/management/auth-files/duplicates?provider=gemini-cli"); !strings.Contains(rec.Body.String(), `"total_groups":0`) {
+		t.Fatalf("provider filter: %s", rec.Body.String())
+	}
+
+	if rec = call(h.ResolveDuplicateAuthFiles, http.MethodPost, "/v0/management/auth-files/duplicates?resolve=keep_oldest"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown resolve: status %d", rec.Code)
+	}
+	rec = call(h.ResolveDuplicateAuthFiles, http.MethodPost, "/v0/management/auth-files/duplicates?resolve=keep_newest")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"deleted":2`) {
+		t.Fatalf("resolve: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	for i, path := range codexPaths {
+		_, err := os.Stat(path)
+		if removed := i == 0 || i == 2; removed != os.IsNotExist(err) {
+			t.Fatalf("%s: removed %v, stat err %v", filepath.Base(path), removed, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(authDir, authTrashDirName, "codex-02.json")); err != nil {
+		t.Fatalf("duplicate not trashed: %v", err)
+	}
+	for _, path := range geminiPaths {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("distinct project removed: %v", err)
+		}
+	}
+	if rec = call(h.ListDuplicateAuthFiles, http.MethodGet, "/v0/management/auth-files/duplicates"); !strings.Contains(rec.Body.String(), `"total_groups":0`) {
+		t.Fatalf("after resolve: %s", rec.Body.String())
+	}
+}
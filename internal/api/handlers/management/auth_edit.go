@@ -0,0 +1,205 @@
+package management
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// editableAuthKeys are the auth file fields PATCH /auth-files/:id may change.
+var editableAuthKeys = []string{"label", "notes", "priority", "proxy_url", "tags"}
+
+// protectedAuthKeys are fields named in the rejection of an edit so callers
+// learn they are credentials or inspection state rather than unknown.
+var protectedAuthKeys = []string{"access_token", "refresh_token", "id_token", "type", "disabled"}
+
+// authFieldError is a rejected field of an auth file edit.
+type authFieldError struct {
+	key string
+	msg string
+}
+
+func (e *authFieldError) Error() string { return e.key + " " + e.msg }
+
+// PatchAuthFile edits the metadata of the auth named by :id. The body is a
+// JSON object of editableAuthKeys; null or an empty value removes a field.
+// The change is applied through the auth manager, which writes the merged
+// record to the token store, and the updated entry is returned with its
+// tokens redacted.
+func (h *Handler) PatchAuthFile(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	var changes map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&changes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if len(changes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
+		return
+	}
+	keys := make([]string, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if slices.Contains(editableAuthKeys, key) {
+			continue
+		}
+		if slices.Contains(protectedAuthKeys, key) || strings.HasPrefix(key, "token_invalid") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": key + " is protected and cannot be edited"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s is not editable; editable fields are %s", key, strings.Join(editableAuthKeys, ", "))})
+		return
+	}
+
+	target := h.findAuthByNameOrID(strings.TrimSpace(c.Param("id")))
+	if target == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+		return
+	}
+	if isRuntimeOnlyAuth(target) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "runtime-only auths have no file to edit"})
+		return
+	}
+	auth, err := h.authManager.Edit(c.Request.Context(), target.ID, func(auth *coreauth.Auth) error {
+		return applyAuthEdit(auth, keys, changes)
+	})
+	var fieldErr *authFieldError
+	var managerErr *coreauth.Error
+	switch {
+	case errors.As(err, &fieldErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": fieldErr.Error()})
+		return
+	case errors.As(err, &managerErr) && managerErr.Code == "auth_not_found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save auth file: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, h.authRecordPayload(auth))
+}
+
+// authRecordPayload is the list entry of auth with its metadata, credentials
+// stripped, as returned by the endpoints that change an auth file.
+func (h *Handler) authRecordPayload(auth *coreauth.Auth) gin.H {
+	entry := h.buildAuthFileEntry(auth)
+	if entry == nil {
+		entry = gin.H{"id": auth.ID}
+	}
+	// Nested values are shared with the manager's copy, so redact a deep one.
+	metadata := make(map[string]any, len(auth.Metadata))
+	if raw, err := json.Marshal(auth.Metadata); err == nil {
+		_ = json.Unmarshal(raw, &metadata)
+	}
+	util.StripAuthCredentials(metadata)
+	entry["metadata"] = metadata
+	return entry
+}
+
+// applyAuthEdit validates every change before applying any, so a rejected
+// field leaves auth untouched. The fields mirror how the file synthesizer
+// reads them back: label, proxy_url and priority also set the Auth fields
+// used at runtime.
+func applyAuthEdit(auth *coreauth.Auth, keys []string, changes map[string]json.RawMessage) error {
+	values := make(map[string]any, len(keys))
+	for _, key := range keys {
+		value, err := parseAuthEditValue(key, changes[key])
+		if err != nil {
+			return &authFieldError{key: key, msg: err.Error()}
+		}
+		values[key] = value
+	}
+	if auth.Metadata == nil {
+		auth.Metadata = make(map[string]any)
+	}
+	if auth.Attributes == nil {
+		auth.Attributes = make(map[string]string)
+	}
+	for _, key := range keys {
+		value := values[key]
+		if value == nil {
+			delete(auth.Metadata, key)
+		} else {
+			auth.Metadata[key] = value
+		}
+		switch key {
+		case "label":
+			label, _ := value.(string)
+			if label == "" {
+				label = auth.Provider
+				if email, _ := auth.Metadata["email"].(string); email != "" {
+					label = email
+				}
+			}
+			auth.Label = label
+		case "proxy_url":
+			auth.ProxyURL, _ = value.(string)
+		case "priority":
+			if priority, ok := value.(int); ok {
+				auth.Attributes["priority"] = strconv.Itoa(priority)
+			} else {
+				delete(auth.Attributes, "priority")
+			}
+		}
+	}
+	return nil
+}
+
+// parseAuthEditValue decodes the new value of key. It returns nil for a
+// field to remove.
+func parseAuthEditValue(key string, raw json.RawMessage) (any, error) {
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	switch key {
+	case "priority":
+		var priority int
+		if err := json.Unmarshal(raw, &priority); err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return priority, nil
+	case "tags":
+		var tags []string
+		if err := json.Unmarshal(raw, &tags); err != nil {
+			return nil, fmt.Errorf("must be an array of strings")
+		}
+		out, err := normalizeAuthTags(tags)
+		if err != nil {
+			return nil, fmt.Errorf("are invalid: %v", err)
+		}
+		if len(out) == 0 {
+			return nil, nil
+		}
+		return out, nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return nil, fmt.Errorf("must be a string")
+	}
+	if text = strings.TrimSpace(text); text == "" {
+		return nil, nil
+	}
+	if key == "proxy_url" {
+		parsed, err := url.Parse(text)
+		if err != nil || parsed.Host == "" || !slices.Contains([]string{"http", "https", "socks5"}, parsed.Scheme) {
+			return nil, fmt.Errorf("must be an http, https or socks5 URL")
+		}
+	}
+	return text, nil
+}
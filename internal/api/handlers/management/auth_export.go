@@ -0,0 +1,162 @@
+package management
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// ExportAuthFiles streams a ZIP of the auth files in AuthDir. The provider,
+// status and ids query parameters, each a comma-separated list, select the
+// auths through the auth manager; files whose path lies outside AuthDir are
+// left out, as they are by deletion. With redact=true the credential fields
+// are stripped from every file. Files are written to the archive one at a time,
+// so the response is never held in memory.
+func (h *Handler) ExportAuthFiles(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	providers := queryList(c.QueryArray("provider"))
+	for i, provider := range providers {
+		if alias, ok := inspectionProviderAliases[provider]; ok {
+			providers[i] = alias
+		}
+	}
+	statuses := queryList(c.QueryArray("status"))
+	ids := make(map[string]struct{})
+	for _, id := range c.QueryArray("ids") {
+		for _, part := range strings.Split(id, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				ids[part] = struct{}{}
+			}
+		}
+	}
+	redact, _ := strconv.ParseBool(c.Query("redact"))
+
+	auths := h.authManager.List()
+	sort.Slice(auths, func(i, j int) bool { return auths[i].ID < auths[j].ID })
+	paths := make([]string, 0, len(auths))
+	seen := make(map[string]struct{}, len(auths))
+	for _, auth := range auths {
+		if isRuntimeOnlyAuth(auth) {
+			continue
+		}
+		if len(providers) > 0 && !slices.Contains(providers, strings.ToLower(strings.TrimSpace(auth.Provider))) {
+			continue
+		}
+		if len(statuses) > 0 && !slices.Contains(statuses, strings.ToLower(string(auth.Status))) {
+			continue
+		}
+		if _, ok := ids[auth.ID]; len(ids) > 0 && !ok {
+			continue
+		}
+		path, ok := h.resolveAuthFilePath(auth)
+		if !ok {
+			continue
+		}
+		if _, dup := seen[path]; dup {
+			continue
+		}
+		seen[path] = struct{}{}
+		paths = append(paths, path)
+	}
+
+	filename := "auth-files-" + time.Now().UTC().Format("20060102T150405Z") + ".zip"
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Status(http.StatusOK)
+	archive := zip.NewWriter(c.Writer)
+	exported := 0
+	for _, path := range paths {
+		started, err := h.exportAuthFile(archive, path, redact)
+		if err != nil && started {
+			// The archive is broken past this point, usually because the
+			// client went away.
+			log.Warnf("auth export: aborted at %s: %v", path, err)
+			return
+		}
+		if err != nil {
+			log.Warnf("auth export: skipped %s: %v", path, err)
+			continue
+		}
+		exported++
+	}
+	if err := archive.Close(); err != nil {
+		log.Warnf("auth export: failed to finish archive: %v", err)
+		return
+	}
+	log.Infof("auth export: exported %d auth files (redact=%v)", exported, redact)
+}
+
+// exportAuthFile adds the file at path to archive under its path relative
+// to AuthDir. A file that no longer exists or, with redact, is not a JSON
+// object fails before anything is written for it; started reports whether
+// the error came later, leaving the archive unusable.
+func (h *Handler) exportAuthFile(archive *zip.Writer, path string, redact bool) (started bool, err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = src.Close() }()
+	info, err := src.Stat()
+	if err != nil {
+		return false, err
+	}
+	var body io.Reader = src
+	if redact {
+		var metadata map[string]any
+		if err = json.NewDecoder(src).Decode(&metadata); err != nil {
+			return false, fmt.Errorf("invalid auth file: %w", err)
+		}
+		util.StripAuthCredentials(metadata)
+		data, errMarshal := json.MarshalIndent(metadata, "", "  ")
+		if errMarshal != nil {
+			return false, errMarshal
+		}
+		body = strings.NewReader(string(data))
+	}
+	name := filepath.Base(path)
+	if rel, errRel := filepath.Rel(h.cfg.AuthDir, path); errRel == nil && !strings.HasPrefix(rel, "..") {
+		name = rel
+	} else if absDir, errAbs := filepath.Abs(h.cfg.AuthDir); errAbs == nil {
+		if rel, errRel = filepath.Rel(absDir, path); errRel == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+	}
+	header := &zip.FileHeader{Name: filepath.ToSlash(name), Method: zip.Deflate, Modified: info.ModTime()}
+	header.SetMode(0o600)
+	dst, err := archive.CreateHeader(header)
+	if err != nil {
+		return true, err
+	}
+	_, err = io.Copy(dst, body)
+	return true, err
+}
+
+// queryList splits comma-separated query values into trimmed, lowercased
+// items, dropping empty ones.
+func queryList(values []string) []string {
+	var out []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
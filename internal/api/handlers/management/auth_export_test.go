@@ -0,0 +1,97 @@
+package management
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestExportAuthFiles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, authDir, "codex", 2)
+	registerInspectionFixtures(t, manager, authDir, "claude", 1)
+	disabled, _ := manager.GetByID("codex-01.json")
+	disabled.Status = coreauth.StatusDisabled
+	if _, err := manager.Update(context.Background(), disabled); err != nil {
+		t.Fatalf("update auth: %v", err)
+	}
+	secret := `{"type":"codex","email":"a@example.com","access_token":"at","refresh_token":"rt","token":{"access_token":"nested"}}`
+	if err := os.WriteFile(filepath.Join(authDir, "codex-00.json"), []byte(secret), 0o600); err != nil {
+		t.Fatalf("write auth file: %v", err)
+	}
+	outside := filepath.Join(t.TempDir(), "outside.json")
+	if err := os.WriteFile(outside, []byte(`{"type":"codex"}`), 0o600); err != nil {
+		t.Fatalf("write outside file: %v", err)
+	}
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: "outside.json", Provider: "codex", Status: coreauth.StatusActive, Attributes: map[string]string{"path": outside}}); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager}
+	export := func(query string) (*httptest.ResponseRecorder, map[string]string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-files/export?"+query, nil)
+		h.ExportAuthFiles(c)
+		archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+		if err != nil {
+			t.Fatalf("%s: read zip: %v (status %d)", query, err, rec.Code)
+		}
+		files := make(map[string]string, len(archive.File))
+		for _, entry := range archive.File {
+			rc, _ := entry.Open()
+			data, _ := io.ReadAll(rc)
+			_ = rc.Close()
+			files[entry.Name] = string(data)
+		}
+		return rec, files
+	}
+	names := func(files map[string]string) string {
+		out := make([]string, 0, len(files))
+		for name := range files {
+			out = append(out, name)
+		}
+		sort.Strings(out)
+		return strings.Join(out, ",")
+	}
+
+	rec, files := export("")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("status %d content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if disposition := rec.Header().Get("Content-Disposition"); !regexp.MustCompile(`^attachment; filename="auth-files-\d{8}T\d{6}Z\.zip"$`).MatchString(disposition) {
+		t.Fatalf("Content-Disposition = %q", disposition)
+	}
+	if got := names(files); got != "claude-00.json,codex-00.json,codex-01.json" {
+		t.Fatalf("exported %s", got)
+	}
+	if files["codex-00.json"] != secret {
+		t.Fatalf("unredacted export changed the file: %s", files["codex-00.json"])
+	}
+
+	if _, files = export("provider=codex&status=active"); names(files) != "codex-00.json" {
+		t.Fatalf("provider and status filter exported %s", names(files))
+	}
+	if _, files = export("ids=codex-01.json,claude-00.json"); names(files) != "claude-00.json,codex-01.json" {
+		t.Fatalf("ids filter exported %s", names(files))
+	}
+	_, files = export("ids=codex-00.json&redact=true")
+	if redacted := files["codex-00.json"]; strings.Contains(redacted, "access_token") || strings.Contains(redacted, "refresh_token") || !strings.Contains(redacted, "a@example.com") {
+		t.Fatalf("redacted export = %s", redacted)
+	}
+}
@@ -11,9 +11,11 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
-	"sort"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -51,13 +53,26 @@ const (
 	geminiCLIApiClient       = "gl-node/22.17.0"
 	geminiCLIClientMetadata  = "ideType=IDE_UNSPECIFIED,platform=PLATFORM_UNSPECIFIED,pluginType=GEMINI"
 	codexUsageProbeUserAgent = "codex_cli_rs/0.76.0 (Debian 13.0.0; x86_64) WindowsTerminal"
-	tokenInvalidMetaKey      = "token_invalid"
-	tokenInvalidReasonKey    = "token_invalid_reason"
-	tokenInvalidAtKey        = "token_invalid_at"
+	tokenInvalidMetaKey      = coreauth.MetadataTokenInvalid
+	tokenInvalidReasonKey    = coreauth.MetadataTokenInvalidReason
+	tokenInvalidAtKey        = coreauth.MetadataTokenInvalidAt
 )
 
+// codexUsageProbeURL is the usage endpoint the codex probe checks unless the
+// verification config names another.
 var codexUsageProbeURL = "https://chatgpt.com/backend-api/wham/usage"
 
+// codexModelsProbeURL is the models endpoint the codex probe falls back to
+// while the usage endpoint is unavailable.
+var codexModelsProbeURL = "https://chatgpt.com/backend-api/codex/models"
+
+// codexUsageProbeRetries is the number of times a usage or code assist probe
+// failing with a network error, timeout or 5xx is retried, waiting codexUsageProbeBackoff
+// before the first retry and twice as long before each further one.
+const codexUsageProbeRetries = 3
+
+var codexUsageProbeBackoff = 500 * time.Millisecond
+
 type callbackForwarder struct {
 	provider string
 	server   *http.Server
@@ -253,6 +268,12 @@ func (h *Handler) managementCallbackURL(path string) (string, error) {
 	return fmt.Sprintf("%s://127.0.0.1:%d%s", scheme, h.cfg.Port, path), nil
 }
 
+// ListAuthFiles lists the auth files. provider, status, tag, unavailable,
+// invalid, name_like and account_like filter the list, and q searches the identity
+// fields of authSearchFields, reporting the one that matched as
+// matched_field. sort orders the list by name, provider, status,
+// last_verified_at, created or latency (slowest first), reversed with order. With limit the list is paged: next_cursor, passed as
+// cursor, fetches the following page. total counts every matching file.
 func (h *Handler) ListAuthFiles(c *gin.Context) {
 	if h == nil {
 		c.JSON(500, gin.H{"error": "handler not initialized"})
@@ -262,19 +283,33 @@ func (h *Handler) ListAuthFiles(c *gin.Context) {
 		h.listAuthFilesFromDisk(c)
 		return
 	}
+	query, err := parseAuthFilesQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	auths := h.authManager.List()
-	files := make([]gin.H, 0, len(auths))
+	rows := make([]authFileRow, 0, len(auths))
 	for _, auth := range auths {
-		if entry := h.buildAuthFileEntry(auth); entry != nil {
-			files = append(files, entry)
+		entry := h.buildAuthFileEntry(auth)
+		if entry == nil || !query.matches(entry) {
+			continue
+		}
+		if query.search != "" {
+			field, ok := matchAuthIdentity(auth, query.search)
+			if !ok {
+				continue
+			}
+			entry["matched_field"] = field
 		}
+		rows = append(rows, query.row(auth, entry))
 	}
-	sort.Slice(files, func(i, j int) bool {
-		nameI, _ := files[i]["name"].(string)
-		nameJ, _ := files[j]["name"].(string)
-		return strings.ToLower(nameI) < strings.ToLower(nameJ)
-	})
-	c.JSON(200, gin.H{"files": files})
+	files, next := query.page(rows)
+	resp := gin.H{"files": files, "total": len(rows), "filters": query.filters()}
+	if next != "" {
+		resp["next_cursor"] = next
+	}
+	c.JSON(200, resp)
 }
 
 // GetAuthFileModels returns the models supported by a specific auth file
@@ -325,6 +360,20 @@ func (h *Handler) GetAuthFileModels(c *gin.Context) {
 	c.JSON(200, gin.H{"models": result})
 }
 
+// authEntryMatchesAccount reports whether the file name, label, email or plan
+// of entry contains needle, which must already be lower case.
+func authEntryMatchesAccount(entry gin.H, needle string) bool {
+	if needle == "" {
+		return true
+	}
+	for _, key := range []string{"name", "label", "email", "account", "account_email", "account_plan"} {
+		if v, ok := entry[key].(string); ok && strings.Contains(strings.ToLower(v), needle) {
+			return true
+		}
+	}
+	return false
+}
+
 // List auth files from disk when the auth manager is unavailable.
 func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 	entries, err := os.ReadDir(h.cfg.AuthDir)
@@ -332,6 +381,7 @@ func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 		c.JSON(500, gin.H{"error": fmt.Sprintf("failed to read auth dir: %v", err)})
 		return
 	}
+	accountLike := strings.ToLower(strings.TrimSpace(c.Query("account_like")))
 	files := make([]gin.H, 0)
 	for _, e := range entries {
 		if e.IsDir() {
@@ -353,7 +403,9 @@ func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 				fileData["email"] = emailValue
 			}
 
-			files = append(files, fileData)
+			if authEntryMatchesAccount(fileData, accountLike) {
+				files = append(files, fileData)
+			}
 		}
 	}
 	c.JSON(200, gin.H{"files": files})
@@ -391,6 +443,25 @@ func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
 		"source":         "memory",
 		"size":           int64(0),
 	}
+	frozen, frozenUntil := coreauth.FrozenState(auth, time.Now())
+	entry["frozen"] = frozen
+	if frozen {
+		entry["status"] = "frozen"
+		if !frozenUntil.IsZero() {
+			entry["frozen_until"] = frozenUntil
+		}
+	}
+	adminDisabled, disabledAt, disabledReason := coreauth.AdminDisabledState(auth)
+	entry["disabled_by_admin"] = adminDisabled
+	if adminDisabled {
+		if !disabledAt.IsZero() {
+			entry["disabled_by_admin_at"] = disabledAt
+		}
+		if disabledReason != "" {
+			entry["disabled_by_admin_reason"] = disabledReason
+		}
+	}
+	entry["tags"] = tagsOrEmpty(authTags(auth))
 	tokenInvalid, tokenInvalidReason := tokenInvalidState(auth)
 	entry["token_invalid"] = tokenInvalid
 	if tokenInvalidReason != "" {
@@ -399,6 +470,19 @@ func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
 	if email := authEmail(auth); email != "" {
 		entry["email"] = email
 	}
+	if v, ok := auth.Metadata[coreauth.MetadataAccountEmail].(string); ok && v != "" {
+		entry["account_email"] = v
+	}
+	if v, ok := auth.Metadata[coreauth.MetadataAccountPlan].(string); ok && v != "" {
+		entry["account_plan"] = v
+	}
+	if v, ok := auth.Metadata[codexUsageMetaKey].(map[string]any); ok && len(v) > 0 {
+		entry["usage"] = v
+	}
+	if last, avg, ok := coreauth.ProbeLatency(auth); ok {
+		entry["last_probe_latency_ms"] = last.Milliseconds()
+		entry["probe_latency_avg_ms"] = avg.Milliseconds()
+	}
 	if accountType, account := auth.AccountInfo(); accountType != "" || account != "" {
 		if accountType != "" {
 			entry["account_type"] = accountType
@@ -538,85 +622,206 @@ func (h *Handler) DownloadAuthFile(c *gin.Context) {
 	c.Data(200, "application/json", data)
 }
 
-// Upload auth file: multipart or raw JSON with ?name=
+// Upload auth file: multipart or raw JSON with ?name=. An auth that is
+// already registered is rejected with 409 unless overwrite=true. The stored
+// auth is then probed in the background, without delaying the response,
+// unless verify=false or skip-verify-on-upload is set. tags, when given,
+// replace the tags the file carries. Multipart uploads of several files or
+// zip archives are stored file by file, see uploadAuthFiles. A file missing
+// the fields its type needs, see authSchemaValidators, is rejected with 422
+// unless force=true, which stores it with the auth in error and unverified.
 func (h *Handler) UploadAuthFile(c *gin.Context) {
 	if h.authManager == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
 		return
 	}
-	ctx := c.Request.Context()
+	form, errForm := c.MultipartForm()
+	tags, errTags := uploadTags(c)
+	if errTags != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTags.Error()})
+		return
+	}
+	if errForm == nil && isBulkUpload(form) {
+		h.uploadAuthFiles(c, form, tags)
+		return
+	}
+	var (
+		name string
+		data []byte
+	)
 	if file, err := c.FormFile("file"); err == nil && file != nil {
-		name := filepath.Base(file.Filename)
+		name = filepath.Base(file.Filename)
 		if !strings.HasSuffix(strings.ToLower(name), ".json") {
 			c.JSON(400, gin.H{"error": "file must be .json"})
 			return
 		}
-		dst := filepath.Join(h.cfg.AuthDir, name)
-		if !filepath.IsAbs(dst) {
-			if abs, errAbs := filepath.Abs(dst); errAbs == nil {
-				dst = abs
-			}
+		src, errOpen := file.Open()
+		if errOpen != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to open uploaded file: %v", errOpen)})
+			return
+		}
+		data, err = io.ReadAll(src)
+		_ = src.Close()
+		if err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to read uploaded file: %v", err)})
+			return
 		}
-		if errSave := c.SaveUploadedFile(file, dst); errSave != nil {
-			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to save file: %v", errSave)})
+	} else {
+		name = c.Query("name")
+		if name == "" || strings.Contains(name, string(os.PathSeparator)) {
+			c.JSON(400, gin.H{"error": "invalid name"})
 			return
 		}
-		data, errRead := os.ReadFile(dst)
-		if errRead != nil {
-			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to read saved file: %v", errRead)})
+		if !strings.HasSuffix(strings.ToLower(name), ".json") {
+			c.JSON(400, gin.H{"error": "name must end with .json"})
 			return
 		}
-		if errReg := h.registerAuthFromFile(ctx, dst, data); errReg != nil {
-			c.JSON(500, gin.H{"error": errReg.Error()})
+		data, err = io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "failed to read body"})
 			return
 		}
-		c.JSON(200, gin.H{"status": "ok"})
+	}
+	// A single file is checked as each file of a bulk upload is.
+	name, errName := sanitizeUploadName(name)
+	if errName != nil {
+		c.JSON(400, gin.H{"error": errName.Error()})
 		return
 	}
-	name := c.Query("name")
-	if name == "" || strings.Contains(name, string(os.PathSeparator)) {
-		c.JSON(400, gin.H{"error": "invalid name"})
+	if errValidate := validateUploadedAuth(data); errValidate != nil {
+		c.JSON(400, gin.H{"error": errValidate.Error()})
 		return
 	}
-	if !strings.HasSuffix(strings.ToLower(name), ".json") {
-		c.JSON(400, gin.H{"error": "name must end with .json"})
+	var schemaErr *authSchemaError
+	if _, errSchema := validateAuthSchema(data); errSchema != nil && !errors.As(errSchema, &schemaErr) {
+		c.JSON(400, gin.H{"error": errSchema.Error()})
 		return
 	}
-	data, err := io.ReadAll(c.Request.Body)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "failed to read body"})
+	if schemaErr != nil && !queryTruthy(c.Query("force")) {
+		c.JSON(http.StatusUnprocessableEntity, schemaErrorPayload(schemaErr))
 		return
 	}
-	dst := filepath.Join(h.cfg.AuthDir, filepath.Base(name))
+	dst := filepath.Join(h.cfg.AuthDir, name)
 	if !filepath.IsAbs(dst) {
 		if abs, errAbs := filepath.Abs(dst); errAbs == nil {
 			dst = abs
 		}
 	}
-	if errWrite := os.WriteFile(dst, data, 0o600); errWrite != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("failed to write file: %v", errWrite)})
+	data, errTag := tagAuthData(data, tags)
+	if errTag != nil {
+		c.JSON(400, gin.H{"error": errTag.Error()})
+		return
+	}
+	overwrite, _ := strconv.ParseBool(c.Query("overwrite"))
+	if !overwrite && h.uploadTargetExists(dst) {
+		c.JSON(http.StatusConflict, gin.H{"error": "auth file already exists; set overwrite=true to replace it"})
 		return
 	}
-	if err = h.registerAuthFromFile(ctx, dst, data); err != nil {
+	auth, err := h.saveUploadedAuthFile(c.Request.Context(), dst, data, overwrite, schemaErr)
+	if err != nil {
+		var conflict *coreauth.ErrAlreadyRegistered
+		if errors.As(err, &conflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": "auth file already registered; set overwrite=true to replace it", "id": conflict.Existing.ID})
+			return
+		}
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	if schemaErr != nil {
+		c.JSON(200, gin.H{"status": "ok", "auth_status": auth.Status, "validation_error": schemaErr.Error(), "fields": schemaErr.Fields})
+		return
+	}
+	verify := !h.effectiveAuthInspectionConfig().SkipVerifyOnUpload
+	if v, errParse := strconv.ParseBool(c.Query("verify")); errParse == nil && !v {
+		verify = false
+	}
+	if verify && h.verifyUploadedAuth(auth.ID) {
+		c.JSON(200, gin.H{"status": "ok", "verification": "queued"})
+		return
+	}
 	c.JSON(200, gin.H{"status": "ok"})
 }
 
-// Delete auth files: single by name or all
+// saveUploadedAuthFile writes an uploaded auth file and registers it,
+// returning the registered auth. Without overwrite the auth is registered
+// first, so a conflicting upload leaves the existing file untouched. invalid,
+// set when a file failing validation is forced in, puts the auth in error.
+func (h *Handler) saveUploadedAuthFile(ctx context.Context, dst string, data []byte, overwrite bool, invalid *authSchemaError) (*coreauth.Auth, error) {
+	auth, err := h.authFromFile(dst, data)
+	if err != nil {
+		return nil, err
+	}
+	if invalid != nil {
+		auth.Status = coreauth.StatusError
+		auth.StatusMessage = invalid.Error()
+	}
+	if overwrite {
+		if errWrite := os.WriteFile(dst, data, 0o600); errWrite != nil {
+			return nil, fmt.Errorf("failed to write file: %w", errWrite)
+		}
+		return h.authManager.RegisterOrUpdate(ctx, auth)
+	}
+	registered, err := h.authManager.Register(ctx, auth)
+	if err != nil {
+		return nil, err
+	}
+	if errWrite := os.WriteFile(dst, data, 0o600); errWrite != nil {
+		h.authManager.MarkRemoved(ctx, auth.ID, "upload failed")
+		return nil, fmt.Errorf("failed to write file: %w", errWrite)
+	}
+	return registered, nil
+}
+
+// Delete auth files: single by name or all. provider and tag narrow the bulk
+// deletions, invalid, failed and all, to the auths of one of the providers
+// that carry one of the tags. Without provider, the invalid and failed
+// deletions report their counts per provider. A JSON body of "ids" or
+// "names" deletes those auths instead, see deleteAuthFilesByRef. Deleted
+// files are moved to the trash unless purge is set, see discardAuthFile.
 func (h *Handler) DeleteAuthFile(c *gin.Context) {
 	if h.authManager == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
 		return
 	}
+	refs, batch, errBatch := readBatchDeleteRequest(c)
+	if errBatch != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errBatch.Error()})
+		return
+	}
+	purge := queryTruthy(c.Query("purge"))
+	h.purgeTrash()
+	if batch {
+		h.deleteAuthFilesByRef(c, refs, purge)
+		return
+	}
 	ctx := c.Request.Context()
+	tags, errTags := queryTags(c)
+	if errTags != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTags.Error()})
+		return
+	}
+	providers := queryList(c.QueryArray("provider"))
+	for i, provider := range providers {
+		if alias, ok := inspectionProviderAliases[provider]; ok {
+			providers[i] = alias
+		}
+	}
 	if queryTruthy(c.Query("invalid")) {
-		h.deleteInvalidAuthFiles(c, ctx)
+		h.deleteInvalidAuthFiles(c, ctx, providers, tags, purge)
 		return
 	}
 	if queryTruthy(c.Query("failed")) {
-		h.deleteFailedAuthFiles(c, ctx)
+		h.deleteMatchingAuthFiles(c, ctx, "failed", providers, tags, purge, h.authFailed)
+		return
+	}
+	if queryTruthy(c.Query("all")) && (len(providers) > 0 || len(tags) > 0) {
+		h.deleteMatchingAuthFiles(c, ctx, "all", providers, tags, purge, func(auth *coreauth.Auth) bool {
+			return !isRuntimeOnlyAuth(auth) && !h.authManager.Removed(auth.ID)
+		})
+		return
+	}
+	if len(providers) > 0 || len(tags) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provider and tag select auths to delete in bulk; combine them with invalid, failed or all"})
 		return
 	}
 	if queryTruthy(c.Query("all")) {
@@ -640,7 +845,7 @@ func (h *Handler) DeleteAuthFile(c *gin.Context) {
 					full = abs
 				}
 			}
-			if err = os.Remove(full); err == nil {
+			if err = h.discardAuthFile(nil, full, "all", purge); err == nil {
 				if errDel := h.deleteTokenRecord(ctx, full); errDel != nil {
 					c.JSON(500, gin.H{"error": errDel.Error()})
 					return
@@ -663,7 +868,7 @@ func (h *Handler) DeleteAuthFile(c *gin.Context) {
 			full = abs
 		}
 	}
-	if err := os.Remove(full); err != nil {
+	if err := h.discardAuthFile(nil, full, "name", purge); err != nil {
 		if os.IsNotExist(err) {
 			c.JSON(404, gin.H{"error": "file not found"})
 		} else {
@@ -706,77 +911,12 @@ func parsePositiveInt(raw string, defaultValue, minValue, maxValue int) int {
 	return parsed
 }
 
-func metadataTruthy(raw any) bool {
-	switch typed := raw.(type) {
-	case bool:
-		return typed
-	case string:
-		return queryTruthy(typed)
-	case int:
-		return typed != 0
-	case int64:
-		return typed != 0
-	case float64:
-		return typed != 0
-	default:
-		return false
-	}
-}
-
 func tokenInvalidState(auth *coreauth.Auth) (bool, string) {
-	if auth == nil || len(auth.Metadata) == 0 {
-		return false, ""
-	}
-	invalid := metadataTruthy(auth.Metadata[tokenInvalidMetaKey])
-	reason, _ := auth.Metadata[tokenInvalidReasonKey].(string)
-	return invalid, strings.TrimSpace(reason)
+	return coreauth.TokenInvalidState(auth)
 }
 
 func setTokenInvalidState(auth *coreauth.Auth, invalid bool, reason string) {
-	if auth == nil {
-		return
-	}
-	if auth.Metadata == nil {
-		auth.Metadata = make(map[string]any)
-	}
-	if invalid {
-		auth.Metadata[tokenInvalidMetaKey] = true
-		auth.Metadata[tokenInvalidAtKey] = time.Now().UTC().Format(time.RFC3339)
-		trimmedReason := strings.TrimSpace(reason)
-		if trimmedReason != "" {
-			auth.Metadata[tokenInvalidReasonKey] = trimmedReason
-		} else {
-			delete(auth.Metadata, tokenInvalidReasonKey)
-		}
-		return
-	}
-	delete(auth.Metadata, tokenInvalidMetaKey)
-	delete(auth.Metadata, tokenInvalidReasonKey)
-	delete(auth.Metadata, tokenInvalidAtKey)
-}
-
-func isInvalidAuthFileCandidate(auth *coreauth.Auth) bool {
-	if auth == nil {
-		return false
-	}
-	if isRuntimeOnlyAuth(auth) {
-		return false
-	}
-	invalid, _ := tokenInvalidState(auth)
-	return invalid
-}
-
-func isFailedAuthFileCandidate(auth *coreauth.Auth) bool {
-	if auth == nil {
-		return false
-	}
-	if auth.Disabled || auth.Status == coreauth.StatusDisabled {
-		return false
-	}
-	if isRuntimeOnlyAuth(auth) {
-		return false
-	}
-	return auth.Unavailable
+	coreauth.SetTokenInvalidState(auth, invalid, reason)
 }
 
 func (h *Handler) resolveAuthFilePath(auth *coreauth.Auth) (string, bool) {
@@ -824,52 +964,154 @@ func (h *Handler) resolveAuthFilePath(auth *coreauth.Auth) (string, bool) {
 	return path, true
 }
 
-func (h *Handler) deleteInvalidAuthFiles(c *gin.Context, ctx context.Context) {
-	deleted, matched, err := h.deleteInvalidAuthFilesInternal(ctx)
+func (h *Handler) deleteInvalidAuthFiles(c *gin.Context, ctx context.Context, providers, tags []string, purge bool) {
+	opts := h.invalidAuthFileDeleteOptions(deleteFilterLabel("invalid", providers, tags), purge)
+	opts.Providers = providers
+	opts.Filter = taggedAuthFilter(opts.Filter, tags)
+	result, err := h.authInspector().DeleteInvalid(ctx, opts)
+	h.inspectionMetrics.filesDeleted(result.Deleted)
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(200, gin.H{"status": "ok", "deleted": deleted, "matched": matched, "scope": "invalid"})
+	c.JSON(200, bulkDeletePayload("invalid", result.Matched, result.Deleted, providers, tags, result.ByProvider))
 }
 
-func (h *Handler) deleteInvalidAuthFilesInternal(ctx context.Context) (int, int, error) {
-	auths := h.authManager.List()
-	deleted := 0
-	matched := 0
-	seenPaths := make(map[string]struct{})
-	for _, auth := range auths {
-		if !isInvalidAuthFileCandidate(auth) {
-			continue
-		}
-		path, ok := h.resolveAuthFilePath(auth)
-		if !ok {
-			continue
-		}
-		if _, exists := seenPaths[path]; exists {
-			continue
-		}
-		seenPaths[path] = struct{}{}
-		matched++
-		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
-			return deleted, matched, fmt.Errorf("failed to remove file: %w", err)
+// bulkDeletePayload is the response of a bulk deletion: its counts, the
+// selectors applied and, without a provider selector, the counts per
+// provider.
+func bulkDeletePayload(scope string, matched, deleted int, providers, tags []string, byProvider map[string]coreauth.DeleteCounts) gin.H {
+	payload := gin.H{"status": "ok", "deleted": deleted, "matched": matched, "scope": scope}
+	if len(providers) > 0 {
+		payload["provider"] = providers
+	} else {
+		if byProvider == nil {
+			byProvider = map[string]coreauth.DeleteCounts{}
 		}
-		if err := h.deleteTokenRecord(ctx, path); err != nil {
-			return deleted, matched, err
+		payload["by_provider"] = byProvider
+	}
+	if len(tags) > 0 {
+		payload["tag"] = tags
+	}
+	return payload
+}
+
+// deleteInvalidAuthFilesInternal deletes the invalid auth files of
+// providers, or of every provider when none is given.
+func (h *Handler) deleteInvalidAuthFilesInternal(ctx context.Context, providers ...string) (int, int, error) {
+	return h.deleteInvalidAuthFilesFor(ctx, queryList(providers))
+}
+
+// deleteInvalidAuthFilesFor deletes the invalid auth files of providers, or of
+// every provider when providers is empty, for the inspection's auto-delete.
+func (h *Handler) deleteInvalidAuthFilesFor(ctx context.Context, providers []string) (int, int, error) {
+	h.purgeTrash()
+	opts := h.scheduledInvalidDeleteOptions(deleteFilterLabel("invalid", providers, nil))
+	opts.Providers = providers
+	result, err := h.authInspector().DeleteInvalid(ctx, opts)
+	h.inspectionMetrics.filesDeleted(result.Deleted)
+	return result.Deleted, result.Matched, err
+}
+
+// previewInvalidAuthFilesFor returns the file names deleteInvalidAuthFilesFor
+// would remove for providers, without removing anything.
+func (h *Handler) previewInvalidAuthFilesFor(ctx context.Context, providers []string) ([]string, error) {
+	opts := h.scheduledInvalidDeleteOptions("")
+	opts.Providers = providers
+	opts.DryRun = true
+	result, err := h.authInspector().DeleteInvalid(ctx, opts)
+	names := make([]string, 0, len(result.Candidates))
+	for _, auth := range result.Candidates {
+		name := strings.TrimSpace(auth.FileName)
+		if name == "" {
+			name = auth.ID
 		}
-		h.disableAuth(ctx, path)
-		deleted++
+		names = append(names, name)
 	}
-	return deleted, matched, nil
+	return names, err
 }
 
-func (h *Handler) deleteFailedAuthFiles(c *gin.Context, ctx context.Context) {
+// disableInvalidAuthsFor sidelines the auths of providers marked invalid,
+// keeping their files, and returns how many it disabled. Auths left out by the
+// inspection's patterns and those already sidelined are skipped.
+func (h *Handler) disableInvalidAuthsFor(ctx context.Context, providers []string) (int, error) {
+	filter := inspectionFilter(h.effectiveAuthInspectionConfig())
+	result, err := h.authInspector().DeleteInvalid(ctx, coreauth.DeleteOptions{
+		Providers: providers,
+		DryRun:    true,
+		Filter: func(auth *coreauth.Auth) bool {
+			return !coreauth.IsInvalidDisabled(auth) && (filter == nil || filter(auth))
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	disabled := 0
+	for _, auth := range result.Candidates {
+		_, reason := tokenInvalidState(auth)
+		if _, err = h.authManager.DisableInvalid(ctx, auth.ID, reason); err != nil {
+			return disabled, err
+		}
+		disabled++
+	}
+	return disabled, nil
+}
+
+// invalidAuthFileDeleteOptions removes each invalid auth's file once, along
+// with its token record, and disables the auth. With a quarantine dir
+// configured the file is moved there; otherwise it goes to the trash, recorded
+// as removed by filter, or is unlinked with purge. It selects every invalid
+// auth; the inspection's own deletions narrow it with scheduledInvalidDeleteOptions.
+func (h *Handler) invalidAuthFileDeleteOptions(filter string, purge bool) coreauth.DeleteOptions {
+	return coreauth.DeleteOptions{
+		Key: h.resolveAuthFilePath,
+		Remove: func(ctx context.Context, auth *coreauth.Auth) error {
+			path, _ := h.resolveAuthFilePath(auth)
+			quarantine, err := h.quarantineDir()
+			if err != nil {
+				return err
+			}
+			if quarantine != "" {
+				if err = quarantineAuthFile(auth, path, quarantine); err != nil {
+					return err
+				}
+			} else if err = h.discardAuthFile(auth, path, filter, purge); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove file: %w", err)
+			}
+			if err := h.deleteTokenRecord(ctx, path); err != nil {
+				return err
+			}
+			h.disableAuth(ctx, path)
+			return nil
+		},
+	}
+}
+
+// scheduledInvalidDeleteOptions are the invalidAuthFileDeleteOptions of the
+// inspection's own deletions, which keep the auths left out by its include and
+// exclude patterns and tags. A manual deletion is not narrowed by them.
+func (h *Handler) scheduledInvalidDeleteOptions(filter string) coreauth.DeleteOptions {
+	opts := h.invalidAuthFileDeleteOptions(filter, false)
+	opts.Filter = inspectionFilter(h.effectiveAuthInspectionConfig())
+	return opts
+}
+
+// deleteMatchingAuthFiles deletes the file of every auth that match selects,
+// of one of providers and carrying one of tags when there are any, reporting
+// scope. Files are moved to the trash unless purge is set.
+func (h *Handler) deleteMatchingAuthFiles(c *gin.Context, ctx context.Context, scope string, providers, tags []string, purge bool, match func(*coreauth.Auth) bool) {
+	filter := deleteFilterLabel(scope, providers, tags)
 	auths := h.authManager.List()
 	deleted := 0
 	matched := 0
+	byProvider := make(map[string]coreauth.DeleteCounts)
 	seenPaths := make(map[string]struct{})
 	for _, auth := range auths {
-		if !isFailedAuthFileCandidate(auth) {
+		provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+		if len(providers) > 0 && !slices.Contains(providers, provider) {
+			continue
+		}
+		if !match(auth) || !authHasAnyTag(auth, tags) {
 			continue
 		}
 		path, ok := h.resolveAuthFilePath(auth)
@@ -881,7 +1123,10 @@ func (h *Handler) deleteFailedAuthFiles(c *gin.Context, ctx context.Context) {
 		}
 		seenPaths[path] = struct{}{}
 		matched++
-		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		counts := byProvider[provider]
+		counts.Matched++
+		byProvider[provider] = counts
+		if err := h.discardAuthFile(auth, path, filter, purge); err != nil && !os.IsNotExist(err) {
 			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to remove file: %v", err)})
 			return
 		}
@@ -891,8 +1136,10 @@ func (h *Handler) deleteFailedAuthFiles(c *gin.Context, ctx context.Context) {
 		}
 		h.disableAuth(ctx, path)
 		deleted++
+		counts.Deleted++
+		byProvider[provider] = counts
 	}
-	c.JSON(200, gin.H{"status": "ok", "deleted": deleted, "matched": matched, "scope": "failed"})
+	c.JSON(200, bulkDeletePayload(scope, matched, deleted, providers, tags, byProvider))
 }
 
 func normalizeTokenInvalidReason(raw string) string {
@@ -907,15 +1154,14 @@ func normalizeTokenInvalidReason(raw string) string {
 	return reason[:maxLen]
 }
 
-func isSupportedTokenVerifyProvider(provider string) bool {
-	switch strings.ToLower(strings.TrimSpace(provider)) {
-	case "codex", "gemini-cli", "antigravity":
-		return true
-	default:
-		return false
-	}
-}
-
+// verifyCodexAuthToken refreshes a codex auth's access token when it has
+// expired, saving the new one through the token store, and then checks usage
+// with it. Only a refresh the token endpoint rejects marks the auth invalid
+// before the probe runs. The codex error rules classify the response first;
+// by default 401, 403 and account_deactivated mark the auth invalid and 429
+// reports it throttled. A 5xx leaves the auth's state alone and reports it
+// throttled, never invalid, unless the fallback probe settles it. The plan and rate-limit windows of a 2xx
+// response are kept under codexUsageMetaKey.
 func (h *Handler) verifyCodexAuthToken(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
 	if auth == nil {
 		return true, "auth is nil", nil
@@ -928,30 +1174,44 @@ func (h *Handler) verifyCodexAuthToken(ctx context.Context, auth *coreauth.Auth)
 
 	accessToken, errToken := h.refreshCodexOAuthAccessToken(refreshCtx, auth)
 	if errToken != nil {
-		return true, normalizeTokenInvalidReason(fmt.Sprintf("token refresh failed: %v", errToken)), nil
+		return refreshFailureOutcome(errToken)
 	}
 	if strings.TrimSpace(accessToken) == "" {
 		return true, "token is empty", nil
 	}
 
-	statusCode, respBody, errProbe := h.probeCodexUsage(ctx, auth, accessToken)
-	if errProbe != nil {
-		// Probe failures (network/timeout) are not definitive invalid signals.
-		invalid, reason := tokenInvalidState(auth)
-		if invalid {
-			return true, reason, nil
-		}
-		return false, "", nil
+	settings := h.probeSettings("codex")
+	statusCode, respBody, errProbe := probeWithRetry(ctx, func() (int, string, error) {
+		return h.probeCodexUsage(ctx, auth, accessToken, settings)
+	})
+	if settings.fallsBack(ctx, statusCode, errProbe) {
+		return h.fallbackProbe(ctx, "codex", "usage probe", settings, statusCode, errProbe, func(fallback probeSettings) (int, string, error) {
+			return h.probeCodexUsage(ctx, auth, accessToken, fallback)
+		})
 	}
-
-	if statusCode == http.StatusUnauthorized {
-		return true, normalizeTokenInvalidReason(fmt.Sprintf("401 %s", strings.TrimSpace(respBody))), nil
+	recordProbeResponse(ctx, statusCode, respBody)
+	if errCtx := ctx.Err(); errCtx != nil {
+		return false, "", errCtx
+	}
+	// Probe failures (network/timeout) and 5xx are not definitive invalid signals.
+	if errProbe != nil && statusCode < http.StatusInternalServerError {
+		return false, "", fmt.Errorf("%w: usage probe failed: %v", coreauth.ErrProbeInconclusive, errProbe)
 	}
-	if statusCode >= 200 && statusCode < 300 {
+	if match := h.matchErrorRules("codex", statusCode, respBody); match.verdict != verdictNone {
+		return match.outcome("usage probe", fmt.Sprintf("usage probe %s: %s", match, strings.TrimSpace(respBody)))
+	}
+	// Outages say nothing about the token.
+	if statusCode >= http.StatusInternalServerError {
+		return false, "", fmt.Errorf("%w: usage probe returned %d", coreauth.ErrProbeThrottled, statusCode)
+	}
+	if settings.expects(statusCode) {
+		if statusCode >= 200 && statusCode < 300 {
+			recordCodexUsage(auth, respBody, time.Now())
+		}
 		return false, "", nil
 	}
 
-	// Non-2xx/401 responses (e.g. 429/5xx) are treated as inconclusive.
+	// Other responses keep the auth's current state.
 	invalid, reason := tokenInvalidState(auth)
 	if invalid {
 		return true, reason, nil
@@ -959,17 +1219,61 @@ func (h *Handler) verifyCodexAuthToken(ctx context.Context, auth *coreauth.Auth)
 	return false, "", nil
 }
 
-func (h *Handler) probeCodexUsage(ctx context.Context, auth *coreauth.Auth, accessToken string) (int, string, error) {
+var refreshServerStatus = regexp.MustCompile(`status:? 5\d\d\b`)
+
+// refreshFailureOutcome maps a failed token refresh to a probe outcome. The
+// token endpoint rejecting the refresh token marks the auth invalid; network
+// errors, timeouts and 5xx say nothing about the token, so they are
+// inconclusive and leave the auth's state alone.
+func refreshFailureOutcome(errRefresh error) (bool, string, error) {
+	var errURL *url.Error
+	if errors.As(errRefresh, &errURL) || errors.Is(errRefresh, context.DeadlineExceeded) || errors.Is(errRefresh, context.Canceled) || refreshServerStatus.MatchString(errRefresh.Error()) {
+		return false, "", fmt.Errorf("%w: token refresh failed: %v", coreauth.ErrProbeInconclusive, errRefresh)
+	}
+	return true, normalizeTokenInvalidReason(fmt.Sprintf("token refresh failed: %v", errRefresh)), nil
+}
+
+func statusCodesOrEmpty(codes []int) []int {
+	if codes == nil {
+		return []int{}
+	}
+	return codes
+}
+
+// probeWithRetry runs probe, retrying network errors, timeouts and 5xx
+// responses with backoff. It returns the last attempt.
+func probeWithRetry(ctx context.Context, probe func() (int, string, error)) (int, string, error) {
+	backoff := codexUsageProbeBackoff
+	for attempt := 0; ; attempt++ {
+		statusCode, respBody, errProbe := probe()
+		if errProbe == nil && statusCode < http.StatusInternalServerError {
+			return statusCode, respBody, nil
+		}
+		if attempt == codexUsageProbeRetries || ctx.Err() != nil {
+			return statusCode, respBody, errProbe
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return statusCode, respBody, errProbe
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+func (h *Handler) probeCodexUsage(ctx context.Context, auth *coreauth.Auth, accessToken string, settings probeSettings) (int, string, error) {
 	if strings.TrimSpace(accessToken) == "" {
 		return 0, "", fmt.Errorf("missing access token")
 	}
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	probeCtx, cancelProbe := context.WithTimeout(ctx, 30*time.Second)
+	probeCtx, cancelProbe := context.WithTimeout(ctx, settings.Timeout)
 	defer cancelProbe()
 
-	req, errReq := http.NewRequestWithContext(probeCtx, http.MethodGet, codexUsageProbeURL, nil)
+	req, errReq := http.NewRequestWithContext(probeCtx, http.MethodGet, settings.URL, nil)
 	if errReq != nil {
 		return 0, "", errReq
 	}
@@ -981,13 +1285,14 @@ func (h *Handler) probeCodexUsage(ctx context.Context, auth *coreauth.Auth, acce
 	}
 
 	httpClient := &http.Client{
-		Timeout:   30 * time.Second,
+		Timeout:   settings.Timeout,
 		Transport: h.apiCallTransport(auth),
 	}
 	resp, errDo := httpClient.Do(req)
 	if errDo != nil {
 		return 0, "", errDo
 	}
+	h.pauseProbesOnRetryAfter(resp, time.Now())
 	defer func() {
 		_ = resp.Body.Close()
 	}()
@@ -996,9 +1301,37 @@ func (h *Handler) probeCodexUsage(ctx context.Context, auth *coreauth.Auth, acce
 	if errRead != nil {
 		return resp.StatusCode, "", errRead
 	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		if retryAt := probeRetryAt(resp.Header, bodyBytes, time.Now()); !retryAt.IsZero() {
+			coreauth.RecordProbeRetryAt(ctx, retryAt)
+		}
+	}
 	return resp.StatusCode, string(bodyBytes), nil
 }
 
+// probeRetryAt returns when a throttled upstream allows the auth again, from
+// the Retry-After header (seconds or an HTTP date) or else the resets_at or
+// resets_in_seconds of the error body, or the zero time if neither says.
+func probeRetryAt(header http.Header, body []byte, now time.Time) time.Time {
+	if retryAfter := strings.TrimSpace(header.Get("Retry-After")); retryAfter != "" {
+		if seconds, errAtoi := strconv.Atoi(retryAfter); errAtoi == nil && seconds >= 0 {
+			return now.Add(time.Duration(seconds) * time.Second)
+		}
+		if at, errParse := http.ParseTime(retryAfter); errParse == nil {
+			return at
+		}
+	}
+	for _, prefix := range []string{"error.", ""} {
+		if resetsAt := gjson.GetBytes(body, prefix+"resets_at").Int(); resetsAt > 0 {
+			return time.Unix(resetsAt, 0)
+		}
+		if resetsIn := gjson.GetBytes(body, prefix+"resets_in_seconds").Int(); resetsIn > 0 {
+			return now.Add(time.Duration(resetsIn) * time.Second)
+		}
+	}
+	return time.Time{}
+}
+
 func codexAccountID(auth *coreauth.Auth) string {
 	if auth == nil || len(auth.Metadata) == 0 {
 		return ""
@@ -1021,245 +1354,79 @@ func codexAccountID(auth *coreauth.Auth) string {
 }
 
 func (h *Handler) verifyAuthTokenState(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
-	if auth == nil {
-		return false, "", nil
-	}
-	provider := strings.ToLower(strings.TrimSpace(auth.Provider))
-	if !isSupportedTokenVerifyProvider(provider) {
-		return false, "", nil
-	}
-	if auth.Disabled || auth.Status == coreauth.StatusDisabled {
-		return false, "", nil
+	return h.authInspector().Verify(ctx, auth)
+}
+
+// verifyInvalidAuthBatch verifies one batch, leaving out the auths verified
+// valid within min-reverify-seconds and reusing the results probed within
+// verify-cache-seconds and backing off from the auths whose probes keep
+// failing, unless force is set. With tags only the auths carrying one of
+// them are verified. onResult, when set, receives each result
+// as soon as it is known. When ctx ends mid-batch the auths verified by then
+// are returned with ctx's error.
+func (h *Handler) verifyInvalidAuthBatch(ctx context.Context, providerFilter, scope string, tags []string, concurrency, batchSize, cursor int, force bool, onResult func(coreauth.VerifyResult)) (coreauth.VerifyBatchResult, error) {
+	cfg := h.effectiveAuthInspectionConfig()
+	opts := coreauth.VerifyOptions{
+		Concurrency: concurrency,
+		BatchSize:   batchSize,
+		Cursor:      cursor,
+		Filter:      taggedAuthFilter(scopedInspectionFilter(inspectionFilter(cfg), scope), tags),
+		Throttle:    h.throttleProbe,
+		OnResult:    onResult,
 	}
-	if isRuntimeOnlyAuth(auth) {
-		return false, "", nil
+	cacheTTL := time.Duration(cfg.VerifyCacheSeconds) * time.Second
+	if !force {
+		opts.MinReverify = time.Duration(cfg.MinReverifySeconds) * time.Second
+		opts.Backoff = probeBackoff(cfg)
+		opts.Cached = func(auth *coreauth.Auth) (coreauth.VerifyResult, bool) {
+			return h.verifyCache.lookup(auth, cacheTTL, time.Now())
+		}
 	}
+	result, err := h.authInspector().VerifyBatch(ctx, providerFilter, opts)
+	h.verifyCache.store(h.authManager, result.Results, cacheTTL, time.Now())
+	h.inspectionMetrics.probed(result.Results)
+	return result, err
+}
 
-	var (
-		invalid bool
-		reason  string
-		err     error
-	)
-	switch provider {
-	case "codex":
-		invalid, reason, err = h.verifyCodexAuthToken(ctx, auth)
-	default:
-		var token string
-		token, err = h.resolveTokenForAuth(ctx, auth)
-		if err != nil {
-			invalid = true
-			reason = normalizeTokenInvalidReason(fmt.Sprintf("token refresh failed: %v", err))
-		} else if strings.TrimSpace(token) == "" {
-			invalid = true
-			reason = "token is empty"
-		}
+// verifyResultPayload is the response row of one verified auth.
+func verifyResultPayload(item coreauth.VerifyResult) gin.H {
+	row := gin.H{
+		"id":         item.ID,
+		"name":       item.Name,
+		"provider":   item.Provider,
+		"invalid":    item.Invalid,
+		"outcome":    item.Outcome,
+		"latency_ms": item.LatencyMs,
 	}
-	if err != nil {
-		return false, "", err
+	if item.StatusCode != 0 {
+		row["status_code"] = item.StatusCode
 	}
-
-	setTokenInvalidState(auth, invalid, reason)
-	auth.UpdatedAt = time.Now()
-	if _, errUpdate := h.authManager.Update(ctx, auth); errUpdate != nil {
-		return false, "", errUpdate
+	if item.ErrorCode != "" {
+		row["error_code"] = item.ErrorCode
 	}
-	return invalid, reason, nil
-}
-
-type verifyInvalidEntry struct {
-	ID       string
-	Name     string
-	Provider string
-	Invalid  bool
-	Reason   string
-}
-
-type verifyInvalidBatchResult struct {
-	Scope       string
-	Provider    string
-	Concurrency int
-	BatchSize   int
-	Cursor      int
-	NextCursor  int
-	Total       int
-	Done        bool
-	Checked     int
-	Valid       int
-	Invalid     int
-	Skipped     int
-	Results     []verifyInvalidEntry
-}
-
-func filterVerifyInvalidCandidates(auths []*coreauth.Auth, providerFilter string) ([]*coreauth.Auth, int) {
-	skippedCount := 0
-	candidates := make([]*coreauth.Auth, 0, len(auths))
-	for _, auth := range auths {
-		if auth == nil {
-			skippedCount++
-			continue
-		}
-		provider := strings.ToLower(strings.TrimSpace(auth.Provider))
-		if providerFilter != "" && provider != providerFilter {
-			continue
-		}
-		if !isSupportedTokenVerifyProvider(provider) {
-			skippedCount++
-			continue
-		}
-		if auth.Disabled || auth.Status == coreauth.StatusDisabled || isRuntimeOnlyAuth(auth) {
-			skippedCount++
-			continue
-		}
-		candidates = append(candidates, auth)
+	if item.Probe != "" {
+		row["probe"] = item.Probe
 	}
-	sort.Slice(candidates, func(i, j int) bool {
-		return strings.Compare(strings.TrimSpace(candidates[i].ID), strings.TrimSpace(candidates[j].ID)) < 0
-	})
-	return candidates, skippedCount
-}
-
-func (h *Handler) verifyInvalidAuthBatch(ctx context.Context, providerFilter string, concurrency, batchSize, cursor int) (*verifyInvalidBatchResult, error) {
-	auths := h.authManager.List()
-	candidates, skippedCount := filterVerifyInvalidCandidates(auths, providerFilter)
-	total := len(candidates)
-	if total == 0 || cursor >= total {
-		return &verifyInvalidBatchResult{
-			Scope:       "invalid",
-			Provider:    providerFilter,
-			Concurrency: concurrency,
-			BatchSize:   batchSize,
-			Cursor:      cursor,
-			NextCursor:  cursor,
-			Total:       total,
-			Done:        true,
-			Checked:     0,
-			Valid:       0,
-			Invalid:     0,
-			Skipped:     skippedCount,
-			Results:     []verifyInvalidEntry{},
-		}, nil
-	}
-
-	if concurrency < 1 {
-		concurrency = 1
-	}
-	if batchSize < 1 {
-		batchSize = 1
-	}
-	if cursor < 0 {
-		cursor = 0
-	}
-	end := cursor + batchSize
-	if end > total {
-		end = total
-	}
-	currentBatch := candidates[cursor:end]
-	if len(currentBatch) == 0 {
-		return &verifyInvalidBatchResult{
-			Scope:       "invalid",
-			Provider:    providerFilter,
-			Concurrency: concurrency,
-			BatchSize:   batchSize,
-			Cursor:      cursor,
-			NextCursor:  end,
-			Total:       total,
-			Done:        end >= total,
-			Checked:     0,
-			Valid:       0,
-			Invalid:     0,
-			Skipped:     skippedCount,
-			Results:     []verifyInvalidEntry{},
-		}, nil
-	}
-	if concurrency > len(currentBatch) {
-		concurrency = len(currentBatch)
-	}
-
-	type verifyResult struct {
-		auth    *coreauth.Auth
-		invalid bool
-		reason  string
-		err     error
-	}
-
-	jobs := make(chan *coreauth.Auth)
-	resultsCh := make(chan verifyResult, len(currentBatch))
-	var wg sync.WaitGroup
-	worker := func() {
-		defer wg.Done()
-		for auth := range jobs {
-			invalid, reason, errVerify := h.verifyAuthTokenState(ctx, auth)
-			resultsCh <- verifyResult{
-				auth:    auth,
-				invalid: invalid,
-				reason:  reason,
-				err:     errVerify,
-			}
-		}
+	if item.Reason != "" {
+		row["reason"] = item.Reason
 	}
-	for i := 0; i < concurrency; i++ {
-		wg.Add(1)
-		go worker()
+	if item.ReasonCode != "" {
+		row["reason_code"] = item.ReasonCode
 	}
-	for _, auth := range currentBatch {
-		jobs <- auth
+	if item.Error != "" {
+		row["error"] = item.Error
 	}
-	close(jobs)
-	wg.Wait()
-	close(resultsCh)
-
-	validCount := 0
-	invalidCount := 0
-	entries := make([]verifyInvalidEntry, 0, len(currentBatch))
-	var firstErr error
-	for res := range resultsCh {
-		if res.err != nil {
-			if firstErr == nil {
-				firstErr = fmt.Errorf("failed to verify token for %s: %w", res.auth.ID, res.err)
-			}
-			continue
-		}
-		provider := strings.ToLower(strings.TrimSpace(res.auth.Provider))
-		name := strings.TrimSpace(res.auth.FileName)
-		if name == "" {
-			name = strings.TrimSpace(res.auth.ID)
-		}
-		entry := verifyInvalidEntry{
-			ID:       res.auth.ID,
-			Name:     name,
-			Provider: provider,
-			Invalid:  res.invalid,
-			Reason:   strings.TrimSpace(res.reason),
-		}
-		entries = append(entries, entry)
-		if res.invalid {
-			invalidCount++
-		} else {
-			validCount++
-		}
+	if item.Recovered {
+		row["recovered"] = true
 	}
-	if firstErr != nil {
-		return nil, firstErr
+	if item.Cached {
+		row["cached"] = true
+		row["cached_at"] = item.CachedAt
 	}
-	sort.Slice(entries, func(i, j int) bool {
-		return strings.Compare(strings.TrimSpace(entries[i].ID), strings.TrimSpace(entries[j].ID)) < 0
-	})
-
-	return &verifyInvalidBatchResult{
-		Scope:       "invalid",
-		Provider:    providerFilter,
-		Concurrency: concurrency,
-		BatchSize:   batchSize,
-		Cursor:      cursor,
-		NextCursor:  end,
-		Total:       total,
-		Done:        end >= total,
-		Checked:     len(currentBatch),
-		Valid:       validCount,
-		Invalid:     invalidCount,
-		Skipped:     skippedCount,
-		Results:     entries,
-	}, nil
+	if item.VerifiedAt != "" {
+		row["verified_at"] = item.VerifiedAt
+	}
+	return row
 }
 
 func (h *Handler) VerifyInvalidAuthFiles(c *gin.Context) {
@@ -1272,50 +1439,188 @@ func (h *Handler) VerifyInvalidAuthFiles(c *gin.Context) {
 	if providerFilter == "all" || providerFilter == "*" {
 		providerFilter = ""
 	}
-	const (
-		defaultVerifyConcurrency = 20
-		maxVerifyConcurrency     = 50
-		defaultBatchSize         = 100
-		maxBatchSize             = 1000
-	)
-	concurrency := parsePositiveInt(c.Query("concurrency"), defaultVerifyConcurrency, 1, maxVerifyConcurrency)
-	batchSize := parsePositiveInt(c.Query("batch_size"), defaultBatchSize, 1, maxBatchSize)
-	cursor := parsePositiveInt(c.Query("cursor"), 0, 0, 1<<30)
-	result, errVerify := h.verifyInvalidAuthBatch(ctx, providerFilter, concurrency, batchSize, cursor)
-	if errVerify != nil {
+	if alias, ok := inspectionProviderAliases[providerFilter]; ok {
+		providerFilter = alias
+	}
+	concurrency, batchSize, cursor, errParams := parseVerifyInvalidParams(c.Query("concurrency"), c.Query("batch_size"), c.Query("cursor"))
+	if errParams != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errParams.Error()})
+		return
+	}
+	force, _ := strconv.ParseBool(c.Query("force"))
+	scope, ok := parseInspectionScope(c.Query("scope"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("scope must be %q or %q", inspectionScopeAll, inspectionScopeInvalidOnly)})
+		return
+	}
+	tags, errTags := queryTags(c)
+	if errTags != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTags.Error()})
+		return
+	}
+	csvFormat, errFormat := parseVerifyFormat(c.Query("format"))
+	if errFormat != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errFormat.Error()})
+		return
+	}
+	if async, _ := strconv.ParseBool(c.Query("async")); async {
+		if csvFormat {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format=csv applies to the job's results, fetch them from verify-jobs with format=csv"})
+			return
+		}
+		h.startVerifyJob(c, providerFilter, scope, tags, concurrency, batchSize, cursor, force)
+		return
+	}
+	// A CSV export streams each row as its probe completes.
+	var csvOut *verifyCSVWriter
+	var onResult func(coreauth.VerifyResult)
+	if csvFormat {
+		csvOut = h.newVerifyCSVWriter(c)
+		onResult = csvOut.write
+	}
+	// A client that disconnects cancels ctx, which stops the outstanding
+	// probes; the partial counts are still written in case it is listening.
+	result, errVerify := h.verifyInvalidAuthBatch(ctx, providerFilter, scope, tags, concurrency, batchSize, cursor, force, onResult)
+	cancelled := errVerify != nil && ctx.Err() != nil && errors.Is(errVerify, ctx.Err())
+	if errVerify != nil && !cancelled {
+		if csvOut != nil && csvOut.started() {
+			// The rows already sent fixed the status; end the export there.
+			log.Warnf("verify-invalid: CSV export cut short: %v", errVerify)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": errVerify.Error()})
 		return
 	}
+	if csvOut != nil {
+		csvOut.finish()
+		return
+	}
+	details, _ := strconv.ParseBool(c.Query("details"))
+	withRows := details || len(result.Results) <= maxVerifyResultRows
 	results := make([]gin.H, 0, len(result.Results))
-	for _, item := range result.Results {
-		row := gin.H{
-			"id":       item.ID,
-			"name":     item.Name,
-			"provider": item.Provider,
-			"invalid":  item.Invalid,
+	if withRows {
+		for _, item := range result.Results {
+			results = append(results, verifyResultPayload(item))
+		}
+	}
+	unsupported := result.Unsupported
+	if unsupported == nil {
+		unsupported = []string{}
+	}
+
+	payload := gin.H{
+		"status":           "ok",
+		"scope":            scope,
+		"provider":         result.Provider,
+		"concurrency":      concurrency,
+		"batch_size":       batchSize,
+		"cursor":           cursor,
+		"next_cursor":      result.NextCursor,
+		"total":            result.Total,
+		"done":             result.Done,
+		"checked":          result.Checked,
+		"valid":            result.Valid,
+		"invalid":          result.Invalid,
+		"errors":           result.Errors,
+		"throttled":        result.Throttled,
+		"skipped":          result.Skipped,
+		"skipped_backoff":  result.BackedOff,
+		"frozen":           result.Frozen,
+		"filtered":         result.Filtered,
+		"recovered":        result.Recovered,
+		"cached":           result.Cached,
+		"results":          results,
+		"unsupported":      unsupported,
+		"reason_histogram": addReasonHistogram(map[string]int{}, result.Results),
+	}
+	if len(tags) > 0 {
+		payload["tag"] = tags
+	}
+	if providerFilter == "" {
+		payload["by_provider"] = verifyResultsByProvider(result.Results, withRows)
+	}
+	if !withRows {
+		payload["results_omitted"] = true
+	}
+	if cancelled {
+		payload["cancelled"] = true
+	}
+	c.JSON(http.StatusOK, payload)
+}
+
+const (
+	defaultVerifyConcurrency = 10
+	maxVerifyConcurrency     = 100
+	defaultVerifyBatchSize   = 100
+	maxVerifyBatchSize       = 1000
+	maxVerifyCursor          = 1 << 30
+	// maxVerifyResultRows is the most per-auth rows a verify response lists
+	// unless details=true; above it only the counts are returned.
+	maxVerifyResultRows = 1000
+)
+
+// parseVerifyInvalidParams reads the concurrency, batch_size and cursor query
+// parameters of verify-invalid. Concurrency and batch size are clamped to
+// 1-100 and 1-1000, and fall back to 10 and 100 when unset or not numbers. A
+// cursor defaults to 0 and must be a non-negative integer.
+func parseVerifyInvalidParams(rawConcurrency, rawBatchSize, rawCursor string) (concurrency, batchSize, cursor int, err error) {
+	concurrency = parsePositiveInt(rawConcurrency, defaultVerifyConcurrency, 1, maxVerifyConcurrency)
+	batchSize = parsePositiveInt(rawBatchSize, defaultVerifyBatchSize, 1, maxVerifyBatchSize)
+	if rawCursor = strings.TrimSpace(rawCursor); rawCursor != "" {
+		parsed, errAtoi := strconv.Atoi(rawCursor)
+		if errAtoi != nil || parsed < 0 {
+			return 0, 0, 0, fmt.Errorf("cursor must be a non-negative integer")
+		}
+		cursor = min(parsed, maxVerifyCursor)
+	}
+	return concurrency, batchSize, cursor, nil
+}
+
+// verifyResultsByProvider groups the rows of a batch spanning every provider
+// by provider, with each provider's counts. Without withRows only the counts
+// are kept.
+func verifyResultsByProvider(items []coreauth.VerifyResult, withRows bool) gin.H {
+	type providerGroup struct {
+		checked, valid, invalid, errors, throttled int
+		results                                    []gin.H
+	}
+	groups := make(map[string]*providerGroup)
+	for _, item := range items {
+		group := groups[item.Provider]
+		if group == nil {
+			group = &providerGroup{}
+			groups[item.Provider] = group
+		}
+		group.checked++
+		switch item.Outcome {
+		case coreauth.OutcomeThrottled:
+			group.throttled++
+		case coreauth.OutcomeError:
+			group.errors++
+		case coreauth.OutcomeInvalid:
+			group.invalid++
+		default:
+			group.valid++
+		}
+		if withRows {
+			group.results = append(group.results, verifyResultPayload(item))
+		}
+	}
+	out := make(gin.H, len(groups))
+	for provider, group := range groups {
+		entry := gin.H{
+			"checked":   group.checked,
+			"valid":     group.valid,
+			"invalid":   group.invalid,
+			"errors":    group.errors,
+			"throttled": group.throttled,
 		}
-		if item.Reason != "" {
-			row["reason"] = item.Reason
+		if withRows {
+			entry["results"] = group.results
 		}
-		results = append(results, row)
+		out[provider] = entry
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"status":      "ok",
-		"scope":       result.Scope,
-		"provider":    result.Provider,
-		"concurrency": result.Concurrency,
-		"batch_size":  result.BatchSize,
-		"cursor":      result.Cursor,
-		"next_cursor": result.NextCursor,
-		"total":       result.Total,
-		"done":        result.Done,
-		"checked":     result.Checked,
-		"valid":       result.Valid,
-		"invalid":     result.Invalid,
-		"skipped":     result.Skipped,
-		"results":     results,
-	})
+	return out
 }
 
 func (h *Handler) authIDForPath(path string) string {
@@ -1336,23 +1641,36 @@ func (h *Handler) authIDForPath(path string) string {
 	return path
 }
 
+// registerAuthFromFile registers the auth file at path, merging it into an
+// already managed entry so the entry's runtime state survives.
 func (h *Handler) registerAuthFromFile(ctx context.Context, path string, data []byte) error {
 	if h.authManager == nil {
 		return nil
 	}
+	auth, err := h.authFromFile(path, data)
+	if err != nil {
+		return err
+	}
+	_, err = h.authManager.RegisterOrUpdate(ctx, auth)
+	return err
+}
+
+// authFromFile builds the auth record for the file at path, reading it when
+// data is nil.
+func (h *Handler) authFromFile(path string, data []byte) (*coreauth.Auth, error) {
 	if path == "" {
-		return fmt.Errorf("auth path is empty")
+		return nil, fmt.Errorf("auth path is empty")
 	}
 	if data == nil {
 		var err error
 		data, err = os.ReadFile(path)
 		if err != nil {
-			return fmt.Errorf("failed to read auth file: %w", err)
+			return nil, fmt.Errorf("failed to read auth file: %w", err)
 		}
 	}
 	metadata := make(map[string]any)
 	if err := json.Unmarshal(data, &metadata); err != nil {
-		return fmt.Errorf("invalid auth file: %w", err)
+		return nil, fmt.Errorf("invalid auth file: %w", err)
 	}
 	provider, _ := metadata["type"].(string)
 	if provider == "" {
@@ -1362,6 +1680,9 @@ func (h *Handler) registerAuthFromFile(ctx context.Context, path string, data []
 	if email, ok := metadata["email"].(string); ok && email != "" {
 		label = email
 	}
+	if custom, ok := metadata["label"].(string); ok && strings.TrimSpace(custom) != "" {
+		label = strings.TrimSpace(custom)
+	}
 	lastRefresh, hasLastRefresh := extractLastRefreshTimestamp(metadata)
 
 	authID := h.authIDForPath(path)
@@ -1386,18 +1707,7 @@ func (h *Handler) registerAuthFromFile(ctx context.Context, path string, data []
 	if hasLastRefresh {
 		auth.LastRefreshedAt = lastRefresh
 	}
-	if existing, ok := h.authManager.GetByID(authID); ok {
-		auth.CreatedAt = existing.CreatedAt
-		if !hasLastRefresh {
-			auth.LastRefreshedAt = existing.LastRefreshedAt
-		}
-		auth.NextRefreshAfter = existing.NextRefreshAfter
-		auth.Runtime = existing.Runtime
-		_, err := h.authManager.Update(ctx, auth)
-		return err
-	}
-	_, err := h.authManager.Register(ctx, auth)
-	return err
+	return auth, nil
 }
 
 // PatchAuthFileStatus toggles the disabled state of an auth file
@@ -1428,20 +1738,7 @@ func (h *Handler) PatchAuthFileStatus(c *gin.Context) {
 
 	ctx := c.Request.Context()
 
-	// Find auth by name or ID
-	var targetAuth *coreauth.Auth
-	if auth, ok := h.authManager.GetByID(name); ok {
-		targetAuth = auth
-	} else {
-		auths := h.authManager.List()
-		for _, auth := range auths {
-			if auth.FileName == name {
-				targetAuth = auth
-				break
-			}
-		}
-	}
-
+	targetAuth := h.findAuthByNameOrID(name)
 	if targetAuth == nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
 		return
@@ -1477,13 +1774,7 @@ func (h *Handler) disableAuth(ctx context.Context, id string) {
 	if authID == "" {
 		return
 	}
-	if auth, ok := h.authManager.GetByID(authID); ok {
-		auth.Disabled = true
-		auth.Status = coreauth.StatusDisabled
-		auth.StatusMessage = "removed via management API"
-		auth.UpdatedAt = time.Now()
-		_, _ = h.authManager.Update(ctx, auth)
-	}
+	h.authManager.MarkRemoved(ctx, authID, "removed via management API")
 }
 
 func (h *Handler) deleteTokenRecord(ctx context.Context, path string) error {
@@ -0,0 +1,272 @@
+package management
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+const (
+	// maxAuthFilesPageSize bounds the limit of one auth files page.
+	maxAuthFilesPageSize = 1000
+	// authFilesSortTimeLayout is fixed width so formatted times sort as text.
+	authFilesSortTimeLayout = "2006-01-02T15:04:05.000000000Z"
+)
+
+// authFilesSorts are the sort keys of the auth files list and their default
+// order. latency lists the slowest first.
+var authFilesSorts = map[string]string{
+	"name":             "asc",
+	"provider":         "asc",
+	"status":           "asc",
+	"last_verified_at": "desc",
+	"created":          "desc",
+	"latency":          "desc",
+}
+
+// authFilesQuery is a parsed auth files list request.
+type authFilesQuery struct {
+	providers   []string
+	statuses    []string
+	tags        []string
+	unavailable *bool
+	invalid     *bool
+	nameLike    string
+	accountLike string
+	search      string // matched by matchAuthIdentity
+	sortBy      string
+	desc        bool
+	limit       int // 0 lists every match
+	after       *authFilesCursor
+}
+
+// authFilesCursor is the position after which the next page starts: the
+// sort position of the previous page's last entry. Keying pages by position
+// rather than offset keeps them from shifting while auths are registered or
+// removed between requests.
+type authFilesCursor struct {
+	Sort    string `json:"s"`
+	Desc    bool   `json:"d,omitempty"`
+	Missing bool   `json:"m,omitempty"`
+	Key     string `json:"k"`
+	Name    string `json:"n"`
+	ID      string `json:"i"`
+}
+
+// authFileRow is a listed entry with its sort position.
+type authFileRow struct {
+	entry gin.H
+	pos   authFilesCursor
+}
+
+// parseAuthFilesQuery reads the filter, sort and page parameters of
+// GET /auth-files.
+func parseAuthFilesQuery(c *gin.Context) (authFilesQuery, error) {
+	q := authFilesQuery{
+		providers:   queryList(c.QueryArray("provider")),
+		statuses:    queryList(c.QueryArray("status")),
+		nameLike:    strings.ToLower(strings.TrimSpace(c.Query("name_like"))),
+		accountLike: strings.ToLower(strings.TrimSpace(c.Query("account_like"))),
+		search:      strings.ToLower(strings.TrimSpace(c.Query("q"))),
+		sortBy:      strings.ToLower(strings.TrimSpace(c.DefaultQuery("sort", "name"))),
+	}
+	for i, provider := range q.providers {
+		if alias, ok := inspectionProviderAliases[provider]; ok {
+			q.providers[i] = alias
+		}
+	}
+	tags, err := queryTags(c)
+	if err != nil {
+		return q, err
+	}
+	q.tags = tags
+	for name, target := range map[string]**bool{"unavailable": &q.unavailable, "invalid": &q.invalid} {
+		raw := strings.TrimSpace(c.Query(name))
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return q, fmt.Errorf("%s must be true or false", name)
+		}
+		*target = &value
+	}
+	order, ok := authFilesSorts[q.sortBy]
+	if !ok {
+		return q, fmt.Errorf("sort must be one of name, provider, status, last_verified_at, created or latency")
+	}
+	switch raw := strings.ToLower(strings.TrimSpace(c.Query("order"))); raw {
+	case "":
+	case "asc", "desc":
+		order = raw
+	default:
+		return q, fmt.Errorf(`order must be "asc" or "desc"`)
+	}
+	q.desc = order == "desc"
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxAuthFilesPageSize {
+			return q, fmt.Errorf("limit must be between 1 and %d", maxAuthFilesPageSize)
+		}
+		q.limit = limit
+	}
+	if raw := strings.TrimSpace(c.Query("cursor")); raw != "" {
+		var after authFilesCursor
+		data, err := base64.RawURLEncoding.DecodeString(raw)
+		if err == nil {
+			err = json.Unmarshal(data, &after)
+		}
+		if err != nil {
+			return q, fmt.Errorf("invalid cursor")
+		}
+		if after.Sort != q.sortBy || after.Desc != q.desc {
+			return q, fmt.Errorf("cursor belongs to a different sort order")
+		}
+		q.after = &after
+	}
+	return q, nil
+}
+
+// matches reports whether entry passes every filter of q.
+func (q authFilesQuery) matches(entry gin.H) bool {
+	if len(q.providers) > 0 && !slices.Contains(q.providers, strings.ToLower(fmt.Sprint(entry["provider"]))) {
+		return false
+	}
+	if len(q.statuses) > 0 && !slices.Contains(q.statuses, strings.ToLower(fmt.Sprint(entry["status"]))) {
+		return false
+	}
+	if tags, _ := entry["tags"].([]string); len(q.tags) > 0 && !slices.ContainsFunc(tags, func(tag string) bool { return slices.Contains(q.tags, tag) }) {
+		return false
+	}
+	if unavailable, _ := entry["unavailable"].(bool); q.unavailable != nil && unavailable != *q.unavailable {
+		return false
+	}
+	if invalid, _ := entry["token_invalid"].(bool); q.invalid != nil && invalid != *q.invalid {
+		return false
+	}
+	if name, _ := entry["name"].(string); q.nameLike != "" && !strings.Contains(strings.ToLower(name), q.nameLike) {
+		return false
+	}
+	return authEntryMatchesAccount(entry, q.accountLike)
+}
+
+// row places entry, built from auth, in the sort order of q. Entries without
+// a sort value (never verified, never probed) go last in either order.
+func (q authFilesQuery) row(auth *coreauth.Auth, entry gin.H) authFileRow {
+	name, _ := entry["name"].(string)
+	pos := authFilesCursor{Sort: q.sortBy, Desc: q.desc, Name: strings.ToLower(name), ID: auth.ID}
+	switch q.sortBy {
+	case "name":
+		pos.Key = pos.Name
+	case "provider":
+		pos.Key = strings.ToLower(fmt.Sprint(entry["provider"]))
+	case "status":
+		pos.Key = strings.ToLower(fmt.Sprint(entry["status"]))
+	case "last_verified_at":
+		at, _ := coreauth.LastVerification(auth)
+		pos.Missing = at.IsZero()
+		pos.Key = formatAuthFilesSortTime(at)
+	case "created":
+		pos.Missing = auth.CreatedAt.IsZero()
+		pos.Key = formatAuthFilesSortTime(auth.CreatedAt)
+	case "latency":
+		avg, probed := entry["probe_latency_avg_ms"].(int64)
+		pos.Missing = !probed
+		pos.Key = fmt.Sprintf("%020d", avg)
+	}
+	return authFileRow{entry: entry, pos: pos}
+}
+
+func formatAuthFilesSortTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(authFilesSortTimeLayout)
+}
+
+// before reports whether a sorts before b. Ties on the sort key fall back to
+// the name and then the ID, so the order is total and pages never overlap.
+func (a authFilesCursor) before(b authFilesCursor) bool {
+	if a.Missing != b.Missing {
+		return !a.Missing
+	}
+	if a.Key != b.Key {
+		return (a.Key < b.Key) != a.Desc
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.ID < b.ID
+}
+
+func (a authFilesCursor) encode() string {
+	data, _ := json.Marshal(a)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// page sorts rows and returns the page q asks for, with the cursor of the
+// next page or "" when it is the last.
+func (q authFilesQuery) page(rows []authFileRow) ([]gin.H, string) {
+	sort.Slice(rows, func(i, j int) bool { return rows[i].pos.before(rows[j].pos) })
+	start := 0
+	if q.after != nil {
+		start = sort.Search(len(rows), func(i int) bool { return q.after.before(rows[i].pos) })
+	}
+	end := len(rows)
+	if q.limit > 0 && start+q.limit < end {
+		end = start + q.limit
+	}
+	files := make([]gin.H, 0, end-start)
+	for _, row := range rows[start:end] {
+		files = append(files, row.entry)
+	}
+	next := ""
+	if end < len(rows) {
+		next = rows[end-1].pos.encode()
+	}
+	return files, next
+}
+
+// filters reports the filters, sort and page size q applied.
+func (q authFilesQuery) filters() gin.H {
+	out := gin.H{"sort": q.sortBy, "order": "asc"}
+	if q.desc {
+		out["order"] = "desc"
+	}
+	if len(q.providers) > 0 {
+		out["provider"] = q.providers
+	}
+	if len(q.statuses) > 0 {
+		out["status"] = q.statuses
+	}
+	if len(q.tags) > 0 {
+		out["tag"] = q.tags
+	}
+	if q.unavailable != nil {
+		out["unavailable"] = *q.unavailable
+	}
+	if q.invalid != nil {
+		out["invalid"] = *q.invalid
+	}
+	if q.nameLike != "" {
+		out["name_like"] = q.nameLike
+	}
+	if q.accountLike != "" {
+		out["account_like"] = q.accountLike
+	}
+	if q.search != "" {
+		out["q"] = q.search
+	}
+	if q.limit > 0 {
+		out["limit"] = q.limit
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+type authFilesPage struct {
+	Files      []map[string]any `json:"files"`
+	Total      int              `json:"total"`
+	Filters    map[string]any   `json:"filters"`
+	NextCursor string           `json:"next_cursor"`
+}
+
+func TestListAuthFiles_FiltersAndPages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	register := func(auth *coreauth.Auth) {
+		t.Helper()
+		path := filepath.Join(authDir, auth.ID)
+		if err := os.WriteFile(path, []byte(`{"type":"`+auth.Provider+`"}`), 0o600); err != nil {
+			t.Fatalf("write auth file: %v", err)
+		}
+		auth.FileName = auth.ID
+		auth.Attributes = map[string]string{"path": path}
+		if auth.Metadata == nil {
+			auth.Metadata = map[string]any{"type": auth.Provider}
+		}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+	verified := func(at string) map[string]any {
+		return map[string]any{"type": "codex", coreauth.MetadataLastVerifiedAt: at, coreauth.MetadataLastVerifiedOutcome: coreauth.OutcomeValid}
+	}
+	register(&coreauth.Auth{ID: "b.json", Provider: "codex", Status: coreauth.StatusActive, Metadata: verified("2026-01-02T00:00:00Z")})
+	register(&coreauth.Auth{ID: "d.json", Provider: "codex", Status: coreauth.StatusError, Unavailable: true})
+	register(&coreauth.Auth{ID: "f.json", Provider: "claude", Status: coreauth.StatusActive, Metadata: verified("2026-01-03T00:00:00Z")})
+	register(&coreauth.Auth{ID: "h.json", Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{"type": "codex", coreauth.MetadataTokenInvalid: true}})
+	register(&coreauth.Auth{ID: "j.json", Provider: "gemini-cli", Status: coreauth.StatusActive, Metadata: verified("2026-01-01T00:00:00Z")})
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager}
+	list := func(query string) (int, authFilesPage) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-files?"+query, nil)
+		h.ListAuthFiles(c)
+		var page authFilesPage
+		_ = json.Unmarshal(rec.Body.Bytes(), &page)
+		return rec.Code, page
+	}
+	names := func(page authFilesPage) string {
+		out := make([]string, 0, len(page.Files))
+		for _, file := range page.Files {
+			out = append(out, file["name"].(string))
+		}
+		return strings.Join(out, ",")
+	}
+
+	for query, want := range map[string]string{
+		"provider=codex":               "b.json,d.json,h.json",
+		"provider=gemini":              "j.json",
+		"status=error":                 "d.json",
+		"unavailable=true":             "d.json",
+		"invalid=true":                 "h.json",
+		"invalid=false&provider=codex": "b.json,d.json",
+		"name_like=F":                  "f.json",
+		"sort=last_verified_at":        "f.json,b.json,j.json,d.json,h.json",
+		"sort=provider&order=desc":     "j.json,b.json,d.json,h.json,f.json",
+	} {
+		code, page := list(query)
+		if code != http.StatusOK || names(page) != want || page.Total != len(page.Files) {
+			t.Errorf("%s: status %d files %s total %d, want %s", query, code, names(page), page.Total, want)
+		}
+	}
+	if _, page := list("provider=codex&status=active&limit=10"); page.Filters["sort"] != "name" || page.Filters["limit"] != float64(10) || len(page.Filters["provider"].([]any)) != 1 {
+		t.Fatalf("filters = %v", page.Filters)
+	}
+	for _, query := range []string{"sort=size", "order=up", "limit=0", "limit=1001", "invalid=maybe", "cursor=not-a-cursor"} {
+		if code, _ := list(query); code != http.StatusBadRequest {
+			t.Errorf("%s: status %d", query, code)
+		}
+	}
+
+	// Pages follow on from one another even when auths arrive in between.
+	code, page := list("limit=2")
+	if code != http.StatusOK || names(page) != "b.json,d.json" || page.Total != 5 || page.NextCursor == "" {
+		t.Fatalf("first page: status %d files %s total %d cursor %q", code, names(page), page.Total, page.NextCursor)
+	}
+	register(&coreauth.Auth{ID: "a.json", Provider: "codex", Status: coreauth.StatusActive})
+	register(&coreauth.Auth{ID: "e.json", Provider: "codex", Status: coreauth.StatusActive})
+	if _, page = list("limit=2&cursor=" + page.NextCursor); names(page) != "e.json,f.json" || page.Total != 7 {
+		t.Fatalf("second page: files %s total %d", names(page), page.Total)
+	}
+	if _, page = list("limit=2&cursor=" + page.NextCursor); names(page) != "h.json,j.json" || page.NextCursor != "" {
+		t.Fatalf("last page: files %s cursor %q", names(page), page.NextCursor)
+	}
+	if code, _ = list("sort=provider&cursor=" + page.NextCursor); code != http.StatusOK {
+		t.Fatalf("empty cursor: status %d", code)
+	}
+	_, page = list("limit=2")
+	if code, _ = list("sort=created&cursor=" + page.NextCursor); code != http.StatusBadRequest {
+		t.Fatalf("cursor of another sort: status %d", code)
+	}
+
+	// Created times order newest first by default.
+	auth, _ := manager.GetByID("a.json")
+	auth.CreatedAt = time.Now().Add(time.Hour)
+	if _, err := manager.Update(context.Background(), auth); err != nil {
+		t.Fatalf("update auth: %v", err)
+	}
+	if _, page = list("sort=created&limit=1"); names(page) != "a.json" {
+		t.Fatalf("newest = %s", names(page))
+	}
+}
@@ -0,0 +1,77 @@
+package management
+
+import (
+	"strings"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// authSearchField is an identity field of an auth that the q parameter of
+// the auth files list matches against.
+type authSearchField struct {
+	name  string // reported as matched_field
+	value func(auth *coreauth.Auth) string
+}
+
+// authSearchFields declares, per provider, the identity fields q searches.
+// Tokens, keys and other secrets are never listed here, so they can neither
+// be searched nor echoed back. Providers without an entry search their email.
+var authSearchFields = map[string][]authSearchField{
+	"codex": {
+		metadataSearchField("chatgpt_account_id"),
+		metadataSearchField("account_id"),
+		{name: "id_token.chatgpt_account_id", value: func(auth *coreauth.Auth) string {
+			accountID, _ := extractCodexIDTokenClaims(auth)["chatgpt_account_id"].(string)
+			return accountID
+		}},
+		metadataSearchField("email"),
+	},
+	"gemini-cli":  {metadataSearchField("email"), metadataSearchField("project_id")},
+	"gemini":      {metadataSearchField("email"), metadataSearchField("project_id")},
+	"antigravity": {metadataSearchField("email"), metadataSearchField("project_id")},
+	"vertex":      {metadataSearchField("project_id"), metadataSearchField("client_email"), metadataSearchField("email")},
+	"claude": {
+		metadataSearchField("email"),
+		metadataSearchField("organization.name"),
+		metadataSearchField("organization.uuid"),
+		metadataSearchField("organization"),
+	},
+}
+
+var defaultAuthSearchFields = []authSearchField{metadataSearchField("email")}
+
+// metadataSearchField reads the string at path, dot-separated for nested
+// objects, in the auth's metadata.
+func metadataSearchField(path string) authSearchField {
+	keys := strings.Split(path, ".")
+	return authSearchField{name: path, value: func(auth *coreauth.Auth) string {
+		var value any = auth.Metadata
+		for _, key := range keys {
+			object, ok := value.(map[string]any)
+			if !ok {
+				return ""
+			}
+			value = object[key]
+		}
+		text, _ := value.(string)
+		return text
+	}}
+}
+
+// matchAuthIdentity returns the first identity field of auth containing
+// needle, which must already be lower case.
+func matchAuthIdentity(auth *coreauth.Auth, needle string) (string, bool) {
+	if auth == nil || needle == "" {
+		return "", false
+	}
+	fields, ok := authSearchFields[strings.ToLower(strings.TrimSpace(auth.Provider))]
+	if !ok {
+		fields = defaultAuthSearchFields
+	}
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(strings.TrimSpace(field.value(auth))), needle) {
+			return field.name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,80 @@
+package management
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestListAuthFiles_IdentitySearch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"https://api.openai.com/auth":{"chatgpt_account_id":"acct-FROM-TOKEN"}}`))
+	for _, auth := range []*coreauth.Auth{
+		{ID: "codex-1.json", Provider: "codex", Metadata: map[string]any{"type": "codex", "email": "alice@example.com", "chatgpt_account_id": "acct-123", "access_token": "secret-acct-999"}},
+		{ID: "codex-2.json", Provider: "codex", Metadata: map[string]any{"type": "codex", "id_token": "header." + claims + ".signature"}},
+		{ID: "gemini-1.json", Provider: "gemini-cli", Metadata: map[string]any{"type": "gemini", "email": "bob@gmail.com", "project_id": "acct-project"}},
+		{ID: "claude-1.json", Provider: "claude", Metadata: map[string]any{"type": "claude", "organization": map[string]any{"name": "Acme Corp", "uuid": "org-1"}}},
+	} {
+		path := filepath.Join(authDir, auth.ID)
+		if err := os.WriteFile(path, []byte(`{"type":"codex"}`), 0o600); err != nil {
+			t.Fatalf("write auth file: %v", err)
+		}
+		auth.FileName = auth.ID
+		auth.Attributes = map[string]string{"path": path}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager}
+	search := func(q string) map[string]string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-files?q="+q, nil)
+		h.ListAuthFiles(c)
+		if strings.Contains(rec.Body.String(), "secret-acct") {
+			t.Fatalf("secret echoed: %s", rec.Body.String())
+		}
+		var page authFilesPage
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("q=%s: status %d body=%s", q, rec.Code, rec.Body.String())
+		}
+		hits := make(map[string]string, len(page.Files))
+		for _, file := range page.Files {
+			hits[file["name"].(string)], _ = file["matched_field"].(string)
+		}
+		return hits
+	}
+
+	for q, want := range map[string]map[string]string{
+		"ACCT-123":        {"codex-1.json": "chatgpt_account_id"},
+		"acct-from-token": {"codex-2.json": "id_token.chatgpt_account_id"},
+		"acct":            {"codex-1.json": "chatgpt_account_id", "codex-2.json": "id_token.chatgpt_account_id", "gemini-1.json": "project_id"},
+		"gmail":           {"gemini-1.json": "email"},
+		"acme":            {"claude-1.json": "organization.name"},
+		"secret":          {},
+	} {
+		hits := search(q)
+		if len(hits) != len(want) {
+			t.Errorf("q=%s: hits %v, want %v", q, hits, want)
+			continue
+		}
+		for name, field := range want {
+			if hits[name] != field {
+				t.Errorf("q=%s: %s matched %q, want %q", q, name, hits[name], field)
+			}
+		}
+	}
+}
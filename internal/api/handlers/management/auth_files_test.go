@@ -8,7 +8,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
@@ -231,6 +233,120 @@ func TestDeleteAuthFile_InvalidOnly(t *testing.T) {
 	}
 }
 
+// registerMixedProviderAuths registers a failed and an invalid auth for both
+// codex and gemini, plus a healthy codex one, and returns their paths by ID.
+func registerMixedProviderAuths(t *testing.T, manager *coreauth.Manager, authDir string) map[string]string {
+	t.Helper()
+	paths := make(map[string]string)
+	for _, auth := range []*coreauth.Auth{
+		{ID: "codex-failed.json", Provider: "codex", Status: coreauth.StatusError, Unavailable: true},
+		{ID: "codex-invalid.json", Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{"type": "codex", tokenInvalidMetaKey: true}},
+		{ID: "codex-healthy.json", Provider: "codex", Status: coreauth.StatusActive},
+		{ID: "gemini-failed.json", Provider: "Gemini", Status: coreauth.StatusError, Unavailable: true},
+		{ID: "gemini-invalid.json", Provider: "gemini", Status: coreauth.StatusActive, Metadata: map[string]any{"type": "gemini", tokenInvalidMetaKey: true}},
+	} {
+		path := filepath.Join(authDir, auth.ID)
+		if err := os.WriteFile(path, []byte(`{"type":"`+strings.ToLower(auth.Provider)+`"}`), 0o600); err != nil {
+			t.Fatalf("write auth file: %v", err)
+		}
+		auth.FileName = auth.ID
+		auth.Attributes = map[string]string{"path": path}
+		if auth.Metadata == nil {
+			auth.Metadata = map[string]any{"type": strings.ToLower(auth.Provider)}
+		}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+		paths[auth.ID] = path
+	}
+	return paths
+}
+
+func deleteAuthFilesQuery(t *testing.T, h *Handler, query string) map[string]any {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(http.MethodDelete, "/v0/management/auth-files?"+query, nil)
+	h.DeleteAuthFile(ctx)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp
+}
+
+func TestDeleteAuthFile_FailedOnlyByProvider(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	paths := registerMixedProviderAuths(t, manager, authDir)
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager, tokenStore: store}
+
+	// Invalid auths count as failed too.
+	resp := deleteAuthFilesQuery(t, h, "failed=true&provider=CODEX")
+	if resp["deleted"] != float64(2) || resp["matched"] != float64(2) || resp["by_provider"] != nil {
+		t.Fatalf("codex failed response = %v", resp)
+	}
+	for _, id := range []string{"codex-failed.json", "codex-invalid.json"} {
+		if _, err := os.Stat(paths[id]); !os.IsNotExist(err) {
+			t.Fatalf("expected %s removed, err=%v", id, err)
+		}
+	}
+	for _, id := range []string{"codex-healthy.json", "gemini-failed.json", "gemini-invalid.json"} {
+		if _, err := os.Stat(paths[id]); err != nil {
+			t.Fatalf("expected %s retained, err=%v", id, err)
+		}
+	}
+
+	// Provider intersects with tag: no gemini auth carries the tag.
+	resp = deleteAuthFilesQuery(t, h, "failed=true&provider=gemini&tag=support")
+	if resp["matched"] != float64(0) {
+		t.Fatalf("provider+tag response = %v", resp)
+	}
+
+	resp = deleteAuthFilesQuery(t, h, "failed=true")
+	byProvider, _ := resp["by_provider"].(map[string]any)
+	if gemini, _ := byProvider["gemini"].(map[string]any); resp["deleted"] != float64(2) || gemini["matched"] != float64(2) || gemini["deleted"] != float64(2) {
+		t.Fatalf("all providers failed response = %v", resp)
+	}
+}
+
+func TestDeleteAuthFile_InvalidOnlyByProvider(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	paths := registerMixedProviderAuths(t, manager, authDir)
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager, tokenStore: store}
+
+	resp := deleteAuthFilesQuery(t, h, "invalid=true&provider=codex")
+	if resp["deleted"] != float64(1) || resp["matched"] != float64(1) || resp["by_provider"] != nil {
+		t.Fatalf("codex invalid response = %v", resp)
+	}
+	if _, err := os.Stat(paths["codex-invalid.json"]); !os.IsNotExist(err) {
+		t.Fatalf("expected codex invalid file removed, err=%v", err)
+	}
+	if _, err := os.Stat(paths["gemini-invalid.json"]); err != nil {
+		t.Fatalf("expected gemini invalid file retained, err=%v", err)
+	}
+	if deleted, matched, err := h.deleteInvalidAuthFilesInternal(context.Background(), "codex"); err != nil || deleted != 0 || matched != 0 {
+		t.Fatalf("internal codex delete = %d/%d, %v", deleted, matched, err)
+	}
+
+	resp = deleteAuthFilesQuery(t, h, "invalid=true")
+	byProvider, _ := resp["by_provider"].(map[string]any)
+	if gemini, _ := byProvider["gemini"].(map[string]any); resp["deleted"] != float64(1) || gemini["deleted"] != float64(1) || byProvider["codex"] != nil {
+		t.Fatalf("all providers invalid response = %v", resp)
+	}
+	if _, err := os.Stat(paths["gemini-invalid.json"]); !os.IsNotExist(err) {
+		t.Fatalf("expected gemini invalid file removed, err=%v", err)
+	}
+}
+
 func TestVerifyInvalidAuthFiles_CodexMarks401AsInvalid(t *testing.T) {
 	t.Helper()
 	gin.SetMode(gin.TestMode)
@@ -307,8 +423,8 @@ func TestVerifyInvalidAuthFiles_CodexMarks401AsInvalid(t *testing.T) {
 	if !invalid {
 		t.Fatalf("expected auth marked invalid")
 	}
-	if !strings.Contains(reason, "401") {
-		t.Fatalf("expected reason contains 401, got %q", reason)
+	if !strings.HasPrefix(reason, "usage probe 401") {
+		t.Fatalf("expected reason to start with usage probe 401, got %q", reason)
 	}
 }
 
@@ -398,6 +514,193 @@ func TestVerifyInvalidAuthFiles_CodexClearsInvalidOn2xx(t *testing.T) {
 	}
 }
 
+func TestVerifyInvalidAuthFiles_CodexReactivatesFailedAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	originalProbeURL := codexUsageProbeURL
+	codexUsageProbeURL = srv.URL
+	t.Cleanup(func() {
+		codexUsageProbeURL = originalProbeURL
+	})
+
+	// The runtime marked the auth failed and put one of its models in cooldown.
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	auth := &coreauth.Auth{
+		ID:             "codex-failed.json",
+		FileName:       "codex-failed.json",
+		Provider:       "codex",
+		Status:         coreauth.StatusError,
+		StatusMessage:  "unauthorized",
+		Unavailable:    true,
+		NextRetryAfter: time.Now().Add(time.Hour),
+		Quota:          coreauth.QuotaState{Exceeded: true, Reason: "quota"},
+		ModelStates: map[string]*coreauth.ModelState{
+			"gpt-5": {Status: coreauth.StatusError, Unavailable: true, NextRetryAfter: time.Now().Add(time.Hour)},
+		},
+		Metadata: map[string]any{
+			"type":         "codex",
+			"access_token": "ok-token",
+			"expired":      "2099-01-01T00:00:00Z",
+			"account_id":   "acct-failed",
+		},
+	}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	h := &Handler{cfg: &config.Config{AuthDir: t.TempDir()}, authManager: manager, tokenStore: store}
+
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex", nil)
+	h.VerifyInvalidAuthFiles(ctx)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp["valid"] != float64(1) || resp["recovered"] != float64(1) {
+		t.Fatalf("counts = valid %v recovered %v", resp["valid"], resp["recovered"])
+	}
+
+	for _, got := range []*coreauth.Auth{mustGetAuth(t, manager, auth.ID), store.items[auth.ID]} {
+		state := got.ModelStates["gpt-5"]
+		if got.Status != coreauth.StatusActive || got.Unavailable || got.Quota.Exceeded || !got.NextRetryAfter.IsZero() || state == nil || state.Unavailable || state.Status != coreauth.StatusActive {
+			t.Fatalf("auth not reactivated: status %s unavailable %v quota %+v model %+v", got.Status, got.Unavailable, got.Quota, state)
+		}
+	}
+
+	// A second verify finds nothing left to recover.
+	rec = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex", nil)
+	h.VerifyInvalidAuthFiles(ctx)
+	if !strings.Contains(rec.Body.String(), `"recovered":0`) {
+		t.Fatalf("second verify = %s", rec.Body.String())
+	}
+}
+
+func mustGetAuth(t *testing.T, manager *coreauth.Manager, id string) *coreauth.Auth {
+	t.Helper()
+	auth, ok := manager.GetByID(id)
+	if !ok {
+		t.Fatalf("auth %s missing", id)
+	}
+	return auth
+}
+
+func TestVerifyInvalidAuthFiles_CodexRetriesTransientFailures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	originalBackoff := codexUsageProbeBackoff
+	codexUsageProbeBackoff = time.Millisecond
+	t.Cleanup(func() {
+		codexUsageProbeBackoff = originalBackoff
+	})
+
+	// Each auth's account id selects the probe server's replies.
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	replies := map[string][]int{
+		"acct-flaky":   {http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+		"acct-down":    {http.StatusServiceUnavailable},
+		"acct-403":     {http.StatusForbidden},
+		"acct-payment": {http.StatusPaymentRequired},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		account := r.Header.Get("Chatgpt-Account-Id")
+		mu.Lock()
+		seq := replies[account]
+		status := seq[min(calls[account], len(seq)-1)]
+		calls[account]++
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	originalProbeURL, originalFallbackURL := codexUsageProbeURL, codexModelsProbeURL
+	codexUsageProbeURL = srv.URL
+	// Without a fallback target a lasting 5xx reports the auth throttled.
+	codexModelsProbeURL = ""
+	t.Cleanup(func() {
+		codexUsageProbeURL, codexModelsProbeURL = originalProbeURL, originalFallbackURL
+	})
+
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	for account := range replies {
+		metadata := map[string]any{
+			"type":         "codex",
+			"access_token": "ok-token",
+			"expired":      "2099-01-01T00:00:00Z",
+			"account_id":   account,
+		}
+		if account == "acct-down" {
+			metadata[tokenInvalidMetaKey] = true
+			metadata[tokenInvalidReasonKey] = "old reason"
+		}
+		auth := &coreauth.Auth{ID: account + ".json", FileName: account + ".json", Provider: "codex", Status: coreauth.StatusActive, Metadata: metadata}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+	cfg := &config.Config{AuthDir: t.TempDir()}
+	cfg.AuthInspection.InvalidStatusCodes = []int{http.StatusPaymentRequired}
+	h := &Handler{cfg: cfg, authManager: manager, tokenStore: store}
+
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex", nil)
+	h.VerifyInvalidAuthFiles(ctx)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp["valid"] != float64(1) || resp["invalid"] != float64(2) || resp["errors"] != float64(0) || resp["throttled"] != float64(1) {
+		t.Fatalf("counts = valid %v invalid %v errors %v throttled %v", resp["valid"], resp["invalid"], resp["errors"], resp["throttled"])
+	}
+	if calls["acct-flaky"] != 3 || calls["acct-down"] != 1+codexUsageProbeRetries || calls["acct-403"] != 1 {
+		t.Fatalf("probe calls = %v", calls)
+	}
+
+	for account, want := range map[string]bool{"acct-flaky": false, "acct-403": true, "acct-payment": true} {
+		auth, _ := manager.GetByID(account + ".json")
+		if invalid, _ := tokenInvalidState(auth); invalid != want {
+			t.Fatalf("%s invalid = %v, want %v", account, invalid, want)
+		}
+	}
+	down, _ := manager.GetByID("acct-down.json")
+	if invalid, reason := tokenInvalidState(down); !invalid || reason != "old reason" {
+		t.Fatalf("persistent 5xx changed the auth state: %v %q", invalid, reason)
+	}
+
+	// Each row reports the final status, the mapped outcome and the latency.
+	rows, _ := resp["results"].([]any)
+	want := map[string][2]any{
+		"acct-flaky.json":   {"valid", float64(http.StatusOK)},
+		"acct-down.json":    {"throttled", float64(http.StatusServiceUnavailable)},
+		"acct-403.json":     {"invalid", float64(http.StatusForbidden)},
+		"acct-payment.json": {"invalid", float64(http.StatusPaymentRequired)},
+	}
+	for _, raw := range rows {
+		row, _ := raw.(map[string]any)
+		w := want[row["name"].(string)]
+		if _, ok := row["latency_ms"].(float64); !ok || row["outcome"] != w[0] || row["status_code"] != w[1] {
+			t.Fatalf("row = %v, want %v", row, w)
+		}
+		delete(want, row["name"].(string))
+	}
+	if len(want) != 0 {
+		t.Fatalf("missing rows: %v", want)
+	}
+}
+
 func TestVerifyInvalidAuthFiles_CodexBatchCursor(t *testing.T) {
 	t.Helper()
 	gin.SetMode(gin.TestMode)
@@ -483,3 +786,171 @@ func TestVerifyInvalidAuthFiles_CodexBatchCursor(t *testing.T) {
 		t.Fatalf("expected done=true in round2")
 	}
 }
+
+func TestParseVerifyInvalidParams(t *testing.T) {
+	for _, tc := range []struct {
+		name                                   string
+		concurrency, batchSize, cursor         string
+		wantConcurrency, wantBatch, wantCursor int
+		wantErr                                bool
+	}{
+		{name: "defaults", wantConcurrency: 10, wantBatch: 100},
+		{name: "in range", concurrency: "5", batchSize: "20", cursor: "40", wantConcurrency: 5, wantBatch: 20, wantCursor: 40},
+		{name: "clamped high", concurrency: "100000", batchSize: "5000", wantConcurrency: 100, wantBatch: 1000},
+		{name: "clamped low", concurrency: "0", batchSize: "0", wantConcurrency: 1, wantBatch: 1},
+		{name: "negative counts", concurrency: "-3", batchSize: "-1", wantConcurrency: 1, wantBatch: 1},
+		{name: "non-numeric counts", concurrency: "lots", batchSize: "1e3", wantConcurrency: 10, wantBatch: 100},
+		{name: "padded", concurrency: " 7 ", batchSize: " 30 ", cursor: " 2 ", wantConcurrency: 7, wantBatch: 30, wantCursor: 2},
+		{name: "negative cursor", cursor: "-1", wantErr: true},
+		{name: "non-numeric cursor", cursor: "next", wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			concurrency, batchSize, cursor, err := parseVerifyInvalidParams(tc.concurrency, tc.batchSize, tc.cursor)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %d/%d/%d", concurrency, batchSize, cursor)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if concurrency != tc.wantConcurrency || batchSize != tc.wantBatch || cursor != tc.wantCursor {
+				t.Fatalf("got %d/%d/%d, want %d/%d/%d", concurrency, batchSize, cursor, tc.wantConcurrency, tc.wantBatch, tc.wantCursor)
+			}
+		})
+	}
+}
+
+func TestVerifyInvalidAuthFiles_EchoesClampedParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{cfg: &config.Config{AuthDir: t.TempDir()}, authManager: coreauth.NewManager(&memoryAuthStore{}, nil, nil)}
+	call := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex&"+query, nil)
+		h.VerifyInvalidAuthFiles(c)
+		return rec
+	}
+
+	if rec := call("cursor=-5"); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "cursor") {
+		t.Fatalf("negative cursor: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	rec := call("concurrency=100000&batch_size=0")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Concurrency int `json:"concurrency"`
+		BatchSize   int `json:"batch_size"`
+		Cursor      int `json:"cursor"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Concurrency != 100 || resp.BatchSize != 1 || resp.Cursor != 0 {
+		t.Fatalf("effective params = %+v", resp)
+	}
+}
+
+func TestListAuthFiles_AccountLikeFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	for _, auth := range []*coreauth.Auth{
+		{ID: "codex-17.json", FileName: "codex-17.json", Provider: "codex", Metadata: map[string]any{"type": "codex", "email": "alice@example.com", coreauth.MetadataAccountPlan: "plus"}},
+		{ID: "codex-18.json", FileName: "codex-18.json", Provider: "codex", Metadata: map[string]any{"type": "codex", "email": "bob@example.com", coreauth.MetadataAccountPlan: "team"}},
+	} {
+		path := filepath.Join(authDir, auth.FileName)
+		if err := os.WriteFile(path, []byte(`{"type":"codex"}`), 0o600); err != nil {
+			t.Fatalf("write auth file: %v", err)
+		}
+		auth.Attributes = map[string]string{"path": path}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager}
+
+	list := func(query string) []map[string]any {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-files?"+query, nil)
+		h.ListAuthFiles(c)
+		var body struct {
+			Files []map[string]any `json:"files"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode list: %v", err)
+		}
+		return body.Files
+	}
+
+	files := list("account_like=ALICE")
+	if len(files) != 1 || files[0]["name"] != "codex-17.json" || files[0]["account_email"] != "alice@example.com" || files[0]["account_plan"] != "plus" {
+		t.Fatalf("unexpected filter by email: %v", files)
+	}
+	if files = list("account_like=team"); len(files) != 1 || files[0]["name"] != "codex-18.json" {
+		t.Fatalf("unexpected filter by plan: %v", files)
+	}
+	if files = list(""); len(files) != 2 {
+		t.Fatalf("expected all files without a filter, got %v", files)
+	}
+}
+
+func TestListAuthFiles_SortByLatency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	for _, auth := range []*coreauth.Auth{
+		{ID: "a-fast.json", FileName: "a-fast.json", Provider: "codex", Metadata: map[string]any{"type": "codex", coreauth.MetadataProbeLatencyMs: int64(200), coreauth.MetadataProbeLatencyAvgMs: int64(250)}},
+		{ID: "b-unprobed.json", FileName: "b-unprobed.json", Provider: "codex", Metadata: map[string]any{"type": "codex"}},
+		{ID: "c-slow.json", FileName: "c-slow.json", Provider: "codex", Metadata: map[string]any{"type": "codex", coreauth.MetadataProbeLatencyMs: float64(4000), coreauth.MetadataProbeLatencyAvgMs: float64(6500)}},
+	} {
+		path := filepath.Join(authDir, auth.FileName)
+		if err := os.WriteFile(path, []byte(`{"type":"codex"}`), 0o600); err != nil {
+			t.Fatalf("write auth file: %v", err)
+		}
+		auth.Attributes = map[string]string{"path": path}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager}
+
+	list := func(query string) (int, []map[string]any) {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-files?"+query, nil)
+		h.ListAuthFiles(c)
+		var body struct {
+			Files []map[string]any `json:"files"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body.Files
+	}
+
+	code, files := list("sort=latency")
+	if code != http.StatusOK || len(files) != 3 {
+		t.Fatalf("status %d files %v", code, files)
+	}
+	for i, want := range []string{"c-slow.json", "a-fast.json", "b-unprobed.json"} {
+		if files[i]["name"] != want {
+			t.Fatalf("position %d = %v, want %s", i, files[i]["name"], want)
+		}
+	}
+	if files[0]["last_probe_latency_ms"] != float64(4000) || files[0]["probe_latency_avg_ms"] != float64(6500) {
+		t.Fatalf("slow entry = %v", files[0])
+	}
+	if _, ok := files[2]["probe_latency_avg_ms"]; ok {
+		t.Fatalf("unprobed entry reports a latency: %v", files[2])
+	}
+	if _, files = list(""); files[0]["name"] != "a-fast.json" {
+		t.Fatalf("default order = %v", files)
+	}
+	if code, _ = list("sort=size"); code != http.StatusBadRequest {
+		t.Fatalf("unknown sort: status %d", code)
+	}
+}
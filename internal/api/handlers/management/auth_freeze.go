@@ -0,0 +1,100 @@
+package management
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// findAuthByNameOrID returns the auth registered under name, or the first
+// auth whose file name is name.
+func (h *Handler) findAuthByNameOrID(name string) *coreauth.Auth {
+	if auth, ok := h.authManager.GetByID(name); ok {
+		return auth
+	}
+	for _, auth := range h.authManager.List() {
+		if auth.FileName == name {
+			return auth
+		}
+	}
+	return nil
+}
+
+// FreezeAuthFile freezes the auth named by :id, optionally until the RFC 3339
+// time given as "until" in the JSON body or query. A frozen auth stays listed
+// but is not routed to, refreshed, probed or deleted automatically.
+func (h *Handler) FreezeAuthFile(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	var req struct {
+		Until string `json:"until"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	rawUntil := strings.TrimSpace(req.Until)
+	if rawUntil == "" {
+		rawUntil = strings.TrimSpace(c.Query("until"))
+	}
+	var until time.Time
+	if rawUntil != "" {
+		parsed, err := time.Parse(time.RFC3339, rawUntil)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "until must be an RFC 3339 timestamp"})
+			return
+		}
+		if !parsed.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "until must be in the future"})
+			return
+		}
+		until = parsed
+	}
+
+	target := h.findAuthByNameOrID(strings.TrimSpace(c.Param("id")))
+	if target == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+		return
+	}
+	auth, err := h.authManager.Freeze(c.Request.Context(), target.ID, until)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to freeze auth: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, freezePayload(auth))
+}
+
+// UnfreezeAuthFile lifts the freeze of the auth named by :id.
+func (h *Handler) UnfreezeAuthFile(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	target := h.findAuthByNameOrID(strings.TrimSpace(c.Param("id")))
+	if target == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+		return
+	}
+	auth, err := h.authManager.Unfreeze(c.Request.Context(), target.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unfreeze auth: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, freezePayload(auth))
+}
+
+func freezePayload(auth *coreauth.Auth) gin.H {
+	frozen, until := coreauth.FrozenState(auth, time.Now())
+	payload := gin.H{"status": "ok", "id": auth.ID, "frozen": frozen}
+	if frozen && !until.IsZero() {
+		payload["frozen_until"] = until
+	}
+	return payload
+}
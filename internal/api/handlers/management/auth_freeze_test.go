@@ -0,0 +1,81 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func callFreezeEndpoint(handler gin.HandlerFunc, id, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Params = gin.Params{{Key: "id", Value: id}}
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/"+id+"/freeze", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	handler(c)
+	return rec
+}
+
+func TestFreezeAuthFile_SkipsInspectionAndShowsInListing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, authDir, "codex", 3)
+	var probed []string
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		probed = append(probed, auth.ID)
+		return true, "401 revoked", nil
+	}))
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex", Concurrency: 1}}
+	cfg.AuthInspection.AutoDeleteInvalid = true
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+
+	if rec := callFreezeEndpoint(h.FreezeAuthFile, "missing.json", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing auth: status %d", rec.Code)
+	}
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	if rec := callFreezeEndpoint(h.FreezeAuthFile, "codex-00.json", `{"until":"`+past+`"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("past until: status %d", rec.Code)
+	}
+	until := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	if rec := callFreezeEndpoint(h.FreezeAuthFile, "codex-00.json", `{"until":"`+until+`"}`); rec.Code != http.StatusOK {
+		t.Fatalf("freeze: status %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	auth, _ := manager.GetByID("codex-00.json")
+	entry := h.buildAuthFileEntry(auth)
+	if entry["frozen"] != true || entry["status"] != "frozen" || entry["frozen_until"] == nil {
+		t.Fatalf("listing entry = %+v", entry)
+	}
+
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+	payload := h.authInspectionStatusPayload()
+	if payload["frozen"] != 1 || payload["checked"] != 2 {
+		t.Fatalf("status payload = %+v", payload)
+	}
+	for _, id := range probed {
+		if id == "codex-00.json" {
+			t.Fatal("frozen auth was probed")
+		}
+	}
+	if _, ok := manager.GetByID("codex-00.json"); !ok || h.findAuthByNameOrID("codex-00.json").Disabled {
+		t.Fatal("frozen auth was auto-deleted")
+	}
+
+	if rec := callFreezeEndpoint(h.UnfreezeAuthFile, "codex-00.json", ""); rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), `"frozen":true`) {
+		t.Fatalf("unfreeze: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if auth, _ := manager.GetByID("codex-00.json"); coreauth.IsFrozen(auth) {
+		t.Fatal("auth still frozen after unfreeze")
+	}
+}
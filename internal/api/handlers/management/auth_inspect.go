@@ -0,0 +1,120 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// AuthInspectionResult is the verification outcome for one auth file.
+type AuthInspectionResult = coreauth.VerifyResult
+
+// AuthInspectionReport summarises an inspection run started outside the server.
+type AuthInspectionReport = coreauth.InspectionReport
+
+// SetInspector makes the handler verify and delete auths through inspector,
+// so its probes and event subscribers also apply to management runs. The
+// built-in probes are added for providers the inspector does not cover yet.
+// inspector must operate on the handler's auth manager.
+func (h *Handler) SetInspector(inspector *coreauth.Inspector) {
+	if h == nil || inspector == nil {
+		return
+	}
+	h.registerBuiltinProbes(inspector)
+	h.inspectorMu.Lock()
+	h.inspector = inspector
+	h.inspectorMu.Unlock()
+}
+
+// authInspector returns the inspector set by SetInspector, or one over the
+// handler's auth manager with only the built-in probes.
+func (h *Handler) authInspector() *coreauth.Inspector {
+	h.inspectorMu.Lock()
+	defer h.inspectorMu.Unlock()
+	if h.inspector == nil {
+		h.inspector = coreauth.NewInspector(h.authManager)
+		h.registerBuiltinProbes(h.inspector)
+	}
+	return h.inspector
+}
+
+// registerBuiltinProbes adds the codex usage probe, the claude, qwen and
+// iflow models probes, the gemini-cli code assist probe and the token refresh
+// probe for antigravity, keeping probes already registered.
+func (h *Handler) registerBuiltinProbes(inspector *coreauth.Inspector) {
+	refreshProbe := coreauth.ProbeFunc(func(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
+		token, err := h.resolveTokenForAuth(ctx, auth)
+		if err != nil {
+			return true, normalizeTokenInvalidReason(fmt.Sprintf("token refresh failed: %v", err)), nil
+		}
+		if strings.TrimSpace(token) == "" {
+			return true, "token is empty", nil
+		}
+		return false, "", nil
+	})
+	builtin := map[string]coreauth.Probe{
+		"codex":       coreauth.ProbeFunc(h.verifyCodexAuthToken),
+		"claude":      coreauth.ProbeFunc(h.verifyClaudeAuthToken),
+		"qwen":        coreauth.ProbeFunc(h.verifyQwenAuthToken),
+		"iflow":       coreauth.ProbeFunc(h.verifyIFlowAuthToken),
+		"gemini-cli":  coreauth.ProbeFunc(h.verifyGeminiAuthToken),
+		"antigravity": refreshProbe,
+	}
+	for provider, probe := range builtin {
+		if !inspector.HasProbe(provider) {
+			inspector.RegisterProbe(provider, probe)
+		}
+	}
+}
+
+// InspectAuthFiles runs the scheduler's verification batches once against
+// manager, using the same probes and concurrency. providerFilter "" or "all"
+// checks every supported provider. When deleteInvalid is set, auth files
+// marked invalid are removed as by the delete-invalid endpoint. The returned
+// report is populated as far as the run got, even on error.
+func InspectAuthFiles(ctx context.Context, cfg *config.Config, manager *coreauth.Manager, providerFilter string, deleteInvalid bool) (*AuthInspectionReport, error) {
+	// A bare handler: no scheduler, alert evaluator or attempt cleanup goroutines.
+	h := &Handler{cfg: cfg, authManager: manager, tokenStore: sdkAuth.GetTokenStore()}
+
+	runCtx, cancel := context.WithTimeout(ctx, time.Duration(h.effectiveAuthInspectionConfig().RunTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	if deleteInvalid {
+		h.purgeTrash()
+	}
+	report, err := h.authInspector().Run(runCtx, h.inspectionRunOptions(providerFilter, deleteInvalid))
+	return &report, err
+}
+
+// inspectionRunOptions returns the scheduler's batch settings, removing
+// invalid auth files as the delete-invalid endpoint does.
+func (h *Handler) inspectionRunOptions(providerFilter string, deleteInvalid bool) coreauth.RunOptions {
+	cfg := h.effectiveAuthInspectionConfig()
+	return coreauth.RunOptions{
+		Provider:      providerFilter,
+		Concurrency:   cfg.VerifyConcurrency,
+		BatchSize:     cfg.VerifyBatchSize,
+		MaxRounds:     authInspectionVerifyMaxRounds,
+		DeleteInvalid: deleteInvalid,
+		Delete:        h.scheduledInvalidDeleteOptions("inspection"),
+		Filter:        inspectionFilter(cfg),
+		Throttle:      h.throttleProbe,
+		MinReverify:   time.Duration(cfg.MinReverifySeconds) * time.Second,
+		Backoff:       probeBackoff(cfg),
+	}
+}
+
+// probeBackoff returns the backoff from auths whose probes keep failing with
+// non-auth errors that cfg configures.
+func probeBackoff(cfg config.AuthInspectionConfig) *coreauth.ProbeBackoff {
+	return &coreauth.ProbeBackoff{
+		Threshold: cfg.ProbeFailureThreshold,
+		Base:      time.Duration(cfg.ProbeBackoffSeconds) * time.Second,
+		Max:       maxAuthInspectionProbeBackoffSeconds * time.Second,
+	}
+}
@@ -0,0 +1,147 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// claudeModelsProbeURL lists a single model, the cheapest call an OAuth
+// token is accepted for.
+var claudeModelsProbeURL = "https://api.anthropic.com/v1/models?limit=1"
+
+const (
+	claudeProbeAPIVersion = "2023-06-01"
+	claudeProbeOAuthBeta  = "oauth-2025-04-20"
+)
+
+// verifyClaudeAuthToken refreshes a claude auth's access token when it is
+// about to expire and lists models with it. The claude error rules, by
+// default 401 and 403, mark the auth invalid with the upstream error type as
+// the reason; 2xx is valid. Network errors and 5xx are retried like the codex
+// usage probe; other responses, such as 429, keep the auth's current state.
+func (h *Handler) verifyClaudeAuthToken(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
+	if auth == nil {
+		return true, "auth is nil", nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	refreshCtx, cancelRefresh := context.WithTimeout(ctx, 20*time.Second)
+	defer cancelRefresh()
+
+	accessToken, errToken := h.refreshClaudeOAuthAccessToken(refreshCtx, auth)
+	if errToken != nil {
+		return refreshFailureOutcome(errToken)
+	}
+	if strings.TrimSpace(accessToken) == "" {
+		return true, "token is empty", nil
+	}
+
+	settings := h.probeSettings("claude")
+	statusCode, respBody, errProbe := probeWithRetry(ctx, func() (int, string, error) {
+		return h.probeClaudeModels(ctx, auth, accessToken, settings)
+	})
+	if settings.fallsBack(ctx, statusCode, errProbe) {
+		return h.fallbackProbe(ctx, "claude", "models probe", settings, statusCode, errProbe, func(fallback probeSettings) (int, string, error) {
+			return h.probeClaudeModels(ctx, auth, accessToken, fallback)
+		})
+	}
+	recordProbeResponse(ctx, statusCode, respBody)
+	if errCtx := ctx.Err(); errCtx != nil {
+		return false, "", errCtx
+	}
+	if errProbe != nil {
+		return false, "", fmt.Errorf("%w: models probe failed: %v", coreauth.ErrProbeInconclusive, errProbe)
+	}
+	if match := h.matchErrorRules("claude", statusCode, respBody); match.verdict != verdictNone {
+		return match.outcome("models probe", fmt.Sprintf("%s %s", match, claudeErrorReason(respBody)))
+	}
+	if statusCode >= http.StatusInternalServerError {
+		return false, "", fmt.Errorf("%w: models probe returned %d", coreauth.ErrProbeInconclusive, statusCode)
+	}
+	if settings.expects(statusCode) {
+		return false, "", nil
+	}
+
+	invalid, reason := tokenInvalidState(auth)
+	if invalid {
+		return true, reason, nil
+	}
+	return false, "", nil
+}
+
+func (h *Handler) probeClaudeModels(ctx context.Context, auth *coreauth.Auth, accessToken string, settings probeSettings) (int, string, error) {
+	probeCtx, cancelProbe := context.WithTimeout(ctx, settings.Timeout)
+	defer cancelProbe()
+
+	req, errReq := http.NewRequestWithContext(probeCtx, http.MethodGet, settings.URL, nil)
+	if errReq != nil {
+		return 0, "", errReq
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(accessToken))
+	req.Header.Set("Anthropic-Version", claudeProbeAPIVersion)
+	req.Header.Set("Anthropic-Beta", claudeProbeOAuthBeta)
+	if orgID := claudeMetadataValue(auth, "organization_uuid", "organization_id"); orgID != "" {
+		req.Header.Set("X-Organization-Uuid", orgID)
+	}
+	if accountID := claudeMetadataValue(auth, "account_uuid", "account_id"); accountID != "" {
+		req.Header.Set("X-Account-Uuid", accountID)
+	}
+
+	httpClient := &http.Client{
+		Timeout:   settings.Timeout,
+		Transport: h.apiCallTransport(auth),
+	}
+	resp, errDo := httpClient.Do(req)
+	if errDo != nil {
+		return 0, "", errDo
+	}
+	h.pauseProbesOnRetryAfter(resp, time.Now())
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	bodyBytes, errRead := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if errRead != nil {
+		return resp.StatusCode, "", errRead
+	}
+	return resp.StatusCode, string(bodyBytes), nil
+}
+
+// claudeMetadataValue returns the first of keys set in auth's metadata.
+func claudeMetadataValue(auth *coreauth.Auth, keys ...string) string {
+	if auth == nil {
+		return ""
+	}
+	for _, key := range keys {
+		if value := stringValue(auth.Metadata, key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// claudeErrorReason returns "<type>: <message>" from an Anthropic error body,
+// or the trimmed body when it is not one.
+func claudeErrorReason(body string) string {
+	var payload struct {
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil || payload.Error.Type == "" {
+		return strings.TrimSpace(body)
+	}
+	if payload.Error.Message == "" {
+		return payload.Error.Type
+	}
+	return payload.Error.Type + ": " + payload.Error.Message
+}
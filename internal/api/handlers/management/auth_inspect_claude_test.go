@@ -0,0 +1,112 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// verifyClaudeAuth registers auth, answers the models probe with handler and
+// runs verify-invalid for claude, returning the decoded response.
+func verifyClaudeAuth(t *testing.T, auth *coreauth.Auth, handler http.HandlerFunc) (map[string]any, *coreauth.Manager) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	originalProbeURL := claudeModelsProbeURL
+	claudeModelsProbeURL = srv.URL
+	t.Cleanup(func() { claudeModelsProbeURL = originalProbeURL })
+
+	h := &Handler{cfg: &config.Config{AuthDir: t.TempDir()}, authManager: manager}
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=claude", nil)
+	h.VerifyInvalidAuthFiles(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp, manager
+}
+
+func TestVerifyInvalidAuthFiles_ClaudeMarks401AsInvalid(t *testing.T) {
+	auth := &coreauth.Auth{
+		ID:       "claude-401.json",
+		FileName: "claude-401.json",
+		Provider: "claude",
+		Status:   coreauth.StatusActive,
+		Metadata: map[string]any{
+			"type":              "claude",
+			"access_token":      "live-token",
+			"expired":           "2099-01-01T00:00:00Z",
+			"organization_uuid": "org-401",
+		},
+	}
+	resp, manager := verifyClaudeAuth(t, auth, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer live-token" {
+			t.Errorf("unexpected authorization header: %q", got)
+		}
+		if got := r.Header.Get("X-Organization-Uuid"); got != "org-401" {
+			t.Errorf("unexpected organization header: %q", got)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"OAuth token has been revoked"}}`))
+	})
+	if got, _ := resp["total"].(float64); got != 1 {
+		t.Fatalf("expected total=1, got %v", resp["total"])
+	}
+	if got, _ := resp["invalid"].(float64); got != 1 {
+		t.Fatalf("expected invalid=1, got %v", resp["invalid"])
+	}
+
+	updated, _ := manager.GetByID(auth.ID)
+	invalid, reason := tokenInvalidState(updated)
+	if !invalid || !strings.Contains(reason, "401 authentication_error") {
+		t.Fatalf("invalid = %v, reason = %q", invalid, reason)
+	}
+}
+
+func TestVerifyInvalidAuthFiles_ClaudeClearsInvalidOn2xx(t *testing.T) {
+	auth := &coreauth.Auth{
+		ID:       "claude-200.json",
+		FileName: "claude-200.json",
+		Provider: "claude",
+		Status:   coreauth.StatusActive,
+		Metadata: map[string]any{
+			"type":                "claude",
+			"access_token":        "ok-token",
+			"expired":             "2099-01-01T00:00:00Z",
+			tokenInvalidMetaKey:   true,
+			tokenInvalidReasonKey: "old reason",
+			tokenInvalidAtKey:     "2026-02-18T00:00:00Z",
+		},
+	}
+	resp, manager := verifyClaudeAuth(t, auth, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Anthropic-Beta"); got != claudeProbeOAuthBeta {
+			t.Errorf("unexpected beta header: %q", got)
+		}
+		_, _ = w.Write([]byte(`{"data":[{"id":"claude-sonnet-4-5"}]}`))
+	})
+	if got, _ := resp["valid"].(float64); got != 1 {
+		t.Fatalf("expected valid=1, got %v", resp["valid"])
+	}
+
+	updated, _ := manager.GetByID(auth.ID)
+	if invalid, reason := tokenInvalidState(updated); invalid || reason != "" {
+		t.Fatalf("invalid = %v, reason = %q after success", invalid, reason)
+	}
+}
@@ -0,0 +1,91 @@
+package management
+
+import (
+	"strings"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+// codexUsageMetaKey holds the plan and rate-limit windows the codex probe read
+// from the last successful usage response. It is kept apart from
+// coreauth.MetadataAccountPlan, which follows the ID token.
+const codexUsageMetaKey = "codex_usage"
+
+// recordCodexUsage saves the plan, subscription status and rate-limit windows
+// of a 2xx usage response into auth's metadata; the inspector persists them
+// with the probe's outcome. Bodies that do not parse leave the metadata alone.
+func recordCodexUsage(auth *coreauth.Auth, body string, now time.Time) {
+	usage := parseCodexUsage(body, now)
+	if auth == nil || usage == nil {
+		return
+	}
+	if auth.Metadata == nil {
+		auth.Metadata = make(map[string]any)
+	}
+	auth.Metadata[codexUsageMetaKey] = usage
+}
+
+// parseCodexUsage normalizes a wham/usage body, or returns nil when it holds
+// nothing worth keeping. Reset times are RFC 3339, whether the body gives
+// them as unix seconds or seconds from now.
+func parseCodexUsage(body string, now time.Time) map[string]any {
+	if !gjson.Valid(body) {
+		return nil
+	}
+	root := gjson.Parse(body)
+	if !root.IsObject() {
+		return nil
+	}
+	usage := make(map[string]any)
+	if plan := strings.TrimSpace(root.Get("plan_type").String()); plan != "" {
+		usage["plan_type"] = plan
+	}
+	for _, path := range []string{"subscription_status", "subscription.status", "account_status"} {
+		if status := strings.TrimSpace(root.Get(path).String()); status != "" {
+			usage["subscription_status"] = status
+			break
+		}
+	}
+	rateLimit := root.Get("rate_limit")
+	if limitReached := rateLimit.Get("limit_reached"); limitReached.Exists() {
+		usage["limit_reached"] = limitReached.Bool()
+	}
+	for _, name := range []string{"primary_window", "secondary_window"} {
+		if window := parseCodexUsageWindow(rateLimit.Get(name), now); window != nil {
+			usage[name] = window
+		}
+	}
+	if len(usage) == 0 {
+		return nil
+	}
+	usage["checked_at"] = now.UTC().Format(time.RFC3339)
+	return usage
+}
+
+func parseCodexUsageWindow(window gjson.Result, now time.Time) map[string]any {
+	if !window.IsObject() {
+		return nil
+	}
+	out := make(map[string]any)
+	if used := window.Get("used_percent"); used.Exists() {
+		out["used_percent"] = used.Float()
+	}
+	if seconds := window.Get("limit_window_seconds").Int(); seconds > 0 {
+		out["window_seconds"] = seconds
+	}
+	resetsAt := time.Time{}
+	if at := window.Get("reset_at").Int(); at > 0 {
+		resetsAt = time.Unix(at, 0)
+	} else if after := window.Get("reset_after_seconds"); after.Exists() && after.Int() >= 0 {
+		resetsAt = now.Add(time.Duration(after.Int()) * time.Second)
+	}
+	if !resetsAt.IsZero() {
+		out["resets_at"] = resetsAt.UTC().Format(time.RFC3339)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestVerifyInvalidAuthFiles_CodexRecordsUsage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer pro-token":
+			_, _ = w.Write([]byte(`{"plan_type":"pro","rate_limit":{"allowed":true,"limit_reached":false,` +
+				`"primary_window":{"used_percent":12.5,"limit_window_seconds":18000,"reset_after_seconds":3600},` +
+				`"secondary_window":{"used_percent":40,"limit_window_seconds":604800,"reset_at":4102444800}}}`))
+		default:
+			_, _ = w.Write([]byte(`not json`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	originalProbeURL := codexUsageProbeURL
+	codexUsageProbeURL = srv.URL
+	t.Cleanup(func() { codexUsageProbeURL = originalProbeURL })
+
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	for id, token := range map[string]string{"codex-pro.json": "pro-token", "codex-garbled.json": "garbled-token"} {
+		auth := &coreauth.Auth{ID: id, FileName: id, Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{
+			"type":         "codex",
+			"access_token": token,
+			"expired":      "2099-01-01T00:00:00Z",
+		}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+
+	h := &Handler{cfg: &config.Config{AuthDir: t.TempDir()}, authManager: manager}
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex", nil)
+	h.VerifyInvalidAuthFiles(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	pro, _ := manager.GetByID("codex-pro.json")
+	usage, _ := pro.Metadata[codexUsageMetaKey].(map[string]any)
+	if usage == nil || usage["plan_type"] != "pro" || usage["limit_reached"] != false {
+		t.Fatalf("usage = %+v", pro.Metadata[codexUsageMetaKey])
+	}
+	primary, _ := usage["primary_window"].(map[string]any)
+	if primary == nil || primary["used_percent"] != 12.5 || primary["window_seconds"] != int64(18000) {
+		t.Fatalf("primary window = %+v", usage["primary_window"])
+	}
+	if resetsAt, err := time.Parse(time.RFC3339, primary["resets_at"].(string)); err != nil || time.Until(resetsAt) < 55*time.Minute {
+		t.Fatalf("primary resets_at = %v (%v)", primary["resets_at"], err)
+	}
+	secondary, _ := usage["secondary_window"].(map[string]any)
+	if secondary == nil || secondary["resets_at"] != "2100-01-01T00:00:00Z" {
+		t.Fatalf("secondary window = %+v", usage["secondary_window"])
+	}
+	store.mu.Lock()
+	saved := store.items["codex-pro.json"]
+	store.mu.Unlock()
+	if saved == nil || saved.Metadata[codexUsageMetaKey] == nil {
+		t.Fatalf("usage not persisted: %+v", saved)
+	}
+
+	garbled, _ := manager.GetByID("codex-garbled.json")
+	if _, ok := garbled.Metadata[codexUsageMetaKey]; ok {
+		t.Fatalf("garbled body recorded usage: %+v", garbled.Metadata[codexUsageMetaKey])
+	}
+	if invalid, _ := tokenInvalidState(garbled); invalid {
+		t.Fatalf("garbled body marked the auth invalid")
+	}
+}
@@ -0,0 +1,139 @@
+package management
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// geminiCodeAssistProbeURL is the CloudCode endpoint the gemini-cli probe
+// calls; it only reads the account's code assist setup.
+var geminiCodeAssistProbeURL = geminiCLIEndpoint + "/" + geminiCLIVersion + ":loadCodeAssist"
+
+// inspectionProviderAliases maps provider names accepted by verify-invalid to
+// the provider their auths are registered under. Gemini OAuth files are typed
+// "gemini" but served by the gemini-cli executor.
+var inspectionProviderAliases = map[string]string{
+	"gemini": "gemini-cli",
+}
+
+// verifyGeminiAuthToken refreshes a gemini-cli auth's access token and asks
+// CloudCode for the code assist setup of its project. The gemini-cli error
+// rules classify the response first: by default 401 and 403 mark the auth
+// invalid, and a 429, meaning the account works but is out of quota, is
+// ignored so the auth keeps its state. 2xx is valid. Network errors and 5xx
+// are retried like the codex usage probe.
+func (h *Handler) verifyGeminiAuthToken(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
+	if auth == nil {
+		return true, "auth is nil", nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	refreshCtx, cancelRefresh := context.WithTimeout(ctx, 20*time.Second)
+	defer cancelRefresh()
+
+	accessToken, errToken := h.refreshGeminiOAuthAccessToken(refreshCtx, auth)
+	if errToken != nil {
+		return refreshFailureOutcome(errToken)
+	}
+	if strings.TrimSpace(accessToken) == "" {
+		return true, "token is empty", nil
+	}
+
+	settings := h.probeSettings("gemini-cli")
+	statusCode, respBody, errProbe := probeWithRetry(ctx, func() (int, string, error) {
+		return h.probeGeminiCodeAssist(ctx, auth, accessToken, settings)
+	})
+	if settings.fallsBack(ctx, statusCode, errProbe) {
+		return h.fallbackProbe(ctx, "gemini-cli", "code assist probe", settings, statusCode, errProbe, func(fallback probeSettings) (int, string, error) {
+			return h.probeGeminiCodeAssist(ctx, auth, accessToken, fallback)
+		})
+	}
+	recordProbeResponse(ctx, statusCode, respBody)
+	if errCtx := ctx.Err(); errCtx != nil {
+		return false, "", errCtx
+	}
+	if errProbe != nil {
+		return false, "", fmt.Errorf("%w: code assist probe failed: %v", coreauth.ErrProbeInconclusive, errProbe)
+	}
+	if match := h.matchErrorRules("gemini-cli", statusCode, respBody); match.verdict != verdictNone {
+		return match.outcome("code assist probe", fmt.Sprintf("%s %s", match, strings.TrimSpace(respBody)))
+	}
+	switch {
+	case statusCode >= http.StatusInternalServerError:
+		return false, "", fmt.Errorf("%w: code assist probe returned %d", coreauth.ErrProbeInconclusive, statusCode)
+	case settings.expects(statusCode):
+		return false, "", nil
+	}
+
+	// Other responses keep the auth's current state.
+	invalid, reason := tokenInvalidState(auth)
+	if invalid {
+		return true, reason, nil
+	}
+	return false, "", nil
+}
+
+func (h *Handler) probeGeminiCodeAssist(ctx context.Context, auth *coreauth.Auth, accessToken string, settings probeSettings) (int, string, error) {
+	probeCtx, cancelProbe := context.WithTimeout(ctx, settings.Timeout)
+	defer cancelProbe()
+
+	body := map[string]any{
+		"metadata": map[string]string{
+			"ideType":    "IDE_UNSPECIFIED",
+			"platform":   "PLATFORM_UNSPECIFIED",
+			"pluginType": "GEMINI",
+		},
+	}
+	if projectID := geminiProjectID(auth); projectID != "" {
+		body["cloudaicompanionProject"] = projectID
+	}
+	rawBody, errMarshal := json.Marshal(body)
+	if errMarshal != nil {
+		return 0, "", errMarshal
+	}
+	req, errReq := http.NewRequestWithContext(probeCtx, http.MethodPost, settings.URL, bytes.NewReader(rawBody))
+	if errReq != nil {
+		return 0, "", errReq
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(accessToken))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", geminiCLIUserAgent)
+	req.Header.Set("X-Goog-Api-Client", geminiCLIApiClient)
+	req.Header.Set("Client-Metadata", geminiCLIClientMetadata)
+
+	httpClient := &http.Client{
+		Timeout:   settings.Timeout,
+		Transport: h.apiCallTransport(auth),
+	}
+	resp, errDo := httpClient.Do(req)
+	if errDo != nil {
+		return 0, "", errDo
+	}
+	h.pauseProbesOnRetryAfter(resp, time.Now())
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	bodyBytes, errRead := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if errRead != nil {
+		return resp.StatusCode, "", errRead
+	}
+	return resp.StatusCode, string(bodyBytes), nil
+}
+
+// geminiProjectID returns the first project of a gemini-cli auth, which may
+// list several separated by commas.
+func geminiProjectID(auth *coreauth.Auth) string {
+	metadata, _ := geminiOAuthMetadata(auth)
+	projectID, _, _ := strings.Cut(stringValue(metadata, "project_id"), ",")
+	return strings.TrimSpace(projectID)
+}
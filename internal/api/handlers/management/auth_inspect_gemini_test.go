@@ -0,0 +1,94 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestVerifyInvalidAuthFiles_Gemini(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	for _, item := range []struct {
+		id, token string
+		invalid   bool
+	}{
+		{"gemini-ok.json", "ok-token", true},
+		{"gemini-revoked.json", "revoked-token", false},
+		{"gemini-quota.json", "quota-token", false},
+	} {
+		metadata := map[string]any{
+			"type":         "gemini",
+			"access_token": item.token,
+			"expiry":       "2099-01-01T00:00:00Z",
+			"project_id":   "proj-a,proj-b",
+		}
+		if item.invalid {
+			metadata[tokenInvalidMetaKey] = true
+			metadata[tokenInvalidReasonKey] = "old reason"
+		}
+		auth := &coreauth.Auth{ID: item.id, FileName: item.id, Provider: "gemini-cli", Status: coreauth.StatusActive, Metadata: metadata}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Project string `json:"cloudaicompanionProject"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Project != "proj-a" {
+			t.Errorf("load code assist body: project %q, err %v", body.Project, err)
+		}
+		switch r.Header.Get("Authorization") {
+		case "Bearer ok-token":
+			_, _ = w.Write([]byte(`{"currentTier":{"id":"free-tier"}}`))
+		case "Bearer revoked-token":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"status":"UNAUTHENTICATED"}}`))
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	originalProbeURL := geminiCodeAssistProbeURL
+	geminiCodeAssistProbeURL = srv.URL
+	t.Cleanup(func() { geminiCodeAssistProbeURL = originalProbeURL })
+
+	h := &Handler{cfg: &config.Config{AuthDir: t.TempDir()}, authManager: manager}
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=gemini", nil)
+	h.VerifyInvalidAuthFiles(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Provider string `json:"provider"`
+		Total    int    `json:"total"`
+		Done     bool   `json:"done"`
+		Checked  int    `json:"checked"`
+		Valid    int    `json:"valid"`
+		Invalid  int    `json:"invalid"`
+		Errors   int    `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Provider != "gemini-cli" || resp.Total != 3 || !resp.Done || resp.Valid != 1 || resp.Invalid != 1 || resp.Errors != 1 {
+		t.Fatalf("response = %s", rec.Body.String())
+	}
+
+	for id, wantInvalid := range map[string]bool{"gemini-ok.json": false, "gemini-revoked.json": true, "gemini-quota.json": false} {
+		auth, _ := manager.GetByID(id)
+		if invalid, reason := tokenInvalidState(auth); invalid != wantInvalid {
+			t.Errorf("%s: invalid = %v (%q), want %v", id, invalid, reason, wantInvalid)
+		}
+	}
+}
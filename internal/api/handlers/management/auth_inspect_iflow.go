@@ -0,0 +1,121 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	iflowauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/iflow"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// iflowModelsProbeURL, when set, replaces the models URL the iflow probe
+// derives from the auth's base_url.
+var iflowModelsProbeURL = ""
+
+const iflowProbeUserAgent = "iFlow-Cli"
+
+// verifyIFlowAuthToken lists models with an iflow auth's API key. The iflow
+// error rules, by default 401, 403 and the configured invalid status codes,
+// mark the auth invalid; 2xx is valid, network errors and 5xx are retried and
+// inconclusive, and other responses keep the current state.
+func (h *Handler) verifyIFlowAuthToken(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
+	if auth == nil {
+		return true, "auth is nil", nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	apiKey, baseURL := iflowProbeCreds(auth)
+	if apiKey == "" {
+		return true, "api key is empty", nil
+	}
+
+	settings := h.probeSettings("iflow")
+	statusCode, respBody, errProbe := probeWithRetry(ctx, func() (int, string, error) {
+		return h.probeIFlowModels(ctx, auth, apiKey, baseURL, settings)
+	})
+	if settings.fallsBack(ctx, statusCode, errProbe) {
+		return h.fallbackProbe(ctx, "iflow", "models probe", settings, statusCode, errProbe, func(fallback probeSettings) (int, string, error) {
+			return h.probeIFlowModels(ctx, auth, apiKey, baseURL, fallback)
+		})
+	}
+	recordProbeResponse(ctx, statusCode, respBody)
+	if errCtx := ctx.Err(); errCtx != nil {
+		return false, "", errCtx
+	}
+	if errProbe != nil {
+		return false, "", fmt.Errorf("%w: models probe failed: %v", coreauth.ErrProbeInconclusive, errProbe)
+	}
+	if match := h.matchErrorRules("iflow", statusCode, respBody); match.verdict != verdictNone {
+		return match.outcome("models probe", fmt.Sprintf("%s %s", match, strings.TrimSpace(respBody)))
+	}
+	if statusCode >= http.StatusInternalServerError {
+		return false, "", fmt.Errorf("%w: models probe returned %d", coreauth.ErrProbeInconclusive, statusCode)
+	}
+	if settings.expects(statusCode) {
+		return false, "", nil
+	}
+
+	invalid, reason := tokenInvalidState(auth)
+	if invalid {
+		return true, reason, nil
+	}
+	return false, "", nil
+}
+
+func (h *Handler) probeIFlowModels(ctx context.Context, auth *coreauth.Auth, apiKey, baseURL string, settings probeSettings) (int, string, error) {
+	probeCtx, cancelProbe := context.WithTimeout(ctx, settings.Timeout)
+	defer cancelProbe()
+
+	probeURL := settings.URL
+	if probeURL == "" {
+		probeURL = strings.TrimSuffix(baseURL, "/") + "/models"
+	}
+	req, errReq := http.NewRequestWithContext(probeCtx, http.MethodGet, probeURL, nil)
+	if errReq != nil {
+		return 0, "", errReq
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", iflowProbeUserAgent)
+
+	httpClient := &http.Client{
+		Timeout:   settings.Timeout,
+		Transport: h.apiCallTransport(auth),
+	}
+	resp, errDo := httpClient.Do(req)
+	if errDo != nil {
+		return 0, "", errDo
+	}
+	h.pauseProbesOnRetryAfter(resp, time.Now())
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	bodyBytes, errRead := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if errRead != nil {
+		return resp.StatusCode, "", errRead
+	}
+	return resp.StatusCode, string(bodyBytes), nil
+}
+
+// iflowProbeCreds returns the API key and base URL an iflow auth is served
+// with, preferring attributes over metadata as the executor does.
+func iflowProbeCreds(auth *coreauth.Auth) (string, string) {
+	apiKey := strings.TrimSpace(auth.Attributes["api_key"])
+	if apiKey == "" {
+		apiKey = stringValue(auth.Metadata, "api_key")
+	}
+	baseURL := strings.TrimSpace(auth.Attributes["base_url"])
+	if baseURL == "" {
+		baseURL = stringValue(auth.Metadata, "base_url")
+	}
+	if baseURL == "" {
+		baseURL = iflowauth.DefaultAPIBaseURL
+	}
+	return apiKey, baseURL
+}
@@ -0,0 +1,84 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestVerifyInvalidAuthFiles_IFlow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	for _, item := range []struct{ id, key string }{
+		{"iflow-ok.json", "sk-ok"},
+		{"iflow-revoked.json", "sk-revoked"},
+	} {
+		auth := &coreauth.Auth{ID: item.id, FileName: item.id, Provider: "iflow", Status: coreauth.StatusActive, Metadata: map[string]any{
+			"type":    "iflow",
+			"api_key": item.key,
+		}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected method %s", r.Method)
+		}
+		if r.Header.Get("Authorization") != "Bearer sk-ok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid api key"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"id":"qwen3-max"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	originalProbeURL := iflowModelsProbeURL
+	iflowModelsProbeURL = srv.URL
+	t.Cleanup(func() { iflowModelsProbeURL = originalProbeURL })
+
+	h := &Handler{cfg: &config.Config{AuthDir: t.TempDir()}, authManager: manager}
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=iflow", nil)
+	h.VerifyInvalidAuthFiles(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Valid   int `json:"valid"`
+		Invalid int `json:"invalid"`
+		Results []struct {
+			ID         string `json:"id"`
+			Invalid    bool   `json:"invalid"`
+			StatusCode int    `json:"status_code"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Valid != 1 || resp.Invalid != 1 || len(resp.Results) != 2 {
+		t.Fatalf("response = %s", rec.Body.String())
+	}
+	for _, row := range resp.Results {
+		wantInvalid := row.ID == "iflow-revoked.json"
+		if row.Invalid != wantInvalid {
+			t.Errorf("%s: invalid = %v, want %v", row.ID, row.Invalid, wantInvalid)
+		}
+		if wantInvalid && row.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s: status_code = %d", row.ID, row.StatusCode)
+		}
+	}
+
+	revoked, _ := manager.GetByID("iflow-revoked.json")
+	if invalid, reason := tokenInvalidState(revoked); !invalid || reason == "" {
+		t.Fatalf("revoked: invalid = %v, reason = %q", invalid, reason)
+	}
+}
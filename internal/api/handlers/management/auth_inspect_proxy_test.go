@@ -0,0 +1,91 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestVerifyInvalidAuthFiles_UsesAuthProxy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	originalBackoff := codexUsageProbeBackoff
+	codexUsageProbeBackoff = time.Millisecond
+	t.Cleanup(func() { codexUsageProbeBackoff = originalBackoff })
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("usage probe bypassed the proxy")
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(upstream.Close)
+	originalProbeURL := codexUsageProbeURL
+	codexUsageProbeURL = upstream.URL + "/usage"
+	t.Cleanup(func() { codexUsageProbeURL = originalProbeURL })
+	upstreamURL, _ := url.Parse(upstream.URL)
+
+	var proxied atomic.Int32
+	proxySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Host != upstreamURL.Host || r.URL.Path != "/usage" {
+			t.Errorf("proxy got %s %s", r.Method, r.URL)
+		}
+		proxied.Add(1)
+		_, _ = w.Write([]byte(`{"plan_type":"plus"}`))
+	}))
+	t.Cleanup(proxySrv.Close)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	deadProxy := "http://" + listener.Addr().String()
+	_ = listener.Close()
+
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	for id, proxyURL := range map[string]string{"codex-proxied.json": proxySrv.URL, "codex-dead-proxy.json": deadProxy} {
+		auth := &coreauth.Auth{ID: id, FileName: id, Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{
+			"type":         "codex",
+			"access_token": "live-token",
+			"expired":      "2099-01-01T00:00:00Z",
+			"proxy_url":    proxyURL,
+		}}
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register auth: %v", errRegister)
+		}
+	}
+
+	h := &Handler{cfg: &config.Config{AuthDir: t.TempDir()}, authManager: manager}
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex", nil)
+	h.VerifyInvalidAuthFiles(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Valid   int `json:"valid"`
+		Invalid int `json:"invalid"`
+		Errors  int `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Valid != 1 || resp.Invalid != 0 || resp.Errors != 1 {
+		t.Fatalf("response = %s", rec.Body.String())
+	}
+	if proxied.Load() != 1 {
+		t.Fatalf("proxy saw %d probes, want 1", proxied.Load())
+	}
+	dead, _ := manager.GetByID("codex-dead-proxy.json")
+	if invalid, reason := tokenInvalidState(dead); invalid {
+		t.Fatalf("unreachable proxy marked the auth invalid: %q", reason)
+	}
+}
@@ -0,0 +1,132 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// qwenModelsProbeURL, when set, replaces the models URL the qwen probe
+// derives from the auth's resource_url.
+var qwenModelsProbeURL = ""
+
+const (
+	qwenDefaultBaseURL     = "https://portal.qwen.ai/v1"
+	qwenProbeUserAgent     = "QwenCode/0.10.3 (darwin; arm64)"
+	qwenProbeDashscopeAuth = "qwen-oauth"
+)
+
+// verifyQwenAuthToken refreshes a qwen auth's access token when it is about
+// to expire and lists models with it. A rejected refresh and the qwen error
+// rules, by default a 401 or an invalid_grant error code, mark the auth
+// invalid; 2xx and quota errors mean the credentials work, so the auth is
+// valid even when throttled. Network errors
+// and 5xx are retried like the codex usage probe.
+func (h *Handler) verifyQwenAuthToken(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
+	if auth == nil {
+		return true, "auth is nil", nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	refreshCtx, cancelRefresh := context.WithTimeout(ctx, 20*time.Second)
+	defer cancelRefresh()
+
+	accessToken, errToken := h.refreshQwenOAuthAccessToken(refreshCtx, auth)
+	if errToken != nil {
+		return refreshFailureOutcome(errToken)
+	}
+	if strings.TrimSpace(accessToken) == "" {
+		return true, "token is empty", nil
+	}
+
+	settings := h.probeSettings("qwen")
+	statusCode, respBody, errProbe := probeWithRetry(ctx, func() (int, string, error) {
+		return h.probeQwenModels(ctx, auth, accessToken, settings)
+	})
+	if settings.fallsBack(ctx, statusCode, errProbe) {
+		return h.fallbackProbe(ctx, "qwen", "models probe", settings, statusCode, errProbe, func(fallback probeSettings) (int, string, error) {
+			return h.probeQwenModels(ctx, auth, accessToken, fallback)
+		})
+	}
+	recordProbeResponse(ctx, statusCode, respBody)
+	if errCtx := ctx.Err(); errCtx != nil {
+		return false, "", errCtx
+	}
+	if errProbe != nil {
+		return false, "", fmt.Errorf("%w: models probe failed: %v", coreauth.ErrProbeInconclusive, errProbe)
+	}
+	if match := h.matchErrorRules("qwen", statusCode, respBody); match.verdict != verdictNone {
+		return match.outcome("models probe", fmt.Sprintf("%s %s", match, strings.TrimSpace(respBody)))
+	}
+	lowerBody := strings.ToLower(respBody)
+	switch {
+	case statusCode >= http.StatusInternalServerError:
+		return false, "", fmt.Errorf("%w: models probe returned %d", coreauth.ErrProbeInconclusive, statusCode)
+	case statusCode == http.StatusTooManyRequests || strings.Contains(lowerBody, "quota"):
+		return false, "", nil
+	case settings.expects(statusCode):
+		return false, "", nil
+	}
+
+	// Other responses keep the auth's current state.
+	invalid, reason := tokenInvalidState(auth)
+	if invalid {
+		return true, reason, nil
+	}
+	return false, "", nil
+}
+
+func (h *Handler) probeQwenModels(ctx context.Context, auth *coreauth.Auth, accessToken string, settings probeSettings) (int, string, error) {
+	probeCtx, cancelProbe := context.WithTimeout(ctx, settings.Timeout)
+	defer cancelProbe()
+
+	probeURL := settings.URL
+	if probeURL == "" {
+		probeURL = qwenModelsURL(auth)
+	}
+	req, errReq := http.NewRequestWithContext(probeCtx, http.MethodGet, probeURL, nil)
+	if errReq != nil {
+		return 0, "", errReq
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(accessToken))
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", qwenProbeUserAgent)
+	req.Header.Set("X-Dashscope-Authtype", qwenProbeDashscopeAuth)
+
+	httpClient := &http.Client{
+		Timeout:   settings.Timeout,
+		Transport: h.apiCallTransport(auth),
+	}
+	resp, errDo := httpClient.Do(req)
+	if errDo != nil {
+		return 0, "", errDo
+	}
+	h.pauseProbesOnRetryAfter(resp, time.Now())
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	bodyBytes, errRead := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if errRead != nil {
+		return resp.StatusCode, "", errRead
+	}
+	return resp.StatusCode, string(bodyBytes), nil
+}
+
+// qwenModelsURL returns the models endpoint of the resource server the qwen
+// auth was issued for, as the executor builds its base URL.
+func qwenModelsURL(auth *coreauth.Auth) string {
+	baseURL := qwenDefaultBaseURL
+	if auth != nil {
+		if resourceURL := stringValue(auth.Metadata, "resource_url"); resourceURL != "" {
+			baseURL = fmt.Sprintf("https://%s/v1", resourceURL)
+		}
+	}
+	return baseURL + "/models"
+}
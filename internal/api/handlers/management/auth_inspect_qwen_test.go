@@ -0,0 +1,104 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestVerifyInvalidAuthFiles_Qwen(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	for _, item := range []struct {
+		id, token, expired string
+	}{
+		{"qwen-ok.json", "ok-token", "2099-01-01T00:00:00Z"},
+		{"qwen-revoked.json", "revoked-token", "2099-01-01T00:00:00Z"},
+		{"qwen-quota.json", "quota-token", "2099-01-01T00:00:00Z"},
+		{"qwen-expired.json", "stale-token", "2020-01-01T00:00:00Z"},
+	} {
+		auth := &coreauth.Auth{ID: item.id, FileName: item.id, Provider: "qwen", Status: coreauth.StatusActive, Metadata: map[string]any{
+			"type":          "qwen",
+			"access_token":  item.token,
+			"refresh_token": "refresh-" + item.id,
+			"expired":       item.expired,
+		}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("refresh_token") != "refresh-qwen-expired.json" {
+			t.Errorf("unexpected refresh form: %v (%v)", r.PostForm, err)
+		}
+		_, _ = w.Write([]byte(`{"access_token":"fresh-token","refresh_token":"refresh-rotated","token_type":"Bearer","expires_in":3600}`))
+	}))
+	t.Cleanup(tokenSrv.Close)
+	originalTokenURL := qwenOAuthTokenURL
+	qwenOAuthTokenURL = tokenSrv.URL
+	t.Cleanup(func() { qwenOAuthTokenURL = originalTokenURL })
+
+	probeSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer ok-token", "Bearer fresh-token":
+			_, _ = w.Write([]byte(`{"data":[{"id":"qwen3-coder-plus"}]}`))
+		case "Bearer revoked-token":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":"invalid_grant","message":"token revoked"}}`))
+		case "Bearer quota-token":
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"code":"insufficient_quota"}}`))
+		default:
+			t.Errorf("unexpected authorization header: %q", r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(probeSrv.Close)
+	originalProbeURL := qwenModelsProbeURL
+	qwenModelsProbeURL = probeSrv.URL
+	t.Cleanup(func() { qwenModelsProbeURL = originalProbeURL })
+
+	h := &Handler{cfg: &config.Config{AuthDir: t.TempDir()}, authManager: manager}
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=qwen", nil)
+	h.VerifyInvalidAuthFiles(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Total   int `json:"total"`
+		Valid   int `json:"valid"`
+		Invalid int `json:"invalid"`
+		Errors  int `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Total != 4 || resp.Valid != 3 || resp.Invalid != 1 || resp.Errors != 0 {
+		t.Fatalf("response = %s", rec.Body.String())
+	}
+
+	revoked, _ := manager.GetByID("qwen-revoked.json")
+	if invalid, reason := tokenInvalidState(revoked); !invalid || reason == "" {
+		t.Fatalf("revoked: invalid = %v, reason = %q", invalid, reason)
+	}
+	if at, _ := revoked.Metadata[tokenInvalidAtKey].(string); at == "" {
+		t.Fatalf("revoked: %s not recorded", tokenInvalidAtKey)
+	}
+
+	store.mu.Lock()
+	saved := store.items["qwen-expired.json"]
+	store.mu.Unlock()
+	if saved == nil || saved.Metadata["access_token"] != "fresh-token" || saved.Metadata["refresh_token"] != "refresh-rotated" {
+		t.Fatalf("refreshed token not persisted: %+v", saved)
+	}
+}
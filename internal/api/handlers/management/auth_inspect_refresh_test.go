@@ -0,0 +1,103 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestVerifyInvalidAuthFiles_CodexRefreshesExpiredToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	for _, item := range []struct{ id, refreshToken string }{
+		{"codex-expired.json", "refresh-ok"},
+		{"codex-revoked.json", "refresh-revoked"},
+		{"codex-outage.json", "refresh-outage"},
+	} {
+		auth := &coreauth.Auth{ID: item.id, FileName: item.id, Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{
+			"type":          "codex",
+			"access_token":  "stale-token",
+			"refresh_token": item.refreshToken,
+			"expired":       "2020-01-01T00:00:00Z",
+			"account_id":    "acct-" + item.id,
+		}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/token":
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("parse refresh form: %v", err)
+			}
+			switch r.PostForm.Get("refresh_token") {
+			case "refresh-ok":
+				_, _ = w.Write([]byte(`{"access_token":"fresh-token","refresh_token":"refresh-rotated","expires_in":3600}`))
+			case "refresh-revoked":
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			default:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/usage":
+			if got := r.Header.Get("Authorization"); got != "Bearer fresh-token" {
+				t.Errorf("usage probed with %q", got)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"plan_type":"plus"}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	originalTokenURL, originalProbeURL := codexOAuthTokenURL, codexUsageProbeURL
+	codexOAuthTokenURL, codexUsageProbeURL = srv.URL+"/oauth/token", srv.URL+"/usage"
+	t.Cleanup(func() { codexOAuthTokenURL, codexUsageProbeURL = originalTokenURL, originalProbeURL })
+
+	h := &Handler{cfg: &config.Config{AuthDir: t.TempDir()}, authManager: manager, tokenStore: store}
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex", nil)
+	h.VerifyInvalidAuthFiles(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Valid   int `json:"valid"`
+		Invalid int `json:"invalid"`
+		Errors  int `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Valid != 1 || resp.Invalid != 1 || resp.Errors != 1 {
+		t.Fatalf("response = %s", rec.Body.String())
+	}
+
+	store.mu.Lock()
+	saved := store.items["codex-expired.json"]
+	store.mu.Unlock()
+	if saved == nil || saved.Metadata["access_token"] != "fresh-token" || saved.Metadata["refresh_token"] != "refresh-rotated" {
+		t.Fatalf("refreshed token not persisted: %+v", saved)
+	}
+
+	revoked, _ := manager.GetByID("codex-revoked.json")
+	if invalid, reason := tokenInvalidState(revoked); !invalid || !strings.HasPrefix(reason, "token refresh failed") {
+		t.Fatalf("revoked: invalid = %v, reason = %q", invalid, reason)
+	}
+	outage, _ := manager.GetByID("codex-outage.json")
+	if invalid, reason := tokenInvalidState(outage); invalid {
+		t.Fatalf("refresh outage marked invalid: %q", reason)
+	}
+}
@@ -0,0 +1,254 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestInspectAuthFiles_DeletesInvalid(t *testing.T) {
+	authDir := t.TempDir()
+	brokenPath := filepath.Join(authDir, "codex-broken.json")
+	if err := os.WriteFile(brokenPath, []byte(`{"type":"codex"}`), 0o600); err != nil {
+		t.Fatalf("write auth file: %v", err)
+	}
+
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	broken := &coreauth.Auth{
+		ID:         "codex-broken.json",
+		FileName:   "codex-broken.json",
+		Provider:   "codex",
+		Status:     coreauth.StatusActive,
+		Attributes: map[string]string{"path": brokenPath},
+	}
+	other := &coreauth.Auth{
+		ID:       "gemini.json",
+		FileName: "gemini.json",
+		Provider: "gemini",
+		Status:   coreauth.StatusActive,
+	}
+	for _, auth := range []*coreauth.Auth{broken, other} {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+
+	cfg := &config.Config{AuthDir: authDir}
+	report, err := InspectAuthFiles(context.Background(), cfg, manager, "codex", false)
+	if err != nil {
+		t.Fatalf("inspect: %v", err)
+	}
+	if report.Total != 1 || report.Invalid != 1 || len(report.Results) != 1 || report.Results[0].Reason == "" {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if _, errStat := os.Stat(brokenPath); errStat != nil {
+		t.Fatalf("file must be kept without delete-invalid: %v", errStat)
+	}
+
+	report, err = InspectAuthFiles(context.Background(), cfg, manager, "codex", true)
+	if err != nil {
+		t.Fatalf("inspect with delete: %v", err)
+	}
+	if report.Deleted != 1 {
+		t.Fatalf("expected one deleted file, got %+v", report)
+	}
+	if _, errStat := os.Stat(brokenPath); !os.IsNotExist(errStat) {
+		t.Fatalf("invalid auth file should be deleted, stat err = %v", errStat)
+	}
+}
+
+func TestVerifyInvalidAuthFiles_UsesInspectorProbes(t *testing.T) {
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: "acme.json", FileName: "acme.json", Provider: "acme", Status: coreauth.StatusActive}); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("acme", coreauth.ProbeFunc(func(context.Context, *coreauth.Auth) (bool, string, error) {
+		return true, "revoked", nil
+	}))
+	var checked []string
+	inspector.Subscribe(func(ev coreauth.InspectionEvent) {
+		if ev.Type == coreauth.InspectionAuthChecked {
+			checked = append(checked, ev.Auth.ID)
+		}
+	})
+	h := &Handler{cfg: &config.Config{}, authManager: manager}
+	h.SetInspector(inspector)
+	if !inspector.HasProbe("codex") {
+		t.Fatal("built-in codex probe not registered on the shared inspector")
+	}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=acme", nil)
+	h.VerifyInvalidAuthFiles(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Invalid int `json:"invalid"`
+		Scope   string
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Invalid != 1 || resp.Scope != "all" {
+		t.Fatalf("unexpected response: %s", rec.Body.String())
+	}
+	if len(checked) != 1 || checked[0] != "acme.json" {
+		t.Fatalf("subscriber saw %v", checked)
+	}
+	auth, _ := manager.GetByID("acme.json")
+	if invalid, reason := tokenInvalidState(auth); !invalid || reason != "revoked" {
+		t.Fatalf("token state = %v %q", invalid, reason)
+	}
+}
+
+func TestVerifyInvalidAuthFiles_AllProviders(t *testing.T) {
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	for _, auth := range []*coreauth.Auth{
+		{ID: "a-acme.json", Provider: "acme"},
+		{ID: "b-beta.json", Provider: "beta"},
+		{ID: "c-acme.json", Provider: "acme"},
+		{ID: "d-mystery.json", Provider: "mystery"},
+	} {
+		auth.FileName, auth.Status = auth.ID, coreauth.StatusActive
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("acme", coreauth.ProbeFunc(func(context.Context, *coreauth.Auth) (bool, string, error) {
+		return true, "revoked", nil
+	}))
+	inspector.RegisterProbe("beta", coreauth.ProbeFunc(func(context.Context, *coreauth.Auth) (bool, string, error) {
+		return false, "", nil
+	}))
+	h := &Handler{cfg: &config.Config{}, authManager: manager}
+	h.SetInspector(inspector)
+
+	type group struct {
+		Checked int              `json:"checked"`
+		Valid   int              `json:"valid"`
+		Invalid int              `json:"invalid"`
+		Results []map[string]any `json:"results"`
+	}
+	var resp struct {
+		Total       int              `json:"total"`
+		NextCursor  int              `json:"next_cursor"`
+		Done        bool             `json:"done"`
+		Checked     int              `json:"checked"`
+		Unsupported []string         `json:"unsupported"`
+		ByProvider  map[string]group `json:"by_provider"`
+	}
+	verify := func(query string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?"+query, nil)
+		h.VerifyInvalidAuthFiles(c)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status: %d body=%s", rec.Code, rec.Body.String())
+		}
+		resp.ByProvider = nil
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+	}
+
+	verify("provider=all&batch_size=2")
+	if resp.Total != 3 || resp.Checked != 2 || resp.NextCursor != 2 || resp.Done {
+		t.Fatalf("first page = %+v", resp)
+	}
+	if len(resp.Unsupported) != 1 || resp.Unsupported[0] != "mystery" {
+		t.Fatalf("unsupported = %v", resp.Unsupported)
+	}
+	if acme := resp.ByProvider["acme"]; acme.Checked != 1 || acme.Invalid != 1 || len(acme.Results) != 1 {
+		t.Fatalf("acme group = %+v", acme)
+	}
+	if beta := resp.ByProvider["beta"]; beta.Checked != 1 || beta.Valid != 1 {
+		t.Fatalf("beta group = %+v", beta)
+	}
+
+	verify("provider=&batch_size=2&cursor=2")
+	if resp.Total != 3 || resp.Checked != 1 || !resp.Done || resp.ByProvider["acme"].Results[0]["id"] != "c-acme.json" {
+		t.Fatalf("second page = %+v", resp)
+	}
+}
+
+func TestInspectAuthFiles_KeepsThrottledAuths(t *testing.T) {
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	paths := make(map[string]string)
+	for _, account := range []string{"acct-revoked", "acct-weekly-limit"} {
+		path := filepath.Join(authDir, account+".json")
+		if err := os.WriteFile(path, []byte(`{"type":"codex"}`), 0o600); err != nil {
+			t.Fatalf("write auth file: %v", err)
+		}
+		paths[account] = path
+		auth := &coreauth.Auth{
+			ID:         account + ".json",
+			FileName:   account + ".json",
+			Provider:   "codex",
+			Status:     coreauth.StatusActive,
+			Attributes: map[string]string{"path": path},
+			Metadata: map[string]any{
+				"type":         "codex",
+				"access_token": "token-" + account,
+				"expired":      "2099-01-01T00:00:00Z",
+				"account_id":   account,
+			},
+		}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Chatgpt-Account-Id") == "acct-revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"type":"usage_limit_reached"}}`))
+	}))
+	t.Cleanup(srv.Close)
+	originalProbeURL := codexUsageProbeURL
+	codexUsageProbeURL = srv.URL
+	t.Cleanup(func() { codexUsageProbeURL = originalProbeURL })
+
+	started := time.Now()
+	report, err := InspectAuthFiles(context.Background(), &config.Config{AuthDir: authDir}, manager, "codex", true)
+	if err != nil {
+		t.Fatalf("inspect with delete: %v", err)
+	}
+	if report.Invalid != 1 || report.Throttled != 1 || report.Errors != 0 || report.Deleted != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if _, errStat := os.Stat(paths["acct-revoked"]); !os.IsNotExist(errStat) {
+		t.Fatalf("revoked auth file should be deleted, stat err = %v", errStat)
+	}
+	if _, errStat := os.Stat(paths["acct-weekly-limit"]); errStat != nil {
+		t.Fatalf("throttled auth file must survive auto delete: %v", errStat)
+	}
+
+	throttled, _ := manager.GetByID("acct-weekly-limit.json")
+	if invalid, _ := tokenInvalidState(throttled); invalid {
+		t.Fatal("throttled auth marked invalid")
+	}
+	raw, _ := throttled.Metadata[coreauth.MetadataQuotaExhaustedUntil].(string)
+	until, errParse := time.Parse(time.RFC3339, raw)
+	if errParse != nil || until.Before(started.Add(59*time.Minute)) {
+		t.Fatalf("quota_exhausted_until = %q", raw)
+	}
+}
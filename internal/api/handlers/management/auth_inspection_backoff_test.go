@@ -0,0 +1,72 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthInspection_BacksOffRepeatedProbeFailures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, t.TempDir(), "codex", 3)
+	var probes atomic.Int32
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(context.Context, *coreauth.Auth) (bool, string, error) {
+		probes.Add(1)
+		return false, "", fmt.Errorf("%w: usage probe returned 502", coreauth.ErrProbeInconclusive)
+	}))
+	cfg := &config.Config{}
+	cfg.AuthInspection.ProbeFailureThreshold = 1
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+	verify := func(query string) map[string]any {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex"+query, nil)
+		h.VerifyInvalidAuthFiles(c)
+		var resp map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("verify%s: status %d body=%s", query, rec.Code, rec.Body.String())
+		}
+		return resp
+	}
+
+	// Probes up to one past the threshold still run.
+	for round := 1; round <= 2; round++ {
+		if resp := verify("&force=true"); resp["errors"] != float64(3) || resp["skipped_backoff"] != float64(0) {
+			t.Fatalf("round %d = errors %v skipped_backoff %v", round, resp["errors"], resp["skipped_backoff"])
+		}
+	}
+	if resp := verify(""); resp["checked"] != float64(0) || resp["skipped"] != float64(3) || resp["skipped_backoff"] != float64(3) || probes.Load() != 6 {
+		t.Fatalf("backed off verify = checked %v skipped %v skipped_backoff %v probes %d", resp["checked"], resp["skipped"], resp["skipped_backoff"], probes.Load())
+	}
+
+	h.runAuthInspection(context.Background(), "scheduled", nil, false)
+	payload := h.authInspectionStatusPayload()
+	if payload["skipped"] != 3 || payload["skipped_backoff"] != 3 || probes.Load() != 6 {
+		t.Fatalf("status = skipped %v skipped_backoff %v probes %d", payload["skipped"], payload["skipped_backoff"], probes.Load())
+	}
+	sub, _ := payload["providers"].(gin.H)["codex"].(gin.H)
+	if sub["skipped_backoff"] != 3 {
+		t.Fatalf("codex status = %v", sub)
+	}
+
+	// Verifying one auth by ID always probes it.
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Params = gin.Params{{Key: "id", Value: "codex-00.json"}}
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/codex-00.json/verify", nil)
+	h.VerifyAuthFile(c)
+	if probes.Load() != 7 {
+		t.Fatalf("verify by ID: status %d probes %d body=%s", rec.Code, probes.Load(), rec.Body.String())
+	}
+}
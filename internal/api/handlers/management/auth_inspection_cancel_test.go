@@ -0,0 +1,164 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestCancelAuthInspection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	paths := registerInspectionFixtures(t, manager, authDir, "codex", 3)
+
+	probing := make(chan struct{}, 3)
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(ctx context.Context, _ *coreauth.Auth) (bool, string, error) {
+		probing <- struct{}{}
+		<-ctx.Done()
+		return true, "401 revoked", nil
+	}))
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex", Concurrency: 1}}
+	cfg.AuthInspection.AutoDeleteInvalid = true
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+
+	cancel := func() map[string]any {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-inspection/cancel", nil)
+		h.CancelAuthInspection(c)
+		var resp map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("cancel: status %d body=%s", rec.Code, rec.Body.String())
+		}
+		return resp
+	}
+
+	if resp := cancel(); resp["cancelled"] != false || resp["started"] != false {
+		t.Fatalf("idle cancel = %v", resp)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.runAuthInspection(context.Background(), "manual", nil, false)
+		close(done)
+	}()
+	select {
+	case <-probing:
+	case <-time.After(2 * time.Second):
+		t.Fatal("inspection never started probing")
+	}
+	if resp := cancel(); resp["cancelled"] != true {
+		t.Fatalf("cancel = %v", resp)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("cancelled run did not stop")
+	}
+
+	payload := h.authInspectionStatusPayload()
+	if payload["running"] != false || payload["cancelled"] != true || payload["checked"] != 0 {
+		t.Fatalf("status after cancel = %+v", payload)
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("cancelled run deleted %s: %v", path, err)
+		}
+	}
+	if resp := cancel(); resp["cancelled"] != false {
+		t.Fatalf("second cancel = %v", resp)
+	}
+}
+
+func TestPutAuthInspectionConfig_CancelRunning(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	paths := registerInspectionFixtures(t, manager, authDir, "codex", 12)
+
+	// The first batch of ten is found invalid; the second blocks until the
+	// run is cancelled.
+	probing := make(chan struct{}, 2)
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
+		if auth.FileName >= "codex-10.json" {
+			probing <- struct{}{}
+			<-ctx.Done()
+		}
+		return true, "401 revoked", nil
+	}))
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection = config.AuthInspectionConfig{Enabled: true, AutoDeleteInvalid: true, VerifyBatchSize: 10}
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex", Concurrency: 1}}
+	h := &Handler{cfg: cfg, authManager: manager, configFilePath: configPath}
+	h.SetInspector(inspector)
+	put := func(body string) map[string]any {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPut, "/v0/management/auth-files/inspection-config", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.PutAuthInspectionConfig(c)
+		var resp map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("put %s: status %d body=%s", body, rec.Code, rec.Body.String())
+		}
+		return resp
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.runAuthInspection(context.Background(), "scheduled", nil, false)
+		close(done)
+	}()
+	select {
+	case <-probing:
+	case <-time.After(2 * time.Second):
+		t.Fatal("inspection never reached the second batch")
+	}
+	// Without cancel_running the run carries on.
+	if resp := put(`{"jitter_seconds":0}`); resp["cancelled"] != false {
+		t.Fatalf("put without cancel_running = %v", resp)
+	}
+	if resp := put(`{"enabled":false,"cancel_running":true}`); resp["cancelled"] != true || resp["enabled"] != false {
+		t.Fatalf("put with cancel_running = %v", resp)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("cancelled run did not stop")
+	}
+
+	payload := h.authInspectionStatusPayload()
+	if payload["cancelled"] != true || payload["cancel_reason"] != "cancelled by config change" || payload["invalid"] != 10 || payload["deleted"] != 0 {
+		t.Fatalf("status after cancel = cancelled %v reason %v invalid %v deleted %v", payload["cancelled"], payload["cancel_reason"], payload["invalid"], payload["deleted"])
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("cancelled run deleted %s: %v", path, err)
+		}
+	}
+	if run, _ := h.inspectionRuns.get(""); !run.Cancelled || run.CancelReason != "cancelled by config change" {
+		t.Fatalf("history = %+v", run)
+	}
+	if resp := put(`{"cancel_running":true,"enabled":false}`); resp["cancelled"] != false {
+		t.Fatalf("idle put with cancel_running = %v", resp)
+	}
+}
@@ -0,0 +1,171 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestPutAuthInspectionConfig_Providers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	h := &Handler{cfg: &config.Config{}, configFilePath: configPath, authManager: coreauth.NewManager(&memoryAuthStore{}, nil, nil)}
+
+	put := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPut, "/v0/management/auth-files/inspection-config", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.PutAuthInspectionConfig(c)
+		return rec
+	}
+
+	rec := put(`{"providers":["codex",{"name":"Gemini-CLI","concurrency":8}]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	got := h.effectiveAuthInspectionConfig().Providers
+	if len(got) != 2 || got[0].Name != "codex" || got[1].Name != "gemini-cli" || got[1].Concurrency != 8 {
+		t.Fatalf("providers = %+v", got)
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil || !strings.Contains(string(saved), "gemini-cli") {
+		t.Fatalf("providers not saved: %s (%v)", saved, err)
+	}
+
+	rec = put(`{"providers":["codex","nope"]}`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "nope") {
+		t.Fatalf("unknown provider: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if got := h.effectiveAuthInspectionConfig().Providers; len(got) != 2 {
+		t.Fatalf("rejected request changed providers: %+v", got)
+	}
+}
+
+func TestPutAuthInspectionConfig_Cron(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg := &config.Config{}
+	cfg.AuthInspection.Enabled = true
+	cfg.AuthInspection.IntervalSeconds = 3600
+	h := &Handler{cfg: cfg, configFilePath: configPath}
+
+	put := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPut, "/v0/management/auth-files/inspection-config", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.PutAuthInspectionConfig(c)
+		return rec
+	}
+
+	if rec := put(`{"cron":"61 3 * * *"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid cron: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if h.effectiveAuthInspectionConfig().Cron != "" {
+		t.Fatal("rejected cron was applied")
+	}
+
+	rec := put(`{"cron":"0 3 * * *"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Cron     string      `json:"cron"`
+		NextRuns []time.Time `json:"next_runs"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Cron != "0 3 * * *" || len(resp.NextRuns) != 3 {
+		t.Fatalf("response = %+v", resp)
+	}
+	for i, run := range resp.NextRuns {
+		local := run.In(time.Local)
+		if local.Hour() != 3 || local.Minute() != 0 {
+			t.Fatalf("next_runs[%d] = %v, want 03:00 local", i, local)
+		}
+		if i > 0 && !run.After(resp.NextRuns[i-1]) {
+			t.Fatalf("next_runs not increasing: %v", resp.NextRuns)
+		}
+	}
+	if next, _ := h.authInspectionStatusPayload()["next_run_at"].(time.Time); !next.Equal(resp.NextRuns[0]) {
+		t.Fatalf("next_run_at = %v, want %v", next, resp.NextRuns[0])
+	}
+
+	// A manual run recomputes the next run from the cron, so it stays on 03:00.
+	next := nextAuthInspectionRun(h.effectiveAuthInspectionConfig(), time.Now())
+	if !next.Equal(resp.NextRuns[0]) {
+		t.Fatalf("next run after manual trigger = %v, want %v", next, resp.NextRuns[0])
+	}
+
+	if rec := put(`{"cron":""}`); rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "next_runs") {
+		t.Fatalf("clear cron: status %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAuthInspectionConfig_VerifySettings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	h := &Handler{cfg: &config.Config{}, configFilePath: configPath}
+
+	get := func() map[string]any {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-files/inspection-config", nil)
+		h.GetAuthInspectionConfig(c)
+		var resp map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+	put := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPut, "/v0/management/auth-files/inspection-config", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.PutAuthInspectionConfig(c)
+		return rec
+	}
+
+	if resp := get(); resp["verify_concurrency"] != float64(40) || resp["verify_batch_size"] != float64(100) || resp["run_timeout_seconds"] != float64(7200) {
+		t.Fatalf("defaults = %+v", resp)
+	}
+	for _, body := range []string{`{"verify_concurrency":0}`, `{"verify_concurrency":101}`, `{"verify_batch_size":5}`, `{"verify_batch_size":501}`, `{"run_timeout_seconds":10}`} {
+		if rec := put(body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d body=%s", body, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := put(`{"verify_concurrency":4,"verify_batch_size":20,"run_timeout_seconds":600}`); rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if resp := get(); resp["verify_concurrency"] != float64(4) || resp["verify_batch_size"] != float64(20) || resp["run_timeout_seconds"] != float64(600) {
+		t.Fatalf("updated = %+v", resp)
+	}
+	cfg := h.effectiveAuthInspectionConfig()
+	if cfg.Providers[0].Concurrency != 4 {
+		t.Fatalf("provider concurrency = %d, want the configured default 4", cfg.Providers[0].Concurrency)
+	}
+	if opts := h.inspectionRunOptions("codex", false); opts.Concurrency != 4 || opts.BatchSize != 20 {
+		t.Fatalf("run options = %+v", opts)
+	}
+}
@@ -0,0 +1,58 @@
+package management
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthInspection_AutoDisableKeepsFilesAndRestores(t *testing.T) {
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	paths := registerInspectionFixtures(t, manager, authDir, "codex", 3)
+	revoked := map[string]bool{"codex-00.json": true, "codex-02.json": true}
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		if revoked[auth.FileName] {
+			return true, "401 revoked", nil
+		}
+		return false, "", nil
+	}))
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection.AutoDeleteInvalid = true
+	cfg.AuthInspection.AutoDisableInvalid = true
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+	payload := h.authInspectionStatusPayload()
+	if payload["disabled"] != 2 || payload["deleted"] != 0 {
+		t.Fatalf("status = disabled %v deleted %v", payload["disabled"], payload["deleted"])
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("auto-disable removed %s: %v", path, err)
+		}
+	}
+	auth, _ := manager.GetByID("codex-00.json")
+	if !coreauth.IsInvalidDisabled(auth) || !auth.Unavailable || auth.Status != coreauth.StatusError {
+		t.Fatalf("codex-00 = status %s unavailable %v", auth.Status, auth.Unavailable)
+	}
+
+	// Sidelined auths are not disabled twice; a recovered one is restored.
+	delete(revoked, "codex-00.json")
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+	if payload = h.authInspectionStatusPayload(); payload["disabled"] != 0 || payload["recovered"] != 1 {
+		t.Fatalf("second run = disabled %v recovered %v", payload["disabled"], payload["recovered"])
+	}
+	auth, _ = manager.GetByID("codex-00.json")
+	if coreauth.IsInvalidDisabled(auth) || auth.Unavailable || auth.Status != coreauth.StatusActive {
+		t.Fatalf("codex-00 after recovery = status %s unavailable %v", auth.Status, auth.Unavailable)
+	}
+	if auth, _ = manager.GetByID("codex-02.json"); !coreauth.IsInvalidDisabled(auth) {
+		t.Fatal("codex-02 lost its sideline")
+	}
+}
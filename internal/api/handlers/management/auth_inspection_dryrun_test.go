@@ -0,0 +1,71 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthInspection_DryRunListsWouldDelete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	paths := registerInspectionFixtures(t, manager, authDir, "codex", 3)
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		return auth.ID != "codex-00.json", "401 revoked", nil
+	}))
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex", Concurrency: 1}}
+	cfg.AuthInspection.AutoDeleteInvalid = true
+	cfg.AuthInspection.DryRun = true
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+
+	h.runAuthInspection(context.Background(), "scheduled", nil, false)
+
+	payload := h.authInspectionStatusPayload()
+	wouldDelete, _ := payload["would_delete"].([]string)
+	if payload["dry_run"] != true || payload["deleted"] != 0 || len(wouldDelete) != 2 {
+		t.Fatalf("status payload = %+v", payload)
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("dry run removed %s: %v", path, err)
+		}
+	}
+	run, ok := h.inspectionRuns.get("")
+	if !ok || !run.DryRun || run.Deleted != 0 || len(run.WouldDelete) != 2 {
+		t.Fatalf("history entry = %+v", run)
+	}
+
+	// A manual run can ask for a dry run even when real deletion is configured.
+	cfg.AuthInspection.DryRun = false
+	h.inspectionTrigger = make(chan inspectionRequest, 1)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/inspection-run?dry_run=true", nil)
+	h.RunAuthInspectionNow(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("run now: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	req := <-h.inspectionTrigger
+	if !req.DryRun || req.Trigger != "manual" {
+		t.Fatalf("queued request = %+v", req)
+	}
+	h.runAuthInspection(context.Background(), req.Trigger, nil, req.DryRun)
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("manual dry run removed %s: %v", path, err)
+		}
+	}
+	if run, _ := h.inspectionRuns.get(""); !run.DryRun || len(run.WouldDelete) != 2 {
+		t.Fatalf("manual dry run entry = %+v", run)
+	}
+}
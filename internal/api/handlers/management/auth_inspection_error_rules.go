@@ -0,0 +1,244 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+// probeErrorCodePaths are where probe error bodies carry their error code,
+// covering the OpenAI, Anthropic, Google and OAuth error shapes.
+var probeErrorCodePaths = []string{"error.code", "error.type", "error.status", "error", "code", "detail.code", "error_code"}
+
+// probeVerdict is what an error rule makes of a probe response.
+type probeVerdict int
+
+const (
+	verdictNone probeVerdict = iota
+	verdictInvalid
+	verdictIgnore
+	verdictThrottle
+)
+
+// probeMatch is the error rule a probe response matched.
+type probeMatch struct {
+	verdict    probeVerdict
+	statusCode int
+	// code is the body error code the rule matched, empty for a status match.
+	code string
+}
+
+// String is the status, followed by the matched code if any.
+func (m probeMatch) String() string {
+	if m.code == "" {
+		return strconv.Itoa(m.statusCode)
+	}
+	return strconv.Itoa(m.statusCode) + " " + m.code
+}
+
+// outcome maps a matched rule to a probe outcome. what names the probe in
+// errors; reason is recorded when the rule marks the auth invalid.
+func (m probeMatch) outcome(what, reason string) (bool, string, error) {
+	switch m.verdict {
+	case verdictIgnore:
+		return false, "", fmt.Errorf("%w: %s returned %s, ignored by error rules", coreauth.ErrProbeInconclusive, what, m)
+	case verdictThrottle:
+		return false, "", fmt.Errorf("%w: %s returned %s", coreauth.ErrProbeThrottled, what, m)
+	}
+	return true, normalizeTokenInvalidReason(reason), nil
+}
+
+// builtinErrorRules returns the error rules a provider uses without an
+// error-rules entry. They keep each probe's long-standing status mapping;
+// codex also treats a deactivated account as definitive, whatever its status.
+func builtinErrorRules(provider string) config.AuthInspectionErrorRules {
+	authFailures := []int{http.StatusUnauthorized, http.StatusForbidden}
+	switch provider {
+	case "codex":
+		return config.AuthInspectionErrorRules{
+			Invalid:  config.AuthInspectionErrorMatch{StatusCodes: authFailures, Codes: []string{"account_deactivated"}},
+			Throttle: config.AuthInspectionErrorMatch{StatusCodes: []int{http.StatusTooManyRequests}},
+		}
+	case "gemini-cli":
+		return config.AuthInspectionErrorRules{
+			Invalid: config.AuthInspectionErrorMatch{StatusCodes: authFailures},
+			Ignore:  config.AuthInspectionErrorMatch{StatusCodes: []int{http.StatusTooManyRequests}},
+		}
+	case "qwen":
+		return config.AuthInspectionErrorRules{
+			Invalid: config.AuthInspectionErrorMatch{StatusCodes: []int{http.StatusUnauthorized}, Codes: []string{"invalid_grant"}},
+		}
+	}
+	return config.AuthInspectionErrorRules{
+		Invalid: config.AuthInspectionErrorMatch{StatusCodes: authFailures},
+	}
+}
+
+// errorRules returns the effective error rules of provider: its error-rules
+// entry or the built-in ones. The codex and iflow probes also mark the
+// top-level invalid status codes invalid.
+func (h *Handler) errorRules(provider string) config.AuthInspectionErrorRules {
+	cfg := h.effectiveAuthInspectionConfig()
+	rules := builtinErrorRules(provider)
+	if entry, ok := cfg.Verification[provider]; ok && entry.ErrorRules != nil {
+		rules = *entry.ErrorRules
+	}
+	if provider == "codex" || provider == "iflow" {
+		rules.Invalid.StatusCodes = append(slices.Clone(rules.Invalid.StatusCodes), cfg.InvalidStatusCodes...)
+	}
+	return rules
+}
+
+// matchErrorRules classifies a probe response against provider's error rules.
+func (h *Handler) matchErrorRules(provider string, statusCode int, body string) probeMatch {
+	return classifyProbeResponse(h.errorRules(provider), statusCode, probeErrorCodes(body))
+}
+
+func classifyProbeResponse(rules config.AuthInspectionErrorRules, statusCode int, codes []string) probeMatch {
+	ordered := []struct {
+		verdict probeVerdict
+		match   config.AuthInspectionErrorMatch
+	}{
+		{verdictIgnore, rules.Ignore},
+		{verdictThrottle, rules.Throttle},
+		{verdictInvalid, rules.Invalid},
+	}
+	for _, rule := range ordered {
+		for _, code := range codes {
+			if slices.Contains(rule.match.Codes, code) {
+				return probeMatch{verdict: rule.verdict, statusCode: statusCode, code: code}
+			}
+		}
+	}
+	for _, rule := range ordered {
+		if slices.Contains(rule.match.StatusCodes, statusCode) {
+			return probeMatch{verdict: rule.verdict, statusCode: statusCode}
+		}
+	}
+	return probeMatch{}
+}
+
+// probeErrorCodes returns the lowercased error codes found in a JSON probe
+// body, or nil when it carries none.
+func probeErrorCodes(body string) []string {
+	if !gjson.Valid(body) {
+		return nil
+	}
+	var codes []string
+	for _, path := range probeErrorCodePaths {
+		value := gjson.Get(body, path)
+		if value.Type != gjson.String {
+			continue
+		}
+		if code := strings.ToLower(strings.TrimSpace(value.String())); code != "" && !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// recordProbeResponse reports a probe's HTTP status and, for an error
+// response, the first error code of its body to the inspector.
+func recordProbeResponse(ctx context.Context, statusCode int, body string) {
+	if statusCode <= 0 {
+		return
+	}
+	coreauth.RecordProbeStatus(ctx, statusCode)
+	if statusCode >= http.StatusBadRequest {
+		if codes := probeErrorCodes(body); len(codes) > 0 {
+			coreauth.RecordProbeErrorCode(ctx, codes[0])
+		}
+	}
+}
+
+// normalizeErrorRules trims and lowercases the codes of rules, returning a
+// copy so the live config is never modified.
+func normalizeErrorRules(rules *config.AuthInspectionErrorRules) *config.AuthInspectionErrorRules {
+	if rules == nil {
+		return nil
+	}
+	normalize := func(match config.AuthInspectionErrorMatch) config.AuthInspectionErrorMatch {
+		out := config.AuthInspectionErrorMatch{StatusCodes: slices.Clone(match.StatusCodes)}
+		for _, code := range match.Codes {
+			if code = strings.ToLower(strings.TrimSpace(code)); code != "" {
+				out.Codes = append(out.Codes, code)
+			}
+		}
+		return out
+	}
+	return &config.AuthInspectionErrorRules{
+		Invalid:  normalize(rules.Invalid),
+		Ignore:   normalize(rules.Ignore),
+		Throttle: normalize(rules.Throttle),
+	}
+}
+
+// validateErrorRules checks the error rules of one verification entry.
+func validateErrorRules(provider string, rules *config.AuthInspectionErrorRules) error {
+	if rules == nil {
+		return nil
+	}
+	for _, list := range []struct {
+		name  string
+		match config.AuthInspectionErrorMatch
+	}{{"invalid", rules.Invalid}, {"ignore", rules.Ignore}, {"throttle", rules.Throttle}} {
+		for _, code := range list.match.StatusCodes {
+			if code < 100 || code > 599 {
+				return fmt.Errorf("verification.%s.error_rules.%s.status_codes: %d is not an HTTP status", provider, list.name, code)
+			}
+			if code >= 200 && code < 300 {
+				return fmt.Errorf("verification.%s.error_rules.%s.status_codes: %d is a success status", provider, list.name, code)
+			}
+		}
+		for _, code := range list.match.Codes {
+			if strings.TrimSpace(code) == "" {
+				return fmt.Errorf("verification.%s.error_rules.%s.codes must not contain empty codes", provider, list.name)
+			}
+		}
+	}
+	return nil
+}
+
+// errorRulesRequest is the error_rules object of a verification entry in an
+// inspection config update.
+type errorRulesRequest struct {
+	Invalid  errorMatchRequest `json:"invalid"`
+	Ignore   errorMatchRequest `json:"ignore"`
+	Throttle errorMatchRequest `json:"throttle"`
+}
+
+type errorMatchRequest struct {
+	StatusCodes []int    `json:"status_codes"`
+	Codes       []string `json:"codes"`
+}
+
+func (r *errorRulesRequest) config() *config.AuthInspectionErrorRules {
+	if r == nil {
+		return nil
+	}
+	match := func(m errorMatchRequest) config.AuthInspectionErrorMatch {
+		return config.AuthInspectionErrorMatch{StatusCodes: m.StatusCodes, Codes: m.Codes}
+	}
+	return &config.AuthInspectionErrorRules{Invalid: match(r.Invalid), Ignore: match(r.Ignore), Throttle: match(r.Throttle)}
+}
+
+// errorRulesPayload reports the effective error rules of provider.
+func (h *Handler) errorRulesPayload(provider string) gin.H {
+	rules := h.errorRules(provider)
+	match := func(m config.AuthInspectionErrorMatch) gin.H {
+		codes := m.Codes
+		if codes == nil {
+			codes = []string{}
+		}
+		return gin.H{"status_codes": statusCodesOrEmpty(m.StatusCodes), "codes": codes}
+	}
+	return gin.H{"invalid": match(rules.Invalid), "ignore": match(rules.Ignore), "throttle": match(rules.Throttle)}
+}
@@ -0,0 +1,130 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestProbeErrorRules_Codex(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer deactivated-token":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":{"code":"Account_Deactivated","message":"account deactivated"}}`))
+		case "Bearer flaky-token":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"message":"upstream hiccup"}}`))
+		case "Bearer limited-token":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"detail":{"code":"usage_limit_reached"}}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	originalProbeURL := codexUsageProbeURL
+	codexUsageProbeURL = srv.URL
+	t.Cleanup(func() { codexUsageProbeURL = originalProbeURL })
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	h := &Handler{cfg: &config.Config{}, configFilePath: configPath}
+	verify := func(token string) (bool, string, error) {
+		auth := &coreauth.Auth{ID: token + ".json", Provider: "codex", Metadata: map[string]any{
+			"type":         "codex",
+			"access_token": token,
+			"expired":      "2099-01-01T00:00:00Z",
+		}}
+		return h.verifyCodexAuthToken(context.Background(), auth)
+	}
+
+	// The built-in rules mark any 401 invalid and record the matched code.
+	if invalid, reason, err := verify("flaky-token"); !invalid || err != nil || !strings.HasPrefix(reason, "usage probe 401: ") {
+		t.Fatalf("default 401 = %v, %q, %v", invalid, reason, err)
+	}
+	if invalid, reason, err := verify("deactivated-token"); !invalid || err != nil || !strings.HasPrefix(reason, "usage probe 403 account_deactivated: ") {
+		t.Fatalf("default deactivated = %v, %q, %v", invalid, reason, err)
+	}
+
+	put := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPut, "/v0/management/auth-files/inspection-config", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.PutAuthInspectionConfig(c)
+		return rec
+	}
+	for _, body := range []string{
+		`{"verification":{"codex":{"error_rules":{"invalid":{"status_codes":[700]}}}}}`,
+		`{"verification":{"codex":{"error_rules":{"ignore":{"status_codes":[200]}}}}}`,
+		`{"verification":{"codex":{"error_rules":{"throttle":{"codes":[" "]}}}}}`,
+	} {
+		if rec := put(body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d body=%s", body, rec.Code, rec.Body.String())
+		}
+	}
+	rec := put(`{"verification":{"codex":{"error_rules":{` +
+		`"invalid":{"status_codes":[403],"codes":["account_deactivated"]},` +
+		`"ignore":{"status_codes":[401]},` +
+		`"throttle":{"codes":["Usage_Limit_Reached"]}}}}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	if invalid, _, err := verify("flaky-token"); invalid || !errors.Is(err, coreauth.ErrProbeInconclusive) {
+		t.Fatalf("ignored 401 = %v, %v", invalid, err)
+	}
+	if invalid, _, err := verify("limited-token"); invalid || !errors.Is(err, coreauth.ErrProbeThrottled) {
+		t.Fatalf("throttled code = %v, %v", invalid, err)
+	}
+	if invalid, reason, err := verify("deactivated-token"); !invalid || err != nil || !strings.Contains(reason, "403 account_deactivated") {
+		t.Fatalf("configured deactivated = %v, %q, %v", invalid, reason, err)
+	}
+	if invalid, _, err := verify("live-token"); invalid || err != nil {
+		t.Fatalf("live token = %v, %v", invalid, err)
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil || !strings.Contains(string(saved), "error-rules") {
+		t.Fatalf("error rules not saved: %s (%v)", saved, err)
+	}
+}
+
+func TestClassifyProbeResponse_Precedence(t *testing.T) {
+	rules := config.AuthInspectionErrorRules{
+		Invalid:  config.AuthInspectionErrorMatch{StatusCodes: []int{401, 403}, Codes: []string{"account_deactivated"}},
+		Ignore:   config.AuthInspectionErrorMatch{StatusCodes: []int{401}},
+		Throttle: config.AuthInspectionErrorMatch{StatusCodes: []int{429}, Codes: []string{"rate_limited"}},
+	}
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   probeVerdict
+		code   string
+	}{
+		{"ignore wins over invalid", 401, `{}`, verdictIgnore, ""},
+		{"code wins over status", 401, `{"error":{"code":"account_deactivated"}}`, verdictInvalid, "account_deactivated"},
+		{"code from oauth error", 400, `{"error":"rate_limited"}`, verdictThrottle, "rate_limited"},
+		{"plain status", 403, `not json`, verdictInvalid, ""},
+		{"no match", 404, `{"error":{"type":"not_found"}}`, verdictNone, ""},
+	}
+	for _, tt := range tests {
+		got := classifyProbeResponse(rules, tt.status, probeErrorCodes(tt.body))
+		if got.verdict != tt.want || got.code != tt.code {
+			t.Errorf("%s: got %+v", tt.name, got)
+		}
+	}
+}
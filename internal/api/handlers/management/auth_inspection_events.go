@@ -0,0 +1,140 @@
+package management
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authInspectionEventBuffer is the number of events a slow subscriber may
+// lag behind before its oldest pending event is dropped.
+const authInspectionEventBuffer = 16
+
+// authInspectionEventHeartbeat is the interval of the keep-alive comments on
+// an idle event stream.
+var authInspectionEventHeartbeat = 15 * time.Second
+
+// inspectionEvent is one server-sent event of the inspection stream.
+type inspectionEvent struct {
+	Name string
+	Data gin.H
+}
+
+// inspectionEventHub fans inspection progress out to the event stream
+// subscribers. Publishing never blocks: a subscriber that falls behind loses
+// its oldest events, each of which carries the full status anyway.
+type inspectionEventHub struct {
+	mu   sync.Mutex
+	subs map[chan inspectionEvent]struct{}
+}
+
+func (b *inspectionEventHub) subscribe() (<-chan inspectionEvent, func()) {
+	ch := make(chan inspectionEvent, authInspectionEventBuffer)
+	b.mu.Lock()
+	if b.subs == nil {
+		b.subs = make(map[chan inspectionEvent]struct{})
+	}
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+	}
+}
+
+func (b *inspectionEventHub) subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+func (b *inspectionEventHub) publish(event inspectionEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+			// Only publish sends, under mu, so dropping one event makes room.
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- event
+		}
+	}
+}
+
+// publishInspectionEvent sends the current status, with the names checked in
+// the batch that triggered it, to the event stream subscribers. It must be
+// called without inspectionMu held.
+func (h *Handler) publishInspectionEvent(name string, batchNames []string) {
+	if h.inspectionEvents.subscribers() == 0 {
+		return
+	}
+	payload := h.authInspectionStatusPayload()
+	if batchNames == nil {
+		batchNames = []string{}
+	}
+	payload["batch_checked"] = batchNames
+	h.inspectionEvents.publish(inspectionEvent{Name: name, Data: payload})
+}
+
+// StreamAuthInspectionEvents streams the inspection status as server-sent
+// events: a "snapshot" on connect, a "progress" event after every batch and a
+// "finished" event when a run ends, with heartbeat comments in between.
+func (h *Handler) StreamAuthInspectionEvents(c *gin.Context) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "streaming unsupported"})
+		return
+	}
+	events, unsubscribe := h.inspectionEvents.subscribe()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	snapshot := h.authInspectionStatusPayload()
+	snapshot["batch_checked"] = []string{}
+	if err := writeInspectionEvent(c.Writer, inspectionEvent{Name: "snapshot", Data: snapshot}); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(authInspectionEventHeartbeat)
+	defer heartbeat.Stop()
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			if err := writeInspectionEvent(c.Writer, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeInspectionEvent(w io.Writer, event inspectionEvent) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Name, data)
+	return err
+}
@@ -0,0 +1,128 @@
+package management
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// readInspectionEvent returns the next event of an inspection stream,
+// skipping heartbeat comments unless wantHeartbeat is set.
+func readInspectionEvent(t *testing.T, r *bufio.Reader, wantHeartbeat bool) (string, gin.H) {
+	t.Helper()
+	var name string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, ": heartbeat"):
+			if wantHeartbeat {
+				return "heartbeat", nil
+			}
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			var data gin.H
+			if err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &data); err != nil {
+				t.Fatalf("decode event: %v", err)
+			}
+			return name, data
+		}
+	}
+}
+
+func TestStreamAuthInspectionEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	heartbeat := authInspectionEventHeartbeat
+	authInspectionEventHeartbeat = 50 * time.Millisecond
+	defer func() { authInspectionEventHeartbeat = heartbeat }()
+
+	h := &Handler{cfg: &config.Config{}}
+	router := gin.New()
+	router.GET("/events", h.StreamAuthInspectionEvents)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	connect := func(ctx context.Context) *bufio.Reader {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("content type = %q", ct)
+		}
+		return bufio.NewReader(resp.Body)
+	}
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	first, second := connect(ctx1), connect(ctx2)
+	for _, r := range []*bufio.Reader{first, second} {
+		if name, data := readInspectionEvent(t, r, false); name != "snapshot" || data["running"] != false {
+			t.Fatalf("snapshot = %s %v", name, data)
+		}
+	}
+
+	h.beginAuthInspection("manual")
+	h.updateAuthInspectionProgress("codex", 5, 2, 1, 1, 0, 0, 0, 0, 0, 1, "codex-01.json", []string{"codex-00.json", "codex-01.json"})
+	h.finishAuthInspection(0, nil)
+	for _, r := range []*bufio.Reader{first, second} {
+		name, data := readInspectionEvent(t, r, false)
+		if batch, _ := data["batch_checked"].([]any); name != "progress" || data["checked"] != float64(2) || data["running"] != true || len(batch) != 2 {
+			t.Fatalf("progress = %s %v", name, data)
+		}
+		if name, data = readInspectionEvent(t, r, false); name != "finished" || data["running"] != false {
+			t.Fatalf("finished = %s %v", name, data)
+		}
+	}
+	if name, _ := readInspectionEvent(t, first, true); name != "heartbeat" {
+		t.Fatalf("want heartbeat, got %s", name)
+	}
+
+	// A disconnected client unsubscribes; the others keep streaming.
+	cancel1()
+	deadline := time.Now().Add(2 * time.Second)
+	for h.inspectionEvents.subscribers() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("subscribers = %d after disconnect", h.inspectionEvents.subscribers())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestInspectionEventHub_SlowSubscriberDoesNotBlock(t *testing.T) {
+	var hub inspectionEventHub
+	events, unsubscribe := hub.subscribe()
+	defer unsubscribe()
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3*authInspectionEventBuffer; i++ {
+			hub.publish(inspectionEvent{Name: "progress", Data: gin.H{"n": i}})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("publish blocked on a full subscriber")
+	}
+	if len(events) != authInspectionEventBuffer {
+		t.Fatalf("buffered = %d", len(events))
+	}
+	if oldest := <-events; oldest.Data["n"] != 2*authInspectionEventBuffer {
+		t.Fatalf("oldest kept event = %v, want %d", oldest.Data["n"], 2*authInspectionEventBuffer)
+	}
+}
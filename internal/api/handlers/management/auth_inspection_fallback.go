@@ -0,0 +1,75 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// probeSourceFallback is reported as the probe of results the fallback
+// target settled.
+const probeSourceFallback = "fallback"
+
+// builtinFallbackProbeURL returns the target a provider's probe falls back to
+// without a verification entry, or "" if it has none.
+func builtinFallbackProbeURL(provider string) string {
+	if provider == "codex" {
+		return codexModelsProbeURL
+	}
+	return ""
+}
+
+// fallsBack reports whether a primary probe that ended with statusCode and
+// errProbe should be retried at the fallback target: it answered 5xx or
+// timed out, and the run itself is still going.
+func (s probeSettings) fallsBack(ctx context.Context, statusCode int, errProbe error) bool {
+	if s.FallbackURL == "" || ctx.Err() != nil {
+		return false
+	}
+	return statusCode >= http.StatusInternalServerError || probeTimedOut(errProbe)
+}
+
+func probeTimedOut(err error) bool {
+	var errNet net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &errNet) && errNet.Timeout())
+}
+
+// fallbackProbe runs probe once at the fallback target of settings after the
+// primary target, described by what, failed with primaryStatus or
+// primaryErr. A 2xx means the token is valid and a response provider's error
+// rules mark invalid means it is not; anything else is inconclusive.
+func (h *Handler) fallbackProbe(ctx context.Context, provider, what string, settings probeSettings, primaryStatus int, primaryErr error, probe func(probeSettings) (int, string, error)) (bool, string, error) {
+	fallback := settings
+	fallback.URL = settings.FallbackURL
+	coreauth.RecordProbeSource(ctx, probeSourceFallback)
+	statusCode, respBody, errProbe := probe(fallback)
+	recordProbeResponse(ctx, statusCode, respBody)
+	if errCtx := ctx.Err(); errCtx != nil {
+		return false, "", errCtx
+	}
+	if errProbe == nil {
+		if statusCode >= 200 && statusCode < 300 {
+			return false, "", nil
+		}
+		if match := h.matchErrorRules(provider, statusCode, respBody); match.verdict == verdictInvalid {
+			return match.outcome("fallback probe", fmt.Sprintf("fallback probe %s: %s", match, strings.TrimSpace(respBody)))
+		}
+	}
+	return false, "", fmt.Errorf("%w: %s %s and fallback probe %s", coreauth.ErrProbeInconclusive, what, probeFailure(primaryStatus, primaryErr), probeFailure(statusCode, errProbe))
+}
+
+// probeFailure describes how a probe attempt failed.
+func probeFailure(statusCode int, errProbe error) string {
+	if errProbe != nil {
+		if probeTimedOut(errProbe) {
+			return "timed out"
+		}
+		return fmt.Sprintf("failed: %v", errProbe)
+	}
+	return fmt.Sprintf("returned %d", statusCode)
+}
@@ -0,0 +1,141 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestVerifyInvalidAuthFiles_CodexFallbackProbe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	originalBackoff := codexUsageProbeBackoff
+	codexUsageProbeBackoff = time.Millisecond
+	t.Cleanup(func() { codexUsageProbeBackoff = originalBackoff })
+
+	var usageCalls, modelsCalls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/usage" {
+			usageCalls.Add(1)
+			if r.Header.Get("Authorization") == "Bearer healthy" {
+				_, _ = w.Write([]byte(`{}`))
+				return
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		modelsCalls.Add(1)
+		switch r.Header.Get("Authorization") {
+		case "Bearer revoked":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":"token_revoked"}}`))
+		case "Bearer flaky":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			_, _ = w.Write([]byte(`{"models":[]}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	for _, token := range []string{"flaky", "healthy", "live", "revoked"} {
+		id := "codex-" + token + ".json"
+		auth := &coreauth.Auth{ID: id, FileName: id, Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{
+			"type":         "codex",
+			"access_token": token,
+			"expired":      "2099-01-01T00:00:00Z",
+		}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+	cfg := &config.Config{AuthDir: t.TempDir()}
+	cfg.AuthInspection.Verification = map[string]config.AuthInspectionVerification{
+		"codex": {ProbeURL: srv.URL + "/usage", FallbackProbeURL: srv.URL + "/models"},
+	}
+	h := &Handler{cfg: cfg, authManager: manager}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex", nil)
+	h.VerifyInvalidAuthFiles(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Results []struct {
+			ID         string `json:"id"`
+			Outcome    string `json:"outcome"`
+			Probe      string `json:"probe"`
+			StatusCode int    `json:"status_code"`
+			Reason     string `json:"reason"`
+			Error      string `json:"error"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.Results) != 4 {
+		t.Fatalf("decode response: %v body=%s", err, rec.Body.String())
+	}
+	flaky, healthy, live, revoked := resp.Results[0], resp.Results[1], resp.Results[2], resp.Results[3]
+	if flaky.Outcome != coreauth.OutcomeError || flaky.Probe != "fallback" || !strings.Contains(flaky.Error, "usage probe returned 503 and fallback probe returned 502") {
+		t.Fatalf("flaky row = %+v", flaky)
+	}
+	if healthy.Outcome != coreauth.OutcomeValid || healthy.Probe != "" {
+		t.Fatalf("healthy row = %+v", healthy)
+	}
+	if live.Outcome != coreauth.OutcomeValid || live.Probe != "fallback" || live.StatusCode != http.StatusOK {
+		t.Fatalf("live row = %+v", live)
+	}
+	if revoked.Outcome != coreauth.OutcomeInvalid || revoked.Probe != "fallback" || revoked.StatusCode != http.StatusUnauthorized || !strings.HasPrefix(revoked.Reason, "fallback probe 401: ") {
+		t.Fatalf("revoked row = %+v", revoked)
+	}
+	// Each failing usage probe is retried before the fallback runs once.
+	if got := usageCalls.Load(); got != 1+3*(1+codexUsageProbeRetries) {
+		t.Fatalf("usage probe calls = %d", got)
+	}
+	if got := modelsCalls.Load(); got != 3 {
+		t.Fatalf("fallback probe calls = %d", got)
+	}
+}
+
+func TestPutAuthInspectionConfig_FallbackProbeURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	h := &Handler{cfg: &config.Config{}, configFilePath: configPath}
+	put := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPut, "/v0/management/auth-files/inspection-config", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.PutAuthInspectionConfig(c)
+		return rec
+	}
+
+	if rec := put(`{"verification":{"claude":{"fallback_probe_url":"ftp://models"}}}`); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "fallback_probe_url") {
+		t.Fatalf("bad url: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	rec := put(`{"verification":{"claude":{"fallback_probe_url":"https://gateway.example.com/v1/models"}}}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"fallback_probe_url":"https://gateway.example.com/v1/models"`) {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	// The built-in codex fallback is reported while unconfigured.
+	if !strings.Contains(rec.Body.String(), `"fallback_probe_url":"`+codexModelsProbeURL+`"`) {
+		t.Fatalf("codex fallback missing: %s", rec.Body.String())
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil || !strings.Contains(string(saved), "fallback-probe-url: https://gateway.example.com/v1/models") {
+		t.Fatalf("fallback not saved: %s (%v)", saved, err)
+	}
+}
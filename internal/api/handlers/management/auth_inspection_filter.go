@@ -0,0 +1,110 @@
+package management
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// normalizeInspectionPatterns trims patterns and drops the empty ones.
+func normalizeInspectionPatterns(in []string) []string {
+	var out []string
+	for _, pattern := range in {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			out = append(out, pattern)
+		}
+	}
+	return out
+}
+
+// validateInspectionPatterns reports the first malformed glob under field.
+func validateInspectionPatterns(field string, patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%s: invalid pattern %q", field, pattern)
+		}
+	}
+	return nil
+}
+
+// matchesInspectionPattern reports whether name matches any of patterns.
+func matchesInspectionPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// patternsOrEmpty returns patterns, or an empty list in place of nil.
+func patternsOrEmpty(patterns []string) []string {
+	if patterns == nil {
+		return []string{}
+	}
+	return patterns
+}
+
+// Inspection scopes select the auths a run or verify call probes.
+const (
+	inspectionScopeAll         = "all"
+	inspectionScopeInvalidOnly = "invalid_only"
+)
+
+// parseInspectionScope normalizes scope, defaulting it to all, and reports
+// whether it is known.
+func parseInspectionScope(scope string) (string, bool) {
+	switch scope = strings.ToLower(strings.TrimSpace(scope)); scope {
+	case "", inspectionScopeAll:
+		return inspectionScopeAll, true
+	case inspectionScopeInvalidOnly:
+		return scope, true
+	default:
+		return inspectionScopeAll, false
+	}
+}
+
+// scopedInspectionFilter narrows filter to scope. Under invalid_only it keeps
+// only the auths marked invalid or in StatusError.
+func scopedInspectionFilter(filter func(*coreauth.Auth) bool, scope string) func(*coreauth.Auth) bool {
+	if scope != inspectionScopeInvalidOnly {
+		return filter
+	}
+	return func(auth *coreauth.Auth) bool {
+		if invalid, _ := coreauth.TokenInvalidState(auth); !invalid && auth.Status != coreauth.StatusError {
+			return false
+		}
+		return filter == nil || filter(auth)
+	}
+}
+
+// inspectionFilter returns the filter applying cfg's include and exclude
+// patterns to Auth.FileName and its include and exclude tags to the auth's
+// tags, or nil when none is set. An auth must match an include pattern and
+// carry an include tag, when there are any, and match no exclude pattern nor
+// carry an exclude tag.
+func inspectionFilter(cfg config.AuthInspectionConfig) func(*coreauth.Auth) bool {
+	include := normalizeInspectionPatterns(cfg.IncludePatterns)
+	exclude := normalizeInspectionPatterns(cfg.ExcludePatterns)
+	includeTags, _ := normalizeAuthTags(cfg.IncludeTags)
+	excludeTags, _ := normalizeAuthTags(cfg.ExcludeTags)
+	if len(include) == 0 && len(exclude) == 0 && len(includeTags) == 0 && len(excludeTags) == 0 {
+		return nil
+	}
+	return func(auth *coreauth.Auth) bool {
+		name := strings.TrimSpace(auth.FileName)
+		if len(include) > 0 && !matchesInspectionPattern(include, name) {
+			return false
+		}
+		if len(includeTags) > 0 && !authHasAnyTag(auth, includeTags) {
+			return false
+		}
+		if len(excludeTags) > 0 && authHasAnyTag(auth, excludeTags) {
+			return false
+		}
+		return !matchesInspectionPattern(exclude, name)
+	}
+}
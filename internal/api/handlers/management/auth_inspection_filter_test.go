@@ -0,0 +1,170 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthInspection_PatternsFilterProbesAndDeletes(t *testing.T) {
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	paths := registerInspectionFixtures(t, manager, authDir, "codex", 3)
+	probed := make(map[string]bool)
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		probed[auth.FileName] = true
+		return true, "401 revoked", nil
+	}))
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex", Concurrency: 1}}
+	cfg.AuthInspection.AutoDeleteInvalid = true
+	cfg.AuthInspection.IncludePatterns = []string{"codex-*.json"}
+	cfg.AuthInspection.ExcludePatterns = []string{" *-01.json "}
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+	if probed["codex-01.json"] || len(probed) != 2 {
+		t.Fatalf("probed = %v", probed)
+	}
+	if _, err := os.Stat(paths[1]); err != nil {
+		t.Fatalf("excluded file removed: %v", err)
+	}
+	for _, path := range []string{paths[0], paths[2]} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("included file kept: %s", path)
+		}
+	}
+	payload := h.authInspectionStatusPayload()
+	if payload["filtered"] != 1 || payload["checked"] != 2 {
+		t.Fatalf("status = filtered %v checked %v", payload["filtered"], payload["checked"])
+	}
+
+	// An excluded auth already marked invalid survives delete-invalid too.
+	auth, _ := manager.GetByID("codex-01.json")
+	setTokenInvalidState(auth, true, "401 revoked")
+	if _, err := manager.Update(context.Background(), auth); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if _, _, err := h.deleteInvalidAuthFilesInternal(context.Background()); err != nil {
+		t.Fatalf("delete invalid: %v", err)
+	}
+	if _, err := os.Stat(paths[1]); err != nil {
+		t.Fatalf("excluded file removed by delete-invalid: %v", err)
+	}
+
+	// A manual delete-invalid request is not narrowed by the patterns.
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/v0/management/auth-files?invalid=true", nil)
+	h.DeleteAuthFile(c)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"deleted":1`) {
+		t.Fatalf("manual delete invalid: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if _, err := os.Stat(paths[1]); !os.IsNotExist(err) {
+		t.Fatalf("excluded file kept by a manual delete-invalid: %v", err)
+	}
+}
+
+func TestPutAuthInspectionConfig_Patterns(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	h := &Handler{cfg: &config.Config{}, configFilePath: configPath}
+	put := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPut, "/v0/management/auth-inspection/config", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.PutAuthInspectionConfig(c)
+		return rec
+	}
+
+	if rec := put(`{"exclude_patterns":["pinned-["]}`); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "exclude_patterns") {
+		t.Fatalf("malformed pattern: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	rec := put(`{"exclude_patterns":[" pinned-*.json ",""]}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"exclude_patterns":["pinned-*.json"]`) || !strings.Contains(rec.Body.String(), `"include_patterns":[]`) {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if saved, err := os.ReadFile(configPath); err != nil || !strings.Contains(string(saved), "pinned-*.json") {
+		t.Fatalf("saved config = %q (%v)", saved, err)
+	}
+}
+
+func TestAuthInspection_InvalidOnlyScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, t.TempDir(), "codex", 6)
+	for _, id := range []string{"codex-01.json", "codex-03.json"} {
+		auth, _ := manager.GetByID(id)
+		setTokenInvalidState(auth, true, "401 revoked")
+		if _, err := manager.Update(context.Background(), auth); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+	}
+	failed := &coreauth.Auth{ID: "codex-failed.json", FileName: "codex-failed.json", Provider: "codex", Status: coreauth.StatusError}
+	if _, err := manager.Register(context.Background(), failed); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	probed := make(map[string]int)
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		probed[auth.FileName]++
+		return auth.FileName == "codex-01.json", "401 revoked", nil
+	}))
+	cfg := &config.Config{}
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex", Concurrency: 1}}
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+	verify := func(query string) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex&concurrency=1"+query, nil)
+		h.VerifyInvalidAuthFiles(c)
+		var resp map[string]any
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	if code, _ := verify("&scope=broken"); code != http.StatusBadRequest {
+		t.Fatalf("unknown scope: status %d", code)
+	}
+	// The cursor pages through the three flagged auths only. codex-03
+	// recovers and leaves the set, so the next page starts one back.
+	_, first := verify("&scope=invalid_only&batch_size=2")
+	if first["scope"] != "invalid_only" || first["total"] != float64(3) || first["checked"] != float64(2) || first["next_cursor"] != float64(1) || first["done"] != false {
+		t.Fatalf("first page = %v", first)
+	}
+	_, second := verify("&scope=invalid_only&batch_size=2&cursor=1")
+	if second["total"] != float64(2) || second["checked"] != float64(1) || second["done"] != true {
+		t.Fatalf("second page = %v", second)
+	}
+	if len(probed) != 3 || probed["codex-01.json"] != 1 || probed["codex-03.json"] != 1 || probed["codex-failed.json"] != 1 {
+		t.Fatalf("probed = %v", probed)
+	}
+
+	// Scheduled runs follow the configured scope: only codex-01 is still
+	// marked invalid.
+	cfg.AuthInspection.Scope = "invalid_only"
+	h.runAuthInspection(context.Background(), "scheduled", nil, false)
+	if payload := h.authInspectionStatusPayload(); payload["checked"] != 1 || payload["invalid"] != 1 || probed["codex-01.json"] != 2 {
+		t.Fatalf("scoped run = checked %v invalid %v probed %v", payload["checked"], payload["invalid"], probed)
+	}
+	if _, all := verify(""); all["scope"] != "all" || all["total"] != float64(7) {
+		t.Fatalf("default scope = %v total %v", all["scope"], all["total"])
+	}
+}
@@ -0,0 +1,110 @@
+package management
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+// inspectionHistoryFileName is written next to the config file.
+const inspectionHistoryFileName = "auth-inspection-history.json"
+
+// inspectionHistoryPath returns where the run history of the server using
+// configFilePath is kept, or "" when there is no config file.
+func inspectionHistoryPath(configFilePath string) string {
+	if configFilePath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(configFilePath), inspectionHistoryFileName)
+}
+
+// load reads the runs saved at path, if any, and persists later runs there.
+func (r *inspectionRunHistory) load(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.path = path
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var runs []*inspectionRunSummary
+	if err = json.Unmarshal(data, &runs); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	if excess := len(runs) - inspectionRunHistoryLimit; excess > 0 {
+		runs = runs[excess:]
+	}
+	r.runs = append(runs, r.runs...)
+	return nil
+}
+
+// saveLocked writes the runs through a temporary file so a crash never
+// leaves a truncated history.
+func (r *inspectionRunHistory) saveLocked() error {
+	data, err := json.Marshal(r.runs)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.path, data)
+}
+
+// writeFileAtomic replaces path with data through a temporary file in the
+// same directory, readable by the owner only.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Chmod(0o600)
+	}
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// GetAuthInspectionHistory lists the latest completed inspection runs, newest
+// first, up to ?limit= (default and maximum 50).
+func (h *Handler) GetAuthInspectionHistory(c *gin.Context) {
+	limit := parsePositiveInt(c.Query("limit"), inspectionRunHistoryLimit, 1, inspectionRunHistoryLimit)
+	runs := h.inspectionRuns.latest(limit)
+	out := make([]gin.H, 0, len(runs))
+	for _, run := range runs {
+		out = append(out, gin.H{
+			"id":           run.ID,
+			"trigger":      run.Trigger,
+			"started_at":   run.StartedAt,
+			"finished_at":  run.FinishedAt,
+			"checked":      run.Checked,
+			"valid":        run.Valid,
+			"invalid":      run.Invalid,
+			"deleted":      run.Deleted,
+			"disabled":     run.Disabled,
+			"cancelled":    run.Cancelled,
+			"interrupted":  run.Interrupted,
+			"dry_run":      run.DryRun,
+			"would_delete": wouldDeleteOrEmpty(run.WouldDelete),
+			"last_error":   run.Error,
+			"providers":    runCountsOrEmpty(run.Providers),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "runs": out})
+}
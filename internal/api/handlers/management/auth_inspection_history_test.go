@@ -0,0 +1,66 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthInspectionHistory_PersistsAndLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	path := inspectionHistoryPath(filepath.Join(t.TempDir(), "config.yaml"))
+
+	h := &Handler{}
+	if err := h.inspectionRuns.load(path); err != nil {
+		t.Fatalf("load empty history: %v", err)
+	}
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < inspectionRunHistoryLimit+5; i++ {
+		run := newInspectionRunSummary("scheduled", start.Add(time.Duration(i)*time.Minute))
+		run.Checked, run.Valid, run.Invalid = 10, 10-i%3, i%3
+		run.FinishedAt = run.StartedAt.Add(time.Second)
+		h.inspectionRuns.add(run)
+	}
+
+	// A restarted process sees the same bounded history.
+	restarted := &Handler{}
+	if err := restarted.inspectionRuns.load(path); err != nil {
+		t.Fatalf("reload history: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-inspection/history?limit=3", nil)
+	restarted.GetAuthInspectionHistory(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Runs []struct {
+			ID        string    `json:"id"`
+			Trigger   string    `json:"trigger"`
+			StartedAt time.Time `json:"started_at"`
+			Checked   int       `json:"checked"`
+			Valid     int       `json:"valid"`
+			Invalid   int       `json:"invalid"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Runs) != 3 {
+		t.Fatalf("runs = %d, want 3", len(resp.Runs))
+	}
+	last := inspectionRunHistoryLimit + 4
+	newest := resp.Runs[0]
+	if !newest.StartedAt.Equal(start.Add(time.Duration(last)*time.Minute)) || newest.Trigger != "scheduled" || newest.Checked != 10 || newest.Invalid != last%3 || newest.Valid != 10-last%3 {
+		t.Fatalf("newest run = %+v", newest)
+	}
+	if got := len(restarted.inspectionRuns.latest(1000)); got != inspectionRunHistoryLimit {
+		t.Fatalf("reloaded %d runs, want %d", got, inspectionRunHistoryLimit)
+	}
+}
@@ -0,0 +1,197 @@
+package management
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	authInspectionLeaseName  = "auth-inspection"
+	authInspectionLeaseTTL   = 15 * time.Second
+	authInspectionLeaseRenew = 5 * time.Second
+	// authInspectionRunRequestKey holds the instance that asked the leader for a manual run.
+	authInspectionRunRequestKey = "auth-inspection-run-requested"
+	// authInspectionLastRunKey holds the instance that finished the last run.
+	authInspectionLastRunKey   = "auth-inspection-last-run"
+	authInspectionStoreTimeout = 5 * time.Second
+)
+
+// inspectionLeaseStore is implemented by token stores shared between replicas.
+// When the registered store provides it, only the lease holder runs the
+// inspection scheduler; otherwise every instance runs it as a single node.
+type inspectionLeaseStore interface {
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	LeaseHolder(ctx context.Context, name string) (string, error)
+	SetCoordinationValue(ctx context.Context, key, value string) error
+	CoordinationValue(ctx context.Context, key string) (string, error)
+	TakeCoordinationValue(ctx context.Context, key string) (string, error)
+}
+
+func (h *Handler) inspectionLeases() inspectionLeaseStore {
+	if h == nil {
+		return nil
+	}
+	leases, _ := h.tokenStore.(inspectionLeaseStore)
+	return leases
+}
+
+// inspectionInstanceID returns auth-inspection.instance-id, defaulting to the hostname.
+func (h *Handler) inspectionInstanceID() string {
+	if h != nil && h.cfg != nil {
+		if id := strings.TrimSpace(h.cfg.AuthInspection.InstanceID); id != "" {
+			return id
+		}
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return host
+	}
+	return "local"
+}
+
+func storeContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), authInspectionStoreTimeout)
+}
+
+// refreshInspectionLeadership acquires or renews the scheduler lease and
+// reports whether this instance may run inspections. Without a shared store
+// the instance always leads.
+func (h *Handler) refreshInspectionLeadership() bool {
+	leases := h.inspectionLeases()
+	if leases == nil {
+		return true
+	}
+	id := h.inspectionInstanceID()
+	ctx, cancel := storeContext()
+	defer cancel()
+	leader, err := leases.AcquireLease(ctx, authInspectionLeaseName, id, authInspectionLeaseTTL)
+	if err != nil {
+		log.Warnf("auth inspection: failed to renew leader lease: %v", err)
+		leader = false
+	}
+
+	h.inspectionMu.Lock()
+	changed := h.inspectionLeader != leader
+	h.inspectionLeader = leader
+	h.inspectionMu.Unlock()
+	if changed {
+		if leader {
+			log.Infof("auth inspection: instance %s is now the scheduler leader", id)
+		} else {
+			log.Infof("auth inspection: instance %s is no longer the scheduler leader", id)
+		}
+	}
+	return leader
+}
+
+// isInspectionLeader reports the leadership observed at the last lease refresh.
+func (h *Handler) isInspectionLeader() bool {
+	if h.inspectionLeases() == nil {
+		return true
+	}
+	h.inspectionMu.RLock()
+	defer h.inspectionMu.RUnlock()
+	return h.inspectionLeader
+}
+
+// requestLeaderInspection asks the leader to run an inspection on its next lease refresh.
+func (h *Handler) requestLeaderInspection() error {
+	ctx, cancel := storeContext()
+	defer cancel()
+	return h.inspectionLeases().SetCoordinationValue(ctx, authInspectionRunRequestKey, h.inspectionInstanceID())
+}
+
+// takeLeaderInspectionRequest returns the instance that requested a run, if any.
+func (h *Handler) takeLeaderInspectionRequest() string {
+	leases := h.inspectionLeases()
+	if leases == nil {
+		return ""
+	}
+	ctx, cancel := storeContext()
+	defer cancel()
+	requester, err := leases.TakeCoordinationValue(ctx, authInspectionRunRequestKey)
+	if err != nil {
+		log.Warnf("auth inspection: failed to read run request: %v", err)
+		return ""
+	}
+	return strings.TrimSpace(requester)
+}
+
+// runCoordinatedInspection runs an inspection while holding the leader lease.
+// The lease is renewed during the run and the run is cancelled if it is lost,
+// so two replicas never probe or delete concurrently.
+func (h *Handler) runCoordinatedInspection(req inspectionRequest, only []string) {
+	leases := h.inspectionLeases()
+	if leases == nil {
+		h.runInspection(h.life.context(), req, only)
+		h.recordInspectionRunner()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(h.life.context())
+	defer cancel()
+	stop := make(chan struct{})
+	renewDone := make(chan struct{})
+	go func() {
+		defer close(renewDone)
+		ticker := time.NewTicker(authInspectionLeaseRenew)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if !h.refreshInspectionLeadership() {
+					log.Warn("auth inspection: leader lease lost, cancelling the running inspection")
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	h.runInspection(ctx, req, only)
+	close(stop)
+	<-renewDone
+	if ctx.Err() == nil {
+		h.recordInspectionRunner()
+	}
+}
+
+func (h *Handler) recordInspectionRunner() {
+	id := h.inspectionInstanceID()
+	h.inspectionMu.Lock()
+	h.inspectionStatus.LastRunBy = id
+	h.inspectionMu.Unlock()
+	leases := h.inspectionLeases()
+	if leases == nil {
+		return
+	}
+	ctx, cancel := storeContext()
+	defer cancel()
+	if err := leases.SetCoordinationValue(ctx, authInspectionLastRunKey, id); err != nil {
+		log.Warnf("auth inspection: failed to record last run: %v", err)
+	}
+}
+
+// inspectionLeadershipPayload describes the scheduler leader for status responses.
+func (h *Handler) inspectionLeadershipPayload() (leader, lastRunBy string) {
+	h.inspectionMu.RLock()
+	lastRunBy = h.inspectionStatus.LastRunBy
+	h.inspectionMu.RUnlock()
+	leases := h.inspectionLeases()
+	if leases == nil {
+		return h.inspectionInstanceID(), lastRunBy
+	}
+	ctx, cancel := storeContext()
+	defer cancel()
+	if holder, err := leases.LeaseHolder(ctx, authInspectionLeaseName); err == nil {
+		leader = holder
+	}
+	if shared, err := leases.CoordinationValue(ctx, authInspectionLastRunKey); err == nil && shared != "" {
+		lastRunBy = shared
+	}
+	return leader, lastRunBy
+}
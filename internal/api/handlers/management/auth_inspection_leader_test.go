@@ -0,0 +1,166 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// leaseAuthStore is a shared in-memory store with lease support.
+type leaseAuthStore struct {
+	memoryAuthStore
+	leaseMu sync.Mutex
+	holder  string
+	expires time.Time
+	values  map[string]string
+}
+
+func (s *leaseAuthStore) AcquireLease(_ context.Context, _, holder string, ttl time.Duration) (bool, error) {
+	s.leaseMu.Lock()
+	defer s.leaseMu.Unlock()
+	if s.holder != "" && s.holder != holder && time.Now().Before(s.expires) {
+		return false, nil
+	}
+	s.holder, s.expires = holder, time.Now().Add(ttl)
+	return true, nil
+}
+
+func (s *leaseAuthStore) LeaseHolder(context.Context, string) (string, error) {
+	s.leaseMu.Lock()
+	defer s.leaseMu.Unlock()
+	if time.Now().After(s.expires) {
+		return "", nil
+	}
+	return s.holder, nil
+}
+
+func (s *leaseAuthStore) SetCoordinationValue(_ context.Context, key, value string) error {
+	s.leaseMu.Lock()
+	defer s.leaseMu.Unlock()
+	if s.values == nil {
+		s.values = make(map[string]string)
+	}
+	s.values[key] = value
+	return nil
+}
+
+func (s *leaseAuthStore) CoordinationValue(_ context.Context, key string) (string, error) {
+	s.leaseMu.Lock()
+	defer s.leaseMu.Unlock()
+	return s.values[key], nil
+}
+
+func (s *leaseAuthStore) TakeCoordinationValue(_ context.Context, key string) (string, error) {
+	s.leaseMu.Lock()
+	defer s.leaseMu.Unlock()
+	value := s.values[key]
+	delete(s.values, key)
+	return value, nil
+}
+
+func TestAuthInspectionLeadership_ForwardsManualRun(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &leaseAuthStore{}
+	newReplica := func(id string) *Handler {
+		cfg := &config.Config{}
+		cfg.AuthInspection.InstanceID = id
+		return &Handler{cfg: cfg, tokenStore: store, inspectionTrigger: make(chan inspectionRequest, 1)}
+	}
+	leader, follower := newReplica("replica-a"), newReplica("replica-b")
+
+	if !leader.refreshInspectionLeadership() {
+		t.Fatal("first replica should acquire the lease")
+	}
+	if follower.refreshInspectionLeadership() {
+		t.Fatal("second replica must not acquire a held lease")
+	}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-inspection/run", nil)
+	follower.RunAuthInspectionNow(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("run on follower: status %d: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Started    bool `json:"started"`
+		Forwarded  bool `json:"forwarded"`
+		Inspection struct {
+			InstanceID string `json:"instance_id"`
+			Leader     string `json:"leader"`
+			IsLeader   bool   `json:"is_leader"`
+		} `json:"inspection"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Started || !body.Forwarded {
+		t.Fatalf("follower should forward the run, got %s", rec.Body.String())
+	}
+	if body.Inspection.InstanceID != "replica-b" || body.Inspection.Leader != "replica-a" || body.Inspection.IsLeader {
+		t.Fatalf("unexpected leadership status: %s", rec.Body.String())
+	}
+	if len(follower.inspectionTrigger) != 0 {
+		t.Fatal("follower must not run the inspection itself")
+	}
+
+	if requester := leader.takeLeaderInspectionRequest(); requester != "replica-b" {
+		t.Fatalf("leader should pick up the request from replica-b, got %q", requester)
+	}
+	if requester := leader.takeLeaderInspectionRequest(); requester != "" {
+		t.Fatalf("request must be consumed once, got %q", requester)
+	}
+
+	leader.recordInspectionRunner()
+	if _, lastRunBy := follower.inspectionLeadershipPayload(); lastRunBy != "replica-a" {
+		t.Fatalf("follower should report the leader's last run, got %q", lastRunBy)
+	}
+}
+
+func TestAuthInspection_CancelledRunKeepsState(t *testing.T) {
+	authDir := t.TempDir()
+	brokenPath := filepath.Join(authDir, "codex-broken.json")
+	if err := os.WriteFile(brokenPath, []byte(`{"type":"codex"}`), 0o600); err != nil {
+		t.Fatalf("write auth file: %v", err)
+	}
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	broken := &coreauth.Auth{
+		ID:         "codex-broken.json",
+		FileName:   "codex-broken.json",
+		Provider:   "codex",
+		Status:     coreauth.StatusActive,
+		Attributes: map[string]string{"path": brokenPath},
+	}
+	if _, err := manager.Register(context.Background(), broken); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := h.verifyAuthTokenState(ctx, broken.Clone()); err == nil {
+		t.Fatal("verification under a cancelled context should fail")
+	}
+	h.runAuthInspection(ctx, "scheduled", nil, false)
+
+	auth, ok := manager.GetByID("codex-broken.json")
+	if !ok {
+		t.Fatal("auth should still be registered")
+	}
+	if _, marked := auth.Metadata[tokenInvalidMetaKey]; marked {
+		t.Fatalf("cancelled run must not mark auths invalid: %+v", auth.Metadata)
+	}
+	if h.inspectionStatus.LastError == "" {
+		t.Fatal("cancelled run should record an error")
+	}
+}
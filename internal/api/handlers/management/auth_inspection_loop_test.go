@@ -0,0 +1,375 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// fakeClock is a schedulerClock whose timers fire only on Advance. Every
+// Timer call is reported on armed.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+	armed  chan time.Duration
+}
+
+type fakeTimer struct {
+	at      time.Time
+	ch      chan time.Time
+	stopped bool
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, armed: make(chan time.Duration, 64)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Timer(d time.Duration) (<-chan time.Time, func() bool) {
+	c.mu.Lock()
+	timer := &fakeTimer{at: c.now.Add(d), ch: make(chan time.Time, 1)}
+	c.timers = append(c.timers, timer)
+	c.mu.Unlock()
+	c.armed <- d
+	return timer.ch, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		active := !timer.stopped
+		timer.stopped = true
+		return active
+	}
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, timer := range c.timers {
+		if !timer.stopped && !c.now.Before(timer.at) {
+			timer.stopped = true
+			timer.ch <- c.now
+		}
+	}
+}
+
+// waitArmed returns the duration of the next timer the loop arms.
+func (c *fakeClock) waitArmed(t *testing.T) time.Duration {
+	t.Helper()
+	select {
+	case d := <-c.armed:
+		return d
+	case <-time.After(2 * time.Second):
+		t.Fatal("scheduler never armed a timer")
+		return 0
+	}
+}
+
+// expectIdle fails if the loop arms a timer within a short grace period.
+func (c *fakeClock) expectIdle(t *testing.T) {
+	t.Helper()
+	select {
+	case d := <-c.armed:
+		t.Fatalf("scheduler armed an unexpected %v timer", d)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAuthInspectionSchedulerLoop_TimerAndTriggers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, t.TempDir(), "codex", 1)
+	var probes atomic.Int32
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(context.Context, *coreauth.Auth) (bool, string, error) {
+		probes.Add(1)
+		return false, "", nil
+	}))
+	cfg := &config.Config{}
+	cfg.AuthInspection = config.AuthInspectionConfig{Enabled: true, IntervalSeconds: 3600}
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	h := &Handler{cfg: cfg, authManager: manager, configFilePath: configPath, inspectionClock: clock}
+	h.SetInspector(inspector)
+	h.startAuthInspectionScheduler()
+	defer func() { _ = h.Stop(context.Background()) }()
+
+	nextRun := func() time.Time {
+		next, _ := h.authInspectionStatusPayload()["next_run_at"].(time.Time)
+		return next
+	}
+
+	// The loop sleeps until the scheduled run instead of ticking.
+	if d := clock.waitArmed(t); d != time.Hour {
+		t.Fatalf("first timer = %v, want 1h", d)
+	}
+	if !nextRun().Equal(start.Add(time.Hour)) {
+		t.Fatalf("next_run_at = %v", nextRun())
+	}
+	clock.expectIdle(t)
+
+	// A manual trigger runs at once and restarts the interval from then.
+	clock.Advance(10 * time.Minute)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/inspection-run", nil)
+	h.RunAuthInspectionNow(c)
+	if !strings.Contains(rec.Body.String(), `"started":true`) {
+		t.Fatalf("run now = %s", rec.Body.String())
+	}
+	waitFor(t, "the manual run", func() bool { return probes.Load() == 1 })
+	if d := clock.waitArmed(t); d != time.Hour {
+		t.Fatalf("timer after manual run = %v, want 1h", d)
+	}
+	if !nextRun().Equal(start.Add(70 * time.Minute)) {
+		t.Fatalf("next_run_at after manual run = %v", nextRun())
+	}
+
+	// The timer firing runs the scheduled inspection exactly once.
+	clock.Advance(time.Hour)
+	waitFor(t, "the scheduled run", func() bool { return probes.Load() == 2 })
+	if d := clock.waitArmed(t); d != time.Hour {
+		t.Fatalf("timer after scheduled run = %v, want 1h", d)
+	}
+	if probes.Load() != 2 || !nextRun().Equal(start.Add(130*time.Minute)) {
+		t.Fatalf("probes = %d, next_run_at = %v", probes.Load(), nextRun())
+	}
+
+	// Disabling the scheduler leaves the loop waiting without a timer.
+	rec = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPut, "/v0/management/auth-files/inspection-config", strings.NewReader(`{"enabled":false}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	h.PutAuthInspectionConfig(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("disable: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	clock.expectIdle(t)
+	if !nextRun().IsZero() {
+		t.Fatalf("next_run_at while disabled = %v", nextRun())
+	}
+	clock.Advance(24 * time.Hour)
+	clock.expectIdle(t)
+	if probes.Load() != 2 {
+		t.Fatalf("disabled scheduler ran: probes = %d", probes.Load())
+	}
+}
+
+func TestAuthInspectionSchedulerLoop_IntervalChangeMidWait(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, t.TempDir(), "codex", 1)
+	var probes atomic.Int32
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(context.Context, *coreauth.Auth) (bool, string, error) {
+		probes.Add(1)
+		return false, "", nil
+	}))
+	cfg := &config.Config{}
+	cfg.AuthInspection = config.AuthInspectionConfig{Enabled: true, IntervalSeconds: 7200}
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	h := &Handler{cfg: cfg, authManager: manager, configFilePath: configPath, inspectionClock: clock}
+	h.SetInspector(inspector)
+	h.startAuthInspectionScheduler()
+	defer func() { _ = h.Stop(context.Background()) }()
+
+	nextRun := func() time.Time {
+		next, _ := h.authInspectionStatusPayload()["next_run_at"].(time.Time)
+		return next
+	}
+	if d := clock.waitArmed(t); d != 2*time.Hour {
+		t.Fatalf("first timer = %v, want 2h", d)
+	}
+
+	// Shortening the interval mid-wait reschedules from the time of the PUT.
+	clock.Advance(30 * time.Minute)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPut, "/v0/management/auth-files/inspection-config", strings.NewReader(`{"interval_seconds":3600}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	h.PutAuthInspectionConfig(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("put: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if !nextRun().Equal(start.Add(90 * time.Minute)) {
+		t.Fatalf("next_run_at after put = %v", nextRun())
+	}
+	if d := clock.waitArmed(t); d != time.Hour {
+		t.Fatalf("timer after put = %v, want 1h", d)
+	}
+	clock.Advance(59 * time.Minute)
+	clock.expectIdle(t)
+	if probes.Load() != 0 {
+		t.Fatalf("ran before the new interval: probes = %d", probes.Load())
+	}
+	clock.Advance(time.Minute)
+	waitFor(t, "the rescheduled run", func() bool { return probes.Load() == 1 })
+	if d := clock.waitArmed(t); d != time.Hour {
+		t.Fatalf("timer after rescheduled run = %v, want 1h", d)
+	}
+
+	// A hot-reloaded config with a new interval is applied the same way.
+	clock.Advance(10 * time.Minute)
+	reloaded := &config.Config{}
+	reloaded.AuthInspection = config.AuthInspectionConfig{Enabled: true, IntervalSeconds: 10800}
+	h.SetConfig(reloaded)
+	if !nextRun().Equal(start.Add(280 * time.Minute)) {
+		t.Fatalf("next_run_at after reload = %v", nextRun())
+	}
+	if d := clock.waitArmed(t); d != 3*time.Hour {
+		t.Fatalf("timer after reload = %v, want 3h", d)
+	}
+
+	// Reloading an unchanged schedule keeps the pending run.
+	clock.Advance(10 * time.Minute)
+	unchanged := &config.Config{}
+	unchanged.AuthInspection = reloaded.AuthInspection
+	h.SetConfig(unchanged)
+	if !nextRun().Equal(start.Add(280 * time.Minute)) {
+		t.Fatalf("next_run_at after unchanged reload = %v", nextRun())
+	}
+}
+
+func TestAuthInspectionSchedulerLoop_RunOnStart(t *testing.T) {
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, t.TempDir(), "codex", 1)
+	var probes atomic.Int32
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(context.Context, *coreauth.Auth) (bool, string, error) {
+		probes.Add(1)
+		return false, "", nil
+	}))
+	cfg := &config.Config{}
+	cfg.AuthInspection = config.AuthInspectionConfig{Enabled: true, IntervalSeconds: 3600, RunOnStart: true, StartDelaySeconds: 20}
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	h := &Handler{cfg: cfg, authManager: manager, inspectionClock: clock}
+	h.SetInspector(inspector)
+	h.startAuthInspectionScheduler()
+	defer func() { _ = h.Stop(context.Background()) }()
+
+	// The loop arms its scheduled run and the startup worker its delay.
+	armed := map[time.Duration]bool{clock.waitArmed(t): true, clock.waitArmed(t): true}
+	if !armed[time.Hour] || !armed[20*time.Second] {
+		t.Fatalf("armed timers = %v, want 1h and 20s", armed)
+	}
+	clock.Advance(19 * time.Second)
+	clock.expectIdle(t)
+	if probes.Load() != 0 {
+		t.Fatalf("startup run fired before its delay: probes = %d", probes.Load())
+	}
+
+	clock.Advance(time.Second)
+	waitFor(t, "the startup run", func() bool { return probes.Load() == 1 })
+	if d := clock.waitArmed(t); d != time.Hour {
+		t.Fatalf("timer after startup run = %v, want 1h", d)
+	}
+	if trigger := h.authInspectionStatusPayload()["trigger"]; trigger != "startup" {
+		t.Fatalf("trigger = %v, want startup", trigger)
+	}
+
+	// A startup request arriving after another run has started is dropped.
+	if !h.queueAuthInspection(inspectionRequest{Trigger: "startup"}) {
+		t.Fatal("queue startup request")
+	}
+	if d := clock.waitArmed(t); d != time.Hour {
+		t.Fatalf("timer after skipped startup run = %v, want 1h", d)
+	}
+	if probes.Load() != 1 {
+		t.Fatalf("startup run fired twice: probes = %d", probes.Load())
+	}
+}
+
+func TestRunAuthInspectionNow_ForceQueuesBehindRunningRun(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, t.TempDir(), "codex", 1)
+	var probes atomic.Int32
+	release := make(chan struct{})
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(context.Context, *coreauth.Auth) (bool, string, error) {
+		if probes.Add(1) == 1 {
+			<-release
+		}
+		return false, "", nil
+	}))
+	h := &Handler{cfg: &config.Config{}, authManager: manager, inspectionClock: newFakeClock(time.Now())}
+	h.SetInspector(inspector)
+	h.startAuthInspectionScheduler()
+	defer func() { _ = h.Stop(context.Background()) }()
+
+	runNow := func(query string) gin.H {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/inspection-run"+query, nil)
+		h.RunAuthInspectionNow(c)
+		var resp gin.H
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return resp
+	}
+	if resp := runNow(""); resp["started"] != true {
+		t.Fatalf("first run: %v", resp)
+	}
+	waitFor(t, "the first probe", func() bool { return probes.Load() == 1 })
+
+	// Without force a request during a run is refused as before.
+	if resp := runNow(""); resp["started"] != false || resp["queued"] != nil || resp["reason"] != "inspection already running" {
+		t.Fatalf("run without force: %v", resp)
+	}
+	for i := 0; i < 3; i++ {
+		resp := runNow("?force=true")
+		if inspection, _ := resp["inspection"].(map[string]any); resp["queued"] != true || inspection["queued"] != true {
+			t.Fatalf("forced run %d: %v", i, resp)
+		}
+	}
+
+	close(release)
+	waitFor(t, "the queued run", func() bool {
+		status := h.authInspectionStatusPayload()
+		return probes.Load() == 2 && status["running"] == false && status["queued"] == false
+	})
+	// The three forced requests coalesced into one run.
+	time.Sleep(50 * time.Millisecond)
+	if probes.Load() != 2 {
+		t.Fatalf("probes = %d, want 2", probes.Load())
+	}
+}
@@ -0,0 +1,183 @@
+package management
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// GetMetrics serves the inspection metrics in the Prometheus text format.
+// The names below are a stable interface that dashboards depend on; a
+// metric is only ever added, never renamed or relabelled.
+//
+//	cliproxy_auth_inspection_runs_total{trigger}             counter    runs started, by trigger kind: manual, scheduled, startup, ...
+//	cliproxy_auth_inspection_probes_total{provider,outcome}  counter    auths probed by runs, verify-invalid and single-auth checks; outcome is valid, invalid or error
+//	cliproxy_auth_inspection_deleted_files_total             counter    invalid auth files deleted or moved to quarantine
+//	cliproxy_auth_inspection_run_duration_seconds            histogram  wall time of finished runs
+//	cliproxy_auth_inspection_running                         gauge      1 while a run is in progress
+//	cliproxy_auth_inspection_next_run_seconds                gauge      seconds until the next scheduled run; absent while none is scheduled
+func (h *Handler) GetMetrics(c *gin.Context) {
+	h.inspectionMu.RLock()
+	running := h.inspectionStatus.Running
+	nextRunAt := h.inspectionStatus.NextRunAt
+	if h.inspectionStatus.Paused {
+		nextRunAt = time.Time{}
+	}
+	h.inspectionMu.RUnlock()
+	var buf bytes.Buffer
+	h.inspectionMetrics.write(&buf, running, nextRunAt, h.schedulerClock().Now())
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", buf.Bytes())
+}
+
+// inspectionRunDurationBuckets are the upper bounds, in seconds, of the run
+// duration histogram.
+var inspectionRunDurationBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600}
+
+// inspectionMetrics accumulates the counters served by GetMetrics. The zero
+// value is ready to use.
+type inspectionMetrics struct {
+	mu            sync.Mutex
+	runs          map[string]uint64
+	probes        map[probeMetricKey]uint64
+	deleted       uint64
+	durationCount []uint64 // per bucket, not cumulative
+	durationSum   float64
+	durationTotal uint64
+}
+
+type probeMetricKey struct {
+	provider string
+	outcome  string
+}
+
+// runStarted counts a run under its trigger kind: the part of the trigger
+// before any ":", so "manual:<instance>" counts as manual.
+func (m *inspectionMetrics) runStarted(trigger string) {
+	kind, _, _ := strings.Cut(strings.TrimSpace(trigger), ":")
+	if kind == "" {
+		kind = "unknown"
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.runs == nil {
+		m.runs = make(map[string]uint64)
+	}
+	m.runs[kind]++
+}
+
+func (m *inspectionMetrics) runFinished(elapsed time.Duration) {
+	seconds := elapsed.Seconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.durationCount == nil {
+		m.durationCount = make([]uint64, len(inspectionRunDurationBuckets))
+	}
+	for i, bound := range inspectionRunDurationBuckets {
+		if seconds <= bound {
+			m.durationCount[i]++
+			break
+		}
+	}
+	m.durationSum += seconds
+	m.durationTotal++
+}
+
+// probed counts the outcomes of probed auths; skipped ones have no outcome
+// and cached ones were not probed.
+func (m *inspectionMetrics) probed(results []coreauth.VerifyResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, result := range results {
+		if result.Outcome == "" || result.Cached {
+			continue
+		}
+		if m.probes == nil {
+			m.probes = make(map[probeMetricKey]uint64)
+		}
+		m.probes[probeMetricKey{provider: result.Provider, outcome: result.Outcome}]++
+	}
+}
+
+func (m *inspectionMetrics) filesDeleted(n int) {
+	if n <= 0 {
+		return
+	}
+	m.mu.Lock()
+	m.deleted += uint64(n)
+	m.mu.Unlock()
+}
+
+func (m *inspectionMetrics) write(w io.Writer, running bool, nextRunAt, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	writeMetricHeader(w, "cliproxy_auth_inspection_runs_total", "counter", "Auth inspection runs started, by trigger kind.")
+	triggers := make([]string, 0, len(m.runs))
+	for trigger := range m.runs {
+		triggers = append(triggers, trigger)
+	}
+	sort.Strings(triggers)
+	for _, trigger := range triggers {
+		_, _ = fmt.Fprintf(w, "cliproxy_auth_inspection_runs_total{trigger=%s} %d\n", metricLabel(trigger), m.runs[trigger])
+	}
+
+	writeMetricHeader(w, "cliproxy_auth_inspection_probes_total", "counter", "Auths probed, by provider and outcome.")
+	keys := make([]probeMetricKey, 0, len(m.probes))
+	for key := range m.probes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].provider != keys[j].provider {
+			return keys[i].provider < keys[j].provider
+		}
+		return keys[i].outcome < keys[j].outcome
+	})
+	for _, key := range keys {
+		_, _ = fmt.Fprintf(w, "cliproxy_auth_inspection_probes_total{provider=%s,outcome=%s} %d\n", metricLabel(key.provider), metricLabel(key.outcome), m.probes[key])
+	}
+
+	writeMetricHeader(w, "cliproxy_auth_inspection_deleted_files_total", "counter", "Invalid auth files deleted or quarantined.")
+	_, _ = fmt.Fprintf(w, "cliproxy_auth_inspection_deleted_files_total %d\n", m.deleted)
+
+	writeMetricHeader(w, "cliproxy_auth_inspection_run_duration_seconds", "histogram", "Wall time of finished auth inspection runs.")
+	var cumulative uint64
+	for i, bound := range inspectionRunDurationBuckets {
+		if m.durationCount != nil {
+			cumulative += m.durationCount[i]
+		}
+		_, _ = fmt.Fprintf(w, "cliproxy_auth_inspection_run_duration_seconds_bucket{le=\"%s\"} %d\n", strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	_, _ = fmt.Fprintf(w, "cliproxy_auth_inspection_run_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.durationTotal)
+	_, _ = fmt.Fprintf(w, "cliproxy_auth_inspection_run_duration_seconds_sum %s\n", strconv.FormatFloat(m.durationSum, 'g', -1, 64))
+	_, _ = fmt.Fprintf(w, "cliproxy_auth_inspection_run_duration_seconds_count %d\n", m.durationTotal)
+
+	writeMetricHeader(w, "cliproxy_auth_inspection_running", "gauge", "1 while an auth inspection run is in progress.")
+	runningValue := 0
+	if running {
+		runningValue = 1
+	}
+	_, _ = fmt.Fprintf(w, "cliproxy_auth_inspection_running %d\n", runningValue)
+
+	writeMetricHeader(w, "cliproxy_auth_inspection_next_run_seconds", "gauge", "Seconds until the next scheduled auth inspection run.")
+	if !nextRunAt.IsZero() {
+		_, _ = fmt.Fprintf(w, "cliproxy_auth_inspection_next_run_seconds %s\n", strconv.FormatFloat(max(nextRunAt.Sub(now).Seconds(), 0), 'f', 3, 64))
+	}
+}
+
+func writeMetricHeader(w io.Writer, name, kind, help string) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// metricLabel quotes a label value, escaping as the text format requires.
+func metricLabel(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
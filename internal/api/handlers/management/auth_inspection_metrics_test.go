@@ -0,0 +1,76 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestGetMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, authDir, "codex", 3)
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		return auth.ID == "codex-01.json", "401 token revoked", nil
+	}))
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection.AutoDeleteInvalid = true
+	clock := newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	h := &Handler{cfg: cfg, authManager: manager, inspectionClock: clock}
+	h.SetInspector(inspector)
+
+	h.runAuthInspection(context.Background(), "manual:replica-a", nil, false)
+	cfg.AuthInspection.AutoDeleteInvalid = false
+	h.runAuthInspection(context.Background(), "scheduled", nil, false)
+	h.inspectionMu.Lock()
+	h.inspectionStatus.NextRunAt = clock.Now().Add(90 * time.Second)
+	h.inspectionMu.Unlock()
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/metrics", nil)
+	h.GetMetrics(c)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("status %d content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE cliproxy_auth_inspection_runs_total counter\n",
+		`cliproxy_auth_inspection_runs_total{trigger="manual"} 1` + "\n",
+		`cliproxy_auth_inspection_runs_total{trigger="scheduled"} 1` + "\n",
+		// The second run skips the auth the first one deleted and disabled.
+		`cliproxy_auth_inspection_probes_total{provider="codex",outcome="invalid"} 1` + "\n",
+		`cliproxy_auth_inspection_probes_total{provider="codex",outcome="valid"} 4` + "\n",
+		"cliproxy_auth_inspection_deleted_files_total 1\n",
+		`cliproxy_auth_inspection_run_duration_seconds_bucket{le="1"} 2` + "\n",
+		`cliproxy_auth_inspection_run_duration_seconds_bucket{le="+Inf"} 2` + "\n",
+		"cliproxy_auth_inspection_run_duration_seconds_count 2\n",
+		"cliproxy_auth_inspection_running 0\n",
+		"cliproxy_auth_inspection_next_run_seconds 90.000\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+
+	// A paused scheduler has no next run to count down to.
+	h.inspectionMu.Lock()
+	h.inspectionStatus.Paused = true
+	h.inspectionMu.Unlock()
+	rec = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/metrics", nil)
+	h.GetMetrics(c)
+	if strings.Contains(rec.Body.String(), "\ncliproxy_auth_inspection_next_run_seconds ") {
+		t.Fatalf("paused scheduler reports a next run:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,111 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+const (
+	authInspectionNotifyEvent = "auth_inspection.invalid"
+	// inspectionNotifyListed bounds the files named in the message text; the
+	// event data lists them all.
+	inspectionNotifyListed = 5
+)
+
+// inspectionInvalidFile is one invalid auth reported by a run notification.
+type inspectionInvalidFile struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+	Reason   string `json:"reason"`
+}
+
+func invalidFilesFromBatch(res coreauth.VerifyBatchResult) []inspectionInvalidFile {
+	var out []inspectionInvalidFile
+	for _, item := range res.Results {
+		if !item.Invalid {
+			continue
+		}
+		name := strings.TrimSpace(item.Name)
+		if name == "" {
+			name = strings.TrimSpace(item.ID)
+		}
+		out = append(out, inspectionInvalidFile{Name: name, Provider: item.Provider, Reason: item.Reason})
+	}
+	return out
+}
+
+// notifyAuthInspection sends the outcome of a run that found newly invalid
+// auths or deleted any to the configured notify URL and notification
+// channels. Only the newly invalid files are listed, so auths that stay
+// invalid are not reported run after run. Delivery is retried twice with
+// backoff; a final failure lands in the status LastError and nowhere else.
+func (h *Handler) notifyAuthInspection(summary *inspectionRunSummary, invalid []inspectionInvalidFile) {
+	var target config.NotificationsConfig
+	if h.cfg != nil {
+		target = h.cfg.Notifications
+	}
+	if url := strings.TrimSpace(h.effectiveAuthInspectionConfig().NotifyURL); url != "" {
+		target.Webhooks = append([]config.WebhookNotification{{Name: "auth-inspection", URL: url}}, target.Webhooks...)
+	}
+	if !notify.HasChannels(target) || (len(summary.NewInvalid) == 0 && summary.Deleted == 0) {
+		return
+	}
+	newly := make([]inspectionInvalidFile, 0, len(summary.NewInvalid))
+	for _, file := range invalid {
+		if slices.Contains(summary.NewInvalid, file.Name) {
+			newly = append(newly, file)
+		}
+	}
+	event := notify.Event{
+		Type:     authInspectionNotifyEvent,
+		Severity: "warning",
+		Title:    "Auth inspection found invalid auths",
+		Message:  inspectionNotifyMessage(summary, newly),
+		Data: map[string]any{
+			"run_id":         summary.ID,
+			"trigger":        summary.Trigger,
+			"started_at":     summary.StartedAt.UTC(),
+			"finished_at":    summary.FinishedAt.UTC(),
+			"checked":        summary.Checked,
+			"valid":          summary.Valid,
+			"invalid":        summary.Invalid,
+			"deleted":        summary.Deleted,
+			"disabled":       summary.Disabled,
+			"delete_skipped": summary.DeleteSkipped,
+			"cancelled":      summary.Cancelled,
+			"invalid_files":  newly,
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), alertNotifyTimeout)
+	defer cancel()
+	if err := notify.Send(ctx, target, event); err != nil {
+		message := fmt.Sprintf("notify failed: %v", err)
+		h.inspectionMu.Lock()
+		if prev := h.inspectionStatus.LastError; prev != "" {
+			message = prev + "\n" + message
+		}
+		h.inspectionStatus.LastError = message
+		h.inspectionMu.Unlock()
+	}
+}
+
+// inspectionNotifyMessage summarizes a run for chat channels: the counts,
+// then the first few newly invalid files with their reasons.
+func inspectionNotifyMessage(summary *inspectionRunSummary, newly []inspectionInvalidFile) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d newly invalid (%d in total) and %d deleted of %d checked in run %s (%s)", len(summary.NewInvalid), summary.Invalid, summary.Deleted, summary.Checked, summary.ID, summary.Trigger)
+	for i, file := range newly {
+		if i == inspectionNotifyListed {
+			fmt.Fprintf(&b, "\n... and %d more", len(newly)-i)
+			break
+		}
+		fmt.Fprintf(&b, "\n- %s (%s): %s", file.Name, file.Provider, file.Reason)
+	}
+	return b.String()
+}
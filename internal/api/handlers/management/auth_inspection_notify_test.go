@@ -0,0 +1,168 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthInspection_NotifiesInvalidAuths(t *testing.T) {
+	var bodies [][]byte
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, authDir, "codex", 3)
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		return auth.ID == "codex-01.json", "401 token revoked", nil
+	}))
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex", Concurrency: 1}}
+	cfg.AuthInspection.NotifyURL = srv.URL
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+	if len(bodies) != 1 {
+		t.Fatalf("deliveries = %d, want 1", len(bodies))
+	}
+	var event struct {
+		Type string `json:"type"`
+		Data struct {
+			RunID        string `json:"run_id"`
+			Trigger      string `json:"trigger"`
+			Checked      int    `json:"checked"`
+			Invalid      int    `json:"invalid"`
+			Deleted      int    `json:"deleted"`
+			StartedAt    string `json:"started_at"`
+			FinishedAt   string `json:"finished_at"`
+			InvalidFiles []struct {
+				Name   string `json:"name"`
+				Reason string `json:"reason"`
+			} `json:"invalid_files"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(bodies[0], &event); err != nil {
+		t.Fatalf("decode notification: %v", err)
+	}
+	data := event.Data
+	if event.Type != authInspectionNotifyEvent || data.RunID == "" || data.Trigger != "manual" || data.Checked != 3 || data.Invalid != 1 || data.Deleted != 0 || data.StartedAt == "" || data.FinishedAt == "" {
+		t.Fatalf("notification = %s", bodies[0])
+	}
+	if len(data.InvalidFiles) != 1 || data.InvalidFiles[0].Name != "codex-01.json" || data.InvalidFiles[0].Reason != "401 token revoked" {
+		t.Fatalf("invalid files = %+v", data.InvalidFiles)
+	}
+
+	// An auth that stays invalid is not reported again.
+	h.runAuthInspection(context.Background(), "scheduled", nil, false)
+	if len(bodies) != 1 {
+		t.Fatalf("deliveries after a repeat run = %d, want 1", len(bodies))
+	}
+
+	// A rejected delivery is reported without failing the run.
+	status = http.StatusBadRequest
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		return auth.ID != "codex-00.json", "401 token revoked", nil
+	}))
+	h.runAuthInspection(context.Background(), "scheduled", nil, false)
+	payload := h.authInspectionStatusPayload()
+	if lastErr, _ := payload["last_error"].(string); !strings.Contains(lastErr, "notify failed") {
+		t.Fatalf("last_error = %q", lastErr)
+	}
+	if run, ok := h.inspectionRuns.get(""); !ok || run.Error != "" || run.Invalid != 2 {
+		t.Fatalf("run after failed notification = %+v", run)
+	}
+	if err := json.Unmarshal(bodies[len(bodies)-1], &event); err != nil {
+		t.Fatalf("decode notification: %v", err)
+	}
+	if files := event.Data.InvalidFiles; len(files) != 1 || files[0].Name != "codex-02.json" {
+		t.Fatalf("notified files = %+v, want only the newly invalid codex-02.json", files)
+	}
+
+	// Runs without invalid auths stay quiet.
+	delivered := len(bodies)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(context.Context, *coreauth.Auth) (bool, string, error) {
+		return false, "", nil
+	}))
+	h.runAuthInspection(context.Background(), "scheduled", nil, false)
+	if len(bodies) != delivered {
+		t.Fatalf("clean run sent a notification")
+	}
+}
+
+func TestAuthInspection_NewlyInvalidAndRecovered(t *testing.T) {
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, t.TempDir(), "codex", 4)
+	invalid := map[string]bool{"codex-00.json": true, "codex-01.json": true}
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		return invalid[auth.ID], "401 token revoked", nil
+	}))
+	cfg := &config.Config{}
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex", Concurrency: 1}}
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+	h.runAuthInspection(context.Background(), "scheduled", nil, false)
+
+	// codex-01 stays invalid, codex-00 recovers and codex-03 goes bad.
+	invalid = map[string]bool{"codex-01.json": true, "codex-03.json": true}
+	h.runAuthInspection(context.Background(), "scheduled", nil, false)
+	payload := h.authInspectionStatusPayload()
+	newly, _ := payload["newly_invalid"].([]string)
+	recovered, _ := payload["newly_recovered"].([]string)
+	if strings.Join(newly, ",") != "codex-03.json" || strings.Join(recovered, ",") != "codex-00.json" {
+		t.Fatalf("status newly_invalid = %v newly_recovered = %v", newly, recovered)
+	}
+	run, _ := h.inspectionRuns.get("")
+	if strings.Join(run.NewInvalid, ",") != "codex-03.json" || strings.Join(run.NewRecovered, ",") != "codex-00.json" {
+		t.Fatalf("history newly_invalid = %v newly_recovered = %v", run.NewInvalid, run.NewRecovered)
+	}
+}
+
+func TestAuthInspection_NotifiesChatChannels(t *testing.T) {
+	var texts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Text string `json:"text"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		texts = append(texts, body.Text)
+	}))
+	defer srv.Close()
+
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, authDir, "codex", 8)
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		return auth.ID != "codex-00.json", "401 token revoked", nil
+	}))
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex", Concurrency: 1}}
+	cfg.Notifications.Slack = []config.SlackNotification{{WebhookURL: srv.URL}}
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+	if len(texts) != 1 {
+		t.Fatalf("messages = %d, want 1", len(texts))
+	}
+	text := texts[0]
+	if !strings.Contains(text, "7 newly invalid") || !strings.Contains(text, "- codex-01.json (codex): 401 token revoked") || !strings.Contains(text, "... and 2 more") || strings.Contains(text, "codex-06.json") {
+		t.Fatalf("message = %q", text)
+	}
+}
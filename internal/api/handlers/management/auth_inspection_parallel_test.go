@@ -0,0 +1,171 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func registerInspectionFixtures(t *testing.T, manager *coreauth.Manager, authDir, provider string, n int) []string {
+	t.Helper()
+	var paths []string
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("%s-%02d.json", provider, i)
+		path := filepath.Join(authDir, name)
+		if err := os.WriteFile(path, []byte(`{"type":"`+provider+`"}`), 0o600); err != nil {
+			t.Fatalf("write auth file: %v", err)
+		}
+		auth := &coreauth.Auth{ID: name, FileName: name, Provider: provider, Status: coreauth.StatusActive, Attributes: map[string]string{"path": path}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestAuthInspection_ProvidersRunInParallel(t *testing.T) {
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	slowPaths := registerInspectionFixtures(t, manager, authDir, "slow", 3)
+	fastPaths := registerInspectionFixtures(t, manager, authDir, "fast", 4)
+
+	// The slow provider's first probe blocks until the fast provider has
+	// probed, which only happens if both loops run at the same time.
+	fastProbed := make(chan struct{})
+	var fastOnce atomic.Bool
+	var failFast atomic.Bool
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("slow", coreauth.ProbeFunc(func(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
+		select {
+		case <-fastProbed:
+		case <-time.After(2 * time.Second):
+			return false, "", errors.New("fast provider never ran alongside")
+		}
+		time.Sleep(10 * time.Millisecond)
+		return strings.HasSuffix(auth.ID, "-00.json"), "401 revoked", nil
+	}))
+	inspector.RegisterProbe("fast", coreauth.ProbeFunc(func(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
+		if fastOnce.CompareAndSwap(false, true) {
+			close(fastProbed)
+		}
+		if failFast.Load() {
+			return false, "", errors.New("upstream unreachable")
+		}
+		return strings.HasSuffix(auth.ID, "-01.json") || strings.HasSuffix(auth.ID, "-02.json"), "token is empty", nil
+	}))
+
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "slow", Concurrency: 1}, {Name: "fast", Concurrency: 2}}
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+
+	payload := h.authInspectionStatusPayload()
+	if payload["last_error"] != "" {
+		t.Fatalf("unexpected error: %v", payload["last_error"])
+	}
+	if payload["total"] != 7 || payload["checked"] != 7 || payload["invalid"] != 3 || payload["valid"] != 4 {
+		t.Fatalf("unexpected totals: %+v", payload)
+	}
+	h.inspectionMu.RLock()
+	slow, fast := *h.inspectionStatus.Providers["slow"], *h.inspectionStatus.Providers["fast"]
+	h.inspectionMu.RUnlock()
+	if slow.Checked != 3 || slow.Invalid != 1 || slow.Concurrency != 1 || slow.Running {
+		t.Fatalf("unexpected slow status: %+v", slow)
+	}
+	if fast.Checked != 4 || fast.Invalid != 2 || fast.Concurrency != 2 || fast.Running {
+		t.Fatalf("unexpected fast status: %+v", fast)
+	}
+	// The run's history entry keeps the same breakdown.
+	run, _ := h.inspectionRuns.get("")
+	if got := run.Providers["slow"]; got == nil || *got != (inspectionRunCounts{Checked: 3, Valid: 2, Invalid: 1}) {
+		t.Fatalf("slow history counts = %+v", got)
+	}
+	if got := run.Providers["fast"]; got == nil || *got != (inspectionRunCounts{Checked: 4, Valid: 2, Invalid: 2}) {
+		t.Fatalf("fast history counts = %+v", got)
+	}
+
+	// A failing provider records its own error; the other still completes
+	// and auto-delete only touches the provider that finished.
+	failFast.Store(true)
+	cfg.AuthInspection.AutoDeleteInvalid = true
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+
+	h.inspectionMu.RLock()
+	slow, fast = *h.inspectionStatus.Providers["slow"], *h.inspectionStatus.Providers["fast"]
+	lastError := h.inspectionStatus.LastError
+	h.inspectionMu.RUnlock()
+	if slow.LastError != "" || slow.Checked != 3 {
+		t.Fatalf("slow provider should complete: %+v", slow)
+	}
+	if !strings.Contains(fast.LastError, "upstream unreachable") || !strings.Contains(lastError, "fast:") {
+		t.Fatalf("fast provider error not recorded: sub=%q run=%q", fast.LastError, lastError)
+	}
+	if _, err := os.Stat(slowPaths[0]); !os.IsNotExist(err) {
+		t.Fatalf("invalid slow file should be deleted, stat err = %v", err)
+	}
+	for _, path := range fastPaths {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("failed provider's files must be kept: %v", err)
+		}
+	}
+}
+
+func TestAuthInspection_ProvidersShareProbePool(t *testing.T) {
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, authDir, "bulk", 30)
+	registerInspectionFixtures(t, manager, authDir, "small", 3)
+
+	var inFlight, peak atomic.Int32
+	var bulkProbed, smallDoneAt atomic.Int32
+	probe := func(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(2 * time.Millisecond)
+		if auth.Provider == "bulk" {
+			bulkProbed.Add(1)
+		} else {
+			smallDoneAt.Store(bulkProbed.Load())
+		}
+		return false, "", nil
+	}
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("bulk", coreauth.ProbeFunc(probe))
+	inspector.RegisterProbe("small", coreauth.ProbeFunc(probe))
+
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection.VerifyPoolSize = 3
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "bulk", Concurrency: 8}, {Name: "small", Concurrency: 8}}
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+
+	if got := peak.Load(); got > 3 {
+		t.Fatalf("peak probes in flight = %d, want at most the pool size 3", got)
+	}
+	// The small provider is not queued behind the bulk one.
+	if got := smallDoneAt.Load(); got >= 30 {
+		t.Fatalf("small provider finished after all %d bulk probes", got)
+	}
+	h.inspectionMu.RLock()
+	bulk, small := *h.inspectionStatus.Providers["bulk"], *h.inspectionStatus.Providers["small"]
+	h.inspectionMu.RUnlock()
+	if bulk.Checked != 30 || small.Checked != 3 || bulk.Running || small.Running {
+		t.Fatalf("provider counts: bulk=%+v small=%+v", bulk, small)
+	}
+}
@@ -0,0 +1,62 @@
+package management
+
+import (
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestInspectionProgress(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	providers := map[string]*authInspectionProviderStatus{
+		// 2 batches of 100 in 40s: 3 batches left, 60s from the last one.
+		"codex": {Running: true, Total: 500, Cursor: 200, BatchSize: 100, Batches: 2, BatchTime: 40 * time.Second, LastBatchAt: now},
+		// Finished providers count as fully done.
+		"gemini-cli": {Running: false, Total: 100, Cursor: 40},
+	}
+	percent, eta, ok := inspectionProgress(providers)
+	if !ok || percent != 50 || !eta.Equal(now.Add(time.Minute)) {
+		t.Fatalf("progress = %v %v %v, want 50%% finishing at %v", percent, eta, ok, now.Add(time.Minute))
+	}
+
+	// A running provider without a timed batch leaves the ETA unknown.
+	providers["claude"] = &authInspectionProviderStatus{Running: true, Total: 200}
+	if percent, eta, ok = inspectionProgress(providers); !ok || percent != 37.5 || !eta.IsZero() {
+		t.Fatalf("progress with untimed provider = %v %v %v", percent, eta, ok)
+	}
+
+	if _, _, ok = inspectionProgress(map[string]*authInspectionProviderStatus{"codex": {Running: true}}); ok {
+		t.Fatal("progress reported without a total")
+	}
+}
+
+func TestAuthInspectionStatusPayload_ProgressFields(t *testing.T) {
+	h := &Handler{cfg: &config.Config{}}
+	h.beginAuthInspection("manual")
+	if payload := h.authInspectionStatusPayload(); payload["progress_percent"] != nil || payload["eta"] != nil {
+		t.Fatalf("progress before the first batch = %v %v", payload["progress_percent"], payload["eta"])
+	}
+
+	res := coreauth.VerifyBatchResult{BatchSize: 10, NextCursor: 10, Total: 40, Checked: 10, Valid: 10}
+	h.recordInspectionBatchTiming("codex", res, 2*time.Second, time.Now())
+	h.updateAuthInspectionProgress("codex", res.Total, 10, 10, 0, 0, 0, 0, 0, 0, 1, "codex-09.json", nil)
+	payload := h.authInspectionStatusPayload()
+	eta, _ := payload["eta"].(time.Time)
+	if payload["progress_percent"] != 25.0 || time.Until(eta) < 5*time.Second || time.Until(eta) > 6*time.Second {
+		t.Fatalf("progress = %v eta in %v", payload["progress_percent"], time.Until(eta))
+	}
+
+	h.finishAuthInspection(0, nil)
+	if payload = h.authInspectionStatusPayload(); payload["progress_percent"] != nil || payload["eta"] != nil {
+		t.Fatalf("progress after the run = %v %v", payload["progress_percent"], payload["eta"])
+	}
+
+	// A new run starts without the previous run's timing.
+	h.beginAuthInspection("manual")
+	h.updateAuthInspectionProgress("codex", 40, 0, 0, 0, 0, 0, 0, 0, 0, 1, "", nil)
+	if payload = h.authInspectionStatusPayload(); payload["progress_percent"] != 0.0 || payload["eta"] != nil {
+		t.Fatalf("progress of the new run = %v %v", payload["progress_percent"], payload["eta"])
+	}
+}
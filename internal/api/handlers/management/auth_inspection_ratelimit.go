@@ -0,0 +1,133 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxProbePause bounds how long a Retry-After holds every probe back.
+const maxProbePause = 5 * time.Minute
+
+// errProbesPaused is returned by throttleProbe when a Retry-After pause would
+// outlast the caller's deadline.
+var errProbesPaused = errors.New("probes paused by upstream Retry-After")
+
+// probeRateLimiter spaces probes evenly at a rate per minute. It is a token
+// bucket holding a single token, so concurrent workers never burst past the
+// rate. The zero value is ready to use.
+type probeRateLimiter struct {
+	mu   sync.Mutex
+	next time.Time
+}
+
+// wait blocks until the next probe slot at perMinute, or until ctx is done.
+// A perMinute below 1 does not limit.
+func (l *probeRateLimiter) wait(ctx context.Context, perMinute int) error {
+	if perMinute < 1 {
+		return nil
+	}
+	interval := time.Minute / time.Duration(perMinute)
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// probePause holds every probe back after an upstream answered a probe with
+// 429 and Retry-After. The zero value is not paused.
+type probePause struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// extend pauses the probes until until, unless they already are for longer.
+func (p *probePause) extend(until time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if until.After(p.until) {
+		p.until = until
+	}
+}
+
+// pausedUntil returns when the current pause ends, or the zero time if the
+// probes are not paused at now.
+func (p *probePause) pausedUntil(now time.Time) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if now.Before(p.until) {
+		return p.until
+	}
+	return time.Time{}
+}
+
+// wait blocks until the pause is over or ctx is done. A pause ending after
+// ctx's deadline fails at once rather than running the deadline out.
+func (p *probePause) wait(ctx context.Context) error {
+	for {
+		until := p.pausedUntil(time.Now())
+		if until.IsZero() {
+			return nil
+		}
+		if deadline, ok := ctx.Deadline(); ok && until.After(deadline) {
+			return fmt.Errorf("%w until %s, past the run deadline", errProbesPaused, until.UTC().Format(time.RFC3339))
+		}
+		timer := time.NewTimer(time.Until(until))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// pauseProbesOnRetryAfter pauses every probe when resp is a 429 carrying
+// Retry-After, for at most maxProbePause. Probes already in flight finish;
+// the others resume once the pause ends.
+func (h *Handler) pauseProbesOnRetryAfter(resp *http.Response, now time.Time) {
+	if resp == nil || resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		return
+	}
+	until := probeRetryAt(resp.Header, nil, now)
+	if !until.After(now) {
+		return
+	}
+	if limit := now.Add(maxProbePause); until.After(limit) {
+		until = limit
+	}
+	h.probePause.extend(until)
+	log.Warnf("auth inspection: probe endpoint asked to retry after %s, pausing probes until %s", until.Sub(now).Round(time.Second), until.UTC().Format(time.RFC3339))
+}
+
+// throttleProbe waits out any Retry-After pause and then for a probe slot
+// under the configured probe rate. Every probe path passes it to the
+// inspector, so they share one budget.
+func (h *Handler) throttleProbe(ctx context.Context) error {
+	if err := h.probePause.wait(ctx); err != nil {
+		return err
+	}
+	return h.probeLimiter.wait(ctx, h.effectiveAuthInspectionConfig().ProbeRatePerMinute)
+}
@@ -0,0 +1,211 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestVerifyInvalidAuthFiles_ProbeRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, t.TempDir(), "codex", 5)
+	var mu sync.Mutex
+	var probedAt []time.Time
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(context.Context, *coreauth.Auth) (bool, string, error) {
+		mu.Lock()
+		probedAt = append(probedAt, time.Now())
+		mu.Unlock()
+		return false, "", nil
+	}))
+	cfg := &config.Config{}
+	cfg.AuthInspection.ProbeRatePerMinute = 600
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex&concurrency=5", nil)
+	h.VerifyInvalidAuthFiles(c)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"checked":5`) {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	// 600 per minute spaces the five concurrent probes 100ms apart.
+	sort.Slice(probedAt, func(a, b int) bool { return probedAt[a].Before(probedAt[b]) })
+	for i := 1; i < len(probedAt); i++ {
+		if gap := probedAt[i].Sub(probedAt[i-1]); gap < 90*time.Millisecond {
+			t.Fatalf("probes %d and %d only %v apart", i-1, i, gap)
+		}
+	}
+}
+
+func TestProbeRateLimiter_HonoursDeadline(t *testing.T) {
+	var limiter probeRateLimiter
+	if err := limiter.wait(context.Background(), 1); err != nil {
+		t.Fatalf("first slot: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	if err := limiter.wait(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wait = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("wait outlived its deadline by %v", elapsed)
+	}
+	if err := limiter.wait(context.Background(), 0); err != nil {
+		t.Fatalf("unlimited wait: %v", err)
+	}
+}
+
+func TestPutAuthInspectionConfig_ProbeRate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	h := &Handler{cfg: &config.Config{}, configFilePath: configPath}
+	put := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPut, "/v0/management/auth-inspection/config", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.PutAuthInspectionConfig(c)
+		return rec
+	}
+
+	if rec := put(`{"probe_rate_per_minute":0}`); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "probe_rate_per_minute") {
+		t.Fatalf("zero rate: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := put(`{"probe_rate_per_minute":120}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"probe_rate_per_minute":120`) {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-inspection/config", nil)
+	h.GetAuthInspectionConfig(c)
+	if !strings.Contains(rec.Body.String(), `"probe_rate_per_minute":120`) {
+		t.Fatalf("get = %s", rec.Body.String())
+	}
+}
+
+func registerCodexProbeFixtures(t *testing.T, manager *coreauth.Manager, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("codex-%02d.json", i)
+		auth := &coreauth.Auth{ID: id, FileName: id, Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{
+			"type":         "codex",
+			"access_token": "token-" + id,
+			"expired":      "2099-01-01T00:00:00Z",
+		}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+}
+
+func TestAuthInspection_PausesOnRetryAfter(t *testing.T) {
+	var mu sync.Mutex
+	var probedAt []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		probedAt = append(probedAt, time.Now())
+		first := len(probedAt) == 1
+		mu.Unlock()
+		if first {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	originalProbeURL := codexUsageProbeURL
+	codexUsageProbeURL = srv.URL
+	t.Cleanup(func() { codexUsageProbeURL = originalProbeURL })
+
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerCodexProbeFixtures(t, manager, 3)
+	cfg := &config.Config{AuthDir: t.TempDir()}
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex", Concurrency: 1}}
+	h := &Handler{cfg: cfg, authManager: manager}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.runAuthInspection(context.Background(), "manual", nil, false)
+	}()
+	waitFor(t, "the pause", func() bool {
+		_, ok := h.authInspectionStatusPayload()["paused_until"]
+		return ok
+	})
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not resume after the pause")
+	}
+
+	payload := h.authInspectionStatusPayload()
+	if payload["last_error"] != "" || payload["checked"] != 3 || payload["throttled"] != 1 || payload["valid"] != 2 {
+		t.Fatalf("unexpected status: %+v", payload)
+	}
+	if _, ok := payload["paused_until"]; ok {
+		t.Fatal("paused_until still reported after the pause")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(probedAt) != 3 {
+		t.Fatalf("probe server received %d requests, want 3", len(probedAt))
+	}
+	if gap := probedAt[1].Sub(probedAt[0]); gap < 900*time.Millisecond {
+		t.Fatalf("probing resumed %v after Retry-After: 1", gap)
+	}
+}
+
+func TestAuthInspection_PausePastRunDeadlineEndsRun(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(srv.Close)
+	originalProbeURL := codexUsageProbeURL
+	codexUsageProbeURL = srv.URL
+	t.Cleanup(func() { codexUsageProbeURL = originalProbeURL })
+
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerCodexProbeFixtures(t, manager, 3)
+	cfg := &config.Config{AuthDir: t.TempDir()}
+	cfg.AuthInspection.RunTimeoutSeconds = 60
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex", Concurrency: 1}}
+	h := &Handler{cfg: cfg, authManager: manager}
+
+	started := time.Now()
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Fatalf("run waited %v for a pause past its deadline", elapsed)
+	}
+	payload := h.authInspectionStatusPayload()
+	if lastError, _ := payload["last_error"].(string); !strings.Contains(lastError, "codex:") || !strings.Contains(lastError, "past the run deadline") {
+		t.Fatalf("last_error = %q", payload["last_error"])
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("probe server received %d requests, want 1", got)
+	}
+}
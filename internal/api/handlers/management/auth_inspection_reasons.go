@@ -0,0 +1,465 @@
+package management
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	inspectionRunHistoryLimit = 50
+	inspectionReasonExamples  = 10
+	// A run is suspect when one reason code covers more than this share of
+	// at least systemicMinInvalid invalid auths.
+	systemicReasonShare = 0.8
+	systemicMinInvalid  = 5
+)
+
+// inspectionReasonGroup counts the invalid auths sharing a reason code or a
+// provider, with a few example file names.
+type inspectionReasonGroup struct {
+	Count    int      `json:"count"`
+	Examples []string `json:"examples,omitempty"`
+}
+
+func (g *inspectionReasonGroup) add(name string) {
+	g.Count++
+	if len(g.Examples) < inspectionReasonExamples && name != "" {
+		g.Examples = append(g.Examples, name)
+	}
+}
+
+// inspectionRunCounts is one provider's share of a run's counters.
+type inspectionRunCounts struct {
+	Checked   int `json:"checked"`
+	Valid     int `json:"valid"`
+	Invalid   int `json:"invalid"`
+	Errors    int `json:"errors"`
+	Throttled int `json:"throttled"`
+}
+
+// inspectionRunSummary is aggregated batch by batch while a run progresses,
+// so it stays complete however little per-file detail is kept.
+type inspectionRunSummary struct {
+	ID            string                            `json:"id"`
+	Trigger       string                            `json:"trigger"`
+	Schedule      string                            `json:"schedule,omitempty"`
+	StartedAt     time.Time                         `json:"started_at"`
+	FinishedAt    time.Time                         `json:"finished_at"`
+	Checked       int                               `json:"checked"`
+	Valid         int                               `json:"valid"`
+	Invalid       int                               `json:"invalid"`
+	Errors        int                               `json:"errors"`
+	Throttled     int                               `json:"throttled"`
+	Deleted       int                               `json:"deleted"`
+	Disabled      int                               `json:"disabled,omitempty"`
+	DeleteSkipped bool                              `json:"delete_skipped,omitempty"`
+	DryRun        bool                              `json:"dry_run,omitempty"`
+	WouldDelete   []string                          `json:"would_delete,omitempty"`
+	NewInvalid    []string                          `json:"newly_invalid,omitempty"`
+	NewRecovered  []string                          `json:"newly_recovered,omitempty"`
+	Cancelled     bool                              `json:"cancelled,omitempty"`
+	CancelReason  string                            `json:"cancel_reason,omitempty"`
+	Interrupted   bool                              `json:"interrupted,omitempty"`
+	Error         string                            `json:"error,omitempty"`
+	ByReason      map[string]*inspectionReasonGroup `json:"by_reason,omitempty"`
+	ByProvider    map[string]*inspectionReasonGroup `json:"by_provider,omitempty"`
+	Providers     map[string]*inspectionRunCounts   `json:"providers,omitempty"`
+}
+
+func newInspectionRunSummary(trigger string, startedAt time.Time) *inspectionRunSummary {
+	return &inspectionRunSummary{
+		ID:         startedAt.UTC().Format("20060102T150405.000Z"),
+		Trigger:    strings.TrimSpace(trigger),
+		StartedAt:  startedAt,
+		ByReason:   make(map[string]*inspectionReasonGroup),
+		ByProvider: make(map[string]*inspectionReasonGroup),
+		Providers:  make(map[string]*inspectionRunCounts),
+	}
+}
+
+func (s *inspectionRunSummary) addBatch(res coreauth.VerifyBatchResult) {
+	s.Checked += res.Checked
+	s.Valid += res.Valid
+	s.Errors += res.Errors
+	s.Throttled += res.Throttled
+	counts, ok := s.Providers[res.Provider]
+	if !ok {
+		counts = &inspectionRunCounts{}
+		s.Providers[res.Provider] = counts
+	}
+	counts.Checked += res.Checked
+	counts.Valid += res.Valid
+	counts.Errors += res.Errors
+	counts.Throttled += res.Throttled
+	for _, item := range res.Results {
+		if !item.Invalid {
+			continue
+		}
+		s.Invalid++
+		counts.Invalid++
+		name := strings.TrimSpace(item.Name)
+		if name == "" {
+			name = strings.TrimSpace(item.ID)
+		}
+		code := item.ReasonCode
+		if code == "" {
+			code = coreauth.InvalidReasonCode(item.Reason)
+		}
+		groupFor(s.ByReason, code).add(name)
+		groupFor(s.ByProvider, item.Provider).add(name)
+	}
+}
+
+func groupFor(groups map[string]*inspectionReasonGroup, key string) *inspectionReasonGroup {
+	group, ok := groups[key]
+	if !ok {
+		group = &inspectionReasonGroup{}
+		groups[key] = group
+	}
+	return group
+}
+
+// dominantReason returns the most frequent reason code and its share of the
+// invalid auths.
+func (s *inspectionRunSummary) dominantReason() (string, float64) {
+	if s.Invalid == 0 {
+		return "", 0
+	}
+	code, count := "", 0
+	for key, group := range s.ByReason {
+		if group.Count > count || (group.Count == count && key < code) {
+			code, count = key, group.Count
+		}
+	}
+	return code, float64(count) / float64(s.Invalid)
+}
+
+// suspectSystemic reports whether a single reason dominates enough invalids
+// to suggest an upstream failure rather than dead accounts.
+func (s *inspectionRunSummary) suspectSystemic() bool {
+	_, share := s.dominantReason()
+	return s.Invalid >= systemicMinInvalid && share > systemicReasonShare
+}
+
+func (s *inspectionRunSummary) payload() gin.H {
+	dominant, share := s.dominantReason()
+	return gin.H{
+		"id":               s.ID,
+		"trigger":          s.Trigger,
+		"schedule":         s.Schedule,
+		"started_at":       s.StartedAt,
+		"finished_at":      s.FinishedAt,
+		"checked":          s.Checked,
+		"valid":            s.Valid,
+		"invalid":          s.Invalid,
+		"deleted":          s.Deleted,
+		"disabled":         s.Disabled,
+		"delete_skipped":   s.DeleteSkipped,
+		"dry_run":          s.DryRun,
+		"would_delete":     wouldDeleteOrEmpty(s.WouldDelete),
+		"newly_invalid":    namesOrEmpty(s.NewInvalid),
+		"newly_recovered":  namesOrEmpty(s.NewRecovered),
+		"cancelled":        s.Cancelled,
+		"cancel_reason":    s.CancelReason,
+		"interrupted":      s.Interrupted,
+		"error":            s.Error,
+		"suspect_systemic": s.suspectSystemic(),
+		"dominant_reason":  dominant,
+		"dominant_share":   share,
+		"by_reason":        groupsPayload(s.ByReason, "reason_code"),
+		"by_provider":      groupsPayload(s.ByProvider, "provider"),
+		"providers":        runCountsOrEmpty(s.Providers),
+	}
+}
+
+// runCountsOrEmpty returns counts, or an empty map for runs recorded before
+// the per-provider counters were kept.
+func runCountsOrEmpty(counts map[string]*inspectionRunCounts) map[string]*inspectionRunCounts {
+	if counts == nil {
+		return map[string]*inspectionRunCounts{}
+	}
+	return counts
+}
+
+// invalidAuthNames returns the file names, keyed by ID, of the auths of
+// providers currently marked invalid.
+func (h *Handler) invalidAuthNames(providers []config.AuthInspectionProvider) map[string]string {
+	wanted := make(map[string]bool, len(providers))
+	for _, provider := range providers {
+		wanted[provider.Name] = true
+	}
+	out := make(map[string]string)
+	for _, auth := range h.authManager.List() {
+		if !wanted[strings.ToLower(strings.TrimSpace(auth.Provider))] {
+			continue
+		}
+		if invalid, _ := coreauth.TokenInvalidState(auth); invalid {
+			name := strings.TrimSpace(auth.FileName)
+			if name == "" {
+				name = auth.ID
+			}
+			out[auth.ID] = name
+		}
+	}
+	return out
+}
+
+// invalidChanges compares the invalid auths before and after a run: newly
+// lists the ones that became invalid, recovered the ones that are no longer
+// marked though they still exist. Both are sorted.
+func (h *Handler) invalidChanges(before, after map[string]string) (newly, recovered []string) {
+	for id, name := range after {
+		if _, ok := before[id]; !ok {
+			newly = append(newly, name)
+		}
+	}
+	for id, name := range before {
+		if _, ok := after[id]; ok {
+			continue
+		}
+		if _, exists := h.authManager.GetByID(id); exists {
+			recovered = append(recovered, name)
+		}
+	}
+	sort.Strings(newly)
+	sort.Strings(recovered)
+	return newly, recovered
+}
+
+func namesOrEmpty(names []string) []string {
+	if names == nil {
+		return []string{}
+	}
+	return names
+}
+
+func wouldDeleteOrEmpty(names []string) []string {
+	if names == nil {
+		return []string{}
+	}
+	return names
+}
+
+// groupsPayload lists groups by descending count, then key.
+func groupsPayload(groups map[string]*inspectionReasonGroup, keyName string) []gin.H {
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if groups[keys[i]].Count != groups[keys[j]].Count {
+			return groups[keys[i]].Count > groups[keys[j]].Count
+		}
+		return keys[i] < keys[j]
+	})
+	out := make([]gin.H, 0, len(keys))
+	for _, key := range keys {
+		examples := groups[key].Examples
+		if examples == nil {
+			examples = []string{}
+		}
+		out = append(out, gin.H{keyName: key, "count": groups[key].Count, "examples": examples})
+	}
+	return out
+}
+
+var (
+	reasonTimestamp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[t ]\d{2}:\d{2}:\d{2}(\.\d+)?(z|[+-]\d{2}:?\d{2})?`)
+	reasonUUID      = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	reasonToken     = regexp.MustCompile(`[a-z0-9_-]*[0-9][a-z0-9_-]*`)
+	reasonSpace     = regexp.MustCompile(`\s+`)
+)
+
+// reasonBucket normalizes an invalid reason so the same failure on different
+// auths lands in one bucket: timestamps, UUIDs, ID-like tokens and long
+// numbers are replaced with placeholders. Three-digit status codes are kept.
+func reasonBucket(reason string) string {
+	bucket := strings.ToLower(strings.TrimSpace(reason))
+	if bucket == "" {
+		return "unknown"
+	}
+	bucket = reasonTimestamp.ReplaceAllString(bucket, "<time>")
+	bucket = reasonUUID.ReplaceAllString(bucket, "<id>")
+	bucket = reasonToken.ReplaceAllStringFunc(bucket, maskReasonToken)
+	return reasonSpace.ReplaceAllString(bucket, " ")
+}
+
+// maskReasonToken replaces a token holding at least four digits: "<n>" for
+// a number, "<id>" for an identifier of eight or more characters.
+func maskReasonToken(token string) string {
+	digits := 0
+	for _, r := range token {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	switch {
+	case digits < 4:
+		return token
+	case digits == len(token):
+		return "<n>"
+	case len(token) >= 8:
+		return "<id>"
+	}
+	return token
+}
+
+// addReasonHistogram counts the invalid results of a batch into hist by
+// reasonBucket, allocating hist when needed.
+func addReasonHistogram(hist map[string]int, results []coreauth.VerifyResult) map[string]int {
+	for _, result := range results {
+		if !result.Invalid {
+			continue
+		}
+		if hist == nil {
+			hist = make(map[string]int)
+		}
+		hist[reasonBucket(result.Reason)]++
+	}
+	return hist
+}
+
+// copyReasonHistogram returns a copy of hist, or an empty map for nil.
+func copyReasonHistogram(hist map[string]int) map[string]int {
+	out := make(map[string]int, len(hist))
+	for bucket, count := range hist {
+		out[bucket] = count
+	}
+	return out
+}
+
+// invalidReasonSummary counts a provider's invalid auths by reason bucket.
+type invalidReasonSummary struct {
+	Invalid         int            `json:"invalid"`
+	ReasonHistogram map[string]int `json:"reason_histogram"`
+}
+
+// GetInvalidAuthSummary buckets the reasons of the auths currently marked
+// invalid, per provider and overall. ?provider= limits it to one provider.
+func (h *Handler) GetInvalidAuthSummary(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	providerFilter := strings.ToLower(strings.TrimSpace(c.Query("provider")))
+	if providerFilter == "all" || providerFilter == "*" {
+		providerFilter = ""
+	}
+	total := make(map[string]int)
+	invalid := 0
+	providers := make(map[string]*invalidReasonSummary)
+	for _, auth := range h.authManager.List() {
+		provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+		if providerFilter != "" && provider != providerFilter {
+			continue
+		}
+		marked, reason := coreauth.TokenInvalidState(auth)
+		if !marked {
+			continue
+		}
+		entry, ok := providers[provider]
+		if !ok {
+			entry = &invalidReasonSummary{ReasonHistogram: make(map[string]int)}
+			providers[provider] = entry
+		}
+		bucket := reasonBucket(reason)
+		entry.Invalid++
+		entry.ReasonHistogram[bucket]++
+		total[bucket]++
+		invalid++
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":           "ok",
+		"invalid":          invalid,
+		"reason_histogram": total,
+		"providers":        providers,
+	})
+}
+
+// inspectionRunHistory keeps the summaries of the latest completed runs,
+// oldest first. The zero value is ready to use and keeps them in memory only;
+// after load they are also written to a file.
+type inspectionRunHistory struct {
+	mu   sync.RWMutex
+	runs []*inspectionRunSummary
+	path string
+}
+
+func (r *inspectionRunHistory) add(summary *inspectionRunSummary) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs = append(r.runs, summary)
+	if excess := len(r.runs) - inspectionRunHistoryLimit; excess > 0 {
+		r.runs = append([]*inspectionRunSummary(nil), r.runs[excess:]...)
+	}
+	if r.path != "" {
+		if err := r.saveLocked(); err != nil {
+			log.Warnf("failed to save auth inspection history: %v", err)
+		}
+	}
+}
+
+// get returns the run with id, or the latest run when id is empty.
+func (r *inspectionRunHistory) get(id string) (*inspectionRunSummary, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.runs) == 0 {
+		return nil, false
+	}
+	if id == "" {
+		return r.runs[len(r.runs)-1], true
+	}
+	for _, run := range r.runs {
+		if run.ID == id {
+			return run, true
+		}
+	}
+	return nil, false
+}
+
+// latest returns up to limit runs, newest first.
+func (r *inspectionRunHistory) latest(limit int) []*inspectionRunSummary {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*inspectionRunSummary, 0, min(limit, len(r.runs)))
+	for i := len(r.runs) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, r.runs[i])
+	}
+	return out
+}
+
+func (r *inspectionRunHistory) ids() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]string, 0, len(r.runs))
+	for i := len(r.runs) - 1; i >= 0; i-- {
+		ids = append(ids, r.runs[i].ID)
+	}
+	return ids
+}
+
+// GetAuthInspectionReasons groups the invalid auths of the last completed
+// inspection run, or of the run named by ?run_id=, by reason code and by
+// provider.
+func (h *Handler) GetAuthInspectionReasons(c *gin.Context) {
+	runID := strings.TrimSpace(c.Query("run_id"))
+	summary, ok := h.inspectionRuns.get(runID)
+	if !ok {
+		if runID == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no completed inspection run"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "inspection run not found", "runs": h.inspectionRuns.ids()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "run": summary.payload(), "runs": h.inspectionRuns.ids()})
+}
@@ -0,0 +1,196 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthInspectionReasons_SystemicRunSkipsDelete(t *testing.T) {
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	var paths []string
+	for i := 0; i < 12; i++ {
+		name := fmt.Sprintf("codex-%02d.json", i)
+		path := filepath.Join(authDir, name)
+		if err := os.WriteFile(path, []byte(`{"type":"codex"}`), 0o600); err != nil {
+			t.Fatalf("write auth file: %v", err)
+		}
+		paths = append(paths, path)
+		auth := &coreauth.Auth{ID: name, FileName: name, Provider: "codex", Status: coreauth.StatusActive, Attributes: map[string]string{"path": path}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+	// Eleven accounts hit the same 403, one has a revoked refresh token.
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		if auth.ID == "codex-00.json" {
+			return true, "token refresh failed: invalid_grant", nil
+		}
+		return true, "403 forbidden", nil
+	}))
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection.SkipDeleteOnSystemic = true
+	cfg.AuthInspection.AutoDeleteInvalid = true
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("systemic run must keep %s: %v", path, err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-files/inspection/reasons", nil)
+	h.GetAuthInspectionReasons(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Run struct {
+			ID              string `json:"id"`
+			Invalid         int    `json:"invalid"`
+			SuspectSystemic bool   `json:"suspect_systemic"`
+			DeleteSkipped   bool   `json:"delete_skipped"`
+			DominantReason  string `json:"dominant_reason"`
+			ByReason        []struct {
+				ReasonCode string   `json:"reason_code"`
+				Count      int      `json:"count"`
+				Examples   []string `json:"examples"`
+			} `json:"by_reason"`
+			ByProvider []struct {
+				Provider string `json:"provider"`
+				Count    int    `json:"count"`
+			} `json:"by_provider"`
+		} `json:"run"`
+		Runs []string `json:"runs"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	run := resp.Run
+	if run.Invalid != 12 || !run.SuspectSystemic || !run.DeleteSkipped || run.DominantReason != "http_403" {
+		t.Fatalf("unexpected run: %s", rec.Body.String())
+	}
+	if len(run.ByReason) != 2 || run.ByReason[0].Count != 11 || len(run.ByReason[0].Examples) != inspectionReasonExamples || run.ByReason[1].ReasonCode != "invalid_grant" {
+		t.Fatalf("unexpected reason groups: %+v", run.ByReason)
+	}
+	if len(run.ByProvider) != 1 || run.ByProvider[0].Provider != "codex" || run.ByProvider[0].Count != 12 {
+		t.Fatalf("unexpected provider groups: %+v", run.ByProvider)
+	}
+	if len(resp.Runs) != 1 || resp.Runs[0] != run.ID {
+		t.Fatalf("unexpected run ids: %v", resp.Runs)
+	}
+
+	rec = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-files/inspection/reasons?run_id=missing", nil)
+	h.GetAuthInspectionReasons(c)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown run id: status %d", rec.Code)
+	}
+
+	// Without the safeguard the same run deletes the files.
+	cfg.AuthInspection.SkipDeleteOnSystemic = false
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+	if _, err := os.Stat(paths[1]); !os.IsNotExist(err) {
+		t.Fatalf("invalid file should be deleted, stat err = %v", err)
+	}
+}
+
+func TestReasonBucket(t *testing.T) {
+	cases := map[string]string{
+		"":                      "unknown",
+		"401 invalid_token":     "401 invalid_token",
+		"Account_Deactivated  ": "account_deactivated",
+		"token refresh failed: request req_8f3a9c21d4 at 2026-10-16T08:01:02Z": "token refresh failed: request <id> at <time>",
+		"user 6f1c2b3a-1d2e-4f50-9a8b-0c1d2e3f4a5b banned since 1760601662":    "user <id> banned since <n>",
+	}
+	for reason, want := range cases {
+		if got := reasonBucket(reason); got != want {
+			t.Errorf("reasonBucket(%q) = %q, want %q", reason, got, want)
+		}
+	}
+}
+
+func TestAuthInspection_ReasonHistogram(t *testing.T) {
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, authDir, "codex", 4)
+	registerInspectionFixtures(t, manager, authDir, "claude", 1)
+	inspector := coreauth.NewInspector(manager)
+	probe := coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		switch auth.ID {
+		case "codex-00.json":
+			return false, "", nil
+		case "codex-01.json", "codex-02.json":
+			return true, fmt.Sprintf("401 invalid_token (request req_%s99999)", auth.ID[:8]), nil
+		}
+		return true, "account_deactivated", nil
+	})
+	inspector.RegisterProbe("codex", probe)
+	inspector.RegisterProbe("claude", probe)
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager}
+	h.cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex"}, {Name: "claude"}}
+	h.SetInspector(inspector)
+
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+
+	status := h.authInspectionStatusPayload()
+	want := map[string]int{"401 invalid_token (request <id>)": 2, "account_deactivated": 2}
+	if got := status["reason_histogram"].(map[string]int); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("status reason_histogram = %v, want %v", got, want)
+	}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-files/invalid-summary", nil)
+	h.GetInvalidAuthSummary(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Invalid         int                              `json:"invalid"`
+		ReasonHistogram map[string]int                   `json:"reason_histogram"`
+		Providers       map[string]*invalidReasonSummary `json:"providers"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Invalid != 4 || fmt.Sprint(resp.ReasonHistogram) != fmt.Sprint(want) {
+		t.Fatalf("unexpected summary: %s", rec.Body.String())
+	}
+	codex, claude := resp.Providers["codex"], resp.Providers["claude"]
+	if codex == nil || codex.Invalid != 3 || codex.ReasonHistogram["account_deactivated"] != 1 || claude == nil || claude.ReasonHistogram["account_deactivated"] != 1 {
+		t.Fatalf("unexpected providers: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-files/invalid-summary?provider=claude", nil)
+	h.GetInvalidAuthSummary(c)
+	var filtered struct {
+		Invalid   int                              `json:"invalid"`
+		Providers map[string]*invalidReasonSummary `json:"providers"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &filtered); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if filtered.Invalid != 1 || len(filtered.Providers) != 1 {
+		t.Fatalf("provider filter: %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,129 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthInspection_RecentResultsKeepLatest(t *testing.T) {
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, t.TempDir(), "codex", authInspectionRecentResults+5)
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
+		if auth.FileName == "codex-54.json" {
+			coreauth.RecordProbeStatus(ctx, 401)
+			return true, "401 revoked", nil
+		}
+		coreauth.RecordProbeStatus(ctx, 200)
+		return false, "", nil
+	}))
+	cfg := &config.Config{}
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex", Concurrency: 4}}
+	cfg.AuthInspection.VerifyBatchSize = 10
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+	results, _ := h.authInspectionStatusPayload()["recent_results"].([]coreauth.VerifyResult)
+	if len(results) != authInspectionRecentResults {
+		t.Fatalf("recent results = %d, want %d", len(results), authInspectionRecentResults)
+	}
+	if first := results[0].Name; first != fmt.Sprintf("codex-%02d.json", 5) {
+		t.Fatalf("oldest kept result = %s", first)
+	}
+	last := results[len(results)-1]
+	if last.Name != "codex-54.json" || last.Outcome != coreauth.OutcomeInvalid || last.StatusCode != 401 || last.Reason != "401 revoked" {
+		t.Fatalf("latest result = %+v", last)
+	}
+}
+
+func TestRunAuthInspectionNow_SingleAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, t.TempDir(), "codex", 3)
+	disabled := &coreauth.Auth{ID: "codex-disabled.json", FileName: "codex-disabled.json", Provider: "codex", Status: coreauth.StatusDisabled, Disabled: true}
+	if _, err := manager.Register(context.Background(), disabled); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	// The full run blocks on its first auth while the single probes go through.
+	blocking, release := make(chan struct{}, 1), make(chan struct{})
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
+		switch auth.FileName {
+		case "codex-00.json":
+			blocking <- struct{}{}
+			<-release
+		case "codex-02.json":
+			coreauth.RecordProbeStatus(ctx, 401)
+			return true, "401 revoked", nil
+		}
+		return false, "", nil
+	}))
+	cfg := &config.Config{}
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex", Concurrency: 1}}
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+	run := func(query string) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-inspection/run?"+query, nil)
+		h.RunAuthInspectionNow(c)
+		var resp map[string]any
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.runAuthInspection(context.Background(), "manual", nil, false)
+		close(done)
+	}()
+	select {
+	case <-blocking:
+	case <-time.After(2 * time.Second):
+		t.Fatal("inspection never started probing")
+	}
+
+	code, resp := run("name=codex-02.json")
+	result, _ := resp["result"].(map[string]any)
+	if code != http.StatusOK || result["outcome"] != coreauth.OutcomeInvalid || result["status_code"] != float64(401) || result["reason"] != "401 revoked" {
+		t.Fatalf("single invalid = %d %v", code, resp)
+	}
+	if invalid, _ := coreauth.TokenInvalidState(mustGetAuth(t, manager, "codex-02.json")); !invalid {
+		t.Fatal("single probe did not mark the auth invalid")
+	}
+	if code, resp = run("id=codex-01.json"); code != http.StatusOK || resp["result"].(map[string]any)["outcome"] != coreauth.OutcomeValid {
+		t.Fatalf("single valid = %d %v", code, resp)
+	}
+	if code, _ = run("id=missing.json"); code != http.StatusNotFound {
+		t.Fatalf("unknown id: status %d", code)
+	}
+	if code, _ = run("id=codex-disabled.json"); code != http.StatusConflict {
+		t.Fatalf("disabled auth: status %d", code)
+	}
+
+	// The running inspection's status is untouched.
+	payload := h.authInspectionStatusPayload()
+	if payload["running"] != true || payload["trigger"] != "manual" || payload["checked"] != 0 || payload["invalid"] != 0 {
+		t.Fatalf("status during single probes = running %v trigger %v checked %v invalid %v", payload["running"], payload["trigger"], payload["checked"], payload["invalid"])
+	}
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("inspection did not finish")
+	}
+	if payload = h.authInspectionStatusPayload(); payload["checked"] != 3 || payload["invalid"] != 1 {
+		t.Fatalf("status after the run = checked %v invalid %v", payload["checked"], payload["invalid"])
+	}
+}
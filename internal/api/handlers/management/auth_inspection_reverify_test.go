@@ -0,0 +1,58 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthInspection_MinReverifySkipsFreshAuths(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, t.TempDir(), "codex", 3)
+	var probes atomic.Int32
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(context.Context, *coreauth.Auth) (bool, string, error) {
+		probes.Add(1)
+		return false, "", nil
+	}))
+	cfg := &config.Config{}
+	cfg.AuthInspection.MinReverifySeconds = 1800
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+	verify := func(query string) map[string]any {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex"+query, nil)
+		h.VerifyInvalidAuthFiles(c)
+		var resp map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("verify%s: status %d body=%s", query, rec.Code, rec.Body.String())
+		}
+		return resp
+	}
+
+	if resp := verify(""); resp["checked"] != float64(3) || resp["skipped"] != float64(0) {
+		t.Fatalf("first verify = checked %v skipped %v", resp["checked"], resp["skipped"])
+	}
+	if resp := verify(""); resp["checked"] != float64(0) || resp["skipped"] != float64(3) || probes.Load() != 3 {
+		t.Fatalf("second verify = checked %v skipped %v probes %d", resp["checked"], resp["skipped"], probes.Load())
+	}
+	if resp := verify("&force=true"); resp["checked"] != float64(3) || probes.Load() != 6 {
+		t.Fatalf("forced verify = checked %v probes %d", resp["checked"], probes.Load())
+	}
+
+	// The scheduled path honours the TTL too and reports the skips.
+	h.runAuthInspection(context.Background(), "scheduled", nil, false)
+	payload := h.authInspectionStatusPayload()
+	if payload["checked"] != 0 || payload["skipped"] != 3 || probes.Load() != 6 {
+		t.Fatalf("status = checked %v skipped %v probes %d", payload["checked"], payload["skipped"], probes.Load())
+	}
+}
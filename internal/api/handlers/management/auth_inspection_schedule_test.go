@@ -0,0 +1,177 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthInspection_ProviderOverridesScheduleIndependently(t *testing.T) {
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	codexPaths := registerInspectionFixtures(t, manager, authDir, "codex", 2)
+	geminiPaths := registerInspectionFixtures(t, manager, authDir, "gemini-cli", 2)
+
+	probed := make(map[string]int)
+	inspector := coreauth.NewInspector(manager)
+	for _, provider := range []string{"codex", "gemini-cli"} {
+		inspector.RegisterProbe(provider, coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+			probed[auth.Provider]++
+			return true, "401 revoked", nil
+		}))
+	}
+	autoDelete := true
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection = config.AuthInspectionConfig{
+		Enabled:         true,
+		IntervalSeconds: 24 * 3600,
+		Providers:       []config.AuthInspectionProvider{{Name: "codex", Concurrency: 1}, {Name: "gemini-cli", Concurrency: 1}},
+		ProviderOverrides: map[string]config.AuthInspectionOverride{
+			"Codex": {IntervalSeconds: 60, AutoDeleteInvalid: &autoDelete},
+		},
+	}
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+
+	// The override interval is clamped like the top-level one.
+	effective := h.effectiveAuthInspectionConfig()
+	if got := inspectionConfigFor(effective, "codex").IntervalSeconds; got != minAuthInspectionIntervalSeconds {
+		t.Fatalf("codex interval = %d, want %d", got, minAuthInspectionIntervalSeconds)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if due := h.dueAuthInspectionProviders(effective, now); len(due) != 0 {
+		t.Fatalf("due before any interval elapsed: %v", due)
+	}
+	if next, _ := h.authInspectionStatusPayload()["next_run_at"].(time.Time); !next.Equal(now.Add(time.Hour)) {
+		t.Fatalf("next_run_at = %v, want the codex run", next)
+	}
+	due := h.dueAuthInspectionProviders(effective, now.Add(time.Hour))
+	if len(due) != 1 || due[0] != "codex" {
+		t.Fatalf("due after an hour = %v, want [codex]", due)
+	}
+
+	h.runAuthInspection(context.Background(), "scheduled", due, false)
+	h.rescheduleAuthInspection(effective, due, now.Add(time.Hour))
+	if probed["codex"] != 2 || probed["gemini-cli"] != 0 {
+		t.Fatalf("probed = %v, want codex only", probed)
+	}
+	for _, path := range codexPaths {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("codex override should auto-delete %s", path)
+		}
+	}
+	for _, path := range geminiPaths {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("gemini-cli file removed: %v", err)
+		}
+	}
+
+	schedules := h.authInspectionStatusPayload()["schedules"].(gin.H)
+	codex, gemini := schedules["codex"].(gin.H), schedules["gemini-cli"].(gin.H)
+	if codex["last_checked"] != 2 || codex["last_invalid"] != 2 || codex["auto_delete_invalid"] != true {
+		t.Fatalf("codex schedule = %+v", codex)
+	}
+	if next, _ := codex["next_run_at"].(time.Time); !next.Equal(now.Add(2 * time.Hour)) {
+		t.Fatalf("codex next_run_at = %v", next)
+	}
+	if next, _ := gemini["next_run_at"].(time.Time); !next.Equal(now.Add(24*time.Hour)) || gemini["auto_delete_invalid"] != false {
+		t.Fatalf("gemini-cli schedule = %+v", gemini)
+	}
+	if _, ran := gemini["last_checked"]; ran {
+		t.Fatalf("gemini-cli reports a run it never had: %+v", gemini)
+	}
+}
+
+func TestAuthInspection_JitterDelaysScheduledRuns(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.AuthInspection = config.AuthInspectionConfig{Enabled: true, IntervalSeconds: 3600, JitterSeconds: 600}
+	h := &Handler{cfg: cfg}
+	effective := h.effectiveAuthInspectionConfig()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	base := now.Add(time.Hour)
+	seen := make(map[time.Time]struct{})
+	for i := 0; i < 50; i++ {
+		next := nextAuthInspectionRun(effective, now)
+		if next.Before(base) || next.After(base.Add(600*time.Second)) {
+			t.Fatalf("jittered next run %v outside [%v, +600s]", next, base)
+		}
+		seen[next] = struct{}{}
+	}
+	if len(seen) < 2 {
+		t.Fatal("jitter never varied the next run")
+	}
+
+	// The status reports the jittered time the scheduler will actually use.
+	if due := h.dueAuthInspectionProviders(effective, now); len(due) != 0 {
+		t.Fatalf("due = %v", due)
+	}
+	h.inspectionMu.RLock()
+	scheduled := h.inspectionStatus.Schedules["codex"].NextRunAt
+	h.inspectionMu.RUnlock()
+	if next, _ := h.authInspectionStatusPayload()["next_run_at"].(time.Time); !next.Equal(scheduled) || next.Before(base) {
+		t.Fatalf("next_run_at = %v, scheduled %v", next, scheduled)
+	}
+
+	cfg.AuthInspection.JitterSeconds = 10 * maxAuthInspectionJitterSeconds
+	if got := h.effectiveAuthInspectionConfig().JitterSeconds; got != maxAuthInspectionJitterSeconds {
+		t.Fatalf("jitter = %d, want clamp to %d", got, maxAuthInspectionJitterSeconds)
+	}
+}
+
+func TestAuthInspection_PauseHoldsScheduledRuns(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg := &config.Config{}
+	cfg.AuthInspection = config.AuthInspectionConfig{Enabled: true, IntervalSeconds: 3600}
+	h := &Handler{cfg: cfg, configFilePath: configPath}
+	effective := h.effectiveAuthInspectionConfig()
+
+	post := func(handler gin.HandlerFunc) gin.H {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-inspection/pause", nil)
+		handler(c)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+		}
+		return h.authInspectionStatusPayload()
+	}
+
+	start := time.Now().Add(-2 * time.Hour)
+	h.dueAuthInspectionProviders(effective, start)
+	missed := start.Add(time.Hour)
+	if payload := post(h.PauseAuthInspection); payload["paused"] != true {
+		t.Fatalf("pause payload = %+v", payload)
+	}
+	if due := h.dueAuthInspectionProviders(effective, time.Now()); len(due) != 0 {
+		t.Fatalf("due while paused = %v", due)
+	}
+	if next, _ := h.authInspectionStatusPayload()["next_run_at"].(time.Time); !next.Equal(missed) {
+		t.Fatalf("paused next_run_at = %v, want the missed run %v", next, missed)
+	}
+
+	before := time.Now()
+	payload := post(h.ResumeAuthInspection)
+	if next, _ := payload["next_run_at"].(time.Time); payload["paused"] != false || next.Before(before.Add(time.Hour)) {
+		t.Fatalf("resume payload = %+v", payload)
+	}
+	if due := h.dueAuthInspectionProviders(effective, time.Now()); len(due) != 0 {
+		t.Fatalf("resume ran the missed schedule: %v", due)
+	}
+	if saved, err := os.ReadFile(configPath); err != nil || string(saved) != "port: 8317\n" {
+		t.Fatalf("pause touched the config file: %q (%v)", saved, err)
+	}
+}
@@ -2,40 +2,149 @@ package management
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/backup"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
 )
 
 const (
 	defaultAuthInspectionIntervalSeconds = 3600
 	minAuthInspectionIntervalSeconds     = 3600
 	maxAuthInspectionIntervalSeconds     = 7 * 24 * 3600
+	maxAuthInspectionJitterSeconds       = 3600
 	authInspectionVerifyConcurrency      = 40
+	maxAuthInspectionVerifyConcurrency   = 200
+	maxAuthInspectionDefaultConcurrency  = 100
 	authInspectionVerifyBatchSize        = 100
+	minAuthInspectionVerifyBatchSize     = 10
+	maxAuthInspectionVerifyBatchSize     = 500
 	authInspectionVerifyMaxRounds        = 20000
-	authInspectionRunTimeout             = 2 * time.Hour
+	authInspectionRunTimeoutSeconds      = 2 * 3600
+	authInspectionRecentResults          = 50
+	minAuthInspectionRunTimeoutSeconds   = 60
+	maxAuthInspectionRunTimeoutSeconds   = 24 * 3600
+	maxAuthInspectionProbeRatePerMinute  = 6000
+	authInspectionStartDelaySeconds      = 30
+	maxAuthInspectionStartDelaySeconds   = 600
+	authInspectionVerifyCacheSeconds     = 60
+	maxAuthInspectionVerifyCacheSeconds  = 3600
+	authInspectionProbeFailures          = 3
+	maxAuthInspectionProbeFailures       = 100
+	authInspectionProbeBackoffSeconds    = 300
+	minAuthInspectionProbeBackoffSeconds = 60
+	maxAuthInspectionProbeBackoffSeconds = 24 * 3600
 )
 
+// authInspectionStatus is saved after every run, see saveInspectionState;
+// the fields tagged "-" only describe this process.
 type authInspectionStatus struct {
-	Running          bool
-	Trigger          string
-	CurrentFile      string
-	RecentChecked    []string
+	Running         bool `json:"-"`
+	Trigger         string
+	CurrentProvider string
+	CurrentFile     string
+	RecentChecked   []string
+	RecentResults   []coreauth.VerifyResult
+	Checked         int
+	Valid           int
+	Invalid         int
+	Errors          int
+	Throttled       int
+	Deleted         int
+	Disabled        int
+	Recovered       int
+	Total           int
+	Frozen          int
+	Filtered        int
+	Skipped         int
+	BackedOff       int
+	Round           int
+	LastError       string
+	NewInvalid      []string
+	NewRecovered    []string
+	Cancelled       bool
+	CancelReason    string
+	Interrupted     bool
+	Paused          bool `json:"-"`
+	// Queued is set while a manual run waits for the current one to finish;
+	// QueuedDryRun holds whether it reports instead of deleting.
+	Queued       bool `json:"-"`
+	QueuedDryRun bool `json:"-"`
+	DryRun       bool
+	WouldDelete  []string
+	// ReasonHistogram counts the run's invalid reasons by reasonBucket.
+	ReasonHistogram  map[string]int
+	LastRunStartedAt time.Time
+	LastRunFinished  time.Time
+	// StartedSinceBoot is set once this process starts a run; the times
+	// above may come from a saved status.
+	StartedSinceBoot bool `json:"-"`
+	// NextRunAt is the earliest of the provider schedules.
+	NextRunAt time.Time
+	LastRunBy string
+	// Providers holds each provider's progress in the current or last run;
+	// the counters above are their totals.
+	Providers map[string]*authInspectionProviderStatus
+	// Schedules holds each configured provider's next scheduled run and the
+	// counters of its last finished run, which outlive runs that skip it.
+	Schedules map[string]*authInspectionProviderSchedule
+	// NamedSchedules does the same for each named schedule; while any are
+	// configured they alone set NextRunAt.
+	NamedSchedules map[string]*authInspectionProviderSchedule
+}
+
+// authInspectionProviderSchedule is one provider's or named schedule's place
+// in the schedule.
+type authInspectionProviderSchedule struct {
+	NextRunAt        time.Time
+	LastRunStartedAt time.Time
+	LastRunFinished  time.Time
 	Checked          int
 	Valid            int
 	Invalid          int
-	Deleted          int
-	Total            int
-	Round            int
+	Frozen           int
+	Filtered         int
 	LastError        string
-	LastRunStartedAt time.Time
-	LastRunFinished  time.Time
-	NextRunAt        time.Time
+}
+
+// authInspectionProviderStatus is one provider's share of a run.
+type authInspectionProviderStatus struct {
+	Running     bool
+	Concurrency int
+	Total       int
+	Checked     int
+	Valid       int
+	Invalid     int
+	Errors      int
+	Throttled   int
+	Frozen      int
+	Filtered    int
+	Skipped     int
+	BackedOff   int
+	Round       int
+	CurrentFile string
+	LastError   string
+	// Cursor is where the next batch starts; Batches and BatchTime time the
+	// batches done so far, for the run's ETA.
+	Cursor      int
+	BatchSize   int
+	Batches     int
+	BatchTime   time.Duration
+	LastBatchAt time.Time
 }
 
 func (h *Handler) startAuthInspectionScheduler() {
@@ -44,11 +153,55 @@ func (h *Handler) startAuthInspectionScheduler() {
 	}
 	h.inspectionMu.Lock()
 	if h.inspectionTrigger == nil {
-		h.inspectionTrigger = make(chan string, 1)
+		h.inspectionTrigger = make(chan inspectionRequest, 1)
+	}
+	if h.inspectionWake == nil {
+		h.inspectionWake = make(chan struct{}, 1)
 	}
 	h.inspectionMu.Unlock()
 
-	go h.authInspectionSchedulerLoop()
+	h.loadInspectionState(h.effectiveAuthInspectionConfig())
+	h.life.goWorker(h.authInspectionSchedulerLoop)
+	if h.effectiveAuthInspectionConfig().RunOnStart {
+		h.life.goWorker(h.queueStartupInspection)
+	}
+}
+
+// queueStartupInspection hands the scheduler loop a "startup" run once
+// StartDelaySeconds have passed, giving the token store time to load.
+func (h *Handler) queueStartupInspection() {
+	delay := time.Duration(h.effectiveAuthInspectionConfig().StartDelaySeconds) * time.Second
+	fired, stop := h.schedulerClock().Timer(delay)
+	select {
+	case <-h.life.stopping():
+		stop()
+		return
+	case <-fired:
+	}
+	if !h.queueAuthInspection(inspectionRequest{Trigger: "startup"}) {
+		log.Debug("auth inspection: startup run not queued, trigger queue is busy")
+	}
+}
+
+// startupInspectionNeeded reports whether a queued startup run should go
+// ahead: only the leader runs it, and not once any other run has started
+// since boot, so a scheduled run already due does not fire twice.
+func (h *Handler) startupInspectionNeeded(leader bool) bool {
+	if !leader {
+		return false
+	}
+	h.inspectionMu.RLock()
+	defer h.inspectionMu.RUnlock()
+	return !h.inspectionStatus.Running && !h.inspectionStatus.StartedSinceBoot
+}
+
+// inspectionRequest asks the scheduler loop for a manual run.
+type inspectionRequest struct {
+	Trigger string
+	// DryRun reports what auto-delete would remove instead of removing it.
+	DryRun bool
+	// Schedule names the schedule whose providers and settings the run uses.
+	Schedule string
 }
 
 func (h *Handler) effectiveAuthInspectionConfig() config.AuthInspectionConfig {
@@ -59,57 +212,325 @@ func (h *Handler) effectiveAuthInspectionConfig() config.AuthInspectionConfig {
 	if cfg.IntervalSeconds <= 0 {
 		cfg.IntervalSeconds = defaultAuthInspectionIntervalSeconds
 	}
-	if cfg.IntervalSeconds < minAuthInspectionIntervalSeconds {
-		cfg.IntervalSeconds = minAuthInspectionIntervalSeconds
+	cfg.IntervalSeconds = clampAuthInspectionInterval(cfg.IntervalSeconds)
+	cfg.Cron = strings.TrimSpace(cfg.Cron)
+	cfg.JitterSeconds = min(max(cfg.JitterSeconds, 0), maxAuthInspectionJitterSeconds)
+	cfg.VerifyConcurrency = clampOrDefault(cfg.VerifyConcurrency, authInspectionVerifyConcurrency, 1, maxAuthInspectionDefaultConcurrency)
+	cfg.VerifyPoolSize = clampOrDefault(cfg.VerifyPoolSize, cfg.VerifyConcurrency, 1, maxAuthInspectionVerifyConcurrency)
+	cfg.VerifyBatchSize = clampOrDefault(cfg.VerifyBatchSize, authInspectionVerifyBatchSize, minAuthInspectionVerifyBatchSize, maxAuthInspectionVerifyBatchSize)
+	cfg.RunTimeoutSeconds = clampOrDefault(cfg.RunTimeoutSeconds, authInspectionRunTimeoutSeconds, minAuthInspectionRunTimeoutSeconds, maxAuthInspectionRunTimeoutSeconds)
+	cfg.ProbeRatePerMinute = min(max(cfg.ProbeRatePerMinute, 0), maxAuthInspectionProbeRatePerMinute)
+	cfg.MinReverifySeconds = min(max(cfg.MinReverifySeconds, 0), maxAuthInspectionIntervalSeconds)
+	cfg.VerifyCacheSeconds = clampOrDefault(cfg.VerifyCacheSeconds, authInspectionVerifyCacheSeconds, 1, maxAuthInspectionVerifyCacheSeconds)
+	cfg.ProbeFailureThreshold = clampOrDefault(cfg.ProbeFailureThreshold, authInspectionProbeFailures, 1, maxAuthInspectionProbeFailures)
+	cfg.ProbeBackoffSeconds = clampOrDefault(cfg.ProbeBackoffSeconds, authInspectionProbeBackoffSeconds, minAuthInspectionProbeBackoffSeconds, maxAuthInspectionProbeBackoffSeconds)
+	cfg.Scope, _ = parseInspectionScope(cfg.Scope)
+	cfg.StartDelaySeconds = clampOrDefault(cfg.StartDelaySeconds, authInspectionStartDelaySeconds, 1, maxAuthInspectionStartDelaySeconds)
+	cfg.Providers = normalizeInspectionProviders(cfg.Providers, cfg.VerifyConcurrency)
+	cfg.ProviderOverrides = normalizeInspectionOverrides(cfg.ProviderOverrides)
+	cfg.Verification = normalizeInspectionVerification(cfg.Verification)
+	cfg.Schedules = normalizeInspectionSchedules(cfg)
+	cfg.IncludePatterns = normalizeInspectionPatterns(cfg.IncludePatterns)
+	cfg.ExcludePatterns = normalizeInspectionPatterns(cfg.ExcludePatterns)
+	cfg.IncludeTags, _ = normalizeAuthTags(cfg.IncludeTags)
+	cfg.ExcludeTags, _ = normalizeAuthTags(cfg.ExcludeTags)
+	return cfg
+}
+
+// clampOrDefault returns def for unset values and clamps the others to [lo, hi].
+func clampOrDefault(value, def, lo, hi int) int {
+	if value <= 0 {
+		return def
 	}
-	if cfg.IntervalSeconds > maxAuthInspectionIntervalSeconds {
-		cfg.IntervalSeconds = maxAuthInspectionIntervalSeconds
+	return min(max(value, lo), hi)
+}
+
+func clampAuthInspectionInterval(seconds int) int {
+	if seconds < minAuthInspectionIntervalSeconds {
+		return minAuthInspectionIntervalSeconds
+	}
+	if seconds > maxAuthInspectionIntervalSeconds {
+		return maxAuthInspectionIntervalSeconds
+	}
+	return seconds
+}
+
+// normalizeInspectionOverrides lowercases provider names and clamps override
+// intervals like the top-level one. It returns a copy so the live config is
+// never modified.
+func normalizeInspectionOverrides(in map[string]config.AuthInspectionOverride) map[string]config.AuthInspectionOverride {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]config.AuthInspectionOverride, len(in))
+	for name, override := range in {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if override.IntervalSeconds > 0 {
+			override.IntervalSeconds = clampAuthInspectionInterval(override.IntervalSeconds)
+		} else {
+			override.IntervalSeconds = 0
+		}
+		out[name] = override
+	}
+	return out
+}
+
+// inspectionConfigFor returns cfg as it applies to provider: an override
+// interval replaces the top-level schedule, cron included, and an override
+// auto-delete setting replaces the top-level one.
+func inspectionConfigFor(cfg config.AuthInspectionConfig, provider string) config.AuthInspectionConfig {
+	override, ok := cfg.ProviderOverrides[provider]
+	if !ok {
+		return cfg
+	}
+	if override.IntervalSeconds > 0 {
+		cfg.IntervalSeconds = override.IntervalSeconds
+		cfg.Cron = ""
+	}
+	if override.AutoDeleteInvalid != nil {
+		cfg.AutoDeleteInvalid = *override.AutoDeleteInvalid
 	}
 	return cfg
 }
 
-func (h *Handler) authInspectionSchedulerLoop() {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
+// inspectionScheduleChanged reports whether b schedules runs differently
+// from a.
+func inspectionScheduleChanged(a, b config.AuthInspectionConfig) bool {
+	schedule := func(cfg config.AuthInspectionConfig) config.AuthInspectionConfig {
+		return config.AuthInspectionConfig{
+			Enabled:           cfg.Enabled,
+			IntervalSeconds:   cfg.IntervalSeconds,
+			Cron:              cfg.Cron,
+			JitterSeconds:     cfg.JitterSeconds,
+			Providers:         cfg.Providers,
+			ProviderOverrides: cfg.ProviderOverrides,
+			Schedules:         cfg.Schedules,
+		}
+	}
+	return !reflect.DeepEqual(schedule(a), schedule(b))
+}
 
-	nextRun := time.Time{}
-	for range ticker.C {
-		select {
-		case trigger := <-h.inspectionTrigger:
-			cfg := h.effectiveAuthInspectionConfig()
-			h.runAuthInspection(context.Background(), strings.TrimSpace(trigger), cfg.AutoDeleteInvalid)
-			if cfg.Enabled {
-				nextRun = time.Now().Add(time.Duration(cfg.IntervalSeconds) * time.Second)
-			} else {
-				nextRun = time.Time{}
+// nextAuthInspectionRun returns when the next scheduled inspection is due
+// after now: the next cron match when a cron is set, otherwise one interval
+// from now, plus up to JitterSeconds of random delay. An invalid cron yields
+// the zero time, so nothing is scheduled.
+func nextAuthInspectionRun(cfg config.AuthInspectionConfig, now time.Time) time.Time {
+	next := now.Add(time.Duration(cfg.IntervalSeconds) * time.Second)
+	if cfg.Cron != "" {
+		schedule, err := backup.ParseCron(cfg.Cron)
+		if err != nil {
+			return time.Time{}
+		}
+		next = schedule.NextIn(now, time.Local)
+	}
+	if next.IsZero() || cfg.JitterSeconds <= 0 {
+		return next
+	}
+	return next.Add(time.Duration(rand.IntN(cfg.JitterSeconds+1)) * time.Second)
+}
+
+// normalizeInspectionProviders lowercases and dedupes provider names and
+// clamps their concurrency, defaulting it to defaultConcurrency. An empty list
+// means codex only.
+func normalizeInspectionProviders(in []config.AuthInspectionProvider, defaultConcurrency int) []config.AuthInspectionProvider {
+	out := make([]config.AuthInspectionProvider, 0, len(in))
+	seen := make(map[string]struct{}, len(in))
+	for _, provider := range in {
+		name := strings.ToLower(strings.TrimSpace(provider.Name))
+		if name == "" {
+			continue
+		}
+		if _, dup := seen[name]; dup {
+			continue
+		}
+		seen[name] = struct{}{}
+		concurrency := provider.Concurrency
+		if concurrency <= 0 {
+			concurrency = defaultConcurrency
+		}
+		if concurrency > maxAuthInspectionVerifyConcurrency {
+			concurrency = maxAuthInspectionVerifyConcurrency
+		}
+		out = append(out, config.AuthInspectionProvider{Name: name, Concurrency: concurrency})
+	}
+	if len(out) == 0 {
+		out = append(out, config.AuthInspectionProvider{Name: "codex", Concurrency: defaultConcurrency})
+	}
+	return out
+}
+
+// schedulerClock is the time source of the inspection scheduler loop, so tests
+// can drive it with a fake clock.
+type schedulerClock interface {
+	Now() time.Time
+	// Timer returns a channel that receives once d has elapsed and a func
+	// that stops the timer.
+	Timer(d time.Duration) (<-chan time.Time, func() bool)
+}
+
+type wallClock struct{}
+
+func (wallClock) Now() time.Time { return time.Now() }
+
+func (wallClock) Timer(d time.Duration) (<-chan time.Time, func() bool) {
+	timer := time.NewTimer(d)
+	return timer.C, timer.Stop
+}
+
+func (h *Handler) schedulerClock() schedulerClock {
+	if h.inspectionClock != nil {
+		return h.inspectionClock
+	}
+	return wallClock{}
+}
+
+// authInspectionSchedulerLoop sleeps until the earliest scheduled run, the
+// next lease renewal of a shared store, a manual trigger or a wake-up after
+// the config or schedule changed. With nothing scheduled and no lease to
+// renew it waits without a timer.
+func (h *Handler) authInspectionSchedulerLoop() {
+	clock := h.schedulerClock()
+	shared := h.inspectionLeases() != nil
+	nextLeaseCheck := time.Time{}
+	leader := !shared
+	for {
+		now := clock.Now()
+		if shared && !now.Before(nextLeaseCheck) {
+			nextLeaseCheck = now.Add(authInspectionLeaseRenew)
+			leader = h.refreshInspectionLeadership()
+			if leader {
+				if requester := h.takeLeaderInspectionRequest(); requester != "" {
+					h.queueAuthInspection(inspectionRequest{Trigger: "manual:" + requester})
+				}
 			}
-			h.updateAuthInspectionNextRun(nextRun)
-		default:
 		}
 
-		cfg := h.effectiveAuthInspectionConfig()
-		h.inspectionMu.RLock()
-		statusNextRun := h.inspectionStatus.NextRunAt
-		h.inspectionMu.RUnlock()
-		if !statusNextRun.IsZero() && (nextRun.IsZero() || !statusNextRun.Equal(nextRun)) {
-			nextRun = statusNextRun
-		}
-		if !cfg.Enabled {
-			nextRun = time.Time{}
-			h.updateAuthInspectionNextRun(nextRun)
+		if req, ok := h.takeQueuedInspection(); ok {
+			h.runCoordinatedInspection(req, nil)
+			h.rescheduleAuthInspection(h.effectiveAuthInspectionConfig(), nil, clock.Now())
 			continue
 		}
-		if nextRun.IsZero() {
-			nextRun = time.Now().Add(time.Duration(cfg.IntervalSeconds) * time.Second)
-			h.updateAuthInspectionNextRun(nextRun)
+
+		cfg := h.effectiveAuthInspectionConfig()
+		// Named schedules run one at a time, so one falling due during
+		// another's run starts when it finishes.
+		if name := h.dueInspectionSchedule(cfg, now); name != "" {
+			if leader {
+				h.runCoordinatedInspection(inspectionRequest{Trigger: "scheduled:" + name, Schedule: name}, nil)
+			}
+			h.rescheduleInspectionSchedule(h.effectiveAuthInspectionConfig(), name, clock.Now())
+			continue
 		}
-		if time.Now().Before(nextRun) {
+		if due := h.dueAuthInspectionProviders(cfg, now); len(due) > 0 {
+			// Followers skip scheduled runs; the leader's own schedule covers them.
+			if leader {
+				h.runCoordinatedInspection(inspectionRequest{Trigger: "scheduled"}, due)
+			}
+			h.rescheduleAuthInspection(h.effectiveAuthInspectionConfig(), due, clock.Now())
 			continue
 		}
 
-		h.runAuthInspection(context.Background(), "scheduled", cfg.AutoDeleteInvalid)
-		nextRun = time.Now().Add(time.Duration(cfg.IntervalSeconds) * time.Second)
-		h.updateAuthInspectionNextRun(nextRun)
+		wake := h.nextSchedulerWake()
+		if shared && (wake.IsZero() || nextLeaseCheck.Before(wake)) {
+			wake = nextLeaseCheck
+		}
+		var fired <-chan time.Time
+		stop := func() bool { return false }
+		if !wake.IsZero() {
+			fired, stop = clock.Timer(max(wake.Sub(now), 0))
+		}
+		select {
+		case <-h.life.stopping():
+			stop()
+			return
+		case req := <-h.inspectionTrigger:
+			stop()
+			if req.Trigger == "startup" && !h.startupInspectionNeeded(leader) {
+				log.Debug("auth inspection: skipping startup run, another run already started")
+				continue
+			}
+			req.Trigger = strings.TrimSpace(req.Trigger)
+			h.runCoordinatedInspection(req, nil)
+			// A cron schedule is anchored to the clock, so a manual run
+			// leaves it where it was.
+			h.rescheduleAuthInspection(h.effectiveAuthInspectionConfig(), nil, clock.Now())
+		case <-h.inspectionWake:
+			stop()
+		case <-fired:
+		}
+	}
+}
+
+// nextSchedulerWake returns the earliest scheduled run, or the zero time when
+// the scheduler is disabled or paused.
+func (h *Handler) nextSchedulerWake() time.Time {
+	h.inspectionMu.RLock()
+	defer h.inspectionMu.RUnlock()
+	if h.inspectionStatus.Paused {
+		return time.Time{}
+	}
+	return h.inspectionStatus.NextRunAt
+}
+
+// wakeAuthInspectionScheduler makes the scheduler loop re-read the config and
+// schedule without blocking.
+func (h *Handler) wakeAuthInspectionScheduler() {
+	h.inspectionMu.RLock()
+	ch := h.inspectionWake
+	h.inspectionMu.RUnlock()
+	if ch == nil {
+		return
+	}
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// queueBehindRunningInspection records a manual run to start once the
+// current run finishes and reports whether a run is in progress. Repeated
+// calls coalesce into one pending run, which is a dry run only if every
+// request asked for one.
+func (h *Handler) queueBehindRunningInspection(dryRun bool) bool {
+	h.inspectionMu.Lock()
+	defer h.inspectionMu.Unlock()
+	if !h.inspectionStatus.Running {
+		return false
+	}
+	h.inspectionStatus.QueuedDryRun = dryRun && (!h.inspectionStatus.Queued || h.inspectionStatus.QueuedDryRun)
+	h.inspectionStatus.Queued = true
+	return true
+}
+
+// takeQueuedInspection clears and returns the run queued behind the last one.
+func (h *Handler) takeQueuedInspection() (inspectionRequest, bool) {
+	h.inspectionMu.Lock()
+	defer h.inspectionMu.Unlock()
+	if !h.inspectionStatus.Queued || h.inspectionStatus.Running {
+		return inspectionRequest{}, false
+	}
+	req := inspectionRequest{Trigger: "manual", DryRun: h.inspectionStatus.QueuedDryRun}
+	h.inspectionStatus.Queued = false
+	h.inspectionStatus.QueuedDryRun = false
+	return req, true
+}
+
+// queueAuthInspection hands req to the scheduler loop without blocking.
+func (h *Handler) queueAuthInspection(req inspectionRequest) bool {
+	h.inspectionMu.RLock()
+	ch := h.inspectionTrigger
+	h.inspectionMu.RUnlock()
+	if ch == nil {
+		return false
+	}
+	select {
+	case ch <- req:
+		return true
+	default:
+		return false
 	}
 }
 
@@ -145,6 +566,41 @@ func appendRecentChecked(prev []string, names []string, limit int) []string {
 	return dedup
 }
 
+// recordInspectionResults adds a batch's probe outcomes to the recent results,
+// the reason histogram and the probe metrics, and counts the auths they
+// reactivated.
+func (h *Handler) recordInspectionResults(results []coreauth.VerifyResult) {
+	if len(results) == 0 {
+		return
+	}
+	h.inspectionMu.Lock()
+	h.inspectionStatus.RecentResults = appendRecentResults(h.inspectionStatus.RecentResults, results, authInspectionRecentResults)
+	h.inspectionStatus.ReasonHistogram = addReasonHistogram(h.inspectionStatus.ReasonHistogram, results)
+	for _, result := range results {
+		if result.Recovered {
+			h.inspectionStatus.Recovered++
+		}
+	}
+	h.inspectionMu.Unlock()
+	h.inspectionMetrics.probed(results)
+}
+
+func recentResultsOrEmpty(results []coreauth.VerifyResult) []coreauth.VerifyResult {
+	if results == nil {
+		return []coreauth.VerifyResult{}
+	}
+	return results
+}
+
+// appendRecentResults appends results to prev, keeping the last limit.
+func appendRecentResults(prev, results []coreauth.VerifyResult, limit int) []coreauth.VerifyResult {
+	merged := append(append([]coreauth.VerifyResult{}, prev...), results...)
+	if len(merged) > limit {
+		merged = merged[len(merged)-limit:]
+	}
+	return merged
+}
+
 func (h *Handler) beginAuthInspection(trigger string) bool {
 	h.inspectionMu.Lock()
 	defer h.inspectionMu.Unlock()
@@ -153,88 +609,510 @@ func (h *Handler) beginAuthInspection(trigger string) bool {
 	}
 	h.inspectionStatus.Running = true
 	h.inspectionStatus.Trigger = strings.TrimSpace(trigger)
+	h.inspectionStatus.CurrentProvider = ""
 	h.inspectionStatus.CurrentFile = ""
 	h.inspectionStatus.RecentChecked = nil
+	h.inspectionStatus.RecentResults = nil
 	h.inspectionStatus.Checked = 0
 	h.inspectionStatus.Valid = 0
 	h.inspectionStatus.Invalid = 0
+	h.inspectionStatus.Errors = 0
+	h.inspectionStatus.Throttled = 0
 	h.inspectionStatus.Deleted = 0
+	h.inspectionStatus.Disabled = 0
+	h.inspectionStatus.Recovered = 0
 	h.inspectionStatus.Total = 0
+	h.inspectionStatus.Frozen = 0
+	h.inspectionStatus.Filtered = 0
+	h.inspectionStatus.Skipped = 0
+	h.inspectionStatus.BackedOff = 0
 	h.inspectionStatus.Round = 0
 	h.inspectionStatus.LastError = ""
+	h.inspectionStatus.NewInvalid = nil
+	h.inspectionStatus.NewRecovered = nil
+	h.inspectionStatus.Cancelled = false
+	h.inspectionStatus.CancelReason = ""
+	h.inspectionStatus.Interrupted = false
+	h.inspectionStatus.DryRun = false
+	h.inspectionStatus.WouldDelete = nil
+	h.inspectionStatus.ReasonHistogram = nil
 	h.inspectionStatus.LastRunStartedAt = time.Now()
 	h.inspectionStatus.LastRunFinished = time.Time{}
+	h.inspectionStatus.StartedSinceBoot = true
+	h.inspectionStatus.Providers = nil
 	return true
 }
 
-func (h *Handler) updateAuthInspectionNextRun(next time.Time) {
+// dueAuthInspectionProviders returns the configured providers whose next run
+// is due at now, scheduling the ones that have no next run yet. Nothing is
+// due while the scheduler is paused; the missed times stay in the schedule.
+// Nothing is due either while named schedules are configured; see
+// dueInspectionSchedule.
+func (h *Handler) dueAuthInspectionProviders(cfg config.AuthInspectionConfig, now time.Time) []string {
+	h.inspectionMu.Lock()
+	defer h.inspectionMu.Unlock()
+	if !cfg.Enabled {
+		h.clearInspectionScheduleLocked()
+		return nil
+	}
+	if len(cfg.Schedules) > 0 {
+		return nil
+	}
+	var due []string
+	for _, provider := range cfg.Providers {
+		sched := h.inspectionScheduleLocked(provider.Name)
+		if sched.NextRunAt.IsZero() {
+			sched.NextRunAt = nextAuthInspectionRun(inspectionConfigFor(cfg, provider.Name), now)
+		}
+		if !h.inspectionStatus.Paused && !sched.NextRunAt.IsZero() && !now.Before(sched.NextRunAt) {
+			due = append(due, provider.Name)
+		}
+	}
+	h.syncInspectionScheduleLocked(cfg)
+	return due
+}
+
+// rescheduleAuthInspection computes the next run of providers from now, or of
+// every configured provider and named schedule when providers is nil.
+func (h *Handler) rescheduleAuthInspection(cfg config.AuthInspectionConfig, providers []string, now time.Time) {
 	h.inspectionMu.Lock()
+	defer h.inspectionMu.Unlock()
+	if !cfg.Enabled {
+		h.clearInspectionScheduleLocked()
+		return
+	}
+	if providers == nil {
+		for _, sched := range cfg.Schedules {
+			h.namedInspectionScheduleLocked(sched.Name).NextRunAt = nextAuthInspectionRun(inspectionConfigForSchedule(cfg, sched), now)
+		}
+		for _, provider := range cfg.Providers {
+			providers = append(providers, provider.Name)
+		}
+	}
+	for _, name := range providers {
+		h.inspectionScheduleLocked(name).NextRunAt = nextAuthInspectionRun(inspectionConfigFor(cfg, name), now)
+	}
+	h.syncInspectionScheduleLocked(cfg)
+}
+
+func (h *Handler) inspectionScheduleLocked(provider string) *authInspectionProviderSchedule {
+	if h.inspectionStatus.Schedules == nil {
+		h.inspectionStatus.Schedules = make(map[string]*authInspectionProviderSchedule)
+	}
+	sched, ok := h.inspectionStatus.Schedules[provider]
+	if !ok {
+		sched = &authInspectionProviderSchedule{}
+		h.inspectionStatus.Schedules[provider] = sched
+	}
+	return sched
+}
+
+// clearInspectionScheduleLocked drops every next run but keeps the counters.
+func (h *Handler) clearInspectionScheduleLocked() {
+	for _, sched := range h.inspectionStatus.Schedules {
+		sched.NextRunAt = time.Time{}
+	}
+	for _, sched := range h.inspectionStatus.NamedSchedules {
+		sched.NextRunAt = time.Time{}
+	}
+	h.inspectionStatus.NextRunAt = time.Time{}
+}
+
+// syncInspectionScheduleLocked forgets providers and named schedules that are
+// no longer configured and recomputes the earliest next run. Provider
+// schedules are dropped while named schedules replace them.
+func (h *Handler) syncInspectionScheduleLocked(cfg config.AuthInspectionConfig) {
+	configured := make(map[string]struct{}, len(cfg.Providers))
+	for _, provider := range cfg.Providers {
+		configured[provider.Name] = struct{}{}
+	}
+	named := make(map[string]struct{}, len(cfg.Schedules))
+	for _, sched := range cfg.Schedules {
+		named[sched.Name] = struct{}{}
+	}
+	next := time.Time{}
+	for name, sched := range h.inspectionStatus.Schedules {
+		if _, ok := configured[name]; !ok {
+			delete(h.inspectionStatus.Schedules, name)
+			continue
+		}
+		if len(named) > 0 {
+			sched.NextRunAt = time.Time{}
+		}
+		if !sched.NextRunAt.IsZero() && (next.IsZero() || sched.NextRunAt.Before(next)) {
+			next = sched.NextRunAt
+		}
+	}
+	for name, sched := range h.inspectionStatus.NamedSchedules {
+		if _, ok := named[name]; !ok {
+			delete(h.inspectionStatus.NamedSchedules, name)
+			continue
+		}
+		if !sched.NextRunAt.IsZero() && (next.IsZero() || sched.NextRunAt.Before(next)) {
+			next = sched.NextRunAt
+		}
+	}
 	h.inspectionStatus.NextRunAt = next
+}
+
+// startInspectionProviders resets the per-provider progress for a run.
+func (h *Handler) startInspectionProviders(providers []config.AuthInspectionProvider) {
+	h.inspectionMu.Lock()
+	h.inspectionStatus.Providers = make(map[string]*authInspectionProviderStatus, len(providers))
+	for _, provider := range providers {
+		h.inspectionStatus.Providers[provider.Name] = &authInspectionProviderStatus{Running: true, Concurrency: provider.Concurrency}
+	}
 	h.inspectionMu.Unlock()
 }
 
-func (h *Handler) updateAuthInspectionProgress(total, checked, valid, invalid, round int, currentFile string, batchNames []string) {
+// updateAuthInspectionProgress records provider's cumulative progress and
+// recomputes the run totals; Round is the furthest any provider got. The new
+// status is then published to the event stream.
+func (h *Handler) updateAuthInspectionProgress(provider string, total, checked, valid, invalid, errs, throttled, frozen, filtered, skipped, round int, currentFile string, batchNames []string) {
+	defer h.publishInspectionEvent("progress", batchNames)
 	h.inspectionMu.Lock()
-	h.inspectionStatus.Total = total
-	h.inspectionStatus.Checked = checked
-	h.inspectionStatus.Valid = valid
-	h.inspectionStatus.Invalid = invalid
-	h.inspectionStatus.Round = round
+	defer h.inspectionMu.Unlock()
+	if h.inspectionStatus.Providers == nil {
+		h.inspectionStatus.Providers = make(map[string]*authInspectionProviderStatus)
+	}
+	sub, ok := h.inspectionStatus.Providers[provider]
+	if !ok {
+		sub = &authInspectionProviderStatus{Running: true}
+		h.inspectionStatus.Providers[provider] = sub
+	}
+	sub.Total, sub.Checked, sub.Valid, sub.Invalid, sub.Errors, sub.Throttled, sub.Frozen, sub.Filtered, sub.Skipped, sub.Round = total, checked, valid, invalid, errs, throttled, frozen, filtered, skipped, round
 	if strings.TrimSpace(currentFile) != "" {
-		h.inspectionStatus.CurrentFile = strings.TrimSpace(currentFile)
+		sub.CurrentFile = strings.TrimSpace(currentFile)
+		h.inspectionStatus.CurrentProvider = provider
+		h.inspectionStatus.CurrentFile = sub.CurrentFile
 	}
 	if len(batchNames) > 0 {
 		h.inspectionStatus.RecentChecked = appendRecentChecked(h.inspectionStatus.RecentChecked, batchNames, 10)
 	}
-	h.inspectionMu.Unlock()
+
+	h.inspectionStatus.Total, h.inspectionStatus.Checked, h.inspectionStatus.Valid, h.inspectionStatus.Invalid, h.inspectionStatus.Errors, h.inspectionStatus.Throttled, h.inspectionStatus.Frozen, h.inspectionStatus.Filtered, h.inspectionStatus.Skipped, h.inspectionStatus.BackedOff, h.inspectionStatus.Round = 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0
+	for _, p := range h.inspectionStatus.Providers {
+		h.inspectionStatus.Total += p.Total
+		h.inspectionStatus.Frozen += p.Frozen
+		h.inspectionStatus.Filtered += p.Filtered
+		h.inspectionStatus.Skipped += p.Skipped
+		h.inspectionStatus.BackedOff += p.BackedOff
+		h.inspectionStatus.Checked += p.Checked
+		h.inspectionStatus.Valid += p.Valid
+		h.inspectionStatus.Invalid += p.Invalid
+		h.inspectionStatus.Errors += p.Errors
+		h.inspectionStatus.Throttled += p.Throttled
+		if p.Round > h.inspectionStatus.Round {
+			h.inspectionStatus.Round = p.Round
+		}
+	}
+}
+
+// recordInspectionBatchTiming records how far provider's run has got, how
+// long its last batch took and the auths it backed off from.
+func (h *Handler) recordInspectionBatchTiming(provider string, res coreauth.VerifyBatchResult, elapsed time.Duration, now time.Time) {
+	h.inspectionMu.Lock()
+	defer h.inspectionMu.Unlock()
+	if h.inspectionStatus.Providers == nil {
+		h.inspectionStatus.Providers = make(map[string]*authInspectionProviderStatus)
+	}
+	sub, ok := h.inspectionStatus.Providers[provider]
+	if !ok {
+		sub = &authInspectionProviderStatus{Running: true}
+		h.inspectionStatus.Providers[provider] = sub
+	}
+	sub.Cursor = res.NextCursor
+	if res.Done {
+		sub.Cursor = res.Total
+	}
+	sub.BatchSize = res.BatchSize
+	sub.BackedOff += res.BackedOff
+	sub.Batches++
+	sub.BatchTime += elapsed
+	sub.LastBatchAt = now
+}
+
+// inspectionProgress returns how much of a run is done, in percent, and when
+// it should finish: each running provider is expected to take its average
+// batch time for each batch left before its cursor reaches its total. ok is
+// false while the total is unknown; eta is zero until every running provider
+// has timed a batch.
+func inspectionProgress(providers map[string]*authInspectionProviderStatus) (percent float64, eta time.Time, ok bool) {
+	total, done := 0, 0
+	timed := true
+	for _, sub := range providers {
+		total += sub.Total
+		if !sub.Running {
+			done += sub.Total
+			continue
+		}
+		done += min(sub.Cursor, sub.Total)
+		if sub.Batches == 0 || sub.BatchSize <= 0 {
+			timed = false
+			continue
+		}
+		left := max(sub.Total-sub.Cursor, 0)
+		batchesLeft := (left + sub.BatchSize - 1) / sub.BatchSize
+		finish := sub.LastBatchAt.Add(sub.BatchTime / time.Duration(sub.Batches) * time.Duration(batchesLeft))
+		if finish.After(eta) {
+			eta = finish
+		}
+	}
+	if total <= 0 {
+		return 0, time.Time{}, false
+	}
+	if !timed {
+		eta = time.Time{}
+	}
+	return math.Round(float64(done)*1000/float64(total)) / 10, eta, true
+}
+
+func (h *Handler) finishInspectionProvider(provider string, err error) {
+	h.inspectionMu.Lock()
+	defer h.inspectionMu.Unlock()
+	if sub, ok := h.inspectionStatus.Providers[provider]; ok {
+		sub.Running = false
+		if err != nil {
+			sub.LastError = strings.TrimSpace(err.Error())
+		}
+		sched := h.inspectionScheduleLocked(provider)
+		sched.LastRunStartedAt = h.inspectionStatus.LastRunStartedAt
+		sched.LastRunFinished = time.Now()
+		sched.Checked, sched.Valid, sched.Invalid, sched.Frozen, sched.Filtered = sub.Checked, sub.Valid, sub.Invalid, sub.Frozen, sub.Filtered
+		sched.LastError = sub.LastError
+	}
 }
 
 func (h *Handler) finishAuthInspection(deleted int, err error) {
 	h.inspectionMu.Lock()
 	h.inspectionStatus.Running = false
+	h.inspectionCancel = nil
 	h.inspectionStatus.Deleted = deleted
 	if err != nil {
 		h.inspectionStatus.LastError = strings.TrimSpace(err.Error())
 	}
 	h.inspectionStatus.LastRunFinished = time.Now()
 	h.inspectionMu.Unlock()
+	h.saveInspectionState()
+	h.publishInspectionEvent("finished", nil)
 }
 
-func (h *Handler) runAuthInspection(parent context.Context, trigger string, autoDeleteInvalid bool) {
+// cancelAuthInspection cancels the running inspection, recording reason, and
+// reports whether there was one to cancel. A cancelled run deletes nothing.
+func (h *Handler) cancelAuthInspection(reason string) bool {
+	h.inspectionMu.Lock()
+	defer h.inspectionMu.Unlock()
+	if !h.inspectionStatus.Running || h.inspectionCancel == nil || h.inspectionStatus.Cancelled {
+		return false
+	}
+	h.inspectionStatus.Cancelled = true
+	h.inspectionStatus.CancelReason = reason
+	h.inspectionCancel()
+	return true
+}
+
+// inspectionCancelled reports whether the running inspection was cancelled
+// and why.
+func (h *Handler) inspectionCancelled() (bool, string) {
+	h.inspectionMu.RLock()
+	defer h.inspectionMu.RUnlock()
+	return h.inspectionStatus.Cancelled, h.inspectionStatus.CancelReason
+}
+
+// runAuthInspection inspects the configured providers named in only, or all
+// of them when only is nil, and auto-deletes the invalid auths of each
+// provider whose effective settings ask for it. In a dry run, or with dry-run
+// configured, the files are only listed under WouldDelete.
+func (h *Handler) runAuthInspection(parent context.Context, trigger string, only []string, dryRun bool) {
+	h.runInspection(parent, inspectionRequest{Trigger: trigger, DryRun: dryRun}, only)
+}
+
+// runInspection runs req as runAuthInspection does. A run of a named
+// schedule inspects that schedule's providers with its scope and auto-delete
+// setting, and records its counters under the schedule.
+func (h *Handler) runInspection(parent context.Context, req inspectionRequest, only []string) {
 	if h == nil || h.authManager == nil {
 		return
 	}
+	trigger, dryRun := req.Trigger, req.DryRun
+	cfg := h.effectiveAuthInspectionConfig()
+	if req.Schedule != "" {
+		sched, ok := findInspectionSchedule(cfg, req.Schedule)
+		if !ok {
+			return
+		}
+		cfg = inspectionConfigForSchedule(cfg, sched)
+		if sched.Providers != nil {
+			only = sched.Providers
+		}
+	}
 	if !h.beginAuthInspection(trigger) {
 		return
 	}
+	h.inspectionMetrics.runStarted(trigger)
 
 	ctx := parent
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	runCtx, cancel := context.WithTimeout(ctx, authInspectionRunTimeout)
+	runCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.RunTimeoutSeconds)*time.Second)
 	defer cancel()
+	h.inspectionMu.Lock()
+	h.inspectionCancel = cancel
+	h.inspectionMu.Unlock()
+
+	dryRun = dryRun || cfg.DryRun
+	providers := selectInspectionProviders(cfg.Providers, only)
+	h.startInspectionProviders(providers)
+	summary := newInspectionRunSummary(trigger, time.Now())
+	summary.Schedule = req.Schedule
+	summary.DryRun = dryRun
+	invalidBefore := h.invalidAuthNames(providers)
+	// Every provider walks its own cursor, but their probes share one pool so
+	// the run is paced by its largest provider, not by the provider count.
+	pool := coreauth.NewProbePool(cfg.VerifyPoolSize)
+	var (
+		wg           sync.WaitGroup
+		summaryMu    sync.Mutex
+		invalidFiles []inspectionInvalidFile
+		errs         = make([]error, len(providers))
+	)
+	for idx, provider := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[idx] = h.inspectProvider(runCtx, provider, cfg.Scope, pool, func(res coreauth.VerifyBatchResult) {
+				summaryMu.Lock()
+				summary.addBatch(res)
+				invalidFiles = append(invalidFiles, invalidFilesFromBatch(res)...)
+				summaryMu.Unlock()
+			})
+		}()
+	}
+	wg.Wait()
+	// Compared before auto-delete removes the invalid auths.
+	summary.NewInvalid, summary.NewRecovered = h.invalidChanges(invalidBefore, h.invalidAuthNames(providers))
+
+	// Only providers whose loop completed have trustworthy invalid marks, and
+	// only those set to auto-delete are cleaned up. Auto-disable replaces
+	// deletion for all of them.
+	var deletable, disableable []string
+	for idx, provider := range providers {
+		switch {
+		case errs[idx] != nil:
+		case cfg.AutoDisableInvalid:
+			disableable = append(disableable, provider.Name)
+		case inspectionConfigFor(cfg, provider.Name).AutoDeleteInvalid:
+			deletable = append(deletable, provider.Name)
+		}
+	}
+	runErr := errors.Join(errs...)
+
+	deleted, disabled := 0, 0
+	summary.Cancelled, summary.CancelReason = h.inspectionCancelled()
+	// A run cut short by Stop is reported as interrupted, not cancelled.
+	summary.Interrupted = !summary.Cancelled && h.life.context().Err() != nil
+	if summary.Cancelled {
+		log.Infof("auth inspection run %s %s", summary.ID, summary.CancelReason)
+	} else if summary.Interrupted {
+		log.Infof("auth inspection run %s interrupted by shutdown", summary.ID)
+	} else if len(deletable) > 0 || len(disableable) > 0 {
+		if cfg.SkipDeleteOnSystemic && summary.suspectSystemic() {
+			code, share := summary.dominantReason()
+			log.Warnf("auth inspection: %d invalid auths, %.0f%% with reason %s; skipping auto delete for run %s", summary.Invalid, share*100, code, summary.ID)
+			summary.DeleteSkipped = true
+		} else if len(disableable) > 0 {
+			if !dryRun {
+				disabledCount, errDisable := h.disableInvalidAuthsFor(runCtx, disableable)
+				disabled = disabledCount
+				if errDisable != nil {
+					runErr = errors.Join(runErr, fmt.Errorf("auto disable invalid failed: %w", errDisable))
+				}
+			}
+		} else if dryRun {
+			wouldDelete, errPreview := h.previewInvalidAuthFilesFor(runCtx, deletable)
+			summary.WouldDelete = wouldDelete
+			if errPreview != nil {
+				runErr = errors.Join(runErr, fmt.Errorf("auto delete dry run failed: %w", errPreview))
+			}
+		} else {
+			deletedCount, _, errDelete := h.deleteInvalidAuthFilesFor(runCtx, deletable)
+			deleted = deletedCount
+			if errDelete != nil {
+				runErr = errors.Join(runErr, fmt.Errorf("auto delete invalid failed: %w", errDelete))
+			}
+		}
+	}
+	h.inspectionMu.Lock()
+	h.inspectionStatus.DryRun = dryRun
+	h.inspectionStatus.WouldDelete = summary.WouldDelete
+	h.inspectionStatus.Interrupted = summary.Interrupted
+	h.inspectionStatus.Disabled = disabled
+	h.inspectionStatus.NewInvalid = summary.NewInvalid
+	h.inspectionStatus.NewRecovered = summary.NewRecovered
+	if req.Schedule != "" {
+		h.finishNamedInspectionLocked(req.Schedule, summary, runErr, time.Now())
+	}
+	h.inspectionMu.Unlock()
+	h.finishAuthInspection(deleted, runErr)
+	summary.FinishedAt = time.Now()
+	h.inspectionMetrics.runFinished(summary.FinishedAt.Sub(summary.StartedAt))
+	summary.Deleted = deleted
+	summary.Disabled = disabled
+	if runErr != nil {
+		summary.Error = runErr.Error()
+	}
+	h.notifyAuthInspection(summary, invalidFiles)
+	h.inspectionRuns.add(summary)
+	h.evaluateAlerts(ctx, "inspection")
+}
+
+// selectInspectionProviders keeps the providers named in only, in their
+// configured order; a nil only keeps them all.
+func selectInspectionProviders(providers []config.AuthInspectionProvider, only []string) []config.AuthInspectionProvider {
+	if only == nil {
+		return providers
+	}
+	out := make([]config.AuthInspectionProvider, 0, len(only))
+	for _, provider := range providers {
+		for _, name := range only {
+			if provider.Name == name {
+				out = append(out, provider)
+				break
+			}
+		}
+	}
+	return out
+}
 
-	cursor := 0
-	round := 0
+// inspectProvider walks every candidate of one provider with its own cursor,
+// recording progress under the provider's sub-status and probing only the
+// auths in scope. Its probes take their slots from pool, which the run's
+// other providers share; provider.Concurrency caps its share. The returned
+// error names the provider.
+func (h *Handler) inspectProvider(ctx context.Context, provider config.AuthInspectionProvider, scope string, pool *coreauth.ProbePool, onBatch func(coreauth.VerifyBatchResult)) error {
 	checked := 0
 	valid := 0
 	invalid := 0
-	total := 0
-	done := false
-	var runErr error
-
-	for !done && round < authInspectionVerifyMaxRounds {
-		res, errBatch := h.verifyInvalidAuthBatch(runCtx, "codex", authInspectionVerifyConcurrency, authInspectionVerifyBatchSize, cursor)
-		if errBatch != nil {
-			runErr = errBatch
-			break
-		}
-		total = res.Total
+	errs := 0
+	throttled := 0
+	skipped := 0
+	opts := h.inspectionRunOptions(provider.Name, false)
+	opts.Concurrency = min(provider.Concurrency, pool.Size())
+	opts.Pool = pool
+	opts.Filter = scopedInspectionFilter(opts.Filter, scope)
+	batchStart := time.Now()
+	opts.OnBatch = func(res coreauth.VerifyBatchResult, round int) {
+		onBatch(res)
+		now := time.Now()
+		h.recordInspectionBatchTiming(provider.Name, res, now.Sub(batchStart), now)
+		batchStart = now
 		checked += res.Checked
 		valid += res.Valid
 		invalid += res.Invalid
-		round++
+		errs += res.Errors
+		throttled += res.Throttled
+		skipped += res.Fresh + res.BackedOff
 
 		currentName := ""
 		batchNames := make([]string, 0, len(res.Results))
@@ -249,59 +1127,206 @@ func (h *Handler) runAuthInspection(parent context.Context, trigger string, auto
 			batchNames = append(batchNames, name)
 			currentName = name
 		}
-		h.updateAuthInspectionProgress(total, checked, valid, invalid, round, currentName, batchNames)
-
-		cursor = res.NextCursor
-		done = res.Done || cursor <= res.Cursor || (res.Total > 0 && cursor >= res.Total)
+		h.recordInspectionResults(res.Results)
+		h.updateAuthInspectionProgress(provider.Name, res.Total, checked, valid, invalid, errs, throttled, res.Frozen, res.Filtered, skipped, round, currentName, batchNames)
 	}
-
-	deleted := 0
-	if runErr == nil && autoDeleteInvalid {
-		deletedCount, _, errDelete := h.deleteInvalidAuthFilesInternal(runCtx)
-		deleted = deletedCount
-		if errDelete != nil {
-			runErr = fmt.Errorf("auto delete invalid failed: %w", errDelete)
-		}
+	_, err := h.authInspector().Run(ctx, opts)
+	if err != nil {
+		err = fmt.Errorf("%s: %w", provider.Name, err)
 	}
-	h.finishAuthInspection(deleted, runErr)
+	h.finishInspectionProvider(provider.Name, err)
+	return err
 }
 
 func (h *Handler) authInspectionStatusPayload() gin.H {
 	cfg := h.effectiveAuthInspectionConfig()
 	h.inspectionMu.RLock()
 	state := h.inspectionStatus
+	providers := make(gin.H, len(state.Providers))
+	for name, sub := range state.Providers {
+		providers[name] = gin.H{
+			"running":         sub.Running,
+			"concurrency":     sub.Concurrency,
+			"total":           sub.Total,
+			"checked":         sub.Checked,
+			"valid":           sub.Valid,
+			"invalid":         sub.Invalid,
+			"errors":          sub.Errors,
+			"throttled":       sub.Throttled,
+			"frozen":          sub.Frozen,
+			"filtered":        sub.Filtered,
+			"skipped":         sub.Skipped,
+			"skipped_backoff": sub.BackedOff,
+			"round":           sub.Round,
+			"current_file":    sub.CurrentFile,
+			"last_error":      sub.LastError,
+		}
+	}
+	schedules := make(gin.H, len(cfg.Providers))
+	for _, provider := range cfg.Providers {
+		providerCfg := inspectionConfigFor(cfg, provider.Name)
+		entry := gin.H{
+			"interval_seconds":    providerCfg.IntervalSeconds,
+			"cron":                providerCfg.Cron,
+			"auto_delete_invalid": providerCfg.AutoDeleteInvalid,
+			"next_run_at":         time.Time{},
+		}
+		sched, ok := state.Schedules[provider.Name]
+		if ok {
+			entry["next_run_at"] = sched.NextRunAt
+		}
+		if ok && !sched.LastRunFinished.IsZero() {
+			entry["last_run_started_at"] = sched.LastRunStartedAt
+			entry["last_run_finished"] = sched.LastRunFinished
+			entry["last_checked"] = sched.Checked
+			entry["last_valid"] = sched.Valid
+			entry["last_invalid"] = sched.Invalid
+			entry["last_frozen"] = sched.Frozen
+			entry["last_filtered"] = sched.Filtered
+			entry["last_error"] = sched.LastError
+		}
+		schedules[provider.Name] = entry
+	}
+	namedSchedules := h.namedSchedulesPayloadLocked(cfg)
+	percent, eta, hasProgress := inspectionProgress(state.Providers)
+	histogram := copyReasonHistogram(state.ReasonHistogram)
 	h.inspectionMu.RUnlock()
+	leader, lastRunBy := h.inspectionLeadershipPayload()
+	lastRunID := ""
+	if run, ok := h.inspectionRuns.get(""); ok {
+		lastRunID = run.ID
+	}
 
-	return gin.H{
+	payload := gin.H{
+		"instance_id":         h.inspectionInstanceID(),
+		"leader":              leader,
+		"is_leader":           h.isInspectionLeader(),
+		"last_run_by":         lastRunBy,
 		"enabled":             cfg.Enabled,
 		"interval_seconds":    cfg.IntervalSeconds,
+		"cron":                cfg.Cron,
+		"jitter_seconds":      cfg.JitterSeconds,
 		"auto_delete_invalid": cfg.AutoDeleteInvalid,
+		"last_run_id":         lastRunID,
 		"running":             state.Running,
 		"trigger":             strings.TrimSpace(state.Trigger),
+		"current_provider":    state.CurrentProvider,
 		"current_file":        strings.TrimSpace(state.CurrentFile),
 		"recent_checked":      state.RecentChecked,
+		"recent_results":      recentResultsOrEmpty(state.RecentResults),
 		"checked":             state.Checked,
 		"valid":               state.Valid,
 		"invalid":             state.Invalid,
+		"errors":              state.Errors,
+		"throttled":           state.Throttled,
 		"deleted":             state.Deleted,
+		"disabled":            state.Disabled,
+		"recovered":           state.Recovered,
 		"total":               state.Total,
+		"frozen":              state.Frozen,
+		"filtered":            state.Filtered,
+		"skipped":             state.Skipped,
+		"skipped_backoff":     state.BackedOff,
 		"round":               state.Round,
 		"last_error":          strings.TrimSpace(state.LastError),
+		"newly_invalid":       namesOrEmpty(state.NewInvalid),
+		"newly_recovered":     namesOrEmpty(state.NewRecovered),
+		"cancelled":           state.Cancelled,
+		"cancel_reason":       state.CancelReason,
+		"interrupted":         state.Interrupted,
+		"paused":              state.Paused,
+		"queued":              state.Queued,
+		"dry_run":             state.DryRun,
+		"would_delete":        wouldDeleteOrEmpty(state.WouldDelete),
+		"reason_histogram":    histogram,
 		"last_run_started_at": state.LastRunStartedAt,
 		"last_run_finished":   state.LastRunFinished,
 		"next_run_at":         state.NextRunAt,
+		"providers":           providers,
+		"schedules":           schedules,
+		"named_schedules":     namedSchedules,
+	}
+	if until := h.probePause.pausedUntil(time.Now()); !until.IsZero() {
+		payload["paused_until"] = until.UTC()
 	}
+	// Progress is reported while a run is going and its total is known.
+	if state.Running && hasProgress {
+		payload["progress_percent"] = percent
+		if !eta.IsZero() {
+			payload["eta"] = eta
+		}
+	}
+	return payload
 }
 
 func (h *Handler) GetAuthInspectionConfig(c *gin.Context) {
 	cfg := h.effectiveAuthInspectionConfig()
-	c.JSON(http.StatusOK, gin.H{
-		"enabled":              cfg.Enabled,
-		"interval_seconds":     cfg.IntervalSeconds,
-		"auto_delete_invalid":  cfg.AutoDeleteInvalid,
-		"min_interval_seconds": minAuthInspectionIntervalSeconds,
-		"max_interval_seconds": maxAuthInspectionIntervalSeconds,
-	})
+	payload := gin.H{
+		"enabled":                 cfg.Enabled,
+		"interval_seconds":        cfg.IntervalSeconds,
+		"cron":                    cfg.Cron,
+		"jitter_seconds":          cfg.JitterSeconds,
+		"run_on_start":            cfg.RunOnStart,
+		"start_delay_seconds":     cfg.StartDelaySeconds,
+		"auto_delete_invalid":     cfg.AutoDeleteInvalid,
+		"auto_disable_invalid":    cfg.AutoDisableInvalid,
+		"dry_run":                 cfg.DryRun,
+		"skip_delete_on_systemic": cfg.SkipDeleteOnSystemic,
+		"skip_verify_on_upload":   cfg.SkipVerifyOnUpload,
+		"invalid_status_codes":    statusCodesOrEmpty(cfg.InvalidStatusCodes),
+		"notify_url":              cfg.NotifyURL,
+		"quarantine_dir":          cfg.QuarantineDir,
+		"verify_concurrency":      cfg.VerifyConcurrency,
+		"verify_pool_size":        cfg.VerifyPoolSize,
+		"verify_batch_size":       cfg.VerifyBatchSize,
+		"run_timeout_seconds":     cfg.RunTimeoutSeconds,
+		"probe_rate_per_minute":   cfg.ProbeRatePerMinute,
+		"min_reverify_seconds":    cfg.MinReverifySeconds,
+		"verify_cache_seconds":    cfg.VerifyCacheSeconds,
+		"probe_failure_threshold": cfg.ProbeFailureThreshold,
+		"probe_backoff_seconds":   cfg.ProbeBackoffSeconds,
+		"scope":                   cfg.Scope,
+		"providers":               cfg.Providers,
+		"provider_overrides":      inspectionOverridesPayload(cfg.ProviderOverrides),
+		"verification":            h.verificationPayload(),
+		"include_patterns":        patternsOrEmpty(cfg.IncludePatterns),
+		"exclude_patterns":        patternsOrEmpty(cfg.ExcludePatterns),
+		"include_tags":            tagsOrEmpty(cfg.IncludeTags),
+		"exclude_tags":            tagsOrEmpty(cfg.ExcludeTags),
+		"min_interval_seconds":    minAuthInspectionIntervalSeconds,
+		"max_interval_seconds":    maxAuthInspectionIntervalSeconds,
+	}
+	if cfg.Cron != "" {
+		if _, err := backup.ParseCron(cfg.Cron); err != nil {
+			payload["cron_error"] = err.Error()
+		}
+	}
+	c.JSON(http.StatusOK, payload)
+}
+
+func inspectionOverridesPayload(overrides map[string]config.AuthInspectionOverride) gin.H {
+	out := make(gin.H, len(overrides))
+	for name, override := range overrides {
+		entry := gin.H{"interval_seconds": override.IntervalSeconds}
+		if override.AutoDeleteInvalid != nil {
+			entry["auto_delete_invalid"] = *override.AutoDeleteInvalid
+		}
+		out[name] = entry
+	}
+	return out
+}
+
+// nextCronRuns returns the next n times schedule fires after now, in local time.
+func nextCronRuns(schedule *backup.Cron, now time.Time, n int) []time.Time {
+	runs := make([]time.Time, 0, n)
+	for len(runs) < n {
+		now = schedule.NextIn(now, time.Local)
+		if now.IsZero() {
+			break
+		}
+		runs = append(runs, now)
+	}
+	return runs
 }
 
 func (h *Handler) PutAuthInspectionConfig(c *gin.Context) {
@@ -310,18 +1335,184 @@ func (h *Handler) PutAuthInspectionConfig(c *gin.Context) {
 		return
 	}
 	var req struct {
-		Enabled           *bool `json:"enabled"`
-		IntervalSeconds   *int  `json:"interval_seconds"`
-		AutoDeleteInvalid *bool `json:"auto_delete_invalid"`
+		Enabled               *bool                     `json:"enabled"`
+		IntervalSeconds       *int                      `json:"interval_seconds"`
+		Cron                  *string                   `json:"cron"`
+		JitterSeconds         *int                      `json:"jitter_seconds"`
+		RunOnStart            *bool                     `json:"run_on_start"`
+		StartDelaySeconds     *int                      `json:"start_delay_seconds"`
+		AutoDeleteInvalid     *bool                     `json:"auto_delete_invalid"`
+		AutoDisableInvalid    *bool                     `json:"auto_disable_invalid"`
+		DryRun                *bool                     `json:"dry_run"`
+		SkipDeleteOnSystemic  *bool                     `json:"skip_delete_on_systemic"`
+		SkipVerifyOnUpload    *bool                     `json:"skip_verify_on_upload"`
+		InvalidStatusCodes    *[]int                    `json:"invalid_status_codes"`
+		NotifyURL             *string                   `json:"notify_url"`
+		QuarantineDir         *string                   `json:"quarantine_dir"`
+		VerifyConcurrency     *int                      `json:"verify_concurrency"`
+		VerifyPoolSize        *int                      `json:"verify_pool_size"`
+		VerifyBatchSize       *int                      `json:"verify_batch_size"`
+		RunTimeoutSeconds     *int                      `json:"run_timeout_seconds"`
+		ProbeRatePerMinute    *int                      `json:"probe_rate_per_minute"`
+		MinReverifySeconds    *int                      `json:"min_reverify_seconds"`
+		VerifyCacheSeconds    *int                      `json:"verify_cache_seconds"`
+		ProbeFailureThreshold *int                      `json:"probe_failure_threshold"`
+		ProbeBackoffSeconds   *int                      `json:"probe_backoff_seconds"`
+		Scope                 *string                   `json:"scope"`
+		Providers             *inspectionProvidersField `json:"providers"`
+		ProviderOverrides     *map[string]struct {
+			IntervalSeconds   int   `json:"interval_seconds"`
+			AutoDeleteInvalid *bool `json:"auto_delete_invalid"`
+		} `json:"provider_overrides"`
+		Verification *map[string]struct {
+			ProbeURL            string             `json:"probe_url"`
+			FallbackProbeURL    string             `json:"fallback_probe_url"`
+			TimeoutSeconds      int                `json:"timeout_seconds"`
+			ExpectedStatusCodes []int              `json:"expected_status_codes"`
+			ErrorRules          *errorRulesRequest `json:"error_rules"`
+		} `json:"verification"`
+		IncludePatterns *[]string `json:"include_patterns"`
+		ExcludePatterns *[]string `json:"exclude_patterns"`
+		IncludeTags     *[]string `json:"include_tags"`
+		ExcludeTags     *[]string `json:"exclude_tags"`
+		// CancelRunning also cancels the running inspection, e.g. when
+		// disabling the scheduler; it is not saved.
+		CancelRunning bool `json:"cancel_running"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
 		return
 	}
-	if req.Enabled == nil && req.IntervalSeconds == nil && req.AutoDeleteInvalid == nil {
+	if req.Enabled == nil && req.IntervalSeconds == nil && req.Cron == nil && req.JitterSeconds == nil && req.RunOnStart == nil && req.StartDelaySeconds == nil && req.AutoDeleteInvalid == nil && req.AutoDisableInvalid == nil && req.DryRun == nil && req.SkipDeleteOnSystemic == nil && req.SkipVerifyOnUpload == nil && req.InvalidStatusCodes == nil && req.NotifyURL == nil && req.QuarantineDir == nil && req.VerifyConcurrency == nil && req.VerifyPoolSize == nil && req.VerifyBatchSize == nil && req.RunTimeoutSeconds == nil && req.ProbeRatePerMinute == nil && req.MinReverifySeconds == nil && req.VerifyCacheSeconds == nil && req.ProbeFailureThreshold == nil && req.ProbeBackoffSeconds == nil && req.Scope == nil && req.Providers == nil && req.ProviderOverrides == nil && req.Verification == nil && req.IncludePatterns == nil && req.ExcludePatterns == nil && req.IncludeTags == nil && req.ExcludeTags == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "no config field provided"})
 		return
 	}
+	var schedule *backup.Cron
+	if req.Cron != nil {
+		if expr := strings.TrimSpace(*req.Cron); expr != "" {
+			parsed, err := backup.ParseCron(expr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			schedule = parsed
+		}
+	}
+	if req.Providers != nil {
+		inspector := h.authInspector()
+		for i, provider := range *req.Providers {
+			(*req.Providers)[i].Name = strings.ToLower(strings.TrimSpace(provider.Name))
+			if !inspector.HasProbe(provider.Name) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown inspection provider %q", provider.Name), "supported": inspector.Providers()})
+				return
+			}
+		}
+	}
+
+	if req.JitterSeconds != nil && (*req.JitterSeconds < 0 || *req.JitterSeconds > maxAuthInspectionJitterSeconds) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("jitter_seconds must be between 0 and %d", maxAuthInspectionJitterSeconds)})
+		return
+	}
+	for _, bound := range []struct {
+		name  string
+		value *int
+		min   int
+		max   int
+	}{
+		{"verify_concurrency", req.VerifyConcurrency, 1, maxAuthInspectionDefaultConcurrency},
+		{"verify_pool_size", req.VerifyPoolSize, 1, maxAuthInspectionVerifyConcurrency},
+		{"verify_batch_size", req.VerifyBatchSize, minAuthInspectionVerifyBatchSize, maxAuthInspectionVerifyBatchSize},
+		{"run_timeout_seconds", req.RunTimeoutSeconds, minAuthInspectionRunTimeoutSeconds, maxAuthInspectionRunTimeoutSeconds},
+		{"probe_rate_per_minute", req.ProbeRatePerMinute, 1, maxAuthInspectionProbeRatePerMinute},
+		{"min_reverify_seconds", req.MinReverifySeconds, 0, maxAuthInspectionIntervalSeconds},
+		{"verify_cache_seconds", req.VerifyCacheSeconds, 1, maxAuthInspectionVerifyCacheSeconds},
+		{"probe_failure_threshold", req.ProbeFailureThreshold, 1, maxAuthInspectionProbeFailures},
+		{"probe_backoff_seconds", req.ProbeBackoffSeconds, minAuthInspectionProbeBackoffSeconds, maxAuthInspectionProbeBackoffSeconds},
+		{"start_delay_seconds", req.StartDelaySeconds, 1, maxAuthInspectionStartDelaySeconds},
+	} {
+		if bound.value != nil && (*bound.value < bound.min || *bound.value > bound.max) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be between %d and %d", bound.name, bound.min, bound.max)})
+			return
+		}
+	}
+	for _, patterns := range []struct {
+		field string
+		value *[]string
+	}{
+		{"include_patterns", req.IncludePatterns},
+		{"exclude_patterns", req.ExcludePatterns},
+	} {
+		if patterns.value == nil {
+			continue
+		}
+		if err := validateInspectionPatterns(patterns.field, *patterns.value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Scope != nil {
+		if _, ok := parseInspectionScope(*req.Scope); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("scope must be %q or %q", inspectionScopeAll, inspectionScopeInvalidOnly)})
+			return
+		}
+	}
+	if req.InvalidStatusCodes != nil {
+		for _, code := range *req.InvalidStatusCodes {
+			if code < 400 || code > 499 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid_status_codes: %d is not a 4xx status", code)})
+				return
+			}
+		}
+	}
+	if req.QuarantineDir != nil {
+		if _, err := resolveQuarantineDir(*req.QuarantineDir, h.cfg.AuthDir); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.NotifyURL != nil {
+		if raw := strings.TrimSpace(*req.NotifyURL); raw != "" {
+			if parsed, err := url.Parse(raw); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "notify_url must be an http or https URL"})
+				return
+			}
+		}
+	}
+	var overrides map[string]config.AuthInspectionOverride
+	if req.ProviderOverrides != nil {
+		overrides = make(map[string]config.AuthInspectionOverride, len(*req.ProviderOverrides))
+		for name, override := range *req.ProviderOverrides {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if !h.authInspector().HasProbe(name) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown inspection provider %q", name), "supported": h.authInspector().Providers()})
+				return
+			}
+			if override.IntervalSeconds != 0 && (override.IntervalSeconds < minAuthInspectionIntervalSeconds || override.IntervalSeconds > maxAuthInspectionIntervalSeconds) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("provider_overrides.%s.interval_seconds must be between %d and %d", name, minAuthInspectionIntervalSeconds, maxAuthInspectionIntervalSeconds)})
+				return
+			}
+			overrides[name] = config.AuthInspectionOverride{IntervalSeconds: override.IntervalSeconds, AutoDeleteInvalid: override.AutoDeleteInvalid}
+		}
+	}
+	var verification map[string]config.AuthInspectionVerification
+	if req.Verification != nil {
+		verification = make(map[string]config.AuthInspectionVerification, len(*req.Verification))
+		for name, entry := range *req.Verification {
+			name = strings.ToLower(strings.TrimSpace(name))
+			settings := config.AuthInspectionVerification{
+				ProbeURL:            strings.TrimSpace(entry.ProbeURL),
+				FallbackProbeURL:    strings.TrimSpace(entry.FallbackProbeURL),
+				TimeoutSeconds:      entry.TimeoutSeconds,
+				ExpectedStatusCodes: entry.ExpectedStatusCodes,
+				ErrorRules:          entry.ErrorRules.config(),
+			}
+			if err := validateInspectionVerification(name, settings); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			verification[name] = settings
+		}
+	}
 
 	h.mu.Lock()
 	oldCfg := h.cfg.AuthInspection
@@ -337,9 +1528,111 @@ func (h *Handler) PutAuthInspectionConfig(c *gin.Context) {
 		}
 		cfg.IntervalSeconds = *req.IntervalSeconds
 	}
+	if req.Cron != nil {
+		cfg.Cron = strings.TrimSpace(*req.Cron)
+	}
+	if req.JitterSeconds != nil {
+		cfg.JitterSeconds = *req.JitterSeconds
+	}
+	if req.RunOnStart != nil {
+		cfg.RunOnStart = *req.RunOnStart
+	}
+	if req.StartDelaySeconds != nil {
+		cfg.StartDelaySeconds = *req.StartDelaySeconds
+	}
 	if req.AutoDeleteInvalid != nil {
 		cfg.AutoDeleteInvalid = *req.AutoDeleteInvalid
 	}
+	if req.AutoDisableInvalid != nil {
+		cfg.AutoDisableInvalid = *req.AutoDisableInvalid
+	}
+	if req.DryRun != nil {
+		cfg.DryRun = *req.DryRun
+	}
+	if req.SkipDeleteOnSystemic != nil {
+		cfg.SkipDeleteOnSystemic = *req.SkipDeleteOnSystemic
+	}
+	if req.SkipVerifyOnUpload != nil {
+		cfg.SkipVerifyOnUpload = *req.SkipVerifyOnUpload
+	}
+	if req.InvalidStatusCodes != nil {
+		cfg.InvalidStatusCodes = *req.InvalidStatusCodes
+	}
+	if req.NotifyURL != nil {
+		cfg.NotifyURL = strings.TrimSpace(*req.NotifyURL)
+	}
+	if req.QuarantineDir != nil {
+		cfg.QuarantineDir = strings.TrimSpace(*req.QuarantineDir)
+	}
+	tagLists := make(map[string][]string, 2)
+	for _, tags := range []struct {
+		field string
+		value *[]string
+	}{
+		{"include_tags", req.IncludeTags},
+		{"exclude_tags", req.ExcludeTags},
+	} {
+		if tags.value == nil {
+			continue
+		}
+		normalized, err := normalizeAuthTags(*tags.value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: %v", tags.field, err)})
+			return
+		}
+		tagLists[tags.field] = normalized
+	}
+	if req.Providers != nil {
+		cfg.Providers = []config.AuthInspectionProvider(*req.Providers)
+	}
+	if req.ProviderOverrides != nil {
+		cfg.ProviderOverrides = overrides
+	}
+	if req.Verification != nil {
+		cfg.Verification = verification
+	}
+	if req.IncludePatterns != nil {
+		cfg.IncludePatterns = normalizeInspectionPatterns(*req.IncludePatterns)
+	}
+	if req.ExcludePatterns != nil {
+		cfg.ExcludePatterns = normalizeInspectionPatterns(*req.ExcludePatterns)
+	}
+	if req.IncludeTags != nil {
+		cfg.IncludeTags = tagLists["include_tags"]
+	}
+	if req.ExcludeTags != nil {
+		cfg.ExcludeTags = tagLists["exclude_tags"]
+	}
+	if req.VerifyConcurrency != nil {
+		cfg.VerifyConcurrency = *req.VerifyConcurrency
+	}
+	if req.VerifyPoolSize != nil {
+		cfg.VerifyPoolSize = *req.VerifyPoolSize
+	}
+	if req.VerifyBatchSize != nil {
+		cfg.VerifyBatchSize = *req.VerifyBatchSize
+	}
+	if req.RunTimeoutSeconds != nil {
+		cfg.RunTimeoutSeconds = *req.RunTimeoutSeconds
+	}
+	if req.ProbeRatePerMinute != nil {
+		cfg.ProbeRatePerMinute = *req.ProbeRatePerMinute
+	}
+	if req.MinReverifySeconds != nil {
+		cfg.MinReverifySeconds = *req.MinReverifySeconds
+	}
+	if req.VerifyCacheSeconds != nil {
+		cfg.VerifyCacheSeconds = *req.VerifyCacheSeconds
+	}
+	if req.ProbeFailureThreshold != nil {
+		cfg.ProbeFailureThreshold = *req.ProbeFailureThreshold
+	}
+	if req.ProbeBackoffSeconds != nil {
+		cfg.ProbeBackoffSeconds = *req.ProbeBackoffSeconds
+	}
+	if req.Scope != nil {
+		cfg.Scope, _ = parseInspectionScope(*req.Scope)
+	}
 	if cfg.IntervalSeconds <= 0 {
 		cfg.IntervalSeconds = defaultAuthInspectionIntervalSeconds
 	}
@@ -354,24 +1647,164 @@ func (h *Handler) PutAuthInspectionConfig(c *gin.Context) {
 		return
 	}
 
-	if cfg.Enabled {
-		h.updateAuthInspectionNextRun(time.Now().Add(time.Duration(cfg.IntervalSeconds) * time.Second))
-	} else {
-		h.updateAuthInspectionNextRun(time.Time{})
+	cancelled := req.CancelRunning && h.cancelAuthInspection("cancelled by config change")
+	now := h.schedulerClock().Now()
+	effective := h.effectiveAuthInspectionConfig()
+	h.rescheduleAuthInspection(effective, nil, now)
+	h.wakeAuthInspectionScheduler()
+	payload := gin.H{
+		"status":                  "ok",
+		"cancelled":               cancelled,
+		"enabled":                 cfg.Enabled,
+		"interval_seconds":        cfg.IntervalSeconds,
+		"cron":                    cfg.Cron,
+		"jitter_seconds":          cfg.JitterSeconds,
+		"run_on_start":            cfg.RunOnStart,
+		"start_delay_seconds":     effective.StartDelaySeconds,
+		"auto_delete_invalid":     cfg.AutoDeleteInvalid,
+		"auto_disable_invalid":    cfg.AutoDisableInvalid,
+		"dry_run":                 cfg.DryRun,
+		"skip_delete_on_systemic": cfg.SkipDeleteOnSystemic,
+		"skip_verify_on_upload":   cfg.SkipVerifyOnUpload,
+		"invalid_status_codes":    statusCodesOrEmpty(cfg.InvalidStatusCodes),
+		"notify_url":              cfg.NotifyURL,
+		"quarantine_dir":          cfg.QuarantineDir,
+		"verify_concurrency":      effective.VerifyConcurrency,
+		"verify_pool_size":        effective.VerifyPoolSize,
+		"verify_batch_size":       effective.VerifyBatchSize,
+		"run_timeout_seconds":     effective.RunTimeoutSeconds,
+		"probe_rate_per_minute":   effective.ProbeRatePerMinute,
+		"min_reverify_seconds":    effective.MinReverifySeconds,
+		"verify_cache_seconds":    effective.VerifyCacheSeconds,
+		"probe_failure_threshold": effective.ProbeFailureThreshold,
+		"probe_backoff_seconds":   effective.ProbeBackoffSeconds,
+		"scope":                   effective.Scope,
+		"providers":               effective.Providers,
+		"provider_overrides":      inspectionOverridesPayload(effective.ProviderOverrides),
+		"verification":            h.verificationPayload(),
+		"include_patterns":        patternsOrEmpty(effective.IncludePatterns),
+		"exclude_patterns":        patternsOrEmpty(effective.ExcludePatterns),
+		"include_tags":            tagsOrEmpty(effective.IncludeTags),
+		"exclude_tags":            tagsOrEmpty(effective.ExcludeTags),
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"status":              "ok",
-		"enabled":             cfg.Enabled,
-		"interval_seconds":    cfg.IntervalSeconds,
-		"auto_delete_invalid": cfg.AutoDeleteInvalid,
-	})
+	if schedule != nil {
+		payload["next_runs"] = nextCronRuns(schedule, now, 3)
+	}
+	c.JSON(http.StatusOK, payload)
+}
+
+// inspectionProvidersField accepts providers as names or as objects with a
+// name and concurrency.
+type inspectionProvidersField []config.AuthInspectionProvider
+
+func (f *inspectionProvidersField) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	out := make([]config.AuthInspectionProvider, 0, len(raw))
+	for _, item := range raw {
+		var name string
+		if err := json.Unmarshal(item, &name); err == nil {
+			out = append(out, config.AuthInspectionProvider{Name: name})
+			continue
+		}
+		var provider config.AuthInspectionProvider
+		if err := json.Unmarshal(item, &provider); err != nil {
+			return err
+		}
+		out = append(out, provider)
+	}
+	*f = out
+	return nil
 }
 
 func (h *Handler) GetAuthInspectionStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok", "inspection": h.authInspectionStatusPayload()})
 }
 
+// CancelAuthInspection stops the running inspection after its current round.
+// Nothing is deleted by a cancelled run; the schedule carries on as usual.
+func (h *Handler) CancelAuthInspection(c *gin.Context) {
+	if !h.cancelAuthInspection("cancelled by request") {
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "cancelled": false, "started": false, "reason": "no inspection running", "inspection": h.authInspectionStatusPayload()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "cancelled": true, "inspection": h.authInspectionStatusPayload()})
+}
+
+// inspectSingleAuth probes the auth with ID or file name key and records the
+// outcome, answering with it synchronously. It runs alongside a scheduled or
+// manual run without touching that run's status or counters.
+func (h *Handler) inspectSingleAuth(c *gin.Context, key string) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	auth := h.findAuthByNameOrID(key)
+	if auth == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth not found"})
+		return
+	}
+	result, err := h.authInspector().VerifyOne(c.Request.Context(), auth, h.throttleProbe)
+	if errors.Is(err, coreauth.ErrNotProbed) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s is not probed: no %q probe, or it is disabled, frozen or runtime-only", key, auth.Provider)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.inspectionMetrics.probed([]coreauth.VerifyResult{result})
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "result": verifyResultPayload(result)})
+}
+
+// PauseAuthInspection holds back scheduled runs until ResumeAuthInspection,
+// without touching the config file. Manual runs are still allowed.
+func (h *Handler) PauseAuthInspection(c *gin.Context) {
+	h.inspectionMu.Lock()
+	changed := !h.inspectionStatus.Paused
+	h.inspectionStatus.Paused = true
+	h.inspectionMu.Unlock()
+	h.wakeAuthInspectionScheduler()
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "paused": true, "changed": changed, "inspection": h.authInspectionStatusPayload()})
+}
+
+// ResumeAuthInspection lifts a pause. Runs missed while paused are dropped and
+// the schedule restarts from now.
+func (h *Handler) ResumeAuthInspection(c *gin.Context) {
+	h.inspectionMu.Lock()
+	changed := h.inspectionStatus.Paused
+	h.inspectionStatus.Paused = false
+	h.inspectionMu.Unlock()
+	if changed {
+		h.rescheduleAuthInspection(h.effectiveAuthInspectionConfig(), nil, h.schedulerClock().Now())
+		h.wakeAuthInspectionScheduler()
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "paused": false, "changed": changed, "inspection": h.authInspectionStatusPayload()})
+}
+
+// RunAuthInspectionNow queues a manual run. With ?dry_run=true its auto-delete
+// only reports the files it would remove. Runs forwarded to another replica's
+// leader follow that replica's dry-run setting. With ?force=true a request
+// made while a run is in progress queues one more run to start right after
+// it. With ?id= or ?name= it probes that one auth instead; see
+// inspectSingleAuth.
 func (h *Handler) RunAuthInspectionNow(c *gin.Context) {
+	if key := strings.TrimSpace(c.Query("id")); key != "" {
+		h.inspectSingleAuth(c, key)
+		return
+	}
+	if key := strings.TrimSpace(c.Query("name")); key != "" {
+		h.inspectSingleAuth(c, key)
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	force, _ := strconv.ParseBool(c.Query("force"))
+	if force && h.queueBehindRunningInspection(dryRun) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "started": false, "queued": true, "reason": "queued behind the running inspection", "inspection": h.authInspectionStatusPayload()})
+		return
+	}
 	h.inspectionMu.RLock()
 	running := h.inspectionStatus.Running
 	trigger := h.inspectionTrigger
@@ -384,9 +1817,17 @@ func (h *Handler) RunAuthInspectionNow(c *gin.Context) {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "inspection scheduler unavailable"})
 		return
 	}
+	if h.inspectionLeases() != nil && !h.isInspectionLeader() {
+		if err := h.requestLeaderInspection(); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": fmt.Sprintf("failed to request inspection from leader: %v", err)})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "started": false, "forwarded": true, "reason": "inspection requested from the scheduler leader", "inspection": h.authInspectionStatusPayload()})
+		return
+	}
 	started := false
 	select {
-	case trigger <- "manual":
+	case trigger <- inspectionRequest{Trigger: "manual", DryRun: dryRun}:
 		started = true
 	default:
 	}
@@ -394,5 +1835,5 @@ func (h *Handler) RunAuthInspectionNow(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok", "started": false, "reason": "inspection trigger queue is busy", "inspection": h.authInspectionStatusPayload()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"status": "ok", "started": true, "inspection": h.authInspectionStatusPayload()})
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "started": true, "dry_run": dryRun, "inspection": h.authInspectionStatusPayload()})
 }
@@ -0,0 +1,326 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/backup"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// normalizeInspectionSchedules drops unnamed and duplicate schedules and
+// fills in what each one inherits from cfg, which must already be
+// normalized: an unset interval and cron take the top-level schedule, an
+// unset scope the top-level scope. Providers are lowercased and limited to
+// the configured ones; a schedule left with none of its listed providers is
+// dropped rather than widened to all of them. It returns a copy so the live
+// config is never modified.
+func normalizeInspectionSchedules(cfg config.AuthInspectionConfig) []config.AuthInspectionSchedule {
+	if len(cfg.Schedules) == 0 {
+		return nil
+	}
+	configured := make(map[string]struct{}, len(cfg.Providers))
+	for _, provider := range cfg.Providers {
+		configured[provider.Name] = struct{}{}
+	}
+	out := make([]config.AuthInspectionSchedule, 0, len(cfg.Schedules))
+	seen := make(map[string]struct{}, len(cfg.Schedules))
+	for _, sched := range cfg.Schedules {
+		sched.Name = strings.TrimSpace(sched.Name)
+		if sched.Name == "" {
+			continue
+		}
+		if _, dup := seen[sched.Name]; dup {
+			continue
+		}
+		var providers []string
+		for _, name := range sched.Providers {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if _, ok := configured[name]; ok && !slices.Contains(providers, name) {
+				providers = append(providers, name)
+			}
+		}
+		if len(sched.Providers) > 0 && len(providers) == 0 {
+			continue
+		}
+		seen[sched.Name] = struct{}{}
+		sched.Providers = providers
+		sched.Cron = strings.TrimSpace(sched.Cron)
+		switch {
+		case sched.IntervalSeconds > 0:
+			sched.IntervalSeconds = clampAuthInspectionInterval(sched.IntervalSeconds)
+			sched.Cron = ""
+		case sched.Cron != "":
+			sched.IntervalSeconds = cfg.IntervalSeconds
+		default:
+			sched.IntervalSeconds, sched.Cron = cfg.IntervalSeconds, cfg.Cron
+		}
+		if strings.TrimSpace(sched.Scope) == "" {
+			sched.Scope = cfg.Scope
+		} else {
+			sched.Scope, _ = parseInspectionScope(sched.Scope)
+		}
+		out = append(out, sched)
+	}
+	return out
+}
+
+// findInspectionSchedule returns the named schedule of cfg.
+func findInspectionSchedule(cfg config.AuthInspectionConfig, name string) (config.AuthInspectionSchedule, bool) {
+	for _, sched := range cfg.Schedules {
+		if sched.Name == name {
+			return sched, true
+		}
+	}
+	return config.AuthInspectionSchedule{}, false
+}
+
+// inspectionConfigForSchedule returns cfg as it applies to runs of sched:
+// its interval or cron and scope replace the top-level ones, and its
+// auto-delete setting, when set, replaces both the top-level and the
+// per-provider ones.
+func inspectionConfigForSchedule(cfg config.AuthInspectionConfig, sched config.AuthInspectionSchedule) config.AuthInspectionConfig {
+	cfg.IntervalSeconds = sched.IntervalSeconds
+	cfg.Cron = sched.Cron
+	cfg.Scope = sched.Scope
+	if sched.AutoDeleteInvalid != nil {
+		cfg.AutoDeleteInvalid = *sched.AutoDeleteInvalid
+		overrides := make(map[string]config.AuthInspectionOverride, len(cfg.ProviderOverrides))
+		for name, override := range cfg.ProviderOverrides {
+			override.AutoDeleteInvalid = nil
+			overrides[name] = override
+		}
+		cfg.ProviderOverrides = overrides
+	}
+	return cfg
+}
+
+// dueInspectionSchedule returns the named schedule that fell due first at
+// now, scheduling the ones that have no next run yet, or "" when none is due
+// or the scheduler is paused.
+func (h *Handler) dueInspectionSchedule(cfg config.AuthInspectionConfig, now time.Time) string {
+	h.inspectionMu.Lock()
+	defer h.inspectionMu.Unlock()
+	if !cfg.Enabled || len(cfg.Schedules) == 0 {
+		return ""
+	}
+	due, dueAt := "", time.Time{}
+	for _, sched := range cfg.Schedules {
+		state := h.namedInspectionScheduleLocked(sched.Name)
+		if state.NextRunAt.IsZero() {
+			state.NextRunAt = nextAuthInspectionRun(inspectionConfigForSchedule(cfg, sched), now)
+		}
+		if h.inspectionStatus.Paused || state.NextRunAt.IsZero() || now.Before(state.NextRunAt) {
+			continue
+		}
+		if due == "" || state.NextRunAt.Before(dueAt) {
+			due, dueAt = sched.Name, state.NextRunAt
+		}
+	}
+	h.syncInspectionScheduleLocked(cfg)
+	return due
+}
+
+// rescheduleInspectionSchedule computes the next run of the named schedule
+// from now.
+func (h *Handler) rescheduleInspectionSchedule(cfg config.AuthInspectionConfig, name string, now time.Time) {
+	h.inspectionMu.Lock()
+	defer h.inspectionMu.Unlock()
+	if !cfg.Enabled {
+		h.clearInspectionScheduleLocked()
+		return
+	}
+	if sched, ok := findInspectionSchedule(cfg, name); ok {
+		h.namedInspectionScheduleLocked(name).NextRunAt = nextAuthInspectionRun(inspectionConfigForSchedule(cfg, sched), now)
+	}
+	h.syncInspectionScheduleLocked(cfg)
+}
+
+func (h *Handler) namedInspectionScheduleLocked(name string) *authInspectionProviderSchedule {
+	if h.inspectionStatus.NamedSchedules == nil {
+		h.inspectionStatus.NamedSchedules = make(map[string]*authInspectionProviderSchedule)
+	}
+	sched, ok := h.inspectionStatus.NamedSchedules[name]
+	if !ok {
+		sched = &authInspectionProviderSchedule{}
+		h.inspectionStatus.NamedSchedules[name] = sched
+	}
+	return sched
+}
+
+// finishNamedInspectionLocked records the counters and error of a finished
+// run of the named schedule.
+func (h *Handler) finishNamedInspectionLocked(name string, summary *inspectionRunSummary, err error, finished time.Time) {
+	sched := h.namedInspectionScheduleLocked(name)
+	sched.LastRunStartedAt = summary.StartedAt
+	sched.LastRunFinished = finished
+	sched.Checked, sched.Valid, sched.Invalid = summary.Checked, summary.Valid, summary.Invalid
+	sched.Frozen, sched.Filtered = h.inspectionStatus.Frozen, h.inspectionStatus.Filtered
+	sched.LastError = ""
+	if err != nil {
+		sched.LastError = strings.TrimSpace(err.Error())
+	}
+}
+
+// namedSchedulesPayloadLocked describes each named schedule with its next run
+// and the counters of its last run.
+func (h *Handler) namedSchedulesPayloadLocked(cfg config.AuthInspectionConfig) gin.H {
+	out := make(gin.H, len(cfg.Schedules))
+	for _, sched := range cfg.Schedules {
+		entry := inspectionSchedulePayload(inspectionConfigForSchedule(cfg, sched), sched)
+		entry["next_run_at"] = time.Time{}
+		state, ok := h.inspectionStatus.NamedSchedules[sched.Name]
+		if ok {
+			entry["next_run_at"] = state.NextRunAt
+		}
+		if ok && !state.LastRunFinished.IsZero() {
+			entry["last_run_started_at"] = state.LastRunStartedAt
+			entry["last_run_finished"] = state.LastRunFinished
+			entry["last_checked"] = state.Checked
+			entry["last_valid"] = state.Valid
+			entry["last_invalid"] = state.Invalid
+			entry["last_frozen"] = state.Frozen
+			entry["last_filtered"] = state.Filtered
+			entry["last_error"] = state.LastError
+		}
+		out[sched.Name] = entry
+	}
+	return out
+}
+
+// inspectionSchedulePayload describes sched as it runs under effective, the
+// config returned for it by inspectionConfigForSchedule.
+func inspectionSchedulePayload(effective config.AuthInspectionConfig, sched config.AuthInspectionSchedule) gin.H {
+	providers := sched.Providers
+	if providers == nil {
+		providers = []string{}
+	}
+	return gin.H{
+		"name":                sched.Name,
+		"providers":           providers,
+		"interval_seconds":    sched.IntervalSeconds,
+		"cron":                sched.Cron,
+		"scope":               sched.Scope,
+		"auto_delete_invalid": effective.AutoDeleteInvalid,
+	}
+}
+
+// inspectionSchedulesPayload lists the named schedules in their configured order.
+func inspectionSchedulesPayload(cfg config.AuthInspectionConfig) []gin.H {
+	out := make([]gin.H, 0, len(cfg.Schedules))
+	for _, sched := range cfg.Schedules {
+		out = append(out, inspectionSchedulePayload(inspectionConfigForSchedule(cfg, sched), sched))
+	}
+	return out
+}
+
+// GetAuthInspectionSchedules lists the named inspection schedules with the
+// settings they inherit filled in.
+func (h *Handler) GetAuthInspectionSchedules(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"schedules": inspectionSchedulesPayload(h.effectiveAuthInspectionConfig())})
+}
+
+// inspectionScheduleRequest is one named schedule in a PUT body.
+type inspectionScheduleRequest struct {
+	Name              string   `json:"name"`
+	Providers         []string `json:"providers"`
+	IntervalSeconds   int      `json:"interval_seconds"`
+	Cron              string   `json:"cron"`
+	Scope             string   `json:"scope"`
+	AutoDeleteInvalid *bool    `json:"auto_delete_invalid"`
+}
+
+// PutAuthInspectionSchedules replaces the named inspection schedules. An
+// empty list goes back to the top-level and per-provider schedule.
+func (h *Handler) PutAuthInspectionSchedules(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "config unavailable"})
+		return
+	}
+	var req struct {
+		Schedules []inspectionScheduleRequest `json:"schedules"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	schedules := make([]config.AuthInspectionSchedule, 0, len(req.Schedules))
+	seen := make(map[string]struct{}, len(req.Schedules))
+	for i, item := range req.Schedules {
+		sched, err := h.validateInspectionSchedule(item)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("schedules[%d]: %v", i, err)})
+			return
+		}
+		if _, dup := seen[sched.Name]; dup {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("schedules[%d]: duplicate name %q", i, sched.Name)})
+			return
+		}
+		seen[sched.Name] = struct{}{}
+		schedules = append(schedules, sched)
+	}
+	if len(schedules) == 0 {
+		schedules = nil
+	}
+
+	h.mu.Lock()
+	oldCfg := h.cfg.AuthInspection
+	h.cfg.AuthInspection.Schedules = schedules
+	errSave := config.SaveConfigPreserveComments(h.configFilePath, h.cfg)
+	if errSave != nil {
+		h.cfg.AuthInspection = oldCfg
+	}
+	h.mu.Unlock()
+	if errSave != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", errSave)})
+		return
+	}
+
+	effective := h.effectiveAuthInspectionConfig()
+	h.rescheduleAuthInspection(effective, nil, h.schedulerClock().Now())
+	h.wakeAuthInspectionScheduler()
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "schedules": inspectionSchedulesPayload(effective)})
+}
+
+// validateInspectionSchedule checks one schedule of a PUT body and returns it
+// as it is saved.
+func (h *Handler) validateInspectionSchedule(item inspectionScheduleRequest) (config.AuthInspectionSchedule, error) {
+	sched := config.AuthInspectionSchedule{
+		Name:              strings.TrimSpace(item.Name),
+		IntervalSeconds:   item.IntervalSeconds,
+		Cron:              strings.TrimSpace(item.Cron),
+		AutoDeleteInvalid: item.AutoDeleteInvalid,
+	}
+	if sched.Name == "" {
+		return sched, fmt.Errorf("name is required")
+	}
+	if sched.IntervalSeconds != 0 && (sched.IntervalSeconds < minAuthInspectionIntervalSeconds || sched.IntervalSeconds > maxAuthInspectionIntervalSeconds) {
+		return sched, fmt.Errorf("interval_seconds must be between %d and %d", minAuthInspectionIntervalSeconds, maxAuthInspectionIntervalSeconds)
+	}
+	if sched.Cron != "" {
+		if _, err := backup.ParseCron(sched.Cron); err != nil {
+			return sched, fmt.Errorf("invalid cron: %v", err)
+		}
+	}
+	if strings.TrimSpace(item.Scope) != "" {
+		scope, ok := parseInspectionScope(item.Scope)
+		if !ok {
+			return sched, fmt.Errorf("scope must be %q or %q", inspectionScopeAll, inspectionScopeInvalidOnly)
+		}
+		sched.Scope = scope
+	}
+	for _, name := range item.Providers {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !h.authInspector().HasProbe(name) {
+			return sched, fmt.Errorf("unknown inspection provider %q", name)
+		}
+		if !slices.Contains(sched.Providers, name) {
+			sched.Providers = append(sched.Providers, name)
+		}
+	}
+	return sched, nil
+}
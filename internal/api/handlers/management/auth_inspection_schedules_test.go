@@ -0,0 +1,149 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthInspectionSchedulerLoop_NamedSchedules(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, authDir, "codex", 2)
+	registerInspectionFixtures(t, manager, authDir, "gemini-cli", 3)
+	var codexProbes, geminiProbes atomic.Int32
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(context.Context, *coreauth.Auth) (bool, string, error) {
+		codexProbes.Add(1)
+		return false, "", nil
+	}))
+	inspector.RegisterProbe("gemini-cli", coreauth.ProbeFunc(func(context.Context, *coreauth.Auth) (bool, string, error) {
+		geminiProbes.Add(1)
+		return false, "", nil
+	}))
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection = config.AuthInspectionConfig{
+		Enabled:         true,
+		IntervalSeconds: 3600,
+		Providers:       []config.AuthInspectionProvider{{Name: "codex"}, {Name: "gemini-cli"}},
+		Schedules: []config.AuthInspectionSchedule{
+			{Name: "hourly-codex", Providers: []string{"Codex"}},
+			{Name: "gemini", Providers: []string{"gemini-cli"}, IntervalSeconds: 7200},
+			{Name: "gemini", Providers: []string{"codex"}},
+			{Name: "unknown", Providers: []string{"claude"}},
+		},
+	}
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	h := &Handler{cfg: cfg, authManager: manager, configFilePath: configPath, inspectionClock: clock}
+	h.SetInspector(inspector)
+
+	if schedules := h.effectiveAuthInspectionConfig().Schedules; len(schedules) != 2 || schedules[0].Providers[0] != "codex" {
+		t.Fatalf("effective schedules = %+v", schedules)
+	}
+
+	h.startAuthInspectionScheduler()
+	defer func() { _ = h.Stop(context.Background()) }()
+
+	if d := clock.waitArmed(t); d != time.Hour {
+		t.Fatalf("first timer = %v, want 1h", d)
+	}
+	clock.Advance(time.Hour)
+	waitFor(t, "the hourly codex run", func() bool { return codexProbes.Load() == 2 })
+	if d := clock.waitArmed(t); d != time.Hour {
+		t.Fatalf("timer after the hourly run = %v, want 1h", d)
+	}
+	if geminiProbes.Load() != 0 {
+		t.Fatalf("gemini probed by the codex schedule: %d", geminiProbes.Load())
+	}
+
+	// Both fall due together and run one after the other.
+	clock.Advance(time.Hour)
+	waitFor(t, "both schedules", func() bool { return codexProbes.Load() == 4 && geminiProbes.Load() == 3 })
+	clock.waitArmed(t)
+
+	named, _ := h.authInspectionStatusPayload()["named_schedules"].(gin.H)
+	gemini, _ := named["gemini"].(gin.H)
+	if gemini["last_checked"] != 3 || gemini["interval_seconds"] != 7200 {
+		t.Fatalf("gemini schedule status = %v", gemini)
+	}
+	if next, _ := gemini["next_run_at"].(time.Time); !next.Equal(start.Add(4 * time.Hour)) {
+		t.Fatalf("gemini next_run_at = %v", next)
+	}
+	hourly, _ := named["hourly-codex"].(gin.H)
+	if hourly["last_checked"] != 2 {
+		t.Fatalf("hourly schedule status = %v", hourly)
+	}
+	if run, ok := h.inspectionRuns.get(""); !ok || !strings.HasPrefix(run.Trigger, "scheduled:") || run.Schedule == "" {
+		t.Fatalf("last run = %+v", run)
+	}
+}
+
+func TestPutAuthInspectionSchedules(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(context.Context, *coreauth.Auth) (bool, string, error) {
+		return false, "", nil
+	}))
+	cfg := &config.Config{}
+	cfg.AuthInspection = config.AuthInspectionConfig{Enabled: true, IntervalSeconds: 3600}
+	h := &Handler{cfg: cfg, authManager: manager, configFilePath: configPath}
+	h.SetInspector(inspector)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPut, "/v0/management/auth-files/inspection-schedules", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.PutAuthInspectionSchedules(c)
+		return rec
+	}
+
+	for _, body := range []string{
+		`{"schedules":[{"providers":["codex"]}]}`,
+		`{"schedules":[{"name":"a","providers":["no-such-provider"]}]}`,
+		`{"schedules":[{"name":"a","cron":"not a cron"}]}`,
+		`{"schedules":[{"name":"a","interval_seconds":60}]}`,
+		`{"schedules":[{"name":"a","scope":"some"}]}`,
+		`{"schedules":[{"name":"a"},{"name":" a "}]}`,
+	} {
+		if rec := put(body); rec.Code != http.StatusBadRequest {
+			t.Errorf("PUT %s: status %d, want 400", body, rec.Code)
+		}
+	}
+
+	rec := put(`{"schedules":[{"name":"weekly","cron":"0 3 * * 0","scope":"invalid_only","auto_delete_invalid":true}]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"scope":"invalid_only"`) || !strings.Contains(rec.Body.String(), `"auto_delete_invalid":true`) {
+		t.Fatalf("PUT response = %s", rec.Body.String())
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if !strings.Contains(string(saved), "name: weekly") || !strings.Contains(string(saved), "cron: 0 3 * * 0") {
+		t.Fatalf("saved config:\n%s", saved)
+	}
+}
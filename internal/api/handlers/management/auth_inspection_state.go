@@ -0,0 +1,97 @@
+package management
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// inspectionStateFileName is written next to the config file.
+const inspectionStateFileName = "auth-inspection-status.json"
+
+// inspectionStatePath returns where the inspection status of the server
+// using configFilePath is kept, or "" when there is no config file.
+func inspectionStatePath(configFilePath string) string {
+	if configFilePath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(configFilePath), inspectionStateFileName)
+}
+
+// saveInspectionState writes the status after a run, so a restart does not
+// look as if inspections never ran. A failure is only logged.
+func (h *Handler) saveInspectionState() {
+	path := inspectionStatePath(h.configFilePath)
+	if path == "" {
+		return
+	}
+	h.inspectionMu.RLock()
+	data, err := json.Marshal(h.inspectionStatus)
+	h.inspectionMu.RUnlock()
+	if err == nil {
+		err = writeFileAtomic(path, data)
+	}
+	if err != nil {
+		log.Warnf("failed to save auth inspection status: %v", err)
+	}
+}
+
+// loadInspectionState restores the status saved by the previous process; a
+// missing or unreadable file leaves it empty. Nothing is running after a
+// restart, and each provider's next run is counted from when its last run
+// finished rather than from now, so a crash loop cannot hold inspections
+// back indefinitely.
+func (h *Handler) loadInspectionState(cfg config.AuthInspectionConfig) {
+	path := inspectionStatePath(h.configFilePath)
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var saved authInspectionStatus
+	if err = json.Unmarshal(data, &saved); err != nil {
+		log.Debugf("ignoring unreadable auth inspection status %s: %v", path, err)
+		return
+	}
+	for _, sub := range saved.Providers {
+		if sub != nil {
+			sub.Running = false
+		}
+	}
+	for name, sched := range saved.Schedules {
+		if sched == nil {
+			delete(saved.Schedules, name)
+			continue
+		}
+		sched.NextRunAt = time.Time{}
+		if cfg.Enabled && !sched.LastRunFinished.IsZero() {
+			sched.NextRunAt = nextAuthInspectionRun(inspectionConfigFor(cfg, name), sched.LastRunFinished)
+		}
+	}
+	for name, sched := range saved.NamedSchedules {
+		named, ok := findInspectionSchedule(cfg, name)
+		if sched == nil || !ok {
+			delete(saved.NamedSchedules, name)
+			continue
+		}
+		sched.NextRunAt = time.Time{}
+		if cfg.Enabled && !sched.LastRunFinished.IsZero() {
+			sched.NextRunAt = nextAuthInspectionRun(inspectionConfigForSchedule(cfg, named), sched.LastRunFinished)
+		}
+	}
+
+	h.inspectionMu.Lock()
+	defer h.inspectionMu.Unlock()
+	if h.inspectionStatus.StartedSinceBoot {
+		return
+	}
+	saved.Paused = h.inspectionStatus.Paused
+	h.inspectionStatus = saved
+	h.syncInspectionScheduleLocked(cfg)
+}
@@ -0,0 +1,66 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthInspectionStatus_SurvivesRestart(t *testing.T) {
+	authDir := t.TempDir()
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, authDir, "codex", 3)
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		return auth.ID == "codex-02.json", "401 token revoked", nil
+	}))
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection = config.AuthInspectionConfig{Enabled: true, IntervalSeconds: 3600, RunOnStart: true}
+	h := &Handler{cfg: cfg, authManager: manager, configFilePath: configPath}
+	h.SetInspector(inspector)
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+	before := h.authInspectionStatusPayload()
+
+	restarted := &Handler{cfg: cfg, authManager: manager, configFilePath: configPath}
+	restarted.loadInspectionState(restarted.effectiveAuthInspectionConfig())
+	after := restarted.authInspectionStatusPayload()
+	for _, key := range []string{"checked", "valid", "invalid", "last_run_started_at", "last_run_finished", "newly_invalid"} {
+		want, _ := json.Marshal(before[key])
+		got, _ := json.Marshal(after[key])
+		if string(got) != string(want) {
+			t.Errorf("%s = %v after restart, want %v", key, after[key], before[key])
+		}
+	}
+	if after["running"] != false || after["checked"] != 3 {
+		t.Fatalf("restored status = %v", after)
+	}
+	// The next run counts from the last run, not from the restart.
+	restarted.inspectionMu.RLock()
+	finished := restarted.inspectionStatus.Schedules["codex"].LastRunFinished
+	next := restarted.inspectionStatus.NextRunAt
+	restarted.inspectionMu.RUnlock()
+	if want := finished.Add(time.Hour); !next.Equal(want) {
+		t.Fatalf("next run = %v, want %v", next, want)
+	}
+	// A restored run does not stop run-on-start from firing.
+	if !restarted.startupInspectionNeeded(true) {
+		t.Fatal("startup run suppressed by the restored status")
+	}
+
+	// A corrupted state file is ignored.
+	if err := os.WriteFile(inspectionStatePath(configPath), []byte("{not json"), 0o600); err != nil {
+		t.Fatalf("write state: %v", err)
+	}
+	fresh := &Handler{cfg: cfg, authManager: manager, configFilePath: configPath}
+	fresh.loadInspectionState(fresh.effectiveAuthInspectionConfig())
+	if status := fresh.authInspectionStatusPayload(); status["checked"] != 0 {
+		t.Fatalf("status from a corrupted file = %v", status)
+	}
+}
@@ -0,0 +1,152 @@
+package management
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const (
+	defaultProbeTimeoutSeconds = 30
+	minProbeTimeoutSeconds     = 1
+	maxProbeTimeoutSeconds     = 120
+)
+
+// probeSettings is how one provider's probe requests are made.
+type probeSettings struct {
+	// URL is the probe target. Empty lets the qwen and iflow probes derive
+	// it from the auth.
+	URL     string
+	Timeout time.Duration
+	// ExpectedStatusCodes are the statuses meaning the token is valid; empty
+	// accepts any 2xx.
+	ExpectedStatusCodes []int
+	// FallbackURL is probed when URL answers 5xx or times out; empty never
+	// falls back.
+	FallbackURL string
+}
+
+// expects reports whether a probe status means the token is valid.
+func (s probeSettings) expects(statusCode int) bool {
+	if len(s.ExpectedStatusCodes) == 0 {
+		return statusCode >= 200 && statusCode < 300
+	}
+	return slices.Contains(s.ExpectedStatusCodes, statusCode)
+}
+
+// builtinProbeURL returns the probe target a provider uses without a
+// verification entry, and whether the provider's probe has one at all.
+func builtinProbeURL(provider string) (string, bool) {
+	switch provider {
+	case "codex":
+		return codexUsageProbeURL, true
+	case "claude":
+		return claudeModelsProbeURL, true
+	case "gemini-cli":
+		return geminiCodeAssistProbeURL, true
+	case "qwen":
+		return qwenModelsProbeURL, true
+	case "iflow":
+		return iflowModelsProbeURL, true
+	}
+	return "", false
+}
+
+// probeSettings returns the effective probe settings of provider: its
+// verification entry over the built-in target and a 30s timeout.
+func (h *Handler) probeSettings(provider string) probeSettings {
+	settings := probeSettings{Timeout: defaultProbeTimeoutSeconds * time.Second}
+	settings.URL, _ = builtinProbeURL(provider)
+	settings.FallbackURL = builtinFallbackProbeURL(provider)
+	entry, ok := h.effectiveAuthInspectionConfig().Verification[provider]
+	if !ok {
+		return settings
+	}
+	if entry.ProbeURL != "" {
+		settings.URL = entry.ProbeURL
+	}
+	if entry.FallbackProbeURL != "" {
+		settings.FallbackURL = entry.FallbackProbeURL
+	}
+	if entry.TimeoutSeconds > 0 {
+		settings.Timeout = time.Duration(entry.TimeoutSeconds) * time.Second
+	}
+	settings.ExpectedStatusCodes = entry.ExpectedStatusCodes
+	return settings
+}
+
+// normalizeInspectionVerification lowercases provider names, trims probe URLs,
+// clamps timeouts to 1-120 seconds and lowercases error rule codes. It returns
+// a copy so the live config is never modified.
+func normalizeInspectionVerification(in map[string]config.AuthInspectionVerification) map[string]config.AuthInspectionVerification {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]config.AuthInspectionVerification, len(in))
+	for name, entry := range in {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		entry.ProbeURL = strings.TrimSpace(entry.ProbeURL)
+		entry.FallbackProbeURL = strings.TrimSpace(entry.FallbackProbeURL)
+		if entry.TimeoutSeconds > 0 {
+			entry.TimeoutSeconds = min(max(entry.TimeoutSeconds, minProbeTimeoutSeconds), maxProbeTimeoutSeconds)
+		} else {
+			entry.TimeoutSeconds = 0
+		}
+		entry.ExpectedStatusCodes = slices.Clone(entry.ExpectedStatusCodes)
+		entry.ErrorRules = normalizeErrorRules(entry.ErrorRules)
+		out[name] = entry
+	}
+	return out
+}
+
+// validateInspectionVerification checks one verification entry of a config
+// update.
+func validateInspectionVerification(provider string, entry config.AuthInspectionVerification) error {
+	if _, ok := builtinProbeURL(provider); !ok {
+		return fmt.Errorf("verification: provider %q has no configurable probe", provider)
+	}
+	for _, target := range []struct{ name, raw string }{{"probe_url", entry.ProbeURL}, {"fallback_probe_url", entry.FallbackProbeURL}} {
+		if raw := strings.TrimSpace(target.raw); raw != "" {
+			if parsed, err := url.Parse(raw); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return fmt.Errorf("verification.%s.%s must be an http or https URL", provider, target.name)
+			}
+		}
+	}
+	if entry.TimeoutSeconds != 0 && (entry.TimeoutSeconds < minProbeTimeoutSeconds || entry.TimeoutSeconds > maxProbeTimeoutSeconds) {
+		return fmt.Errorf("verification.%s.timeout_seconds must be between %d and %d", provider, minProbeTimeoutSeconds, maxProbeTimeoutSeconds)
+	}
+	for _, code := range entry.ExpectedStatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("verification.%s.expected_status_codes: %d is not an HTTP status", provider, code)
+		}
+	}
+	return validateErrorRules(provider, entry.ErrorRules)
+}
+
+// verificationPayload reports the effective probe settings and error rules of
+// every provider with a configurable probe. An empty probe_url means the
+// target is derived from each auth; an empty fallback_probe_url never falls
+// back.
+func (h *Handler) verificationPayload() gin.H {
+	providers := []string{"claude", "codex", "gemini-cli", "iflow", "qwen"}
+	out := make(gin.H, len(providers))
+	for _, name := range providers {
+		settings := h.probeSettings(name)
+		out[name] = gin.H{
+			"probe_url":             settings.URL,
+			"fallback_probe_url":    settings.FallbackURL,
+			"timeout_seconds":       int(settings.Timeout / time.Second),
+			"expected_status_codes": statusCodesOrEmpty(settings.ExpectedStatusCodes),
+			"error_rules":           h.errorRulesPayload(name),
+		}
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthInspectionConfig_Verification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	h := &Handler{cfg: &config.Config{}, configFilePath: configPath}
+
+	type probeEntry struct {
+		ProbeURL            string `json:"probe_url"`
+		TimeoutSeconds      int    `json:"timeout_seconds"`
+		ExpectedStatusCodes []int  `json:"expected_status_codes"`
+	}
+	get := func() map[string]probeEntry {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-files/inspection-config", nil)
+		h.GetAuthInspectionConfig(c)
+		var resp struct {
+			Verification map[string]probeEntry `json:"verification"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp.Verification
+	}
+	put := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPut, "/v0/management/auth-files/inspection-config", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.PutAuthInspectionConfig(c)
+		return rec
+	}
+
+	if got := get()["codex"]; got.ProbeURL != codexUsageProbeURL || got.TimeoutSeconds != 30 || len(got.ExpectedStatusCodes) != 0 {
+		t.Fatalf("codex defaults = %+v", got)
+	}
+	for _, body := range []string{
+		`{"verification":{"codex":{"probe_url":"ftp://gateway.example.com/usage"}}}`,
+		`{"verification":{"codex":{"probe_url":"/usage"}}}`,
+		`{"verification":{"codex":{"timeout_seconds":-1}}}`,
+		`{"verification":{"codex":{"timeout_seconds":121}}}`,
+		`{"verification":{"codex":{"expected_status_codes":[99]}}}`,
+		`{"verification":{"antigravity":{"timeout_seconds":10}}}`,
+	} {
+		if rec := put(body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d body=%s", body, rec.Code, rec.Body.String())
+		}
+	}
+
+	var hits []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits = append(hits, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	rec := put(`{"verification":{"Codex":{"probe_url":"` + srv.URL + `/regional/usage","timeout_seconds":5,"expected_status_codes":[204]}}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if got := get()["codex"]; got.ProbeURL != srv.URL+"/regional/usage" || got.TimeoutSeconds != 5 || len(got.ExpectedStatusCodes) != 1 {
+		t.Fatalf("codex settings = %+v", got)
+	}
+	if settings := h.probeSettings("codex"); settings.Timeout != 5*time.Second || settings.expects(http.StatusOK) || !settings.expects(http.StatusNoContent) {
+		t.Fatalf("probe settings = %+v", settings)
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil || !strings.Contains(string(saved), "probe-url") {
+		t.Fatalf("verification not saved: %s (%v)", saved, err)
+	}
+
+	auth := &coreauth.Auth{ID: "codex-a.json", Provider: "codex", Metadata: map[string]any{
+		"type":         "codex",
+		"access_token": "live-token",
+		"expired":      "2099-01-01T00:00:00Z",
+	}}
+	invalid, reason, errVerify := h.verifyCodexAuthToken(context.Background(), auth)
+	if invalid || errVerify != nil {
+		t.Fatalf("verify = %v, %q, %v", invalid, reason, errVerify)
+	}
+	if len(hits) != 1 || hits[0] != "/regional/usage" {
+		t.Fatalf("probe requests = %v", hits)
+	}
+}
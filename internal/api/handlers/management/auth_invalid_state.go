@@ -0,0 +1,50 @@
+package management
+
+import (
+	"context"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// markAuthInvalid records that the token of the auth id was found invalid for
+// reason. The token-invalid metadata, StatusError and the unavailable flag
+// are set together and written through the token store once, so the auth is
+// listed by both ?invalid=true and ?failed=true.
+func (h *Handler) markAuthInvalid(ctx context.Context, id, reason string) (*coreauth.Auth, error) {
+	return h.authManager.MarkInvalid(ctx, id, normalizeTokenInvalidReason(reason))
+}
+
+// clearAuthInvalid undoes markAuthInvalid, and DisableInvalid, for the auth
+// id after its token was seen working. It reports whether the auth was
+// marked; unmarked auths are not written.
+func (h *Handler) clearAuthInvalid(ctx context.Context, id string) (*coreauth.Auth, bool, error) {
+	return h.authManager.ClearInvalid(ctx, id)
+}
+
+// authInvalid reports whether auth is selected by DELETE ?invalid=true: its
+// token is marked invalid. Runtime-only, frozen, admin-disabled and already
+// removed auths are never selected.
+func (h *Handler) authInvalid(auth *coreauth.Auth) bool {
+	if auth == nil || isRuntimeOnlyAuth(auth) || coreauth.IsFrozen(auth) || coreauth.IsAdminDisabled(auth) || h.authManager.Removed(auth.ID) {
+		return false
+	}
+	invalid, _ := tokenInvalidState(auth)
+	return invalid
+}
+
+// authFailed reports whether auth is selected by DELETE ?failed=true: it is
+// invalid, or enabled and unavailable after failing requests. Every invalid
+// auth is failed; an auth failing for other reasons, such as an upstream
+// outage, is failed without being invalid.
+func (h *Handler) authFailed(auth *coreauth.Auth) bool {
+	if h.authInvalid(auth) {
+		return true
+	}
+	if auth == nil || auth.Disabled || auth.Status == coreauth.StatusDisabled {
+		return false
+	}
+	if isRuntimeOnlyAuth(auth) || coreauth.IsFrozen(auth) || coreauth.IsAdminDisabled(auth) {
+		return false
+	}
+	return auth.Unavailable
+}
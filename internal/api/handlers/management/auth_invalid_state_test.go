@@ -0,0 +1,118 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthInvalidAndFailedViews(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	register := func(auth *coreauth.Auth) {
+		t.Helper()
+		path := filepath.Join(authDir, auth.ID)
+		if err := os.WriteFile(path, []byte(`{"type":"gemini"}`), 0o600); err != nil {
+			t.Fatalf("write auth file: %v", err)
+		}
+		auth.FileName, auth.Provider = auth.ID, "gemini"
+		auth.Attributes = map[string]string{"path": path}
+		if auth.Metadata == nil {
+			auth.Metadata = map[string]any{"type": "gemini"}
+		}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+	register(&coreauth.Auth{ID: "healthy.json", Status: coreauth.StatusActive})
+	register(&coreauth.Auth{ID: "marked.json", Status: coreauth.StatusActive})
+	register(&coreauth.Auth{ID: "outage.json", Status: coreauth.StatusError, Unavailable: true})
+	register(&coreauth.Auth{ID: "disabled-invalid.json", Status: coreauth.StatusDisabled, Disabled: true, Metadata: map[string]any{
+		"type":              "gemini",
+		tokenInvalidMetaKey: true,
+	}})
+	register(&coreauth.Auth{ID: "disabled-outage.json", Status: coreauth.StatusDisabled, Disabled: true, Unavailable: true})
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager, tokenStore: store}
+
+	marked, err := h.markAuthInvalid(context.Background(), "marked.json", "401 revoked")
+	if err != nil {
+		t.Fatalf("markAuthInvalid: %v", err)
+	}
+	if invalid, reason := tokenInvalidState(marked); !invalid || reason != "401 revoked" || marked.Status != coreauth.StatusError || !marked.Unavailable || marked.StatusMessage != "401 revoked" {
+		t.Fatalf("marked auth = status %s unavailable %v metadata %v", marked.Status, marked.Unavailable, marked.Metadata)
+	}
+	store.mu.Lock()
+	persisted := store.items["marked.json"].Clone()
+	store.mu.Unlock()
+	if invalid, _ := tokenInvalidState(persisted); !invalid || persisted.Status != coreauth.StatusError {
+		t.Fatalf("mark not persisted: status %s metadata %v", persisted.Status, persisted.Metadata)
+	}
+
+	tests := []struct {
+		id              string
+		invalid, failed bool
+	}{
+		{"healthy.json", false, false},
+		{"marked.json", true, true},
+		{"outage.json", false, true},
+		{"disabled-invalid.json", true, true},
+		{"disabled-outage.json", false, false},
+	}
+	for _, tt := range tests {
+		auth, _ := manager.GetByID(tt.id)
+		if got := h.authInvalid(auth); got != tt.invalid {
+			t.Errorf("%s: authInvalid = %v, want %v", tt.id, got, tt.invalid)
+		}
+		if got := h.authFailed(auth); got != tt.failed {
+			t.Errorf("%s: authFailed = %v, want %v", tt.id, got, tt.failed)
+		}
+	}
+
+	// A working token clears the mark and its failure; clearing again is a no-op.
+	cleared, changed, err := h.clearAuthInvalid(context.Background(), "marked.json")
+	if err != nil || !changed || h.authInvalid(cleared) || h.authFailed(cleared) || cleared.Status != coreauth.StatusActive {
+		t.Fatalf("clearAuthInvalid = status %s unavailable %v changed %v err %v", cleared.Status, cleared.Unavailable, changed, err)
+	}
+	if _, changed, err = h.clearAuthInvalid(context.Background(), "outage.json"); err != nil || changed {
+		t.Fatalf("clearing an unmarked auth = %v, %v", changed, err)
+	}
+	if _, err = h.markAuthInvalid(context.Background(), "marked.json", "401 revoked"); err != nil {
+		t.Fatalf("markAuthInvalid: %v", err)
+	}
+
+	deleteAuths := func(query string) float64 {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodDelete, "/v0/management/auth-files?"+query, nil)
+		h.DeleteAuthFile(c)
+		var resp struct {
+			Deleted float64 `json:"deleted"`
+		}
+		if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &resp) != nil {
+			t.Fatalf("%s: status %d body=%s", query, rec.Code, rec.Body.String())
+		}
+		return resp.Deleted
+	}
+	if got := deleteAuths("invalid=true"); got != 2 {
+		t.Fatalf("invalid=true deleted %v, want the marked and disabled-invalid auths", got)
+	}
+	if got := deleteAuths("failed=true"); got != 1 {
+		t.Fatalf("failed=true deleted %v, want the outage auth", got)
+	}
+	for name, kept := range map[string]bool{"healthy.json": true, "marked.json": false, "outage.json": false, "disabled-invalid.json": false, "disabled-outage.json": true} {
+		if _, err := os.Stat(filepath.Join(authDir, name)); (err == nil) != kept {
+			t.Errorf("%s: kept = %v, want %v", name, err == nil, kept)
+		}
+	}
+}
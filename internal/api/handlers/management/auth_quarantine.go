@@ -0,0 +1,195 @@
+package management
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// quarantineSidecarSuffix names the file kept next to a quarantined auth file
+// that records where it came from and why it was removed.
+const quarantineSidecarSuffix = ".quarantine.json"
+
+// quarantineRecord is the content of a quarantine sidecar file.
+type quarantineRecord struct {
+	OriginalPath  string    `json:"original_path"`
+	ID            string    `json:"id"`
+	Provider      string    `json:"provider,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+// quarantineDir returns the absolute quarantine directory, or "" when invalid
+// auth files are deleted outright. A directory inside the auth dir is
+// rejected, since the watcher would load the quarantined files again.
+func (h *Handler) quarantineDir() (string, error) {
+	if h == nil || h.cfg == nil {
+		return "", nil
+	}
+	return resolveQuarantineDir(h.cfg.AuthInspection.QuarantineDir, h.cfg.AuthDir)
+}
+
+func resolveQuarantineDir(raw, authDir string) (string, error) {
+	dir, err := util.ResolveAuthDir(strings.TrimSpace(raw))
+	if err != nil || dir == "" {
+		return "", err
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return "", err
+	}
+	if base, errBase := util.ResolveAuthDir(strings.TrimSpace(authDir)); errBase == nil && base != "" {
+		if base, errBase = filepath.Abs(base); errBase == nil && pathWithin(base, dir) {
+			return "", fmt.Errorf("quarantine dir %s must be outside the auth dir", dir)
+		}
+	}
+	return dir, nil
+}
+
+// pathWithin reports whether path is dir or lies below it.
+func pathWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// quarantineAuthFile moves the auth file at path into dir and writes its
+// sidecar record.
+func quarantineAuthFile(auth *coreauth.Auth, path, dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create quarantine dir: %w", err)
+	}
+	base := filepath.Base(path)
+	dst := filepath.Join(dir, base)
+	if _, err := os.Stat(dst); err == nil {
+		dst = filepath.Join(dir, strings.TrimSuffix(base, filepath.Ext(base))+"-"+time.Now().UTC().Format("20060102T150405")+filepath.Ext(base))
+	}
+	if err := moveFile(path, dst); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to quarantine file: %w", err)
+	}
+	_, reason := tokenInvalidState(auth)
+	record, err := json.MarshalIndent(quarantineRecord{
+		OriginalPath:  path,
+		ID:            auth.ID,
+		Provider:      auth.Provider,
+		Reason:        reason,
+		QuarantinedAt: time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(dst+quarantineSidecarSuffix, record, 0o600); err != nil {
+		return fmt.Errorf("failed to write quarantine record: %w", err)
+	}
+	return nil
+}
+
+// moveFile renames src to dst, copying across filesystems when needed.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	} else if _, errStat := os.Stat(src); errStat != nil {
+		return errStat
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err == nil {
+		err = out.Sync()
+	}
+	if errClose := out.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// RestoreAuthFile moves a quarantined auth file back into the auth dir, at its
+// original path when that is still inside it, clears its invalid mark and
+// registers it again.
+func (h *Handler) RestoreAuthFile(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || name != filepath.Base(name) || !strings.HasSuffix(strings.ToLower(name), ".json") || strings.HasSuffix(name, quarantineSidecarSuffix) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid name"})
+		return
+	}
+	dir, err := h.quarantineDir()
+	if err != nil || dir == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "quarantine is not configured"})
+		return
+	}
+	src := filepath.Join(dir, name)
+	data, err := os.ReadFile(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "quarantined file not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read quarantined file: %v", err)})
+		}
+		return
+	}
+
+	authDir, _ := filepath.Abs(h.cfg.AuthDir)
+	dst := filepath.Join(authDir, name)
+	var record quarantineRecord
+	if raw, errRecord := os.ReadFile(src + quarantineSidecarSuffix); errRecord == nil && json.Unmarshal(raw, &record) == nil {
+		if original := filepath.Clean(record.OriginalPath); filepath.IsAbs(original) && pathWithin(authDir, original) {
+			dst = original
+		}
+	}
+	if _, errStat := os.Stat(dst); errStat == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "an auth file already exists at the restore path", "path": dst})
+		return
+	}
+
+	auth, err := h.authFromFile(dst, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	setTokenInvalidState(auth, false, "")
+	if err = os.MkdirAll(filepath.Dir(dst), 0o700); err == nil {
+		err = moveFile(src, dst)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to restore file: %v", err)})
+		return
+	}
+	_ = os.Remove(src + quarantineSidecarSuffix)
+	if _, err = h.authManager.RegisterOrUpdate(c.Request.Context(), auth); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to register auth: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "id": auth.ID, "path": dst, "reason": record.Reason})
+}
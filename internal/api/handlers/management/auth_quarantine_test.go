@@ -0,0 +1,96 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthInspection_QuarantineAndRestore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	quarantine := filepath.Join(t.TempDir(), "quarantine")
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	paths := registerInspectionFixtures(t, manager, authDir, "codex", 2)
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		return auth.ID == "codex-01.json", "402 billing issue", nil
+	}))
+	cfg := &config.Config{AuthDir: authDir}
+	cfg.AuthInspection.Providers = []config.AuthInspectionProvider{{Name: "codex", Concurrency: 1}}
+	cfg.AuthInspection.AutoDeleteInvalid = true
+	cfg.AuthInspection.QuarantineDir = quarantine
+	h := &Handler{cfg: cfg, authManager: manager, tokenStore: &memoryAuthStore{}}
+	h.SetInspector(inspector)
+
+	h.runAuthInspection(context.Background(), "manual", nil, false)
+
+	if _, err := os.Stat(paths[1]); !os.IsNotExist(err) {
+		t.Fatalf("invalid file still in auth dir: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(quarantine, "codex-01.json"))
+	if err != nil || !strings.Contains(string(content), `"type":"codex"`) {
+		t.Fatalf("quarantined content = %q (%v)", content, err)
+	}
+	var record quarantineRecord
+	raw, err := os.ReadFile(filepath.Join(quarantine, "codex-01.json"+quarantineSidecarSuffix))
+	if err != nil || json.Unmarshal(raw, &record) != nil {
+		t.Fatalf("sidecar = %q (%v)", raw, err)
+	}
+	if record.OriginalPath != paths[1] || record.Reason != "402 billing issue" {
+		t.Fatalf("sidecar record = %+v", record)
+	}
+	if auth, ok := manager.GetByID("codex-01.json"); !ok || !auth.Disabled {
+		t.Fatal("quarantined auth should be deregistered")
+	}
+	if _, err = os.Stat(paths[0]); err != nil {
+		t.Fatalf("valid file touched: %v", err)
+	}
+
+	restore := func(name string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/restore", strings.NewReader(`{"name":"`+name+`"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.RestoreAuthFile(c)
+		return rec
+	}
+	if rec := restore("../codex-01.json"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("path traversal: status %d", rec.Code)
+	}
+	if rec := restore("missing.json"); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing file: status %d", rec.Code)
+	}
+	if rec := restore("codex-01.json"); rec.Code != http.StatusOK {
+		t.Fatalf("restore: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if _, err = os.Stat(paths[1]); err != nil {
+		t.Fatalf("restored file missing: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(quarantine, "codex-01.json"+quarantineSidecarSuffix)); !os.IsNotExist(err) {
+		t.Fatalf("sidecar left behind: %v", err)
+	}
+	auth, ok := manager.GetByID("codex-01.json")
+	if invalid, _ := tokenInvalidState(auth); !ok || auth.Disabled || invalid {
+		t.Fatalf("restored auth = %+v", auth)
+	}
+}
+
+func TestResolveQuarantineDir_RejectsAuthDir(t *testing.T) {
+	authDir := t.TempDir()
+	if _, err := resolveQuarantineDir(filepath.Join(authDir, "quarantine"), authDir); err == nil {
+		t.Fatal("quarantine inside the auth dir should be rejected")
+	}
+	if dir, err := resolveQuarantineDir("", authDir); err != nil || dir != "" {
+		t.Fatalf("unset quarantine = %q, %v", dir, err)
+	}
+}
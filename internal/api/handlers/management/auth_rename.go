@@ -0,0 +1,136 @@
+package management
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// RenameAuthFile renames the file of the auth named by :id to the "name" of
+// the JSON body, within its directory. The auth keeps its runtime state; its
+// file name and path follow the file, and so does its ID when it was derived
+// from the file name. Auths whose path lies outside AuthDir are refused, as
+// they are by deletion.
+func (h *Handler) RenameAuthFile(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	name, errName := validateRenameTarget(req.Name)
+	if errName != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errName.Error()})
+		return
+	}
+	target := h.findAuthByNameOrID(strings.TrimSpace(c.Param("id")))
+	if target == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+		return
+	}
+	if isRuntimeOnlyAuth(target) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "runtime-only auths have no file to rename"})
+		return
+	}
+	src, ok := h.resolveAuthFilePath(target)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "auth file is outside the auth directory"})
+		return
+	}
+	if _, err := os.Stat(src); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found on disk"})
+		return
+	}
+	oldName := filepath.Base(src)
+	dst := filepath.Join(filepath.Dir(src), name)
+	if dst == src {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "auth file already has that name"})
+		return
+	}
+	newID := target.ID
+	switch target.ID {
+	case h.authIDForPath(src):
+		newID = h.authIDForPath(dst)
+	case oldName:
+		newID = name
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": name + " already exists"})
+		return
+	}
+	if existing, taken := h.authManager.GetByID(newID); taken && newID != target.ID && !h.authManager.Removed(existing.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": name + " is already registered"})
+		return
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to rename auth file: %v", err)})
+		return
+	}
+	auth, err := h.authManager.Rename(c.Request.Context(), target.ID, newID, func(auth *coreauth.Auth) {
+		auth.FileName = name
+		if auth.Attributes == nil {
+			auth.Attributes = make(map[string]string)
+		}
+		auth.Attributes["path"] = dst
+		if source := auth.Attributes["source"]; source == "" || source == src {
+			auth.Attributes["source"] = dst
+		}
+	})
+	var conflict *coreauth.ErrAlreadyRegistered
+	switch {
+	case auth == nil && err != nil:
+		// The manager kept the old record, so put the file back under it.
+		if errBack := os.Rename(dst, src); errBack != nil {
+			log.Errorf("auth rename: failed to restore %s after %v: %v", src, err, errBack)
+		}
+		if errors.As(err, &conflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": name + " is already registered"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to rename auth: %v", err)})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("auth file renamed but not saved: %v", err)})
+		return
+	}
+	// Stores mirroring the auth dir keep a record per file, so the one under
+	// the old name goes; Rename already saved the auth under the new one.
+	if errDel := h.deleteTokenRecord(c.Request.Context(), src); errDel != nil && !errors.Is(errDel, os.ErrNotExist) {
+		log.Warnf("auth rename: renamed %s but not its token record: %v", oldName, errDel)
+	}
+	log.Infof("auth rename: %s -> %s", oldName, name)
+	payload := h.authRecordPayload(auth)
+	payload["old_name"] = oldName
+	payload["old_id"] = target.ID
+	c.JSON(http.StatusOK, payload)
+}
+
+// validateRenameTarget checks the new name of an auth file: a plain .json
+// file name, not hidden, without path separators.
+func validateRenameTarget(raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	switch {
+	case name == "":
+		return "", fmt.Errorf("name is required")
+	case strings.ContainsAny(name, `/\`) || strings.ContainsRune(name, 0):
+		return "", fmt.Errorf("name must not contain path separators")
+	case strings.HasPrefix(name, "."):
+		return "", fmt.Errorf("name must not start with a dot")
+	case !strings.HasSuffix(strings.ToLower(name), ".json"):
+		return "", fmt.Errorf("name must end in .json")
+	}
+	return name, nil
+}
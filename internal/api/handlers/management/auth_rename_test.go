@@ -0,0 +1,169 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestRenameAuthFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	store := sdkAuth.NewFileTokenStore()
+	store.SetBaseDir(authDir)
+	manager := coreauth.NewManager(store, nil, nil)
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager}
+	register := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write auth file: %v", err)
+		}
+		auth, err := h.authFromFile(path, nil)
+		if err != nil {
+			t.Fatalf("load auth: %v", err)
+		}
+		if _, err = manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+	src := filepath.Join(authDir, "0b7c2f.json")
+	register(src, `{"type":"codex","email":"alice@example.com","access_token":"at"}`)
+	register(filepath.Join(authDir, "taken.json"), `{"type":"codex"}`)
+	outside := filepath.Join(t.TempDir(), "outside.json")
+	if err := os.WriteFile(outside, []byte(`{"type":"codex"}`), 0o600); err != nil {
+		t.Fatalf("write outside file: %v", err)
+	}
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: "outside.json", Provider: "codex", Attributes: map[string]string{"path": outside}, Metadata: map[string]any{"type": "codex"}}); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	if _, err := manager.MarkInvalid(context.Background(), "0b7c2f.json", "401 token revoked"); err != nil {
+		t.Fatalf("mark invalid: %v", err)
+	}
+	rename := func(id, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/"+id+"/rename", strings.NewReader(body))
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		h.RenameAuthFile(c)
+		return rec
+	}
+
+	for body, want := range map[string]int{
+		`{"name":"../escape.json"}`: http.StatusBadRequest,
+		`{"name":"sub\\x.json"}`:    http.StatusBadRequest,
+		`{"name":"alice.txt"}`:      http.StatusBadRequest,
+		`{"name":".hidden.json"}`:   http.StatusBadRequest,
+		`{"name":""}`:               http.StatusBadRequest,
+		`{"name":"taken.json"}`:     http.StatusConflict,
+		`{"name":"0b7c2f.json"}`:    http.StatusBadRequest,
+	} {
+		if rec := rename("0b7c2f.json", body); rec.Code != want {
+			t.Errorf("%s: status %d, want %d (%s)", body, rec.Code, want, rec.Body.String())
+		}
+	}
+	if rec := rename("outside.json", `{"name":"moved.json"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("outside rename: status %d", rec.Code)
+	}
+	if rec := rename("missing.json", `{"name":"x.json"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing rename: status %d", rec.Code)
+	}
+
+	rec := rename("0b7c2f.json", `{"name":"codex-alice@example.json"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("rename: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp["old_name"] != "0b7c2f.json" || resp["id"] != "codex-alice@example.json" || resp["name"] != "codex-alice@example.json" || strings.Contains(rec.Body.String(), `"at"`) {
+		t.Fatalf("response = %s", rec.Body.String())
+	}
+	dst := filepath.Join(authDir, "codex-alice@example.json")
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Fatalf("old file still present: %v", err)
+	}
+	if data, _ := os.ReadFile(dst); !strings.Contains(string(data), "alice@example.com") {
+		t.Fatalf("renamed file = %s", data)
+	}
+	if _, ok := manager.GetByID("0b7c2f.json"); ok {
+		t.Fatalf("old ID still registered")
+	}
+	auth, ok := manager.GetByID("codex-alice@example.json")
+	if !ok || auth.FileName != "codex-alice@example.json" || auth.Attributes["path"] != dst {
+		t.Fatalf("renamed auth = %+v", auth)
+	}
+	if invalid, reason := coreauth.TokenInvalidState(auth); !invalid || reason == "" {
+		t.Fatalf("renamed auth lost its state: invalid=%v reason=%q", invalid, reason)
+	}
+}
+
+// mirrorStore keeps a copy of every auth file under its base name, as the
+// object and git stores do, on top of the file store.
+type mirrorStore struct {
+	*sdkAuth.FileTokenStore
+	mu      sync.Mutex
+	records map[string]bool
+}
+
+func (s *mirrorStore) Save(ctx context.Context, auth *coreauth.Auth) (string, error) {
+	path, err := s.FileTokenStore.Save(ctx, auth)
+	if err == nil && path != "" {
+		s.mu.Lock()
+		s.records[filepath.Base(path)] = true
+		s.mu.Unlock()
+	}
+	return path, err
+}
+
+func (s *mirrorStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	delete(s.records, filepath.Base(id))
+	s.mu.Unlock()
+	return s.FileTokenStore.Delete(ctx, id)
+}
+
+func TestRenameAuthFile_DeletesOldStoreRecord(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	store := &mirrorStore{FileTokenStore: sdkAuth.NewFileTokenStore(), records: map[string]bool{}}
+	store.SetBaseDir(authDir)
+	manager := coreauth.NewManager(store, nil, nil)
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager, tokenStore: store}
+	src := filepath.Join(authDir, "old.json")
+	if err := os.WriteFile(src, []byte(`{"type":"codex","email":"a@example.com"}`), 0o600); err != nil {
+		t.Fatalf("write auth file: %v", err)
+	}
+	auth, err := h.authFromFile(src, nil)
+	if err != nil {
+		t.Fatalf("load auth: %v", err)
+	}
+	if _, err = manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	store.records["old.json"] = true
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/old.json/rename", strings.NewReader(`{"name":"new.json"}`))
+	c.Params = gin.Params{{Key: "id", Value: "old.json"}}
+	h.RenameAuthFile(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("rename: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if store.records["old.json"] || !store.records["new.json"] {
+		t.Fatalf("store records = %v", store.records)
+	}
+	if _, err = os.Stat(filepath.Join(authDir, "new.json")); err != nil {
+		t.Fatalf("renamed file missing: %v", err)
+	}
+}
@@ -0,0 +1,307 @@
+package management
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+const (
+	authStatusHookTimestampHeader = "X-Hook-Timestamp"
+	authStatusHookSignatureHeader = "X-Hook-Signature"
+	authStatusHookMaxSkew         = 5 * time.Minute
+	authStatusHookMaxBody         = 1 << 20
+	// authStatusHookDeliveryTTL bounds how long event IDs are remembered for replay detection.
+	authStatusHookDeliveryTTL = 24 * time.Hour
+	externalReasonPrefix      = "external: "
+)
+
+// Statuses that mark an auth invalid. Any other known status only disables it.
+var authStatusHookInvalidStatuses = map[string]struct{}{
+	"banned":      {},
+	"suspended":   {},
+	"deactivated": {},
+	"revoked":     {},
+	"invalid":     {},
+}
+
+var authStatusHookDisableStatuses = map[string]struct{}{
+	"disabled":       {},
+	"rate_limited":   {},
+	"quota_exceeded": {},
+	"paused":         {},
+}
+
+// authStatusEvent is one external signal about an account.
+type authStatusEvent struct {
+	ID       string `json:"id"`
+	Account  string `json:"account"`
+	Provider string `json:"provider"`
+	Status   string `json:"status"`
+	Reason   string `json:"reason"`
+}
+
+type authStatusChange struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Action string `json:"action"` // invalidated or disabled
+}
+
+type authStatusEventResult struct {
+	ID        string             `json:"id,omitempty"`
+	Account   string             `json:"account"`
+	Status    string             `json:"status"`
+	Matched   int                `json:"matched"`
+	Changed   []authStatusChange `json:"changed"`
+	Duplicate bool               `json:"duplicate,omitempty"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// hookDeliveries remembers processed event IDs so replayed deliveries are no-ops.
+type hookDeliveries struct {
+	mu   sync.Mutex
+	seen map[string]hookDelivery
+}
+
+type hookDelivery struct {
+	at     time.Time
+	result authStatusEventResult
+}
+
+func (d *hookDeliveries) lookup(id string, now time.Time) (authStatusEventResult, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entry, ok := d.seen[id]
+	if !ok || now.Sub(entry.at) > authStatusHookDeliveryTTL {
+		return authStatusEventResult{}, false
+	}
+	return entry.result, true
+}
+
+func (d *hookDeliveries) remember(id string, result authStatusEventResult, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.seen == nil {
+		d.seen = make(map[string]hookDelivery)
+	}
+	for key, entry := range d.seen {
+		if now.Sub(entry.at) > authStatusHookDeliveryTTL {
+			delete(d.seen, key)
+		}
+	}
+	d.seen[id] = hookDelivery{at: now, result: result}
+}
+
+// verifyAuthStatusHookSignature checks the HMAC-SHA256 signature of
+// "<timestamp>.<body>" and rejects timestamps outside authStatusHookMaxSkew.
+func verifyAuthStatusHookSignature(secret, timestamp, signature string, body []byte, now time.Time) error {
+	ts, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing or invalid %s", authStatusHookTimestampHeader)
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > authStatusHookMaxSkew || skew < -authStatusHookMaxSkew {
+		return fmt.Errorf("timestamp outside the allowed window")
+	}
+	provided, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), "sha256="))
+	if err != nil || len(provided) == 0 {
+		return fmt.Errorf("missing or invalid %s", authStatusHookSignatureHeader)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.TrimSpace(timestamp)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	if !hmac.Equal(provided, mac.Sum(nil)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// AuthStatusHook receives signed external signals about accounts, e.g. ban
+// notices, and marks the matching auths invalid or disables them. It accepts
+// a single event or an array of events. When any event matches no auth the
+// response is 202 so the sender can retry later.
+func (h *Handler) AuthStatusHook(c *gin.Context) {
+	secret := ""
+	if h.cfg != nil {
+		secret = strings.TrimSpace(h.cfg.RemoteManagement.AuthStatusHook.Secret)
+	}
+	if secret == "" {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	if !h.checkManagementNetwork(c, h.cfg) {
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, authStatusHookMaxBody+1))
+	if err != nil || len(body) > authStatusHookMaxBody {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+		return
+	}
+	now := time.Now()
+	if errSig := verifyAuthStatusHookSignature(secret, c.GetHeader(authStatusHookTimestampHeader), c.GetHeader(authStatusHookSignatureHeader), body, now); errSig != nil {
+		h.recordAudit(auditEntry{
+			Actor:    "auth-status-hook",
+			ClientIP: h.managementClientIP(c),
+			Method:   c.Request.Method,
+			Path:     c.Request.URL.Path,
+			Status:   http.StatusUnauthorized,
+			Action:   "auth_status_hook_rejected",
+			Detail:   errSig.Error(),
+		})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errSig.Error()})
+		return
+	}
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+
+	var events []authStatusEvent
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &events)
+	} else {
+		var event authStatusEvent
+		err = json.Unmarshal(trimmed, &event)
+		events = []authStatusEvent{event}
+	}
+	if err != nil || len(events) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	results := make([]authStatusEventResult, 0, len(events))
+	matched, changed, unmatched := 0, 0, 0
+	for _, event := range events {
+		result := h.applyAuthStatusEvent(ctx, event, now)
+		if !result.Duplicate && result.Error == "" && len(result.Changed) > 0 {
+			names := make([]string, 0, len(result.Changed))
+			for _, ch := range result.Changed {
+				names = append(names, ch.Name+"="+ch.Action)
+			}
+			h.recordAudit(auditEntry{
+				Actor:    "auth-status-hook",
+				ClientIP: h.managementClientIP(c),
+				Method:   c.Request.Method,
+				Path:     c.Request.URL.Path,
+				Status:   http.StatusOK,
+				Action:   "auth_status_hook",
+				Detail:   fmt.Sprintf("event=%s account=%s status=%s changed=%s", result.ID, result.Account, result.Status, strings.Join(names, ",")),
+			})
+		}
+		if result.Error == "" && result.Matched == 0 {
+			unmatched++
+		}
+		matched += result.Matched
+		changed += len(result.Changed)
+		results = append(results, result)
+	}
+	if changed > 0 {
+		h.life.goWorker(func() { h.evaluateAlerts(context.Background(), "auth-status-hook") })
+	}
+
+	status := http.StatusOK
+	if unmatched > 0 {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, gin.H{"status": "ok", "matched": matched, "changed": changed, "results": results})
+}
+
+// applyAuthStatusEvent applies one event. Events that match no auth are not
+// remembered, so a retried delivery is processed again.
+func (h *Handler) applyAuthStatusEvent(ctx context.Context, event authStatusEvent, now time.Time) authStatusEventResult {
+	id := strings.TrimSpace(event.ID)
+	account := strings.TrimSpace(event.Account)
+	status := strings.ToLower(strings.TrimSpace(event.Status))
+	result := authStatusEventResult{ID: id, Account: account, Status: status, Changed: []authStatusChange{}}
+	if id != "" {
+		if previous, ok := h.hookDeliveries.lookup(id, now); ok {
+			previous.Duplicate = true
+			return previous
+		}
+	}
+	if account == "" {
+		result.Error = "account is required"
+		return result
+	}
+	_, invalidate := authStatusHookInvalidStatuses[status]
+	_, disable := authStatusHookDisableStatuses[status]
+	if !invalidate && !disable {
+		result.Error = fmt.Sprintf("unknown status %q", event.Status)
+		return result
+	}
+	reason := strings.TrimSpace(event.Reason)
+	if reason == "" {
+		reason = status
+	}
+	reason = normalizeTokenInvalidReason(externalReasonPrefix + reason)
+
+	provider := strings.ToLower(strings.TrimSpace(event.Provider))
+	for _, auth := range h.authManager.List() {
+		if h.authManager.Removed(auth.ID) || !authMatchesExternalAccount(auth, provider, account) {
+			continue
+		}
+		result.Matched++
+		change := authStatusChange{ID: auth.ID, Name: auth.FileName, Action: "disabled"}
+		if change.Name == "" {
+			change.Name = auth.ID
+		}
+		var err error
+		if invalidate {
+			change.Action = "invalidated"
+			_, err = h.markAuthInvalid(ctx, auth.ID, reason)
+		} else {
+			_, err = h.authManager.Edit(ctx, auth.ID, func(stored *coreauth.Auth) error {
+				stored.Disabled = true
+				stored.Status = coreauth.StatusDisabled
+				stored.StatusMessage = reason
+				return nil
+			})
+		}
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to update %s: %v", change.Name, err)
+			return result
+		}
+		result.Changed = append(result.Changed, change)
+	}
+	if id != "" && result.Matched > 0 {
+		h.hookDeliveries.remember(id, result, now)
+	}
+	return result
+}
+
+// authMatchesExternalAccount matches account against the auth's file name, ID,
+// email, account label or Codex account ID.
+func authMatchesExternalAccount(auth *coreauth.Auth, provider, account string) bool {
+	if auth == nil {
+		return false
+	}
+	if provider != "" && !strings.EqualFold(strings.TrimSpace(auth.Provider), provider) {
+		return false
+	}
+	_, label := auth.AccountInfo()
+	candidates := []string{auth.ID, auth.FileName, strings.TrimSuffix(auth.FileName, ".json"), authEmail(auth), label}
+	if strings.EqualFold(strings.TrimSpace(auth.Provider), "codex") {
+		candidates = append(candidates, codexAccountID(auth))
+	}
+	for _, candidate := range candidates {
+		if candidate = strings.TrimSpace(candidate); candidate != "" && strings.EqualFold(candidate, account) {
+			return true
+		}
+	}
+	return false
+}
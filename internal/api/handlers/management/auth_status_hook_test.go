@@ -0,0 +1,108 @@
+package management
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthStatusHook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const secret = "hook-secret"
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	for _, auth := range []*coreauth.Auth{
+		{ID: "codex-a.json", FileName: "codex-a.json", Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{"type": "codex", "account_id": "acct-123"}},
+		{ID: "gemini-b.json", FileName: "gemini-b.json", Provider: "gemini-cli", Status: coreauth.StatusActive, Metadata: map[string]any{"type": "gemini-cli", "email": "b@example.com"}},
+	} {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+	cfg := &config.Config{}
+	h := &Handler{cfg: cfg, authManager: manager}
+
+	send := func(body, signature string) (int, map[string]any) {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		if signature == "" {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write([]byte(ts + "." + body))
+			signature = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		}
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/hooks/auth-status", strings.NewReader(body))
+		c.Request.Header.Set(authStatusHookTimestampHeader, ts)
+		c.Request.Header.Set(authStatusHookSignatureHeader, signature)
+		h.AuthStatusHook(c)
+		var out map[string]any
+		_ = json.Unmarshal(rec.Body.Bytes(), &out)
+		return rec.Code, out
+	}
+
+	banned := `{"id":"evt-1","account":"acct-123","provider":"codex","status":"banned","reason":"email notice"}`
+	if code, _ := send(banned, ""); code != http.StatusNotFound {
+		t.Fatalf("hook without secret should be disabled, got %d", code)
+	}
+	cfg.RemoteManagement.AuthStatusHook.Secret = secret
+
+	if code, _ := send(banned, "sha256=00"); code != http.StatusUnauthorized {
+		t.Fatalf("bad signature should be rejected, got %d", code)
+	}
+
+	code, out := send(banned, "")
+	if code != http.StatusOK || out["matched"] != float64(1) || out["changed"] != float64(1) {
+		t.Fatalf("banned event: status %d body %v", code, out)
+	}
+	auth, _ := manager.GetByID("codex-a.json")
+	if invalid, reason := tokenInvalidState(auth); !invalid || reason != "external: email notice" {
+		t.Fatalf("auth should be invalid with external reason, got %v %q", invalid, reason)
+	}
+
+	code, out = send(banned, "")
+	results, _ := out["results"].([]any)
+	if code != http.StatusOK || len(results) != 1 || results[0].(map[string]any)["duplicate"] != true {
+		t.Fatalf("replayed event should be a duplicate: status %d body %v", code, out)
+	}
+	if entries := h.audit.list(0); len(entries) != 2 {
+		t.Fatalf("replay must not be applied twice, audit = %+v", entries)
+	}
+
+	batch := `[{"id":"evt-2","account":"b@example.com","status":"rate_limited"},{"id":"evt-3","account":"unknown@example.com","status":"banned"}]`
+	code, out = send(batch, "")
+	if code != http.StatusAccepted || out["matched"] != float64(1) {
+		t.Fatalf("batch with unknown account: status %d body %v", code, out)
+	}
+	auth, _ = manager.GetByID("gemini-b.json")
+	if !auth.Disabled || auth.Status != coreauth.StatusDisabled {
+		t.Fatalf("soft status should disable the auth: %+v", auth)
+	}
+	if invalid, _ := tokenInvalidState(auth); invalid {
+		t.Fatal("soft status must not mark the auth invalid")
+	}
+	if _, ok := h.hookDeliveries.lookup("evt-3", time.Now()); ok {
+		t.Fatal("unmatched events must stay retryable")
+	}
+
+	// Deleted auths are not matched, so their tombstones stay as they are.
+	manager.MarkRemoved(context.Background(), "gemini-b.json", "removed via management API")
+	code, out = send(`[{"account":"b@example.com","status":"banned"},{"account":"b@example.com","status":"disabled"}]`, "")
+	if code != http.StatusAccepted || out["matched"] != float64(0) {
+		t.Fatalf("events for a deleted auth: status %d body %v", code, out)
+	}
+	auth, _ = manager.GetByID("gemini-b.json")
+	if invalid, _ := tokenInvalidState(auth); invalid || !manager.Removed(auth.ID) || auth.StatusMessage != "removed via management API" {
+		t.Fatalf("tombstone changed: %+v", auth)
+	}
+}
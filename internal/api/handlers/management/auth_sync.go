@@ -0,0 +1,581 @@
+package management
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	authSyncJobHistory     = 20
+	authSyncRequestTimeout = 30 * time.Second
+	authSyncJobTimeout     = 30 * time.Minute
+	authSyncMaxFileBytes   = 1 << 20
+	authSyncModeMerge      = "merge"
+	authSyncModeMirror     = "mirror"
+)
+
+type authSyncRequest struct {
+	SourceURL     string   `json:"source_url"`
+	ManagementKey string   `json:"management_key"`
+	Providers     []string `json:"providers"`
+	Mode          string   `json:"mode"`
+	DryRun        bool     `json:"dry_run"`
+	// Protected lists local auth file names mirror mode must never delete.
+	Protected []string `json:"protected"`
+	// InsecureSkipVerify disables TLS verification of the source.
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+}
+
+// authSyncItem is the outcome for one auth file of a sync job.
+type authSyncItem struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider,omitempty"`
+	Action   string `json:"action"` // download, delete or skip
+	Reason   string `json:"reason,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// authSyncJob tracks one asynchronous sync from another instance.
+type authSyncJob struct {
+	ID         string         `json:"id"`
+	Status     string         `json:"status"` // running, succeeded or failed
+	SourceURL  string         `json:"source_url"`
+	Mode       string         `json:"mode"`
+	DryRun     bool           `json:"dry_run"`
+	Providers  []string       `json:"providers,omitempty"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	Total      int            `json:"total"`
+	Processed  int            `json:"processed"`
+	Downloaded int            `json:"downloaded"`
+	Deleted    int            `json:"deleted"`
+	Skipped    int            `json:"skipped"`
+	Failed     int            `json:"failed"`
+	Error      string         `json:"error,omitempty"`
+	Items      []authSyncItem `json:"items"`
+}
+
+// authSyncJobs keeps the most recent sync jobs in memory.
+type authSyncJobs struct {
+	mu    sync.Mutex
+	jobs  map[string]*authSyncJob
+	order []string
+}
+
+// start registers a new job unless another one is still running.
+func (s *authSyncJobs) start(job *authSyncJob) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.jobs {
+		if existing.Status == "running" {
+			return false
+		}
+	}
+	if s.jobs == nil {
+		s.jobs = make(map[string]*authSyncJob)
+	}
+	s.jobs[job.ID] = job
+	s.order = append(s.order, job.ID)
+	for len(s.order) > authSyncJobHistory {
+		delete(s.jobs, s.order[0])
+		s.order = s.order[1:]
+	}
+	return true
+}
+
+func (s *authSyncJobs) update(id string, fn func(job *authSyncJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+// get returns a copy of the job.
+func (s *authSyncJobs) get(id string) (authSyncJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return authSyncJob{}, false
+	}
+	out := *job
+	out.Items = append([]authSyncItem(nil), job.Items...)
+	return out, true
+}
+
+// list returns copies of all jobs, newest first, without their items.
+func (s *authSyncJobs) list() []authSyncJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]authSyncJob, 0, len(s.order))
+	for i := len(s.order) - 1; i >= 0; i-- {
+		job := *s.jobs[s.order[i]]
+		job.Items = nil
+		out = append(out, job)
+	}
+	return out
+}
+
+// authSyncSource is a remote instance's management API.
+type authSyncSource struct {
+	base   string
+	key    string
+	client *http.Client
+}
+
+// authSyncRemoteFile is the subset of a remote auth-files entry used for matching.
+type authSyncRemoteFile struct {
+	Name        string `json:"name"`
+	Provider    string `json:"provider"`
+	Type        string `json:"type"`
+	Email       string `json:"email"`
+	Account     string `json:"account"`
+	Source      string `json:"source"`
+	RuntimeOnly bool   `json:"runtime_only"`
+}
+
+func (f authSyncRemoteFile) provider() string {
+	if p := strings.TrimSpace(f.Provider); p != "" {
+		return strings.ToLower(p)
+	}
+	return strings.ToLower(strings.TrimSpace(f.Type))
+}
+
+func (s *authSyncSource) get(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	endpoint := s.base + "/v0/management" + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.key)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("auth sync: response body close error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, authSyncMaxFileBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: status %d: %s", path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if len(body) > authSyncMaxFileBytes {
+		return nil, fmt.Errorf("GET %s: response exceeds %d bytes", path, authSyncMaxFileBytes)
+	}
+	return body, nil
+}
+
+func (s *authSyncSource) listFiles(ctx context.Context) ([]authSyncRemoteFile, error) {
+	body, err := s.get(ctx, "/auth-files", nil)
+	if err != nil {
+		return nil, err
+	}
+	var payload struct {
+		Files []authSyncRemoteFile `json:"files"`
+	}
+	if err = json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decode auth file list: %w", err)
+	}
+	return payload.Files, nil
+}
+
+func (s *authSyncSource) download(ctx context.Context, name string) ([]byte, error) {
+	return s.get(ctx, "/auth-files/download", url.Values{"name": {name}})
+}
+
+// syncClient builds the HTTP client for the source instance. It honours the
+// configured proxy; TLS verification is only disabled on explicit request.
+func (h *Handler) syncClient(insecure bool) *http.Client {
+	transport := h.apiCallTransport(nil)
+	if insecure {
+		if t, ok := transport.(*http.Transport); ok {
+			clone := t.Clone()
+			clone.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: true}
+			transport = clone
+		}
+	}
+	return &http.Client{Timeout: authSyncRequestTimeout, Transport: transport}
+}
+
+// authSyncIdentity keys an account for duplicate detection across instances.
+// The account string is preferred because it distinguishes e.g. Gemini
+// projects that share one email.
+func authSyncIdentity(provider, email, account string) string {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if account = strings.ToLower(strings.TrimSpace(account)); account != "" {
+		return provider + "|account|" + account
+	}
+	if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+		return provider + "|email|" + email
+	}
+	return ""
+}
+
+func localAuthSyncIdentity(auth *coreauth.Auth) string {
+	_, account := auth.AccountInfo()
+	return authSyncIdentity(auth.Provider, authEmail(auth), account)
+}
+
+// validateSyncedAuthFile checks a downloaded auth file before it is written.
+func validateSyncedAuthFile(name, provider string, data []byte) error {
+	if name != filepath.Base(name) || strings.ContainsAny(name, `/\`) || !strings.HasSuffix(strings.ToLower(name), ".json") {
+		return fmt.Errorf("invalid file name")
+	}
+	var metadata map[string]any
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	fileType, _ := metadata["type"].(string)
+	fileType = strings.ToLower(strings.TrimSpace(fileType))
+	if fileType == "" {
+		return fmt.Errorf("missing type")
+	}
+	if provider != "" && fileType != provider {
+		return fmt.Errorf("type %q does not match listed provider %q", fileType, provider)
+	}
+	return nil
+}
+
+// SyncAuthFiles starts copying auth files from another instance. The job
+// runs in the background; poll GetAuthSyncJob for progress.
+func (h *Handler) SyncAuthFiles(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	var req authSyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	source, err := url.Parse(strings.TrimSpace(req.SourceURL))
+	if err != nil || (source.Scheme != "http" && source.Scheme != "https") || source.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "source_url must be an absolute http or https URL"})
+		return
+	}
+	if strings.TrimSpace(req.ManagementKey) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "management_key is required"})
+		return
+	}
+	mode := strings.ToLower(strings.TrimSpace(req.Mode))
+	if mode == "" {
+		mode = authSyncModeMerge
+	}
+	if mode != authSyncModeMerge && mode != authSyncModeMirror {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be merge or mirror"})
+		return
+	}
+	providers := make([]string, 0, len(req.Providers))
+	for _, p := range req.Providers {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			providers = append(providers, p)
+		}
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create job: %v", err)})
+		return
+	}
+
+	source.Path = strings.TrimSuffix(source.Path, "/")
+	source.RawQuery, source.Fragment = "", ""
+	job := &authSyncJob{
+		ID:        id,
+		Status:    "running",
+		SourceURL: source.String(),
+		Mode:      mode,
+		DryRun:    req.DryRun,
+		Providers: providers,
+		StartedAt: time.Now().UTC(),
+		Items:     []authSyncItem{},
+	}
+	if !h.authSyncJobs.start(job) {
+		c.JSON(http.StatusConflict, gin.H{"error": "an auth sync job is already running"})
+		return
+	}
+
+	principal, _ := principalFromContext(c)
+	audit := auditEntry{
+		Actor:      principal.Name,
+		Role:       string(principal.Role),
+		Source:     principal.Source,
+		ClientIP:   h.managementClientIP(c),
+		ClientCert: principal.ClientCert,
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		Action:     "auth_files_sync",
+	}
+	src := &authSyncSource{base: job.SourceURL, key: strings.TrimSpace(req.ManagementKey), client: h.syncClient(req.InsecureSkipVerify)}
+	protected := make(map[string]struct{}, len(req.Protected))
+	for _, name := range req.Protected {
+		if name = strings.TrimSpace(name); name != "" {
+			protected[strings.ToLower(filepath.Base(name))] = struct{}{}
+		}
+	}
+	if !h.life.goWorker(func() { h.runAuthSync(id, src, mode, req.DryRun, providers, protected, audit) }) {
+		h.authSyncJobs.update(id, func(job *authSyncJob) {
+			now := time.Now().UTC()
+			job.FinishedAt = &now
+			job.Status = "failed"
+			job.Error = "server is shutting down"
+		})
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "server is shutting down"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "accepted", "job_id": id})
+}
+
+// GetAuthSyncJob returns the progress and results of a sync job.
+func (h *Handler) GetAuthSyncJob(c *gin.Context) {
+	job, ok := h.authSyncJobs.get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sync job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// ListAuthSyncJobs returns the recent sync jobs without per-file results.
+func (h *Handler) ListAuthSyncJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"jobs": h.authSyncJobs.list()})
+}
+
+func (h *Handler) runAuthSync(id string, src *authSyncSource, mode string, dryRun bool, providers []string, protected map[string]struct{}, audit auditEntry) {
+	ctx, cancel := context.WithTimeout(h.life.context(), authSyncJobTimeout)
+	defer cancel()
+
+	err := h.syncAuthFiles(ctx, id, src, mode, dryRun, providers, protected)
+
+	var summary string
+	h.authSyncJobs.update(id, func(job *authSyncJob) {
+		now := time.Now().UTC()
+		job.FinishedAt = &now
+		job.Status = "succeeded"
+		if err != nil {
+			job.Status = "failed"
+			job.Error = err.Error()
+		}
+		summary = fmt.Sprintf("job=%s source=%s mode=%s dry_run=%t status=%s downloaded=%d deleted=%d skipped=%d failed=%d",
+			job.ID, job.SourceURL, job.Mode, job.DryRun, job.Status, job.Downloaded, job.Deleted, job.Skipped, job.Failed)
+	})
+	audit.Status = http.StatusOK
+	if err != nil {
+		audit.Status = http.StatusBadGateway
+	}
+	audit.Detail = summary
+	h.recordAudit(audit)
+}
+
+func (h *Handler) syncAuthFiles(ctx context.Context, id string, src *authSyncSource, mode string, dryRun bool, providers []string, protected map[string]struct{}) error {
+	wanted := func(provider string) bool {
+		if len(providers) == 0 {
+			return true
+		}
+		for _, p := range providers {
+			if p == provider {
+				return true
+			}
+		}
+		return false
+	}
+	record := func(item authSyncItem) {
+		h.authSyncJobs.update(id, func(job *authSyncJob) {
+			job.Processed++
+			job.Items = append(job.Items, item)
+			switch {
+			case item.Error != "":
+				job.Failed++
+			case item.Action == "download":
+				job.Downloaded++
+			case item.Action == "delete":
+				job.Deleted++
+			default:
+				job.Skipped++
+			}
+		})
+	}
+
+	remoteFiles, err := src.listFiles(ctx)
+	if err != nil {
+		return fmt.Errorf("list source auth files: %w", err)
+	}
+	remote := make([]authSyncRemoteFile, 0, len(remoteFiles))
+	for _, f := range remoteFiles {
+		// Only file-backed credentials can be downloaded from the source.
+		if f.RuntimeOnly || f.Source == "memory" || !wanted(f.provider()) {
+			continue
+		}
+		remote = append(remote, f)
+	}
+	sort.Slice(remote, func(i, j int) bool { return remote[i].Name < remote[j].Name })
+
+	localNames := make(map[string]struct{})
+	localIdentities := make(map[string]struct{})
+	var local []*coreauth.Auth
+	for _, auth := range h.authManager.List() {
+		if auth == nil || isRuntimeOnlyAuth(auth) || auth.Disabled {
+			continue
+		}
+		if _, ok := h.resolveAuthFilePath(auth); !ok {
+			continue
+		}
+		localNames[strings.ToLower(filepath.Base(auth.FileName))] = struct{}{}
+		if key := localAuthSyncIdentity(auth); key != "" {
+			localIdentities[key] = struct{}{}
+		}
+		if wanted(strings.ToLower(strings.TrimSpace(auth.Provider))) {
+			local = append(local, auth)
+		}
+	}
+
+	h.authSyncJobs.update(id, func(job *authSyncJob) { job.Total = len(remote) })
+
+	remoteNames := make(map[string]struct{}, len(remote))
+	remoteIdentities := make(map[string]struct{}, len(remote))
+	for _, f := range remote {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		name := filepath.Base(strings.TrimSpace(f.Name))
+		provider := f.provider()
+		identity := authSyncIdentity(provider, f.Email, f.Account)
+		remoteNames[strings.ToLower(name)] = struct{}{}
+		if identity != "" {
+			remoteIdentities[identity] = struct{}{}
+		}
+
+		item := authSyncItem{Name: name, Provider: provider, Action: "skip"}
+		if _, exists := localNames[strings.ToLower(name)]; exists {
+			item.Reason = "file already present"
+			record(item)
+			continue
+		}
+		if _, exists := localIdentities[identity]; identity != "" && exists {
+			item.Reason = "account already present"
+			record(item)
+			continue
+		}
+
+		data, errDownload := src.download(ctx, name)
+		if errDownload == nil {
+			errDownload = validateSyncedAuthFile(name, provider, data)
+		}
+		if errDownload != nil {
+			item.Error = errDownload.Error()
+			record(item)
+			continue
+		}
+		item.Action = "download"
+		if !dryRun {
+			if errWrite := h.writeSyncedAuthFile(ctx, name, data); errWrite != nil {
+				item.Error = errWrite.Error()
+				record(item)
+				continue
+			}
+		}
+		localNames[strings.ToLower(name)] = struct{}{}
+		if identity != "" {
+			localIdentities[identity] = struct{}{}
+		}
+		record(item)
+	}
+
+	if mode != authSyncModeMirror {
+		return nil
+	}
+	if len(remote) == 0 {
+		return fmt.Errorf("source lists no matching auth files; refusing to mirror")
+	}
+	if job, _ := h.authSyncJobs.get(id); job.Failed > 0 {
+		return fmt.Errorf("%d downloads failed; skipping mirror deletions", job.Failed)
+	}
+
+	for _, auth := range local {
+		name := filepath.Base(auth.FileName)
+		identity := localAuthSyncIdentity(auth)
+		if _, ok := remoteNames[strings.ToLower(name)]; ok {
+			continue
+		}
+		if _, ok := remoteIdentities[identity]; identity != "" && ok {
+			continue
+		}
+		h.authSyncJobs.update(id, func(job *authSyncJob) { job.Total++ })
+		item := authSyncItem{Name: name, Provider: strings.ToLower(strings.TrimSpace(auth.Provider)), Action: "skip"}
+		if _, ok := protected[strings.ToLower(name)]; ok {
+			item.Reason = "protected"
+			record(item)
+			continue
+		}
+		if coreauth.IsFrozen(auth) {
+			item.Reason = "frozen"
+			record(item)
+			continue
+		}
+		if coreauth.IsAdminDisabled(auth) {
+			item.Reason = "disabled_by_admin"
+			record(item)
+			continue
+		}
+		item.Action = "delete"
+		if !dryRun {
+			if errDelete := h.removeSyncedAuthFile(ctx, auth); errDelete != nil {
+				item.Error = errDelete.Error()
+			}
+		}
+		record(item)
+	}
+	return nil
+}
+
+func (h *Handler) writeSyncedAuthFile(ctx context.Context, name string, data []byte) error {
+	dst := filepath.Join(h.cfg.AuthDir, name)
+	if !filepath.IsAbs(dst) {
+		if abs, errAbs := filepath.Abs(dst); errAbs == nil {
+			dst = abs
+		}
+	}
+	if err := os.WriteFile(dst, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return h.registerAuthFromFile(ctx, dst, data)
+}
+
+func (h *Handler) removeSyncedAuthFile(ctx context.Context, auth *coreauth.Auth) error {
+	path, ok := h.resolveAuthFilePath(auth)
+	if !ok {
+		return fmt.Errorf("auth file path unavailable")
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove file: %w", err)
+	}
+	if err := h.deleteTokenRecord(ctx, path); err != nil {
+		return err
+	}
+	h.disableAuth(ctx, path)
+	return nil
+}
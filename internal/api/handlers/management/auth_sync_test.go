@@ -0,0 +1,153 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestSyncAuthFiles_MirrorFromSource(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	remoteFiles := map[string]string{
+		"codex-new.json":     `{"type":"codex","email":"new@example.com"}`,
+		"codex-renamed.json": `{"type":"codex","email":"dup@example.com"}`,
+		"claude-other.json":  `{"type":"claude","email":"other@example.com"}`,
+	}
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer source-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v0/management/auth-files":
+			_, _ = w.Write([]byte(`{"files":[
+				{"name":"codex-new.json","provider":"codex","email":"new@example.com","account_type":"oauth","account":"new@example.com","source":"file"},
+				{"name":"codex-renamed.json","provider":"codex","email":"dup@example.com","account_type":"oauth","account":"dup@example.com","source":"file"},
+				{"name":"claude-other.json","provider":"claude","email":"other@example.com","account_type":"oauth","account":"other@example.com","source":"file"}
+			]}`))
+		case "/v0/management/auth-files/download":
+			data, ok := remoteFiles[r.URL.Query().Get("name")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(data))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer source.Close()
+
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager, tokenStore: &memoryAuthStore{}}
+	for name, content := range map[string]string{
+		"codex-dup.json":       `{"type":"codex","email":"dup@example.com"}`,
+		"codex-stale.json":     `{"type":"codex","email":"stale@example.com"}`,
+		"codex-protected.json": `{"type":"codex","email":"keep@example.com"}`,
+	} {
+		path := filepath.Join(authDir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write local auth: %v", err)
+		}
+		if err := h.registerAuthFromFile(context.Background(), path, []byte(content)); err != nil {
+			t.Fatalf("register local auth: %v", err)
+		}
+	}
+
+	start := func(body string) string {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/sync", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.SyncAuthFiles(c)
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("start sync: status %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			JobID string `json:"job_id"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.JobID == "" {
+			t.Fatalf("decode start response: %v %s", err, rec.Body.String())
+		}
+		return resp.JobID
+	}
+	wait := func(id string) authSyncJob {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if job, ok := h.authSyncJobs.get(id); ok && job.Status != "running" {
+				return job
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("sync job did not finish")
+		return authSyncJob{}
+	}
+	request := `{"source_url":"` + source.URL + `/","management_key":"source-key","providers":["codex"],"mode":"mirror","protected":["codex-protected.json"]%s}`
+
+	job := wait(start(strings.Replace(request, "%s", `,"dry_run":true`, 1)))
+	if job.Status != "succeeded" || job.Downloaded != 1 || job.Deleted != 1 {
+		t.Fatalf("unexpected dry run result: %+v", job)
+	}
+	if _, err := os.Stat(filepath.Join(authDir, "codex-new.json")); !os.IsNotExist(err) {
+		t.Fatalf("dry run must not write files, stat err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(authDir, "codex-stale.json")); err != nil {
+		t.Fatalf("dry run must not delete files: %v", err)
+	}
+
+	job = wait(start(strings.Replace(request, "%s", "", 1)))
+	if job.Status != "succeeded" || job.Downloaded != 1 || job.Deleted != 1 || job.Skipped != 2 || job.Failed != 0 {
+		t.Fatalf("unexpected sync result: %+v", job)
+	}
+	if _, err := os.Stat(filepath.Join(authDir, "codex-new.json")); err != nil {
+		t.Fatalf("new auth file should be written: %v", err)
+	}
+	if _, ok := manager.GetByID("codex-new.json"); !ok {
+		t.Fatal("new auth file should be registered")
+	}
+	for name, want := range map[string]bool{
+		"codex-renamed.json":   false, // same account as codex-dup.json
+		"claude-other.json":    false, // provider not requested
+		"codex-stale.json":     false, // absent from the source
+		"codex-dup.json":       true,
+		"codex-protected.json": true,
+	} {
+		_, err := os.Stat(filepath.Join(authDir, name))
+		if exists := err == nil; exists != want {
+			t.Fatalf("%s: exists = %v, want %v", name, exists, want)
+		}
+	}
+	if entries := h.audit.list(0); len(entries) != 2 || entries[0].Action != "auth_files_sync" {
+		t.Fatalf("sync should be audited, got %+v", entries)
+	}
+}
+
+func TestValidateSyncedAuthFile(t *testing.T) {
+	cases := []struct {
+		name, provider, data string
+		ok                   bool
+	}{
+		{"codex.json", "codex", `{"type":"codex"}`, true},
+		{"../codex.json", "codex", `{"type":"codex"}`, false},
+		{"codex.txt", "codex", `{"type":"codex"}`, false},
+		{"codex.json", "codex", `not json`, false},
+		{"codex.json", "codex", `{"email":"a@b"}`, false},
+		{"codex.json", "claude", `{"type":"codex"}`, false},
+	}
+	for _, tc := range cases {
+		if err := validateSyncedAuthFile(tc.name, tc.provider, []byte(tc.data)); (err == nil) != tc.ok {
+			t.Fatalf("validate(%q, %q, %q) err = %v, want ok %v", tc.name, tc.provider, tc.data, err, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package management
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// authTagsKey is the metadata key, and auth file field, holding the tags.
+	authTagsKey = "tags"
+	// maxAuthTags bounds the number of tags on one auth file.
+	maxAuthTags = 16
+	// maxAuthTagLength bounds the length of one tag, in bytes.
+	maxAuthTagLength = 32
+)
+
+// normalizeAuthTags trims and lowercases tags, dropping empty and repeated
+// ones. A tag holds letters, digits and "-_.:". It returns the valid tags, at
+// most maxAuthTags, along with the first problem found, so callers reading
+// stored tags may ignore the error and callers taking new ones reject them.
+func normalizeAuthTags(tags []string) ([]string, error) {
+	var out []string
+	var errFirst error
+	fail := func(err error) {
+		if errFirst == nil {
+			errFirst = err
+		}
+	}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		switch {
+		case tag == "" || slices.Contains(out, tag):
+			continue
+		case len(tag) > maxAuthTagLength:
+			fail(fmt.Errorf("tag %q is longer than %d characters", tag, maxAuthTagLength))
+			continue
+		case strings.IndexFunc(tag, invalidAuthTagRune) >= 0:
+			fail(fmt.Errorf("tag %q may only hold letters, digits and -_.:", tag))
+			continue
+		case len(out) == maxAuthTags:
+			fail(fmt.Errorf("at most %d tags are allowed", maxAuthTags))
+			continue
+		}
+		out = append(out, tag)
+	}
+	return out, errFirst
+}
+
+func invalidAuthTagRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.:", r)
+}
+
+// authTags returns the tags stored in auth's metadata. Malformed entries, as
+// a hand-edited file may carry, are skipped.
+func authTags(auth *coreauth.Auth) []string {
+	if auth == nil {
+		return nil
+	}
+	var raw []string
+	switch value := auth.Metadata[authTagsKey].(type) {
+	case []string:
+		raw = value
+	case []any:
+		for _, item := range value {
+			if tag, ok := item.(string); ok {
+				raw = append(raw, tag)
+			}
+		}
+	}
+	tags, _ := normalizeAuthTags(raw)
+	return tags
+}
+
+// authHasAnyTag reports whether auth carries one of tags. Every auth does
+// when tags is empty.
+func authHasAnyTag(auth *coreauth.Auth, tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, tag := range authTags(auth) {
+		if slices.Contains(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// tagsOrEmpty returns tags, or an empty list in place of nil.
+func tagsOrEmpty(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// taggedAuthFilter narrows filter to the auths carrying one of tags.
+func taggedAuthFilter(filter func(*coreauth.Auth) bool, tags []string) func(*coreauth.Auth) bool {
+	if len(tags) == 0 {
+		return filter
+	}
+	return func(auth *coreauth.Auth) bool {
+		return authHasAnyTag(auth, tags) && (filter == nil || filter(auth))
+	}
+}
+
+// queryTags reads the tag selector of a request: tag, repeated or comma
+// separated.
+func queryTags(c *gin.Context) ([]string, error) {
+	return normalizeAuthTags(queryList(c.QueryArray("tag")))
+}
+
+// uploadTags reads the tags to set on uploaded auth files: the tags query
+// parameter or, for multipart uploads, form field, repeated or comma
+// separated.
+func uploadTags(c *gin.Context) ([]string, error) {
+	raw := c.QueryArray("tags")
+	if form := c.Request.MultipartForm; form != nil {
+		raw = append(raw, form.Value["tags"]...)
+	}
+	return normalizeAuthTags(queryList(raw))
+}
+
+// tagAuthData sets the tags of an auth file's JSON, replacing those it
+// carries. Without tags data is returned unchanged.
+func tagAuthData(data []byte, tags []string) ([]byte, error) {
+	if len(tags) == 0 {
+		return data, nil
+	}
+	tagged, err := sjson.SetBytes(data, authTagsKey, tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tags: %w", err)
+	}
+	return tagged, nil
+}
@@ -0,0 +1,172 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestNormalizeAuthTags(t *testing.T) {
+	tags, err := normalizeAuthTags([]string{" Research ", "", "support", "research"})
+	if err != nil || strings.Join(tags, ",") != "research,support" {
+		t.Fatalf("tags = %v, err = %v", tags, err)
+	}
+	for _, bad := range [][]string{
+		{"two words"},
+		{"a,b"},
+		{strings.Repeat("x", maxAuthTagLength+1)},
+		strings.Split("a b c d e f g h i j k l m n o p q", " "),
+	} {
+		if _, err = normalizeAuthTags(bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func TestAuthFileTags_UploadListPatchAndDelete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	store := sdkAuth.NewFileTokenStore()
+	store.SetBaseDir(authDir)
+	manager := coreauth.NewManager(store, nil, nil)
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager}
+	call := func(handler gin.HandlerFunc, method, target, body string, params ...gin.Param) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Params = params
+		handler(c)
+		return rec
+	}
+	upload := func(name, tags string) *httptest.ResponseRecorder {
+		return call(h.UploadAuthFile, http.MethodPost, "/v0/management/auth-files?verify=false&name="+name+"&tags="+tags, `{"type":"codex","access_token":"at","refresh_token":"rt","account_id":"acct","email":"`+name+`@example.com"}`)
+	}
+
+	if rec := upload("bad.json", "two%20words"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad tag upload: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	for name, tags := range map[string]string{"a.json": "Research,support", "b.json": "support", "c.json": ""} {
+		if rec := upload(name, tags); rec.Code != http.StatusOK {
+			t.Fatalf("upload %s: status %d body=%s", name, rec.Code, rec.Body.String())
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(authDir, "a.json")); !strings.Contains(string(data), `"tags":["research","support"]`) {
+		t.Fatalf("uploaded file = %s", data)
+	}
+
+	list := func(query string) []string {
+		t.Helper()
+		rec := call(h.ListAuthFiles, http.MethodGet, "/v0/management/auth-files?"+query, "")
+		var page authFilesPage
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("list %s: status %d body=%s", query, rec.Code, rec.Body.String())
+		}
+		var names []string
+		for _, file := range page.Files {
+			names = append(names, file["name"].(string))
+		}
+		return names
+	}
+	if got := strings.Join(list("tag=RESEARCH"), ","); got != "a.json" {
+		t.Fatalf("tag=research lists %s", got)
+	}
+	if got := strings.Join(list("tag=support"), ","); got != "a.json,b.json" {
+		t.Fatalf("tag=support lists %s", got)
+	}
+	if got := strings.Join(list("tag=research,nobody"), ","); got != "a.json" {
+		t.Fatalf("tag=research,nobody lists %s", got)
+	}
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		return call(h.PatchAuthFile, http.MethodPatch, "/v0/management/auth-files/a.json", body, gin.Param{Key: "id", Value: "a.json"})
+	}
+	if rec := patch(`{"tags":["ok","no way"]}`); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "tags are invalid") {
+		t.Fatalf("bad tag patch: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := patch(`{"tags":[]}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"tags":[]`) {
+		t.Fatalf("clear tags: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(authDir, "a.json")); strings.Contains(string(data), `"tags"`) {
+		t.Fatalf("cleared tags still persisted: %s", data)
+	}
+
+	for _, id := range []string{"b.json", "c.json"} {
+		if _, err := manager.MarkInvalid(context.Background(), id, "401 revoked"); err != nil {
+			t.Fatalf("mark invalid: %v", err)
+		}
+	}
+	if rec := call(h.DeleteAuthFile, http.MethodDelete, "/v0/management/auth-files?tag=support&name=c.json", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("tag with name: status %d", rec.Code)
+	}
+	rec := call(h.DeleteAuthFile, http.MethodDelete, "/v0/management/auth-files?tag=support&invalid=true", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"deleted":1`) {
+		t.Fatalf("delete tagged invalid: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(authDir, "b.json")); !os.IsNotExist(err) {
+		t.Fatalf("tagged invalid file kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(authDir, "c.json")); err != nil {
+		t.Fatalf("untagged invalid file removed: %v", err)
+	}
+}
+
+func TestAuthFileTags_SelectVerifyAndInspection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, authDir, "codex", 4)
+	for id, tags := range map[string][]string{"codex-00.json": {"support"}, "codex-01.json": {"support", "research"}, "codex-02.json": {"research"}} {
+		if _, err := manager.Edit(context.Background(), id, func(auth *coreauth.Auth) error {
+			auth.Metadata = map[string]any{"type": "codex", authTagsKey: tags}
+			return nil
+		}); err != nil {
+			t.Fatalf("tag %s: %v", id, err)
+		}
+	}
+	var mu sync.Mutex
+	var probed []string
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		mu.Lock()
+		probed = append(probed, auth.ID)
+		mu.Unlock()
+		return false, "", nil
+	}))
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager}
+	h.SetInspector(inspector)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex&tag=support&force=true", nil)
+	h.VerifyInvalidAuthFiles(c)
+	sort.Strings(probed)
+	if rec.Code != http.StatusOK || strings.Join(probed, ",") != "codex-00.json,codex-01.json" {
+		t.Fatalf("verify tag=support: status %d probed %v body=%s", rec.Code, probed, rec.Body.String())
+	}
+
+	cfg := config.AuthInspectionConfig{IncludeTags: []string{"Research", "support"}, ExcludeTags: []string{" support "}}
+	filter := inspectionFilter(cfg)
+	var kept []string
+	for _, id := range []string{"codex-00.json", "codex-01.json", "codex-02.json", "codex-03.json"} {
+		if auth, _ := manager.GetByID(id); filter(auth) {
+			kept = append(kept, id)
+		}
+	}
+	if strings.Join(kept, ",") != "codex-02.json" {
+		t.Fatalf("inspection filter keeps %v", kept)
+	}
+}
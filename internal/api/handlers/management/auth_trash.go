@@ -0,0 +1,324 @@
+package management
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// authTrashDirName is the directory of AuthDir deleted auth files are
+	// moved to. Being hidden, it is skipped by the token stores and the
+	// watcher, see util.IsHiddenAuthSubdir, so trashed files are never loaded
+	// as auths.
+	authTrashDirName = ".trash"
+	// trashRecordsDirName is the directory of the trash holding, under the
+	// same name, the record of each trashed auth file: where it came from and
+	// which deletion removed it. Being a directory, it cannot be mistaken for
+	// a trashed file, whatever the file is called.
+	trashRecordsDirName = ".records"
+	// defaultAuthTrashRetentionDays applies when auth-trash-retention-days is
+	// unset.
+	defaultAuthTrashRetentionDays = 30
+)
+
+// trashRecord is the content of a trash record file.
+type trashRecord struct {
+	OriginalName string    `json:"original_name"`
+	ID           string    `json:"id,omitempty"`
+	Provider     string    `json:"provider,omitempty"`
+	Filter       string    `json:"filter"`
+	DeletedAt    time.Time `json:"deleted_at"`
+}
+
+// trashDir returns the absolute trash directory, or "" without an auth dir.
+func (h *Handler) trashDir() string {
+	if h == nil || h.cfg == nil || strings.TrimSpace(h.cfg.AuthDir) == "" {
+		return ""
+	}
+	dir, err := filepath.Abs(filepath.Join(h.cfg.AuthDir, authTrashDirName))
+	if err != nil {
+		return ""
+	}
+	return dir
+}
+
+// trashRecordPath returns the record of the trashed file name of dir.
+func trashRecordPath(dir, name string) string {
+	return filepath.Join(dir, trashRecordsDirName, name)
+}
+
+// purgeTrash removes the expired trashed files. Deletions call it once per
+// request or run rather than once per trashed file.
+func (h *Handler) purgeTrash() {
+	if dir := h.trashDir(); dir != "" {
+		h.purgeExpiredTrash(dir, time.Now().UTC())
+	}
+}
+
+// trashRetention returns how long trashed files are kept, or 0 to keep them.
+func (h *Handler) trashRetention() time.Duration {
+	days := defaultAuthTrashRetentionDays
+	if h != nil && h.cfg != nil && h.cfg.AuthTrashRetentionDays != 0 {
+		days = h.cfg.AuthTrashRetentionDays
+	}
+	if days < 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// deleteFilterLabel describes the deletion that removed a file, e.g.
+// "failed provider=codex tag=support", for the trash record.
+func deleteFilterLabel(scope string, providers, tags []string) string {
+	label := scope
+	if len(providers) > 0 {
+		label += " provider=" + strings.Join(providers, ",")
+	}
+	if len(tags) > 0 {
+		label += " tag=" + strings.Join(tags, ",")
+	}
+	return label
+}
+
+// discardAuthFile removes the auth file at path on behalf of the deletion
+// described by filter: it is moved to the trash, or unlinked with purge. auth
+// may be nil when the file is not registered. A missing file is reported as
+// an error satisfying os.IsNotExist.
+func (h *Handler) discardAuthFile(auth *coreauth.Auth, path, filter string, purge bool) error {
+	if purge {
+		return os.Remove(path)
+	}
+	if auth == nil && h.authManager != nil {
+		auth, _ = h.authManager.GetByID(h.authIDForPath(path))
+	}
+	return h.trashAuthFile(auth, path, filter)
+}
+
+// trashAuthFile moves the auth file at path into the trash and writes its
+// record.
+func (h *Handler) trashAuthFile(auth *coreauth.Auth, path, filter string) error {
+	dir := h.trashDir()
+	if dir == "" {
+		return fmt.Errorf("auth dir is not configured")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, trashRecordsDirName), 0o700); err != nil {
+		return fmt.Errorf("failed to create trash dir: %w", err)
+	}
+	now := time.Now().UTC()
+	base := filepath.Base(path)
+	dst := filepath.Join(dir, base)
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(dst); os.IsNotExist(err) {
+			break
+		}
+		dst = filepath.Join(dir, fmt.Sprintf("%s-%s-%d%s", strings.TrimSuffix(base, filepath.Ext(base)), now.Format("20060102T150405"), i, filepath.Ext(base)))
+	}
+	if err := moveFile(path, dst); err != nil {
+		return err
+	}
+	record := trashRecord{OriginalName: base, Filter: filter, DeletedAt: now}
+	if auth != nil {
+		record.ID = auth.ID
+		record.Provider = auth.Provider
+	}
+	raw, err := json.MarshalIndent(record, "", "  ")
+	if err == nil {
+		err = os.WriteFile(trashRecordPath(dir, filepath.Base(dst)), raw, 0o600)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write trash record: %w", err)
+	}
+	return nil
+}
+
+// trashEntry is a trashed auth file and its record.
+type trashEntry struct {
+	name   string
+	size   int64
+	record trashRecord
+}
+
+// readTrash lists the trashed auth files of dir. A file without a readable
+// record is dated by its modification time.
+func readTrash(dir string) ([]trashEntry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entries []trashEntry
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !isTrashedAuthName(name) {
+			continue
+		}
+		info, errInfo := file.Info()
+		if errInfo != nil {
+			continue
+		}
+		entry := trashEntry{name: name, size: info.Size()}
+		if raw, errRecord := os.ReadFile(trashRecordPath(dir, name)); errRecord != nil || json.Unmarshal(raw, &entry.record) != nil {
+			entry.record = trashRecord{OriginalName: name}
+		}
+		if entry.record.DeletedAt.IsZero() {
+			entry.record.DeletedAt = info.ModTime().UTC()
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// isTrashedAuthName reports whether name can be a trashed auth file.
+func isTrashedAuthName(name string) bool {
+	return name == filepath.Base(name) && strings.HasSuffix(strings.ToLower(name), ".json")
+}
+
+// purgeExpiredTrash removes the trashed files of dir older than the
+// retention, with their records.
+func (h *Handler) purgeExpiredTrash(dir string, now time.Time) {
+	retention := h.trashRetention()
+	if retention <= 0 {
+		return
+	}
+	entries, err := readTrash(dir)
+	if err != nil {
+		log.Warnf("auth trash: failed to read %s: %v", dir, err)
+		return
+	}
+	for _, entry := range entries {
+		if now.Sub(entry.record.DeletedAt) <= retention {
+			continue
+		}
+		path := filepath.Join(dir, entry.name)
+		if errRemove := os.Remove(path); errRemove != nil && !os.IsNotExist(errRemove) {
+			log.Warnf("auth trash: failed to purge %s: %v", path, errRemove)
+			continue
+		}
+		_ = os.Remove(trashRecordPath(dir, entry.name))
+		log.Infof("auth trash: purged %s, deleted %s", entry.name, entry.record.DeletedAt.Format(time.RFC3339))
+	}
+}
+
+// ListAuthTrash lists the trashed auth files, most recently deleted first,
+// purging the expired ones first.
+func (h *Handler) ListAuthTrash(c *gin.Context) {
+	dir := h.trashDir()
+	if dir == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "auth dir is not configured"})
+		return
+	}
+	h.purgeExpiredTrash(dir, time.Now().UTC())
+	entries, err := readTrash(dir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read trash: %v", err)})
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].record.DeletedAt.Equal(entries[j].record.DeletedAt) {
+			return entries[i].record.DeletedAt.After(entries[j].record.DeletedAt)
+		}
+		return entries[i].name < entries[j].name
+	})
+	retention := h.trashRetention()
+	files := make([]gin.H, 0, len(entries))
+	for _, entry := range entries {
+		file := gin.H{
+			"name":          entry.name,
+			"original_name": entry.record.OriginalName,
+			"filter":        entry.record.Filter,
+			"deleted_at":    entry.record.DeletedAt,
+			"size":          entry.size,
+		}
+		if entry.record.ID != "" {
+			file["id"] = entry.record.ID
+		}
+		if entry.record.Provider != "" {
+			file["provider"] = entry.record.Provider
+		}
+		if retention > 0 {
+			file["expires_at"] = entry.record.DeletedAt.Add(retention)
+		}
+		files = append(files, file)
+	}
+	c.JSON(http.StatusOK, gin.H{"files": files, "retention_days": int(retention / (24 * time.Hour))})
+}
+
+// RestoreTrashedAuthFile moves the trashed auth file :name back into the auth
+// dir under its original name and registers it again with its state as it
+// was deleted.
+func (h *Handler) RestoreTrashedAuthFile(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	name := strings.TrimSpace(c.Param("name"))
+	dir := h.trashDir()
+	if !isTrashedAuthName(name) || strings.HasPrefix(name, ".") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid name"})
+		return
+	}
+	if dir == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "auth dir is not configured"})
+		return
+	}
+	src := filepath.Join(dir, name)
+	data, err := os.ReadFile(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "trashed file not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read trashed file: %v", err)})
+		}
+		return
+	}
+	original := name
+	var record trashRecord
+	if raw, errRecord := os.ReadFile(trashRecordPath(dir, name)); errRecord == nil && json.Unmarshal(raw, &record) == nil {
+		if candidate := strings.TrimSpace(record.OriginalName); isTrashedAuthName(candidate) && !strings.HasPrefix(candidate, ".") {
+			original = candidate
+		}
+	}
+	dst := filepath.Join(filepath.Dir(dir), original)
+	if _, errStat := os.Lstat(dst); errStat == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": original + " already exists in the auth dir"})
+		return
+	}
+	if existing, ok := h.authManager.GetByID(h.authIDForPath(dst)); ok && !h.authManager.Removed(existing.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": original + " is already registered"})
+		return
+	}
+	auth, err := h.authFromFile(dst, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err = moveFile(src, dst); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to restore file: %v", err)})
+		return
+	}
+	_ = os.Remove(trashRecordPath(dir, name))
+	registered, err := h.authManager.RegisterOrUpdate(c.Request.Context(), auth)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to register auth: %v", err)})
+		return
+	}
+	log.Infof("auth trash: restored %s as %s", name, original)
+	payload := h.authRecordPayload(registered)
+	payload["restored_from"] = name
+	c.JSON(http.StatusOK, payload)
+}
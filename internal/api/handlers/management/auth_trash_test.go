@@ -0,0 +1,186 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAuthTrash_DeleteListRestoreAndPurge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	trash := filepath.Join(authDir, authTrashDirName)
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	paths := registerInspectionFixtures(t, manager, authDir, "codex", 3)
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager, tokenStore: store}
+	call := func(handler gin.HandlerFunc, method, target string, params ...gin.Param) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(method, target, nil)
+		c.Params = params
+		handler(c)
+		return rec
+	}
+
+	if _, err := manager.MarkInvalid(context.Background(), "codex-00.json", "401 revoked"); err != nil {
+		t.Fatalf("mark invalid: %v", err)
+	}
+	if rec := call(h.DeleteAuthFile, http.MethodDelete, "/v0/management/auth-files?invalid=true&provider=codex"); rec.Code != http.StatusOK {
+		t.Fatalf("delete invalid: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := call(h.DeleteAuthFile, http.MethodDelete, "/v0/management/auth-files?name=codex-01.json"); rec.Code != http.StatusOK {
+		t.Fatalf("delete by name: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := call(h.DeleteAuthFile, http.MethodDelete, "/v0/management/auth-files?name=codex-02.json&purge=true"); rec.Code != http.StatusOK {
+		t.Fatalf("purge by name: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("%s kept in the auth dir: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(trash, "codex-02.json")); !os.IsNotExist(err) {
+		t.Fatalf("purged file trashed: %v", err)
+	}
+
+	rec := call(h.ListAuthTrash, http.MethodGet, "/v0/management/auth-files/trash")
+	var listed struct {
+		Files []struct {
+			Name         string `json:"name"`
+			OriginalName string `json:"original_name"`
+			Provider     string `json:"provider"`
+			Filter       string `json:"filter"`
+		} `json:"files"`
+		RetentionDays int `json:"retention_days"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("list: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	filters := map[string]string{}
+	for _, file := range listed.Files {
+		filters[file.Name] = file.Filter
+		if file.OriginalName != file.Name || file.Provider != "codex" {
+			t.Errorf("entry = %+v", file)
+		}
+	}
+	if len(listed.Files) != 2 || filters["codex-00.json"] != "invalid provider=codex" || filters["codex-01.json"] != "name" || listed.RetentionDays != defaultAuthTrashRetentionDays {
+		t.Fatalf("trash lists %s", rec.Body.String())
+	}
+
+	restore := func(name string) *httptest.ResponseRecorder {
+		return call(h.RestoreTrashedAuthFile, http.MethodPost, "/v0/management/auth-files/trash/"+name+"/restore", gin.Param{Key: "name", Value: name})
+	}
+	if rec = restore("..%2Fsecret.json"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad name: status %d", rec.Code)
+	}
+	if rec = restore("missing.json"); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing: status %d", rec.Code)
+	}
+	if rec = restore("codex-01.json"); rec.Code != http.StatusOK {
+		t.Fatalf("restore: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if _, err := os.Stat(paths[1]); err != nil {
+		t.Fatalf("restored file missing: %v", err)
+	}
+	if _, err := os.Stat(trashRecordPath(trash, "codex-01.json")); !os.IsNotExist(err) {
+		t.Fatalf("trash record kept: %v", err)
+	}
+	if auth, ok := manager.GetByID("codex-01.json"); !ok || manager.Removed(auth.ID) {
+		t.Fatalf("restored auth not registered")
+	}
+
+	// A restore never overwrites a file in the auth dir.
+	if rec = call(h.DeleteAuthFile, http.MethodDelete, "/v0/management/auth-files?name=codex-01.json"); rec.Code != http.StatusOK {
+		t.Fatalf("delete restored: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if err := os.WriteFile(paths[0], []byte(`{"type":"codex"}`), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if rec = restore("codex-00.json"); rec.Code != http.StatusConflict {
+		t.Fatalf("restore over file: status %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	// Entries older than the retention are purged.
+	old, _ := json.Marshal(trashRecord{OriginalName: "codex-00.json", Filter: "name", DeletedAt: time.Now().Add(-2 * 24 * time.Hour)})
+	if err := os.WriteFile(trashRecordPath(trash, "codex-00.json"), old, 0o600); err != nil {
+		t.Fatalf("age record: %v", err)
+	}
+	h.cfg.AuthTrashRetentionDays = 1
+	rec = call(h.ListAuthTrash, http.MethodGet, "/v0/management/auth-files/trash")
+	if strings.Contains(rec.Body.String(), `"codex-00.json"`) || !strings.Contains(rec.Body.String(), `"codex-01.json"`) {
+		t.Fatalf("after purge: %s", rec.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(trash, "codex-00.json")); !os.IsNotExist(err) {
+		t.Fatalf("expired file kept: %v", err)
+	}
+}
+
+// A file whose name looks like a record is trashed, listed, restored and
+// purged like any other.
+func TestAuthTrash_RecordLikeNamesRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	trash := filepath.Join(authDir, authTrashDirName)
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager, tokenStore: store}
+	call := func(handler gin.HandlerFunc, method, target string, params ...gin.Param) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(method, target, nil)
+		c.Params = params
+		handler(c)
+		return rec
+	}
+	for _, name := range []string{"codex.json", "codex.json.trash.json"} {
+		if err := os.WriteFile(filepath.Join(authDir, name), []byte(`{"type":"codex","email":"`+name+`@example.com"}`), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		if rec := call(h.DeleteAuthFile, http.MethodDelete, "/v0/management/auth-files?name="+name); rec.Code != http.StatusOK {
+			t.Fatalf("delete %s: status %d body=%s", name, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := call(h.ListAuthTrash, http.MethodGet, "/v0/management/auth-files/trash")
+	if !strings.Contains(rec.Body.String(), `"name":"codex.json"`) || !strings.Contains(rec.Body.String(), `"name":"codex.json.trash.json"`) {
+		t.Fatalf("trash lists %s", rec.Body.String())
+	}
+	name := "codex.json.trash.json"
+	rec = call(h.RestoreTrashedAuthFile, http.MethodPost, "/v0/management/auth-files/trash/"+name+"/restore", gin.Param{Key: "name", Value: name})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("restore: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(authDir, name)); err != nil {
+		t.Fatalf("restored file missing: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(trash, "codex.json")); err != nil {
+		t.Fatalf("trashed file lost by the restore: %v", err)
+	}
+
+	old, _ := json.Marshal(trashRecord{OriginalName: "codex.json", Filter: "name", DeletedAt: time.Now().Add(-2 * 24 * time.Hour)})
+	if err := os.WriteFile(trashRecordPath(trash, "codex.json"), old, 0o600); err != nil {
+		t.Fatalf("age record: %v", err)
+	}
+	h.cfg.AuthTrashRetentionDays = 1
+	if rec = call(h.ListAuthTrash, http.MethodGet, "/v0/management/auth-files/trash"); strings.Contains(rec.Body.String(), `"codex.json"`) {
+		t.Fatalf("after purge: %s", rec.Body.String())
+	}
+	for _, path := range []string{filepath.Join(trash, "codex.json"), trashRecordPath(trash, "codex.json")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("%s kept after purge: %v", path, err)
+		}
+	}
+}
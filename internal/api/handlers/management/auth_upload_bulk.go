@@ -0,0 +1,326 @@
+package management
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+const (
+	// maxBulkUploadFiles bounds the auth files one bulk upload may carry,
+	// zip entries included.
+	maxBulkUploadFiles = 2000
+	// maxUploadedAuthFileSize and maxBulkUploadSize bound one auth file and
+	// all of them once read, so a zip bomb cannot exhaust memory.
+	maxUploadedAuthFileSize = 1 << 20
+	maxBulkUploadSize       = 64 << 20
+)
+
+// uploadAuthTypes are the auth file types a bulk upload accepts.
+var uploadAuthTypes = []string{"antigravity", "claude", "codex", "gemini", "gemini-cli", "iflow", "kimi", "qwen", "vertex"}
+
+// Per-file outcomes of a bulk upload.
+const (
+	uploadCreated     = "created"
+	uploadOverwritten = "overwritten"
+	uploadConflict    = "conflict"
+	uploadRejected    = "rejected"
+)
+
+// uploadFileResult reports what a bulk upload did with one file.
+type uploadFileResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// StatusCode is the HTTP status a single upload of the file would have
+	// answered with: 201, 200 when overwritten, 409 for a conflict and 400,
+	// 422 or 500 when rejected.
+	StatusCode   int    `json:"status_code"`
+	ID           string `json:"id,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+	Verification string `json:"verification,omitempty"`
+	// Fields lists what a file failing validation lacks, whether it was
+	// rejected or forced in.
+	Fields []authFieldProblem `json:"fields,omitempty"`
+}
+
+// uploadedFile is one auth file taken from a bulk upload, or the reason it
+// could not be read.
+type uploadedFile struct {
+	name string
+	data []byte
+	err  error
+}
+
+// isBulkUpload reports whether a multipart upload goes through
+// uploadAuthFiles: it has a "files" part, several "file" parts or a zip.
+func isBulkUpload(form *multipart.Form) bool {
+	if form == nil {
+		return false
+	}
+	if len(form.File["files"]) > 0 || len(form.File["file"]) > 1 {
+		return true
+	}
+	for _, header := range form.File["file"] {
+		if strings.HasSuffix(strings.ToLower(header.Filename), ".zip") {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadAuthFiles stores every auth file of a multipart upload, zip archives
+// expanded, and reports each file's outcome. A file that fails is reported
+// and the others are still stored. Existing auths are reported as conflicts
+// unless overwrite=true, and files failing validation are rejected unless
+// force=true. tags, when given, are set on every stored file.
+func (h *Handler) uploadAuthFiles(c *gin.Context, form *multipart.Form, tags []string) {
+	files, errRead := readUploadedFiles(append(slices.Clone(form.File["file"]), form.File["files"]...))
+	if errRead != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errRead.Error()})
+		return
+	}
+	overwrite, _ := strconv.ParseBool(c.Query("overwrite"))
+	force := queryTruthy(c.Query("force"))
+	verify := !h.effectiveAuthInspectionConfig().SkipVerifyOnUpload
+	if v, errParse := strconv.ParseBool(c.Query("verify")); errParse == nil && !v {
+		verify = false
+	}
+	ctx := c.Request.Context()
+	results := make([]uploadFileResult, 0, len(files))
+	counts := map[string]int{}
+	seen := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		result := h.storeUploadedFile(ctx, file, overwrite, force, tags, seen)
+		if verify && result.ID != "" && len(result.Fields) == 0 && h.verifyUploadedAuth(result.ID) {
+			result.Verification = "queued"
+		}
+		counts[result.Status]++
+		results = append(results, result)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":      "ok",
+		"created":     counts[uploadCreated],
+		"overwritten": counts[uploadOverwritten],
+		"conflicts":   counts[uploadConflict],
+		"rejected":    counts[uploadRejected],
+		"results":     results,
+	})
+}
+
+// storeUploadedFile validates one uploaded file and stores it like a single
+// upload, with tags set on it. seen holds the names already taken by this
+// upload. With force a file failing validation is stored with its auth in
+// error.
+func (h *Handler) storeUploadedFile(ctx context.Context, file uploadedFile, overwrite, force bool, tags []string, seen map[string]struct{}) uploadFileResult {
+	rejected := func(name, reason string) uploadFileResult {
+		return uploadFileResult{Name: name, Status: uploadRejected, StatusCode: http.StatusBadRequest, Reason: reason}
+	}
+	if file.err != nil {
+		return rejected(file.name, file.err.Error())
+	}
+	name, errName := sanitizeUploadName(file.name)
+	if errName != nil {
+		return rejected(file.name, errName.Error())
+	}
+	if errValidate := validateUploadedAuth(file.data); errValidate != nil {
+		return rejected(name, errValidate.Error())
+	}
+	var schemaErr *authSchemaError
+	if _, errSchema := validateAuthSchema(file.data); errors.As(errSchema, &schemaErr) && !force {
+		return uploadFileResult{Name: name, Status: uploadRejected, StatusCode: http.StatusUnprocessableEntity, Reason: schemaErr.Error(), Fields: schemaErr.Fields}
+	}
+	data, errTag := tagAuthData(file.data, tags)
+	if errTag != nil {
+		return rejected(name, errTag.Error())
+	}
+	if _, dup := seen[name]; dup {
+		return uploadFileResult{Name: name, Status: uploadConflict, StatusCode: http.StatusConflict, Reason: "duplicate file name in this upload"}
+	}
+	seen[name] = struct{}{}
+
+	dst := filepath.Join(h.cfg.AuthDir, name)
+	if !filepath.IsAbs(dst) {
+		if abs, errAbs := filepath.Abs(dst); errAbs == nil {
+			dst = abs
+		}
+	}
+	existed := h.uploadTargetExists(dst)
+	if existed && !overwrite {
+		return uploadFileResult{Name: name, Status: uploadConflict, StatusCode: http.StatusConflict, Reason: "auth file already exists; set overwrite=true to replace it"}
+	}
+	auth, err := h.saveUploadedAuthFile(ctx, dst, data, overwrite, schemaErr)
+	if err != nil {
+		var conflict *coreauth.ErrAlreadyRegistered
+		if errors.As(err, &conflict) {
+			return uploadFileResult{Name: name, Status: uploadConflict, StatusCode: http.StatusConflict, ID: conflict.Existing.ID, Reason: "auth file already registered; set overwrite=true to replace it"}
+		}
+		return uploadFileResult{Name: name, Status: uploadRejected, StatusCode: http.StatusInternalServerError, Reason: err.Error()}
+	}
+	result := uploadFileResult{Name: name, Status: uploadCreated, StatusCode: http.StatusCreated, ID: auth.ID}
+	if existed {
+		result.Status, result.StatusCode = uploadOverwritten, http.StatusOK
+	}
+	if schemaErr != nil {
+		result.Reason, result.Fields = schemaErr.Error(), schemaErr.Fields
+	}
+	return result
+}
+
+// uploadTargetExists reports whether an upload stored at dst replaces an
+// auth: a file is already there, registered or not, or an auth is registered
+// under its ID.
+func (h *Handler) uploadTargetExists(dst string) bool {
+	_, errStat := os.Stat(dst)
+	_, registered := h.authManager.GetByID(h.authIDForPath(dst))
+	return errStat == nil || registered
+}
+
+// readUploadedFiles reads the uploaded parts, expanding zip archives into
+// their files. A part that cannot be read is returned with its error; only
+// exceeding maxBulkUploadFiles or maxBulkUploadSize fails the whole upload.
+func readUploadedFiles(headers []*multipart.FileHeader) ([]uploadedFile, error) {
+	var files []uploadedFile
+	size := 0
+	add := func(file uploadedFile) error {
+		if len(files) >= maxBulkUploadFiles {
+			return fmt.Errorf("upload carries more than %d files", maxBulkUploadFiles)
+		}
+		if size += len(file.data); size > maxBulkUploadSize {
+			return fmt.Errorf("upload exceeds %d bytes once unpacked", maxBulkUploadSize)
+		}
+		files = append(files, file)
+		return nil
+	}
+	for _, header := range headers {
+		if !strings.HasSuffix(strings.ToLower(header.Filename), ".zip") {
+			data, err := readUploadedPart(header)
+			if errAdd := add(uploadedFile{name: header.Filename, data: data, err: err}); errAdd != nil {
+				return nil, errAdd
+			}
+			continue
+		}
+		errAdd, errZip := readUploadedZip(header, add)
+		if errAdd != nil {
+			return nil, errAdd
+		}
+		if errZip != nil {
+			if errAdd = add(uploadedFile{name: header.Filename, err: errZip}); errAdd != nil {
+				return nil, errAdd
+			}
+		}
+	}
+	return files, nil
+}
+
+func readUploadedPart(header *multipart.FileHeader) ([]byte, error) {
+	if header.Size > maxUploadedAuthFileSize {
+		return nil, fmt.Errorf("file exceeds %d bytes", maxUploadedAuthFileSize)
+	}
+	src, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+	return readLimited(src)
+}
+
+// readUploadedZip passes each file of a zip archive to add as it is read,
+// named by its path in the archive so sanitizeUploadName can reject
+// traversal; directories are skipped. It returns add's error, which ends the
+// upload, or the error that makes the archive unreadable.
+func readUploadedZip(header *multipart.FileHeader, add func(uploadedFile) error) (errAdd, errZip error) {
+	src, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded zip: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+	archive, err := zip.NewReader(src, header.Size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid zip: %w", err)
+	}
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		file := uploadedFile{name: entry.Name}
+		rc, errOpen := entry.Open()
+		if errOpen != nil {
+			file.err = fmt.Errorf("failed to read zip entry: %w", errOpen)
+		} else {
+			file.data, file.err = readLimited(rc)
+			_ = rc.Close()
+		}
+		if errAdd = add(file); errAdd != nil {
+			return errAdd, nil
+		}
+	}
+	return nil, nil
+}
+
+// readLimited reads r, failing once it exceeds maxUploadedAuthFileSize.
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxUploadedAuthFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	if len(data) > maxUploadedAuthFileSize {
+		return nil, fmt.Errorf("file exceeds %d bytes", maxUploadedAuthFileSize)
+	}
+	return data, nil
+}
+
+// sanitizeUploadName turns an uploaded file name into the name the file is
+// stored under in AuthDir: its base name, with characters other than
+// letters, digits and ".-_@+" replaced by "_". Names climbing out of their
+// directory, hidden names and names not ending in .json are rejected.
+func sanitizeUploadName(raw string) (string, error) {
+	slashed := strings.ReplaceAll(strings.TrimSpace(raw), `\`, "/")
+	if slices.Contains(strings.Split(slashed, "/"), "..") {
+		return "", fmt.Errorf("file name must not contain path traversal")
+	}
+	base := path.Base(slashed)
+	if base == "." || base == "/" || strings.HasPrefix(base, ".") {
+		return "", fmt.Errorf("invalid file name")
+	}
+	if !strings.HasSuffix(strings.ToLower(base), ".json") {
+		return "", fmt.Errorf("file must be .json")
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', strings.ContainsRune(".-_@+", r):
+			return r
+		}
+		return '_'
+	}, base), nil
+}
+
+// validateUploadedAuth checks that data is a JSON object with a recognised
+// type.
+func validateUploadedAuth(data []byte) error {
+	var metadata map[string]any
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	authType, _ := metadata["type"].(string)
+	if authType == "" {
+		return fmt.Errorf("missing type")
+	}
+	if !slices.Contains(uploadAuthTypes, authType) {
+		return fmt.Errorf("unrecognized type %q", authType)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package management
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+type bulkUploadResponse struct {
+	Created     int                `json:"created"`
+	Overwritten int                `json:"overwritten"`
+	Conflicts   int                `json:"conflicts"`
+	Rejected    int                `json:"rejected"`
+	Results     []uploadFileResult `json:"results"`
+}
+
+func bulkUpload(t *testing.T, h *Handler, query string, files map[string]string, archive map[string]string) bulkUploadResponse {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	// Sorted names keep the parts, and so the results, in a stable order.
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		part, _ := form.CreateFormFile("files", name)
+		_, _ = part.Write([]byte(files[name]))
+	}
+	if archive != nil {
+		var zipped bytes.Buffer
+		zw := zip.NewWriter(&zipped)
+		entries := make([]string, 0, len(archive))
+		for name := range archive {
+			entries = append(entries, name)
+		}
+		sort.Strings(entries)
+		for _, name := range entries {
+			w, _ := zw.Create(name)
+			_, _ = w.Write([]byte(archive[name]))
+		}
+		_ = zw.Close()
+		part, _ := form.CreateFormFile("file", "auths.zip")
+		_, _ = part.Write(zipped.Bytes())
+	}
+	_ = form.Close()
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files?"+query, &body)
+	c.Request.Header.Set("Content-Type", form.FormDataContentType())
+	h.UploadAuthFile(c)
+	var resp bulkUploadResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("bulk upload: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	return resp
+}
+
+func TestUploadAuthFile_Bulk(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	h := &Handler{cfg: &config.Config{AuthDir: authDir, AuthInspection: config.AuthInspectionConfig{SkipVerifyOnUpload: true}}, authManager: manager}
+	if rec := uploadAuthFile(h, "name=existing.json", `{"type":"codex","access_token":"at","refresh_token":"rt","account_id":"acct","email":"old@example.com"}`); rec.Code != http.StatusOK {
+		t.Fatalf("seed upload: status %d", rec.Code)
+	}
+
+	resp := bulkUpload(t, h, "", map[string]string{
+		"alice.json":    `{"type":"codex","access_token":"at","refresh_token":"rt","account_id":"acct","email":"alice@example.com"}`,
+		"broken.json":   `{"type":`,
+		"existing.json": `{"type":"codex","access_token":"at","refresh_token":"rt","account_id":"acct","email":"new@example.com"}`,
+		"mystery.json":  `{"type":"mystery"}`,
+	}, map[string]string{
+		"nested/bob smith.json": `{"type":"claude","access_token":"at","refresh_token":"rt"}`,
+		"../escape.json":        `{"type":"codex","access_token":"at","refresh_token":"rt","account_id":"acct"}`,
+		"notes.txt":             "hello",
+		"alice.json":            `{"type":"codex","access_token":"at","refresh_token":"rt","account_id":"acct"}`,
+	})
+	want := map[string]struct {
+		status string
+		code   int
+	}{
+		"alice.json":     {uploadCreated, http.StatusCreated},
+		"broken.json":    {uploadRejected, http.StatusBadRequest},
+		"existing.json":  {uploadConflict, http.StatusConflict},
+		"mystery.json":   {uploadRejected, http.StatusBadRequest},
+		"../escape.json": {uploadRejected, http.StatusBadRequest},
+		"bob_smith.json": {uploadCreated, http.StatusCreated},
+		"notes.txt":      {uploadRejected, http.StatusBadRequest},
+	}
+	if len(resp.Results) != 8 || resp.Created != 2 || resp.Conflicts != 2 || resp.Rejected != 4 {
+		t.Fatalf("response = %+v", resp)
+	}
+	duplicates := 0
+	for _, result := range resp.Results {
+		if result.Name == "alice.json" && result.Status == uploadConflict {
+			duplicates++
+			continue
+		}
+		if w, ok := want[result.Name]; !ok || result.Status != w.status || result.StatusCode != w.code {
+			t.Errorf("result %+v, want %+v", result, w)
+		}
+	}
+	if duplicates != 1 {
+		t.Fatalf("the second alice.json was not reported as a duplicate: %+v", resp.Results)
+	}
+	if _, ok := manager.GetByID("bob_smith.json"); !ok {
+		t.Fatalf("zipped auth not registered")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(authDir), "escape.json")); !os.IsNotExist(err) {
+		t.Fatalf("traversal escaped the auth dir: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(authDir, "existing.json")); !bytes.Contains(data, []byte("old@example.com")) {
+		t.Fatalf("conflicting upload replaced the file: %s", data)
+	}
+
+	resp = bulkUpload(t, h, "overwrite=true", map[string]string{
+		"existing.json": `{"type":"codex","access_token":"at","refresh_token":"rt","account_id":"acct","email":"new@example.com"}`,
+		"carol.json":    `{"type":"gemini","token":{"refresh_token":"rt"},"project_id":"p1"}`,
+	}, nil)
+	if resp.Overwritten != 1 || resp.Created != 1 || resp.Results[1].Name != "existing.json" || resp.Results[1].Status != uploadOverwritten {
+		t.Fatalf("overwrite response = %+v", resp)
+	}
+	if got, _ := manager.GetByID("existing.json"); got.Label != "new@example.com" {
+		t.Fatalf("overwritten auth label = %q", got.Label)
+	}
+}
@@ -0,0 +1,196 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func uploadAuthFile(h *Handler, query, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files?"+query, strings.NewReader(body))
+	h.UploadAuthFile(c)
+	return rec
+}
+
+func TestUploadAuthFile_ConflictUnlessOverwrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	// The uploads are not probed, so nothing else touches the auth's state.
+	h := &Handler{cfg: &config.Config{AuthDir: authDir, AuthInspection: config.AuthInspectionConfig{SkipVerifyOnUpload: true}}, authManager: manager}
+	path := filepath.Join(authDir, "alice.json")
+
+	// Simultaneous uploads of the same file: one wins, the rest conflict.
+	const workers = 16
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		codes = map[int]int{}
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := uploadAuthFile(h, "name=alice.json", `{"type":"codex","access_token":"at","refresh_token":"rt","account_id":"acct","email":"alice@example.com"}`)
+			mu.Lock()
+			codes[rec.Code]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if codes[http.StatusOK] != 1 || codes[http.StatusConflict] != workers-1 {
+		t.Fatalf("unexpected status codes: %v", codes)
+	}
+	if n := len(manager.List()); n != 1 {
+		t.Fatalf("entries = %d, want 1", n)
+	}
+
+	current, _ := manager.GetByID("alice.json")
+	current.Quota = coreauth.QuotaState{Exceeded: true, BackoffLevel: 2}
+	if _, err := manager.Update(t.Context(), current); err != nil {
+		t.Fatalf("update auth: %v", err)
+	}
+
+	rec := uploadAuthFile(h, "name=alice.json", `{"type":"codex","access_token":"at","refresh_token":"rt","account_id":"acct","email":"mallory@example.com"}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("upload without overwrite: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "alice@example.com") {
+		t.Fatalf("conflicting upload replaced the file: %s", data)
+	}
+
+	rec = uploadAuthFile(h, "name=alice.json&overwrite=true", `{"type":"codex","access_token":"at","refresh_token":"rt","account_id":"acct","email":"bob@example.com"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("upload with overwrite: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	got, _ := manager.GetByID("alice.json")
+	if got.Label != "bob@example.com" || !got.Quota.Exceeded || got.Quota.BackoffLevel != 2 {
+		t.Fatalf("overwrite should refresh metadata and keep runtime state: %+v", got)
+	}
+}
+
+// A single upload is checked as each file of a bulk upload is.
+func TestUploadAuthFile_SingleFileValidatedLikeBulk(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	h := &Handler{cfg: &config.Config{AuthDir: authDir, AuthInspection: config.AuthInspectionConfig{SkipVerifyOnUpload: true}}, authManager: manager}
+	const valid = `{"type":"claude","access_token":"a","refresh_token":"r"}`
+
+	if rec := uploadAuthFile(h, "name=mystery.json", `{"type":"mystery"}`); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `unrecognized type`) {
+		t.Fatalf("unknown type: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := uploadAuthFile(h, "name=.hidden.json", valid); rec.Code != http.StatusBadRequest {
+		t.Fatalf("hidden name: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(authDir, "mystery.json")); !os.IsNotExist(err) {
+		t.Fatalf("rejected file stored: %v", err)
+	}
+
+	// A file on disk that is not registered is not replaced without overwrite.
+	path := filepath.Join(authDir, "stray.json")
+	if err := os.WriteFile(path, []byte(`{"type":"claude","note":"keep"}`), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if rec := uploadAuthFile(h, "name=stray.json", valid); rec.Code != http.StatusConflict {
+		t.Fatalf("upload over stray file: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "keep") {
+		t.Fatalf("stray file replaced: %s", data)
+	}
+	if rec := uploadAuthFile(h, "name=stray.json&overwrite=true", valid); rec.Code != http.StatusOK {
+		t.Fatalf("overwrite: status %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestUploadAuthFile_VerifiesInBackground(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	release := make(chan struct{})
+	var inFlight, peak, hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		if r.Header.Get("Authorization") == "Bearer revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	originalProbeURL := codexUsageProbeURL
+	codexUsageProbeURL = srv.URL
+	t.Cleanup(func() { codexUsageProbeURL = originalProbeURL })
+
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	h := &Handler{cfg: &config.Config{AuthDir: t.TempDir()}, authManager: manager}
+	t.Cleanup(func() { _ = h.Stop(t.Context()) })
+	const uploads = 10
+	for n := 0; n < uploads; n++ {
+		token := "live"
+		if n == 0 {
+			token = "revoked"
+		}
+		body := fmt.Sprintf(`{"type":"codex","access_token":%q,"refresh_token":"rt","account_id":"acct","expired":"2099-01-01T00:00:00Z"}`, token)
+		rec := uploadAuthFile(h, fmt.Sprintf("name=codex-%02d.json", n), body)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"verification":"queued"`) {
+			t.Fatalf("upload %d: status %d body=%s", n, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := uploadAuthFile(h, "name=skipped.json&verify=false", `{"type":"codex","access_token":"live","refresh_token":"rt","account_id":"acct"}`); rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "queued") {
+		t.Fatalf("verify=false upload: status %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	// The responses were written while every probe was still held upstream.
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		done := 0
+		for _, auth := range manager.List() {
+			if _, ok := auth.Metadata[coreauth.MetadataLastVerifiedAt]; ok {
+				done++
+			}
+		}
+		if done == uploads {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("verified %d of %d uploads", done, uploads)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := peak.Load(); got > uploadVerifyConcurrency {
+		t.Fatalf("peak concurrent probes = %d, want at most %d", got, uploadVerifyConcurrency)
+	}
+	if got := hits.Load(); got != uploads {
+		t.Fatalf("probe requests = %d, want %d", got, uploads)
+	}
+	revoked, _ := manager.GetByID("codex-00.json")
+	if invalid, _ := tokenInvalidState(revoked); !invalid || revoked.Status != coreauth.StatusError {
+		t.Fatalf("revoked upload not marked invalid: status=%s metadata=%v", revoked.Status, revoked.Metadata)
+	}
+	live, _ := manager.GetByID("codex-01.json")
+	if live.Metadata[coreauth.MetadataLastVerifiedOutcome] != coreauth.OutcomeValid {
+		t.Fatalf("live upload outcome = %v", live.Metadata[coreauth.MetadataLastVerifiedOutcome])
+	}
+}
@@ -0,0 +1,164 @@
+package management
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// authFieldProblem is a field of an auth file that is missing or malformed,
+// named by its dot-separated path.
+type authFieldProblem struct {
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+}
+
+// authSchemaError reports the fields an auth file of Type lacks to be usable.
+type authSchemaError struct {
+	Type   string
+	Fields []authFieldProblem
+}
+
+func (e *authSchemaError) Error() string {
+	problems := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		problems = append(problems, field.Field+" "+field.Problem)
+	}
+	return fmt.Sprintf("invalid %s auth file: %s", e.Type, strings.Join(problems, ", "))
+}
+
+// authSchemaValidators check, per auth file type, the fields its executor
+// needs to make requests. Types without an entry are only checked for being
+// JSON objects with a type.
+var authSchemaValidators = map[string]func(metadata map[string]any) []authFieldProblem{
+	"codex": func(metadata map[string]any) []authFieldProblem {
+		problems := requireAuthFields(metadata, "access_token", "refresh_token")
+		if codexAccountID(&coreauth.Auth{Metadata: metadata}) == "" {
+			problems = append(problems, authFieldProblem{Field: "account_id", Problem: "is missing, and id_token carries no chatgpt_account_id"})
+		}
+		return problems
+	},
+	"claude": func(metadata map[string]any) []authFieldProblem {
+		return requireAuthFields(metadata, "access_token", "refresh_token")
+	},
+	"gemini":      validateGeminiAuth,
+	"gemini-cli":  validateGeminiAuth,
+	"antigravity": func(metadata map[string]any) []authFieldProblem { return requireAuthFields(metadata, "refresh_token") },
+	"vertex": func(metadata map[string]any) []authFieldProblem {
+		return requireAuthFields(metadata, "project_id", "service_account.client_email", "service_account.private_key")
+	},
+	"qwen": func(metadata map[string]any) []authFieldProblem {
+		return requireAuthFields(metadata, "access_token", "refresh_token")
+	},
+	"kimi": func(metadata map[string]any) []authFieldProblem {
+		return requireAuthFields(metadata, "access_token", "refresh_token")
+	},
+	// iFlow signs in with an API key, either pasted or obtained through
+	// OAuth or a cookie.
+	"iflow": func(metadata map[string]any) []authFieldProblem { return requireAuthFields(metadata, "api_key") },
+}
+
+// validateGeminiAuth requires the OAuth token Google's client library stores,
+// with the refresh token that keeps it usable, and the project requests are
+// billed to.
+func validateGeminiAuth(metadata map[string]any) []authFieldProblem {
+	return requireAuthFields(metadata, "token.refresh_token", "project_id")
+}
+
+// requireAuthFields reports each of paths, dot-separated for nested objects,
+// that does not hold a non-empty string in metadata.
+func requireAuthFields(metadata map[string]any, paths ...string) []authFieldProblem {
+	var problems []authFieldProblem
+	for _, path := range paths {
+		if problem := authFieldProblemAt(metadata, path); problem != nil {
+			problems = append(problems, *problem)
+		}
+	}
+	return problems
+}
+
+func authFieldProblemAt(metadata map[string]any, path string) *authFieldProblem {
+	keys := strings.Split(path, ".")
+	var value any = metadata
+	for i, key := range keys {
+		object, ok := value.(map[string]any)
+		if !ok {
+			if value == nil {
+				break
+			}
+			return &authFieldProblem{Field: strings.Join(keys[:i], "."), Problem: "must be an object"}
+		}
+		value = object[key]
+	}
+	text, ok := value.(string)
+	switch {
+	case value == nil:
+		return &authFieldProblem{Field: path, Problem: "is missing"}
+	case !ok:
+		return &authFieldProblem{Field: path, Problem: "must be a string"}
+	case strings.TrimSpace(text) == "":
+		return &authFieldProblem{Field: path, Problem: "is empty"}
+	}
+	return nil
+}
+
+// validateAuthSchema checks an auth file against the validator of its type.
+// It returns the file's type and an *authSchemaError listing the problem
+// fields, or a plain error when data is not a JSON object.
+func validateAuthSchema(data []byte) (string, error) {
+	var metadata map[string]any
+	if err := json.Unmarshal(data, &metadata); err != nil || metadata == nil {
+		return "", fmt.Errorf("invalid JSON: auth file must be a JSON object")
+	}
+	authType, ok := metadata["type"].(string)
+	authType = strings.TrimSpace(authType)
+	if authType == "" {
+		problem := "is missing"
+		if _, present := metadata["type"]; present && !ok {
+			problem = "must be a string"
+		}
+		return "", &authSchemaError{Type: "unknown", Fields: []authFieldProblem{{Field: "type", Problem: problem}}}
+	}
+	validate, known := authSchemaValidators[authType]
+	if !known {
+		return authType, nil
+	}
+	if problems := validate(metadata); len(problems) > 0 {
+		return authType, &authSchemaError{Type: authType, Fields: problems}
+	}
+	return authType, nil
+}
+
+// schemaErrorPayload is the 422 response rejecting an auth file.
+func schemaErrorPayload(err *authSchemaError) gin.H {
+	return gin.H{"error": err.Error(), "type": err.Type, "fields": err.Fields}
+}
+
+// ValidateAuthFile checks the auth file in the request body as an upload
+// would, without storing it, and reports the problem fields.
+func (h *Handler) ValidateAuthFile(c *gin.Context) {
+	data, err := readLimited(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "request body is empty"})
+		return
+	}
+	authType, err := validateAuthSchema(data)
+	var schemaErr *authSchemaError
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"valid": true, "type": authType, "fields": []authFieldProblem{}})
+	case errors.As(err, &schemaErr):
+		c.JSON(http.StatusOK, gin.H{"valid": false, "type": schemaErr.Type, "fields": schemaErr.Fields, "error": schemaErr.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
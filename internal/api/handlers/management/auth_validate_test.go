@@ -0,0 +1,107 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestValidateAuthSchema(t *testing.T) {
+	cases := []struct {
+		body   string
+		fields string // problem fields, "" when valid
+	}{
+		{`{"type":"codex","access_token":"a","refresh_token":"r","account_id":"acct"}`, ""},
+		{`{"type":"codex","access_token":"a","refresh_token":"","chatgpt_account_id":"acct"}`, "refresh_token"},
+		{`{"type":"codex","access_token":1}`, "access_token,refresh_token,account_id"},
+		{`{"type":"claude","access_token":"a","refresh_token":"r"}`, ""},
+		{`{"type":"claude","access_token":"a"}`, "refresh_token"},
+		{`{"type":"gemini","token":{"access_token":"a","refresh_token":"r"},"project_id":"p"}`, ""},
+		{`{"type":"gemini-cli","token":"r"}`, "token,project_id"},
+		{`{"type":"vertex","project_id":"p","service_account":{"client_email":"e"}}`, "service_account.private_key"},
+		{`{"type":"iflow","api_key":"k"}`, ""},
+		{`{"type":"mystery"}`, ""},
+		{`{"email":"a@example.com"}`, "type"},
+	}
+	for _, tc := range cases {
+		_, err := validateAuthSchema([]byte(tc.body))
+		var schemaErr *authSchemaError
+		if tc.fields == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tc.body, err)
+			}
+			continue
+		}
+		if !errors.As(err, &schemaErr) {
+			t.Errorf("%s: err = %v", tc.body, err)
+			continue
+		}
+		var fields []string
+		for _, field := range schemaErr.Fields {
+			fields = append(fields, field.Field)
+		}
+		if got := strings.Join(fields, ","); got != tc.fields {
+			t.Errorf("%s: fields %s, want %s", tc.body, got, tc.fields)
+		}
+	}
+	if _, err := validateAuthSchema([]byte(`[1]`)); err == nil || errors.As(err, new(*authSchemaError)) {
+		t.Fatalf("non-object err = %v", err)
+	}
+}
+
+func TestUploadAuthFile_RejectsInvalidSchemaUnlessForced(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	h := &Handler{cfg: &config.Config{AuthDir: authDir, AuthInspection: config.AuthInspectionConfig{SkipVerifyOnUpload: true}}, authManager: manager}
+	const invalid = `{"type":"claude","access_token":"a"}`
+
+	rec := uploadAuthFile(h, "name=claude.json", invalid)
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), `"field":"refresh_token"`) {
+		t.Fatalf("invalid upload: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(authDir, "claude.json")); !os.IsNotExist(err) {
+		t.Fatalf("rejected file stored: %v", err)
+	}
+	rec = uploadAuthFile(h, "name=claude.json&force=true", invalid)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"auth_status":"error"`) {
+		t.Fatalf("forced upload: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if auth, ok := manager.GetByID("claude.json"); !ok || auth.Status != coreauth.StatusError || !strings.Contains(auth.StatusMessage, "refresh_token") {
+		t.Fatalf("forced auth = %+v", auth)
+	}
+
+	resp := bulkUpload(t, h, "", map[string]string{"a.json": invalid, "b.json": `{"type":"claude","access_token":"a","refresh_token":"r"}`}, nil)
+	if resp.Created != 1 || resp.Rejected != 1 || resp.Results[0].StatusCode != http.StatusUnprocessableEntity || len(resp.Results[0].Fields) != 1 {
+		t.Fatalf("bulk response = %+v", resp)
+	}
+	resp = bulkUpload(t, h, "force=true", map[string]string{"a.json": invalid}, nil)
+	if resp.Created != 1 || resp.Results[0].Fields[0].Field != "refresh_token" {
+		t.Fatalf("forced bulk response = %+v", resp)
+	}
+
+	validate := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/validate", strings.NewReader(body))
+		h.ValidateAuthFile(c)
+		return rec
+	}
+	if rec = validate(invalid); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"valid":false`) {
+		t.Fatalf("validate invalid: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec = validate(`{"type":"claude","access_token":"a","refresh_token":"r"}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"valid":true`) {
+		t.Fatalf("validate valid: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec = validate(`{`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("validate broken: status %d", rec.Code)
+	}
+}
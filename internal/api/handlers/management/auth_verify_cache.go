@@ -0,0 +1,86 @@
+package management
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// verifyTokenKeys are the metadata fields whose change drops an auth's cached
+// probe result: a refresh rotates them and a re-upload replaces them.
+var verifyTokenKeys = []string{"access_token", "refresh_token", "id_token", "api_key", "token"}
+
+// verifyResultCache keeps the last conclusive or throttled probe result of
+// each auth verified through verify-invalid, so repeated calls within the
+// TTL do not probe upstream again.
+type verifyResultCache struct {
+	mu      sync.Mutex
+	entries map[string]verifyCacheEntry
+}
+
+type verifyCacheEntry struct {
+	result      coreauth.VerifyResult
+	fingerprint string
+	at          time.Time
+}
+
+// lookup returns auth's cached result if it is younger than ttl and was
+// probed with the token auth holds now.
+func (c *verifyResultCache) lookup(auth *coreauth.Auth, ttl time.Duration, now time.Time) (coreauth.VerifyResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[auth.ID]
+	if !ok {
+		return coreauth.VerifyResult{}, false
+	}
+	if now.Sub(entry.at) >= ttl || entry.fingerprint != authTokenFingerprint(auth) {
+		delete(c.entries, auth.ID)
+		return coreauth.VerifyResult{}, false
+	}
+	result := entry.result
+	result.CachedAt = entry.at.UTC().Format(time.RFC3339)
+	return result, true
+}
+
+// store caches the probed results of a batch against the tokens their auths
+// hold after the probe, dropping the entries that have expired under ttl.
+// Inconclusive results are never cached.
+func (c *verifyResultCache) store(manager *coreauth.Manager, results []coreauth.VerifyResult, ttl time.Duration, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, entry := range c.entries {
+		if now.Sub(entry.at) >= ttl {
+			delete(c.entries, id)
+		}
+	}
+	for _, result := range results {
+		if result.Cached || result.Outcome == coreauth.OutcomeError {
+			continue
+		}
+		auth, ok := manager.GetByID(result.ID)
+		if !ok {
+			continue
+		}
+		if c.entries == nil {
+			c.entries = make(map[string]verifyCacheEntry)
+		}
+		result.Recovered = false
+		c.entries[result.ID] = verifyCacheEntry{result: result, fingerprint: authTokenFingerprint(auth), at: now}
+	}
+}
+
+// authTokenFingerprint hashes the credentials an auth's probe uses.
+func authTokenFingerprint(auth *coreauth.Auth) string {
+	values := make([]any, 0, len(verifyTokenKeys)+1)
+	for _, key := range verifyTokenKeys {
+		values = append(values, auth.Metadata[key])
+	}
+	values = append(values, auth.Attributes["api_key"])
+	raw, _ := json.Marshal(values)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
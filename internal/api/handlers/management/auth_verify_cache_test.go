@@ -0,0 +1,96 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestVerifyInvalidAuthFiles_CachesProbeResults(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, authDir, "codex", 2)
+	var mu sync.Mutex
+	probes := map[string]int{}
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
+		mu.Lock()
+		probes[auth.ID]++
+		mu.Unlock()
+		coreauth.RecordProbeStatus(ctx, http.StatusUnauthorized)
+		return true, "usage probe 401: revoked", nil
+	}))
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager}
+	h.SetInspector(inspector)
+
+	type row struct {
+		ID         string `json:"id"`
+		Outcome    string `json:"outcome"`
+		StatusCode int    `json:"status_code"`
+		Cached     bool   `json:"cached"`
+		CachedAt   string `json:"cached_at"`
+	}
+	verify := func(query string) (int, []row) {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex"+query, nil)
+		h.VerifyInvalidAuthFiles(c)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Cached  int   `json:"cached"`
+			Results []row `json:"results"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return resp.Cached, resp.Results
+	}
+	probeCount := func(id string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return probes[id]
+	}
+
+	if cached, _ := verify(""); cached != 0 || probeCount("codex-00.json") != 1 || probeCount("codex-01.json") != 1 {
+		t.Fatalf("first call: cached=%d probes=%v", cached, probes)
+	}
+	cached, results := verify("")
+	if cached != 2 || probeCount("codex-00.json") != 1 || probeCount("codex-01.json") != 1 {
+		t.Fatalf("second call: cached=%d probes=%v", cached, probes)
+	}
+	for _, r := range results {
+		if !r.Cached || r.CachedAt == "" || r.Outcome != coreauth.OutcomeInvalid || r.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("cached row = %+v", r)
+		}
+	}
+
+	if cached, _ := verify("&force=true"); cached != 0 || probeCount("codex-00.json") != 2 {
+		t.Fatalf("forced call: cached=%d probes=%v", cached, probes)
+	}
+
+	// A new token drops the auth's cached result.
+	auth, _ := manager.GetByID("codex-01.json")
+	auth.Metadata["access_token"] = "re-uploaded"
+	if _, err := manager.Update(context.Background(), auth); err != nil {
+		t.Fatalf("update auth: %v", err)
+	}
+	cached, results = verify("")
+	if cached != 1 || probeCount("codex-00.json") != 2 || probeCount("codex-01.json") != 3 {
+		t.Fatalf("after token change: cached=%d probes=%v", cached, probes)
+	}
+	for _, r := range results {
+		if r.Cached != (r.ID == "codex-00.json") {
+			t.Fatalf("row %s cached = %v", r.ID, r.Cached)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestVerifyInvalidAuthFiles_StopsWhenClientDisconnects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	originalProbeURL := codexUsageProbeURL
+	codexUsageProbeURL = srv.URL
+	t.Cleanup(func() { codexUsageProbeURL = originalProbeURL })
+
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	for n := 0; n < 3; n++ {
+		id := fmt.Sprintf("codex-%02d.json", n)
+		auth := &coreauth.Auth{ID: id, FileName: id, Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{
+			"type":         "codex",
+			"access_token": "token-" + id,
+			"expired":      "2099-01-01T00:00:00Z",
+		}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+	h := &Handler{cfg: &config.Config{AuthDir: t.TempDir()}, authManager: manager}
+
+	// The client goes away as soon as the first auth has been checked.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.authInspector().Subscribe(func(event coreauth.InspectionEvent) {
+		if event.Type == coreauth.InspectionAuthChecked {
+			cancel()
+		}
+	})
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex&concurrency=1", nil).WithContext(ctx)
+	h.VerifyInvalidAuthFiles(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Checked    int  `json:"checked"`
+		Valid      int  `json:"valid"`
+		Done       bool `json:"done"`
+		NextCursor int  `json:"next_cursor"`
+		Cancelled  bool `json:"cancelled"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !resp.Cancelled || resp.Checked != 1 || resp.Valid != 1 || resp.Done || resp.NextCursor != 0 {
+		t.Fatalf("response = %+v", resp)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("probe server received %d requests, want 1", got)
+	}
+}
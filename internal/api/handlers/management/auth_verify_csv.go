@@ -0,0 +1,127 @@
+package management
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// verifyCSVColumns is the header row of a verify result CSV export.
+var verifyCSVColumns = []string{"id", "name", "provider", "outcome", "http_status", "reason", "plan_type", "latency_ms", "verified_at"}
+
+// parseVerifyFormat reads the format query parameter of the verify
+// endpoints: "json", the default, or "csv".
+func parseVerifyFormat(raw string) (csvFormat bool, err error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "json":
+		return false, nil
+	case "csv":
+		return true, nil
+	}
+	return false, fmt.Errorf("format must be %q or %q", "json", "csv")
+}
+
+// verifyCSVWriter streams verify results to a response as CSV rows. The
+// response is started with the first row, so a request that fails before
+// producing any can still answer with a JSON error. Rows may be written
+// concurrently.
+type verifyCSVWriter struct {
+	h  *Handler
+	c  *gin.Context
+	mu sync.Mutex
+	w  *csv.Writer
+}
+
+func (h *Handler) newVerifyCSVWriter(c *gin.Context) *verifyCSVWriter {
+	return &verifyCSVWriter{h: h, c: c}
+}
+
+// startLocked sends the headers and the header row. The filename carries
+// the export time so successive exports do not overwrite each other.
+func (v *verifyCSVWriter) startLocked() {
+	if v.w != nil {
+		return
+	}
+	filename := "verify-results-" + time.Now().UTC().Format("20060102T150405Z") + ".csv"
+	v.c.Header("Content-Type", "text/csv; charset=utf-8")
+	v.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	v.c.Status(http.StatusOK)
+	v.w = csv.NewWriter(v.c.Writer)
+	_ = v.w.Write(verifyCSVColumns)
+}
+
+// started reports whether the response has been started.
+func (v *verifyCSVWriter) started() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.w != nil
+}
+
+// write sends the row of item and flushes it to the client.
+func (v *verifyCSVWriter) write(item coreauth.VerifyResult) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.startLocked()
+	_ = v.w.Write(v.row(item))
+	v.flushLocked()
+}
+
+// finish starts the response if no row did, so an empty export still has
+// its header row, and flushes it.
+func (v *verifyCSVWriter) finish() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.startLocked()
+	v.flushLocked()
+}
+
+func (v *verifyCSVWriter) flushLocked() {
+	v.w.Flush()
+	v.c.Writer.Flush()
+}
+
+// row lays item out in verifyCSVColumns order. Inconclusive results report
+// their error as the reason; csv.Writer quotes fields with commas, quotes
+// or line breaks.
+func (v *verifyCSVWriter) row(item coreauth.VerifyResult) []string {
+	status := ""
+	if item.StatusCode != 0 {
+		status = strconv.Itoa(item.StatusCode)
+	}
+	reason := item.Reason
+	if reason == "" {
+		reason = item.Error
+	}
+	return []string{
+		item.ID,
+		item.Name,
+		item.Provider,
+		item.Outcome,
+		status,
+		reason,
+		v.h.authPlanType(item.ID),
+		strconv.FormatInt(item.LatencyMs, 10),
+		item.VerifiedAt,
+	}
+}
+
+// authPlanType returns the plan type in the ID token of a codex auth, or ""
+// for other auths and unknown IDs.
+func (h *Handler) authPlanType(id string) string {
+	if h.authManager == nil {
+		return ""
+	}
+	auth, ok := h.authManager.GetByID(id)
+	if !ok {
+		return ""
+	}
+	plan, _ := extractCodexIDTokenClaims(auth)["plan_type"].(string)
+	return plan
+}
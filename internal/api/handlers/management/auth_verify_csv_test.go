@@ -0,0 +1,113 @@
+package management
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestVerifyInvalidAuthFiles_CSVExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, t.TempDir(), "codex", 3)
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"https://api.openai.com/auth":{"chatgpt_plan_type":"plus"}}`))
+	plus, _ := manager.GetByID("codex-00.json")
+	plus.Metadata = map[string]any{"type": "codex", "id_token": "header." + claims + ".signature"}
+	if _, err := manager.Update(context.Background(), plus); err != nil {
+		t.Fatalf("update auth: %v", err)
+	}
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
+		switch auth.ID {
+		case "codex-01.json":
+			coreauth.RecordProbeStatus(ctx, http.StatusUnauthorized)
+			return true, `401 "token_revoked", re-login required`, nil
+		case "codex-02.json":
+			return false, "", fmt.Errorf("%w: usage probe returned 502", coreauth.ErrProbeInconclusive)
+		}
+		return false, "", nil
+	}))
+	h := &Handler{cfg: &config.Config{}, authManager: manager}
+	h.SetInspector(inspector)
+	defer func() { _ = h.Stop(context.Background()) }()
+	request := func(handler gin.HandlerFunc, method, target string, params gin.Params) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(method, target, nil)
+		c.Params = params
+		handler(c)
+		return rec
+	}
+
+	rec := request(h.VerifyInvalidAuthFiles, http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex&format=csv", nil)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("status %d content type %q body=%s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	if disposition := rec.Header().Get("Content-Disposition"); !regexp.MustCompile(`^attachment; filename="verify-results-\d{8}T\d{6}Z\.csv"$`).MatchString(disposition) {
+		t.Fatalf("Content-Disposition = %q", disposition)
+	}
+	rows, err := csv.NewReader(strings.NewReader(rec.Body.String())).ReadAll()
+	if err != nil || len(rows) != 4 {
+		t.Fatalf("parse CSV: %v body=%s", err, rec.Body.String())
+	}
+	if strings.Join(rows[0], ",") != "id,name,provider,outcome,http_status,reason,plan_type,latency_ms,verified_at" {
+		t.Fatalf("header = %v", rows[0])
+	}
+	// Rows arrive in completion order.
+	byID := make(map[string][]string, 3)
+	for _, row := range rows[1:] {
+		if row[7] == "" || row[8] == "" {
+			t.Fatalf("row without latency or time: %v", row)
+		}
+		byID[row[0]] = row
+	}
+	if row := byID["codex-00.json"]; row[3] != coreauth.OutcomeValid || row[6] != "plus" {
+		t.Fatalf("valid row = %v", row)
+	}
+	if row := byID["codex-01.json"]; row[3] != coreauth.OutcomeInvalid || row[4] != "401" || row[5] != `401 "token_revoked", re-login required` || row[6] != "" {
+		t.Fatalf("invalid row = %v", row)
+	}
+	if row := byID["codex-02.json"]; row[3] != coreauth.OutcomeError || !strings.Contains(row[5], "usage probe returned 502") {
+		t.Fatalf("error row = %v", row)
+	}
+	if !strings.Contains(rec.Body.String(), `"401 ""token_revoked"", re-login required"`) {
+		t.Fatalf("reason not quoted: %s", rec.Body.String())
+	}
+
+	if rec = request(h.VerifyInvalidAuthFiles, http.MethodPost, "/v0/management/auth-files/verify-invalid?format=xml", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("format=xml: status %d", rec.Code)
+	}
+	if rec = request(h.VerifyInvalidAuthFiles, http.MethodPost, "/v0/management/auth-files/verify-invalid?format=csv&async=true", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("async CSV: status %d", rec.Code)
+	}
+
+	// An async job's results export the same way.
+	rec = request(h.VerifyInvalidAuthFiles, http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex&async=true&force=true", nil)
+	var started struct {
+		JobID string `json:"job_id"`
+	}
+	if err = json.Unmarshal(rec.Body.Bytes(), &started); err != nil || started.JobID == "" {
+		t.Fatalf("async verify: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	params := gin.Params{{Key: "id", Value: started.JobID}}
+	waitFor(t, "the job", func() bool {
+		job, _ := h.verifyJobs.get(started.JobID, time.Now())
+		return job.Status != "running"
+	})
+	rec = request(h.GetVerifyJob, http.MethodGet, "/v0/management/auth-files/verify-jobs/"+started.JobID+"?format=csv", params)
+	if rows, err = csv.NewReader(strings.NewReader(rec.Body.String())).ReadAll(); err != nil || len(rows) != 4 || rows[1][0] != "codex-00.json" || rows[1][6] != "plus" {
+		t.Fatalf("job CSV: status %d rows %v err %v", rec.Code, rows, err)
+	}
+}
@@ -0,0 +1,126 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// A probe still in flight when its auth is deleted must not bring the file or
+// the auth back, whatever its verdict.
+func TestVerifyAuth_DeletedDuringProbeStaysDeleted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verdicts := map[string]struct {
+		invalid bool
+		err     error
+		retryAt time.Time
+	}{
+		"valid":     {},
+		"invalid":   {invalid: true},
+		"failure":   {err: errors.New("connection reset")},
+		"throttled": {err: coreauth.ErrProbeThrottled, retryAt: time.Now().Add(time.Hour)},
+	}
+	for name, verdict := range verdicts {
+		t.Run(name, func(t *testing.T) {
+			authDir := t.TempDir()
+			store := sdkAuth.NewFileTokenStore()
+			store.SetBaseDir(authDir)
+			manager := coreauth.NewManager(store, nil, nil)
+			h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager, tokenStore: store}
+			path := filepath.Join(authDir, "codex.json")
+			if err := os.WriteFile(path, []byte(`{"type":"codex","access_token":"at","refresh_token":"rt","account_id":"acct"}`), 0o600); err != nil {
+				t.Fatalf("write auth file: %v", err)
+			}
+			auth, err := h.authFromFile(path, nil)
+			if err != nil {
+				t.Fatalf("load auth: %v", err)
+			}
+			if _, err = manager.Register(context.Background(), auth); err != nil {
+				t.Fatalf("register auth: %v", err)
+			}
+
+			probing, release := make(chan struct{}), make(chan struct{})
+			inspector := coreauth.NewInspector(manager)
+			inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(ctx context.Context, _ *coreauth.Auth) (bool, string, error) {
+				close(probing)
+				<-release
+				coreauth.RecordProbeRetryAt(ctx, verdict.retryAt)
+				return verdict.invalid, "401 revoked", verdict.err
+			}))
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				stored, _ := manager.GetByID(auth.ID)
+				_, _ = inspector.VerifyOne(context.Background(), stored, nil)
+			}()
+			select {
+			case <-probing:
+			case <-time.After(2 * time.Second):
+				t.Fatal("probe never started")
+			}
+
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodDelete, "/v0/management/auth-files?name=codex.json&purge=true", nil)
+			h.DeleteAuthFile(c)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("delete: status %d body=%s", rec.Code, rec.Body.String())
+			}
+			close(release)
+			select {
+			case <-done:
+			case <-time.After(2 * time.Second):
+				t.Fatal("verify never returned")
+			}
+
+			if _, err = os.Stat(path); !os.IsNotExist(err) {
+				t.Fatalf("auth file rewritten: %v", err)
+			}
+			if !manager.Removed(auth.ID) {
+				t.Fatal("tombstone cleared")
+			}
+			if stored, ok := manager.GetByID(auth.ID); !ok || !stored.Disabled || stored.Status != coreauth.StatusDisabled {
+				t.Fatalf("deleted auth = %+v", stored)
+			}
+		})
+	}
+}
+
+// An invalid verdict marks the auth as it is once the probe returns, keeping
+// the keys edited while the probe ran.
+func TestVerifyAuth_InvalidVerdictKeepsConcurrentEdits(t *testing.T) {
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	auth := &coreauth.Auth{ID: "codex.json", Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{"type": "codex", "note": "old"}}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(ctx context.Context, _ *coreauth.Auth) (bool, string, error) {
+		if _, err := manager.Edit(ctx, auth.ID, func(stored *coreauth.Auth) error {
+			stored.Metadata["note"] = "patched"
+			stored.Metadata["tags"] = []any{"team-a"}
+			return nil
+		}); err != nil {
+			t.Errorf("edit during probe: %v", err)
+		}
+		return true, "401 revoked", nil
+	}))
+	stored, _ := manager.GetByID(auth.ID)
+	if _, err := inspector.VerifyOne(context.Background(), stored, nil); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	stored, _ = manager.GetByID(auth.ID)
+	if invalid, _ := tokenInvalidState(stored); !invalid || stored.Metadata["note"] != "patched" || stored.Metadata["tags"] == nil {
+		t.Fatalf("auth after verify = %+v", stored.Metadata)
+	}
+}
@@ -0,0 +1,120 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestVerifyInvalidAuthFiles_ReportsStatusAndErrorCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer deactivated" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":{"code":"account_deactivated","message":"gone"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	originalProbeURL := codexUsageProbeURL
+	codexUsageProbeURL = srv.URL
+	t.Cleanup(func() { codexUsageProbeURL = originalProbeURL })
+
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	for id, token := range map[string]string{"codex-dead.json": "deactivated", "codex-live.json": "live"} {
+		auth := &coreauth.Auth{ID: id, FileName: id, Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{
+			"type":         "codex",
+			"access_token": token,
+			"expired":      "2099-01-01T00:00:00Z",
+		}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+	h := &Handler{cfg: &config.Config{AuthDir: t.TempDir()}, authManager: manager}
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=codex", nil)
+	h.VerifyInvalidAuthFiles(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Results []struct {
+			ID         string `json:"id"`
+			Name       string `json:"name"`
+			Provider   string `json:"provider"`
+			Outcome    string `json:"outcome"`
+			StatusCode int    `json:"status_code"`
+			ErrorCode  string `json:"error_code"`
+			Reason     string `json:"reason"`
+			LatencyMs  *int64 `json:"latency_ms"`
+		} `json:"results"`
+		ResultsOmitted bool `json:"results_omitted"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Results) != 2 || resp.ResultsOmitted {
+		t.Fatalf("results = %+v", resp)
+	}
+	dead, live := resp.Results[0], resp.Results[1]
+	if dead.ID != "codex-dead.json" || dead.Name != "codex-dead.json" || dead.Provider != "codex" || dead.Outcome != coreauth.OutcomeInvalid ||
+		dead.StatusCode != http.StatusForbidden || dead.ErrorCode != "account_deactivated" || dead.Reason == "" || dead.LatencyMs == nil {
+		t.Fatalf("invalid row = %+v", dead)
+	}
+	if live.Outcome != coreauth.OutcomeValid || live.StatusCode != http.StatusOK || live.ErrorCode != "" || live.LatencyMs == nil {
+		t.Fatalf("valid row = %+v", live)
+	}
+}
+
+func TestGetVerifyJob_OmitsLargeResultsUnlessDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	const auths = maxVerifyResultRows + 1
+	registerInspectionFixtures(t, manager, authDir, "acme", auths)
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("acme", coreauth.ProbeFunc(func(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
+		return false, "", nil
+	}))
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager}
+	h.SetInspector(inspector)
+	defer func() { _ = h.Stop(context.Background()) }()
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=acme&batch_size=1000&async=true", nil)
+	h.VerifyInvalidAuthFiles(c)
+	var started struct {
+		JobID string `json:"job_id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &started); err != nil || started.JobID == "" {
+		t.Fatalf("async verify: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	getJob := func(query string) verifyJob {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-files/verify-jobs/"+started.JobID+query, nil)
+		c.Params = gin.Params{{Key: "id", Value: started.JobID}}
+		h.GetVerifyJob(c)
+		var job verifyJob
+		_ = json.Unmarshal(rec.Body.Bytes(), &job)
+		return job
+	}
+	waitFor(t, "the job", func() bool { return getJob("").Status != "running" })
+
+	if job := getJob(""); job.Checked != auths || len(job.Results) != 0 || !job.ResultsOmitted {
+		t.Fatalf("job without details: checked=%d results=%d omitted=%v", job.Checked, len(job.Results), job.ResultsOmitted)
+	}
+	if job := getJob("?details=true"); len(job.Results) != auths || job.ResultsOmitted {
+		t.Fatalf("job with details: results=%d omitted=%v", len(job.Results), job.ResultsOmitted)
+	}
+}
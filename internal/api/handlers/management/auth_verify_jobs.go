@@ -0,0 +1,231 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+const (
+	// verifyJobTimeout bounds how long an asynchronous verify run may take.
+	verifyJobTimeout = 2 * time.Hour
+	// verifyJobRetention is how long a finished job stays readable.
+	verifyJobRetention = time.Hour
+)
+
+// verifyJob tracks one asynchronous verify-invalid run.
+type verifyJob struct {
+	ID         string                  `json:"id"`
+	Status     string                  `json:"status"` // running, succeeded, failed or cancelled
+	Provider   string                  `json:"provider"`
+	Scope      string                  `json:"scope"`
+	Tags       []string                `json:"tag,omitempty"`
+	BatchSize  int                     `json:"batch_size"`
+	StartedAt  time.Time               `json:"started_at"`
+	FinishedAt *time.Time              `json:"finished_at,omitempty"`
+	Cursor     int                     `json:"cursor"`
+	Total      int                     `json:"total"`
+	Done       bool                    `json:"done"`
+	Checked    int                     `json:"checked"`
+	Valid      int                     `json:"valid"`
+	Invalid    int                     `json:"invalid"`
+	Errors     int                     `json:"errors"`
+	Throttled  int                     `json:"throttled"`
+	Error      string                  `json:"error,omitempty"`
+	Results    []coreauth.VerifyResult `json:"results"`
+	// ResultsOmitted is set when Results was left empty because the job
+	// holds more than maxVerifyResultRows and details=true was not passed.
+	ResultsOmitted bool `json:"results_omitted,omitempty"`
+
+	cancel context.CancelFunc
+}
+
+// verifyJobs keeps asynchronous verify runs in memory until an hour after
+// they finish.
+type verifyJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*verifyJob
+}
+
+// start registers job, dropping the finished jobs past their retention.
+func (s *verifyJobs) start(job *verifyJob, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, existing := range s.jobs {
+		if existing.FinishedAt != nil && now.Sub(*existing.FinishedAt) > verifyJobRetention {
+			delete(s.jobs, id)
+		}
+	}
+	if s.jobs == nil {
+		s.jobs = make(map[string]*verifyJob)
+	}
+	s.jobs[job.ID] = job
+}
+
+func (s *verifyJobs) update(id string, fn func(job *verifyJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+// get returns a copy of the job unless it is unknown or past its retention.
+func (s *verifyJobs) get(id string, now time.Time) (verifyJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return verifyJob{}, false
+	}
+	if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > verifyJobRetention {
+		delete(s.jobs, id)
+		return verifyJob{}, false
+	}
+	out := *job
+	out.Results = append([]coreauth.VerifyResult(nil), job.Results...)
+	return out, true
+}
+
+// cancelJob cancels a running job and reports whether the job exists.
+func (s *verifyJobs) cancelJob(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return false
+	}
+	if job.FinishedAt == nil && job.cancel != nil {
+		job.cancel()
+	}
+	return true
+}
+
+// startVerifyJob runs verify-invalid batch by batch in the background from
+// cursor and answers 202 with the job's id.
+func (h *Handler) startVerifyJob(c *gin.Context, providerFilter, scope string, tags []string, concurrency, batchSize, cursor int, force bool) {
+	id, err := randomHex(8)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create job: %v", err)})
+		return
+	}
+	ctx, cancel := context.WithTimeout(h.life.context(), verifyJobTimeout)
+	job := &verifyJob{
+		ID:        id,
+		Status:    "running",
+		Provider:  providerFilter,
+		Scope:     scope,
+		Tags:      tags,
+		BatchSize: batchSize,
+		StartedAt: time.Now().UTC(),
+		Cursor:    cursor,
+		Results:   []coreauth.VerifyResult{},
+		cancel:    cancel,
+	}
+	h.verifyJobs.start(job, time.Now())
+	if !h.life.goWorker(func() {
+		h.runVerifyJob(ctx, cancel, id, providerFilter, scope, tags, concurrency, batchSize, cursor, force)
+	}) {
+		cancel()
+		h.finishVerifyJob(id, fmt.Errorf("server is shutting down"))
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "server is shutting down"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "accepted", "job_id": id})
+}
+
+func (h *Handler) runVerifyJob(ctx context.Context, cancel context.CancelFunc, id, providerFilter, scope string, tags []string, concurrency, batchSize, cursor int, force bool) {
+	defer cancel()
+	var errRun error
+	for {
+		if errCtx := ctx.Err(); errCtx != nil {
+			errRun = errCtx
+			break
+		}
+		res, errBatch := h.verifyInvalidAuthBatch(ctx, providerFilter, scope, tags, concurrency, batchSize, cursor, force, nil)
+		if errBatch != nil {
+			errRun = errBatch
+			break
+		}
+		h.verifyJobs.update(id, func(job *verifyJob) {
+			job.Cursor = res.NextCursor
+			job.Total = res.Total
+			job.Done = res.Done
+			job.Checked += res.Checked
+			job.Valid += res.Valid
+			job.Invalid += res.Invalid
+			job.Errors += res.Errors
+			job.Throttled += res.Throttled
+			job.Results = append(job.Results, res.Results...)
+		})
+		// A batch whose auths all left the candidates repeats the cursor.
+		if res.Done || (res.NextCursor <= cursor && res.Left == 0) {
+			break
+		}
+		cursor = res.NextCursor
+	}
+	h.finishVerifyJob(id, errRun)
+}
+
+func (h *Handler) finishVerifyJob(id string, err error) {
+	h.verifyJobs.update(id, func(job *verifyJob) {
+		now := time.Now().UTC()
+		job.FinishedAt = &now
+		switch {
+		case err == nil:
+			job.Status = "succeeded"
+		case errors.Is(err, context.Canceled):
+			job.Status = "cancelled"
+		default:
+			job.Status = "failed"
+			job.Error = err.Error()
+		}
+	})
+}
+
+// GetVerifyJob returns the progress and per-auth results of an asynchronous
+// verify-invalid run. Above maxVerifyResultRows results only the counts are
+// returned unless details=true. With format=csv every result gathered so far
+// is exported as CSV instead.
+func (h *Handler) GetVerifyJob(c *gin.Context) {
+	csvFormat, errFormat := parseVerifyFormat(c.Query("format"))
+	if errFormat != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errFormat.Error()})
+		return
+	}
+	job, ok := h.verifyJobs.get(c.Param("id"), time.Now())
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "verify job not found"})
+		return
+	}
+	if csvFormat {
+		csvOut := h.newVerifyCSVWriter(c)
+		for _, item := range job.Results {
+			csvOut.write(item)
+		}
+		csvOut.finish()
+		return
+	}
+	if details, _ := strconv.ParseBool(c.Query("details")); !details && len(job.Results) > maxVerifyResultRows {
+		job.Results = []coreauth.VerifyResult{}
+		job.ResultsOmitted = true
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelVerifyJob cancels an asynchronous verify-invalid run; the batch in
+// flight is abandoned and the job reports the results gathered so far.
+func (h *Handler) CancelVerifyJob(c *gin.Context) {
+	if !h.verifyJobs.cancelJob(c.Param("id")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "verify job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "cancelling"})
+}
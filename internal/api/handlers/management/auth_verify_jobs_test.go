@@ -0,0 +1,92 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestVerifyInvalidAuthFiles_AsyncJob(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, authDir, "acme", 5)
+	release := make(chan struct{})
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("acme", coreauth.ProbeFunc(func(ctx context.Context, auth *coreauth.Auth) (bool, string, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return false, "", ctx.Err()
+		}
+		return auth.ID == "acme-03.json", "revoked", nil
+	}))
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager}
+	h.SetInspector(inspector)
+	defer func() { _ = h.Stop(context.Background()) }()
+
+	serve := func(method, target string, handler gin.HandlerFunc, id string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(method, target, nil)
+		if id != "" {
+			c.Params = gin.Params{{Key: "id", Value: id}}
+		}
+		handler(c)
+		return rec
+	}
+	startJob := func() string {
+		t.Helper()
+		rec := serve(http.MethodPost, "/v0/management/auth-files/verify-invalid?provider=acme&batch_size=2&async=true", h.VerifyInvalidAuthFiles, "")
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("async verify: status %d body=%s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			JobID string `json:"job_id"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.JobID == "" {
+			t.Fatalf("async verify response = %s", rec.Body.String())
+		}
+		return resp.JobID
+	}
+	getJob := func(id string) (int, verifyJob) {
+		rec := serve(http.MethodGet, "/v0/management/auth-files/verify-jobs/"+id, h.GetVerifyJob, id)
+		var job verifyJob
+		_ = json.Unmarshal(rec.Body.Bytes(), &job)
+		return rec.Code, job
+	}
+
+	// A cancelled job keeps what it gathered and stops.
+	cancelled := startJob()
+	if rec := serve(http.MethodDelete, "/v0/management/auth-files/verify-jobs/"+cancelled, h.CancelVerifyJob, cancelled); rec.Code != http.StatusOK {
+		t.Fatalf("cancel: status %d", rec.Code)
+	}
+	waitFor(t, "the cancelled job", func() bool {
+		_, job := getJob(cancelled)
+		return job.Status == "cancelled"
+	})
+
+	close(release)
+	id := startJob()
+	waitFor(t, "the job", func() bool {
+		_, job := getJob(id)
+		return job.Status != "running"
+	})
+	code, job := getJob(id)
+	if code != http.StatusOK || job.Status != "succeeded" || !job.Done || job.Total != 5 || job.Cursor != 5 {
+		t.Fatalf("job = %+v", job)
+	}
+	if job.Checked != 5 || job.Valid != 4 || job.Invalid != 1 || len(job.Results) != 5 || job.Results[3].ID != "acme-03.json" || !job.Results[3].Invalid {
+		t.Fatalf("job results = %+v", job)
+	}
+
+	if code, _ := getJob("missing"); code != http.StatusNotFound {
+		t.Fatalf("unknown job: status %d", code)
+	}
+}
@@ -0,0 +1,59 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// VerifyAuthFile probes the auth named by :id right away and records the
+// outcome: an invalid token is marked invalid and sidelined like
+// DisableInvalid does, a valid one is returned to service. The probe runs on
+// a copy of the auth and takes a probe slot like any other, so a scheduled
+// inspection running at the same time is left alone.
+func (h *Handler) VerifyAuthFile(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	id := c.Param("id")
+	auth, ok := h.authManager.GetByID(id)
+	if !ok || auth == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth not found"})
+		return
+	}
+	if !h.authInspector().HasProbe(auth.Provider) {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": fmt.Sprintf("no verification probe for provider %q", auth.Provider)})
+		return
+	}
+	result, err := h.verifyAuthNow(c.Request.Context(), auth)
+	if errors.Is(err, coreauth.ErrNotProbed) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s is not probed: it is disabled, frozen or runtime-only", id)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "result": verifyResultPayload(result)})
+}
+
+// verifyAuthNow probes auth on its own, sidelines it when the probe finds it
+// invalid and counts the probe in the inspection metrics.
+func (h *Handler) verifyAuthNow(ctx context.Context, auth *coreauth.Auth) (coreauth.VerifyResult, error) {
+	result, err := h.authInspector().VerifyOne(ctx, auth, h.throttleProbe)
+	if err != nil {
+		return coreauth.VerifyResult{}, err
+	}
+	if result.Outcome == coreauth.OutcomeInvalid {
+		if _, err = h.authManager.DisableInvalid(ctx, auth.ID, result.Reason); err != nil {
+			return coreauth.VerifyResult{}, fmt.Errorf("failed to mark auth invalid: %w", err)
+		}
+	}
+	h.inspectionMetrics.probed([]coreauth.VerifyResult{result})
+	return result, nil
+}
@@ -0,0 +1,95 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func callVerifyAuthFile(h *Handler, id string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Params = gin.Params{{Key: "id", Value: id}}
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/auth-files/"+id+"/verify", nil)
+	h.VerifyAuthFile(c)
+	return rec
+}
+
+func TestVerifyAuthFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	registerInspectionFixtures(t, manager, authDir, "codex", 1)
+	registerInspectionFixtures(t, manager, authDir, "vertex", 1)
+	revoked := true
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(ctx context.Context, _ *coreauth.Auth) (bool, string, error) {
+		if revoked {
+			coreauth.RecordProbeStatus(ctx, http.StatusUnauthorized)
+			return true, "usage probe 401: token revoked", nil
+		}
+		coreauth.RecordProbeStatus(ctx, http.StatusOK)
+		return false, "", nil
+	}))
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}, authManager: manager}
+	h.SetInspector(inspector)
+
+	if rec := callVerifyAuthFile(h, "missing.json"); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing auth: status %d", rec.Code)
+	}
+	if rec := callVerifyAuthFile(h, "vertex-00.json"); rec.Code != http.StatusNotImplemented {
+		t.Fatalf("provider without probe: status %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Result struct {
+			Outcome    string `json:"outcome"`
+			StatusCode int    `json:"status_code"`
+			Reason     string `json:"reason"`
+			LatencyMs  *int64 `json:"latency_ms"`
+		} `json:"result"`
+	}
+	rec := callVerifyAuthFile(h, "codex-00.json")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("invalid auth: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Result.Outcome != coreauth.OutcomeInvalid || resp.Result.StatusCode != http.StatusUnauthorized || resp.Result.Reason == "" || resp.Result.LatencyMs == nil {
+		t.Fatalf("invalid auth: response = %s", rec.Body.String())
+	}
+	auth, _ := manager.GetByID("codex-00.json")
+	if invalid, _ := tokenInvalidState(auth); !invalid || auth.Status != coreauth.StatusError || !auth.Unavailable {
+		t.Fatalf("invalid auth: invalid = %v, status = %q, unavailable = %v", invalid, auth.Status, auth.Unavailable)
+	}
+	store.mu.Lock()
+	saved := store.items["codex-00.json"]
+	store.mu.Unlock()
+	if saved == nil || !coreauth.IsInvalidDisabled(saved) {
+		t.Fatalf("invalid mark not persisted: %+v", saved)
+	}
+
+	revoked = false
+	rec = callVerifyAuthFile(h, "codex-00.json")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("valid auth: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Result.Outcome != coreauth.OutcomeValid || resp.Result.StatusCode != http.StatusOK {
+		t.Fatalf("valid auth: response = %s", rec.Body.String())
+	}
+	auth, _ = manager.GetByID("codex-00.json")
+	if invalid, _ := tokenInvalidState(auth); invalid || auth.Status != coreauth.StatusActive || auth.Unavailable {
+		t.Fatalf("valid auth: invalid = %v, status = %q, unavailable = %v", invalid, auth.Status, auth.Unavailable)
+	}
+}
@@ -0,0 +1,61 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// uploadVerifyConcurrency bounds the background probes of uploaded auths, so
+// a bulk upload of hundreds of files does not stampede upstream.
+const uploadVerifyConcurrency = 4
+
+// uploadVerifier hands out the probe slots of uploaded auths. The zero value
+// is ready to use.
+type uploadVerifier struct {
+	once  sync.Once
+	slots chan struct{}
+}
+
+// acquire waits for a probe slot, returning false if ctx ends first.
+func (v *uploadVerifier) acquire(ctx context.Context) bool {
+	v.once.Do(func() { v.slots = make(chan struct{}, uploadVerifyConcurrency) })
+	select {
+	case v.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (v *uploadVerifier) release() {
+	<-v.slots
+}
+
+// verifyUploadedAuth queues a background probe of the auth id that was just
+// uploaded, recording the outcome like VerifyAuthFile. It reports whether a
+// probe was queued: providers without one are left to later inspections.
+func (h *Handler) verifyUploadedAuth(id string) bool {
+	auth, ok := h.authManager.GetByID(id)
+	if !ok || !h.authInspector().HasProbe(auth.Provider) {
+		return false
+	}
+	return h.life.goWorker(func() {
+		ctx := h.life.context()
+		if !h.uploadVerify.acquire(ctx) {
+			return
+		}
+		defer h.uploadVerify.release()
+		// The auth may have been replaced or deleted while it waited.
+		current, ok := h.authManager.GetByID(id)
+		if !ok {
+			return
+		}
+		if _, err := h.verifyAuthNow(ctx, current); err != nil && !errors.Is(err, coreauth.ErrNotProbed) && ctx.Err() == nil {
+			log.Warnf("auth upload: failed to verify %s: %v", id, err)
+		}
+	})
+}
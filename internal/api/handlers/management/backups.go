@@ -0,0 +1,438 @@
+package management
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/backup"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultBackupIntervalSeconds = 24 * 3600
+	minBackupIntervalSeconds     = 300
+	backupRunTimeout             = 30 * time.Minute
+)
+
+var errBackupRunning = errors.New("a backup is already running")
+
+type backupStatus struct {
+	Running         bool
+	Trigger         string
+	LastRunAt       time.Time
+	LastFinishedAt  time.Time
+	LastName        string
+	LastSize        int
+	LastFiles       int
+	LastEncrypted   bool
+	LastRedacted    bool
+	LastDestination string
+	LastPruned      []string
+	LastError       string
+	NextRunAt       time.Time
+}
+
+func (h *Handler) startBackupScheduler() {
+	if h == nil {
+		return
+	}
+	h.backupMu.Lock()
+	if h.backupTrigger == nil {
+		h.backupTrigger = make(chan string, 1)
+	}
+	h.backupMu.Unlock()
+
+	h.life.goWorker(h.backupSchedulerLoop)
+}
+
+func (h *Handler) effectiveBackupConfig() config.BackupConfig {
+	cfg := config.BackupConfig{}
+	if h != nil && h.cfg != nil {
+		cfg = h.cfg.Backup
+	}
+	if cfg.IntervalSeconds <= 0 {
+		cfg.IntervalSeconds = defaultBackupIntervalSeconds
+	}
+	if cfg.IntervalSeconds < minBackupIntervalSeconds {
+		cfg.IntervalSeconds = minBackupIntervalSeconds
+	}
+	cfg.Cron = strings.TrimSpace(cfg.Cron)
+	return cfg
+}
+
+// nextBackupRun returns when the next scheduled backup is due after now, or
+// the zero time when scheduled backups are off or the cron is invalid.
+func nextBackupRun(cfg config.BackupConfig, now time.Time) time.Time {
+	if !cfg.Enabled {
+		return time.Time{}
+	}
+	if cfg.Cron != "" {
+		schedule, err := backup.ParseCron(cfg.Cron)
+		if err != nil {
+			return time.Time{}
+		}
+		return schedule.Next(now)
+	}
+	return now.Add(time.Duration(cfg.IntervalSeconds) * time.Second)
+}
+
+func (h *Handler) backupSchedulerLoop() {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	nextRun := time.Time{}
+	schedule := ""
+	for {
+		select {
+		case <-h.life.stopping():
+			return
+		case <-ticker.C:
+		}
+		select {
+		case trigger := <-h.backupTrigger:
+			if err := h.runBackup(trigger); err != nil {
+				log.Warnf("backup (%s) failed: %v", trigger, err)
+			}
+		default:
+		}
+
+		cfg := h.effectiveBackupConfig()
+		// Recompute the schedule whenever the config changes.
+		key := fmt.Sprintf("%t|%s|%d", cfg.Enabled, cfg.Cron, cfg.IntervalSeconds)
+		if key != schedule {
+			schedule = key
+			nextRun = nextBackupRun(cfg, time.Now())
+			h.updateBackupNextRun(nextRun)
+		}
+		if nextRun.IsZero() || time.Now().Before(nextRun) {
+			continue
+		}
+		if err := h.runBackup("scheduled"); err != nil {
+			log.Warnf("scheduled backup failed: %v", err)
+		}
+		nextRun = nextBackupRun(cfg, time.Now())
+		h.updateBackupNextRun(nextRun)
+	}
+}
+
+func (h *Handler) updateBackupNextRun(next time.Time) {
+	h.backupMu.Lock()
+	h.backupStatus.NextRunAt = next
+	h.backupMu.Unlock()
+}
+
+func (h *Handler) backupDestination() (backup.Destination, error) {
+	cfg := h.effectiveBackupConfig()
+	if strings.TrimSpace(cfg.Destination.Type) == "" && strings.TrimSpace(cfg.Destination.Path) == "" {
+		return nil, errors.New("backup destination is not configured")
+	}
+	return backup.NewDestination(cfg.Destination)
+}
+
+// collectBackupFiles reads every registered file-backed auth from disk.
+func (h *Handler) collectBackupFiles() ([]backup.File, error) {
+	if h.authManager == nil {
+		return nil, errors.New("core auth manager unavailable")
+	}
+	seen := make(map[string]struct{})
+	var files []backup.File
+	for _, auth := range h.authManager.List() {
+		path, ok := h.resolveAuthFilePath(auth)
+		if !ok {
+			continue
+		}
+		name := filepath.Base(path)
+		if _, dup := seen[name]; dup {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		seen[name] = struct{}{}
+		files = append(files, backup.File{Name: name, Provider: auth.Provider, Data: data})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+// runBackup archives the registered auth files, uploads the archive and
+// prunes old archives. The outcome is recorded in backupStatus.
+func (h *Handler) runBackup(trigger string) error {
+	h.backupMu.Lock()
+	if h.backupStatus.Running {
+		h.backupMu.Unlock()
+		return errBackupRunning
+	}
+	h.backupStatus.Running = true
+	h.backupStatus.Trigger = strings.TrimSpace(trigger)
+	h.backupStatus.LastRunAt = time.Now()
+	h.backupMu.Unlock()
+
+	cfg := h.effectiveBackupConfig()
+	var (
+		name     string
+		data     []byte
+		manifest backup.Manifest
+		pruned   []string
+		target   string
+	)
+	err := func() error {
+		dest, err := h.backupDestination()
+		if err != nil {
+			return err
+		}
+		target = dest.String()
+		files, err := h.collectBackupFiles()
+		if err != nil {
+			return err
+		}
+		name, data, manifest, err = backup.Build(files, backup.Options{Passphrase: cfg.Passphrase, AllowPlaintext: cfg.AllowPlaintext})
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(h.life.context(), backupRunTimeout)
+		defer cancel()
+		if err = dest.Put(ctx, name, data); err != nil {
+			return fmt.Errorf("upload %s: %w", name, err)
+		}
+		if pruned, err = backup.Prune(ctx, dest, cfg.Retention, name); err != nil {
+			return fmt.Errorf("prune: %w", err)
+		}
+		return nil
+	}()
+
+	h.backupMu.Lock()
+	h.backupStatus.Running = false
+	h.backupStatus.LastFinishedAt = time.Now()
+	h.backupStatus.LastDestination = target
+	h.backupStatus.LastError = ""
+	if err != nil {
+		h.backupStatus.LastError = err.Error()
+	} else {
+		h.backupStatus.LastName = name
+		h.backupStatus.LastSize = len(data)
+		h.backupStatus.LastFiles = len(manifest.Files)
+		h.backupStatus.LastEncrypted = manifest.Encrypted
+		h.backupStatus.LastRedacted = manifest.Redacted
+		h.backupStatus.LastPruned = pruned
+	}
+	h.backupMu.Unlock()
+	if err == nil && manifest.Redacted {
+		log.Warn("backup: no passphrase configured, token fields were redacted; the archive cannot be restored")
+	}
+	return err
+}
+
+func (h *Handler) backupStatusPayload() gin.H {
+	cfg := h.effectiveBackupConfig()
+	h.backupMu.RLock()
+	state := h.backupStatus
+	h.backupMu.RUnlock()
+
+	payload := gin.H{
+		"enabled":          cfg.Enabled,
+		"interval_seconds": cfg.IntervalSeconds,
+		"cron":             cfg.Cron,
+		"retention":        cfg.Retention,
+		"encrypted":        cfg.Passphrase != "",
+		"allow_plaintext":  cfg.AllowPlaintext,
+		"running":          state.Running,
+		"trigger":          state.Trigger,
+		"last_run_at":      state.LastRunAt,
+		"last_finished_at": state.LastFinishedAt,
+		"last_name":        state.LastName,
+		"last_size":        state.LastSize,
+		"last_files":       state.LastFiles,
+		"last_encrypted":   state.LastEncrypted,
+		"last_redacted":    state.LastRedacted,
+		"last_destination": state.LastDestination,
+		"last_pruned":      state.LastPruned,
+		"last_error":       state.LastError,
+		"next_run_at":      state.NextRunAt,
+	}
+	if cfg.Cron != "" {
+		if _, err := backup.ParseCron(cfg.Cron); err != nil {
+			payload["cron_error"] = err.Error()
+		}
+	}
+	if dest, err := h.backupDestination(); err == nil {
+		payload["destination"] = dest.String()
+	} else {
+		payload["destination_error"] = err.Error()
+	}
+	return payload
+}
+
+// GetBackupStatus reports the backup schedule and the last run's outcome.
+func (h *Handler) GetBackupStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "backup": h.backupStatusPayload()})
+}
+
+// ListBackups lists the archives stored at the destination, oldest first.
+func (h *Handler) ListBackups(c *gin.Context) {
+	dest, err := h.backupDestination()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	objects, err := dest.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("failed to list backups: %v", err)})
+		return
+	}
+	if objects == nil {
+		objects = []backup.Object{}
+	}
+	c.JSON(http.StatusOK, gin.H{"destination": dest.String(), "backups": objects})
+}
+
+// RunBackupNow queues an immediate backup.
+func (h *Handler) RunBackupNow(c *gin.Context) {
+	h.backupMu.RLock()
+	running := h.backupStatus.Running
+	trigger := h.backupTrigger
+	h.backupMu.RUnlock()
+	if running {
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "started": false, "reason": "backup already running", "backup": h.backupStatusPayload()})
+		return
+	}
+	if trigger == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "backup scheduler unavailable"})
+		return
+	}
+	if _, err := h.backupDestination(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	principal, _ := principalFromContext(c)
+	select {
+	case trigger <- "manual:" + principal.Name:
+	default:
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "started": false, "reason": "backup trigger queue is busy", "backup": h.backupStatusPayload()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "started": true, "backup": h.backupStatusPayload()})
+}
+
+type backupRestoreItem struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider,omitempty"`
+	Action   string `json:"action"`
+	Error    string `json:"error,omitempty"`
+}
+
+// RestoreBackup writes the auth files of an archive back into the auth
+// directory and registers them. With dry_run it only reports what would change.
+func (h *Handler) RestoreBackup(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	var req struct {
+		Name       string `json:"name"`
+		DryRun     bool   `json:"dry_run"`
+		Passphrase string `json:"passphrase"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if !backup.IsArchiveName(name) || filepath.Base(name) != name {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must be a backup archive name"})
+		return
+	}
+	dest, err := h.backupDestination()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	data, err := dest.Get(c.Request.Context(), name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("failed to fetch backup: %v", err)})
+		return
+	}
+	passphrase := req.Passphrase
+	if passphrase == "" {
+		passphrase = h.effectiveBackupConfig().Passphrase
+	}
+	manifest, files, err := backup.Open(name, data, passphrase)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if manifest.Redacted {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": backup.ErrRedacted.Error()})
+		return
+	}
+
+	items := make([]backupRestoreItem, 0, len(files))
+	restored, failed := 0, 0
+	for _, f := range files {
+		item := backupRestoreItem{Name: f.Name, Provider: f.Provider}
+		if errValidate := validateSyncedAuthFile(f.Name, f.Provider, f.Data); errValidate != nil {
+			item.Action, item.Error = "skip", errValidate.Error()
+			failed++
+			items = append(items, item)
+			continue
+		}
+		existing, errRead := os.ReadFile(filepath.Join(h.cfg.AuthDir, f.Name))
+		switch {
+		case errRead != nil:
+			item.Action = "create"
+		case bytes.Equal(existing, f.Data):
+			item.Action = "unchanged"
+		default:
+			item.Action = "overwrite"
+		}
+		if !req.DryRun {
+			if errWrite := h.writeSyncedAuthFile(c.Request.Context(), f.Name, f.Data); errWrite != nil {
+				item.Error = errWrite.Error()
+				failed++
+				items = append(items, item)
+				continue
+			}
+			restored++
+		}
+		items = append(items, item)
+	}
+
+	if !req.DryRun {
+		principal, _ := principalFromContext(c)
+		h.recordAudit(auditEntry{
+			Actor:      principal.Name,
+			Role:       string(principal.Role),
+			Source:     principal.Source,
+			ClientIP:   h.managementClientIP(c),
+			ClientCert: principal.ClientCert,
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			Status:     http.StatusOK,
+			Action:     "backup_restored",
+			Detail:     fmt.Sprintf("name=%s destination=%s restored=%d failed=%d", name, dest.String(), restored, failed),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "ok",
+		"name":       name,
+		"created_at": manifest.CreatedAt,
+		"dry_run":    req.DryRun,
+		"restored":   restored,
+		"failed":     failed,
+		"files":      items,
+	})
+}
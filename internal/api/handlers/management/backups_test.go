@@ -0,0 +1,105 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestBackupRunAndRestore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	backupDir := t.TempDir()
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	h := &Handler{
+		cfg: &config.Config{AuthDir: authDir, Backup: config.BackupConfig{
+			Passphrase:  "hunter2",
+			Retention:   1,
+			Destination: config.BackupDestination{Type: "local", Path: backupDir},
+		}},
+		authManager: manager,
+		tokenStore:  &memoryAuthStore{},
+	}
+	original := `{"type":"codex","email":"a@example.com","refresh_token":"rt-secret"}`
+	path := filepath.Join(authDir, "codex-a.json")
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatalf("write auth: %v", err)
+	}
+	if err := h.registerAuthFromFile(context.Background(), path, []byte(original)); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+
+	if err := h.runBackup("test"); err != nil {
+		t.Fatalf("run backup: %v", err)
+	}
+	status := h.backupStatusPayload()
+	name, _ := status["last_name"].(string)
+	if status["last_error"] != "" || status["last_files"] != 1 || status["last_encrypted"] != true || !strings.HasSuffix(name, ".enc") {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	archive, err := os.ReadFile(filepath.Join(backupDir, name))
+	if err != nil {
+		t.Fatalf("read archive: %v", err)
+	}
+	if strings.Contains(string(archive), "rt-secret") {
+		t.Fatal("archive leaks refresh token")
+	}
+
+	if err = os.WriteFile(path, []byte(`{"type":"codex","email":"a@example.com","refresh_token":"changed"}`), 0o600); err != nil {
+		t.Fatalf("overwrite auth: %v", err)
+	}
+	restore := func(body string) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/backups/restore", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.RestoreBackup(c)
+		var resp map[string]any
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	code, resp := restore(`{"name":"` + name + `","dry_run":true}`)
+	files, _ := resp["files"].([]any)
+	if code != http.StatusOK || len(files) != 1 || files[0].(map[string]any)["action"] != "overwrite" {
+		t.Fatalf("dry run: %d %+v", code, resp)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "rt-secret") {
+		t.Fatal("dry run wrote the file")
+	}
+
+	if code, resp = restore(`{"name":"` + name + `","passphrase":"wrong"}`); code != http.StatusUnprocessableEntity {
+		t.Fatalf("wrong passphrase: %d %+v", code, resp)
+	}
+	if code, resp = restore(`{"name":"` + name + `"}`); code != http.StatusOK || resp["restored"] != float64(1) {
+		t.Fatalf("restore: %d %+v", code, resp)
+	}
+	if data, _ := os.ReadFile(path); string(data) != original {
+		t.Fatalf("restored content = %s", data)
+	}
+	if _, ok := manager.GetByID("codex-a.json"); !ok {
+		t.Fatal("restored auth not registered")
+	}
+
+	// Without a passphrase the archive is redacted and refused on restore.
+	h.cfg.Backup.Passphrase = ""
+	if err = h.runBackup("test"); err != nil {
+		t.Fatalf("run redacted backup: %v", err)
+	}
+	status = h.backupStatusPayload()
+	if status["last_redacted"] != true || len(status["last_pruned"].([]string)) != 1 {
+		t.Fatalf("unexpected redacted status: %+v", status)
+	}
+	if code, resp = restore(`{"name":"` + status["last_name"].(string) + `"}`); code != http.StatusUnprocessableEntity {
+		t.Fatalf("redacted restore: %d %+v", code, resp)
+	}
+}
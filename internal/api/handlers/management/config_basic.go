@@ -182,8 +182,29 @@ func (h *Handler) GetConfigYAML(c *gin.Context) {
 }
 
 // Debug
-func (h *Handler) GetDebug(c *gin.Context) { c.JSON(200, gin.H{"debug": h.cfg.Debug}) }
-func (h *Handler) PutDebug(c *gin.Context) { h.updateBoolField(c, func(v bool) { h.cfg.Debug = v }) }
+func (h *Handler) GetDebug(c *gin.Context) {
+	c.JSON(200, gin.H{"debug": h.cfg.Debug, "pprof-enabled": h.cfg.DebugPprofEnabled})
+}
+
+// PutDebug accepts {"value": bool} for debug logging and/or {"pprof-enabled": bool}
+// to toggle the management pprof endpoints.
+func (h *Handler) PutDebug(c *gin.Context) {
+	var body struct {
+		Value        *bool `json:"value"`
+		PprofEnabled *bool `json:"pprof-enabled"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || (body.Value == nil && body.PprofEnabled == nil) {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	if body.Value != nil {
+		h.cfg.Debug = *body.Value
+	}
+	if body.PprofEnabled != nil {
+		h.cfg.DebugPprofEnabled = *body.PprofEnabled
+	}
+	h.persist(c)
+}
 
 // UsageStatisticsEnabled
 func (h *Handler) GetUsageStatisticsEnabled(c *gin.Context) {
@@ -0,0 +1,99 @@
+package management
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const managementCORSMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+
+var defaultManagementCORSHeaders = []string{"Authorization", "Content-Type", "X-Management-Key"}
+
+// CORSEnabled reports whether remote-management.cors has allowed origins configured.
+func CORSEnabled(cfg *config.Config) bool {
+	return cfg != nil && len(cfg.RemoteManagement.CORS.AllowedOrigins) > 0
+}
+
+// corsOriginAllowed matches origin against exact entries, "*" and wildcard
+// subdomain entries such as "https://*.example.com".
+func corsOriginAllowed(allowed []string, origin string) bool {
+	origin = strings.TrimRight(strings.ToLower(strings.TrimSpace(origin)), "/")
+	if origin == "" {
+		return false
+	}
+	for _, entry := range allowed {
+		pattern := strings.TrimRight(strings.ToLower(strings.TrimSpace(entry)), "/")
+		switch {
+		case pattern == "":
+			continue
+		case pattern == "*":
+			return true
+		case pattern == origin:
+			return true
+		}
+		scheme, host, ok := strings.Cut(pattern, "://*.")
+		if !ok {
+			continue
+		}
+		prefix := scheme + "://"
+		if !strings.HasPrefix(origin, prefix) {
+			continue
+		}
+		originHost := strings.TrimPrefix(origin, prefix)
+		if strings.HasSuffix(originHost, "."+host) && len(originHost) > len(host)+1 {
+			return true
+		}
+	}
+	return false
+}
+
+// CORSMiddleware applies remote-management.cors to management routes. Preflight
+// requests are answered here, before authentication; actual requests only get
+// CORS headers and still require a management key. With no allowed origins the
+// middleware is a no-op.
+func (h *Handler) CORSMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := h.cfg
+		if !CORSEnabled(cfg) {
+			c.Next()
+			return
+		}
+		cors := cfg.RemoteManagement.CORS
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		c.Writer.Header().Add("Vary", "Origin")
+
+		if origin == "" || !corsOriginAllowed(cors.AllowedOrigins, origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		if cors.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			c.Next()
+			return
+		}
+
+		headers := cors.AllowedHeaders
+		if len(headers) == 0 {
+			headers = defaultManagementCORSHeaders
+		}
+		c.Header("Access-Control-Allow-Methods", managementCORSMethods)
+		c.Header("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+		if cors.MaxAge > 0 {
+			c.Header("Access-Control-Max-Age", strconv.Itoa(cors.MaxAge))
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
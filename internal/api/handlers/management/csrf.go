@@ -0,0 +1,126 @@
+package management
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const (
+	// sessionCookieName carries the session token for browser clients. It is
+	// HttpOnly and SameSite=Strict.
+	sessionCookieName = "cpa_session"
+	// csrfCookieName exposes the session's CSRF token to the WebUI script.
+	csrfCookieName = "cpa_csrf"
+	// csrfHeaderName must echo the CSRF token on unsafe cookie-authenticated requests.
+	csrfHeaderName = "X-CSRF-Token"
+
+	managementCookiePath = "/v0/management"
+)
+
+func setManagementCookie(c *gin.Context, name, value string, maxAge int, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     managementCookiePath,
+		MaxAge:   maxAge,
+		HttpOnly: httpOnly,
+		Secure:   c.Request.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// setSessionCookies stores the session token and its CSRF token as browser
+// session cookies; the server-side session decides expiry.
+func setSessionCookies(c *gin.Context, token, csrf string) {
+	setManagementCookie(c, sessionCookieName, token, 0, true)
+	setManagementCookie(c, csrfCookieName, csrf, 0, false)
+}
+
+func clearSessionCookies(c *gin.Context) {
+	setManagementCookie(c, sessionCookieName, "", -1, true)
+	setManagementCookie(c, csrfCookieName, "", -1, false)
+}
+
+// sessionTokenFromCookie returns the session token carried by the session cookie.
+func sessionTokenFromCookie(c *gin.Context) string {
+	token, err := c.Cookie(sessionCookieName)
+	if err != nil || !isSessionToken(token) {
+		return ""
+	}
+	return token
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// sameOrigin reports whether the request's Origin, or failing that its Referer,
+// matches the host serving the request or an allowed management CORS origin.
+// Requests carrying neither header are left to the token check.
+func sameOrigin(r *http.Request, cfg *config.Config) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		referer := r.Header.Get("Referer")
+		if referer == "" {
+			return origin == ""
+		}
+		parsed, err := url.Parse(referer)
+		if err != nil {
+			return false
+		}
+		origin = parsed.Scheme + "://" + parsed.Host
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	if strings.EqualFold(parsed.Host, r.Host) {
+		return true
+	}
+	return CORSEnabled(cfg) && corsOriginAllowed(cfg.RemoteManagement.CORS.AllowedOrigins, origin)
+}
+
+// checkCSRF protects unsafe requests authenticated by the session cookie. It
+// aborts with 403 and a machine-readable code on failure.
+func (h *Handler) checkCSRF(c *gin.Context, cfg *config.Config, sessionID string) bool {
+	if isSafeMethod(c.Request.Method) {
+		return true
+	}
+	if !sameOrigin(c.Request, cfg) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "cross-origin request rejected", "code": "csrf_origin_mismatch"})
+		return false
+	}
+	expected, ok := h.sessions.csrf(sessionID)
+	provided := c.GetHeader(csrfHeaderName)
+	if !ok || provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) != 1 {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing or invalid CSRF token", "code": "csrf_token_invalid"})
+		return false
+	}
+	return true
+}
+
+// GetCSRFToken returns the CSRF token of the current session and refreshes the CSRF cookie.
+func (h *Handler) GetCSRFToken(c *gin.Context) {
+	principal, _ := principalFromContext(c)
+	if principal.SessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "request is not authenticated with a session"})
+		return
+	}
+	token, ok := h.sessions.csrf(principal.SessionID)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired session"})
+		return
+	}
+	setManagementCookie(c, csrfCookieName, token, 0, false)
+	c.JSON(http.StatusOK, gin.H{"csrf_token": token})
+}
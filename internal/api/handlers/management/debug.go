@@ -0,0 +1,110 @@
+package management
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// processStartedAt approximates process start for the runtime uptime report.
+var processStartedAt = time.Now()
+
+// DebugPprof serves net/http/pprof profiles under /debug/pprof/*profile.
+// It responds 404 unless debug-pprof-enabled is set.
+func (h *Handler) DebugPprof(c *gin.Context) {
+	if h == nil || h.cfg == nil || !h.cfg.DebugPprofEnabled {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	name := strings.Trim(c.Param("profile"), "/")
+	switch name {
+	case "":
+		// pprof.Index derives the profile name from a /debug/pprof/ prefix; the
+		// index itself uses relative links, so it renders correctly under our prefix.
+		req := c.Request.Clone(c.Request.Context())
+		req.URL.Path = "/debug/pprof/"
+		pprof.Index(c.Writer, req)
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Handler(name).ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// GetDebugRuntime returns a lightweight snapshot of process runtime statistics.
+func (h *Handler) GetDebugRuntime(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	recent := int(mem.NumGC)
+	if recent > len(mem.PauseNs) {
+		recent = len(mem.PauseNs)
+	}
+	var recentTotal, recentMax uint64
+	for i := 0; i < recent; i++ {
+		pause := mem.PauseNs[(int(mem.NumGC)-1-i+len(mem.PauseNs))%len(mem.PauseNs)]
+		recentTotal += pause
+		if pause > recentMax {
+			recentMax = pause
+		}
+	}
+	var recentAvg uint64
+	if recent > 0 {
+		recentAvg = recentTotal / uint64(recent)
+	}
+	var lastGC time.Time
+	if mem.LastGC > 0 {
+		lastGC = time.Unix(0, int64(mem.LastGC))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"goroutines":     runtime.NumGoroutine(),
+		"num_cpu":        runtime.NumCPU(),
+		"gomaxprocs":     runtime.GOMAXPROCS(0),
+		"go_version":     runtime.Version(),
+		"open_fds":       openFileDescriptorCount(),
+		"uptime_seconds": int64(time.Since(processStartedAt).Seconds()),
+		"started_at":     processStartedAt,
+		"heap": gin.H{
+			"alloc_bytes":    mem.HeapAlloc,
+			"inuse_bytes":    mem.HeapInuse,
+			"idle_bytes":     mem.HeapIdle,
+			"released_bytes": mem.HeapReleased,
+			"sys_bytes":      mem.HeapSys,
+			"objects":        mem.HeapObjects,
+			"total_sys":      mem.Sys,
+		},
+		"gc": gin.H{
+			"num_gc":              mem.NumGC,
+			"last_gc":             lastGC,
+			"pause_total_ns":      mem.PauseTotalNs,
+			"recent_pauses":       recent,
+			"recent_pause_avg_ns": recentAvg,
+			"recent_pause_max_ns": recentMax,
+			"next_gc_bytes":       mem.NextGC,
+		},
+	})
+}
+
+// openFileDescriptorCount returns the number of open descriptors, or -1 when the
+// platform does not expose them through /proc or /dev/fd.
+func openFileDescriptorCount() int {
+	for _, dir := range []string{"/proc/self/fd", "/dev/fd"} {
+		entries, err := os.ReadDir(dir)
+		if err == nil {
+			return len(entries)
+		}
+	}
+	return -1
+}
@@ -3,6 +3,7 @@
 package management
 
 import (
+	"context"
 	"crypto/subtle"
 	"fmt"
 	"net/http"
@@ -13,33 +14,23 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/alerts"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 )
 
-type attemptInfo struct {
-	count        int
-	blockedUntil time.Time
-	lastActivity time.Time // track last activity for cleanup
-}
-
-// attemptCleanupInterval controls how often stale IP entries are purged
-const attemptCleanupInterval = 1 * time.Hour
-
-// attemptMaxIdleTime controls how long an IP can be idle before cleanup
-const attemptMaxIdleTime = 2 * time.Hour
-
 // Handler aggregates config reference, persistence path and helpers.
 type Handler struct {
 	cfg                 *config.Config
 	configFilePath      string
 	mu                  sync.Mutex
 	attemptsMu          sync.Mutex
-	failedAttempts      map[string]*attemptInfo // keyed by client IP
+	failedAttempts      map[string]*attemptInfo // keyed by attemptKeyForIP / attemptKeyForPresentedKey
 	authManager         *coreauth.Manager
 	usageStats          *usage.RequestStatistics
 	tokenStore          coreauth.Store
@@ -50,7 +41,50 @@ type Handler struct {
 
 	inspectionMu      sync.RWMutex
 	inspectionStatus  authInspectionStatus
-	inspectionTrigger chan string
+	inspectionTrigger chan inspectionRequest
+	inspectionLeader  bool // holds the scheduler lease of a shared token store
+	inspectionRuns    inspectionRunHistory
+	inspectionCancel  context.CancelFunc // cancels the running inspection, if any
+	inspectionEvents  inspectionEventHub // streams progress to GET /auth-inspection/events
+	inspectionWake    chan struct{}      // wakes the scheduler loop after config or schedule changes
+	inspectionClock   schedulerClock     // nil uses the wall clock
+	probeLimiter      probeRateLimiter   // paces every probe at probe-rate-per-minute
+	probePause        probePause         // holds every probe back after a 429 with Retry-After
+	inspectionMetrics inspectionMetrics  // served by GetMetrics
+
+	inspectorMu sync.Mutex
+	inspector   *coreauth.Inspector // verifies and deletes auths; see SetInspector
+
+	backupMu      sync.RWMutex
+	backupStatus  backupStatus
+	backupTrigger chan string
+
+	alertsOnce   sync.Once
+	alertsEngine *alerts.Engine
+
+	audit          auditLog
+	userKeyCacheMu sync.Mutex
+	userKeyCache   map[string]string // sha256(key) -> bcrypt hash of the matching user
+
+	allowedNetworks networkListCache
+	trustedProxies  networkListCache
+	ipRejected      ipRejections
+
+	sessions sessionStore
+
+	totpMu       sync.Mutex
+	totpLastStep map[string]int64 // last accepted TOTP step per user, prevents replay
+
+	tokenActivity tokenActivity
+	authSyncJobs  authSyncJobs
+	verifyJobs    verifyJobs
+	verifyCache   verifyResultCache
+	uploadVerify  uploadVerifier
+	authDeleteMu  sync.Mutex // makes each file of a batch deletion atomic
+
+	hookDeliveries hookDeliveries
+
+	life lifecycle // background workers, ended by Stop
 }
 
 // NewHandler creates a new management handler instance.
@@ -68,48 +102,35 @@ func NewHandler(cfg *config.Config, configFilePath string, manager *coreauth.Man
 		allowRemoteOverride: envSecret != "",
 		envSecret:           envSecret,
 	}
+	if err := h.inspectionRuns.load(inspectionHistoryPath(configFilePath)); err != nil {
+		log.Warnf("failed to load auth inspection history: %v", err)
+	}
 	h.startAttemptCleanup()
 	h.startAuthInspectionScheduler()
+	h.startBackupScheduler()
+	h.startAlertEvaluator()
 	return h
 }
 
-// startAttemptCleanup launches a background goroutine that periodically
-// removes stale IP entries from failedAttempts to prevent memory leaks.
-func (h *Handler) startAttemptCleanup() {
-	go func() {
-		ticker := time.NewTicker(attemptCleanupInterval)
-		defer ticker.Stop()
-		for range ticker.C {
-			h.purgeStaleAttempts()
-		}
-	}()
-}
-
-// purgeStaleAttempts removes IP entries that have been idle beyond attemptMaxIdleTime
-// and whose ban (if any) has expired.
-func (h *Handler) purgeStaleAttempts() {
-	now := time.Now()
-	h.attemptsMu.Lock()
-	defer h.attemptsMu.Unlock()
-	for ip, ai := range h.failedAttempts {
-		// Skip if still banned
-		if !ai.blockedUntil.IsZero() && now.Before(ai.blockedUntil) {
-			continue
-		}
-		// Remove if idle too long
-		if now.Sub(ai.lastActivity) > attemptMaxIdleTime {
-			delete(h.failedAttempts, ip)
-		}
-	}
-}
-
 // NewHandler creates a new management handler instance.
 func NewHandlerWithoutConfigFilePath(cfg *config.Config, manager *coreauth.Manager) *Handler {
 	return NewHandler(cfg, "", manager)
 }
 
 // SetConfig updates the in-memory config reference when the server hot-reloads.
-func (h *Handler) SetConfig(cfg *config.Config) { h.cfg = cfg }
+// A changed inspection schedule restarts from now, as a PUT of the
+// inspection config does.
+func (h *Handler) SetConfig(cfg *config.Config) {
+	var old config.AuthInspectionConfig
+	if h.cfg != nil {
+		old = h.cfg.AuthInspection
+	}
+	h.cfg = cfg
+	if cfg != nil && inspectionScheduleChanged(old, cfg.AuthInspection) {
+		h.rescheduleAuthInspection(h.effectiveAuthInspectionConfig(), nil, h.schedulerClock().Now())
+	}
+	h.wakeAuthInspectionScheduler()
+}
 
 // SetAuthManager updates the auth manager reference used by management endpoints.
 func (h *Handler) SetAuthManager(manager *coreauth.Manager) { h.authManager = manager }
@@ -133,144 +154,193 @@ func (h *Handler) SetLogDirectory(dir string) {
 	h.logDir = dir
 }
 
-// Middleware enforces access control for management endpoints.
-// All requests (local and remote) require a valid management key.
-// Additionally, remote access requires allow-remote-management=true.
-func (h *Handler) Middleware() gin.HandlerFunc {
-	const maxFailures = 5
-	const banDuration = 30 * time.Minute
+// managementRequest carries per-request state shared by Middleware and Login.
+type managementRequest struct {
+	cfg         *config.Config
+	clientIP    string
+	localClient bool
+	ipKey       string
+	clientCert  string // verified client certificate identity, when mTLS is enabled
+}
 
-	return func(c *gin.Context) {
-		c.Header("X-CPA-VERSION", buildinfo.Version)
-		c.Header("X-CPA-COMMIT", buildinfo.Commit)
-		c.Header("X-CPA-BUILD-DATE", buildinfo.BuildDate)
-
-		clientIP := c.ClientIP()
-		localClient := clientIP == "127.0.0.1" || clientIP == "::1"
-		cfg := h.cfg
-		var (
-			allowRemote bool
-			secretHash  string
-		)
-		if cfg != nil {
-			allowRemote = cfg.RemoteManagement.AllowRemote
-			secretHash = cfg.RemoteManagement.SecretKey
+// admitManagementRequest runs the checks that precede credential inspection:
+// version headers, network allow-list, lockouts and the remote-access switch.
+// It aborts the request and returns false when any of them fails.
+func (h *Handler) admitManagementRequest(c *gin.Context) (managementRequest, bool) {
+	c.Header("X-CPA-VERSION", buildinfo.Version)
+	c.Header("X-CPA-COMMIT", buildinfo.Commit)
+	c.Header("X-CPA-BUILD-DATE", buildinfo.BuildDate)
+
+	req := managementRequest{cfg: h.cfg}
+	if !h.checkManagementNetwork(c, req.cfg) {
+		return req, false
+	}
+	clientCert, okCert := h.checkClientCertificate(c, req.cfg)
+	if !okCert {
+		return req, false
+	}
+	req.clientCert = clientCert
+
+	req.clientIP = h.managementClientIP(c)
+	req.localClient = req.clientIP == "127.0.0.1" || req.clientIP == "::1"
+	req.ipKey = attemptKeyForIP(req.clientIP)
+	var (
+		allowRemote bool
+		secretHash  string
+	)
+	if req.cfg != nil {
+		allowRemote = req.cfg.RemoteManagement.AllowRemote
+		secretHash = req.cfg.RemoteManagement.SecretKey
+	}
+	if h.allowRemoteOverride {
+		allowRemote = true
+	}
+
+	if !req.localClient {
+		if h.abortIfLockedOut(c, req.ipKey) {
+			return req, false
 		}
-		if h.allowRemoteOverride {
-			allowRemote = true
+		if !allowRemote {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "remote management disabled"})
+			return req, false
 		}
-		envSecret := h.envSecret
-
-		fail := func() {}
-		if !localClient {
-			h.attemptsMu.Lock()
-			ai := h.failedAttempts[clientIP]
-			if ai != nil {
-				if !ai.blockedUntil.IsZero() {
-					if time.Now().Before(ai.blockedUntil) {
-						remaining := time.Until(ai.blockedUntil).Round(time.Second)
-						h.attemptsMu.Unlock()
-						c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("IP banned due to too many failed attempts. Try again in %s", remaining)})
-						return
-					}
-					// Ban expired, reset state
-					ai.blockedUntil = time.Time{}
-					ai.count = 0
-				}
-			}
-			h.attemptsMu.Unlock()
+	}
+	if secretHash == "" && h.envSecret == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "remote management key not set"})
+		return req, false
+	}
+	return req, true
+}
 
-			if !allowRemote {
-				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "remote management disabled"})
-				return
-			}
+// managementKeyFromRequest accepts either Authorization: Bearer <key> or X-Management-Key.
+func managementKeyFromRequest(c *gin.Context) string {
+	var provided string
+	if ah := c.GetHeader("Authorization"); ah != "" {
+		parts := strings.SplitN(ah, " ", 2)
+		if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
+			provided = parts[1]
+		} else {
+			provided = ah
+		}
+	}
+	if provided == "" {
+		provided = c.GetHeader("X-Management-Key")
+	}
+	return provided
+}
 
-			fail = func() {
-				h.attemptsMu.Lock()
-				aip := h.failedAttempts[clientIP]
-				if aip == nil {
-					aip = &attemptInfo{}
-					h.failedAttempts[clientIP] = aip
-				}
-				aip.count++
-				aip.lastActivity = time.Now()
-				if aip.count >= maxFailures {
-					aip.blockedUntil = time.Now().Add(banDuration)
-					aip.count = 0
-				}
-				h.attemptsMu.Unlock()
+// authenticateRawKey matches provided against the local password, the
+// MANAGEMENT_PASSWORD environment secret, the configured secret-key and the
+// management users, in that order.
+func (h *Handler) authenticateRawKey(req managementRequest, provided string) (managementPrincipal, bool) {
+	if req.localClient {
+		if lp := h.localPassword; lp != "" {
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(lp)) == 1 {
+				return managementPrincipal{Name: "local", Role: RoleAdmin, Source: "local-password"}, true
 			}
 		}
-		if secretHash == "" && envSecret == "" {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "remote management key not set"})
+	}
+	var secretHash string
+	if req.cfg != nil {
+		secretHash = req.cfg.RemoteManagement.SecretKey
+	}
+	switch {
+	case h.envSecret != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(h.envSecret)) == 1:
+		return managementPrincipal{Name: "admin", Role: RoleAdmin, Source: "env"}, true
+	case secretHash != "" && bcrypt.CompareHashAndPassword([]byte(secretHash), []byte(provided)) == nil:
+		return managementPrincipal{Name: "admin", Role: RoleAdmin, Source: "secret-key"}, true
+	}
+	if user, ok := h.matchManagementUser(req.cfg, provided); ok {
+		return managementPrincipal{Name: user.Name, Role: Role(user.Role), Source: "user"}, true
+	}
+	return managementPrincipal{}, false
+}
+
+// Middleware enforces access control for management endpoints.
+// All requests (local and remote) require a valid management key, session token or scoped token.
+// Additionally, remote access requires allow-remote-management=true.
+// Repeated failures from a remote client lock it out with exponential backoff.
+func (h *Handler) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, ok := h.admitManagementRequest(c)
+		if !ok {
 			return
 		}
 
-		// Accept either Authorization: Bearer <key> or X-Management-Key
-		var provided string
-		if ah := c.GetHeader("Authorization"); ah != "" {
-			parts := strings.SplitN(ah, " ", 2)
-			if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
-				provided = parts[1]
-			} else {
-				provided = ah
-			}
-		}
+		provided := managementKeyFromRequest(c)
+		fromCookie := false
 		if provided == "" {
-			provided = c.GetHeader("X-Management-Key")
+			provided = sessionTokenFromCookie(c)
+			fromCookie = provided != ""
 		}
-
 		if provided == "" {
-			if !localClient {
-				fail()
-			}
+			h.registerFailedAttempt(c, req.clientIP, "", req.localClient, "missing management key")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing management key"})
 			return
 		}
 
-		if localClient {
-			if lp := h.localPassword; lp != "" {
-				if subtle.ConstantTimeCompare([]byte(provided), []byte(lp)) == 1 {
-					c.Next()
-					return
-				}
-			}
-		}
+		keyKey := attemptKeyForPresentedKey(provided)
 
-		if envSecret != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(envSecret)) == 1 {
-			if !localClient {
-				h.attemptsMu.Lock()
-				if ai := h.failedAttempts[clientIP]; ai != nil {
-					ai.count = 0
-					ai.blockedUntil = time.Time{}
-				}
-				h.attemptsMu.Unlock()
+		if isScopedToken(provided) {
+			principal, okToken := matchScopedToken(req.cfg, provided, time.Now())
+			if !okToken {
+				h.rejectCredential(c, req, provided, "invalid or expired token")
+				return
 			}
-			c.Next()
+			if !req.localClient {
+				h.resetAttempts(req.ipKey, keyKey)
+			}
+			h.tokenActivity.touch(principal.TokenID, req.clientIP, time.Now())
+			h.serveAuthorized(c, withClientCert(principal, req.clientCert))
 			return
 		}
 
-		if secretHash == "" || bcrypt.CompareHashAndPassword([]byte(secretHash), []byte(provided)) != nil {
-			if !localClient {
-				fail()
+		if isSessionToken(provided) {
+			principal, okSession := h.sessions.validate(provided, time.Now())
+			if !okSession {
+				h.rejectCredential(c, req, provided, "invalid or expired session")
+				return
 			}
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid management key"})
+			// Cookies are sent by the browser automatically; header credentials are not.
+			if fromCookie && !h.checkCSRF(c, req.cfg, principal.SessionID) {
+				return
+			}
+			if !req.localClient {
+				h.resetAttempts(req.ipKey, keyKey)
+			}
+			h.serveAuthorized(c, withClientCert(principal, req.clientCert))
 			return
 		}
 
-		if !localClient {
-			h.attemptsMu.Lock()
-			if ai := h.failedAttempts[clientIP]; ai != nil {
-				ai.count = 0
-				ai.blockedUntil = time.Time{}
-			}
-			h.attemptsMu.Unlock()
+		principal, ok := h.authenticateRawKey(req, provided)
+		if !ok {
+			h.rejectCredential(c, req, provided, "invalid management key")
+			return
+		}
+		if totpRequired(req.cfg, principal) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "second factor required; obtain a session via /v0/management/login"})
+			return
+		}
+		if principal.Source != "local-password" && req.cfg != nil && !req.cfg.RemoteManagement.RawSecretAllowed() {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "raw management key not accepted; obtain a session via /v0/management/login"})
+			return
 		}
 
-		c.Next()
+		if !req.localClient {
+			h.resetAttempts(req.ipKey, keyKey)
+		}
+		h.serveAuthorized(c, withClientCert(principal, req.clientCert))
 	}
 }
 
+// serveAuthorized attaches principal to the request, runs the remaining handlers
+// and records the request in the audit log.
+func (h *Handler) serveAuthorized(c *gin.Context, principal managementPrincipal) {
+	c.Set(managementPrincipalKey, principal)
+	c.Next()
+	h.auditRequest(c, principal)
+}
+
 // persist saves the current in-memory config to disk.
 func (h *Handler) persist(c *gin.Context) bool {
 	h.mu.Lock()
@@ -0,0 +1,259 @@
+package management
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxTrackedRejectedIPs bounds the per-IP rejection counters.
+const maxTrackedRejectedIPs = 1024
+
+// networkListCache keeps the parsed form of a CIDR list until the list changes.
+type networkListCache struct {
+	mu       sync.Mutex
+	raw      string
+	prefixes []netip.Prefix
+}
+
+func (n *networkListCache) get(entries []string) []netip.Prefix {
+	key := strings.Join(entries, "\n")
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.prefixes != nil && n.raw == key {
+		return n.prefixes
+	}
+	prefixes, _ := parseNetworkList(entries)
+	n.raw = key
+	n.prefixes = prefixes
+	return prefixes
+}
+
+// ipRejections counts requests rejected by the allow-list.
+type ipRejections struct {
+	total atomic.Int64
+	mu    sync.Mutex
+	byIP  map[string]int64
+}
+
+func (r *ipRejections) add(ip string) int64 {
+	total := r.total.Add(1)
+	r.mu.Lock()
+	if r.byIP == nil {
+		r.byIP = make(map[string]int64)
+	}
+	if _, ok := r.byIP[ip]; ok || len(r.byIP) < maxTrackedRejectedIPs {
+		r.byIP[ip]++
+	}
+	r.mu.Unlock()
+	return total
+}
+
+func (r *ipRejections) snapshot() (int64, map[string]int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string]int64, len(r.byIP))
+	for ip, count := range r.byIP {
+		out[ip] = count
+	}
+	return r.total.Load(), out
+}
+
+// parseNetworkList parses CIDRs or bare IP addresses. Bare addresses become
+// single-host prefixes.
+func parseNetworkList(entries []string) ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		trimmed := strings.TrimSpace(entry)
+		if trimmed == "" {
+			continue
+		}
+		if strings.Contains(trimmed, "/") {
+			prefix, err := netip.ParsePrefix(trimmed)
+			if err != nil {
+				return out, fmt.Errorf("invalid network %q: %w", trimmed, err)
+			}
+			out = append(out, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(trimmed)
+		if err != nil {
+			return out, fmt.Errorf("invalid network %q: %w", trimmed, err)
+		}
+		addr = addr.Unmap()
+		out = append(out, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return out, nil
+}
+
+func prefixesContain(prefixes []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseIP(raw string) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(raw))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// peerAddr returns the address of the direct TCP peer.
+func peerAddr(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return parseIP(host)
+}
+
+// realClientIP resolves the client address for allow-list checks. Forwarding
+// headers are only honored when the direct peer is a trusted proxy; otherwise the
+// socket address is authoritative. X-Forwarded-For is walked right to left,
+// skipping trusted proxies, so a client cannot prepend a spoofed address.
+func realClientIP(r *http.Request, trusted []netip.Prefix) (netip.Addr, bool) {
+	peer, ok := peerAddr(r)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	if len(trusted) == 0 || !prefixesContain(trusted, peer) {
+		return peer, true
+	}
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, okHop := parseIP(hops[i])
+			if !okHop {
+				break
+			}
+			if !prefixesContain(trusted, addr) {
+				return addr, true
+			}
+		}
+	}
+	if addr, okReal := parseIP(r.Header.Get("X-Real-IP")); okReal {
+		return addr, true
+	}
+	return peer, true
+}
+
+// managementClientIP is the client address of a management request, resolved
+// like realClientIP so forwarding headers count only from trusted proxies.
+// gin's ClientIP trusts them from any peer and must not be used here: the
+// address decides local access, lockouts and what the audit log records.
+func (h *Handler) managementClientIP(c *gin.Context) string {
+	var trusted []netip.Prefix
+	if h.cfg != nil {
+		trusted = h.trustedProxies.get(h.cfg.RemoteManagement.TrustedProxies)
+	}
+	if addr, ok := realClientIP(c.Request, trusted); ok {
+		return addr.Unmap().String()
+	}
+	return c.Request.RemoteAddr
+}
+
+// checkManagementNetwork enforces remote-management.allowed-networks. It aborts
+// the request and returns false when the client is not allowed.
+func (h *Handler) checkManagementNetwork(c *gin.Context, cfg *config.Config) bool {
+	if cfg == nil || len(cfg.RemoteManagement.AllowedNetworks) == 0 {
+		return true
+	}
+	allowed := h.allowedNetworks.get(cfg.RemoteManagement.AllowedNetworks)
+	trusted := h.trustedProxies.get(cfg.RemoteManagement.TrustedProxies)
+	addr, ok := realClientIP(c.Request, trusted)
+	if ok && prefixesContain(allowed, addr) {
+		return true
+	}
+	ip := c.Request.RemoteAddr
+	if ok {
+		ip = addr.String()
+	}
+	total := h.ipRejected.add(ip)
+	log.Warnf("management request from %s rejected by allowed-networks (total rejections: %d)", ip, total)
+	h.recordAudit(auditEntry{
+		ClientIP: ip,
+		Method:   c.Request.Method,
+		Path:     c.Request.URL.Path,
+		Status:   http.StatusForbidden,
+		Action:   "network_rejected",
+	})
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "client network not allowed"})
+	return false
+}
+
+func (h *Handler) putNetworkList(c *gin.Context, target *[]string) {
+	var req struct {
+		Items []string `json:"items"`
+		Value []string `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	items := req.Items
+	if items == nil {
+		items = req.Value
+	}
+	if _, err := parseNetworkList(items); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	normalized := make([]string, 0, len(items))
+	for _, item := range items {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			normalized = append(normalized, trimmed)
+		}
+	}
+	h.mu.Lock()
+	old := *target
+	*target = normalized
+	errSave := config.SaveConfigPreserveComments(h.configFilePath, h.cfg)
+	if errSave != nil {
+		*target = old
+	}
+	h.mu.Unlock()
+	if errSave != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", errSave)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GetAllowedNetworks returns the management allow-list and rejection counters.
+func (h *Handler) GetAllowedNetworks(c *gin.Context) {
+	total, byIP := h.ipRejected.snapshot()
+	c.JSON(http.StatusOK, gin.H{
+		"allowed-networks": h.cfg.RemoteManagement.AllowedNetworks,
+		"rejected-total":   total,
+		"rejected-by-ip":   byIP,
+	})
+}
+
+// PutAllowedNetworks replaces the management allow-list. An empty list disables it.
+func (h *Handler) PutAllowedNetworks(c *gin.Context) {
+	h.putNetworkList(c, &h.cfg.RemoteManagement.AllowedNetworks)
+}
+
+// GetTrustedProxies returns the proxies whose forwarding headers are honored.
+func (h *Handler) GetTrustedProxies(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"trusted-proxies": h.cfg.RemoteManagement.TrustedProxies})
+}
+
+// PutTrustedProxies replaces the trusted proxy list.
+func (h *Handler) PutTrustedProxies(c *gin.Context) {
+	h.putNetworkList(c, &h.cfg.RemoteManagement.TrustedProxies)
+}
@@ -0,0 +1,112 @@
+package management
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// lifecycle tracks the handler's background goroutines so Stop can end them.
+// The zero value is ready to use.
+type lifecycle struct {
+	initOnce sync.Once
+	stopCh   chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	mu      sync.Mutex
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+func (l *lifecycle) init() {
+	l.initOnce.Do(func() {
+		l.stopCh = make(chan struct{})
+		l.ctx, l.cancel = context.WithCancel(context.Background())
+	})
+}
+
+// stopping is closed when Stop begins; scheduler loops return on it.
+func (l *lifecycle) stopping() <-chan struct{} {
+	l.init()
+	return l.stopCh
+}
+
+// context is cancelled when Stop begins; long runs such as inspections,
+// backups and syncs derive from it.
+func (l *lifecycle) context() context.Context {
+	l.init()
+	return l.ctx
+}
+
+// goWorker runs fn in a goroutine that Stop waits for. After Stop it does
+// nothing and returns false.
+func (l *lifecycle) goWorker(fn func()) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopped {
+		return false
+	}
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		fn()
+	}()
+	return true
+}
+
+func (l *lifecycle) stop(ctx context.Context) error {
+	l.init()
+	l.mu.Lock()
+	if !l.stopped {
+		l.stopped = true
+		close(l.stopCh)
+		l.cancel()
+	}
+	l.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// leaseReleaser is implemented by token stores that can hand the inspection
+// lease to another replica immediately.
+type leaseReleaser interface {
+	ReleaseLease(ctx context.Context, name, holder string) error
+}
+
+// Stop ends the background workers started by NewHandler. It cancels a
+// running inspection, backup or sync, waits until each has recorded its
+// result, lets pending notifications finish, and releases the inspection
+// lease. An inspection cut short this way is reported as interrupted. It
+// returns ctx.Err() if the workers do not finish in time.
+func (h *Handler) Stop(ctx context.Context) error {
+	if h == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	err := h.life.stop(ctx)
+	if h.isInspectionLeader() {
+		if releaser, ok := h.tokenStore.(leaseReleaser); ok {
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if errRelease := releaser.ReleaseLease(releaseCtx, authInspectionLeaseName, h.inspectionInstanceID()); errRelease != nil {
+				log.Warnf("auth inspection: failed to release scheduler lease: %v", errRelease)
+			}
+			cancel()
+		}
+	}
+	return err
+}
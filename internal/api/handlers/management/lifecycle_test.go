@@ -0,0 +1,94 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestHandlerStop_WaitsForInspectionToFinish(t *testing.T) {
+	h := &Handler{}
+	h.startAuthInspectionScheduler()
+
+	started := make(chan struct{})
+	h.life.goWorker(func() {
+		// Stand-in for runAuthInspection: runs until its context is cancelled.
+		ctx := h.life.context()
+		h.beginAuthInspection("scheduled")
+		close(started)
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		h.finishAuthInspection(0, ctx.Err())
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := h.Stop(ctx); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	h.inspectionMu.RLock()
+	status := h.inspectionStatus
+	h.inspectionMu.RUnlock()
+	if status.Running || status.LastRunFinished.IsZero() || status.LastError != context.Canceled.Error() {
+		t.Fatalf("inspection not finished before Stop returned: %+v", status)
+	}
+	if h.life.goWorker(func() {}) {
+		t.Fatal("workers must not start after Stop")
+	}
+}
+
+func TestHandlerStop_InterruptsRunningInspection(t *testing.T) {
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	registerInspectionFixtures(t, manager, t.TempDir(), "codex", 2)
+	probing := make(chan struct{}, 2)
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("codex", coreauth.ProbeFunc(func(ctx context.Context, _ *coreauth.Auth) (bool, string, error) {
+		probing <- struct{}{}
+		<-ctx.Done()
+		return false, "", ctx.Err()
+	}))
+	cfg := &config.Config{}
+	cfg.AuthInspection.AutoDeleteInvalid = true
+	h := &Handler{cfg: cfg, authManager: manager}
+	h.SetInspector(inspector)
+	h.startAuthInspectionScheduler()
+	if !h.queueAuthInspection(inspectionRequest{Trigger: "manual"}) {
+		t.Fatal("trigger not queued")
+	}
+	select {
+	case <-probing:
+	case <-time.After(2 * time.Second):
+		t.Fatal("inspection never started")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := h.Stop(ctx); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	payload := h.authInspectionStatusPayload()
+	if payload["running"] != false || payload["interrupted"] != true || payload["cancelled"] != false || payload["last_error"] == "" {
+		t.Fatalf("status after stop = running %v interrupted %v cancelled %v last_error %q", payload["running"], payload["interrupted"], payload["cancelled"], payload["last_error"])
+	}
+	if run, ok := h.inspectionRuns.get(""); !ok || !run.Interrupted {
+		t.Fatalf("last run = %+v", run)
+	}
+}
+
+func TestHandlerStop_HonoursDeadline(t *testing.T) {
+	h := &Handler{}
+	release := make(chan struct{})
+	defer close(release)
+	h.life.goWorker(func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := h.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("stop = %v, want deadline exceeded", err)
+	}
+}
@@ -0,0 +1,115 @@
+package management
+
+import (
+	"crypto/x509"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// clientCertIdentity names a certificate by its common name, falling back to
+// the first DNS, email or URI subject alternative name.
+func clientCertIdentity(cert *x509.Certificate) string {
+	switch {
+	case cert.Subject.CommonName != "":
+		return cert.Subject.CommonName
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0]
+	case len(cert.EmailAddresses) > 0:
+		return cert.EmailAddresses[0]
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String()
+	default:
+		return cert.SerialNumber.String()
+	}
+}
+
+// clientCertSubjectAllowed matches the certificate's common name and subject
+// alternative names against glob patterns such as "*.ops.example.com".
+func clientCertSubjectAllowed(cert *x509.Certificate, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	names := make([]string, 0, 1+len(cert.DNSNames)+len(cert.EmailAddresses)+len(cert.URIs))
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		for _, name := range names {
+			if ok, _ := path.Match(pattern, strings.ToLower(name)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkClientCertificate enforces tls.management.mtls for a management request.
+// Certificates were already verified against the client CA during the handshake;
+// this checks presence and the subject allow-list. It returns the certificate
+// identity, or "" when none was presented, and aborts the request on failure.
+func (h *Handler) checkClientCertificate(c *gin.Context, cfg *config.Config) (string, bool) {
+	if cfg == nil || !cfg.TLS.Management.MTLS.Enable {
+		return "", true
+	}
+	mtls := cfg.TLS.Management.MTLS
+	var cert *x509.Certificate
+	if c.Request.TLS != nil && len(c.Request.TLS.PeerCertificates) > 0 {
+		cert = c.Request.TLS.PeerCertificates[0]
+	}
+	if cert == nil {
+		if !mtls.RequireAndVerify {
+			return "", true
+		}
+		h.rejectClientCertificate(c, "", "client certificate required")
+		return "", false
+	}
+	identity := clientCertIdentity(cert)
+	if !clientCertSubjectAllowed(cert, mtls.AllowedSubjects) {
+		h.rejectClientCertificate(c, identity, "client certificate not allowed")
+		return "", false
+	}
+	return identity, true
+}
+
+func (h *Handler) rejectClientCertificate(c *gin.Context, identity, reason string) {
+	log.Warnf("management mTLS: %s (subject %q) from %s", reason, identity, c.Request.RemoteAddr)
+	h.recordAudit(auditEntry{
+		ClientIP:   h.managementClientIP(c),
+		ClientCert: identity,
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		Status:     http.StatusForbidden,
+		Action:     "mtls_rejected",
+		Detail:     reason,
+	})
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": reason})
+}
+
+// withClientCert attributes principal to the client certificate. The shared
+// admin secrets do not identify a person, so for those the certificate
+// identity becomes the acting principal.
+func withClientCert(principal managementPrincipal, identity string) managementPrincipal {
+	if identity == "" {
+		return principal
+	}
+	principal.ClientCert = identity
+	switch principal.Source {
+	case "env", "secret-key", "local-password":
+		principal.Name = "cert:" + identity
+	}
+	return principal
+}
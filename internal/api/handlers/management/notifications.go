@@ -0,0 +1,114 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
+)
+
+const notificationTestEvent = "notifications.test"
+
+func validateNotificationsConfig(cfg config.NotificationsConfig) error {
+	for i, target := range cfg.Webhooks {
+		if !isHTTPURL(target.URL) {
+			return fmt.Errorf("webhooks[%d]: url must be an http or https URL", i)
+		}
+		switch strings.ToLower(strings.TrimSpace(target.Format)) {
+		case "", notify.FormatJSON, notify.FormatDiscord:
+		default:
+			return fmt.Errorf("webhooks[%d]: unsupported format %q", i, target.Format)
+		}
+	}
+	for i, target := range cfg.Telegram {
+		if strings.TrimSpace(target.BotToken) == "" || strings.TrimSpace(target.ChatID) == "" {
+			return fmt.Errorf("telegram[%d]: bot-token and chat-id are required", i)
+		}
+	}
+	for i, target := range cfg.Slack {
+		if !isHTTPURL(target.WebhookURL) {
+			return fmt.Errorf("slack[%d]: webhook-url must be an http or https URL", i)
+		}
+	}
+	return nil
+}
+
+func isHTTPURL(raw string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// GetNotifications returns the notifications section of the config.
+func (h *Handler) GetNotifications(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "config unavailable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"notifications": h.cfg.Notifications})
+}
+
+// PutNotifications replaces the notifications section of the config.
+func (h *Handler) PutNotifications(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "config unavailable"})
+		return
+	}
+	var next config.NotificationsConfig
+	if err := c.ShouldBindJSON(&next); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if err := validateNotificationsConfig(next); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.mu.Lock()
+	oldCfg := h.cfg.Notifications
+	h.cfg.Notifications = next
+	h.cfg.SanitizeNotifications()
+	saved := h.cfg.Notifications
+	errSave := config.SaveConfigPreserveComments(h.configFilePath, h.cfg)
+	if errSave != nil {
+		h.cfg.Notifications = oldCfg
+	}
+	h.mu.Unlock()
+	if errSave != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", errSave)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "notifications": saved})
+}
+
+// TestNotifications sends a test message to every configured channel so
+// credentials can be checked without waiting for a real event.
+func (h *Handler) TestNotifications(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "config unavailable"})
+		return
+	}
+	h.mu.Lock()
+	target := h.cfg.Notifications
+	h.mu.Unlock()
+	if !notify.HasChannels(target) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no notification channels configured"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), alertNotifyTimeout)
+	defer cancel()
+	event := notify.Event{
+		Type:     notificationTestEvent,
+		Severity: "info",
+		Title:    "CLIProxyAPI test notification",
+		Message:  "Notifications are configured correctly.",
+	}
+	if err := notify.Send(ctx, target, event); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,76 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestNotificationsConfigAndTestMessage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var texts []string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Text string `json:"text"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		texts = append(texts, body.Text)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	h := &Handler{cfg: &config.Config{}, configFilePath: configPath}
+
+	call := func(method string, handler gin.HandlerFunc, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(method, "/v0/management/notifications", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		handler(c)
+		return rec
+	}
+
+	if rec := call(http.MethodPost, h.TestNotifications, ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("test without channels: status %d", rec.Code)
+	}
+	if rec := call(http.MethodPut, h.PutNotifications, `{"telegram":[{"bot-token":"123:abc"}]}`); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "telegram[0]") {
+		t.Fatalf("telegram without chat id: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := call(http.MethodPut, h.PutNotifications, `{"slack":[{"webhook-url":"not a url"}]}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad slack url: status %d", rec.Code)
+	}
+
+	rec := call(http.MethodPut, h.PutNotifications, `{"slack":[{"name":" ops ","webhook-url":"`+srv.URL+`"}]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("put: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if got := h.cfg.Notifications.Slack; len(got) != 1 || got[0].Name != "ops" {
+		t.Fatalf("saved slack targets = %+v", got)
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil || !strings.Contains(string(saved), "webhook-url") {
+		t.Fatalf("notifications not saved: %s (%v)", saved, err)
+	}
+	if rec = call(http.MethodGet, h.GetNotifications, ""); !strings.Contains(rec.Body.String(), srv.URL) {
+		t.Fatalf("get: %s", rec.Body.String())
+	}
+
+	if rec = call(http.MethodPost, h.TestNotifications, ""); rec.Code != http.StatusOK || len(texts) != 1 || !strings.Contains(texts[0], "test notification") {
+		t.Fatalf("test message: status %d body=%s texts=%v", rec.Code, rec.Body.String(), texts)
+	}
+	status = http.StatusForbidden
+	if rec = call(http.MethodPost, h.TestNotifications, ""); rec.Code != http.StatusBadGateway || !strings.Contains(rec.Body.String(), "slack ops") {
+		t.Fatalf("failed test message: status %d body=%s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,169 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// tokenActivity tracks when and from where each scoped token was last used.
+// It is kept in memory only; a restart resets it.
+type tokenActivity struct {
+	mu   sync.Mutex
+	seen map[string]tokenSeen
+}
+
+type tokenSeen struct {
+	At time.Time
+	IP string
+}
+
+func (a *tokenActivity) touch(id, ip string, now time.Time) {
+	if id == "" {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.seen == nil {
+		a.seen = make(map[string]tokenSeen)
+	}
+	a.seen[id] = tokenSeen{At: now, IP: ip}
+}
+
+func (a *tokenActivity) get(id string) (tokenSeen, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	seen, ok := a.seen[id]
+	return seen, ok
+}
+
+func (a *tokenActivity) forget(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.seen, id)
+}
+
+func (h *Handler) auditRevocation(c *gin.Context, detail string) {
+	principal, _ := principalFromContext(c)
+	h.recordAudit(auditEntry{
+		Actor:      principal.Name,
+		Role:       string(principal.Role),
+		Source:     principal.Source,
+		ClientIP:   h.managementClientIP(c),
+		ClientCert: principal.ClientCert,
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		Status:     http.StatusOK,
+		Action:     "principal_revoked",
+		Detail:     detail,
+	})
+}
+
+// ListPrincipals returns every valid session and scoped token. The entry used
+// by the caller is flagged as current.
+func (h *Handler) ListPrincipals(c *gin.Context) {
+	caller, _ := principalFromContext(c)
+	now := time.Now()
+
+	out := make([]gin.H, 0)
+	for _, sess := range h.sessions.list() {
+		out = append(out, gin.H{
+			"id":         sess.ID,
+			"kind":       "session",
+			"label":      sess.Principal.Name,
+			"role":       string(sess.Principal.Role),
+			"created_at": sess.IssuedAt,
+			"expires_at": sess.ExpiresAt,
+			"last_seen":  sess.LastSeen,
+			"last_ip":    sess.ClientIP,
+			"user_agent": sess.UserAgent,
+			"current":    sess.ID == caller.SessionID,
+		})
+	}
+
+	h.mu.Lock()
+	tokens := append([]config.ManagementToken(nil), h.cfg.RemoteManagement.Tokens...)
+	h.mu.Unlock()
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.Before(tokens[j].CreatedAt) })
+	for _, token := range tokens {
+		if token.ExpiresAt != nil && !now.Before(*token.ExpiresAt) {
+			continue
+		}
+		entry := gin.H{
+			"id":         token.ID,
+			"kind":       "token",
+			"label":      token.Label,
+			"scopes":     token.Scopes,
+			"created_at": token.CreatedAt,
+			"current":    token.ID == caller.TokenID,
+		}
+		if token.ExpiresAt != nil {
+			entry["expires_at"] = *token.ExpiresAt
+		}
+		if seen, ok := h.tokenActivity.get(token.ID); ok {
+			entry["last_seen"] = seen.At
+			entry["last_ip"] = seen.IP
+		}
+		out = append(out, entry)
+	}
+	c.JSON(http.StatusOK, gin.H{"principals": out})
+}
+
+// RevokePrincipal revokes the session or scoped token with the given id.
+// Sessions are dropped from the store and tokens from the config, so the next
+// request using either is rejected.
+func (h *Handler) RevokePrincipal(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+		return
+	}
+	if h.sessions.revoke(id) {
+		h.auditRevocation(c, "session "+id)
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "kind": "session", "id": id})
+		return
+	}
+	removed, err := h.removeManagementTokens(func(token config.ManagementToken) bool { return token.ID == id })
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", err)})
+		return
+	}
+	if len(removed) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "principal not found"})
+		return
+	}
+	h.auditRevocation(c, fmt.Sprintf("token %s (%s)", id, removed[0].Label))
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "kind": "token", "id": id})
+}
+
+// RevokeAllPrincipals revokes every session and scoped token except the one
+// used for this request, for incident response.
+func (h *Handler) RevokeAllPrincipals(c *gin.Context) {
+	caller, _ := principalFromContext(c)
+	sessions := h.sessions.revokeAllExcept(caller.SessionID)
+	removed, err := h.removeManagementTokens(func(token config.ManagementToken) bool { return token.ID != caller.TokenID })
+	if err != nil {
+		// Sessions are already gone; report the failure to drop tokens.
+		h.auditRevocation(c, fmt.Sprintf("all except current: %d sessions, tokens failed: %v", len(sessions), err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", err), "sessions_revoked": len(sessions)})
+		return
+	}
+	tokens := make([]string, 0, len(removed))
+	for _, token := range removed {
+		tokens = append(tokens, token.ID)
+	}
+	h.auditRevocation(c, fmt.Sprintf("all except current: %d sessions, %d tokens", len(sessions), len(tokens)))
+	c.JSON(http.StatusOK, gin.H{
+		"status":           "ok",
+		"sessions_revoked": len(sessions),
+		"tokens_revoked":   len(tokens),
+		"session_ids":      sessions,
+		"token_ids":        tokens,
+	})
+}
@@ -0,0 +1,253 @@
+package management
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Role is a management API permission level. Higher roles include everything lower roles may do.
+type Role string
+
+const (
+	// RoleViewer may only read non-secret state.
+	RoleViewer Role = "viewer"
+	// RoleOperator may additionally run lifecycle operations such as uploading,
+	// verifying and deleting auth files, but may not change config or secrets.
+	RoleOperator Role = "operator"
+	// RoleAdmin may do everything.
+	RoleAdmin Role = "admin"
+)
+
+const managementPrincipalKey = "managementPrincipal"
+
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleOperator:
+		return 2
+	case RoleAdmin:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// parseRole normalizes raw into a known role.
+func parseRole(raw string) (Role, bool) {
+	role := Role(strings.ToLower(strings.TrimSpace(raw)))
+	return role, role.rank() > 0
+}
+
+// managementPrincipal identifies the caller of a management request.
+type managementPrincipal struct {
+	Name   string
+	Role   Role
+	Source string
+	// SessionID is set when the request authenticated with a session token.
+	SessionID string
+	// TokenID is set when the request authenticated with a scoped token.
+	TokenID string
+	// ClientCert is the verified client certificate identity under mTLS.
+	ClientCert string
+	// Scopes is non-nil for scoped tokens and limits the routes they may call.
+	Scopes []Scope
+}
+
+func principalFromContext(c *gin.Context) (managementPrincipal, bool) {
+	if c == nil {
+		return managementPrincipal{}, false
+	}
+	raw, ok := c.Get(managementPrincipalKey)
+	if !ok {
+		return managementPrincipal{}, false
+	}
+	principal, ok := raw.(managementPrincipal)
+	return principal, ok
+}
+
+// RequireRole rejects requests whose authenticated principal has a lower role than role.
+// It must run after Middleware.
+func (h *Handler) RequireRole(role Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := principalFromContext(c)
+		if !ok || principal.Role.rank() < role.rank() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":         fmt.Sprintf("insufficient role: %s required", role),
+				"required_role": string(role),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// matchManagementUser returns the configured user whose key matches provided.
+// Successful matches are cached by key digest so bcrypt runs once per key.
+func (h *Handler) matchManagementUser(cfg *config.Config, provided string) (config.ManagementUser, bool) {
+	if cfg == nil || len(cfg.RemoteManagement.Users) == 0 || provided == "" {
+		return config.ManagementUser{}, false
+	}
+	sum := sha256.Sum256([]byte(provided))
+	digest := hex.EncodeToString(sum[:])
+
+	h.userKeyCacheMu.Lock()
+	cachedHash := h.userKeyCache[digest]
+	h.userKeyCacheMu.Unlock()
+	if cachedHash != "" {
+		for _, user := range cfg.RemoteManagement.Users {
+			if user.KeyHash == cachedHash {
+				return user, true
+			}
+		}
+	}
+
+	for _, user := range cfg.RemoteManagement.Users {
+		if bcrypt.CompareHashAndPassword([]byte(user.KeyHash), []byte(provided)) == nil {
+			h.userKeyCacheMu.Lock()
+			if h.userKeyCache == nil {
+				h.userKeyCache = make(map[string]string)
+			}
+			h.userKeyCache[digest] = user.KeyHash
+			h.userKeyCacheMu.Unlock()
+			return user, true
+		}
+	}
+	return config.ManagementUser{}, false
+}
+
+func generateManagementKey() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "cpa_" + hex.EncodeToString(buf), nil
+}
+
+func managementUserPayload(user config.ManagementUser) gin.H {
+	return gin.H{
+		"name":         user.Name,
+		"role":         user.Role,
+		"created_at":   user.CreatedAt,
+		"totp_enabled": user.TOTPEnabled,
+	}
+}
+
+// ListManagementUsers returns configured management users without their key hashes.
+func (h *Handler) ListManagementUsers(c *gin.Context) {
+	users := make([]gin.H, 0, len(h.cfg.RemoteManagement.Users))
+	for _, user := range h.cfg.RemoteManagement.Users {
+		users = append(users, managementUserPayload(user))
+	}
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+// CreateManagementUser adds a management user. When no key is supplied one is
+// generated; the plaintext key is only returned in this response.
+func (h *Handler) CreateManagementUser(c *gin.Context) {
+	var req struct {
+		Name string `json:"name"`
+		Role string `json:"role"`
+		Key  string `json:"key"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	role, ok := parseRole(req.Role)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of admin, operator, viewer"})
+		return
+	}
+	key := strings.TrimSpace(req.Key)
+	if key == "" {
+		generated, errGen := generateManagementKey()
+		if errGen != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to generate key: %v", errGen)})
+			return
+		}
+		key = generated
+	}
+	hashed, errHash := bcrypt.GenerateFromPassword([]byte(key), bcrypt.DefaultCost)
+	if errHash != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to hash key: %v", errHash)})
+		return
+	}
+	user := config.ManagementUser{
+		Name:      name,
+		Role:      string(role),
+		KeyHash:   string(hashed),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	h.mu.Lock()
+	for _, existing := range h.cfg.RemoteManagement.Users {
+		if existing.Name == name {
+			h.mu.Unlock()
+			c.JSON(http.StatusConflict, gin.H{"error": "user already exists"})
+			return
+		}
+	}
+	oldUsers := h.cfg.RemoteManagement.Users
+	h.cfg.RemoteManagement.Users = append(append([]config.ManagementUser(nil), oldUsers...), user)
+	errSave := config.SaveConfigPreserveComments(h.configFilePath, h.cfg)
+	if errSave != nil {
+		h.cfg.RemoteManagement.Users = oldUsers
+	}
+	h.mu.Unlock()
+	if errSave != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", errSave)})
+		return
+	}
+	payload := managementUserPayload(user)
+	payload["key"] = key
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "user": payload})
+}
+
+// DeleteManagementUser removes the user named by ?name=.
+func (h *Handler) DeleteManagementUser(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	h.mu.Lock()
+	oldUsers := h.cfg.RemoteManagement.Users
+	next := make([]config.ManagementUser, 0, len(oldUsers))
+	for _, user := range oldUsers {
+		if user.Name != name {
+			next = append(next, user)
+		}
+	}
+	if len(next) == len(oldUsers) {
+		h.mu.Unlock()
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	h.cfg.RemoteManagement.Users = next
+	errSave := config.SaveConfigPreserveComments(h.configFilePath, h.cfg)
+	if errSave != nil {
+		h.cfg.RemoteManagement.Users = oldUsers
+	}
+	h.mu.Unlock()
+	if errSave != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", errSave)})
+		return
+	}
+	h.sessions.revokeUser(name)
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,296 @@
+package management
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// Scope names a group of management endpoints that a scoped token may call.
+// Every management route is registered with exactly one scope.
+type Scope string
+
+const (
+	ScopeConfigRead      Scope = "config:read"
+	ScopeConfigWrite     Scope = "config:write"
+	ScopeSecretsRead     Scope = "secrets:read"
+	ScopeSecretsWrite    Scope = "secrets:write"
+	ScopeAuthFilesRead   Scope = "auth-files:read"
+	ScopeAuthFilesWrite  Scope = "auth-files:write"
+	ScopeInspectionRead  Scope = "inspection:read"
+	ScopeInspectionWrite Scope = "inspection:write"
+	ScopeUsageRead       Scope = "usage:read"
+	ScopeUsageWrite      Scope = "usage:write"
+	ScopeLogsRead        Scope = "logs:read"
+	ScopeLogsWrite       Scope = "logs:write"
+	ScopeAlertsRead      Scope = "alerts:read"
+	ScopeAlertsWrite     Scope = "alerts:write"
+	ScopeDebugRead       Scope = "debug:read"
+	ScopeSecurityRead    Scope = "security:read"
+	ScopeSecurityWrite   Scope = "security:write"
+	ScopeBackupsRead     Scope = "backups:read"
+	ScopeBackupsWrite    Scope = "backups:write"
+)
+
+// scopeDescriptions is the single list of scopes that tokens may be granted.
+var scopeDescriptions = map[Scope]string{
+	ScopeConfigRead:      "read non-secret settings",
+	ScopeConfigWrite:     "change non-secret settings",
+	ScopeSecretsRead:     "read API keys, provider keys, the raw config and auth file contents",
+	ScopeSecretsWrite:    "change API keys, provider keys and the raw config",
+	ScopeAuthFilesRead:   "list auth files and models",
+	ScopeAuthFilesWrite:  "upload, delete, verify and log in auth files",
+	ScopeInspectionRead:  "read inspection config and status",
+	ScopeInspectionWrite: "change inspection config and run inspections",
+	ScopeUsageRead:       "read and export usage statistics",
+	ScopeUsageWrite:      "import usage statistics",
+	ScopeLogsRead:        "read logs",
+	ScopeLogsWrite:       "delete logs",
+	ScopeAlertsRead:      "read alerts",
+	ScopeAlertsWrite:     "change alert rules",
+	ScopeDebugRead:       "read runtime diagnostics and profiles",
+	ScopeSecurityRead:    "read users, tokens, sessions, audit log and access rules",
+	ScopeSecurityWrite:   "change users, tokens, sessions and access rules",
+	ScopeBackupsRead:     "read backup status and list backups",
+	ScopeBackupsWrite:    "run and restore backups",
+}
+
+const scopedTokenPrefix = "cpat_"
+
+// Known reports whether s is a defined scope.
+func (s Scope) Known() bool {
+	_, ok := scopeDescriptions[s]
+	return ok
+}
+
+// RequireScope rejects scoped-token requests that lack scope. Other
+// credentials are governed by roles only. It must run after Middleware.
+func (h *Handler) RequireScope(scope Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, _ := principalFromContext(c)
+		if principal.Scopes == nil {
+			c.Next()
+			return
+		}
+		for _, granted := range principal.Scopes {
+			if granted == scope {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":         fmt.Sprintf("missing scope: %s", scope),
+			"missing_scope": string(scope),
+		})
+	}
+}
+
+func isScopedToken(token string) bool {
+	return strings.HasPrefix(token, scopedTokenPrefix)
+}
+
+func hashScopedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// matchScopedToken returns the principal for a scoped token. Every stored
+// hash is compared so timing does not reveal which token matched.
+func matchScopedToken(cfg *config.Config, provided string, now time.Time) (managementPrincipal, bool) {
+	if cfg == nil || !isScopedToken(provided) {
+		return managementPrincipal{}, false
+	}
+	digest := []byte(hashScopedToken(provided))
+	var match *config.ManagementToken
+	for i := range cfg.RemoteManagement.Tokens {
+		token := &cfg.RemoteManagement.Tokens[i]
+		if subtle.ConstantTimeCompare(digest, []byte(token.TokenHash)) == 1 {
+			match = token
+		}
+	}
+	if match == nil || (match.ExpiresAt != nil && !now.Before(*match.ExpiresAt)) {
+		return managementPrincipal{}, false
+	}
+	scopes := make([]Scope, 0, len(match.Scopes))
+	for _, scope := range match.Scopes {
+		scopes = append(scopes, Scope(scope))
+	}
+	// Scoped tokens pass role checks; RequireScope limits what they can reach.
+	return managementPrincipal{Name: "token:" + match.Label, Role: RoleAdmin, Source: "token", TokenID: match.ID, Scopes: scopes}, true
+}
+
+func managementTokenPayload(token config.ManagementToken) gin.H {
+	payload := gin.H{
+		"id":         token.ID,
+		"label":      token.Label,
+		"scopes":     token.Scopes,
+		"prefix":     token.Prefix,
+		"created_at": token.CreatedAt,
+	}
+	if token.ExpiresAt != nil {
+		payload["expires_at"] = *token.ExpiresAt
+	}
+	return payload
+}
+
+// ListScopes returns the scopes that can be granted to tokens.
+func (h *Handler) ListScopes(c *gin.Context) {
+	names := make([]string, 0, len(scopeDescriptions))
+	for scope := range scopeDescriptions {
+		names = append(names, string(scope))
+	}
+	sort.Strings(names)
+	out := make([]gin.H, 0, len(names))
+	for _, name := range names {
+		out = append(out, gin.H{"scope": name, "description": scopeDescriptions[Scope(name)]})
+	}
+	c.JSON(http.StatusOK, gin.H{"scopes": out})
+}
+
+// ListManagementTokens returns scoped tokens without their hashes.
+func (h *Handler) ListManagementTokens(c *gin.Context) {
+	tokens := make([]gin.H, 0, len(h.cfg.RemoteManagement.Tokens))
+	for _, token := range h.cfg.RemoteManagement.Tokens {
+		tokens = append(tokens, managementTokenPayload(token))
+	}
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+// CreateManagementToken issues a scoped token. The plaintext token is only
+// returned in this response.
+func (h *Handler) CreateManagementToken(c *gin.Context) {
+	var req struct {
+		Label            string   `json:"label"`
+		Scopes           []string `json:"scopes"`
+		ExpiresInSeconds int64    `json:"expires_in_seconds"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	label := strings.TrimSpace(req.Label)
+	if label == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "label is required"})
+		return
+	}
+	if len(req.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one scope is required"})
+		return
+	}
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := make(map[string]struct{}, len(req.Scopes))
+	for _, raw := range req.Scopes {
+		scope := strings.ToLower(strings.TrimSpace(raw))
+		if !Scope(scope).Known() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown scope %q", raw)})
+			return
+		}
+		if _, dup := seen[scope]; dup {
+			continue
+		}
+		seen[scope] = struct{}{}
+		scopes = append(scopes, scope)
+	}
+	if req.ExpiresInSeconds < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_seconds must be positive"})
+		return
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to generate token: %v", err)})
+		return
+	}
+	id, err := randomHex(6)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to generate token: %v", err)})
+		return
+	}
+	plaintext := scopedTokenPrefix + hex.EncodeToString(buf)
+	now := time.Now().UTC()
+	token := config.ManagementToken{
+		ID:        id,
+		Label:     label,
+		Scopes:    scopes,
+		TokenHash: hashScopedToken(plaintext),
+		Prefix:    plaintext[:len(scopedTokenPrefix)+4],
+		CreatedAt: now,
+	}
+	if req.ExpiresInSeconds > 0 {
+		expires := now.Add(time.Duration(req.ExpiresInSeconds) * time.Second)
+		token.ExpiresAt = &expires
+	}
+
+	h.mu.Lock()
+	oldTokens := h.cfg.RemoteManagement.Tokens
+	h.cfg.RemoteManagement.Tokens = append(append([]config.ManagementToken(nil), oldTokens...), token)
+	errSave := config.SaveConfigPreserveComments(h.configFilePath, h.cfg)
+	if errSave != nil {
+		h.cfg.RemoteManagement.Tokens = oldTokens
+	}
+	h.mu.Unlock()
+	if errSave != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", errSave)})
+		return
+	}
+	payload := managementTokenPayload(token)
+	payload["token"] = plaintext
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "token": payload})
+}
+
+// DeleteManagementToken revokes the token with ?id=.
+func (h *Handler) DeleteManagementToken(c *gin.Context) {
+	id := strings.TrimSpace(c.Query("id"))
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+		return
+	}
+	removed, err := h.removeManagementTokens(func(token config.ManagementToken) bool { return token.ID == id })
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", err)})
+		return
+	}
+	if len(removed) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "token not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// removeManagementTokens deletes the tokens matched by drop and persists the
+// config. It returns the removed tokens; nothing is saved when none match.
+func (h *Handler) removeManagementTokens(drop func(config.ManagementToken) bool) ([]config.ManagementToken, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	oldTokens := h.cfg.RemoteManagement.Tokens
+	next := make([]config.ManagementToken, 0, len(oldTokens))
+	var removed []config.ManagementToken
+	for _, token := range oldTokens {
+		if drop(token) {
+			removed = append(removed, token)
+			continue
+		}
+		next = append(next, token)
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	h.cfg.RemoteManagement.Tokens = next
+	if err := config.SaveConfigPreserveComments(h.configFilePath, h.cfg); err != nil {
+		h.cfg.RemoteManagement.Tokens = oldTokens
+		return nil, err
+	}
+	for _, token := range removed {
+		h.tokenActivity.forget(token.ID)
+	}
+	return removed, nil
+}
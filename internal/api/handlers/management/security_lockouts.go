@@ -0,0 +1,319 @@
+package management
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// attemptMaxFailures failures inside attemptFailureWindow trigger a lockout.
+	attemptMaxFailures   = 5
+	attemptFailureWindow = 15 * time.Minute
+	// attemptBaseLockout doubles with every consecutive lockout up to attemptMaxLockout.
+	attemptBaseLockout = 1 * time.Minute
+	attemptMaxLockout  = 24 * time.Hour
+	// attemptKeyHashLen is how much of the hex SHA-256 of a presented key is
+	// kept to correlate attempts.
+	attemptKeyHashLen = 16
+	// maxTrackedAttempts bounds the attempt store so spoofed sources cannot exhaust memory.
+	maxTrackedAttempts = 10000
+
+	// attemptCleanupInterval controls how often stale entries are purged
+	attemptCleanupInterval = 10 * time.Minute
+	// attemptMaxIdleTime controls how long an entry can be idle before cleanup
+	attemptMaxIdleTime = 2 * time.Hour
+)
+
+type attemptInfo struct {
+	count        int
+	windowStart  time.Time
+	lockouts     int // consecutive lockouts, drives the exponential backoff
+	blockedUntil time.Time
+	lastActivity time.Time // track last activity for cleanup
+}
+
+func attemptKeyForIP(ip string) string { return "ip:" + ip }
+
+// attemptKeyForPresentedKey correlates failures that reuse the same wrong key
+// from different addresses. The whole value is hashed: session and scoped
+// tokens share their prefix, so any shorter part would put every token of a
+// kind in one bucket.
+func attemptKeyForPresentedKey(provided string) string {
+	sum := sha256.Sum256([]byte(provided))
+	return "key:" + hex.EncodeToString(sum[:])[:attemptKeyHashLen]
+}
+
+func lockoutDuration(lockouts int) time.Duration {
+	d := attemptBaseLockout
+	for i := 1; i < lockouts && d < attemptMaxLockout; i++ {
+		d *= 2
+	}
+	if d > attemptMaxLockout {
+		d = attemptMaxLockout
+	}
+	return d
+}
+
+// startAttemptCleanup launches a background goroutine that periodically
+// removes stale entries from failedAttempts to prevent memory leaks.
+func (h *Handler) startAttemptCleanup() {
+	h.life.goWorker(func() {
+		ticker := time.NewTicker(attemptCleanupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-h.life.stopping():
+				return
+			case <-ticker.C:
+				h.purgeStaleAttempts()
+			}
+		}
+	})
+}
+
+// purgeStaleAttempts removes entries that have been idle beyond attemptMaxIdleTime
+// and whose lockout (if any) has expired.
+func (h *Handler) purgeStaleAttempts() {
+	h.attemptsMu.Lock()
+	defer h.attemptsMu.Unlock()
+	h.purgeStaleAttemptsLocked(time.Now())
+}
+
+func (h *Handler) purgeStaleAttemptsLocked(now time.Time) {
+	for key, ai := range h.failedAttempts {
+		if now.Before(ai.blockedUntil) {
+			continue
+		}
+		if now.Sub(ai.lastActivity) > attemptMaxIdleTime {
+			delete(h.failedAttempts, key)
+		}
+	}
+}
+
+// attemptEntryLocked returns the entry for key, creating it and evicting the
+// least recently active unlocked entry when the store is full.
+func (h *Handler) attemptEntryLocked(key string, now time.Time) *attemptInfo {
+	if h.failedAttempts == nil {
+		h.failedAttempts = make(map[string]*attemptInfo)
+	}
+	if ai := h.failedAttempts[key]; ai != nil {
+		return ai
+	}
+	if len(h.failedAttempts) >= maxTrackedAttempts {
+		h.purgeStaleAttemptsLocked(now)
+	}
+	if len(h.failedAttempts) >= maxTrackedAttempts {
+		oldestKey := ""
+		var oldest time.Time
+		for k, ai := range h.failedAttempts {
+			if now.Before(ai.blockedUntil) {
+				continue
+			}
+			if oldestKey == "" || ai.lastActivity.Before(oldest) {
+				oldestKey, oldest = k, ai.lastActivity
+			}
+		}
+		if oldestKey == "" {
+			return nil
+		}
+		delete(h.failedAttempts, oldestKey)
+	}
+	ai := &attemptInfo{windowStart: now}
+	h.failedAttempts[key] = ai
+	return ai
+}
+
+// abortIfLockedOut answers 429 with Retry-After when key is locked out.
+func (h *Handler) abortIfLockedOut(c *gin.Context, key string) bool {
+	h.attemptsMu.Lock()
+	ai := h.failedAttempts[key]
+	var remaining time.Duration
+	if ai != nil {
+		remaining = time.Until(ai.blockedUntil)
+	}
+	h.attemptsMu.Unlock()
+	if remaining <= 0 {
+		return false
+	}
+	seconds := int((remaining + time.Second - 1) / time.Second)
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":       fmt.Sprintf("too many failed attempts, try again in %s", remaining.Round(time.Second)),
+		"retry_after": seconds,
+	})
+	return true
+}
+
+// rejectCredential answers a credential that failed validation: with 429
+// while the same value is locked out, otherwise with 401 after counting the
+// failure. The lockout of a presented key is only checked here, once the key
+// is known to be wrong, so a valid credential is never refused because of
+// other clients' failures.
+func (h *Handler) rejectCredential(c *gin.Context, req managementRequest, provided, reason string) {
+	if !req.localClient && provided != "" && h.abortIfLockedOut(c, attemptKeyForPresentedKey(provided)) {
+		return
+	}
+	h.registerFailedAttempt(c, req.clientIP, provided, req.localClient, reason)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": reason})
+}
+
+// registerFailedAttempt records a failed authentication in the audit log and,
+// for remote clients, counts it against the client IP and presented key prefix.
+func (h *Handler) registerFailedAttempt(c *gin.Context, clientIP, provided string, localClient bool, reason string) {
+	h.recordAudit(auditEntry{
+		ClientIP: clientIP,
+		Method:   c.Request.Method,
+		Path:     c.Request.URL.Path,
+		Status:   http.StatusUnauthorized,
+		Action:   "auth_failed",
+		Detail:   reason,
+	})
+	if localClient {
+		return
+	}
+	keys := []string{attemptKeyForIP(clientIP)}
+	if provided != "" {
+		keys = append(keys, attemptKeyForPresentedKey(provided))
+	}
+	now := time.Now()
+	type lockout struct {
+		key      string
+		duration time.Duration
+		until    time.Time
+	}
+	var locked []lockout
+	h.attemptsMu.Lock()
+	for _, key := range keys {
+		ai := h.attemptEntryLocked(key, now)
+		if ai == nil {
+			continue
+		}
+		if now.Sub(ai.windowStart) > attemptFailureWindow {
+			ai.count = 0
+			ai.windowStart = now
+		}
+		ai.count++
+		ai.lastActivity = now
+		if ai.count >= attemptMaxFailures {
+			ai.lockouts++
+			d := lockoutDuration(ai.lockouts)
+			ai.blockedUntil = now.Add(d)
+			ai.count = 0
+			ai.windowStart = now
+			locked = append(locked, lockout{key: key, duration: d, until: ai.blockedUntil})
+		}
+	}
+	h.attemptsMu.Unlock()
+
+	for _, item := range locked {
+		detail := fmt.Sprintf("%s locked out for %s", item.key, item.duration)
+		h.recordAudit(auditEntry{
+			ClientIP: clientIP,
+			Method:   c.Request.Method,
+			Path:     c.Request.URL.Path,
+			Status:   http.StatusTooManyRequests,
+			Action:   "lockout",
+			Detail:   detail,
+		})
+		h.notifyLockout(item.key, clientIP, item.duration, item.until)
+	}
+}
+
+func (h *Handler) notifyLockout(key, clientIP string, duration time.Duration, until time.Time) {
+	cfg := h.cfg
+	if cfg == nil || !cfg.RemoteManagement.NotifyLockouts || !notify.HasChannels(cfg.Notifications) {
+		return
+	}
+	event := notify.Event{
+		Type:     "security.lockout",
+		Severity: "warning",
+		Title:    "Management authentication lockout",
+		Message:  fmt.Sprintf("%s locked out for %s after repeated failed management logins from %s", key, duration, clientIP),
+		Data: map[string]any{
+			"key":       key,
+			"client_ip": clientIP,
+			"until":     until.UTC(),
+		},
+	}
+	h.life.goWorker(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), alertNotifyTimeout)
+		defer cancel()
+		if err := notify.Send(ctx, cfg.Notifications, event); err != nil {
+			log.Warnf("failed to send lockout notification: %v", err)
+		}
+	})
+}
+
+// resetAttempts clears failure counters and lockout backoff after a successful login.
+func (h *Handler) resetAttempts(keys ...string) {
+	h.attemptsMu.Lock()
+	for _, key := range keys {
+		delete(h.failedAttempts, key)
+	}
+	h.attemptsMu.Unlock()
+}
+
+// GetSecurityLockouts lists active lockouts and sources with recent failures.
+func (h *Handler) GetSecurityLockouts(c *gin.Context) {
+	now := time.Now()
+	h.attemptsMu.Lock()
+	items := make([]gin.H, 0, len(h.failedAttempts))
+	for key, ai := range h.failedAttempts {
+		locked := now.Before(ai.blockedUntil)
+		if !locked && ai.count == 0 {
+			continue
+		}
+		item := gin.H{
+			"key":           key,
+			"locked":        locked,
+			"failures":      ai.count,
+			"lockouts":      ai.lockouts,
+			"last_activity": ai.lastActivity,
+		}
+		if locked {
+			item["locked_until"] = ai.blockedUntil
+			item["retry_after"] = int(ai.blockedUntil.Sub(now).Seconds())
+		}
+		items = append(items, item)
+	}
+	tracked := len(h.failedAttempts)
+	h.attemptsMu.Unlock()
+	sort.Slice(items, func(i, j int) bool { return items[i]["key"].(string) < items[j]["key"].(string) })
+	c.JSON(http.StatusOK, gin.H{"lockouts": items, "tracked": tracked})
+}
+
+// DeleteSecurityLockouts unlocks ?key=<lockout key> or ?all=true.
+func (h *Handler) DeleteSecurityLockouts(c *gin.Context) {
+	key := strings.TrimSpace(c.Query("key"))
+	all := queryTruthy(c.Query("all"))
+	if key == "" && !all {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key or all=true is required"})
+		return
+	}
+	h.attemptsMu.Lock()
+	removed := 0
+	if all {
+		removed = len(h.failedAttempts)
+		h.failedAttempts = make(map[string]*attemptInfo)
+	} else if _, ok := h.failedAttempts[key]; ok {
+		delete(h.failedAttempts, key)
+		removed = 1
+	}
+	h.attemptsMu.Unlock()
+	if removed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "lockout not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "removed": removed})
+}
@@ -0,0 +1,382 @@
+package management
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	sessionTokenPrefix           = "cpas_"
+	defaultManagementSessionTTL  = time.Hour
+	maxManagementSessions        = 1000
+	managementSessionIDBytes     = 8
+	managementSessionSecretBytes = 32
+)
+
+// managementSession is a server-side login session. Only a digest of the
+// token secret is kept, so a memory dump does not reveal usable tokens.
+type managementSession struct {
+	ID         string
+	secretHash [sha256.Size]byte
+	Principal  managementPrincipal
+	IssuedAt   time.Time
+	LastSeen   time.Time
+	ExpiresAt  time.Time
+	ClientIP   string
+	UserAgent  string
+	// csrfToken must accompany unsafe requests authenticated by the session cookie.
+	csrfToken string
+}
+
+// sessionStore holds active management sessions. Revocation deletes the entry,
+// so it takes effect on the next request.
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*managementSession
+}
+
+func isSessionToken(token string) bool {
+	return strings.HasPrefix(token, sessionTokenPrefix)
+}
+
+// splitSessionToken parses "cpas_<id>.<secret>".
+func splitSessionToken(token string) (id, secret string, ok bool) {
+	if !isSessionToken(token) {
+		return "", "", false
+	}
+	id, secret, ok = strings.Cut(strings.TrimPrefix(token, sessionTokenPrefix), ".")
+	if !ok || id == "" || secret == "" {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (s *sessionStore) create(principal managementPrincipal, clientIP, userAgent string, ttl time.Duration) (string, managementSession, error) {
+	id, err := randomHex(managementSessionIDBytes)
+	if err != nil {
+		return "", managementSession{}, err
+	}
+	secret, err := randomHex(managementSessionSecretBytes)
+	if err != nil {
+		return "", managementSession{}, err
+	}
+	csrf, err := randomHex(managementSessionSecretBytes)
+	if err != nil {
+		return "", managementSession{}, err
+	}
+	now := time.Now()
+	principal.SessionID = id
+	sess := &managementSession{
+		ID:         id,
+		secretHash: sha256.Sum256([]byte(secret)),
+		Principal:  principal,
+		IssuedAt:   now,
+		LastSeen:   now,
+		ExpiresAt:  now.Add(ttl),
+		ClientIP:   clientIP,
+		UserAgent:  userAgent,
+		csrfToken:  csrf,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessions == nil {
+		s.sessions = make(map[string]*managementSession)
+	}
+	s.purgeExpiredLocked(now)
+	if len(s.sessions) >= maxManagementSessions {
+		var oldest *managementSession
+		for _, existing := range s.sessions {
+			if oldest == nil || existing.LastSeen.Before(oldest.LastSeen) {
+				oldest = existing
+			}
+		}
+		delete(s.sessions, oldest.ID)
+	}
+	s.sessions[id] = sess
+	return sessionTokenPrefix + id + "." + secret, *sess, nil
+}
+
+func (s *sessionStore) purgeExpiredLocked(now time.Time) {
+	for id, sess := range s.sessions {
+		if !now.Before(sess.ExpiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// validate returns the principal of the session identified by token and marks it as seen.
+func (s *sessionStore) validate(token string, now time.Time) (managementPrincipal, bool) {
+	id, secret, ok := splitSessionToken(token)
+	if !ok {
+		return managementPrincipal{}, false
+	}
+	digest := sha256.Sum256([]byte(secret))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess := s.sessions[id]
+	if sess == nil {
+		return managementPrincipal{}, false
+	}
+	if subtle.ConstantTimeCompare(digest[:], sess.secretHash[:]) != 1 {
+		return managementPrincipal{}, false
+	}
+	if !now.Before(sess.ExpiresAt) {
+		delete(s.sessions, id)
+		return managementPrincipal{}, false
+	}
+	sess.LastSeen = now
+	return sess.Principal, true
+}
+
+// refresh slides the expiry of session id forward by ttl.
+func (s *sessionStore) refresh(id string, ttl time.Duration) (managementSession, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess := s.sessions[id]
+	if sess == nil {
+		return managementSession{}, false
+	}
+	now := time.Now()
+	sess.LastSeen = now
+	sess.ExpiresAt = now.Add(ttl)
+	return *sess, true
+}
+
+// csrf returns the CSRF token of session id.
+func (s *sessionStore) csrf(id string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess := s.sessions[id]
+	if sess == nil {
+		return "", false
+	}
+	return sess.csrfToken, true
+}
+
+func (s *sessionStore) revoke(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[id]; !ok {
+		return false
+	}
+	delete(s.sessions, id)
+	return true
+}
+
+// revokeUser removes every session of the management user name. The
+// built-in principals, such as the secret key's "admin", may share a user's
+// name and keep their sessions.
+func (s *sessionStore) revokeUser(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := 0
+	for id, sess := range s.sessions {
+		if sess.Principal.Source == "user" && sess.Principal.Name == name {
+			delete(s.sessions, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// revokeAllExcept removes every session other than keep and returns their IDs.
+func (s *sessionStore) revokeAllExcept(keep string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var removed []string
+	for id := range s.sessions {
+		if id != keep {
+			delete(s.sessions, id)
+			removed = append(removed, id)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+func (s *sessionStore) list() []managementSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeExpiredLocked(time.Now())
+	out := make([]managementSession, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		out = append(out, *sess)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].IssuedAt.Before(out[j].IssuedAt) })
+	return out
+}
+
+func (h *Handler) managementSessionTTL() time.Duration {
+	if h.cfg != nil && h.cfg.RemoteManagement.SessionTTLSeconds > 0 {
+		return time.Duration(h.cfg.RemoteManagement.SessionTTLSeconds) * time.Second
+	}
+	return defaultManagementSessionTTL
+}
+
+func sessionPayload(sess managementSession) gin.H {
+	return gin.H{
+		"id":         sess.ID,
+		"name":       sess.Principal.Name,
+		"role":       string(sess.Principal.Role),
+		"issued_at":  sess.IssuedAt,
+		"last_seen":  sess.LastSeen,
+		"expires_at": sess.ExpiresAt,
+		"ip":         sess.ClientIP,
+		"user_agent": sess.UserAgent,
+	}
+}
+
+// Login exchanges a management key for a session token. It runs the same
+// network, lockout and remote-access checks as Middleware. Users with TOTP
+// enabled must also send totp_code, which may be a recovery code.
+func (h *Handler) Login(c *gin.Context) {
+	req, ok := h.admitManagementRequest(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		Key      string `json:"key"`
+		TOTPCode string `json:"totp_code"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+	provided := strings.TrimSpace(body.Key)
+	if provided == "" {
+		provided = managementKeyFromRequest(c)
+	}
+	if provided == "" || isSessionToken(provided) || isScopedToken(provided) {
+		h.registerFailedAttempt(c, req.clientIP, "", req.localClient, "missing management key")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing management key"})
+		return
+	}
+	keyKey := attemptKeyForPresentedKey(provided)
+	principal, ok := h.authenticateRawKey(req, provided)
+	if !ok {
+		h.rejectCredential(c, req, provided, "invalid management key")
+		return
+	}
+	if totpRequired(req.cfg, principal) {
+		user, _ := findManagementUser(req.cfg, principal.Name)
+		if strings.TrimSpace(body.TOTPCode) == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "totp code required", "totp_required": true})
+			return
+		}
+		// Failed codes count against the key, so whoever holds it cannot
+		// keep guessing codes once it is locked out.
+		if !req.localClient && h.abortIfLockedOut(c, keyKey) {
+			return
+		}
+		usedRecovery, okFactor := h.verifySecondFactor(user, body.TOTPCode)
+		if !okFactor {
+			h.rejectCredential(c, req, provided, "invalid totp code")
+			return
+		}
+		if usedRecovery {
+			h.auditTOTP(c, principal, "totp_recovery_code_used", principal.Name)
+		}
+	}
+	if !req.localClient {
+		h.resetAttempts(req.ipKey, keyKey)
+	}
+
+	principal = withClientCert(principal, req.clientCert)
+	token, sess, err := h.sessions.create(principal, req.clientIP, c.Request.UserAgent(), h.managementSessionTTL())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create session: %v", err)})
+		return
+	}
+	h.recordAudit(auditEntry{
+		Actor:      principal.Name,
+		Role:       string(principal.Role),
+		Source:     principal.Source,
+		ClientIP:   req.clientIP,
+		ClientCert: principal.ClientCert,
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		Status:     http.StatusOK,
+		Action:     "login",
+		Detail:     "session " + sess.ID,
+	})
+	setSessionCookies(c, token, sess.csrfToken)
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "ok",
+		"token":      token,
+		"expires_at": sess.ExpiresAt,
+		"session":    sessionPayload(sess),
+	})
+}
+
+// Logout revokes the session used to authenticate the request.
+func (h *Handler) Logout(c *gin.Context) {
+	principal, _ := principalFromContext(c)
+	if principal.SessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "request is not authenticated with a session"})
+		return
+	}
+	h.sessions.revoke(principal.SessionID)
+	clearSessionCookies(c)
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// RefreshSession extends the current session by the configured TTL.
+func (h *Handler) RefreshSession(c *gin.Context) {
+	principal, _ := principalFromContext(c)
+	if principal.SessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "request is not authenticated with a session"})
+		return
+	}
+	sess, ok := h.sessions.refresh(principal.SessionID, h.managementSessionTTL())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired session"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "expires_at": sess.ExpiresAt, "session": sessionPayload(sess)})
+}
+
+// ListSessions returns active management sessions.
+func (h *Handler) ListSessions(c *gin.Context) {
+	sessions := h.sessions.list()
+	out := make([]gin.H, 0, len(sessions))
+	for _, sess := range sessions {
+		out = append(out, sessionPayload(sess))
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": out})
+}
+
+// DeleteSession revokes the session named by ?id=.
+func (h *Handler) DeleteSession(c *gin.Context) {
+	id := strings.TrimSpace(c.Query("id"))
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+		return
+	}
+	if !h.sessions.revoke(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
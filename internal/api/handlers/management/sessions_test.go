@@ -0,0 +1,51 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// Deleting a user named like a built-in principal revokes only the user's
+// sessions.
+func TestDeleteManagementUser_KeepsBuiltinSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg := &config.Config{}
+	cfg.RemoteManagement.Users = []config.ManagementUser{{Name: "admin", Role: string(RoleViewer)}}
+	h := &Handler{cfg: cfg, configFilePath: configPath}
+	tokens := make(map[string]string)
+	for _, principal := range []managementPrincipal{
+		{Name: "admin", Role: RoleAdmin, Source: "secret-key"},
+		{Name: "admin", Role: RoleAdmin, Source: "env"},
+		{Name: "admin", Role: RoleViewer, Source: "user"},
+	} {
+		token, _, err := h.sessions.create(principal, "127.0.0.1", "test", time.Hour)
+		if err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+		tokens[principal.Source] = token
+	}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/v0/management/users?name=admin", nil)
+	h.DeleteManagementUser(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete user: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	for source, token := range tokens {
+		if _, ok := h.sessions.validate(token, time.Now()); ok != (source != "user") {
+			t.Errorf("%s session valid = %v after deleting user admin", source, ok)
+		}
+	}
+}
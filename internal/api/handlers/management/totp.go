@@ -0,0 +1,271 @@
+package management
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/totp"
+)
+
+const (
+	totpIssuer = "CLIProxyAPI"
+	// totpSkew accepts codes from one step before or after the current one.
+	totpSkew           = 1
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10 // hex characters, printed as two groups of five
+)
+
+var (
+	errManagementUserNotFound = errors.New("user not found")
+	errTOTPAlreadyEnabled     = errors.New("totp already enabled; disable it first")
+)
+
+func findManagementUser(cfg *config.Config, name string) (config.ManagementUser, bool) {
+	if cfg == nil {
+		return config.ManagementUser{}, false
+	}
+	for _, user := range cfg.RemoteManagement.Users {
+		if user.Name == name {
+			return user, true
+		}
+	}
+	return config.ManagementUser{}, false
+}
+
+// totpRequired reports whether principal must present a second factor.
+func totpRequired(cfg *config.Config, principal managementPrincipal) bool {
+	if principal.Source != "user" {
+		return false
+	}
+	user, ok := findManagementUser(cfg, principal.Name)
+	return ok && user.TOTPEnabled
+}
+
+// updateManagementUser applies mutate to the named user and persists the
+// config, restoring the previous users on failure.
+func (h *Handler) updateManagementUser(name string, mutate func(*config.ManagementUser) error) (config.ManagementUser, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	oldUsers := h.cfg.RemoteManagement.Users
+	next := append([]config.ManagementUser(nil), oldUsers...)
+	for i := range next {
+		if next[i].Name != name {
+			continue
+		}
+		if err := mutate(&next[i]); err != nil {
+			return config.ManagementUser{}, err
+		}
+		h.cfg.RemoteManagement.Users = next
+		if err := config.SaveConfigPreserveComments(h.configFilePath, h.cfg); err != nil {
+			h.cfg.RemoteManagement.Users = oldUsers
+			return config.ManagementUser{}, fmt.Errorf("failed to save config: %w", err)
+		}
+		return next[i], nil
+	}
+	return config.ManagementUser{}, errManagementUserNotFound
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateRecoveryCodes() (plain []string, hashed []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, recoveryCodeLength/2)
+		if _, err = rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := hex.EncodeToString(buf)
+		code := raw[:recoveryCodeLength/2] + "-" + raw[recoveryCodeLength/2:]
+		plain = append(plain, code)
+		hashed = append(hashed, hashRecoveryCode(code))
+	}
+	return plain, hashed, nil
+}
+
+// acceptTOTPStep records step as used for name and reports whether it is newer
+// than the last accepted step, so a code cannot be replayed inside its window.
+func (h *Handler) acceptTOTPStep(name string, step int64) bool {
+	h.totpMu.Lock()
+	defer h.totpMu.Unlock()
+	if last, ok := h.totpLastStep[name]; ok && step <= last {
+		return false
+	}
+	if h.totpLastStep == nil {
+		h.totpLastStep = make(map[string]int64)
+	}
+	h.totpLastStep[name] = step
+	return true
+}
+
+// verifySecondFactor checks code as a TOTP code and then as a recovery code.
+// A matching recovery code is consumed.
+func (h *Handler) verifySecondFactor(user config.ManagementUser, code string) (usedRecovery bool, ok bool) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return false, false
+	}
+	if step, valid := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew); valid {
+		return false, h.acceptTOTPStep(user.Name, step)
+	}
+	hashed := hashRecoveryCode(code)
+	_, err := h.updateManagementUser(user.Name, func(u *config.ManagementUser) error {
+		for i, candidate := range u.RecoveryCodes {
+			if candidate == hashed {
+				u.RecoveryCodes = append(append([]string(nil), u.RecoveryCodes[:i]...), u.RecoveryCodes[i+1:]...)
+				return nil
+			}
+		}
+		return errors.New("no matching recovery code")
+	})
+	return err == nil, err == nil
+}
+
+// authorizeTOTPChange allows admins to manage any user and users to manage themselves.
+func authorizeTOTPChange(c *gin.Context, name string) (managementPrincipal, bool) {
+	principal, _ := principalFromContext(c)
+	if principal.Role == RoleAdmin || (principal.Source == "user" && principal.Name == name) {
+		return principal, true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "only admins or the user itself may manage TOTP"})
+	return principal, false
+}
+
+func (h *Handler) auditTOTP(c *gin.Context, principal managementPrincipal, action, name string) {
+	h.recordAudit(auditEntry{
+		Actor:    principal.Name,
+		Role:     string(principal.Role),
+		Source:   principal.Source,
+		ClientIP: h.managementClientIP(c),
+		Method:   c.Request.Method,
+		Path:     c.Request.URL.Path,
+		Status:   http.StatusOK,
+		Action:   action,
+		Detail:   "user " + name,
+	})
+}
+
+func writeUserUpdateError(c *gin.Context, err error) {
+	if errors.Is(err, errManagementUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// EnrollUserTOTP generates a TOTP secret and recovery codes for the user. The
+// factor is not enforced until VerifyUserTOTP confirms a code. Recovery codes
+// are only returned in this response.
+func (h *Handler) EnrollUserTOTP(c *gin.Context) {
+	name := strings.TrimSpace(c.Param("name"))
+	principal, ok := authorizeTOTPChange(c, name)
+	if !ok {
+		return
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to generate secret: %v", err)})
+		return
+	}
+	codes, hashed, err := generateRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to generate recovery codes: %v", err)})
+		return
+	}
+	_, err = h.updateManagementUser(name, func(u *config.ManagementUser) error {
+		if u.TOTPEnabled {
+			return errTOTPAlreadyEnabled
+		}
+		u.TOTPSecret = secret
+		u.RecoveryCodes = hashed
+		return nil
+	})
+	if errors.Is(err, errTOTPAlreadyEnabled) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeUserUpdateError(c, err)
+		return
+	}
+	h.auditTOTP(c, principal, "totp_enroll", name)
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           secret,
+		"provisioning_uri": totp.ProvisioningURI(totpIssuer, name, secret),
+		"recovery_codes":   codes,
+	})
+}
+
+// VerifyUserTOTP activates a pending enrollment once the user proves possession
+// of the secret.
+func (h *Handler) VerifyUserTOTP(c *gin.Context) {
+	name := strings.TrimSpace(c.Param("name"))
+	principal, ok := authorizeTOTPChange(c, name)
+	if !ok {
+		return
+	}
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Code) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+	user, found := findManagementUser(h.cfg, name)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": errManagementUserNotFound.Error()})
+		return
+	}
+	if user.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "totp is not enrolled"})
+		return
+	}
+	step, valid := totp.Validate(user.TOTPSecret, body.Code, time.Now(), totpSkew)
+	if !valid || !h.acceptTOTPStep(name, step) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid totp code"})
+		return
+	}
+	if _, err := h.updateManagementUser(name, func(u *config.ManagementUser) error {
+		u.TOTPEnabled = true
+		return nil
+	}); err != nil {
+		writeUserUpdateError(c, err)
+		return
+	}
+	h.auditTOTP(c, principal, "totp_enable", name)
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// DisableUserTOTP removes the user's TOTP secret and recovery codes.
+func (h *Handler) DisableUserTOTP(c *gin.Context) {
+	name := strings.TrimSpace(c.Param("name"))
+	principal, _ := principalFromContext(c)
+	if _, err := h.updateManagementUser(name, func(u *config.ManagementUser) error {
+		u.TOTPSecret = ""
+		u.TOTPEnabled = false
+		u.RecoveryCodes = nil
+		return nil
+	}); err != nil {
+		writeUserUpdateError(c, err)
+		return
+	}
+	h.totpMu.Lock()
+	delete(h.totpLastStep, name)
+	h.totpMu.Unlock()
+	h.auditTOTP(c, principal, "totp_disable", name)
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
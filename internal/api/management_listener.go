@@ -0,0 +1,106 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+// managementListenAddr returns the dedicated management address, or "" when
+// management routes share the main listener.
+func managementListenAddr(cfg *config.Config) string {
+	if cfg == nil {
+		return ""
+	}
+	return strings.TrimSpace(cfg.Management.Listen)
+}
+
+// sameListenAddress reports whether two host:port listen addresses would
+// collide. Hosts are compared literally, except that a wildcard host collides
+// with every host on the same port.
+func sameListenAddress(a, b string) bool {
+	hostA, portA, errA := net.SplitHostPort(a)
+	hostB, portB, errB := net.SplitHostPort(b)
+	if errA != nil || errB != nil {
+		return strings.EqualFold(a, b)
+	}
+	if portA != portB || portA == "0" {
+		return false
+	}
+	wildcard := func(host string) bool { return host == "" || host == "0.0.0.0" || host == "::" }
+	return wildcard(hostA) || wildcard(hostB) || strings.EqualFold(hostA, hostB)
+}
+
+// newManagementEngine builds the engine for the dedicated management listener.
+// Proxy routes are never registered on it.
+func newManagementEngine(skipCORS func(*gin.Context) bool) *gin.Engine {
+	engine := gin.New()
+	engine.Use(logging.GinLogrusLogger())
+	engine.Use(logging.GinLogrusRecovery())
+	engine.Use(corsMiddleware(skipCORS))
+	return engine
+}
+
+// managementEngine returns the engine hosting management routes.
+func (s *Server) managementEngine() *gin.Engine {
+	if s.mgmtEngine != nil {
+		return s.mgmtEngine
+	}
+	return s.engine
+}
+
+// startManagementListener binds the dedicated management listener and serves
+// it in the background. Binding happens synchronously so configuration errors
+// abort startup.
+func (s *Server) startManagementListener() error {
+	if s.mgmtServer == nil {
+		return nil
+	}
+	addr := s.mgmtServer.Addr
+	if sameListenAddress(addr, s.server.Addr) {
+		return fmt.Errorf("failed to start management server: management.listen %s conflicts with the main listener %s", addr, s.server.Addr)
+	}
+
+	useTLS := s.cfg.Management.TLS.Enable
+	if useTLS {
+		reloader := newManagementTLSReloader(func() *config.Config { return s.cfg })
+		if _, errCert := reloader.certificate(); errCert != nil {
+			return fmt.Errorf("failed to start management server: %v", errCert)
+		}
+		if mtls := s.cfg.TLS.Management.MTLS; mtls.Enable {
+			if _, errCA := reloader.clientCAs(strings.TrimSpace(mtls.ClientCA)); errCA != nil {
+				return fmt.Errorf("failed to start management server: management mTLS: %v", errCA)
+			}
+		}
+		s.mgmtServer.TLSConfig = reloader.serverConfig()
+	} else if s.cfg.TLS.Management.MTLS.Enable {
+		log.Warn("tls.management.mtls is enabled but management.tls.enable is false; management requests cannot present client certificates")
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start management server: %v", err)
+	}
+	go func() {
+		var errServe error
+		if useTLS {
+			log.Debugf("Starting management server on %s with TLS", addr)
+			errServe = s.mgmtServer.ServeTLS(ln, "", "")
+		} else {
+			log.Debugf("Starting management server on %s", addr)
+			errServe = s.mgmtServer.Serve(ln)
+		}
+		if errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
+			log.Errorf("management server stopped: %v", errServe)
+		}
+	}()
+	log.Infof("management API listening on %s", addr)
+	return nil
+}
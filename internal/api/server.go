@@ -51,6 +51,7 @@ type serverOptionConfig struct {
 	keepAliveEnabled     bool
 	keepAliveTimeout     time.Duration
 	keepAliveOnTimeout   func()
+	inspector            *auth.Inspector
 }
 
 // ServerOption customises HTTP server construction.
@@ -104,6 +105,14 @@ func WithKeepAliveEndpoint(timeout time.Duration, onTimeout func()) ServerOption
 	}
 }
 
+// WithInspector makes management inspections verify and delete auths through
+// inspector, so its custom probes and event subscribers apply to them.
+func WithInspector(inspector *auth.Inspector) ServerOption {
+	return func(cfg *serverOptionConfig) {
+		cfg.inspector = inspector
+	}
+}
+
 // WithRequestLoggerFactory customises request logger creation.
 func WithRequestLoggerFactory(factory func(*config.Config, string) logging.RequestLogger) ServerOption {
 	return func(cfg *serverOptionConfig) {
@@ -152,6 +161,11 @@ type Server struct {
 	// management handler
 	mgmt *managementHandlers.Handler
 
+	// mgmtEngine and mgmtServer serve the management API on management.listen.
+	// Both are nil when management routes share the main listener.
+	mgmtEngine *gin.Engine
+	mgmtServer *http.Server
+
 	// ampModule is the Amp routing module for model mapping hot-reload
 	ampModule *ampmodule.AmpModule
 
@@ -223,7 +237,12 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		}
 	}
 
-	engine.Use(corsMiddleware())
+	var s *Server
+	skipCORS := func(c *gin.Context) bool {
+		// Management routes use their own CORS policy once one is configured.
+		return s != nil && managementHandlers.CORSEnabled(s.cfg) && strings.HasPrefix(c.Request.URL.Path, "/v0/management")
+	}
+	engine.Use(corsMiddleware(skipCORS))
 	wd, err := os.Getwd()
 	if err != nil {
 		wd = configFilePath
@@ -234,7 +253,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	envManagementSecret := envAdminPasswordSet && envAdminPassword != ""
 
 	// Create server instance
-	s := &Server{
+	s = &Server{
 		engine:              engine,
 		handlers:            handlers.NewBaseAPIHandlers(&cfg.SDKConfig, authManager),
 		cfg:                 cfg,
@@ -246,6 +265,10 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		envManagementSecret: envManagementSecret,
 		wsRoutes:            make(map[string]struct{}),
 	}
+	if addr := managementListenAddr(cfg); addr != "" {
+		s.mgmtEngine = newManagementEngine(skipCORS)
+		s.mgmtServer = &http.Server{Addr: addr, Handler: s.mgmtEngine}
+	}
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
 	// Save initial YAML snapshot
 	s.oldConfigYaml, _ = yaml.Marshal(cfg)
@@ -260,6 +283,9 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	if optionState.localPassword != "" {
 		s.mgmt.SetLocalPassword(optionState.localPassword)
 	}
+	if optionState.inspector != nil {
+		s.mgmt.SetInspector(optionState.inspector)
+	}
 	logDir := logging.ResolveLogDirectory(cfg)
 	s.mgmt.SetLogDirectory(logDir)
 	s.localPassword = optionState.localPassword
@@ -307,7 +333,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 // setupRoutes configures the API routes for the server.
 // It defines the endpoints and associates them with their respective handlers.
 func (s *Server) setupRoutes() {
-	s.engine.GET("/management.html", s.serveManagementControlPanel)
+	s.managementEngine().GET("/management.html", s.serveManagementControlPanel)
 	openaiHandlers := openai.NewOpenAIAPIHandler(s.handlers)
 	geminiHandlers := gemini.NewGeminiAPIHandler(s.handlers)
 	geminiCLIHandlers := gemini.NewGeminiCLIAPIHandler(s.handlers)
@@ -469,174 +495,300 @@ func (s *Server) registerManagementRoutes() {
 	if !s.managementRoutesRegistered.CompareAndSwap(false, true) {
 		return
 	}
+	engine := s.managementEngine()
 
 	log.Info("management routes registered after secret key configuration")
 
-	mgmt := s.engine.Group("/v0/management")
-	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
+	// Preflight requests are answered by the CORS middleware without authentication.
+	engine.OPTIONS("/v0/management/*path", s.managementAvailabilityMiddleware(), s.mgmt.CORSMiddleware(), func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusNoContent)
+	})
+
+	// Login exchanges a management key for a session token, so it sits outside the
+	// authenticated group and performs its own checks.
+	engine.POST("/v0/management/login", s.managementAvailabilityMiddleware(), s.mgmt.CORSMiddleware(), s.mgmt.Login)
+	// The auth status webhook is authenticated by its HMAC signature, not a management key.
+	engine.POST("/v0/management/hooks/auth-status", s.managementAvailabilityMiddleware(), s.mgmt.AuthStatusHook)
+
+	mgmt := engine.Group("/v0/management")
+	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.CORSMiddleware(), s.mgmt.Middleware())
+
+	// Every route declares the minimum management role it requires and the
+	// scope a scoped token needs to call it.
+	viewer := scopedRoutes{group: mgmt.Group("", s.mgmt.RequireRole(managementHandlers.RoleViewer)), mgmt: s.mgmt}
+	operator := scopedRoutes{group: mgmt.Group("", s.mgmt.RequireRole(managementHandlers.RoleOperator)), mgmt: s.mgmt}
+	admin := scopedRoutes{group: mgmt.Group("", s.mgmt.RequireRole(managementHandlers.RoleAdmin)), mgmt: s.mgmt}
 	{
-		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
-		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
-		mgmt.POST("/usage/import", s.mgmt.ImportUsageStatistics)
-		mgmt.GET("/config", s.mgmt.GetConfig)
-		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
-		mgmt.PUT("/config.yaml", s.mgmt.PutConfigYAML)
-		mgmt.GET("/latest-version", s.mgmt.GetLatestVersion)
-
-		mgmt.GET("/debug", s.mgmt.GetDebug)
-		mgmt.PUT("/debug", s.mgmt.PutDebug)
-		mgmt.PATCH("/debug", s.mgmt.PutDebug)
-
-		mgmt.GET("/logging-to-file", s.mgmt.GetLoggingToFile)
-		mgmt.PUT("/logging-to-file", s.mgmt.PutLoggingToFile)
-		mgmt.PATCH("/logging-to-file", s.mgmt.PutLoggingToFile)
-
-		mgmt.GET("/logs-max-total-size-mb", s.mgmt.GetLogsMaxTotalSizeMB)
-		mgmt.PUT("/logs-max-total-size-mb", s.mgmt.PutLogsMaxTotalSizeMB)
-		mgmt.PATCH("/logs-max-total-size-mb", s.mgmt.PutLogsMaxTotalSizeMB)
-
-		mgmt.GET("/error-logs-max-files", s.mgmt.GetErrorLogsMaxFiles)
-		mgmt.PUT("/error-logs-max-files", s.mgmt.PutErrorLogsMaxFiles)
-		mgmt.PATCH("/error-logs-max-files", s.mgmt.PutErrorLogsMaxFiles)
-
-		mgmt.GET("/usage-statistics-enabled", s.mgmt.GetUsageStatisticsEnabled)
-		mgmt.PUT("/usage-statistics-enabled", s.mgmt.PutUsageStatisticsEnabled)
-		mgmt.PATCH("/usage-statistics-enabled", s.mgmt.PutUsageStatisticsEnabled)
-
-		mgmt.GET("/proxy-url", s.mgmt.GetProxyURL)
-		mgmt.PUT("/proxy-url", s.mgmt.PutProxyURL)
-		mgmt.PATCH("/proxy-url", s.mgmt.PutProxyURL)
-		mgmt.DELETE("/proxy-url", s.mgmt.DeleteProxyURL)
-
-		mgmt.POST("/api-call", s.mgmt.APICall)
-
-		mgmt.GET("/quota-exceeded/switch-project", s.mgmt.GetSwitchProject)
-		mgmt.PUT("/quota-exceeded/switch-project", s.mgmt.PutSwitchProject)
-		mgmt.PATCH("/quota-exceeded/switch-project", s.mgmt.PutSwitchProject)
-
-		mgmt.GET("/quota-exceeded/switch-preview-model", s.mgmt.GetSwitchPreviewModel)
-		mgmt.PUT("/quota-exceeded/switch-preview-model", s.mgmt.PutSwitchPreviewModel)
-		mgmt.PATCH("/quota-exceeded/switch-preview-model", s.mgmt.PutSwitchPreviewModel)
-
-		mgmt.GET("/api-keys", s.mgmt.GetAPIKeys)
-		mgmt.PUT("/api-keys", s.mgmt.PutAPIKeys)
-		mgmt.PATCH("/api-keys", s.mgmt.PatchAPIKeys)
-		mgmt.DELETE("/api-keys", s.mgmt.DeleteAPIKeys)
-
-		mgmt.GET("/gemini-api-key", s.mgmt.GetGeminiKeys)
-		mgmt.PUT("/gemini-api-key", s.mgmt.PutGeminiKeys)
-		mgmt.PATCH("/gemini-api-key", s.mgmt.PatchGeminiKey)
-		mgmt.DELETE("/gemini-api-key", s.mgmt.DeleteGeminiKey)
-
-		mgmt.GET("/logs", s.mgmt.GetLogs)
-		mgmt.DELETE("/logs", s.mgmt.DeleteLogs)
-		mgmt.GET("/request-error-logs", s.mgmt.GetRequestErrorLogs)
-		mgmt.GET("/request-error-logs/:name", s.mgmt.DownloadRequestErrorLog)
-		mgmt.GET("/request-log-by-id/:id", s.mgmt.GetRequestLogByID)
-		mgmt.GET("/request-log", s.mgmt.GetRequestLog)
-		mgmt.PUT("/request-log", s.mgmt.PutRequestLog)
-		mgmt.PATCH("/request-log", s.mgmt.PutRequestLog)
-		mgmt.GET("/ws-auth", s.mgmt.GetWebsocketAuth)
-		mgmt.PUT("/ws-auth", s.mgmt.PutWebsocketAuth)
-		mgmt.PATCH("/ws-auth", s.mgmt.PutWebsocketAuth)
-
-		mgmt.GET("/ampcode", s.mgmt.GetAmpCode)
-		mgmt.GET("/ampcode/upstream-url", s.mgmt.GetAmpUpstreamURL)
-		mgmt.PUT("/ampcode/upstream-url", s.mgmt.PutAmpUpstreamURL)
-		mgmt.PATCH("/ampcode/upstream-url", s.mgmt.PutAmpUpstreamURL)
-		mgmt.DELETE("/ampcode/upstream-url", s.mgmt.DeleteAmpUpstreamURL)
-		mgmt.GET("/ampcode/upstream-api-key", s.mgmt.GetAmpUpstreamAPIKey)
-		mgmt.PUT("/ampcode/upstream-api-key", s.mgmt.PutAmpUpstreamAPIKey)
-		mgmt.PATCH("/ampcode/upstream-api-key", s.mgmt.PutAmpUpstreamAPIKey)
-		mgmt.DELETE("/ampcode/upstream-api-key", s.mgmt.DeleteAmpUpstreamAPIKey)
-		mgmt.GET("/ampcode/restrict-management-to-localhost", s.mgmt.GetAmpRestrictManagementToLocalhost)
-		mgmt.PUT("/ampcode/restrict-management-to-localhost", s.mgmt.PutAmpRestrictManagementToLocalhost)
-		mgmt.PATCH("/ampcode/restrict-management-to-localhost", s.mgmt.PutAmpRestrictManagementToLocalhost)
-		mgmt.GET("/ampcode/model-mappings", s.mgmt.GetAmpModelMappings)
-		mgmt.PUT("/ampcode/model-mappings", s.mgmt.PutAmpModelMappings)
-		mgmt.PATCH("/ampcode/model-mappings", s.mgmt.PatchAmpModelMappings)
-		mgmt.DELETE("/ampcode/model-mappings", s.mgmt.DeleteAmpModelMappings)
-		mgmt.GET("/ampcode/force-model-mappings", s.mgmt.GetAmpForceModelMappings)
-		mgmt.PUT("/ampcode/force-model-mappings", s.mgmt.PutAmpForceModelMappings)
-		mgmt.PATCH("/ampcode/force-model-mappings", s.mgmt.PutAmpForceModelMappings)
-		mgmt.GET("/ampcode/upstream-api-keys", s.mgmt.GetAmpUpstreamAPIKeys)
-		mgmt.PUT("/ampcode/upstream-api-keys", s.mgmt.PutAmpUpstreamAPIKeys)
-		mgmt.PATCH("/ampcode/upstream-api-keys", s.mgmt.PatchAmpUpstreamAPIKeys)
-		mgmt.DELETE("/ampcode/upstream-api-keys", s.mgmt.DeleteAmpUpstreamAPIKeys)
-
-		mgmt.GET("/request-retry", s.mgmt.GetRequestRetry)
-		mgmt.PUT("/request-retry", s.mgmt.PutRequestRetry)
-		mgmt.PATCH("/request-retry", s.mgmt.PutRequestRetry)
-		mgmt.GET("/max-retry-interval", s.mgmt.GetMaxRetryInterval)
-		mgmt.PUT("/max-retry-interval", s.mgmt.PutMaxRetryInterval)
-		mgmt.PATCH("/max-retry-interval", s.mgmt.PutMaxRetryInterval)
-
-		mgmt.GET("/force-model-prefix", s.mgmt.GetForceModelPrefix)
-		mgmt.PUT("/force-model-prefix", s.mgmt.PutForceModelPrefix)
-		mgmt.PATCH("/force-model-prefix", s.mgmt.PutForceModelPrefix)
-
-		mgmt.GET("/routing/strategy", s.mgmt.GetRoutingStrategy)
-		mgmt.PUT("/routing/strategy", s.mgmt.PutRoutingStrategy)
-		mgmt.PATCH("/routing/strategy", s.mgmt.PutRoutingStrategy)
-
-		mgmt.GET("/claude-api-key", s.mgmt.GetClaudeKeys)
-		mgmt.PUT("/claude-api-key", s.mgmt.PutClaudeKeys)
-		mgmt.PATCH("/claude-api-key", s.mgmt.PatchClaudeKey)
-		mgmt.DELETE("/claude-api-key", s.mgmt.DeleteClaudeKey)
-
-		mgmt.GET("/codex-api-key", s.mgmt.GetCodexKeys)
-		mgmt.PUT("/codex-api-key", s.mgmt.PutCodexKeys)
-		mgmt.PATCH("/codex-api-key", s.mgmt.PatchCodexKey)
-		mgmt.DELETE("/codex-api-key", s.mgmt.DeleteCodexKey)
-
-		mgmt.GET("/openai-compatibility", s.mgmt.GetOpenAICompat)
-		mgmt.PUT("/openai-compatibility", s.mgmt.PutOpenAICompat)
-		mgmt.PATCH("/openai-compatibility", s.mgmt.PatchOpenAICompat)
-		mgmt.DELETE("/openai-compatibility", s.mgmt.DeleteOpenAICompat)
-
-		mgmt.GET("/vertex-api-key", s.mgmt.GetVertexCompatKeys)
-		mgmt.PUT("/vertex-api-key", s.mgmt.PutVertexCompatKeys)
-		mgmt.PATCH("/vertex-api-key", s.mgmt.PatchVertexCompatKey)
-		mgmt.DELETE("/vertex-api-key", s.mgmt.DeleteVertexCompatKey)
-
-		mgmt.GET("/oauth-excluded-models", s.mgmt.GetOAuthExcludedModels)
-		mgmt.PUT("/oauth-excluded-models", s.mgmt.PutOAuthExcludedModels)
-		mgmt.PATCH("/oauth-excluded-models", s.mgmt.PatchOAuthExcludedModels)
-		mgmt.DELETE("/oauth-excluded-models", s.mgmt.DeleteOAuthExcludedModels)
-
-		mgmt.GET("/oauth-model-alias", s.mgmt.GetOAuthModelAlias)
-		mgmt.PUT("/oauth-model-alias", s.mgmt.PutOAuthModelAlias)
-		mgmt.PATCH("/oauth-model-alias", s.mgmt.PatchOAuthModelAlias)
-		mgmt.DELETE("/oauth-model-alias", s.mgmt.DeleteOAuthModelAlias)
-
-		mgmt.GET("/auth-files", s.mgmt.ListAuthFiles)
-		mgmt.GET("/auth-files/models", s.mgmt.GetAuthFileModels)
-		mgmt.GET("/model-definitions/:channel", s.mgmt.GetStaticModelDefinitions)
-		mgmt.GET("/auth-files/download", s.mgmt.DownloadAuthFile)
-		mgmt.POST("/auth-files", s.mgmt.UploadAuthFile)
-		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
-		mgmt.POST("/auth-files/verify-invalid", s.mgmt.VerifyInvalidAuthFiles)
-		mgmt.GET("/auth-files/inspection-config", s.mgmt.GetAuthInspectionConfig)
-		mgmt.PUT("/auth-files/inspection-config", s.mgmt.PutAuthInspectionConfig)
-		mgmt.PATCH("/auth-files/inspection-config", s.mgmt.PutAuthInspectionConfig)
-		mgmt.GET("/auth-files/inspection-status", s.mgmt.GetAuthInspectionStatus)
-		mgmt.POST("/auth-files/inspection-run", s.mgmt.RunAuthInspectionNow)
-		mgmt.PATCH("/auth-files/status", s.mgmt.PatchAuthFileStatus)
-		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
-
-		mgmt.GET("/anthropic-auth-url", s.mgmt.RequestAnthropicToken)
-		mgmt.GET("/codex-auth-url", s.mgmt.RequestCodexToken)
-		mgmt.GET("/gemini-cli-auth-url", s.mgmt.RequestGeminiCLIToken)
-		mgmt.GET("/antigravity-auth-url", s.mgmt.RequestAntigravityToken)
-		mgmt.GET("/qwen-auth-url", s.mgmt.RequestQwenToken)
-		mgmt.GET("/kimi-auth-url", s.mgmt.RequestKimiToken)
-		mgmt.GET("/iflow-auth-url", s.mgmt.RequestIFlowToken)
-		mgmt.POST("/iflow-auth-url", s.mgmt.RequestIFlowCookieToken)
-		mgmt.POST("/oauth-callback", s.mgmt.PostOAuthCallback)
-		mgmt.GET("/get-auth-status", s.mgmt.GetAuthStatus)
+		viewer.GET("/usage", managementHandlers.ScopeUsageRead, s.mgmt.GetUsageStatistics)
+		viewer.GET("/usage/export", managementHandlers.ScopeUsageRead, s.mgmt.ExportUsageStatistics)
+		operator.POST("/usage/import", managementHandlers.ScopeUsageWrite, s.mgmt.ImportUsageStatistics)
+		admin.GET("/config", managementHandlers.ScopeSecretsRead, s.mgmt.GetConfig)
+		admin.GET("/config.yaml", managementHandlers.ScopeSecretsRead, s.mgmt.GetConfigYAML)
+		admin.PUT("/config.yaml", managementHandlers.ScopeSecretsWrite, s.mgmt.PutConfigYAML)
+		viewer.GET("/latest-version", managementHandlers.ScopeConfigRead, s.mgmt.GetLatestVersion)
+
+		viewer.GET("/debug", managementHandlers.ScopeConfigRead, s.mgmt.GetDebug)
+		admin.PUT("/debug", managementHandlers.ScopeConfigWrite, s.mgmt.PutDebug)
+		admin.PATCH("/debug", managementHandlers.ScopeConfigWrite, s.mgmt.PutDebug)
+		viewer.GET("/debug/runtime", managementHandlers.ScopeDebugRead, s.mgmt.GetDebugRuntime)
+		admin.GET("/debug/pprof/*profile", managementHandlers.ScopeDebugRead, s.mgmt.DebugPprof)
+		admin.POST("/debug/pprof/*profile", managementHandlers.ScopeDebugRead, s.mgmt.DebugPprof)
+
+		viewer.GET("/logging-to-file", managementHandlers.ScopeConfigRead, s.mgmt.GetLoggingToFile)
+		admin.PUT("/logging-to-file", managementHandlers.ScopeConfigWrite, s.mgmt.PutLoggingToFile)
+		admin.PATCH("/logging-to-file", managementHandlers.ScopeConfigWrite, s.mgmt.PutLoggingToFile)
+
+		viewer.GET("/logs-max-total-size-mb", managementHandlers.ScopeConfigRead, s.mgmt.GetLogsMaxTotalSizeMB)
+		admin.PUT("/logs-max-total-size-mb", managementHandlers.ScopeConfigWrite, s.mgmt.PutLogsMaxTotalSizeMB)
+		admin.PATCH("/logs-max-total-size-mb", managementHandlers.ScopeConfigWrite, s.mgmt.PutLogsMaxTotalSizeMB)
+
+		viewer.GET("/error-logs-max-files", managementHandlers.ScopeConfigRead, s.mgmt.GetErrorLogsMaxFiles)
+		admin.PUT("/error-logs-max-files", managementHandlers.ScopeConfigWrite, s.mgmt.PutErrorLogsMaxFiles)
+		admin.PATCH("/error-logs-max-files", managementHandlers.ScopeConfigWrite, s.mgmt.PutErrorLogsMaxFiles)
+
+		viewer.GET("/usage-statistics-enabled", managementHandlers.ScopeConfigRead, s.mgmt.GetUsageStatisticsEnabled)
+		admin.PUT("/usage-statistics-enabled", managementHandlers.ScopeConfigWrite, s.mgmt.PutUsageStatisticsEnabled)
+		admin.PATCH("/usage-statistics-enabled", managementHandlers.ScopeConfigWrite, s.mgmt.PutUsageStatisticsEnabled)
+
+		viewer.GET("/proxy-url", managementHandlers.ScopeConfigRead, s.mgmt.GetProxyURL)
+		admin.PUT("/proxy-url", managementHandlers.ScopeConfigWrite, s.mgmt.PutProxyURL)
+		admin.PATCH("/proxy-url", managementHandlers.ScopeConfigWrite, s.mgmt.PutProxyURL)
+		admin.DELETE("/proxy-url", managementHandlers.ScopeConfigWrite, s.mgmt.DeleteProxyURL)
+
+		operator.POST("/api-call", managementHandlers.ScopeAuthFilesWrite, s.mgmt.APICall)
+
+		viewer.GET("/quota-exceeded/switch-project", managementHandlers.ScopeConfigRead, s.mgmt.GetSwitchProject)
+		admin.PUT("/quota-exceeded/switch-project", managementHandlers.ScopeConfigWrite, s.mgmt.PutSwitchProject)
+		admin.PATCH("/quota-exceeded/switch-project", managementHandlers.ScopeConfigWrite, s.mgmt.PutSwitchProject)
+
+		viewer.GET("/quota-exceeded/switch-preview-model", managementHandlers.ScopeConfigRead, s.mgmt.GetSwitchPreviewModel)
+		admin.PUT("/quota-exceeded/switch-preview-model", managementHandlers.ScopeConfigWrite, s.mgmt.PutSwitchPreviewModel)
+		admin.PATCH("/quota-exceeded/switch-preview-model", managementHandlers.ScopeConfigWrite, s.mgmt.PutSwitchPreviewModel)
+
+		admin.GET("/api-keys", managementHandlers.ScopeSecretsRead, s.mgmt.GetAPIKeys)
+		admin.PUT("/api-keys", managementHandlers.ScopeSecretsWrite, s.mgmt.PutAPIKeys)
+		admin.PATCH("/api-keys", managementHandlers.ScopeSecretsWrite, s.mgmt.PatchAPIKeys)
+		admin.DELETE("/api-keys", managementHandlers.ScopeSecretsWrite, s.mgmt.DeleteAPIKeys)
+		admin.POST("/api-keys", managementHandlers.ScopeSecretsWrite, s.mgmt.CreateAPIKey)
+		admin.POST("/api-keys/migrate", managementHandlers.ScopeSecretsWrite, s.mgmt.MigrateAPIKeys)
+
+		admin.GET("/gemini-api-key", managementHandlers.ScopeSecretsRead, s.mgmt.GetGeminiKeys)
+		admin.PUT("/gemini-api-key", managementHandlers.ScopeSecretsWrite, s.mgmt.PutGeminiKeys)
+		admin.PATCH("/gemini-api-key", managementHandlers.ScopeSecretsWrite, s.mgmt.PatchGeminiKey)
+		admin.DELETE("/gemini-api-key", managementHandlers.ScopeSecretsWrite, s.mgmt.DeleteGeminiKey)
+
+		viewer.GET("/logs", managementHandlers.ScopeLogsRead, s.mgmt.GetLogs)
+		operator.DELETE("/logs", managementHandlers.ScopeLogsWrite, s.mgmt.DeleteLogs)
+		viewer.GET("/request-error-logs", managementHandlers.ScopeLogsRead, s.mgmt.GetRequestErrorLogs)
+		viewer.GET("/request-error-logs/:name", managementHandlers.ScopeLogsRead, s.mgmt.DownloadRequestErrorLog)
+		viewer.GET("/request-log-by-id/:id", managementHandlers.ScopeLogsRead, s.mgmt.GetRequestLogByID)
+		viewer.GET("/request-log", managementHandlers.ScopeConfigRead, s.mgmt.GetRequestLog)
+		admin.PUT("/request-log", managementHandlers.ScopeConfigWrite, s.mgmt.PutRequestLog)
+		admin.PATCH("/request-log", managementHandlers.ScopeConfigWrite, s.mgmt.PutRequestLog)
+		viewer.GET("/ws-auth", managementHandlers.ScopeConfigRead, s.mgmt.GetWebsocketAuth)
+		admin.PUT("/ws-auth", managementHandlers.ScopeConfigWrite, s.mgmt.PutWebsocketAuth)
+		admin.PATCH("/ws-auth", managementHandlers.ScopeConfigWrite, s.mgmt.PutWebsocketAuth)
+
+		admin.GET("/ampcode", managementHandlers.ScopeSecretsRead, s.mgmt.GetAmpCode)
+		viewer.GET("/ampcode/upstream-url", managementHandlers.ScopeConfigRead, s.mgmt.GetAmpUpstreamURL)
+		admin.PUT("/ampcode/upstream-url", managementHandlers.ScopeConfigWrite, s.mgmt.PutAmpUpstreamURL)
+		admin.PATCH("/ampcode/upstream-url", managementHandlers.ScopeConfigWrite, s.mgmt.PutAmpUpstreamURL)
+		admin.DELETE("/ampcode/upstream-url", managementHandlers.ScopeConfigWrite, s.mgmt.DeleteAmpUpstreamURL)
+		admin.GET("/ampcode/upstream-api-key", managementHandlers.ScopeSecretsRead, s.mgmt.GetAmpUpstreamAPIKey)
+		admin.PUT("/ampcode/upstream-api-key", managementHandlers.ScopeSecretsWrite, s.mgmt.PutAmpUpstreamAPIKey)
+		admin.PATCH("/ampcode/upstream-api-key", managementHandlers.ScopeSecretsWrite, s.mgmt.PutAmpUpstreamAPIKey)
+		admin.DELETE("/ampcode/upstream-api-key", managementHandlers.ScopeSecretsWrite, s.mgmt.DeleteAmpUpstreamAPIKey)
+		viewer.GET("/ampcode/restrict-management-to-localhost", managementHandlers.ScopeConfigRead, s.mgmt.GetAmpRestrictManagementToLocalhost)
+		admin.PUT("/ampcode/restrict-management-to-localhost", managementHandlers.ScopeConfigWrite, s.mgmt.PutAmpRestrictManagementToLocalhost)
+		admin.PATCH("/ampcode/restrict-management-to-localhost", managementHandlers.ScopeConfigWrite, s.mgmt.PutAmpRestrictManagementToLocalhost)
+		viewer.GET("/ampcode/model-mappings", managementHandlers.ScopeConfigRead, s.mgmt.GetAmpModelMappings)
+		admin.PUT("/ampcode/model-mappings", managementHandlers.ScopeConfigWrite, s.mgmt.PutAmpModelMappings)
+		admin.PATCH("/ampcode/model-mappings", managementHandlers.ScopeConfigWrite, s.mgmt.PatchAmpModelMappings)
+		admin.DELETE("/ampcode/model-mappings", managementHandlers.ScopeConfigWrite, s.mgmt.DeleteAmpModelMappings)
+		viewer.GET("/ampcode/force-model-mappings", managementHandlers.ScopeConfigRead, s.mgmt.GetAmpForceModelMappings)
+		admin.PUT("/ampcode/force-model-mappings", managementHandlers.ScopeConfigWrite, s.mgmt.PutAmpForceModelMappings)
+		admin.PATCH("/ampcode/force-model-mappings", managementHandlers.ScopeConfigWrite, s.mgmt.PutAmpForceModelMappings)
+		admin.GET("/ampcode/upstream-api-keys", managementHandlers.ScopeSecretsRead, s.mgmt.GetAmpUpstreamAPIKeys)
+		admin.PUT("/ampcode/upstream-api-keys", managementHandlers.ScopeSecretsWrite, s.mgmt.PutAmpUpstreamAPIKeys)
+		admin.PATCH("/ampcode/upstream-api-keys", managementHandlers.ScopeSecretsWrite, s.mgmt.PatchAmpUpstreamAPIKeys)
+		admin.DELETE("/ampcode/upstream-api-keys", managementHandlers.ScopeSecretsWrite, s.mgmt.DeleteAmpUpstreamAPIKeys)
+
+		viewer.GET("/request-retry", managementHandlers.ScopeConfigRead, s.mgmt.GetRequestRetry)
+		admin.PUT("/request-retry", managementHandlers.ScopeConfigWrite, s.mgmt.PutRequestRetry)
+		admin.PATCH("/request-retry", managementHandlers.ScopeConfigWrite, s.mgmt.PutRequestRetry)
+		viewer.GET("/max-retry-interval", managementHandlers.ScopeConfigRead, s.mgmt.GetMaxRetryInterval)
+		admin.PUT("/max-retry-interval", managementHandlers.ScopeConfigWrite, s.mgmt.PutMaxRetryInterval)
+		admin.PATCH("/max-retry-interval", managementHandlers.ScopeConfigWrite, s.mgmt.PutMaxRetryInterval)
+
+		viewer.GET("/force-model-prefix", managementHandlers.ScopeConfigRead, s.mgmt.GetForceModelPrefix)
+		admin.PUT("/force-model-prefix", managementHandlers.ScopeConfigWrite, s.mgmt.PutForceModelPrefix)
+		admin.PATCH("/force-model-prefix", managementHandlers.ScopeConfigWrite, s.mgmt.PutForceModelPrefix)
+
+		viewer.GET("/routing/strategy", managementHandlers.ScopeConfigRead, s.mgmt.GetRoutingStrategy)
+		admin.PUT("/routing/strategy", managementHandlers.ScopeConfigWrite, s.mgmt.PutRoutingStrategy)
+		admin.PATCH("/routing/strategy", managementHandlers.ScopeConfigWrite, s.mgmt.PutRoutingStrategy)
+
+		admin.GET("/claude-api-key", managementHandlers.ScopeSecretsRead, s.mgmt.GetClaudeKeys)
+		admin.PUT("/claude-api-key", managementHandlers.ScopeSecretsWrite, s.mgmt.PutClaudeKeys)
+		admin.PATCH("/claude-api-key", managementHandlers.ScopeSecretsWrite, s.mgmt.PatchClaudeKey)
+		admin.DELETE("/claude-api-key", managementHandlers.ScopeSecretsWrite, s.mgmt.DeleteClaudeKey)
+
+		admin.GET("/codex-api-key", managementHandlers.ScopeSecretsRead, s.mgmt.GetCodexKeys)
+		admin.PUT("/codex-api-key", managementHandlers.ScopeSecretsWrite, s.mgmt.PutCodexKeys)
+		admin.PATCH("/codex-api-key", managementHandlers.ScopeSecretsWrite, s.mgmt.PatchCodexKey)
+		admin.DELETE("/codex-api-key", managementHandlers.ScopeSecretsWrite, s.mgmt.DeleteCodexKey)
+
+		admin.GET("/openai-compatibility", managementHandlers.ScopeSecretsRead, s.mgmt.GetOpenAICompat)
+		admin.PUT("/openai-compatibility", managementHandlers.ScopeSecretsWrite, s.mgmt.PutOpenAICompat)
+		admin.PATCH("/openai-compatibility", managementHandlers.ScopeSecretsWrite, s.mgmt.PatchOpenAICompat)
+		admin.DELETE("/openai-compatibility", managementHandlers.ScopeSecretsWrite, s.mgmt.DeleteOpenAICompat)
+
+		admin.GET("/vertex-api-key", managementHandlers.ScopeSecretsRead, s.mgmt.GetVertexCompatKeys)
+		admin.PUT("/vertex-api-key", managementHandlers.ScopeSecretsWrite, s.mgmt.PutVertexCompatKeys)
+		admin.PATCH("/vertex-api-key", managementHandlers.ScopeSecretsWrite, s.mgmt.PatchVertexCompatKey)
+		admin.DELETE("/vertex-api-key", managementHandlers.ScopeSecretsWrite, s.mgmt.DeleteVertexCompatKey)
+
+		viewer.GET("/oauth-excluded-models", managementHandlers.ScopeConfigRead, s.mgmt.GetOAuthExcludedModels)
+		admin.PUT("/oauth-excluded-models", managementHandlers.ScopeConfigWrite, s.mgmt.PutOAuthExcludedModels)
+		admin.PATCH("/oauth-excluded-models", managementHandlers.ScopeConfigWrite, s.mgmt.PatchOAuthExcludedModels)
+		admin.DELETE("/oauth-excluded-models", managementHandlers.ScopeConfigWrite, s.mgmt.DeleteOAuthExcludedModels)
+
+		viewer.GET("/oauth-model-alias", managementHandlers.ScopeConfigRead, s.mgmt.GetOAuthModelAlias)
+		admin.PUT("/oauth-model-alias", managementHandlers.ScopeConfigWrite, s.mgmt.PutOAuthModelAlias)
+		admin.PATCH("/oauth-model-alias", managementHandlers.ScopeConfigWrite, s.mgmt.PatchOAuthModelAlias)
+		admin.DELETE("/oauth-model-alias", managementHandlers.ScopeConfigWrite, s.mgmt.DeleteOAuthModelAlias)
+
+		viewer.GET("/auth-files", managementHandlers.ScopeAuthFilesRead, s.mgmt.ListAuthFiles)
+		viewer.GET("/auth-files/models", managementHandlers.ScopeAuthFilesRead, s.mgmt.GetAuthFileModels)
+		viewer.GET("/model-definitions/:channel", managementHandlers.ScopeAuthFilesRead, s.mgmt.GetStaticModelDefinitions)
+		admin.GET("/auth-files/download", managementHandlers.ScopeSecretsRead, s.mgmt.DownloadAuthFile)
+		admin.GET("/auth-files/export", managementHandlers.ScopeSecretsRead, s.mgmt.ExportAuthFiles)
+		operator.POST("/auth-files", managementHandlers.ScopeAuthFilesWrite, s.mgmt.UploadAuthFile)
+		operator.DELETE("/auth-files", managementHandlers.ScopeAuthFilesWrite, s.mgmt.DeleteAuthFile)
+		operator.POST("/auth-files/restore", managementHandlers.ScopeAuthFilesWrite, s.mgmt.RestoreAuthFile)
+		operator.POST("/auth-files/validate", managementHandlers.ScopeAuthFilesWrite, s.mgmt.ValidateAuthFile)
+		viewer.GET("/auth-files/trash", managementHandlers.ScopeAuthFilesRead, s.mgmt.ListAuthTrash)
+		viewer.GET("/auth-files/duplicates", managementHandlers.ScopeAuthFilesRead, s.mgmt.ListDuplicateAuthFiles)
+		operator.POST("/auth-files/duplicates", managementHandlers.ScopeAuthFilesWrite, s.mgmt.ResolveDuplicateAuthFiles)
+		operator.POST("/auth-files/trash/:name/restore", managementHandlers.ScopeAuthFilesWrite, s.mgmt.RestoreTrashedAuthFile)
+		operator.POST("/auth-files/verify-invalid", managementHandlers.ScopeAuthFilesWrite, s.mgmt.VerifyInvalidAuthFiles)
+		viewer.GET("/auth-files/verify-jobs/:id", managementHandlers.ScopeAuthFilesRead, s.mgmt.GetVerifyJob)
+		operator.DELETE("/auth-files/verify-jobs/:id", managementHandlers.ScopeAuthFilesWrite, s.mgmt.CancelVerifyJob)
+		viewer.GET("/auth-files/inspection-config", managementHandlers.ScopeInspectionRead, s.mgmt.GetAuthInspectionConfig)
+		admin.PUT("/auth-files/inspection-config", managementHandlers.ScopeInspectionWrite, s.mgmt.PutAuthInspectionConfig)
+		admin.PATCH("/auth-files/inspection-config", managementHandlers.ScopeInspectionWrite, s.mgmt.PutAuthInspectionConfig)
+		viewer.GET("/auth-files/inspection-schedules", managementHandlers.ScopeInspectionRead, s.mgmt.GetAuthInspectionSchedules)
+		admin.PUT("/auth-files/inspection-schedules", managementHandlers.ScopeInspectionWrite, s.mgmt.PutAuthInspectionSchedules)
+		viewer.GET("/auth-files/inspection-status", managementHandlers.ScopeInspectionRead, s.mgmt.GetAuthInspectionStatus)
+		viewer.GET("/auth-files/inspection/reasons", managementHandlers.ScopeInspectionRead, s.mgmt.GetAuthInspectionReasons)
+		viewer.GET("/auth-files/invalid-summary", managementHandlers.ScopeAuthFilesRead, s.mgmt.GetInvalidAuthSummary)
+		operator.POST("/auth-files/inspection-run", managementHandlers.ScopeInspectionWrite, s.mgmt.RunAuthInspectionNow)
+		operator.POST("/auth-inspection/cancel", managementHandlers.ScopeInspectionWrite, s.mgmt.CancelAuthInspection)
+		operator.POST("/auth-inspection/pause", managementHandlers.ScopeInspectionWrite, s.mgmt.PauseAuthInspection)
+		operator.POST("/auth-inspection/resume", managementHandlers.ScopeInspectionWrite, s.mgmt.ResumeAuthInspection)
+		viewer.GET("/auth-inspection/history", managementHandlers.ScopeInspectionRead, s.mgmt.GetAuthInspectionHistory)
+		viewer.GET("/auth-inspection/events", managementHandlers.ScopeInspectionRead, s.mgmt.StreamAuthInspectionEvents)
+		operator.PATCH("/auth-files/status", managementHandlers.ScopeAuthFilesWrite, s.mgmt.PatchAuthFileStatus)
+		operator.PATCH("/auth-files/:id", managementHandlers.ScopeAuthFilesWrite, s.mgmt.PatchAuthFile)
+		operator.POST("/auth-files/:id/verify", managementHandlers.ScopeAuthFilesWrite, s.mgmt.VerifyAuthFile)
+		operator.POST("/auth-files/:id/freeze", managementHandlers.ScopeAuthFilesWrite, s.mgmt.FreezeAuthFile)
+		operator.POST("/auth-files/:id/unfreeze", managementHandlers.ScopeAuthFilesWrite, s.mgmt.UnfreezeAuthFile)
+		operator.POST("/auth-files/:id/disable", managementHandlers.ScopeAuthFilesWrite, s.mgmt.DisableAuthFile)
+		operator.POST("/auth-files/:id/enable", managementHandlers.ScopeAuthFilesWrite, s.mgmt.EnableAuthFile)
+		operator.POST("/auth-files/:id/rename", managementHandlers.ScopeAuthFilesWrite, s.mgmt.RenameAuthFile)
+		admin.POST("/auth-files/sync", managementHandlers.ScopeAuthFilesWrite, s.mgmt.SyncAuthFiles)
+		viewer.GET("/auth-files/sync", managementHandlers.ScopeAuthFilesRead, s.mgmt.ListAuthSyncJobs)
+		viewer.GET("/auth-files/sync/:id", managementHandlers.ScopeAuthFilesRead, s.mgmt.GetAuthSyncJob)
+		viewer.GET("/backups", managementHandlers.ScopeBackupsRead, s.mgmt.ListBackups)
+		viewer.GET("/backups/status", managementHandlers.ScopeBackupsRead, s.mgmt.GetBackupStatus)
+		operator.POST("/backups/run", managementHandlers.ScopeBackupsWrite, s.mgmt.RunBackupNow)
+		admin.POST("/backups/restore", managementHandlers.ScopeBackupsWrite, s.mgmt.RestoreBackup)
+		viewer.GET("/alerts", managementHandlers.ScopeAlertsRead, s.mgmt.GetAlerts)
+		viewer.GET("/alerts/config", managementHandlers.ScopeAlertsRead, s.mgmt.GetAlertsConfig)
+		admin.PUT("/alerts/config", managementHandlers.ScopeAlertsWrite, s.mgmt.PutAlertsConfig)
+		admin.PATCH("/alerts/config", managementHandlers.ScopeAlertsWrite, s.mgmt.PatchAlertsConfig)
+		admin.GET("/notifications", managementHandlers.ScopeSecretsRead, s.mgmt.GetNotifications)
+		admin.PUT("/notifications", managementHandlers.ScopeSecretsWrite, s.mgmt.PutNotifications)
+		admin.POST("/notifications/test", managementHandlers.ScopeAlertsWrite, s.mgmt.TestNotifications)
+		viewer.GET("/metrics", managementHandlers.ScopeInspectionRead, s.mgmt.GetMetrics)
+		operator.POST("/vertex/import", managementHandlers.ScopeAuthFilesWrite, s.mgmt.ImportVertexCredential)
+
+		operator.GET("/anthropic-auth-url", managementHandlers.ScopeAuthFilesWrite, s.mgmt.RequestAnthropicToken)
+		operator.GET("/codex-auth-url", managementHandlers.ScopeAuthFilesWrite, s.mgmt.RequestCodexToken)
+		operator.GET("/gemini-cli-auth-url", managementHandlers.ScopeAuthFilesWrite, s.mgmt.RequestGeminiCLIToken)
+		operator.GET("/antigravity-auth-url", managementHandlers.ScopeAuthFilesWrite, s.mgmt.RequestAntigravityToken)
+		operator.GET("/qwen-auth-url", managementHandlers.ScopeAuthFilesWrite, s.mgmt.RequestQwenToken)
+		operator.GET("/kimi-auth-url", managementHandlers.ScopeAuthFilesWrite, s.mgmt.RequestKimiToken)
+		operator.GET("/iflow-auth-url", managementHandlers.ScopeAuthFilesWrite, s.mgmt.RequestIFlowToken)
+		operator.POST("/iflow-auth-url", managementHandlers.ScopeAuthFilesWrite, s.mgmt.RequestIFlowCookieToken)
+		operator.POST("/oauth-callback", managementHandlers.ScopeAuthFilesWrite, s.mgmt.PostOAuthCallback)
+		viewer.GET("/get-auth-status", managementHandlers.ScopeAuthFilesRead, s.mgmt.GetAuthStatus)
+
+		admin.GET("/users", managementHandlers.ScopeSecurityRead, s.mgmt.ListManagementUsers)
+		admin.POST("/users", managementHandlers.ScopeSecurityWrite, s.mgmt.CreateManagementUser)
+		admin.DELETE("/users", managementHandlers.ScopeSecurityWrite, s.mgmt.DeleteManagementUser)
+		viewer.POST("/users/:name/totp/enroll", managementHandlers.ScopeSecurityWrite, s.mgmt.EnrollUserTOTP)
+		viewer.POST("/users/:name/totp/verify", managementHandlers.ScopeSecurityWrite, s.mgmt.VerifyUserTOTP)
+		admin.DELETE("/users/:name/totp", managementHandlers.ScopeSecurityWrite, s.mgmt.DisableUserTOTP)
+		admin.GET("/audit-log", managementHandlers.ScopeSecurityRead, s.mgmt.GetAuditLog)
+
+		admin.GET("/scopes", managementHandlers.ScopeSecurityRead, s.mgmt.ListScopes)
+		admin.GET("/tokens", managementHandlers.ScopeSecurityRead, s.mgmt.ListManagementTokens)
+		admin.POST("/tokens", managementHandlers.ScopeSecurityWrite, s.mgmt.CreateManagementToken)
+		admin.DELETE("/tokens", managementHandlers.ScopeSecurityWrite, s.mgmt.DeleteManagementToken)
+
+		viewer.POST("/logout", managementHandlers.ScopeSecurityWrite, s.mgmt.Logout)
+		viewer.POST("/session/refresh", managementHandlers.ScopeSecurityWrite, s.mgmt.RefreshSession)
+		viewer.GET("/csrf", managementHandlers.ScopeSecurityRead, s.mgmt.GetCSRFToken)
+		admin.GET("/sessions", managementHandlers.ScopeSecurityRead, s.mgmt.ListSessions)
+		admin.DELETE("/sessions", managementHandlers.ScopeSecurityWrite, s.mgmt.DeleteSession)
+
+		viewer.GET("/security/lockouts", managementHandlers.ScopeSecurityRead, s.mgmt.GetSecurityLockouts)
+		admin.DELETE("/security/lockouts", managementHandlers.ScopeSecurityWrite, s.mgmt.DeleteSecurityLockouts)
+		admin.GET("/security/principals", managementHandlers.ScopeSecurityRead, s.mgmt.ListPrincipals)
+		admin.DELETE("/security/principals/:id", managementHandlers.ScopeSecurityWrite, s.mgmt.RevokePrincipal)
+		admin.POST("/security/principals/revoke-all", managementHandlers.ScopeSecurityWrite, s.mgmt.RevokeAllPrincipals)
+
+		admin.GET("/remote-management/allowed-networks", managementHandlers.ScopeSecurityRead, s.mgmt.GetAllowedNetworks)
+		admin.PUT("/remote-management/allowed-networks", managementHandlers.ScopeSecurityWrite, s.mgmt.PutAllowedNetworks)
+		admin.PATCH("/remote-management/allowed-networks", managementHandlers.ScopeSecurityWrite, s.mgmt.PutAllowedNetworks)
+		admin.GET("/remote-management/trusted-proxies", managementHandlers.ScopeSecurityRead, s.mgmt.GetTrustedProxies)
+		admin.PUT("/remote-management/trusted-proxies", managementHandlers.ScopeSecurityWrite, s.mgmt.PutTrustedProxies)
+		admin.PATCH("/remote-management/trusted-proxies", managementHandlers.ScopeSecurityWrite, s.mgmt.PutTrustedProxies)
 	}
 }
 
+// scopedRoutes registers management routes together with the scope that scoped
+// tokens need, so a route cannot be added without choosing one.
+type scopedRoutes struct {
+	group *gin.RouterGroup
+	mgmt  *managementHandlers.Handler
+}
+
+func (r scopedRoutes) handle(method, path string, scope managementHandlers.Scope, handler gin.HandlerFunc) {
+	r.group.Handle(method, path, r.mgmt.RequireScope(scope), handler)
+}
+
+func (r scopedRoutes) GET(path string, scope managementHandlers.Scope, handler gin.HandlerFunc) {
+	r.handle(http.MethodGet, path, scope, handler)
+}
+
+func (r scopedRoutes) POST(path string, scope managementHandlers.Scope, handler gin.HandlerFunc) {
+	r.handle(http.MethodPost, path, scope, handler)
+}
+
+func (r scopedRoutes) PUT(path string, scope managementHandlers.Scope, handler gin.HandlerFunc) {
+	r.handle(http.MethodPut, path, scope, handler)
+}
+
+func (r scopedRoutes) PATCH(path string, scope managementHandlers.Scope, handler gin.HandlerFunc) {
+	r.handle(http.MethodPatch, path, scope, handler)
+}
+
+func (r scopedRoutes) DELETE(path string, scope managementHandlers.Scope, handler gin.HandlerFunc) {
+	r.handle(http.MethodDelete, path, scope, handler)
+}
+
 func (s *Server) managementAvailabilityMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if !s.managementRoutesEnabled.Load() {
@@ -783,6 +935,9 @@ func (s *Server) Start() error {
 	if s == nil || s.server == nil {
 		return fmt.Errorf("failed to start HTTP server: server not initialized")
 	}
+	if errMgmt := s.startManagementListener(); errMgmt != nil {
+		return errMgmt
+	}
 
 	useTLS := s.cfg != nil && s.cfg.TLS.Enable
 	if useTLS {
@@ -791,13 +946,26 @@ func (s *Server) Start() error {
 		if cert == "" || key == "" {
 			return fmt.Errorf("failed to start HTTPS server: tls.cert or tls.key is empty")
 		}
+		reloader := newTLSReloader(func() *config.Config { return s.cfg })
+		if _, errCert := reloader.certificate(); errCert != nil {
+			return fmt.Errorf("failed to start HTTPS server: %v", errCert)
+		}
+		if mtls := s.cfg.TLS.Management.MTLS; reloader.mtlsApplies(s.cfg) {
+			if _, errCA := reloader.clientCAs(strings.TrimSpace(mtls.ClientCA)); errCA != nil {
+				return fmt.Errorf("failed to start HTTPS server: management mTLS: %v", errCA)
+			}
+		}
+		s.server.TLSConfig = reloader.serverConfig()
 		log.Debugf("Starting API server on %s with TLS", s.server.Addr)
-		if errServeTLS := s.server.ListenAndServeTLS(cert, key); errServeTLS != nil && !errors.Is(errServeTLS, http.ErrServerClosed) {
+		if errServeTLS := s.server.ListenAndServeTLS("", ""); errServeTLS != nil && !errors.Is(errServeTLS, http.ErrServerClosed) {
 			return fmt.Errorf("failed to start HTTPS server: %v", errServeTLS)
 		}
 		return nil
 	}
 
+	if s.cfg != nil && s.cfg.TLS.Management.MTLS.Enable && s.mgmtServer == nil {
+		log.Warn("tls.management.mtls is enabled but tls.enable is false; management requests cannot present client certificates")
+	}
 	log.Debugf("Starting API server on %s", s.server.Addr)
 	if errServe := s.server.ListenAndServe(); errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
 		return fmt.Errorf("failed to start HTTP server: %v", errServe)
@@ -824,22 +992,46 @@ func (s *Server) Stop(ctx context.Context) error {
 		}
 	}
 
+	// Shutdown the management listener first so no admin change races the drain.
+	var errMgmt error
+	if s.mgmtServer != nil {
+		if err := s.mgmtServer.Shutdown(ctx); err != nil {
+			errMgmt = fmt.Errorf("failed to shutdown management server: %v", err)
+		}
+	}
+
 	// Shutdown the HTTP server.
 	if err := s.server.Shutdown(ctx); err != nil {
-		return fmt.Errorf("failed to shutdown HTTP server: %v", err)
+		return errors.Join(errMgmt, fmt.Errorf("failed to shutdown HTTP server: %v", err))
+	}
+	if errMgmt != nil {
+		return errMgmt
 	}
 
 	log.Debug("API server stopped")
 	return nil
 }
 
+// StopWorkers ends the management background workers: running inspections,
+// backups and syncs are cancelled and waited for, and the schedulers exit.
+func (s *Server) StopWorkers(ctx context.Context) error {
+	if s == nil || s.mgmt == nil {
+		return nil
+	}
+	return s.mgmt.Stop(ctx)
+}
+
 // corsMiddleware returns a Gin middleware handler that adds CORS headers
 // to every response, allowing cross-origin requests.
 //
 // Returns:
 //   - gin.HandlerFunc: The CORS middleware handler
-func corsMiddleware() gin.HandlerFunc {
+func corsMiddleware(skip func(*gin.Context) bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if skip != nil && skip(c) {
+			c.Next()
+			return
+		}
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Header("Access-Control-Allow-Headers", "*")
@@ -949,6 +1141,10 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		}
 	}
 
+	if oldCfg != nil && managementListenAddr(oldCfg) != managementListenAddr(cfg) {
+		log.Warn("management.listen changed; restart the server to apply it")
+	}
+
 	s.applyAccessConfig(oldCfg, cfg)
 	s.cfg = cfg
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
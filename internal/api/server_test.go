@@ -1,18 +1,29 @@
 package api
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	gin "github.com/gin-gonic/gin"
 	proxyconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/totp"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func newTestServer(t *testing.T) *Server {
@@ -109,3 +120,882 @@ func TestAmpProviderModelRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestManagementDebugPprofRoutes(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+
+	testCases := []struct {
+		name         string
+		pprofEnabled bool
+		key          string
+		path         string
+		wantStatus   int
+	}{
+		{name: "disabled", pprofEnabled: false, key: "mgmt-secret", path: "/v0/management/debug/pprof/", wantStatus: http.StatusNotFound},
+		{name: "disabled named profile", pprofEnabled: false, key: "mgmt-secret", path: "/v0/management/debug/pprof/goroutine", wantStatus: http.StatusNotFound},
+		{name: "enabled without credentials", pprofEnabled: true, path: "/v0/management/debug/pprof/goroutine", wantStatus: http.StatusUnauthorized},
+		{name: "enabled index", pprofEnabled: true, key: "mgmt-secret", path: "/v0/management/debug/pprof/", wantStatus: http.StatusOK},
+		{name: "enabled goroutine", pprofEnabled: true, key: "mgmt-secret", path: "/v0/management/debug/pprof/goroutine?debug=1", wantStatus: http.StatusOK},
+		{name: "runtime", key: "mgmt-secret", path: "/v0/management/debug/runtime", wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t)
+			server.cfg.DebugPprofEnabled = tc.pprofEnabled
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.key != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.key)
+			}
+			rr := httptest.NewRecorder()
+			server.engine.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("unexpected status code for %s: got %d want %d; body=%s", tc.path, rr.Code, tc.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestManagementRoleEnforcement(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+
+	viewerHash, err := bcrypt.GenerateFromPassword([]byte("viewer-key"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash key: %v", err)
+	}
+
+	testCases := []struct {
+		name       string
+		method     string
+		path       string
+		key        string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "viewer reads", method: http.MethodGet, path: "/v0/management/debug", key: "viewer-key", wantStatus: http.StatusOK},
+		{name: "viewer cannot change config", method: http.MethodPut, path: "/v0/management/debug", key: "viewer-key", wantStatus: http.StatusForbidden, wantBody: "admin required"},
+		{name: "viewer cannot run inspection", method: http.MethodPost, path: "/v0/management/auth-files/inspection-run", key: "viewer-key", wantStatus: http.StatusForbidden, wantBody: "operator required"},
+		{name: "viewer cannot read secrets", method: http.MethodGet, path: "/v0/management/api-keys", key: "viewer-key", wantStatus: http.StatusForbidden, wantBody: "admin required"},
+		{name: "secret maps to admin", method: http.MethodGet, path: "/v0/management/users", key: "mgmt-secret", wantStatus: http.StatusOK},
+		{name: "unknown key", method: http.MethodGet, path: "/v0/management/debug", key: "nope", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t)
+			server.cfg.RemoteManagement.Users = []proxyconfig.ManagementUser{{Name: "ro", Role: "viewer", KeyHash: string(viewerHash)}}
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{"value":true}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tc.key)
+			rr := httptest.NewRecorder()
+			server.engine.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("unexpected status code for %s %s: got %d want %d; body=%s", tc.method, tc.path, rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if tc.wantBody != "" && !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Fatalf("response body missing %q: %s", tc.wantBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestManagementAllowedNetworks(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+
+	testCases := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		trusted    []string
+		wantStatus int
+	}{
+		{name: "allowed peer", remoteAddr: "10.8.0.5:4000", wantStatus: http.StatusOK},
+		{name: "denied peer", remoteAddr: "203.0.113.9:4000", wantStatus: http.StatusForbidden},
+		{name: "allowed ipv6 peer", remoteAddr: "[fd00:1::5]:4000", wantStatus: http.StatusOK},
+		{
+			name:       "spoofed forwarded headers from untrusted peer",
+			remoteAddr: "203.0.113.9:4000",
+			headers:    map[string]string{"X-Forwarded-For": "10.8.0.5", "X-Real-IP": "10.8.0.5"},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "forwarded client behind trusted proxy",
+			remoteAddr: "192.0.2.10:4000",
+			headers:    map[string]string{"X-Forwarded-For": "10.8.0.5"},
+			trusted:    []string{"192.0.2.10"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "spoofed leading hop behind trusted proxy",
+			remoteAddr: "192.0.2.10:4000",
+			headers:    map[string]string{"X-Forwarded-For": "10.8.0.5, 203.0.113.9"},
+			trusted:    []string{"192.0.2.10"},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t)
+			server.cfg.RemoteManagement.AllowedNetworks = []string{"10.8.0.0/16", "fd00:1::/64"}
+			server.cfg.RemoteManagement.TrustedProxies = tc.trusted
+
+			req := httptest.NewRequest(http.MethodGet, "/v0/management/debug", nil)
+			req.RemoteAddr = tc.remoteAddr
+			req.Header.Set("Authorization", "Bearer mgmt-secret")
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			rr := httptest.NewRecorder()
+			server.engine.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("unexpected status code: got %d want %d; body=%s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestManagementBruteForceLockout(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+	server := newTestServer(t)
+
+	do := func(method, path, remoteAddr, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Authorization", "Bearer "+key)
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 5; i++ {
+		if rr := do(http.MethodGet, "/v0/management/debug", "198.51.100.7:1000", "guess-"+string(rune('a'+i))); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401, got %d", i, rr.Code)
+		}
+	}
+	rr := do(http.MethodGet, "/v0/management/debug", "198.51.100.7:1000", "mgmt-secret")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 while locked out, got %d; body=%s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected Retry-After header on lockout")
+	}
+
+	rr = do(http.MethodGet, "/v0/management/security/lockouts", "198.51.100.8:1000", "mgmt-secret")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "ip:198.51.100.7") {
+		t.Fatalf("expected lockout to be listed, got %d; body=%s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodDelete, "/v0/management/security/lockouts?key=ip:198.51.100.7", "198.51.100.8:1000", "mgmt-secret")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected unlock to succeed, got %d; body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodGet, "/v0/management/debug", "198.51.100.7:1000", "mgmt-secret"); rr.Code != http.StatusOK {
+		t.Fatalf("expected access after unlock, got %d; body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestManagementLockoutIgnoresSpoofedForwardedFor(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+	server := newTestServer(t)
+
+	do := func(key, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v0/management/debug", nil)
+		req.RemoteAddr = "198.51.100.9:1000"
+		req.Header.Set("Authorization", "Bearer "+key)
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		req.Header.Set("X-Real-IP", forwardedFor)
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Without trusted proxies the headers are ignored: the guesses neither pass
+	// as local nor spread over per-IP buckets.
+	for i := 0; i < 5; i++ {
+		forwarded := "127.0.0.1"
+		if i%2 == 1 {
+			forwarded = "192.0.2." + string(rune('1'+i))
+		}
+		if rr := do("guess-"+string(rune('a'+i)), forwarded); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401, got %d", i, rr.Code)
+		}
+	}
+	if rr := do("mgmt-secret", "127.0.0.1"); rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 for a spoofed local address, got %d; body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestManagementLockoutSparesValidTokens(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+	server := newTestServer(t)
+
+	do := func(method, path, remoteAddr, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/v0/management/login", "198.51.100.20:1000", "", `{"key":"mgmt-secret"}`)
+	var login struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &login); err != nil || login.Token == "" {
+		t.Fatalf("login failed: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// The same bogus session token from five addresses locks out that value,
+	// and other bogus tokens do not share its bucket.
+	for i := 0; i < 5; i++ {
+		addr := "203.0.113." + string(rune('1'+i)) + ":1000"
+		if rr = do(http.MethodGet, "/v0/management/debug", addr, "cpas_x.y", ""); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("bogus attempt %d: got %d; body=%s", i, rr.Code, rr.Body.String())
+		}
+	}
+	if rr = do(http.MethodGet, "/v0/management/debug", "198.51.100.30:1000", "cpas_x.y", ""); rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("locked-out bogus token: got %d; body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodGet, "/v0/management/debug", "198.51.100.30:1000", "cpas_other.y", ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("other bogus token: got %d; body=%s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodGet, "/v0/management/debug", "198.51.100.31:1000", login.Token, ""); rr.Code != http.StatusOK {
+		t.Fatalf("valid session refused after other clients' failures: got %d; body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestManagementCORS(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+
+	testCases := []struct {
+		name        string
+		method      string
+		path        string
+		origin      string
+		auth        bool
+		wantStatus  int
+		wantOrigin  string
+		wantMethods bool
+	}{
+		{name: "matching origin", method: http.MethodGet, path: "/v0/management/debug", origin: "https://panel.example.org", auth: true, wantStatus: http.StatusOK, wantOrigin: "https://panel.example.org"},
+		{name: "non-matching origin", method: http.MethodGet, path: "/v0/management/debug", origin: "https://evil.example.net", auth: true, wantStatus: http.StatusOK},
+		{name: "wildcard subdomain", method: http.MethodGet, path: "/v0/management/debug", origin: "https://ui.corp.example.com", auth: true, wantStatus: http.StatusOK, wantOrigin: "https://ui.corp.example.com"},
+		{name: "wildcard does not match apex", method: http.MethodGet, path: "/v0/management/debug", origin: "https://example.com", auth: true, wantStatus: http.StatusOK},
+		{name: "preflight without auth", method: http.MethodOptions, path: "/v0/management/debug", origin: "https://panel.example.org", wantStatus: http.StatusNoContent, wantOrigin: "https://panel.example.org", wantMethods: true},
+		{name: "preflight from unknown origin", method: http.MethodOptions, path: "/v0/management/debug", origin: "https://evil.example.net", wantStatus: http.StatusForbidden},
+		{name: "actual request still requires auth", method: http.MethodGet, path: "/v0/management/debug", origin: "https://panel.example.org", wantStatus: http.StatusUnauthorized, wantOrigin: "https://panel.example.org"},
+		{name: "proxy routes unaffected", method: http.MethodOptions, path: "/v1/models", origin: "https://evil.example.net", wantStatus: http.StatusNoContent, wantOrigin: "*"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t)
+			server.cfg.RemoteManagement.CORS = proxyconfig.ManagementCORS{
+				AllowedOrigins:   []string{"https://panel.example.org", "https://*.example.com"},
+				MaxAge:           600,
+				AllowCredentials: true,
+			}
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set("Origin", tc.origin)
+			if tc.method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			}
+			if tc.auth {
+				req.Header.Set("Authorization", "Bearer mgmt-secret")
+			}
+			rr := httptest.NewRecorder()
+			server.engine.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("unexpected status code: got %d want %d; body=%s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Origin"); got != tc.wantOrigin {
+				t.Fatalf("unexpected Access-Control-Allow-Origin: got %q want %q", got, tc.wantOrigin)
+			}
+			if tc.wantMethods {
+				if rr.Header().Get("Access-Control-Allow-Methods") == "" || rr.Header().Get("Access-Control-Max-Age") != "600" {
+					t.Fatalf("expected preflight headers, got %v", rr.Header())
+				}
+			}
+		})
+	}
+}
+
+func TestManagementSessions(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+
+	server := newTestServer(t)
+	allowRaw := false
+	server.cfg.RemoteManagement.AllowRawSecret = &allowRaw
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do(http.MethodGet, "/v0/management/debug", "mgmt-secret", ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("raw secret should be rejected when allow-raw-secret is false: got %d", rr.Code)
+	}
+	if rr := do(http.MethodPost, "/v0/management/login", "", `{"key":"wrong"}`); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("login with wrong key: got %d", rr.Code)
+	}
+
+	rr := do(http.MethodPost, "/v0/management/login", "", `{"key":"mgmt-secret"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("login failed: %d %s", rr.Code, rr.Body.String())
+	}
+	var login struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &login); err != nil || login.Token == "" {
+		t.Fatalf("login response missing token: %s", rr.Body.String())
+	}
+
+	if rr := do(http.MethodGet, "/v0/management/debug", login.Token, ""); rr.Code != http.StatusOK {
+		t.Fatalf("session token rejected: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v0/management/session/refresh", login.Token, ""); rr.Code != http.StatusOK {
+		t.Fatalf("refresh failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v0/management/sessions", login.Token, ""); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"name":"admin"`) {
+		t.Fatalf("unexpected session list: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v0/management/logout", login.Token, ""); rr.Code != http.StatusOK {
+		t.Fatalf("logout failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v0/management/debug", login.Token, ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked session should be rejected: got %d", rr.Code)
+	}
+}
+
+func TestManagementCSRF(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+
+	server := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/v0/management/login", strings.NewReader(`{"key":"mgmt-secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	server.engine.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("login failed: %d %s", rr.Code, rr.Body.String())
+	}
+	cookies := rr.Result().Cookies()
+	var csrf string
+	for _, cookie := range cookies {
+		if cookie.Name == "cpa_session" && (!cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode) {
+			t.Fatalf("session cookie must be HttpOnly and SameSite=Strict: %+v", cookie)
+		}
+		if cookie.Name == "cpa_csrf" {
+			csrf = cookie.Value
+		}
+	}
+	if csrf == "" {
+		t.Fatalf("login did not set the CSRF cookie")
+	}
+
+	do := func(method, path, csrfHeader, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		if csrfHeader != "" {
+			req.Header.Set("X-CSRF-Token", csrfHeader)
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr = do(http.MethodGet, "/v0/management/csrf", "", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), csrf) {
+		t.Fatalf("unexpected csrf response: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v0/management/session/refresh", "", ""); rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "csrf_token_invalid") {
+		t.Fatalf("cookie POST without CSRF token: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v0/management/session/refresh", "wrong", ""); rr.Code != http.StatusForbidden {
+		t.Fatalf("cookie POST with wrong CSRF token: got %d", rr.Code)
+	}
+	if rr := do(http.MethodPost, "/v0/management/session/refresh", csrf, "https://evil.example"); rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "csrf_origin_mismatch") {
+		t.Fatalf("cross-origin cookie POST: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v0/management/session/refresh", csrf, "http://example.com"); rr.Code != http.StatusOK {
+		t.Fatalf("same-origin cookie POST failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v0/management/logout", csrf, ""); rr.Code != http.StatusOK {
+		t.Fatalf("logout failed: %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestManagementTOTPLogin(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+
+	server := newTestServer(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("alice-key"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash key: %v", err)
+	}
+	server.cfg.RemoteManagement.Users = []proxyconfig.ManagementUser{{Name: "alice", Role: "viewer", KeyHash: string(hash)}}
+	if err := os.WriteFile(server.configFilePath, []byte("port: 0\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/v0/management/users/alice/totp/enroll", "alice-key", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("enroll failed: %d %s", rr.Code, rr.Body.String())
+	}
+	var enroll struct {
+		Secret        string   `json:"secret"`
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &enroll); err != nil || enroll.Secret == "" || len(enroll.RecoveryCodes) == 0 {
+		t.Fatalf("unexpected enroll response: %s", rr.Body.String())
+	}
+
+	// Verification consumes the current step, so log in with the next step's code,
+	// which is still inside the accepted skew.
+	step := totp.Step(time.Now())
+	current, _ := totp.CodeAt(enroll.Secret, step)
+	if rr := do(http.MethodPost, "/v0/management/users/alice/totp/verify", "alice-key", `{"code":"`+current+`"}`); rr.Code != http.StatusOK {
+		t.Fatalf("verify failed: %d %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := do(http.MethodGet, "/v0/management/debug", "alice-key", ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("raw key should be rejected once TOTP is enabled: got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/v0/management/debug", "mgmt-secret", ""); rr.Code != http.StatusOK {
+		t.Fatalf("raw secret automation should be unaffected: got %d", rr.Code)
+	}
+	if rr := do(http.MethodPost, "/v0/management/login", "", `{"key":"alice-key"}`); rr.Code != http.StatusUnauthorized || !strings.Contains(rr.Body.String(), "totp_required") {
+		t.Fatalf("login without code should require totp: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v0/management/login", "", `{"key":"alice-key","totp_code":"`+current+`"}`); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("reused code should be rejected: got %d", rr.Code)
+	}
+	next, _ := totp.CodeAt(enroll.Secret, step+1)
+	if rr := do(http.MethodPost, "/v0/management/login", "", `{"key":"alice-key","totp_code":"`+next+`"}`); rr.Code != http.StatusOK {
+		t.Fatalf("login with valid code failed: %d %s", rr.Code, rr.Body.String())
+	}
+
+	recovery := enroll.RecoveryCodes[0]
+	if rr := do(http.MethodPost, "/v0/management/login", "", `{"key":"alice-key","totp_code":"`+recovery+`"}`); rr.Code != http.StatusOK {
+		t.Fatalf("login with recovery code failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v0/management/login", "", `{"key":"alice-key","totp_code":"`+recovery+`"}`); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("recovery code should be single-use: got %d", rr.Code)
+	}
+
+	if rr := do(http.MethodDelete, "/v0/management/users/alice/totp", "mgmt-secret", ""); rr.Code != http.StatusOK {
+		t.Fatalf("disable failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v0/management/debug", "alice-key", ""); rr.Code != http.StatusOK {
+		t.Fatalf("raw key should work after TOTP is disabled: got %d", rr.Code)
+	}
+}
+
+func TestManagementClientCertificates(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+
+	newCert := func(cn string) *x509.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: cn},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+		if err != nil {
+			t.Fatalf("failed to create certificate: %v", err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatalf("failed to parse certificate: %v", err)
+		}
+		return cert
+	}
+
+	testCases := []struct {
+		name       string
+		cert       *x509.Certificate
+		path       string
+		wantStatus int
+	}{
+		{name: "missing certificate", path: "/v0/management/debug", wantStatus: http.StatusForbidden},
+		{name: "allowed subject", cert: newCert("admin.ops.example.com"), path: "/v0/management/debug", wantStatus: http.StatusOK},
+		{name: "disallowed subject", cert: newCert("intruder.example.net"), path: "/v0/management/debug", wantStatus: http.StatusForbidden},
+		{name: "proxy routes unaffected", path: "/v1/models", wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t)
+			server.cfg.TLS.Management.MTLS = proxyconfig.ManagementMTLS{
+				Enable:           true,
+				RequireAndVerify: true,
+				AllowedSubjects:  []string{"*.ops.example.com"},
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.TLS = &tls.ConnectionState{}
+			if tc.cert != nil {
+				req.TLS.PeerCertificates = []*x509.Certificate{tc.cert}
+			}
+			if strings.HasPrefix(tc.path, "/v0/management") {
+				req.Header.Set("Authorization", "Bearer mgmt-secret")
+			} else {
+				req.Header.Set("Authorization", "Bearer test-key")
+			}
+			rr := httptest.NewRecorder()
+			server.engine.ServeHTTP(rr, req)
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("unexpected status code: got %d want %d; body=%s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+		})
+	}
+
+	// The certificate identity replaces the shared secret as the acting principal.
+	server := newTestServer(t)
+	server.cfg.TLS.Management.MTLS = proxyconfig.ManagementMTLS{Enable: true}
+	cert := newCert("alice.ops.example.com")
+	for _, method := range []string{http.MethodPost, http.MethodGet} {
+		path := "/v0/management/session/refresh"
+		if method == http.MethodGet {
+			path = "/v0/management/audit-log"
+		}
+		req := httptest.NewRequest(method, path, nil)
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		req.Header.Set("Authorization", "Bearer mgmt-secret")
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		if method == http.MethodGet && !strings.Contains(rr.Body.String(), `"actor":"cert:alice.ops.example.com"`) {
+			t.Fatalf("expected certificate identity in audit log, got %s", rr.Body.String())
+		}
+	}
+}
+
+func TestHashedAPIKeys(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+
+	const plaintext = "client-secret-key-123"
+	server := newTestServer(t)
+	server.cfg.APIKeys = []string{proxyconfig.HashedAPIKeyEntry(plaintext)}
+	if err := os.WriteFile(server.configFilePath, []byte("port: 0\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := proxyconfig.SaveConfigPreserveComments(server.configFilePath, server.cfg); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	server.applyAccessConfig(nil, server.cfg)
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do(http.MethodGet, "/v1/models", plaintext); rr.Code != http.StatusOK {
+		t.Fatalf("hashed key should authenticate: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v1/models", "wrong-key"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong key should be rejected: got %d", rr.Code)
+	}
+
+	created := do(http.MethodPost, "/v0/management/api-keys", "mgmt-secret")
+	if created.Code != http.StatusOK {
+		t.Fatalf("create api key failed: %d %s", created.Code, created.Body.String())
+	}
+	var payload struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(created.Body.Bytes(), &payload); err != nil || payload.Key == "" {
+		t.Fatalf("create response missing key: %s", created.Body.String())
+	}
+
+	for _, path := range []string{"/v0/management/api-keys", "/v0/management/config", "/v0/management/config.yaml"} {
+		rr := do(http.MethodGet, path, "mgmt-secret")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: unexpected status %d", path, rr.Code)
+		}
+		for _, secret := range []string{plaintext, payload.Key} {
+			if strings.Contains(rr.Body.String(), secret) {
+				t.Fatalf("%s exposes a plaintext api key: %s", path, rr.Body.String())
+			}
+		}
+	}
+}
+
+func TestManagementScopedTokens(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+
+	server := newTestServer(t)
+	if err := os.WriteFile(server.configFilePath, []byte("port: 0\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do(http.MethodPost, "/v0/management/tokens", "mgmt-secret", `{"label":"monitoring","scopes":["bogus:read"]}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("unknown scope should be rejected: got %d", rr.Code)
+	}
+	rr := do(http.MethodPost, "/v0/management/tokens", "mgmt-secret", `{"label":"monitoring","scopes":["inspection:read","usage:read"]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create token failed: %d %s", rr.Code, rr.Body.String())
+	}
+	var created struct {
+		Token struct {
+			ID    string `json:"id"`
+			Token string `json:"token"`
+		} `json:"token"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil || created.Token.Token == "" {
+		t.Fatalf("unexpected create response: %s", rr.Body.String())
+	}
+	token := created.Token.Token
+
+	if rr := do(http.MethodGet, "/v0/management/auth-files/inspection-status", token, ""); rr.Code != http.StatusOK {
+		t.Fatalf("in-scope call failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v0/management/usage", token, ""); rr.Code != http.StatusOK {
+		t.Fatalf("in-scope call failed: %d %s", rr.Code, rr.Body.String())
+	}
+	rr = do(http.MethodGet, "/v0/management/auth-files", token, "")
+	if rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), `"missing_scope":"auth-files:read"`) {
+		t.Fatalf("out-of-scope call should be rejected with the missing scope: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, "/v0/management/tokens", token, `{"label":"escalate","scopes":["security:write"]}`); rr.Code != http.StatusForbidden {
+		t.Fatalf("token must not mint tokens without security:write: got %d", rr.Code)
+	}
+
+	if rr := do(http.MethodGet, "/v0/management/tokens", "mgmt-secret", ""); rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), token) {
+		t.Fatalf("token list must not expose the token: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodDelete, "/v0/management/tokens?id="+created.Token.ID, "mgmt-secret", ""); rr.Code != http.StatusOK {
+		t.Fatalf("revoke failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v0/management/usage", token, ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked token should be rejected: got %d", rr.Code)
+	}
+}
+
+func TestManagementPrincipalRevocation(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+
+	server := newTestServer(t)
+	if err := os.WriteFile(server.configFilePath, []byte("port: 0\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr
+	}
+	login := func() (string, string) {
+		rr := do(http.MethodPost, "/v0/management/login", "", `{"key":"mgmt-secret"}`)
+		var resp struct {
+			Token   string `json:"token"`
+			Session struct {
+				ID string `json:"id"`
+			} `json:"session"`
+		}
+		if rr.Code != http.StatusOK || json.Unmarshal(rr.Body.Bytes(), &resp) != nil {
+			t.Fatalf("login failed: %d %s", rr.Code, rr.Body.String())
+		}
+		return resp.Token, resp.Session.ID
+	}
+
+	laptop, laptopID := login()
+	desk, _ := login()
+	rr := do(http.MethodPost, "/v0/management/tokens", "mgmt-secret", `{"label":"ci","scopes":["usage:read"]}`)
+	var created struct {
+		Token struct {
+			Token string `json:"token"`
+		} `json:"token"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil || created.Token.Token == "" {
+		t.Fatalf("unexpected create response: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v0/management/usage", created.Token.Token, ""); rr.Code != http.StatusOK {
+		t.Fatalf("token call failed: %d", rr.Code)
+	}
+
+	rr = do(http.MethodGet, "/v0/management/security/principals", desk, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("list principals failed: %d %s", rr.Code, rr.Body.String())
+	}
+	var listed struct {
+		Principals []struct {
+			ID      string `json:"id"`
+			Kind    string `json:"kind"`
+			LastIP  string `json:"last_ip"`
+			Current bool   `json:"current"`
+		} `json:"principals"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &listed); err != nil || len(listed.Principals) != 3 {
+		t.Fatalf("expected two sessions and one token: %s", rr.Body.String())
+	}
+	for _, p := range listed.Principals {
+		if p.Kind == "token" && p.LastIP == "" {
+			t.Fatalf("token should report its last IP: %s", rr.Body.String())
+		}
+	}
+
+	if rr := do(http.MethodDelete, "/v0/management/security/principals/"+laptopID, desk, ""); rr.Code != http.StatusOK {
+		t.Fatalf("revoke session failed: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v0/management/debug", laptop, ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked session should be rejected: got %d", rr.Code)
+	}
+
+	laptop, _ = login()
+	rr = do(http.MethodPost, "/v0/management/security/principals/revoke-all", desk, "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"sessions_revoked":1`) || !strings.Contains(rr.Body.String(), `"tokens_revoked":1`) {
+		t.Fatalf("unexpected revoke-all response: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/v0/management/debug", desk, ""); rr.Code != http.StatusOK {
+		t.Fatalf("current session must survive revoke-all: got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/v0/management/debug", laptop, ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("other session should be revoked: got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/v0/management/usage", created.Token.Token, ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("token should be revoked: got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/v0/management/audit-log", desk, ""); !strings.Contains(rr.Body.String(), "principal_revoked") {
+		t.Fatalf("revocations should be audited: %s", rr.Body.String())
+	}
+}
+
+func TestManagementSeparateListener(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+
+	gin.SetMode(gin.TestMode)
+	cfg := &proxyconfig.Config{
+		SDKConfig:  sdkconfig.SDKConfig{APIKeys: []string{"test-key"}},
+		Host:       "127.0.0.1",
+		Port:       0,
+		AuthDir:    t.TempDir(),
+		Management: proxyconfig.ManagementListener{Listen: "127.0.0.1:0"},
+	}
+	server := NewServer(cfg, auth.NewManager(nil, nil, nil), sdkaccess.NewManager(), filepath.Join(t.TempDir(), "config.yaml"))
+
+	serve := func(engine http.Handler, path string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer mgmt-secret")
+		rr := httptest.NewRecorder()
+		engine.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	if code := serve(server.engine, "/v0/management/debug"); code != http.StatusNotFound {
+		t.Fatalf("management route on the main listener: got %d, want 404", code)
+	}
+	if code := serve(server.mgmtEngine, "/v0/management/debug"); code != http.StatusOK {
+		t.Fatalf("management route on the management listener: got %d, want 200", code)
+	}
+	if code := serve(server.mgmtEngine, "/v1/models"); code != http.StatusNotFound {
+		t.Fatalf("proxy route on the management listener: got %d, want 404", code)
+	}
+	if code := serve(server.engine, "/"); code != http.StatusOK {
+		t.Fatalf("root endpoint on the main listener: got %d, want 200", code)
+	}
+}
+
+func TestSameListenAddress(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"127.0.0.1:8317", "127.0.0.1:8317", true},
+		{":8317", "127.0.0.1:8317", true},
+		{"0.0.0.0:8317", "10.0.0.5:8317", true},
+		{"127.0.0.1:8317", "127.0.0.1:8318", false},
+		{"127.0.0.1:8317", "10.0.0.5:8317", false},
+		{"127.0.0.1:0", "127.0.0.1:0", false},
+	}
+	for _, tc := range cases {
+		if got := sameListenAddress(tc.a, tc.b); got != tc.want {
+			t.Errorf("sameListenAddress(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestManagementListenerConflictFailsStartup(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "mgmt-secret")
+
+	gin.SetMode(gin.TestMode)
+	cfg := &proxyconfig.Config{
+		Host:       "127.0.0.1",
+		Port:       18317,
+		AuthDir:    t.TempDir(),
+		Management: proxyconfig.ManagementListener{Listen: "127.0.0.1:18317"},
+	}
+	server := NewServer(cfg, auth.NewManager(nil, nil, nil), sdkaccess.NewManager(), filepath.Join(t.TempDir(), "config.yaml"))
+	err := server.Start()
+	if err == nil || !strings.Contains(err.Error(), "conflicts with the main listener") {
+		t.Fatalf("expected listener conflict error, got %v", err)
+	}
+}
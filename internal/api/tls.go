@@ -0,0 +1,214 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// tlsFileState remembers which version of a file was last loaded.
+type tlsFileState struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+func statTLSFile(path string) (tlsFileState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return tlsFileState{}, err
+	}
+	return tlsFileState{path: path, modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// tlsReloader serves the listener certificate and the management client CA
+// pool, re-reading either file when its path or contents change. A failed
+// reload keeps the previously loaded material.
+type tlsReloader struct {
+	cfg func() *config.Config
+	// management marks the dedicated management listener.
+	management bool
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	certState tlsFileState
+	keyState  tlsFileState
+	caPool    *x509.CertPool
+	caState   tlsFileState
+}
+
+func newTLSReloader(cfg func() *config.Config) *tlsReloader {
+	return &tlsReloader{cfg: cfg}
+}
+
+// newManagementTLSReloader serves management.tls, falling back to tls.cert and
+// tls.key for unset paths.
+func newManagementTLSReloader(cfg func() *config.Config) *tlsReloader {
+	return &tlsReloader{cfg: cfg, management: true}
+}
+
+func (r *tlsReloader) keyPair(cfg *config.Config) (string, string) {
+	certPath := strings.TrimSpace(cfg.TLS.Cert)
+	keyPath := strings.TrimSpace(cfg.TLS.Key)
+	if r.management {
+		if v := strings.TrimSpace(cfg.Management.TLS.Cert); v != "" {
+			certPath = v
+		}
+		if v := strings.TrimSpace(cfg.Management.TLS.Key); v != "" {
+			keyPath = v
+		}
+	}
+	return certPath, keyPath
+}
+
+// mtlsApplies reports whether this listener serves management routes and
+// should therefore request client certificates.
+func (r *tlsReloader) mtlsApplies(cfg *config.Config) bool {
+	if cfg == nil || !cfg.TLS.Management.MTLS.Enable {
+		return false
+	}
+	return r.management || strings.TrimSpace(cfg.Management.Listen) == ""
+}
+
+func (r *tlsReloader) certificate() (*tls.Certificate, error) {
+	cfg := r.cfg()
+	if cfg == nil {
+		return nil, errors.New("tls: no configuration")
+	}
+	certPath, keyPath := r.keyPair(cfg)
+	if certPath == "" || keyPath == "" {
+		return nil, errors.New("tls: tls.cert or tls.key is empty")
+	}
+	certState, errCert := statTLSFile(certPath)
+	keyState, errKey := statTLSFile(keyPath)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cert != nil && errCert == nil && errKey == nil && certState == r.certState && keyState == r.keyState {
+		return r.cert, nil
+	}
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		if r.cert != nil {
+			log.Errorf("failed to reload TLS certificate, keeping previous one: %v", err)
+			return r.cert, nil
+		}
+		return nil, err
+	}
+	if r.cert != nil {
+		log.Info("TLS certificate reloaded")
+	}
+	r.cert, r.certState, r.keyState = &pair, certState, keyState
+	return r.cert, nil
+}
+
+func (r *tlsReloader) clientCAs(path string) (*x509.CertPool, error) {
+	state, errStat := statTLSFile(path)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.caPool != nil && errStat == nil && state == r.caState {
+		return r.caPool, nil
+	}
+	pool, err := loadCertPool(path)
+	if err != nil {
+		if r.caPool != nil && r.caState.path == path {
+			log.Errorf("failed to reload management mTLS client CA, keeping previous bundle: %v", err)
+			return r.caPool, nil
+		}
+		return nil, err
+	}
+	if r.caPool != nil {
+		log.Info("management mTLS client CA reloaded")
+	}
+	r.caPool, r.caState = pool, state
+	return pool, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, errors.New("tls.management.mtls.client-ca is empty")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read client CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("client CA bundle %s contains no certificates", path)
+	}
+	return pool, nil
+}
+
+// serverConfig returns the listener TLS config. Each handshake resolves the
+// current certificate and management mTLS settings, so changes to the config
+// file or to the certificate files apply to new connections without a restart.
+func (r *tlsReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.configForClient()
+		},
+	}
+}
+
+func (r *tlsReloader) configForClient() (*tls.Config, error) {
+	cert, err := r.certificate()
+	if err != nil {
+		return nil, err
+	}
+	conf := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{*cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	cfg := r.cfg()
+	if !r.mtlsApplies(cfg) {
+		return conf, nil
+	}
+	pool, err := r.clientCAs(strings.TrimSpace(cfg.TLS.Management.MTLS.ClientCA))
+	if err != nil {
+		log.Errorf("management mTLS: client CA unavailable: %v", err)
+		return nil, err
+	}
+	// Certificates are requested but not required at the TLS layer so proxy
+	// clients on the same listener keep working; management routes enforce
+	// presence. Presented certificates must always verify. A dedicated
+	// management listener has no proxy clients and can refuse the handshake.
+	conf.ClientAuth = tls.RequestClientCert
+	if r.management && cfg.TLS.Management.MTLS.RequireAndVerify {
+		conf.ClientAuth = tls.RequireAnyClientCert
+	}
+	conf.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return nil
+		}
+		if errVerify := verifyClientCertificate(state.PeerCertificates, pool); errVerify != nil {
+			log.Warnf("management mTLS: rejected client certificate %q: %v", state.PeerCertificates[0].Subject.CommonName, errVerify)
+			return fmt.Errorf("management mTLS: %w", errVerify)
+		}
+		return nil
+	}
+	return conf, nil
+}
+
+func verifyClientCertificate(chain []*x509.Certificate, roots *x509.CertPool) error {
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
+}
@@ -0,0 +1,209 @@
+// Package backup builds, encrypts and stores archives of the registered auth
+// files, and restores them.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// NamePrefix starts the name of every backup archive.
+	NamePrefix = "cliproxy-auth-"
+	// ManifestName is the archive entry describing its contents.
+	ManifestName = "manifest.json"
+
+	archiveExt   = ".tar.gz"
+	encryptedExt = ".enc"
+	authEntryDir = "auth/"
+	nameTimeFmt  = "20060102T150405Z"
+	maxEntrySize = 16 << 20
+)
+
+// ErrRedacted is returned when restoring an archive whose tokens were redacted.
+var ErrRedacted = errors.New("backup: archive is redacted and cannot be restored")
+
+// File is one auth file in an archive.
+type File struct {
+	Name     string
+	Provider string
+	Data     []byte
+}
+
+// ManifestFile describes one archived auth file.
+type ManifestFile struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider,omitempty"`
+	Size     int    `json:"size"`
+	SHA256   string `json:"sha256"`
+}
+
+// Manifest is stored as manifest.json in every archive.
+type Manifest struct {
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	Encrypted bool           `json:"encrypted"`
+	Redacted  bool           `json:"redacted"`
+	Files     []ManifestFile `json:"files"`
+}
+
+// Options controls how an archive protects token material. With a passphrase
+// the archive is encrypted; without one, token fields are redacted unless
+// AllowPlaintext is set.
+type Options struct {
+	Passphrase     string
+	AllowPlaintext bool
+	Now            time.Time
+}
+
+// Build packs files into a timestamped tar.gz with a manifest, applying the
+// encryption or redaction selected by opts. It returns the archive name.
+func Build(files []File, opts Options) (string, []byte, Manifest, error) {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+	encrypt := opts.Passphrase != ""
+	redact := !encrypt && !opts.AllowPlaintext
+
+	manifest := Manifest{Version: 1, CreatedAt: now, Encrypted: encrypt, Redacted: redact, Files: make([]ManifestFile, 0, len(files))}
+	entries := make([]File, 0, len(files))
+	for _, f := range files {
+		data := f.Data
+		if redact {
+			redacted, err := Redact(data)
+			if err != nil {
+				return "", nil, Manifest{}, fmt.Errorf("backup: redact %s: %w", f.Name, err)
+			}
+			data = redacted
+		}
+		sum := sha256.Sum256(data)
+		manifest.Files = append(manifest.Files, ManifestFile{Name: f.Name, Provider: f.Provider, Size: len(data), SHA256: hex.EncodeToString(sum[:])})
+		entries = append(entries, File{Name: f.Name, Provider: f.Provider, Data: data})
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", nil, Manifest{}, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	write := func(name string, data []byte) error {
+		if errHeader := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: now, Typeflag: tar.TypeReg}); errHeader != nil {
+			return errHeader
+		}
+		_, errWrite := tw.Write(data)
+		return errWrite
+	}
+	if err = write(ManifestName, manifestData); err != nil {
+		return "", nil, Manifest{}, fmt.Errorf("backup: write manifest: %w", err)
+	}
+	for _, f := range entries {
+		if err = write(authEntryDir+f.Name, f.Data); err != nil {
+			return "", nil, Manifest{}, fmt.Errorf("backup: write %s: %w", f.Name, err)
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return "", nil, Manifest{}, err
+	}
+	if err = gz.Close(); err != nil {
+		return "", nil, Manifest{}, err
+	}
+
+	name := NamePrefix + now.Format(nameTimeFmt) + archiveExt
+	data := buf.Bytes()
+	if encrypt {
+		if data, err = Encrypt(data, opts.Passphrase); err != nil {
+			return "", nil, Manifest{}, err
+		}
+		name += encryptedExt
+	}
+	return name, data, manifest, nil
+}
+
+// Open decrypts (when name ends in .enc) and unpacks an archive, verifying
+// every file against the manifest checksums.
+func Open(name string, data []byte, passphrase string) (Manifest, []File, error) {
+	if strings.HasSuffix(name, encryptedExt) {
+		if passphrase == "" {
+			return Manifest{}, nil, errors.New("backup: archive is encrypted and no passphrase is configured")
+		}
+		plain, err := Decrypt(data, passphrase)
+		if err != nil {
+			return Manifest{}, nil, err
+		}
+		data = plain
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return Manifest{}, nil, fmt.Errorf("backup: open archive: %w", err)
+	}
+	defer func() { _ = gz.Close() }()
+
+	var manifest Manifest
+	haveManifest := false
+	contents := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, errNext := tr.Next()
+		if errors.Is(errNext, io.EOF) {
+			break
+		}
+		if errNext != nil {
+			return Manifest{}, nil, fmt.Errorf("backup: read archive: %w", errNext)
+		}
+		if hdr.Typeflag != tar.TypeReg || hdr.Size > maxEntrySize {
+			continue
+		}
+		body, errRead := io.ReadAll(io.LimitReader(tr, maxEntrySize))
+		if errRead != nil {
+			return Manifest{}, nil, fmt.Errorf("backup: read %s: %w", hdr.Name, errRead)
+		}
+		switch {
+		case hdr.Name == ManifestName:
+			if errJSON := json.Unmarshal(body, &manifest); errJSON != nil {
+				return Manifest{}, nil, fmt.Errorf("backup: invalid manifest: %w", errJSON)
+			}
+			haveManifest = true
+		case strings.HasPrefix(hdr.Name, authEntryDir):
+			contents[strings.TrimPrefix(hdr.Name, authEntryDir)] = body
+		}
+	}
+	if !haveManifest {
+		return Manifest{}, nil, errors.New("backup: archive has no manifest")
+	}
+
+	files := make([]File, 0, len(manifest.Files))
+	for _, mf := range manifest.Files {
+		if mf.Name != path.Base(mf.Name) || strings.ContainsAny(mf.Name, `/\`) || mf.Name == ".." {
+			return Manifest{}, nil, fmt.Errorf("backup: invalid file name %q in manifest", mf.Name)
+		}
+		body, ok := contents[mf.Name]
+		if !ok {
+			return Manifest{}, nil, fmt.Errorf("backup: %s listed in manifest but missing", mf.Name)
+		}
+		sum := sha256.Sum256(body)
+		if hex.EncodeToString(sum[:]) != mf.SHA256 {
+			return Manifest{}, nil, fmt.Errorf("backup: checksum mismatch for %s", mf.Name)
+		}
+		files = append(files, File{Name: mf.Name, Provider: mf.Provider, Data: body})
+	}
+	return manifest, files, nil
+}
+
+// IsArchiveName reports whether name looks like an archive written by Build.
+func IsArchiveName(name string) bool {
+	return strings.HasPrefix(name, NamePrefix) && (strings.HasSuffix(name, archiveExt) || strings.HasSuffix(name, archiveExt+encryptedExt))
+}
@@ -0,0 +1,140 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+var testFiles = []File{
+	{Name: "codex-a.json", Provider: "codex", Data: []byte(`{"type":"codex","email":"a@example.com","refresh_token":"rt-secret","access_token":"at-secret"}`)},
+	{Name: "gemini-b.json", Provider: "gemini-cli", Data: []byte(`{"type":"gemini-cli","token":{"access_token":"ya29.secret","refresh_token":"1//secret"}}`)},
+}
+
+func TestBuildOpenEncrypted(t *testing.T) {
+	name, data, manifest, err := Build(testFiles, Options{Passphrase: "hunter2", Now: time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if name != "cliproxy-auth-20260304T050607Z.tar.gz.enc" || !IsArchiveName(name) {
+		t.Fatalf("unexpected name %q", name)
+	}
+	if !manifest.Encrypted || manifest.Redacted {
+		t.Fatalf("unexpected manifest flags: %+v", manifest)
+	}
+	if strings.Contains(string(data), "rt-secret") {
+		t.Fatal("encrypted archive leaks refresh token")
+	}
+	if _, _, err = Open(name, data, "wrong"); err == nil {
+		t.Fatal("expected wrong passphrase to fail")
+	}
+	_, files, err := Open(name, data, "hunter2")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if len(files) != 2 || string(files[0].Data) != string(testFiles[0].Data) {
+		t.Fatalf("round trip mismatch: %+v", files)
+	}
+}
+
+func TestBuildRedactsWithoutPassphrase(t *testing.T) {
+	name, data, manifest, err := Build(testFiles, Options{})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if !manifest.Redacted || strings.HasSuffix(name, encryptedExt) {
+		t.Fatalf("expected redacted plaintext archive, got %q %+v", name, manifest)
+	}
+	got, files, err := Open(name, data, "")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if !got.Redacted {
+		t.Fatal("manifest lost redacted flag")
+	}
+	var doc map[string]any
+	if err = json.Unmarshal(files[1].Data, &doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	token := doc["token"].(map[string]any)
+	if token["refresh_token"] != redactedValue || token["access_token"] != redactedValue {
+		t.Fatalf("nested tokens not redacted: %v", token)
+	}
+
+	_, _, manifest, err = Build(testFiles, Options{AllowPlaintext: true})
+	if err != nil || manifest.Redacted || manifest.Encrypted {
+		t.Fatalf("allow-plaintext archive: %+v %v", manifest, err)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	cases := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"30 2 * * *", time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC), time.Date(2026, 1, 2, 2, 30, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 1, 1, 3, 16, 10, 0, time.UTC), time.Date(2026, 1, 1, 3, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 6 1 */3 *", time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 1, 6, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		c, err := ParseCron(tc.expr)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.expr, err)
+		}
+		if got := c.Next(tc.from); !got.Equal(tc.want) {
+			t.Fatalf("%q next after %s = %s, want %s", tc.expr, tc.from, got, tc.want)
+		}
+	}
+	// NextIn matches against the wall clock of the given zone.
+	zone := time.FixedZone("UTC+8", 8*3600)
+	c, _ := ParseCron("0 3 * * *")
+	if got, want := c.NextIn(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), zone), time.Date(2026, 1, 1, 19, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("NextIn = %s, want %s", got, want)
+	}
+	for _, bad := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *"} {
+		if _, err := ParseCron(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestLocalPrune(t *testing.T) {
+	ctx := context.Background()
+	dest, err := NewDestination(config.BackupDestination{Type: "local", Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("destination: %v", err)
+	}
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		name, data, _, errBuild := Build(testFiles, Options{AllowPlaintext: true, Now: base.Add(time.Duration(i) * time.Hour)})
+		if errBuild != nil {
+			t.Fatalf("build: %v", errBuild)
+		}
+		if err = dest.Put(ctx, name, data); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+	}
+	if err = dest.Put(ctx, "unrelated.txt", []byte("x")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	removed, err := Prune(ctx, dest, 2, "")
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if len(removed) != 2 || removed[0] != "cliproxy-auth-20260101T000000Z.tar.gz" {
+		t.Fatalf("unexpected removals: %v", removed)
+	}
+	objects, err := dest.List(ctx)
+	if err != nil || len(objects) != 2 || objects[1].Name != "cliproxy-auth-20260101T030000Z.tar.gz" {
+		t.Fatalf("unexpected remaining archives: %+v %v", objects, err)
+	}
+	if _, err = dest.Get(ctx, "missing"+archiveExt); err == nil {
+		t.Fatalf("expected missing archive error, got %v", err)
+	}
+}
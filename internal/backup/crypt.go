@@ -0,0 +1,98 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Encrypted archives start with this header, followed by the scrypt salt,
+// the GCM nonce and the sealed tar.gz.
+var encryptedMagic = []byte("CPABK1")
+
+const saltSize = 16
+
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+// Encrypt seals data with AES-256-GCM using a key derived from passphrase.
+func Encrypt(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(encryptedMagic)+len(salt)+len(nonce)+len(data)+gcm.Overhead())
+	out = append(out, encryptedMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, data, encryptedMagic), nil
+}
+
+// Decrypt reverses Encrypt.
+func Decrypt(data []byte, passphrase string) ([]byte, error) {
+	if !bytes.HasPrefix(data, encryptedMagic) {
+		return nil, errors.New("backup: not an encrypted archive")
+	}
+	data = data[len(encryptedMagic):]
+	if len(data) < saltSize {
+		return nil, errors.New("backup: encrypted archive is truncated")
+	}
+	key, err := deriveKey(passphrase, data[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+	data = data[saltSize:]
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("backup: encrypted archive is truncated")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], encryptedMagic)
+	if err != nil {
+		return nil, errors.New("backup: wrong passphrase or corrupted archive")
+	}
+	return plain, nil
+}
+
+const redactedValue = "<redacted>"
+
+// Redact replaces credential fields of an auth file with a placeholder,
+// descending into nested objects such as Gemini's "token".
+func Redact(data []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid auth file: %w", err)
+	}
+	util.MaskAuthCredentials(doc, redactedValue)
+	return json.MarshalIndent(doc, "", "  ")
+}
@@ -0,0 +1,336 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/pkg/sftp"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Object is a stored backup archive.
+type Object struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modified_at"`
+}
+
+// Destination stores backup archives.
+type Destination interface {
+	// String describes the destination without credentials.
+	String() string
+	Put(ctx context.Context, name string, data []byte) error
+	Get(ctx context.Context, name string) ([]byte, error)
+	// List returns the backup archives, oldest first.
+	List(ctx context.Context) ([]Object, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// NewDestination builds the destination described by cfg.
+func NewDestination(cfg config.BackupDestination) (Destination, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Type)) {
+	case "local", "":
+		dir := strings.TrimSpace(cfg.Path)
+		if dir == "" {
+			return nil, errors.New("backup: destination.path is required")
+		}
+		resolved, err := util.ResolveAuthDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		return &localDestination{dir: resolved}, nil
+	case "sftp":
+		if strings.TrimSpace(cfg.Host) == "" || strings.TrimSpace(cfg.User) == "" {
+			return nil, errors.New("backup: sftp destination needs host and user")
+		}
+		if strings.TrimSpace(cfg.KnownHosts) == "" {
+			return nil, errors.New("backup: sftp destination needs known-hosts")
+		}
+		return &sftpDestination{cfg: cfg}, nil
+	case "s3":
+		if strings.TrimSpace(cfg.Endpoint) == "" || strings.TrimSpace(cfg.Bucket) == "" {
+			return nil, errors.New("backup: s3 destination needs endpoint and bucket")
+		}
+		options := &minio.Options{
+			Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+			Secure: !cfg.Insecure,
+			Region: cfg.Region,
+		}
+		if cfg.PathStyle {
+			options.BucketLookup = minio.BucketLookupPath
+		}
+		client, err := minio.New(strings.TrimSpace(cfg.Endpoint), options)
+		if err != nil {
+			return nil, fmt.Errorf("backup: create s3 client: %w", err)
+		}
+		prefix := strings.Trim(strings.TrimSpace(cfg.Path), "/")
+		if prefix != "" {
+			prefix += "/"
+		}
+		return &s3Destination{client: client, bucket: strings.TrimSpace(cfg.Bucket), prefix: prefix}, nil
+	default:
+		return nil, fmt.Errorf("backup: unknown destination type %q", cfg.Type)
+	}
+}
+
+func sortObjects(objects []Object) []Object {
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+	return objects
+}
+
+type localDestination struct {
+	dir string
+}
+
+func (d *localDestination) String() string { return "local:" + d.dir }
+
+func (d *localDestination) Put(_ context.Context, name string, data []byte) error {
+	if err := os.MkdirAll(d.dir, 0o700); err != nil {
+		return err
+	}
+	tmp := filepath.Join(d.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(d.dir, name))
+}
+
+func (d *localDestination) Get(_ context.Context, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(d.dir, name))
+}
+
+func (d *localDestination) List(context.Context) ([]Object, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []Object
+	for _, e := range entries {
+		if e.IsDir() || !IsArchiveName(e.Name()) {
+			continue
+		}
+		info, errInfo := e.Info()
+		if errInfo != nil {
+			continue
+		}
+		out = append(out, Object{Name: e.Name(), Size: info.Size(), ModTime: info.ModTime()})
+	}
+	return sortObjects(out), nil
+}
+
+func (d *localDestination) Delete(_ context.Context, name string) error {
+	return os.Remove(filepath.Join(d.dir, name))
+}
+
+type sftpDestination struct {
+	cfg config.BackupDestination
+}
+
+func (d *sftpDestination) String() string {
+	return fmt.Sprintf("sftp://%s@%s/%s", d.cfg.User, d.cfg.Host, strings.TrimPrefix(d.cfg.Path, "/"))
+}
+
+func (d *sftpDestination) dir() string {
+	if p := strings.TrimSpace(d.cfg.Path); p != "" {
+		return p
+	}
+	return "."
+}
+
+// with opens an SFTP session for one operation.
+func (d *sftpDestination) with(ctx context.Context, fn func(*sftp.Client) error) error {
+	hostKeys, err := knownhosts.New(d.cfg.KnownHosts)
+	if err != nil {
+		return fmt.Errorf("backup: load known-hosts: %w", err)
+	}
+	var auth []ssh.AuthMethod
+	if keyPath := strings.TrimSpace(d.cfg.PrivateKey); keyPath != "" {
+		pem, errRead := os.ReadFile(keyPath)
+		if errRead != nil {
+			return fmt.Errorf("backup: read private key: %w", errRead)
+		}
+		signer, errParse := ssh.ParsePrivateKey(pem)
+		if errParse != nil {
+			return fmt.Errorf("backup: parse private key: %w", errParse)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if d.cfg.Password != "" {
+		auth = append(auth, ssh.Password(d.cfg.Password))
+	}
+	host := strings.TrimSpace(d.cfg.Host)
+	if _, _, errSplit := net.SplitHostPort(host); errSplit != nil {
+		host = net.JoinHostPort(host, "22")
+	}
+	dialer := net.Dialer{Timeout: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return fmt.Errorf("backup: connect %s: %w", host, err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, host, &ssh.ClientConfig{
+		User:            d.cfg.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeys,
+		Timeout:         30 * time.Second,
+	})
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("backup: ssh handshake: %w", err)
+	}
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+	defer func() { _ = sshClient.Close() }()
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		return fmt.Errorf("backup: start sftp: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+	return fn(client)
+}
+
+func (d *sftpDestination) Put(ctx context.Context, name string, data []byte) error {
+	return d.with(ctx, func(c *sftp.Client) error {
+		if err := c.MkdirAll(d.dir()); err != nil {
+			return err
+		}
+		tmp := path.Join(d.dir(), "."+name+".tmp")
+		f, err := c.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(f, bytes.NewReader(data)); err != nil {
+			_ = f.Close()
+			return err
+		}
+		if err = f.Close(); err != nil {
+			return err
+		}
+		return c.PosixRename(tmp, path.Join(d.dir(), name))
+	})
+}
+
+func (d *sftpDestination) Get(ctx context.Context, name string) ([]byte, error) {
+	var data []byte
+	err := d.with(ctx, func(c *sftp.Client) error {
+		f, err := c.Open(path.Join(d.dir(), name))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		data, err = io.ReadAll(f)
+		return err
+	})
+	return data, err
+}
+
+func (d *sftpDestination) List(ctx context.Context) ([]Object, error) {
+	var out []Object
+	err := d.with(ctx, func(c *sftp.Client) error {
+		infos, err := c.ReadDir(d.dir())
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		for _, info := range infos {
+			if info.IsDir() || !IsArchiveName(info.Name()) {
+				continue
+			}
+			out = append(out, Object{Name: info.Name(), Size: info.Size(), ModTime: info.ModTime()})
+		}
+		return nil
+	})
+	return sortObjects(out), err
+}
+
+func (d *sftpDestination) Delete(ctx context.Context, name string) error {
+	return d.with(ctx, func(c *sftp.Client) error {
+		return c.Remove(path.Join(d.dir(), name))
+	})
+}
+
+type s3Destination struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+func (d *s3Destination) String() string { return "s3://" + d.bucket + "/" + d.prefix }
+
+func (d *s3Destination) Put(ctx context.Context, name string, data []byte) error {
+	_, err := d.client.PutObject(ctx, d.bucket, d.prefix+name, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	return err
+}
+
+func (d *s3Destination) Get(ctx context.Context, name string) ([]byte, error) {
+	object, err := d.client.GetObject(ctx, d.bucket, d.prefix+name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = object.Close() }()
+	return io.ReadAll(object)
+}
+
+func (d *s3Destination) List(ctx context.Context) ([]Object, error) {
+	var out []Object
+	for object := range d.client.ListObjects(ctx, d.bucket, minio.ListObjectsOptions{Prefix: d.prefix}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		name := strings.TrimPrefix(object.Key, d.prefix)
+		if strings.Contains(name, "/") || !IsArchiveName(name) {
+			continue
+		}
+		out = append(out, Object{Name: name, Size: object.Size, ModTime: object.LastModified})
+	}
+	return sortObjects(out), nil
+}
+
+func (d *s3Destination) Delete(ctx context.Context, name string) error {
+	return d.client.RemoveObject(ctx, d.bucket, d.prefix+name, minio.RemoveObjectOptions{})
+}
+
+// Prune deletes the oldest archives so that at most keep remain, never
+// deleting current (the archive just written). keep <= 0 keeps everything.
+func Prune(ctx context.Context, dest Destination, keep int, current string) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	objects, err := dest.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	excess := len(objects) - keep
+	var removed []string
+	for _, object := range objects {
+		if len(removed) >= excess {
+			break
+		}
+		if object.Name == current {
+			continue
+		}
+		if err = dest.Delete(ctx, object.Name); err != nil {
+			return removed, err
+		}
+		removed = append(removed, object.Name)
+	}
+	return removed, nil
+}
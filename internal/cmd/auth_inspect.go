@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/handlers/management"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/serverlock"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy"
+	log "github.com/sirupsen/logrus"
+)
+
+// Exit codes of the auth inspect command.
+const (
+	AuthInspectExitOK      = 0
+	AuthInspectExitError   = 1
+	AuthInspectExitInvalid = 2
+)
+
+// AuthInspectOptions holds the flags of "auth inspect".
+type AuthInspectOptions struct {
+	Provider      string
+	DeleteInvalid bool
+	Output        string
+	Force         bool
+}
+
+// RegisterAuthInspectFlags adds the "auth inspect" flags to fs.
+func RegisterAuthInspectFlags(fs *flag.FlagSet, opts *AuthInspectOptions) {
+	fs.StringVar(&opts.Provider, "provider", "codex", "Provider to inspect (codex, gemini-cli, antigravity or all)")
+	fs.BoolVar(&opts.DeleteInvalid, "delete-invalid", false, "Delete auth files marked invalid after verification")
+	fs.BoolVar(&opts.DeleteInvalid, "auto-delete", false, "Alias for -delete-invalid")
+	fs.StringVar(&opts.Output, "output", "", "Write the per-file JSON report to this path")
+	fs.BoolVar(&opts.Force, "force", false, "Run even if a server is live against the same auth directory")
+}
+
+// DoAuthInspect verifies auth files without starting the server, using the
+// same token store, probes and concurrency as the inspection scheduler. It
+// returns AuthInspectExitInvalid when any file was marked invalid.
+func DoAuthInspect(cfg *config.Config, opts AuthInspectOptions) int {
+	if marker, live := serverlock.Live(cfg.AuthDir, 2*time.Second); live && !opts.Force {
+		log.Errorf("auth inspect: a server (pid %d, %s) is running against %s; stop it or pass -force", marker.PID, marker.Addr, cfg.AuthDir)
+		return AuthInspectExitError
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	manager := cliproxy.NewCoreAuthManager(cfg)
+	if err := manager.Load(ctx); err != nil {
+		log.Errorf("auth inspect: failed to load auth store: %v", err)
+		return AuthInspectExitError
+	}
+
+	report, errInspect := management.InspectAuthFiles(ctx, cfg, manager, opts.Provider, opts.DeleteInvalid)
+	if path := strings.TrimSpace(opts.Output); path != "" {
+		if err := writeAuthInspectReport(path, report); err != nil {
+			log.Errorf("auth inspect: %v", err)
+			return AuthInspectExitError
+		}
+	}
+	printAuthInspectSummary(report)
+	if errInspect != nil {
+		log.Errorf("auth inspect: %v", errInspect)
+		return AuthInspectExitError
+	}
+	if report.Invalid > 0 {
+		return AuthInspectExitInvalid
+	}
+	return AuthInspectExitOK
+}
+
+func writeAuthInspectReport(path string, report *management.AuthInspectionReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+	if err = os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
+
+func printAuthInspectSummary(report *management.AuthInspectionReport) {
+	provider := report.Provider
+	if provider == "" {
+		provider = "all"
+	}
+	fmt.Printf("Auth inspection (%s): %d checked, %d valid, %d invalid of %d candidates in %s\n",
+		provider, report.Checked, report.Valid, report.Invalid, report.Total,
+		report.FinishedAt.Sub(report.StartedAt).Round(time.Second))
+	for _, result := range report.Results {
+		if !result.Invalid {
+			continue
+		}
+		if result.Reason != "" {
+			fmt.Printf("  invalid: %s (%s)\n", result.Name, result.Reason)
+		} else {
+			fmt.Printf("  invalid: %s\n", result.Name)
+		}
+	}
+	if report.Matched > 0 || report.Deleted > 0 {
+		fmt.Printf("Deleted %d of %d invalid auth files\n", report.Deleted, report.Matched)
+	}
+}
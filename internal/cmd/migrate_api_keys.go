@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoMigrateAPIKeys replaces plaintext api-keys entries in the config file with
+// "sha256:" entries. A timestamped backup of the file is written first.
+func DoMigrateAPIKeys(cfg *config.Config, configFilePath string) {
+	if cfg == nil || strings.TrimSpace(configFilePath) == "" {
+		log.Errorf("migrate-api-keys: no config file loaded")
+		return
+	}
+	converted, backup, err := config.MigrateAPIKeysFile(configFilePath, cfg)
+	if err != nil {
+		log.Errorf("migrate-api-keys: %v", err)
+		return
+	}
+	if converted == 0 {
+		log.Info("migrate-api-keys: no plaintext api-keys found")
+		return
+	}
+	log.Infof("migrate-api-keys: hashed %d api-keys (backup written to %s)", converted, backup)
+}
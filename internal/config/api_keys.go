@@ -0,0 +1,129 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// APIKeyHashPrefix marks api-keys entries stored as SHA-256 digests.
+	APIKeyHashPrefix = "sha256:"
+	// apiKeyHintLength is how much of a plaintext key is kept next to its hash so
+	// operators can tell entries apart.
+	apiKeyHintLength = 6
+)
+
+// HashAPIKey returns the canonical "sha256:<hex>" form of key. It is also the
+// identity used for usage tracking of requests authenticated with a hashed entry.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return APIKeyHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// HashedAPIKeyEntry returns the api-keys entry stored for key:
+// "sha256:<hex>:<prefix>", where prefix is the first few characters of key.
+func HashedAPIKeyEntry(key string) string {
+	hint := key
+	if len(hint) > apiKeyHintLength {
+		hint = hint[:apiKeyHintLength]
+	}
+	return HashAPIKey(key) + ":" + hint
+}
+
+// IsHashedAPIKey reports whether entry is a hashed api-keys entry.
+func IsHashedAPIKey(entry string) bool {
+	_, _, ok := ParseHashedAPIKey(entry)
+	return ok
+}
+
+// ParseHashedAPIKey splits a hashed entry into its canonical hash ("sha256:<hex>")
+// and optional identification prefix.
+func ParseHashedAPIKey(entry string) (hash, hint string, ok bool) {
+	entry = strings.TrimSpace(entry)
+	if !strings.HasPrefix(entry, APIKeyHashPrefix) {
+		return "", "", false
+	}
+	digest, hint, _ := strings.Cut(strings.TrimPrefix(entry, APIKeyHashPrefix), ":")
+	digest = strings.ToLower(digest)
+	if len(digest) != sha256.Size*2 {
+		return "", "", false
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		return "", "", false
+	}
+	return APIKeyHashPrefix + digest, hint, true
+}
+
+// PlaintextAPIKeyCount returns how many api-keys entries are stored in plaintext.
+func (cfg *Config) PlaintextAPIKeyCount() int {
+	if cfg == nil {
+		return 0
+	}
+	count := 0
+	for _, key := range cfg.APIKeys {
+		if strings.TrimSpace(key) != "" && !IsHashedAPIKey(key) {
+			count++
+		}
+	}
+	return count
+}
+
+// HashPlaintextAPIKeys converts plaintext api-keys entries to hashed entries in
+// memory and returns how many were converted.
+func (cfg *Config) HashPlaintextAPIKeys() int {
+	if cfg == nil {
+		return 0
+	}
+	converted := 0
+	for i, key := range cfg.APIKeys {
+		trimmed := strings.TrimSpace(key)
+		if trimmed == "" || IsHashedAPIKey(trimmed) {
+			continue
+		}
+		cfg.APIKeys[i] = HashedAPIKeyEntry(trimmed)
+		converted++
+	}
+	return converted
+}
+
+// BackupConfigFile copies configFile next to itself with a timestamp suffix and
+// returns the backup path.
+func BackupConfigFile(configFile string) (string, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return "", fmt.Errorf("read config for backup: %w", err)
+	}
+	info, err := os.Stat(configFile)
+	if err != nil {
+		return "", fmt.Errorf("stat config for backup: %w", err)
+	}
+	backup := fmt.Sprintf("%s.bak-%s", configFile, time.Now().UTC().Format("20060102T150405Z"))
+	if err = os.WriteFile(backup, data, info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("write config backup: %w", err)
+	}
+	return backup, nil
+}
+
+// MigrateAPIKeysFile hashes plaintext api-keys in configFile after writing a
+// backup. It returns the number of converted entries and the backup path, which
+// is empty when nothing needed converting.
+func MigrateAPIKeysFile(configFile string, cfg *Config) (int, string, error) {
+	if cfg.PlaintextAPIKeyCount() == 0 {
+		return 0, "", nil
+	}
+	backup, err := BackupConfigFile(configFile)
+	if err != nil {
+		return 0, "", err
+	}
+	previous := append([]string(nil), cfg.APIKeys...)
+	converted := cfg.HashPlaintextAPIKeys()
+	if err = SaveConfigPreserveComments(configFile, cfg); err != nil {
+		cfg.APIKeys = previous
+		return 0, backup, err
+	}
+	return converted, backup, nil
+}
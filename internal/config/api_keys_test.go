@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseHashedAPIKey(t *testing.T) {
+	entry := HashedAPIKeyEntry("sk-example-key")
+	hash, hint, ok := ParseHashedAPIKey(entry)
+	if !ok {
+		t.Fatalf("expected %q to parse", entry)
+	}
+	if hash != HashAPIKey("sk-example-key") || hint != "sk-exa" {
+		t.Fatalf("unexpected parse result hash=%q hint=%q", hash, hint)
+	}
+	if _, _, ok = ParseHashedAPIKey(HashAPIKey("sk-example-key")); !ok {
+		t.Fatalf("entry without hint should parse")
+	}
+	for _, invalid := range []string{"sk-example-key", "sha256:abc", "sha256:" + strings.Repeat("z", 64)} {
+		if IsHashedAPIKey(invalid) {
+			t.Fatalf("%q should not be treated as hashed", invalid)
+		}
+	}
+}
+
+func TestMigrateAPIKeysFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	original := "port: 8317\napi-keys:\n  - \"plain-one\"\n  - \"" + HashAPIKey("already") + "\"\n"
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	converted, backup, err := MigrateAPIKeysFile(path, cfg)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if converted != 1 {
+		t.Fatalf("expected 1 converted key, got %d", converted)
+	}
+	if data, _ := os.ReadFile(backup); string(data) != original {
+		t.Fatalf("backup does not match original config")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read migrated config: %v", err)
+	}
+	if strings.Contains(string(data), "plain-one") {
+		t.Fatalf("migrated config still contains the plaintext key:\n%s", data)
+	}
+	if !strings.Contains(string(data), HashAPIKey("plain-one")) {
+		t.Fatalf("migrated config is missing the hashed key:\n%s", data)
+	}
+}
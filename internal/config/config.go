@@ -9,9 +9,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/netip"
 	"os"
 	"strings"
 	"syscall"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
@@ -38,18 +40,41 @@ type Config struct {
 	// RemoteManagement nests management-related options under 'remote-management'.
 	RemoteManagement RemoteManagement `yaml:"remote-management" json:"-"`
 
+	// Management configures a dedicated listener for the management API.
+	Management ManagementListener `yaml:"management,omitempty" json:"-"`
+
 	// AuthInspection controls automatic auth token inspection scheduler behavior.
 	AuthInspection AuthInspectionConfig `yaml:"auth-inspection,omitempty" json:"auth-inspection,omitempty"`
 
+	// Notifications lists outbound channels that receive operational events such as alerts.
+	Notifications NotificationsConfig `yaml:"notifications,omitempty" json:"notifications,omitempty"`
+
+	// Alerts configures threshold rules evaluated against auth state.
+	Alerts AlertsConfig `yaml:"alerts,omitempty" json:"alerts,omitempty"`
+
+	// Backup configures scheduled backups of the registered auth files.
+	Backup BackupConfig `yaml:"backup,omitempty" json:"-"`
+
 	// AuthDir is the directory where authentication token files are stored.
 	AuthDir string `yaml:"auth-dir" json:"-"`
 
+	// AuthTrashRetentionDays is how long auth files deleted through the
+	// management API stay in the .trash directory of AuthDir, restorable,
+	// before they are purged. Defaults to 30; negative keeps them forever.
+	AuthTrashRetentionDays int `yaml:"auth-trash-retention-days,omitempty" json:"-"`
+
 	// Debug enables or disables debug-level logging and other debug features.
 	Debug bool `yaml:"debug" json:"debug"`
 
+	// DebugPprofEnabled exposes pprof profiles under /v0/management/debug/pprof/ behind management auth.
+	DebugPprofEnabled bool `yaml:"debug-pprof-enabled,omitempty" json:"debug-pprof-enabled,omitempty"`
+
 	// Pprof config controls the optional pprof HTTP debug server.
 	Pprof PprofConfig `yaml:"pprof" json:"pprof"`
 
+	// Shutdown controls how long the server drains on SIGINT/SIGTERM.
+	Shutdown ShutdownConfig `yaml:"shutdown" json:"shutdown"`
+
 	// CommercialMode disables high-overhead HTTP middleware features to minimize per-request memory usage.
 	CommercialMode bool `yaml:"commercial-mode" json:"commercial-mode"`
 
@@ -128,6 +153,50 @@ type TLSConfig struct {
 	Cert string `yaml:"cert" json:"cert"`
 	// Key is the path to the TLS private key file.
 	Key string `yaml:"key" json:"key"`
+	// Management holds TLS settings that only apply to management routes.
+	Management ManagementTLSConfig `yaml:"management,omitempty" json:"management,omitempty"`
+}
+
+// ManagementTLSConfig holds TLS settings for the management plane.
+type ManagementTLSConfig struct {
+	// MTLS configures client certificate authentication for /v0/management routes.
+	MTLS ManagementMTLS `yaml:"mtls,omitempty" json:"mtls,omitempty"`
+}
+
+// ManagementMTLS configures client certificates for management requests. Proxy
+// routes on the same listener are unaffected: certificates are requested during
+// the handshake but only enforced on management routes.
+type ManagementMTLS struct {
+	// Enable turns client certificate verification on. Requires tls.enable.
+	Enable bool `yaml:"enable" json:"enable"`
+	// ClientCA is the path to the PEM bundle used to verify client certificates.
+	// The file is re-read when it changes on disk.
+	ClientCA string `yaml:"client-ca" json:"client-ca"`
+	// RequireAndVerify rejects management requests that present no certificate.
+	// When false a certificate is optional but still verified when presented.
+	RequireAndVerify bool `yaml:"require-and-verify" json:"require-and-verify"`
+	// AllowedSubjects optionally restricts accepted certificates to those whose
+	// common name or a subject alternative name matches one of these glob patterns.
+	AllowedSubjects []string `yaml:"allowed-subjects,omitempty" json:"allowed-subjects,omitempty"`
+}
+
+// ManagementListener moves the management API to its own address.
+type ManagementListener struct {
+	// Listen is the host:port serving /v0/management and the control panel.
+	// Empty keeps them on the main listener. Changes require a restart.
+	Listen string `yaml:"listen,omitempty" json:"listen,omitempty"`
+	// TLS configures HTTPS for the dedicated listener. tls.management.mtls
+	// applies to it as well.
+	TLS ManagementListenerTLS `yaml:"tls,omitempty" json:"tls,omitempty"`
+}
+
+// ManagementListenerTLS holds HTTPS settings for the dedicated management listener.
+type ManagementListenerTLS struct {
+	// Enable serves the management listener over HTTPS.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Cert and Key default to tls.cert and tls.key when empty.
+	Cert string `yaml:"cert,omitempty" json:"cert,omitempty"`
+	Key  string `yaml:"key,omitempty" json:"key,omitempty"`
 }
 
 // PprofConfig holds pprof HTTP server settings.
@@ -138,6 +207,16 @@ type PprofConfig struct {
 	Addr string `yaml:"addr" json:"addr"`
 }
 
+// ShutdownConfig holds graceful shutdown timings.
+type ShutdownConfig struct {
+	// GracePeriodSeconds is how long in-flight requests may finish after the
+	// listeners close. Default 30.
+	GracePeriodSeconds int `yaml:"grace-period-seconds,omitempty" json:"grace-period-seconds,omitempty"`
+	// HardDeadlineSeconds forces the process to exit if shutdown has not
+	// completed by then. Default 60; raised to the grace period plus 10s if lower.
+	HardDeadlineSeconds int `yaml:"hard-deadline-seconds,omitempty" json:"hard-deadline-seconds,omitempty"`
+}
+
 // RemoteManagement holds management API configuration under 'remote-management'.
 type RemoteManagement struct {
 	// AllowRemote toggles remote (non-localhost) access to management API.
@@ -149,6 +228,88 @@ type RemoteManagement struct {
 	// PanelGitHubRepository overrides the GitHub repository used to fetch the management panel asset.
 	// Accepts either a repository URL (https://github.com/org/repo) or an API releases endpoint.
 	PanelGitHubRepository string `yaml:"panel-github-repository"`
+	// Users lists additional management keys bound to a role (admin, operator or viewer).
+	// The secret-key above always acts as admin.
+	Users []ManagementUser `yaml:"users,omitempty"`
+	// AllowedNetworks restricts management access to these CIDRs (or single IPs). Empty allows all.
+	AllowedNetworks []string `yaml:"allowed-networks,omitempty"`
+	// TrustedProxies lists peers whose X-Forwarded-For / X-Real-IP headers are honored
+	// when resolving the client address for AllowedNetworks.
+	TrustedProxies []string `yaml:"trusted-proxies,omitempty"`
+	// NotifyLockouts sends authentication lockout events to the notification channels.
+	NotifyLockouts bool `yaml:"notify-lockouts,omitempty"`
+	// AllowRawSecret keeps accepting raw management keys on every request (for automation).
+	// When false, raw keys are only accepted by /v0/management/login. Defaults to true.
+	AllowRawSecret *bool `yaml:"allow-raw-secret,omitempty"`
+	// SessionTTLSeconds is the sliding lifetime of management sessions. Defaults to 3600.
+	SessionTTLSeconds int `yaml:"session-ttl-seconds,omitempty"`
+	// CORS configures cross-origin access to the management API for a separately hosted WebUI.
+	CORS ManagementCORS `yaml:"cors,omitempty"`
+	// Tokens are scoped management tokens limited to named endpoint groups.
+	Tokens []ManagementToken `yaml:"tokens,omitempty"`
+	// AuthStatusHook configures the signed webhook that marks auths invalid from external signals.
+	AuthStatusHook AuthStatusHook `yaml:"auth-status-hook,omitempty"`
+}
+
+// AuthStatusHook configures POST /v0/management/hooks/auth-status.
+type AuthStatusHook struct {
+	// Secret is the shared HMAC-SHA256 key that signs deliveries. Empty disables the hook.
+	Secret string `yaml:"secret,omitempty"`
+}
+
+// RawSecretAllowed reports whether raw management keys are accepted outside of login.
+func (r RemoteManagement) RawSecretAllowed() bool {
+	return r.AllowRawSecret == nil || *r.AllowRawSecret
+}
+
+// ManagementCORS holds CORS settings applied to /v0/management routes only.
+type ManagementCORS struct {
+	// AllowedOrigins lists exact origins or wildcard subdomains ("https://*.example.com").
+	// Empty disables management-specific CORS.
+	AllowedOrigins []string `yaml:"allowed-origins,omitempty" json:"allowed-origins,omitempty"`
+	// AllowedHeaders overrides the request headers allowed in preflight responses.
+	AllowedHeaders []string `yaml:"allowed-headers,omitempty" json:"allowed-headers,omitempty"`
+	// MaxAge is the preflight cache lifetime in seconds.
+	MaxAge int `yaml:"max-age,omitempty" json:"max-age,omitempty"`
+	// AllowCredentials sets Access-Control-Allow-Credentials for allowed origins.
+	AllowCredentials bool `yaml:"allow-credentials,omitempty" json:"allow-credentials,omitempty"`
+}
+
+// ManagementToken is a management credential limited to a set of scopes.
+type ManagementToken struct {
+	// ID identifies the token for listing and revocation.
+	ID string `yaml:"id" json:"id"`
+	// Label names the token in the audit log.
+	Label string `yaml:"label" json:"label"`
+	// Scopes lists the endpoint groups the token may call, e.g. "usage:read".
+	Scopes []string `yaml:"scopes" json:"scopes"`
+	// TokenHash is the hex SHA-256 digest of the token.
+	TokenHash string `yaml:"token-hash" json:"-"`
+	// Prefix is the start of the token, kept to help identify it.
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	// CreatedAt records when the token was issued.
+	CreatedAt time.Time `yaml:"created-at,omitempty" json:"created-at,omitempty"`
+	// ExpiresAt, when set, ends the token's validity.
+	ExpiresAt *time.Time `yaml:"expires-at,omitempty" json:"expires-at,omitempty"`
+}
+
+// ManagementUser is a named management key with a role.
+type ManagementUser struct {
+	// Name identifies the user in the audit log.
+	Name string `yaml:"name" json:"name"`
+	// Role is one of admin, operator or viewer.
+	Role string `yaml:"role" json:"role"`
+	// KeyHash is the bcrypt hash of the user's key. Plaintext values are hashed on load.
+	KeyHash string `yaml:"key-hash" json:"-"`
+	// CreatedAt records when the user was added.
+	CreatedAt time.Time `yaml:"created-at,omitempty" json:"created-at,omitempty"`
+	// TOTPSecret is the base32 TOTP secret written at enrollment. It is only
+	// enforced once TOTPEnabled is set by a successful verification.
+	TOTPSecret string `yaml:"totp-secret,omitempty" json:"-"`
+	// TOTPEnabled requires a TOTP or recovery code at login.
+	TOTPEnabled bool `yaml:"totp-enabled,omitempty" json:"totp-enabled,omitempty"`
+	// RecoveryCodes holds SHA-256 hashes of the unused single-use recovery codes.
+	RecoveryCodes []string `yaml:"recovery-codes,omitempty" json:"-"`
 }
 
 // AuthInspectionConfig controls background token inspection and optional cleanup.
@@ -157,8 +318,308 @@ type AuthInspectionConfig struct {
 	Enabled bool `yaml:"enabled" json:"enabled"`
 	// IntervalSeconds controls the scheduler interval in seconds.
 	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+	// Cron is a five-field cron expression (minute hour day-of-month month
+	// day-of-week) in the server's local time. It takes precedence over
+	// IntervalSeconds when set.
+	Cron string `yaml:"cron,omitempty" json:"cron,omitempty"`
+	// JitterSeconds delays every scheduled run by a random 0..N seconds so
+	// replicas started together do not probe at the same moment. Manual runs
+	// are never delayed.
+	JitterSeconds int `yaml:"jitter-seconds,omitempty" json:"jitter-seconds,omitempty"`
+	// RunOnStart queues an inspection StartDelaySeconds after the server
+	// boots, so auths that went bad while it was down are found without
+	// waiting a full interval. It runs even when the schedule is disabled.
+	RunOnStart bool `yaml:"run-on-start,omitempty" json:"run-on-start,omitempty"`
+	// StartDelaySeconds gives the token store time to load before the
+	// RunOnStart inspection, 1-600. Defaults to 30.
+	StartDelaySeconds int `yaml:"start-delay-seconds,omitempty" json:"start-delay-seconds,omitempty"`
 	// AutoDeleteInvalid removes invalid auth files automatically after each run when true.
 	AutoDeleteInvalid bool `yaml:"auto-delete-invalid,omitempty" json:"auto-delete-invalid,omitempty"`
+	// AutoDisableInvalid sidelines invalid auths after each run instead of
+	// deleting them, even with AutoDeleteInvalid set: they stay on disk but are
+	// marked unavailable until a later verification finds them valid.
+	AutoDisableInvalid bool `yaml:"auto-disable-invalid,omitempty" json:"auto-disable-invalid,omitempty"`
+	// DryRun makes auto-delete list the invalid files it would remove, under
+	// would_delete in the run status and history, instead of removing them.
+	DryRun bool `yaml:"dry-run,omitempty" json:"dry-run,omitempty"`
+	// SkipDeleteOnSystemic keeps the invalid files of a run whose invalids are
+	// dominated by a single reason, which usually points at an upstream outage
+	// rather than dead accounts.
+	SkipDeleteOnSystemic bool `yaml:"skip-delete-on-systemic,omitempty" json:"skip-delete-on-systemic,omitempty"`
+	// SkipVerifyOnUpload stops auth files uploaded through the management API
+	// from being probed in the background right after they are registered.
+	SkipVerifyOnUpload bool `yaml:"skip-verify-on-upload,omitempty" json:"skip-verify-on-upload,omitempty"`
+	// InvalidStatusCodes lists the codex usage probe statuses that mark an auth
+	// invalid besides 401 and 403. Other failures, 5xx after retries included,
+	// leave the auth's state alone.
+	InvalidStatusCodes []int `yaml:"invalid-status-codes,omitempty" json:"invalid-status-codes,omitempty"`
+	// QuarantineDir, when set, receives the invalid auth files that inspection
+	// and delete-invalid would otherwise delete, each with a sidecar recording
+	// its original path and reason. It must lie outside the auth dir.
+	QuarantineDir string `yaml:"quarantine-dir,omitempty" json:"quarantine-dir,omitempty"`
+	// NotifyURL receives a JSON POST after every run that finds newly invalid
+	// auths or deletes any, listing the newly invalid files and their reasons.
+	NotifyURL string `yaml:"notify-url,omitempty" json:"notify-url,omitempty"`
+	// VerifyConcurrency is the probe concurrency of providers that do not set
+	// their own, 1-100. Defaults to 40.
+	VerifyConcurrency int `yaml:"verify-concurrency,omitempty" json:"verify-concurrency,omitempty"`
+	// VerifyPoolSize caps the probes in flight across all providers of a run,
+	// 1-200, so small providers interleave with large ones instead of adding
+	// their own full concurrency. Defaults to verify-concurrency.
+	VerifyPoolSize int `yaml:"verify-pool-size,omitempty" json:"verify-pool-size,omitempty"`
+	// VerifyBatchSize is the number of auths probed per round, 10-500. Defaults to 100.
+	VerifyBatchSize int `yaml:"verify-batch-size,omitempty" json:"verify-batch-size,omitempty"`
+	// RunTimeoutSeconds bounds a whole run. Defaults to two hours.
+	RunTimeoutSeconds int `yaml:"run-timeout-seconds,omitempty" json:"run-timeout-seconds,omitempty"`
+	// Scope limits scheduled runs to a subset of the auths: "all" (the
+	// default) or "invalid_only", the auths marked invalid or in error.
+	Scope string `yaml:"scope,omitempty" json:"scope,omitempty"`
+	// MinReverifySeconds leaves auths verified valid less than this long ago
+	// out of later probes. Zero probes every auth on every run.
+	MinReverifySeconds int `yaml:"min-reverify-seconds,omitempty" json:"min-reverify-seconds,omitempty"`
+	// VerifyCacheSeconds is how long verify-invalid calls reuse an auth's last
+	// probe result instead of probing it again, 1-3600. Defaults to 60. The
+	// result is dropped once the auth's token changes; force=true bypasses it.
+	VerifyCacheSeconds int `yaml:"verify-cache-seconds,omitempty" json:"verify-cache-seconds,omitempty"`
+	// ProbeFailureThreshold is how many probes of an auth in a row may fail
+	// with non-auth errors, such as timeouts or 5xx responses, before batch
+	// verification backs off from it, 1-100. Defaults to 3.
+	ProbeFailureThreshold int `yaml:"probe-failure-threshold,omitempty" json:"probe-failure-threshold,omitempty"`
+	// ProbeBackoffSeconds is how long batch verification then leaves the auth
+	// unprobed, doubling with each further failure up to a day, 60-86400.
+	// Defaults to 300. Verifying the auth by ID always probes it.
+	ProbeBackoffSeconds int `yaml:"probe-backoff-seconds,omitempty" json:"probe-backoff-seconds,omitempty"`
+	// ProbeRatePerMinute caps the outbound probes of all inspections and
+	// verify calls together, whatever their concurrency. Zero means no cap.
+	ProbeRatePerMinute int `yaml:"probe-rate-per-minute,omitempty" json:"probe-rate-per-minute,omitempty"`
+	// Providers lists the providers each run inspects, each in its own loop
+	// running in parallel. Empty inspects codex only.
+	Providers []AuthInspectionProvider `yaml:"providers,omitempty" json:"providers,omitempty"`
+	// ProviderOverrides gives the named providers their own schedule and
+	// cleanup setting; providers without an entry use the settings above.
+	ProviderOverrides map[string]AuthInspectionOverride `yaml:"provider-overrides,omitempty" json:"provider-overrides,omitempty"`
+	// Schedules, when set, replace the schedule above with independent named
+	// schedules, each inspecting its own providers on its own interval or
+	// cron. Runs never overlap: a schedule falling due during another run
+	// waits for it to finish.
+	Schedules []AuthInspectionSchedule `yaml:"schedules,omitempty" json:"schedules,omitempty"`
+	// Verification overrides the probe of the named providers, e.g. to point
+	// it at a self-hosted or regional gateway. Providers without an entry use
+	// the built-in probe target and timeout.
+	Verification map[string]AuthInspectionVerification `yaml:"verification,omitempty" json:"verification,omitempty"`
+	// IncludePatterns limits inspection and auto-delete to the auths whose
+	// file name matches one of these globs. Empty includes every auth.
+	IncludePatterns []string `yaml:"include-patterns,omitempty" json:"include-patterns,omitempty"`
+	// ExcludePatterns keeps the auths whose file name matches one of these
+	// globs out of inspection and auto-delete, even when included.
+	ExcludePatterns []string `yaml:"exclude-patterns,omitempty" json:"exclude-patterns,omitempty"`
+	// IncludeTags limits inspection and auto-delete to the auths tagged with
+	// one of these tags. Empty includes every auth.
+	IncludeTags []string `yaml:"include-tags,omitempty" json:"include-tags,omitempty"`
+	// ExcludeTags keeps the auths tagged with one of these tags out of
+	// inspection and auto-delete, even when included.
+	ExcludeTags []string `yaml:"exclude-tags,omitempty" json:"exclude-tags,omitempty"`
+	// InstanceID names this replica when several share a token store; only the
+	// lease holder runs scheduled inspections. Defaults to the hostname.
+	InstanceID string `yaml:"instance-id,omitempty" json:"instance-id,omitempty"`
+}
+
+// AuthInspectionProvider is one provider inspected by each run.
+type AuthInspectionProvider struct {
+	// Name is the auth provider, e.g. "codex" or "gemini-cli".
+	Name string `yaml:"name" json:"name"`
+	// Concurrency bounds the probes in flight for this provider. Defaults to
+	// the top-level verify-concurrency.
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+}
+
+// AuthInspectionOverride replaces the top-level inspection settings for one provider.
+type AuthInspectionOverride struct {
+	// IntervalSeconds inspects the provider every N seconds, ignoring the
+	// top-level cron. 0 keeps the top-level schedule.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+	// AutoDeleteInvalid overrides the top-level setting when set.
+	AutoDeleteInvalid *bool `yaml:"auto-delete-invalid,omitempty" json:"auto-delete-invalid,omitempty"`
+}
+
+// AuthInspectionVerification configures how one provider's auths are probed.
+type AuthInspectionVerification struct {
+	// ProbeURL replaces the URL the probe requests. Empty keeps the built-in one.
+	ProbeURL string `yaml:"probe-url,omitempty" json:"probe-url,omitempty"`
+	// FallbackProbeURL is requested once when the probe URL answers 5xx or
+	// times out. Empty keeps the built-in one; only codex has one.
+	FallbackProbeURL string `yaml:"fallback-probe-url,omitempty" json:"fallback-probe-url,omitempty"`
+	// TimeoutSeconds bounds each probe request, 1-120. Defaults to 30.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+	// ExpectedStatusCodes lists the probe statuses that mean the token is
+	// valid. Empty accepts any 2xx.
+	ExpectedStatusCodes []int `yaml:"expected-status-codes,omitempty" json:"expected-status-codes,omitempty"`
+	// ErrorRules decides what the probe's error responses mean. Unset keeps
+	// the provider's built-in rules.
+	ErrorRules *AuthInspectionErrorRules `yaml:"error-rules,omitempty" json:"error-rules,omitempty"`
+}
+
+// AuthInspectionErrorRules classifies a provider's probe responses by HTTP
+// status and by the error code of the response body. A code match wins over
+// a status match; a response matching several lists is ignored before it is
+// throttled, and throttled before it is invalid.
+type AuthInspectionErrorRules struct {
+	// Invalid responses mark the auth invalid.
+	Invalid AuthInspectionErrorMatch `yaml:"invalid,omitempty" json:"invalid,omitempty"`
+	// Ignore responses leave the auth's state alone.
+	Ignore AuthInspectionErrorMatch `yaml:"ignore,omitempty" json:"ignore,omitempty"`
+	// Throttle responses only mark the auth throttled.
+	Throttle AuthInspectionErrorMatch `yaml:"throttle,omitempty" json:"throttle,omitempty"`
+}
+
+// AuthInspectionErrorMatch lists the probe responses one error rule applies to.
+type AuthInspectionErrorMatch struct {
+	StatusCodes []int `yaml:"status-codes,omitempty" json:"status-codes,omitempty"`
+	// Codes are matched case-insensitively against the error code of the
+	// body, e.g. error.code or error.type.
+	Codes []string `yaml:"codes,omitempty" json:"codes,omitempty"`
+}
+
+// AuthInspectionSchedule is one named inspection schedule. Unset fields
+// inherit the top-level settings.
+type AuthInspectionSchedule struct {
+	// Name identifies the schedule in the status and run history.
+	Name string `yaml:"name" json:"name"`
+	// Providers limits the schedule to these configured providers. Empty
+	// inspects them all.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+	// IntervalSeconds runs the schedule every N seconds, ignoring Cron.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+	// Cron is a five-field cron expression in the server's local time.
+	Cron string `yaml:"cron,omitempty" json:"cron,omitempty"`
+	// Scope is "all" or "invalid_only", as for the top-level scope.
+	Scope string `yaml:"scope,omitempty" json:"scope,omitempty"`
+	// AutoDeleteInvalid overrides the top-level and per-provider settings
+	// for this schedule's runs when set.
+	AutoDeleteInvalid *bool `yaml:"auto-delete-invalid,omitempty" json:"auto-delete-invalid,omitempty"`
+}
+
+// BackupConfig controls scheduled backups of the auth directory.
+type BackupConfig struct {
+	// Enabled turns scheduled backups on. Manual runs work regardless.
+	Enabled bool `yaml:"enabled"`
+	// IntervalSeconds runs a backup every N seconds. Ignored when Cron is set.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty"`
+	// Cron is a five-field cron expression (minute hour day-of-month month day-of-week) in UTC.
+	Cron string `yaml:"cron,omitempty"`
+	// Retention is the number of backups kept at the destination. 0 keeps all.
+	Retention int `yaml:"retention,omitempty"`
+	// Passphrase encrypts archives with AES-256-GCM.
+	Passphrase string `yaml:"passphrase,omitempty"`
+	// AllowPlaintext uploads unencrypted archives with tokens intact when no
+	// passphrase is set. Otherwise token fields are redacted from the archive.
+	AllowPlaintext bool `yaml:"allow-plaintext,omitempty"`
+	// Destination is where archives are written.
+	Destination BackupDestination `yaml:"destination"`
+}
+
+// BackupDestination selects and configures the backup target.
+type BackupDestination struct {
+	// Type is "local", "sftp" or "s3".
+	Type string `yaml:"type"`
+	// Path is the local directory, the remote SFTP directory, or the S3 key prefix.
+	Path string `yaml:"path,omitempty"`
+
+	// Host is the SFTP server as host or host:port.
+	Host string `yaml:"host,omitempty"`
+	// User is the SFTP login.
+	User string `yaml:"user,omitempty"`
+	// Password authenticates the SFTP login when no private key is set.
+	Password string `yaml:"password,omitempty"`
+	// PrivateKey is a path to a PEM private key for SFTP.
+	PrivateKey string `yaml:"private-key,omitempty"`
+	// KnownHosts is a known_hosts file used to verify the SFTP host key. Required for SFTP.
+	KnownHosts string `yaml:"known-hosts,omitempty"`
+
+	// Endpoint is the S3-compatible endpoint, e.g. s3.amazonaws.com.
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// Bucket is the S3 bucket.
+	Bucket string `yaml:"bucket,omitempty"`
+	// Region is the S3 region.
+	Region string `yaml:"region,omitempty"`
+	// AccessKey and SecretKey are the S3 credentials.
+	AccessKey string `yaml:"access-key,omitempty"`
+	SecretKey string `yaml:"secret-key,omitempty"`
+	// Insecure uses plain HTTP for the S3 endpoint.
+	Insecure bool `yaml:"insecure,omitempty"`
+	// PathStyle forces path-style bucket addressing.
+	PathStyle bool `yaml:"path-style,omitempty"`
+}
+
+// NotificationsConfig lists outbound notification channels.
+type NotificationsConfig struct {
+	// Webhooks receive an HTTP POST for every notification event.
+	Webhooks []WebhookNotification `yaml:"webhooks,omitempty" json:"webhooks,omitempty"`
+	// Telegram targets receive every notification event as a bot message.
+	Telegram []TelegramNotification `yaml:"telegram,omitempty" json:"telegram,omitempty"`
+	// Slack targets receive every notification event through an incoming webhook.
+	Slack []SlackNotification `yaml:"slack,omitempty" json:"slack,omitempty"`
+}
+
+// TelegramNotification describes a Telegram chat that a bot posts to.
+type TelegramNotification struct {
+	// Name identifies the target in logs.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// BotToken is the token issued by @BotFather.
+	BotToken string `yaml:"bot-token" json:"bot-token"`
+	// ChatID is the numeric chat ID or the @username of a public channel.
+	ChatID string `yaml:"chat-id" json:"chat-id"`
+}
+
+// SlackNotification describes a Slack incoming webhook.
+type SlackNotification struct {
+	// Name identifies the target in logs.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// WebhookURL is the incoming webhook URL created in the Slack app.
+	WebhookURL string `yaml:"webhook-url" json:"webhook-url"`
+}
+
+// WebhookNotification describes a single outbound webhook target.
+type WebhookNotification struct {
+	// Name identifies the target in logs and alert history.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// URL is the endpoint that receives the POST request.
+	URL string `yaml:"url" json:"url"`
+	// Format selects the payload shape: "json" (default) or "discord".
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+	// Headers are added to every request sent to this target.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// SigningSecret, when set, signs every delivery with an X-CLIProxy-Signature
+	// HMAC-SHA256 header. See sdk/webhook for the verification procedure.
+	SigningSecret string `yaml:"signing-secret,omitempty" json:"signing-secret,omitempty"`
+}
+
+// AlertsConfig controls threshold-based alerting.
+type AlertsConfig struct {
+	// Enabled turns rule evaluation on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// EvaluateIntervalSeconds additionally evaluates rules against live auth state on this period.
+	// Zero evaluates only after each auth inspection run.
+	EvaluateIntervalSeconds int `yaml:"evaluate-interval-seconds,omitempty" json:"evaluate-interval-seconds,omitempty"`
+	// Rules lists the alert rules to evaluate.
+	Rules []AlertRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// AlertRule describes one alert condition.
+type AlertRule struct {
+	// Name identifies the rule; defaults to "<type>:<provider>".
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Type selects the evaluator, e.g. "invalid-ratio".
+	Type string `yaml:"type" json:"type"`
+	// Provider restricts the rule to a single provider; empty evaluates across all providers.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	// Threshold fires the alert when the observed value reaches it.
+	Threshold float64 `yaml:"threshold" json:"threshold"`
+	// ResolveThreshold resolves a firing alert once the value drops below it.
+	// Defaults to 90% of Threshold so the alert does not flap around the boundary.
+	ResolveThreshold float64 `yaml:"resolve-threshold,omitempty" json:"resolve-threshold,omitempty"`
+	// MinTotal skips evaluation until at least this many auths are present.
+	MinTotal int `yaml:"min-total,omitempty" json:"min-total,omitempty"`
 }
 
 // QuotaExceeded defines the behavior when API quota limits are exceeded.
@@ -584,6 +1045,11 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		_ = SaveConfigPreserveCommentsUpdateNestedScalar(configFile, []string{"remote-management", "secret-key"}, hashed)
 	}
 
+	if err = cfg.SanitizeManagementUsers(); err != nil {
+		return nil, err
+	}
+	cfg.SanitizeManagementNetworks()
+
 	cfg.RemoteManagement.PanelGitHubRepository = strings.TrimSpace(cfg.RemoteManagement.PanelGitHubRepository)
 	if cfg.RemoteManagement.PanelGitHubRepository == "" {
 		cfg.RemoteManagement.PanelGitHubRepository = DefaultPanelGitHubRepository
@@ -626,6 +1092,14 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
+	// Drop incomplete notification targets and alert rules.
+	cfg.SanitizeNotifications()
+	cfg.SanitizeAlerts()
+
+	if n := cfg.PlaintextAPIKeyCount(); n > 0 {
+		log.Warnf("%d api-keys entries are stored in plaintext; plaintext keys are deprecated, convert them with --migrate-api-keys or POST /v0/management/api-keys/migrate", n)
+	}
+
 	// NOTE: Legacy migration persistence is intentionally disabled together with
 	// startup legacy migration to keep startup read-only for config.yaml.
 	// Re-enable the block below if automatic startup migration is needed again.
@@ -645,6 +1119,164 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	return &cfg, nil
 }
 
+// SanitizeManagementUsers normalizes management users, drops entries without a
+// name, key or known role, and hashes plaintext keys in memory.
+func (cfg *Config) SanitizeManagementUsers() error {
+	if cfg == nil || len(cfg.RemoteManagement.Users) == 0 {
+		return nil
+	}
+	out := make([]ManagementUser, 0, len(cfg.RemoteManagement.Users))
+	seen := make(map[string]struct{}, len(cfg.RemoteManagement.Users))
+	for _, user := range cfg.RemoteManagement.Users {
+		user.Name = strings.TrimSpace(user.Name)
+		user.Role = strings.ToLower(strings.TrimSpace(user.Role))
+		user.KeyHash = strings.TrimSpace(user.KeyHash)
+		if user.Name == "" || user.KeyHash == "" {
+			continue
+		}
+		switch user.Role {
+		case "admin", "operator", "viewer":
+		default:
+			continue
+		}
+		if _, dup := seen[user.Name]; dup {
+			continue
+		}
+		seen[user.Name] = struct{}{}
+		if !looksLikeBcrypt(user.KeyHash) {
+			hashed, errHash := hashSecret(user.KeyHash)
+			if errHash != nil {
+				return fmt.Errorf("failed to hash management key for user %s: %w", user.Name, errHash)
+			}
+			user.KeyHash = hashed
+		}
+		out = append(out, user)
+	}
+	cfg.RemoteManagement.Users = out
+	return nil
+}
+
+// SanitizeManagementNetworks drops allowed-networks and trusted-proxies entries
+// that are neither a CIDR nor an IP address.
+func (cfg *Config) SanitizeManagementNetworks() {
+	if cfg == nil {
+		return
+	}
+	cfg.RemoteManagement.AllowedNetworks = sanitizeNetworkList(cfg.RemoteManagement.AllowedNetworks, "allowed-networks")
+	cfg.RemoteManagement.TrustedProxies = sanitizeNetworkList(cfg.RemoteManagement.TrustedProxies, "trusted-proxies")
+}
+
+func sanitizeNetworkList(entries []string, section string) []string {
+	if len(entries) == 0 {
+		return entries
+	}
+	out := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		trimmed := strings.TrimSpace(entry)
+		if trimmed == "" {
+			continue
+		}
+		var errParse error
+		if strings.Contains(trimmed, "/") {
+			_, errParse = netip.ParsePrefix(trimmed)
+		} else {
+			_, errParse = netip.ParseAddr(trimmed)
+		}
+		if errParse != nil {
+			log.Warnf("remote-management.%s: ignoring invalid entry %q: %v", section, trimmed, errParse)
+			continue
+		}
+		out = append(out, trimmed)
+	}
+	return out
+}
+
+// SanitizeNotifications trims notification targets and drops entries without
+// a URL, or without a bot token and chat ID.
+func (cfg *Config) SanitizeNotifications() {
+	if cfg == nil {
+		return
+	}
+	cfg.sanitizeWebhookNotifications()
+	cfg.sanitizeChatNotifications()
+}
+
+func (cfg *Config) sanitizeWebhookNotifications() {
+	if len(cfg.Notifications.Webhooks) == 0 {
+		return
+	}
+	out := make([]WebhookNotification, 0, len(cfg.Notifications.Webhooks))
+	for _, target := range cfg.Notifications.Webhooks {
+		target.Name = strings.TrimSpace(target.Name)
+		target.URL = strings.TrimSpace(target.URL)
+		target.Format = strings.ToLower(strings.TrimSpace(target.Format))
+		target.Headers = NormalizeHeaders(target.Headers)
+		if target.URL == "" {
+			continue
+		}
+		out = append(out, target)
+	}
+	cfg.Notifications.Webhooks = out
+}
+
+func (cfg *Config) sanitizeChatNotifications() {
+	if len(cfg.Notifications.Telegram) > 0 {
+		telegram := make([]TelegramNotification, 0, len(cfg.Notifications.Telegram))
+		for _, target := range cfg.Notifications.Telegram {
+			target.Name = strings.TrimSpace(target.Name)
+			target.BotToken = strings.TrimSpace(target.BotToken)
+			target.ChatID = strings.TrimSpace(target.ChatID)
+			if target.BotToken == "" || target.ChatID == "" {
+				continue
+			}
+			telegram = append(telegram, target)
+		}
+		cfg.Notifications.Telegram = telegram
+	}
+	if len(cfg.Notifications.Slack) > 0 {
+		slack := make([]SlackNotification, 0, len(cfg.Notifications.Slack))
+		for _, target := range cfg.Notifications.Slack {
+			target.Name = strings.TrimSpace(target.Name)
+			target.WebhookURL = strings.TrimSpace(target.WebhookURL)
+			if target.WebhookURL == "" {
+				continue
+			}
+			slack = append(slack, target)
+		}
+		cfg.Notifications.Slack = slack
+	}
+}
+
+// SanitizeAlerts normalizes alert rules and drops rules without a type or with an out-of-range threshold.
+func (cfg *Config) SanitizeAlerts() {
+	if cfg == nil {
+		return
+	}
+	if cfg.Alerts.EvaluateIntervalSeconds < 0 {
+		cfg.Alerts.EvaluateIntervalSeconds = 0
+	}
+	if len(cfg.Alerts.Rules) == 0 {
+		return
+	}
+	out := make([]AlertRule, 0, len(cfg.Alerts.Rules))
+	for _, rule := range cfg.Alerts.Rules {
+		rule.Name = strings.TrimSpace(rule.Name)
+		rule.Type = strings.ToLower(strings.TrimSpace(rule.Type))
+		rule.Provider = strings.ToLower(strings.TrimSpace(rule.Provider))
+		if rule.Type == "" || rule.Threshold <= 0 {
+			continue
+		}
+		if rule.ResolveThreshold < 0 || rule.ResolveThreshold >= rule.Threshold {
+			rule.ResolveThreshold = 0
+		}
+		if rule.MinTotal < 0 {
+			rule.MinTotal = 0
+		}
+		out = append(out, rule)
+	}
+	cfg.Alerts.Rules = out
+}
+
 // SanitizePayloadRules validates raw JSON payload rule params and drops invalid rules.
 func (cfg *Config) SanitizePayloadRules() {
 	if cfg == nil {
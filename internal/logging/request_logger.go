@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -350,6 +351,8 @@ func (l *FileRequestLogger) LogStreamingRequest(url, method string, headers map[
 	}
 
 	// Start async writer goroutine
+	openStreamingWriters.Add(1)
+	writer.tracked = true
 	go writer.asyncWriter()
 
 	return writer, nil
@@ -1013,6 +1016,27 @@ type FileStreamingLogWriter struct {
 
 	// apiResponseTimestamp captures when the API response was received.
 	apiResponseTimestamp time.Time
+
+	// tracked reports whether the writer is counted in openStreamingWriters.
+	tracked bool
+}
+
+// openStreamingWriters counts streaming log writers that have not been closed.
+var openStreamingWriters atomic.Int64
+
+// WaitForStreamingWriters blocks until every open streaming request log has
+// been closed and written out, or ctx is done.
+func WaitForStreamingWriters(ctx context.Context) error {
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for openStreamingWriters.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d streaming request logs still open: %w", openStreamingWriters.Load(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
 }
 
 // WriteChunkAsync writes a response chunk asynchronously (non-blocking).
@@ -1105,6 +1129,10 @@ func (w *FileStreamingLogWriter) SetFirstChunkTimestamp(timestamp time.Time) {
 // Returns:
 //   - error: An error if closing fails, nil otherwise
 func (w *FileStreamingLogWriter) Close() error {
+	if w.tracked {
+		w.tracked = false
+		defer openStreamingWriters.Add(-1)
+	}
 	if w.chunkChan != nil {
 		close(w.chunkChan)
 	}
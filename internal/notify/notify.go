@@ -0,0 +1,265 @@
+// Package notify delivers operational events, such as alerts, to the outbound
+// channels configured under the "notifications" section of the config file.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/webhook"
+)
+
+const (
+	// FormatJSON posts the Event as-is.
+	FormatJSON = "json"
+	// FormatDiscord posts a Discord-compatible {"content": "..."} body.
+	FormatDiscord = "discord"
+
+	sendTimeout = 10 * time.Second
+	// sendAttempts bounds delivery attempts per target for network errors,
+	// 429 and 5xx responses.
+	sendAttempts = 3
+)
+
+// Event is a single notification payload.
+type Event struct {
+	Type      string         `json:"type"`
+	Severity  string         `json:"severity,omitempty"`
+	Title     string         `json:"title"`
+	Message   string         `json:"message"`
+	Data      map[string]any `json:"data,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// httpClient, retryBackoff and telegramAPI are swapped in tests.
+var (
+	httpClient   = &http.Client{Timeout: sendTimeout}
+	retryBackoff = time.Second
+	telegramAPI  = "https://api.telegram.org"
+)
+
+// sender delivers events to one configured channel.
+type sender interface {
+	// label names the channel in errors without revealing its credentials.
+	label() string
+	send(ctx context.Context, event Event) error
+}
+
+// senders returns a sender for every configured channel.
+func senders(cfg config.NotificationsConfig) []sender {
+	out := make([]sender, 0, len(cfg.Webhooks)+len(cfg.Telegram)+len(cfg.Slack))
+	for _, target := range cfg.Webhooks {
+		out = append(out, webhookSender(target))
+	}
+	for _, target := range cfg.Telegram {
+		out = append(out, telegramSender(target))
+	}
+	for _, target := range cfg.Slack {
+		out = append(out, slackSender(target))
+	}
+	return out
+}
+
+// Send delivers event to every configured channel. Delivery continues after a
+// failing target; the returned error joins all failures.
+func Send(ctx context.Context, cfg config.NotificationsConfig, event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var errs []error
+	for _, target := range senders(cfg) {
+		if err := target.send(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target.label(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HasChannels reports whether at least one channel is configured.
+func HasChannels(cfg config.NotificationsConfig) bool {
+	return len(cfg.Webhooks)+len(cfg.Telegram)+len(cfg.Slack) > 0
+}
+
+type webhookSender config.WebhookNotification
+
+func (s webhookSender) label() string {
+	if s.Name != "" {
+		return "webhook " + s.Name
+	}
+	return "webhook " + s.URL
+}
+
+func (s webhookSender) send(ctx context.Context, event Event) error {
+	return sendWebhook(ctx, config.WebhookNotification(s), event)
+}
+
+type telegramSender config.TelegramNotification
+
+func (s telegramSender) label() string {
+	if s.Name != "" {
+		return "telegram " + s.Name
+	}
+	return "telegram chat " + s.ChatID
+}
+
+// send posts the event as a plain-text message through the Bot API.
+func (s telegramSender) send(ctx context.Context, event Event) error {
+	token := strings.TrimSpace(s.BotToken)
+	if token == "" || strings.TrimSpace(s.ChatID) == "" {
+		return errors.New("missing bot token or chat id")
+	}
+	body, err := json.Marshal(map[string]any{
+		"chat_id":                  strings.TrimSpace(s.ChatID),
+		"text":                     PlainText(event),
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+	endpoint := telegramAPI + "/bot" + token + "/sendMessage"
+	return retryDelivery(ctx, func() (bool, error) {
+		return postJSON(ctx, endpoint, body, nil)
+	})
+}
+
+type slackSender config.SlackNotification
+
+func (s slackSender) label() string {
+	if s.Name != "" {
+		return "slack " + s.Name
+	}
+	return "slack webhook"
+}
+
+// send posts the event as a plain-text message to the incoming webhook.
+func (s slackSender) send(ctx context.Context, event Event) error {
+	endpoint := strings.TrimSpace(s.WebhookURL)
+	if endpoint == "" {
+		return errors.New("missing webhook url")
+	}
+	body, err := json.Marshal(map[string]string{"text": PlainText(event)})
+	if err != nil {
+		return err
+	}
+	return retryDelivery(ctx, func() (bool, error) {
+		return postJSON(ctx, endpoint, body, nil)
+	})
+}
+
+// sendWebhook delivers event to target, retrying transient failures. Every
+// attempt carries the same delivery ID; signed targets get a fresh timestamp
+// and signature per attempt.
+func sendWebhook(ctx context.Context, target config.WebhookNotification, event Event) error {
+	endpoint := strings.TrimSpace(target.URL)
+	if endpoint == "" {
+		return errors.New("missing url")
+	}
+	body, err := webhookBody(target.Format, event)
+	if err != nil {
+		return err
+	}
+	deliveryID := uuid.NewString()
+	return retryDelivery(ctx, func() (bool, error) {
+		return postJSON(ctx, endpoint, body, func(header http.Header) {
+			for key, value := range target.Headers {
+				header.Set(key, value)
+			}
+			header.Set(webhook.HeaderEvent, event.Type)
+			header.Set(webhook.HeaderDelivery, deliveryID)
+			if target.SigningSecret != "" {
+				header.Set(webhook.HeaderSignature, webhook.Sign(target.SigningSecret, time.Now(), body))
+			}
+		})
+	})
+}
+
+// retryDelivery runs deliver up to sendAttempts times with exponential
+// backoff while it reports the failure as worth retrying.
+func retryDelivery(ctx context.Context, deliver func() (bool, error)) error {
+	var lastErr error
+	for attempt := 0; attempt < sendAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return errors.Join(lastErr, ctx.Err())
+			case <-time.After(retryBackoff * time.Duration(1<<(attempt-1))):
+			}
+		}
+		retry, errAttempt := deliver()
+		if errAttempt == nil {
+			return nil
+		}
+		lastErr = errAttempt
+		if !retry {
+			break
+		}
+	}
+	return lastErr
+}
+
+// postJSON performs one POST of body, letting setHeaders add headers, and
+// reports whether a failure is worth retrying. Transport errors leave out
+// the URL, which may carry a credential.
+func postJSON(ctx context.Context, endpoint string, body []byte, setHeaders func(http.Header)) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, errors.New("invalid url")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if setHeaders != nil {
+		setHeaders(req.Header)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return ctx.Err() == nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+func webhookBody(format string, event Event) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", FormatJSON:
+		return json.Marshal(event)
+	case FormatDiscord:
+		return json.Marshal(map[string]string{"content": PlainText(event)})
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// PlainText renders event as a short human-readable message for chat channels.
+func PlainText(event Event) string {
+	title := strings.TrimSpace(event.Title)
+	message := strings.TrimSpace(event.Message)
+	switch {
+	case title == "":
+		return message
+	case message == "":
+		return title
+	default:
+		return title + "\n" + message
+	}
+}
@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/webhook"
+)
+
+func TestSendSignsAndRetriesWithSameDeliveryID(t *testing.T) {
+	prevBackoff := retryBackoff
+	retryBackoff = time.Millisecond
+	t.Cleanup(func() { retryBackoff = prevBackoff })
+
+	var (
+		mu         sync.Mutex
+		deliveries []string
+		calls      int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := webhook.VerifySignature("s3cret", r.Header.Get(webhook.HeaderSignature), body, time.Minute); err != nil {
+			t.Errorf("signature verification failed: %v", err)
+		}
+		if got := r.Header.Get(webhook.HeaderEvent); got != "alert.firing" {
+			t.Errorf("unexpected event header %q", got)
+		}
+		mu.Lock()
+		deliveries = append(deliveries, r.Header.Get(webhook.HeaderDelivery))
+		calls++
+		attempt := calls
+		mu.Unlock()
+		if attempt == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	cfg := config.NotificationsConfig{Webhooks: []config.WebhookNotification{{URL: srv.URL, SigningSecret: "s3cret"}}}
+	if err := Send(context.Background(), cfg, Event{Type: "alert.firing", Title: "t"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if len(deliveries) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(deliveries))
+	}
+	if deliveries[0] == "" || deliveries[0] != deliveries[1] {
+		t.Fatalf("retries must reuse the delivery ID: %v", deliveries)
+	}
+}
+
+func TestSendDoesNotRetryClientErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get(webhook.HeaderSignature) != "" {
+			t.Errorf("unsigned target should not send a signature")
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	cfg := config.NotificationsConfig{Webhooks: []config.WebhookNotification{{URL: srv.URL}}}
+	if err := Send(context.Background(), cfg, Event{Type: "alert.firing"}); err == nil {
+		t.Fatalf("expected error for 400 response")
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single attempt, got %d", calls)
+	}
+}
+
+func TestSendTelegramAndSlack(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		paths  []string
+		bodies []map[string]any
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	prevAPI := telegramAPI
+	telegramAPI = srv.URL
+	t.Cleanup(func() { telegramAPI = prevAPI })
+
+	cfg := config.NotificationsConfig{
+		Telegram: []config.TelegramNotification{{BotToken: "123:abc", ChatID: "-100"}},
+		Slack:    []config.SlackNotification{{WebhookURL: srv.URL + "/services/T/B/X"}},
+	}
+	if !HasChannels(cfg) {
+		t.Fatal("chat targets count as channels")
+	}
+	if err := Send(context.Background(), cfg, Event{Type: "alert.firing", Title: "Title", Message: "body"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if len(paths) != 2 || paths[0] != "/bot123:abc/sendMessage" || paths[1] != "/services/T/B/X" {
+		t.Fatalf("paths = %v", paths)
+	}
+	if bodies[0]["chat_id"] != "-100" || bodies[0]["text"] != "Title\nbody" || bodies[1]["text"] != "Title\nbody" {
+		t.Fatalf("bodies = %v", bodies)
+	}
+}
+
+func TestSendErrorsDoNotLeakTelegramToken(t *testing.T) {
+	prevAPI := telegramAPI
+	telegramAPI = "http://127.0.0.1:1"
+	t.Cleanup(func() { telegramAPI = prevAPI })
+	prevBackoff := retryBackoff
+	retryBackoff = time.Millisecond
+	t.Cleanup(func() { retryBackoff = prevBackoff })
+
+	cfg := config.NotificationsConfig{Telegram: []config.TelegramNotification{{BotToken: "123:secret", ChatID: "-100"}}}
+	err := Send(context.Background(), cfg, Event{Type: "alert.firing"})
+	if err == nil || strings.Contains(err.Error(), "secret") || !strings.Contains(err.Error(), "telegram chat -100") {
+		t.Fatalf("err = %v", err)
+	}
+}
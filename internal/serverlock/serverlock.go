@@ -0,0 +1,82 @@
+// Package serverlock records which server instance is running against an auth
+// directory, so offline tools can avoid racing it.
+package serverlock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileName is the marker written into the auth directory while a server runs.
+// It does not end in .json so auth loaders and watchers ignore it.
+const FileName = ".cliproxy-server.lock"
+
+// Marker describes the running server.
+type Marker struct {
+	PID       int       `json:"pid"`
+	Addr      string    `json:"addr"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Write records a server listening on addr in authDir. The returned function
+// removes the marker and is safe to call more than once.
+func Write(authDir, addr string) (func(), error) {
+	path := filepath.Join(authDir, FileName)
+	data, err := json.Marshal(Marker{PID: os.Getpid(), Addr: addr, StartedAt: time.Now().UTC()})
+	if err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		return nil, fmt.Errorf("write server marker: %w", err)
+	}
+	return func() { _ = os.Remove(path) }, nil
+}
+
+// Read returns the marker in authDir, if any.
+func Read(authDir string) (Marker, bool, error) {
+	data, err := os.ReadFile(filepath.Join(authDir, FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return Marker{}, false, nil
+	}
+	if err != nil {
+		return Marker{}, false, err
+	}
+	var marker Marker
+	if err = json.Unmarshal(data, &marker); err != nil {
+		return Marker{}, false, fmt.Errorf("parse server marker: %w", err)
+	}
+	return marker, true, nil
+}
+
+// Live reports whether a server recorded in authDir still accepts connections.
+// A marker left behind by a crashed server is treated as stale.
+func Live(authDir string, timeout time.Duration) (Marker, bool) {
+	marker, ok, err := Read(authDir)
+	if err != nil || !ok || strings.TrimSpace(marker.Addr) == "" {
+		return marker, false
+	}
+	conn, err := net.DialTimeout("tcp", dialAddr(marker.Addr), timeout)
+	if err != nil {
+		return marker, false
+	}
+	_ = conn.Close()
+	return marker, true
+}
+
+// dialAddr turns a listen address such as ":8317" into one that can be dialled.
+func dialAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port)
+}
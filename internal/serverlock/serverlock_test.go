@@ -0,0 +1,36 @@
+package serverlock
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestLive(t *testing.T) {
+	dir := t.TempDir()
+	if _, live := Live(dir, time.Second); live {
+		t.Fatal("no marker should not be live")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	release, err := Write(dir, ln.Addr().String())
+	if err != nil {
+		t.Fatalf("write marker: %v", err)
+	}
+	if _, live := Live(dir, time.Second); !live {
+		t.Fatal("marker with a listening address should be live")
+	}
+
+	_ = ln.Close()
+	if _, live := Live(dir, time.Second); live {
+		t.Fatal("marker for a closed listener should be stale")
+	}
+
+	release()
+	if _, ok, _ := Read(dir); ok {
+		t.Fatal("release should remove the marker")
+	}
+}
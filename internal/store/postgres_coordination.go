@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AcquireLease takes the named lease for holder, or renews it when holder
+// already owns it. It fails without error when another holder owns an
+// unexpired lease. Expiry uses the database clock so replicas with skewed
+// clocks agree.
+func (s *PostgresStore) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO %[1]s (id, holder, expires_at, updated_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 millisecond', NOW())
+		ON CONFLICT (id) DO UPDATE
+			SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at, updated_at = NOW()
+			WHERE %[1]s.holder = EXCLUDED.holder OR %[1]s.expires_at IS NULL OR %[1]s.expires_at < NOW()
+		RETURNING holder
+	`, s.fullTableName(s.cfg.CoordinationTable))
+	var owner string
+	err := s.db.QueryRowContext(ctx, query, "lease:"+name, holder, ttl.Milliseconds()).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("postgres store: acquire lease %s: %w", name, err)
+	}
+	return owner == holder, nil
+}
+
+// ReleaseLease gives up the named lease if holder owns it.
+func (s *PostgresStore) ReleaseLease(ctx context.Context, name, holder string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 AND holder = $2", s.fullTableName(s.cfg.CoordinationTable))
+	if _, err := s.db.ExecContext(ctx, query, "lease:"+name, holder); err != nil {
+		return fmt.Errorf("postgres store: release lease %s: %w", name, err)
+	}
+	return nil
+}
+
+// LeaseHolder returns the current owner of the named lease, or "" when it is
+// free or expired.
+func (s *PostgresStore) LeaseHolder(ctx context.Context, name string) (string, error) {
+	query := fmt.Sprintf("SELECT holder FROM %s WHERE id = $1 AND expires_at > NOW()", s.fullTableName(s.cfg.CoordinationTable))
+	var holder string
+	err := s.db.QueryRowContext(ctx, query, "lease:"+name).Scan(&holder)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("postgres store: read lease %s: %w", name, err)
+	}
+	return holder, nil
+}
+
+// SetCoordinationValue stores a small value shared between replicas.
+func (s *PostgresStore) SetCoordinationValue(ctx context.Context, key, value string) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (id, value, updated_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (id) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()
+	`, s.fullTableName(s.cfg.CoordinationTable))
+	if _, err := s.db.ExecContext(ctx, query, "value:"+key, value); err != nil {
+		return fmt.Errorf("postgres store: set %s: %w", key, err)
+	}
+	return nil
+}
+
+// CoordinationValue returns the value stored under key, or "".
+func (s *PostgresStore) CoordinationValue(ctx context.Context, key string) (string, error) {
+	query := fmt.Sprintf("SELECT value FROM %s WHERE id = $1", s.fullTableName(s.cfg.CoordinationTable))
+	var value string
+	err := s.db.QueryRowContext(ctx, query, "value:"+key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("postgres store: read %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// TakeCoordinationValue removes and returns the value stored under key, so
+// only one replica observes it.
+func (s *PostgresStore) TakeCoordinationValue(ctx context.Context, key string) (string, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 RETURNING value", s.fullTableName(s.cfg.CoordinationTable))
+	var value string
+	err := s.db.QueryRowContext(ctx, query, "value:"+key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("postgres store: take %s: %w", key, err)
+	}
+	return value, nil
+}
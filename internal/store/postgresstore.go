@@ -20,9 +20,10 @@ import (
 )
 
 const (
-	defaultConfigTable = "config_store"
-	defaultAuthTable   = "auth_store"
-	defaultConfigKey   = "config"
+	defaultConfigTable       = "config_store"
+	defaultAuthTable         = "auth_store"
+	defaultCoordinationTable = "coordination_store"
+	defaultConfigKey         = "config"
 )
 
 // PostgresStoreConfig captures configuration required to initialize a Postgres-backed store.
@@ -31,7 +32,9 @@ type PostgresStoreConfig struct {
 	Schema      string
 	ConfigTable string
 	AuthTable   string
-	SpoolDir    string
+	// CoordinationTable holds leases and small values shared between replicas.
+	CoordinationTable string
+	SpoolDir          string
 }
 
 // PostgresStore persists configuration and authentication metadata using PostgreSQL as backend
@@ -58,6 +61,9 @@ func NewPostgresStore(ctx context.Context, cfg PostgresStoreConfig) (*PostgresSt
 	if cfg.AuthTable == "" {
 		cfg.AuthTable = defaultAuthTable
 	}
+	if cfg.CoordinationTable == "" {
+		cfg.CoordinationTable = defaultCoordinationTable
+	}
 
 	spoolRoot := strings.TrimSpace(cfg.SpoolDir)
 	if spoolRoot == "" {
@@ -140,6 +146,18 @@ func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
 	`, authTable)); err != nil {
 		return fmt.Errorf("postgres store: create auth table: %w", err)
 	}
+	coordinationTable := s.fullTableName(s.cfg.CoordinationTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			holder TEXT NOT NULL DEFAULT '',
+			expires_at TIMESTAMPTZ,
+			value TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`, coordinationTable)); err != nil {
+		return fmt.Errorf("postgres store: create coordination table: %w", err)
+	}
 	return nil
 }
 
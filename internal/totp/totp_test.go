@@ -0,0 +1,47 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+func TestCodeAtRFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B SHA1 vectors, truncated to 6 digits.
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tc := range cases {
+		got, err := CodeAt(secret, Step(time.Unix(tc.unix, 0)))
+		if err != nil {
+			t.Fatalf("CodeAt(%d) error: %v", tc.unix, err)
+		}
+		if got != tc.want {
+			t.Fatalf("CodeAt(%d) = %s, want %s", tc.unix, got, tc.want)
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	prev, _ := CodeAt(secret, Step(now)-1)
+	if step, ok := Validate(secret, prev, now, 1); !ok || step != Step(now)-1 {
+		t.Fatalf("previous step code should validate with skew 1")
+	}
+	old, _ := CodeAt(secret, Step(now)-2)
+	if _, ok := Validate(secret, old, now, 1); ok {
+		t.Fatalf("code two steps old should be rejected")
+	}
+}
@@ -0,0 +1,50 @@
+package util
+
+// authCredentialKeys are the auth file fields holding credentials, at any
+// depth: OAuth tokens, API keys, session cookies, OAuth client secrets and
+// service account private keys.
+var authCredentialKeys = map[string]struct{}{
+	"access_token":  {},
+	"refresh_token": {},
+	"id_token":      {},
+	"token":         {},
+	"api_key":       {},
+	"cookie":        {},
+	"client_secret": {},
+	"session_token": {},
+	"password":      {},
+	"private_key":   {},
+}
+
+// StripAuthCredentials removes the credential fields from value, an auth file
+// decoded from JSON, and from every object nested in it.
+func StripAuthCredentials(value any) {
+	redactAuthCredentials(value, func(object map[string]any, key string) { delete(object, key) })
+}
+
+// MaskAuthCredentials replaces the credential fields of value, an auth file
+// decoded from JSON, and of every object nested in it with placeholder.
+func MaskAuthCredentials(value any, placeholder string) {
+	redactAuthCredentials(value, func(object map[string]any, key string) { object[key] = placeholder })
+}
+
+// redactAuthCredentials calls redact for every credential field of value. A
+// credential key holding an object, such as Gemini's "token", is descended
+// into instead, so the fields beside the secrets are kept.
+func redactAuthCredentials(value any, redact func(object map[string]any, key string)) {
+	switch node := value.(type) {
+	case map[string]any:
+		for key, child := range node {
+			_, secret := authCredentialKeys[key]
+			if _, nested := child.(map[string]any); secret && !nested {
+				redact(node, key)
+				continue
+			}
+			redactAuthCredentials(child, redact)
+		}
+	case []any:
+		for _, child := range node {
+			redactAuthCredentials(child, redact)
+		}
+	}
+}
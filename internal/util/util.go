@@ -93,6 +93,14 @@ func ResolveAuthDir(authDir string) (string, error) {
 	return filepath.Clean(authDir), nil
 }
 
+// IsHiddenAuthSubdir reports whether the directory at path, found walking
+// authDir, is hidden and so skipped by everything loading auths from
+// authDir. Hidden directories, such as the management API's .trash, hold no
+// live auths.
+func IsHiddenAuthSubdir(authDir, path string) bool {
+	return path != authDir && strings.HasPrefix(filepath.Base(path), ".")
+}
+
 // CountAuthFiles returns the number of auth records available through the provided Store.
 // For filesystem-backed stores, this reflects the number of JSON auth files under the configured directory.
 func CountAuthFiles[T any](ctx context.Context, store interface {
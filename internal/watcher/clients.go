@@ -82,6 +82,9 @@ func (w *Watcher) reloadClients(rescanAuth bool, affectedOAuthProviders []string
 				if err != nil {
 					return nil
 				}
+				if info.IsDir() && util.IsHiddenAuthSubdir(resolvedAuthDir, path) {
+					return filepath.SkipDir
+				}
 				if !info.IsDir() && strings.HasSuffix(strings.ToLower(info.Name()), ".json") {
 					if data, errReadFile := os.ReadFile(path); errReadFile == nil && len(data) > 0 {
 						sum := sha256.Sum256(data)
@@ -225,6 +228,9 @@ func (w *Watcher) loadFileClients(cfg *config.Config) int {
 			log.Debugf("error accessing path %s: %v", path, err)
 			return err
 		}
+		if info.IsDir() && util.IsHiddenAuthSubdir(authDir, path) {
+			return filepath.SkipDir
+		}
 		if !info.IsDir() && strings.HasSuffix(strings.ToLower(info.Name()), ".json") {
 			authFileCount++
 			log.Debugf("processing auth file %d: %s", authFileCount, filepath.Base(path))
@@ -67,6 +67,9 @@ func (s *FileSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, e
 		if email, _ := metadata["email"].(string); email != "" {
 			label = email
 		}
+		if custom, _ := metadata["label"].(string); strings.TrimSpace(custom) != "" {
+			label = strings.TrimSpace(custom)
+		}
 		// Use relative path under authDir as ID to stay consistent with the file-based token store
 		id := full
 		if rel, errRel := filepath.Rel(ctx.AuthDir, full); errRel == nil && rel != "" {
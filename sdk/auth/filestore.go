@@ -14,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
 
@@ -79,22 +80,24 @@ func (s *FileTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (str
 			if jsonEqual(existing, raw) {
 				return path, nil
 			}
-			file, errOpen := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0o600)
-			if errOpen != nil {
-				return "", fmt.Errorf("auth filestore: open existing failed: %w", errOpen)
-			}
-			if _, errWrite := file.Write(raw); errWrite != nil {
-				_ = file.Close()
-				return "", fmt.Errorf("auth filestore: write existing failed: %w", errWrite)
-			}
-			if errClose := file.Close(); errClose != nil {
-				return "", fmt.Errorf("auth filestore: close existing failed: %w", errClose)
-			}
-			return path, nil
 		} else if !os.IsNotExist(errRead) {
 			return "", fmt.Errorf("auth filestore: read existing failed: %w", errRead)
 		}
-		if errWrite := os.WriteFile(path, raw, 0o600); errWrite != nil {
+		// Write a temporary file and rename it over the record, so a reader
+		// or a crash never sees it half written.
+		tmp, errTemp := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+		if errTemp != nil {
+			return "", fmt.Errorf("auth filestore: create temp file failed: %w", errTemp)
+		}
+		_, errWrite := tmp.Write(raw)
+		if errClose := tmp.Close(); errWrite == nil {
+			errWrite = errClose
+		}
+		if errWrite == nil {
+			errWrite = os.Rename(tmp.Name(), path)
+		}
+		if errWrite != nil {
+			_ = os.Remove(tmp.Name())
 			return "", fmt.Errorf("auth filestore: write file failed: %w", errWrite)
 		}
 	default:
@@ -125,6 +128,9 @@ func (s *FileTokenStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error)
 			return walkErr
 		}
 		if d.IsDir() {
+			if util.IsHiddenAuthSubdir(dir, path) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 		if !strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
@@ -228,6 +234,10 @@ func (s *FileTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth,
 	if disabled {
 		status = cliproxyauth.StatusDisabled
 	}
+	invalidDisabled, _ := metadata[cliproxyauth.MetadataInvalidDisabled].(bool)
+	if invalidDisabled && !disabled {
+		status = cliproxyauth.StatusError
+	}
 	auth := &cliproxyauth.Auth{
 		ID:               id,
 		Provider:         provider,
@@ -235,6 +245,7 @@ func (s *FileTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth,
 		Label:            s.labelFor(metadata),
 		Status:           status,
 		Disabled:         disabled,
+		Unavailable:      invalidDisabled && !disabled,
 		Attributes:       map[string]string{"path": path},
 		Metadata:         metadata,
 		CreatedAt:        info.ModTime(),
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Metadata keys recording that an administrator took an auth out of
+// rotation. An admin-disabled auth keeps its record and is marked
+// unavailable; it is not routed to, probed or deleted automatically until
+// AdminEnable.
+const (
+	// MetadataAdminDisabled holds the RFC 3339 time the auth was disabled.
+	MetadataAdminDisabled = "disabled_by_admin"
+	// MetadataAdminDisabledReason holds the optional reason given.
+	MetadataAdminDisabledReason = "disabled_by_admin_reason"
+)
+
+// AdminDisabledState reports whether auth was disabled by an administrator,
+// when and why. A mark without a parseable time still counts as disabled.
+func AdminDisabledState(auth *Auth) (bool, time.Time, string) {
+	if auth == nil || len(auth.Metadata) == 0 {
+		return false, time.Time{}, ""
+	}
+	var at time.Time
+	switch raw := auth.Metadata[MetadataAdminDisabled].(type) {
+	case string:
+		if strings.TrimSpace(raw) == "" {
+			return false, time.Time{}, ""
+		}
+		at, _ = time.Parse(time.RFC3339, strings.TrimSpace(raw))
+	default:
+		if !metadataTruthy(raw) {
+			return false, time.Time{}, ""
+		}
+	}
+	reason, _ := auth.Metadata[MetadataAdminDisabledReason].(string)
+	return true, at, strings.TrimSpace(reason)
+}
+
+// IsAdminDisabled reports whether auth was disabled by an administrator.
+func IsAdminDisabled(auth *Auth) bool {
+	disabled, _, _ := AdminDisabledState(auth)
+	return disabled
+}
+
+// AdminDisable takes the auth with the given ID out of rotation, recording
+// the time and reason, and writes the mark to the store.
+func (m *Manager) AdminDisable(ctx context.Context, id, reason string) (*Auth, error) {
+	auth, _, err := m.setRuntimeState(ctx, id, func(auth *Auth) bool {
+		if auth.Metadata == nil {
+			auth.Metadata = make(map[string]any)
+		}
+		auth.Metadata[MetadataAdminDisabled] = time.Now().UTC().Format(time.RFC3339)
+		if reason = strings.TrimSpace(reason); reason != "" {
+			auth.Metadata[MetadataAdminDisabledReason] = reason
+		} else {
+			delete(auth.Metadata, MetadataAdminDisabledReason)
+		}
+		auth.Unavailable = true
+		return true
+	})
+	return auth, err
+}
+
+// AdminEnable returns an auth disabled with AdminDisable to rotation. It
+// stays unavailable while its quota is exceeded or its token is marked
+// invalid. It reports whether the auth was disabled.
+func (m *Manager) AdminEnable(ctx context.Context, id string) (*Auth, bool, error) {
+	return m.setRuntimeState(ctx, id, func(auth *Auth) bool {
+		if !IsAdminDisabled(auth) {
+			return false
+		}
+		delete(auth.Metadata, MetadataAdminDisabled)
+		delete(auth.Metadata, MetadataAdminDisabledReason)
+		if invalid, _ := TokenInvalidState(auth); !invalid && !auth.Quota.Exceeded {
+			auth.Unavailable = false
+		}
+		return true
+	})
+}
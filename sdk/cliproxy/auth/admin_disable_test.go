@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAdminDisableKeepsAuthOutOfRotationUntilEnabled(t *testing.T) {
+	inspector, manager, store := newInspectorFixture(t)
+	ctx := context.Background()
+
+	disabled, err := manager.AdminDisable(ctx, "b-bad", " rotating keys ")
+	if err != nil {
+		t.Fatalf("AdminDisable: %v", err)
+	}
+	if !disabled.Unavailable {
+		t.Fatal("admin-disabled auth is available")
+	}
+	store.mu.Lock()
+	persisted := store.items["b-bad"].Clone()
+	store.mu.Unlock()
+	if ok, at, reason := AdminDisabledState(persisted); !ok || at.IsZero() || reason != "rotating keys" {
+		t.Fatalf("mark not persisted: %v", persisted.Metadata)
+	}
+
+	res, err := inspector.VerifyBatch(ctx, "custom", VerifyOptions{BatchSize: 10})
+	if err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	if res.Total != 2 || res.Invalid != 1 {
+		t.Fatalf("batch = %+v", res)
+	}
+
+	// An admin-disabled auth keeps its file and stays out of rotation even
+	// when its invalid mark is cleared.
+	auth := mustAuth(t, manager, "b-bad")
+	SetTokenInvalidState(auth, true, "rejected")
+	manager.mu.Lock()
+	manager.auths["b-bad"] = auth
+	manager.mu.Unlock()
+	deleted, err := inspector.DeleteInvalid(ctx, DeleteOptions{})
+	if err != nil {
+		t.Fatalf("DeleteInvalid: %v", err)
+	}
+	if deleted.Deleted != 1 || !store.has("b-bad") {
+		t.Fatalf("deleted = %+v, admin-disabled auth kept = %v", deleted, store.has("b-bad"))
+	}
+	auth = mustAuth(t, manager, "b-bad")
+	if !ClearTokenInvalid(auth) || !auth.Unavailable {
+		t.Fatal("clearing the invalid mark returned an admin-disabled auth to rotation")
+	}
+	manager.mu.Lock()
+	manager.auths["b-bad"] = auth
+	manager.mu.Unlock()
+
+	enabled, changed, err := manager.AdminEnable(ctx, "b-bad")
+	if err != nil || !changed {
+		t.Fatalf("AdminEnable: changed=%v err=%v", changed, err)
+	}
+	if IsAdminDisabled(enabled) || enabled.Unavailable || enabled.Metadata[MetadataAdminDisabledReason] != nil {
+		t.Fatalf("enabled auth = unavailable %v metadata %v", enabled.Unavailable, enabled.Metadata)
+	}
+	if _, changed, _ = manager.AdminEnable(ctx, "b-bad"); changed {
+		t.Fatal("enabling an enabled auth reported a change")
+	}
+	if _, err = manager.AdminDisable(ctx, "missing", ""); err == nil {
+		t.Fatal("AdminDisable of an unknown auth succeeded")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"path/filepath"
@@ -144,6 +145,18 @@ type Manager struct {
 
 	// Auto refresh state
 	refreshCancel context.CancelFunc
+
+	// unsaved holds IDs whose last store write failed; Flush retries them.
+	unsavedMu sync.Mutex
+	unsaved   map[string]struct{}
+
+	// removed holds IDs whose backing record is gone; their entries are
+	// disabled tombstones that a new registration may replace. Guarded by mu.
+	removed map[string]struct{}
+
+	// editMu serialises Edit, so each edit sees the previous one's result
+	// and the store writes land in the same order.
+	editMu sync.Mutex
 }
 
 // NewManager constructs a manager with optional custom selector and hook.
@@ -161,6 +174,7 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 		hook:            hook,
 		auths:           make(map[string]*Auth),
 		providerOffsets: make(map[string]int),
+		removed:         make(map[string]struct{}),
 	}
 	// atomic.Value requires non-nil initial value.
 	manager.runtimeConfig.Store(&internalconfig.Config{})
@@ -405,7 +419,29 @@ func (m *Manager) UnregisterExecutor(provider string) {
 	m.mu.Unlock()
 }
 
-// Register inserts a new auth entry into the manager.
+// ErrAlreadyRegistered is returned by Register when a live auth with the same
+// ID is already managed. Existing is a copy of that entry.
+type ErrAlreadyRegistered struct {
+	Existing *Auth
+}
+
+func (e *ErrAlreadyRegistered) Error() string {
+	if e == nil || e.Existing == nil {
+		return "auth already registered"
+	}
+	return fmt.Sprintf("auth %s already registered", e.Existing.ID)
+}
+
+// Is reports whether target is an *ErrAlreadyRegistered, so callers can match
+// with errors.Is(err, &ErrAlreadyRegistered{}).
+func (e *ErrAlreadyRegistered) Is(target error) bool {
+	_, ok := target.(*ErrAlreadyRegistered)
+	return ok
+}
+
+// Register inserts a new auth entry into the manager. It returns
+// *ErrAlreadyRegistered when a live entry with the same ID exists; an entry
+// left behind by MarkRemoved is replaced.
 func (m *Manager) Register(ctx context.Context, auth *Auth) (*Auth, error) {
 	if auth == nil {
 		return nil, nil
@@ -414,8 +450,14 @@ func (m *Manager) Register(ctx context.Context, auth *Auth) (*Auth, error) {
 		auth.ID = uuid.NewString()
 	}
 	auth.EnsureIndex()
+	auth.EnsureIdentity()
 	m.mu.Lock()
+	if existing, ok := m.auths[auth.ID]; ok && existing != nil && !m.isRemovedLocked(auth.ID) {
+		m.mu.Unlock()
+		return nil, &ErrAlreadyRegistered{Existing: existing.Clone()}
+	}
 	m.auths[auth.ID] = auth.Clone()
+	delete(m.removed, auth.ID)
 	m.mu.Unlock()
 	m.rebuildAPIKeyModelAliasFromRuntimeConfig()
 	_ = m.persist(ctx, auth)
@@ -423,6 +465,81 @@ func (m *Manager) Register(ctx context.Context, auth *Auth) (*Auth, error) {
 	return auth.Clone(), nil
 }
 
+// RegisterOrUpdate registers auth, or merges it into the live entry with the
+// same ID. The incoming record supplies configuration and metadata, as read
+// from disk; the manager keeps the runtime state it owns: status, cooldowns,
+// quota, per-model state, refresh timestamps and the disabled flag.
+func (m *Manager) RegisterOrUpdate(ctx context.Context, auth *Auth) (*Auth, error) {
+	if auth == nil {
+		return nil, nil
+	}
+	if auth.ID == "" {
+		auth.ID = uuid.NewString()
+	}
+	m.mu.Lock()
+	existing, ok := m.auths[auth.ID]
+	updated := ok && existing != nil && !m.isRemovedLocked(auth.ID)
+	if updated {
+		mergeRuntimeState(auth, existing)
+	}
+	auth.EnsureIndex()
+	auth.EnsureIdentity()
+	m.auths[auth.ID] = auth.Clone()
+	delete(m.removed, auth.ID)
+	m.mu.Unlock()
+	m.rebuildAPIKeyModelAliasFromRuntimeConfig()
+	_ = m.persist(ctx, auth)
+	if updated {
+		m.hook.OnAuthUpdated(ctx, auth.Clone())
+	} else {
+		m.hook.OnAuthRegistered(ctx, auth.Clone())
+	}
+	return auth.Clone(), nil
+}
+
+// mergeRuntimeState copies the state owned by the manager from existing onto
+// auth, which was freshly built from its backing record.
+func mergeRuntimeState(auth, existing *Auth) {
+	if !existing.CreatedAt.IsZero() {
+		auth.CreatedAt = existing.CreatedAt
+	}
+	if auth.LastRefreshedAt.IsZero() {
+		auth.LastRefreshedAt = existing.LastRefreshedAt
+	}
+	if auth.NextRefreshAfter.IsZero() {
+		auth.NextRefreshAfter = existing.NextRefreshAfter
+	}
+	if auth.Runtime == nil {
+		auth.Runtime = existing.Runtime
+	}
+	if !auth.indexAssigned && auth.Index == "" {
+		auth.Index = existing.Index
+		auth.indexAssigned = existing.indexAssigned
+	}
+	auth.Unavailable = existing.Unavailable
+	auth.Quota = existing.Quota
+	auth.LastError = existing.LastError
+	auth.NextRetryAfter = existing.NextRetryAfter
+	auth.ModelStates = nil
+	if len(existing.ModelStates) > 0 {
+		auth.ModelStates = make(map[string]*ModelState, len(existing.ModelStates))
+		for key, state := range existing.ModelStates {
+			auth.ModelStates[key] = state.Clone()
+		}
+	}
+	switch {
+	case existing.Disabled:
+		auth.Disabled = true
+		auth.Status = existing.Status
+		auth.StatusMessage = existing.StatusMessage
+	case auth.Disabled:
+		auth.Status = StatusDisabled
+	default:
+		auth.Status = existing.Status
+		auth.StatusMessage = existing.StatusMessage
+	}
+}
+
 // Update replaces an existing auth entry and notifies hooks.
 func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 	if auth == nil || auth.ID == "" {
@@ -434,7 +551,11 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 		auth.indexAssigned = existing.indexAssigned
 	}
 	auth.EnsureIndex()
+	auth.EnsureIdentity()
 	m.auths[auth.ID] = auth.Clone()
+	if !auth.Disabled {
+		delete(m.removed, auth.ID)
+	}
 	m.mu.Unlock()
 	m.rebuildAPIKeyModelAliasFromRuntimeConfig()
 	_ = m.persist(ctx, auth)
@@ -442,6 +563,131 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 	return auth.Clone(), nil
 }
 
+// MarkRemoved disables the auth with the given ID because its backing record
+// is gone, recording message as the status. The entry stays visible as a
+// tombstone until a later Register or RegisterOrUpdate replaces it.
+func (m *Manager) MarkRemoved(ctx context.Context, id, message string) (*Auth, bool) {
+	m.mu.Lock()
+	existing, ok := m.auths[id]
+	if !ok || existing == nil {
+		m.mu.Unlock()
+		return nil, false
+	}
+	auth := existing.Clone()
+	auth.Disabled = true
+	auth.Status = StatusDisabled
+	auth.StatusMessage = message
+	auth.UpdatedAt = time.Now()
+	m.auths[id] = auth.Clone()
+	m.removed[id] = struct{}{}
+	m.mu.Unlock()
+	m.rebuildAPIKeyModelAliasFromRuntimeConfig()
+	_ = m.persist(ctx, auth)
+	m.hook.OnAuthUpdated(ctx, auth.Clone())
+	return auth.Clone(), true
+}
+
+// Edit applies edit to a copy of the auth with the given ID, installs the
+// result and writes it to the store. edit runs with the manager locked, so it
+// must not call back into the manager; when it fails the auth is left as it
+// was. Concurrent edits of the same auth therefore never lose one another's
+// changes.
+func (m *Manager) Edit(ctx context.Context, id string, edit func(*Auth) error) (*Auth, error) {
+	m.editMu.Lock()
+	defer m.editMu.Unlock()
+	m.mu.Lock()
+	existing, ok := m.auths[id]
+	if !ok || existing == nil {
+		m.mu.Unlock()
+		return nil, &Error{Code: "auth_not_found", Message: "auth not found"}
+	}
+	auth := existing.Clone()
+	if err := edit(auth); err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	auth.UpdatedAt = time.Now()
+	m.auths[id] = auth.Clone()
+	m.mu.Unlock()
+	m.rebuildAPIKeyModelAliasFromRuntimeConfig()
+	if err := m.saveEdited(ctx, auth); err != nil {
+		return auth.Clone(), err
+	}
+	m.hook.OnAuthUpdated(ctx, auth.Clone())
+	return auth.Clone(), nil
+}
+
+// Rename moves the auth with the given ID to newID, applying edit to it
+// first so its file name and path can follow, and writes it to the store.
+// The auth keeps its runtime state and index. It returns
+// *ErrAlreadyRegistered when a live auth holds newID; an entry left behind
+// by MarkRemoved is replaced.
+func (m *Manager) Rename(ctx context.Context, id, newID string, edit func(*Auth)) (*Auth, error) {
+	if newID == "" {
+		newID = id
+	}
+	m.editMu.Lock()
+	defer m.editMu.Unlock()
+	m.mu.Lock()
+	existing, ok := m.auths[id]
+	if !ok || existing == nil {
+		m.mu.Unlock()
+		return nil, &Error{Code: "auth_not_found", Message: "auth not found"}
+	}
+	if taken, exists := m.auths[newID]; newID != id && exists && taken != nil && !m.isRemovedLocked(newID) {
+		m.mu.Unlock()
+		return nil, &ErrAlreadyRegistered{Existing: taken.Clone()}
+	}
+	auth := existing.Clone()
+	auth.ID = newID
+	if edit != nil {
+		edit(auth)
+	}
+	auth.UpdatedAt = time.Now()
+	delete(m.auths, id)
+	delete(m.removed, id)
+	delete(m.removed, newID)
+	m.auths[newID] = auth.Clone()
+	m.mu.Unlock()
+	m.rebuildAPIKeyModelAliasFromRuntimeConfig()
+	if newID != id {
+		m.trackUnsaved(id, nil)
+	}
+	if err := m.saveEdited(ctx, auth); err != nil {
+		return auth.Clone(), err
+	}
+	if newID != id {
+		m.hook.OnAuthRegistered(ctx, auth.Clone())
+	} else {
+		m.hook.OnAuthUpdated(ctx, auth.Clone())
+	}
+	return auth.Clone(), nil
+}
+
+// saveEdited writes an auth changed by Edit or Rename to the store. Like
+// Freeze it writes frozen records too, which persist would skip.
+func (m *Manager) saveEdited(ctx context.Context, auth *Auth) error {
+	if m.store == nil || shouldSkipPersist(ctx) || isRuntimeOnly(auth) {
+		return nil
+	}
+	_, err := m.store.Save(ctx, auth)
+	m.trackUnsaved(auth.ID, err)
+	return err
+}
+
+// Removed reports whether the auth with the given ID was removed with
+// MarkRemoved and not registered again since.
+func (m *Manager) Removed(id string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.isRemovedLocked(id)
+}
+
+func (m *Manager) isRemovedLocked(id string) bool {
+	_, ok := m.removed[id]
+	return ok
+}
+
 // Load resets manager state from the backing store.
 func (m *Manager) Load(ctx context.Context) error {
 	m.mu.Lock()
@@ -454,11 +700,13 @@ func (m *Manager) Load(ctx context.Context) error {
 		return err
 	}
 	m.auths = make(map[string]*Auth, len(items))
+	m.removed = make(map[string]struct{})
 	for _, auth := range items {
 		if auth == nil || auth.ID == "" {
 			continue
 		}
 		auth.EnsureIndex()
+		auth.EnsureIdentity()
 		m.auths[auth.ID] = auth.Clone()
 	}
 	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
@@ -1567,10 +1815,14 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		}
 	}
 	registryRef := registry.GetGlobalRegistry()
+	now := time.Now()
 	for _, candidate := range m.auths {
 		if candidate.Provider != provider || candidate.Disabled {
 			continue
 		}
+		if frozen, _ := FrozenState(candidate, now); frozen || IsInvalidDisabled(candidate) || IsAdminDisabled(candidate) {
+			continue
+		}
 		if _, used := tried[candidate.ID]; used {
 			continue
 		}
@@ -1629,10 +1881,14 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		}
 	}
 	registryRef := registry.GetGlobalRegistry()
+	now := time.Now()
 	for _, candidate := range m.auths {
 		if candidate == nil || candidate.Disabled {
 			continue
 		}
+		if frozen, _ := FrozenState(candidate, now); frozen || IsInvalidDisabled(candidate) || IsAdminDisabled(candidate) {
+			continue
+		}
 		providerKey := strings.TrimSpace(strings.ToLower(candidate.Provider))
 		if providerKey == "" {
 			continue
@@ -1690,6 +1946,10 @@ func (m *Manager) persist(ctx context.Context, auth *Auth) error {
 	if shouldSkipPersist(ctx) {
 		return nil
 	}
+	// A frozen record is only written by Freeze and Unfreeze.
+	if IsFrozen(auth) {
+		return nil
+	}
 	if auth.Attributes != nil {
 		if v := strings.ToLower(strings.TrimSpace(auth.Attributes["runtime_only"])); v == "true" {
 			return nil
@@ -1700,9 +1960,60 @@ func (m *Manager) persist(ctx context.Context, auth *Auth) error {
 		return nil
 	}
 	_, err := m.store.Save(ctx, auth)
+	m.trackUnsaved(auth.ID, err)
 	return err
 }
 
+func (m *Manager) trackUnsaved(id string, err error) {
+	m.unsavedMu.Lock()
+	defer m.unsavedMu.Unlock()
+	if err == nil {
+		delete(m.unsaved, id)
+		return
+	}
+	if m.unsaved == nil {
+		m.unsaved = make(map[string]struct{})
+	}
+	m.unsaved[id] = struct{}{}
+}
+
+// Flush retries store writes that previously failed, so runtime state such
+// as cooldowns and quota backoff survives a shutdown. It returns the first
+// error and leaves the failed auths queued.
+func (m *Manager) Flush(ctx context.Context) error {
+	if m == nil || m.store == nil {
+		return nil
+	}
+	m.unsavedMu.Lock()
+	ids := make([]string, 0, len(m.unsaved))
+	for id := range m.unsaved {
+		ids = append(ids, id)
+	}
+	m.unsavedMu.Unlock()
+
+	var firstErr error
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		m.mu.RLock()
+		current := m.auths[id]
+		var auth *Auth
+		if current != nil {
+			auth = current.Clone()
+		}
+		m.mu.RUnlock()
+		if auth == nil {
+			m.trackUnsaved(id, nil)
+			continue
+		}
+		if err := m.persist(ctx, auth); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("persist %s: %w", id, err)
+		}
+	}
+	return firstErr
+}
+
 // StartAutoRefresh launches a background loop that evaluates auth freshness
 // every few seconds and triggers refresh operations when required.
 // Only one loop is kept alive; starting a new one cancels the previous run.
@@ -1745,6 +2056,7 @@ func (m *Manager) checkRefreshes(ctx context.Context) {
 	// log.Debugf("checking refreshes")
 	now := time.Now()
 	snapshot := m.snapshotAuths()
+	m.expireFreezes(ctx, snapshot, now)
 	for _, a := range snapshot {
 		typ, _ := a.AccountInfo()
 		if typ != "api_key" {
@@ -1778,6 +2090,9 @@ func (m *Manager) shouldRefresh(a *Auth, now time.Time) bool {
 	if a == nil || a.Disabled {
 		return false
 	}
+	if frozen, _ := FrozenState(a, now); frozen {
+		return false
+	}
 	if !a.NextRefreshAfter.IsZero() && now.Before(a.NextRefreshAfter) {
 		return false
 	}
@@ -1986,6 +2301,9 @@ func (m *Manager) markRefreshPending(id string, now time.Time) bool {
 	if !ok || auth == nil || auth.Disabled {
 		return false
 	}
+	if frozen, _ := FrozenState(auth, now); frozen {
+		return false
+	}
 	if !auth.NextRefreshAfter.IsZero() && now.Before(auth.NextRefreshAfter) {
 		return false
 	}
@@ -2005,7 +2323,7 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 		exec = m.executors[auth.Provider]
 	}
 	m.mu.RUnlock()
-	if auth == nil || exec == nil {
+	if auth == nil || exec == nil || IsFrozen(auth) {
 		return
 	}
 	cloned := auth.Clone()
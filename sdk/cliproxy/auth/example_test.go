@@ -0,0 +1,79 @@
+package auth_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// exampleStore keeps auth records in memory.
+type exampleStore struct {
+	mu    sync.Mutex
+	items map[string]*coreauth.Auth
+}
+
+func (s *exampleStore) List(context.Context) ([]*coreauth.Auth, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*coreauth.Auth, 0, len(s.items))
+	for _, a := range s.items {
+		out = append(out, a.Clone())
+	}
+	return out, nil
+}
+
+func (s *exampleStore) Save(_ context.Context, auth *coreauth.Auth) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.items == nil {
+		s.items = make(map[string]*coreauth.Auth)
+	}
+	s.items[auth.ID] = auth.Clone()
+	return auth.ID, nil
+}
+
+func (s *exampleStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, id)
+	return nil
+}
+
+func ExampleInspector() {
+	ctx := context.Background()
+	store := &exampleStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	_, _ = manager.Register(ctx, &coreauth.Auth{ID: "alice.json", Provider: "acme", Metadata: map[string]any{"api_key": "live"}})
+	_, _ = manager.Register(ctx, &coreauth.Auth{ID: "bob.json", Provider: "acme", Metadata: map[string]any{"api_key": "revoked"}})
+
+	inspector := coreauth.NewInspector(manager)
+	inspector.RegisterProbe("acme", coreauth.ProbeFunc(func(_ context.Context, auth *coreauth.Auth) (bool, string, error) {
+		// A real probe would call the provider with the credentials.
+		if auth.Metadata["api_key"] == "revoked" {
+			return true, "401 key revoked", nil
+		}
+		return false, "", nil
+	}))
+	inspector.Subscribe(func(ev coreauth.InspectionEvent) {
+		if ev.Type == coreauth.InspectionAuthDeleted {
+			fmt.Println("deleted", ev.Auth.ID)
+		}
+	})
+
+	report, err := inspector.Run(ctx, coreauth.RunOptions{Provider: "acme", DeleteInvalid: true})
+	if err != nil {
+		fmt.Println("inspection failed:", err)
+		return
+	}
+	for _, result := range report.Results {
+		fmt.Printf("%s invalid=%v reason=%q\n", result.ID, result.Invalid, result.Reason)
+	}
+	fmt.Printf("checked=%d deleted=%d\n", report.Checked, report.Deleted)
+	// Output:
+	// deleted bob.json
+	// alice.json invalid=false reason=""
+	// bob.json invalid=true reason="401 key revoked"
+	// checked=2 deleted=1
+}
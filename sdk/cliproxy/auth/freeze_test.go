@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFreezeSkipsRefreshProbesAndWrites(t *testing.T) {
+	inspector, manager, store := newInspectorFixture(t)
+	ctx := context.Background()
+
+	if _, err := manager.Freeze(ctx, "b-bad", time.Time{}); err != nil {
+		t.Fatalf("Freeze: %v", err)
+	}
+	store.mu.Lock()
+	persisted := store.items["b-bad"].Clone()
+	store.mu.Unlock()
+	if !metadataTruthy(persisted.Metadata[MetadataFrozen]) {
+		t.Fatalf("freeze mark not persisted: %v", persisted.Metadata)
+	}
+
+	frozen, _ := manager.GetByID("b-bad")
+	frozen.Label = "changed"
+	if _, err := manager.Update(ctx, frozen); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	store.mu.Lock()
+	label := store.items["b-bad"].Label
+	store.mu.Unlock()
+	if label == "changed" {
+		t.Fatal("frozen record was written")
+	}
+	if manager.shouldRefresh(frozen, time.Now()) || manager.markRefreshPending("b-bad", time.Now()) {
+		t.Fatal("frozen auth scheduled for refresh")
+	}
+
+	res, err := inspector.VerifyBatch(ctx, "custom", VerifyOptions{Concurrency: 2, BatchSize: 10})
+	if err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	if res.Total != 2 || res.Frozen != 1 || res.Invalid != 1 {
+		t.Fatalf("batch = %+v", res)
+	}
+
+	// A frozen auth keeps its file even when an earlier run marked it invalid.
+	frozen, _ = manager.GetByID("b-bad")
+	SetTokenInvalidState(frozen, true, "rejected")
+	manager.mu.Lock()
+	manager.auths["b-bad"] = frozen
+	manager.mu.Unlock()
+	deleted, err := inspector.DeleteInvalid(ctx, DeleteOptions{})
+	if err != nil {
+		t.Fatalf("DeleteInvalid: %v", err)
+	}
+	if deleted.Deleted != 1 || !store.has("b-bad") {
+		t.Fatalf("deleted = %+v, frozen auth kept = %v", deleted, store.has("b-bad"))
+	}
+}
+
+func TestFreezeLapsesLazily(t *testing.T) {
+	_, manager, store := newInspectorFixture(t)
+	ctx := context.Background()
+	until := time.Now().Add(time.Hour)
+	if _, err := manager.Freeze(ctx, "a-good", until); err != nil {
+		t.Fatalf("Freeze: %v", err)
+	}
+	auth, _ := manager.GetByID("a-good")
+	if frozen, gotUntil := FrozenState(auth, time.Now()); !frozen || !gotUntil.Equal(until.Truncate(time.Second)) {
+		t.Fatalf("FrozenState = %v, %v", frozen, gotUntil)
+	}
+
+	later := until.Add(time.Minute)
+	if frozen, _ := FrozenState(auth, later); frozen {
+		t.Fatal("freeze should lapse after until")
+	}
+	manager.expireFreezes(ctx, manager.snapshotAuths(), later)
+	store.mu.Lock()
+	_, marked := store.items["a-good"].Metadata[MetadataFrozen]
+	store.mu.Unlock()
+	if marked {
+		t.Fatal("lapsed freeze mark not cleared")
+	}
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// Normalized identity metadata derived from stored ID tokens.
+const (
+	// MetadataAccountEmail holds the account email from the ID token, or the stored email.
+	MetadataAccountEmail = "account_email"
+	// MetadataAccountPlan holds the subscription plan, currently the ChatGPT plan for codex.
+	MetadataAccountPlan = "account_plan"
+)
+
+const codexAuthClaim = "https://api.openai.com/auth"
+
+// EnsureIdentity copies the account email and plan from the stored ID token
+// into MetadataAccountEmail and MetadataAccountPlan. The token signature is
+// not verified: the claims only label credentials this process already holds.
+// Tokens that fail to decode leave the fields unchanged.
+func (a *Auth) EnsureIdentity() {
+	if a == nil || a.Metadata == nil {
+		return
+	}
+	claims := idTokenClaims(a.Metadata)
+	email := claimString(claims, "email")
+	if email == "" {
+		email = claimString(a.Metadata, "email")
+	}
+	if email != "" {
+		a.Metadata[MetadataAccountEmail] = email
+	}
+	if strings.EqualFold(strings.TrimSpace(a.Provider), "codex") {
+		codexClaims, _ := claims[codexAuthClaim].(map[string]any)
+		if plan := claimString(codexClaims, "chatgpt_plan_type"); plan != "" {
+			a.Metadata[MetadataAccountPlan] = plan
+		}
+	}
+}
+
+// idTokenClaims decodes the ID token stored at the top level (codex) or in the
+// nested OAuth token (gemini).
+func idTokenClaims(metadata map[string]any) map[string]any {
+	raw := claimString(metadata, "id_token")
+	if raw == "" {
+		if token, ok := metadata["token"].(map[string]any); ok {
+			raw = claimString(token, "id_token")
+		}
+	}
+	if raw == "" {
+		return nil
+	}
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil
+	}
+	var claims map[string]any
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return nil
+	}
+	return claims
+}
+
+func claimString(m map[string]any, key string) string {
+	if m == nil {
+		return ""
+	}
+	v, _ := m[key].(string)
+	return strings.TrimSpace(v)
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+// Real-shaped ID tokens with fake signatures.
+const (
+	// Codex: email alice@example.com, ChatGPT plan "plus", account acct-123.
+	codexIDTokenFixture = "eyJhbGciOiJSUzI1NiIsImtpZCI6ImIxYTgyNTllLWJiN2UtNGExYS05ZjJjLTNlNGQxYjBjMmYxMSIsInR5cCI6IkpXVCJ9." +
+		"eyJhdWQiOlsiYXBwX0VNb2FtRUVaNzNmMENrWGFYcDdocmFubiJdLCJhdXRoX3Byb3ZpZGVyIjoicGFzc3dvcmQiLCJlbWFpbCI6ImFsaWNlQGV4YW1wbGUuY29tIiwiZW1haWxfdmVyaWZpZWQiOnRydWUsImV4cCI6MTc2NzIyNTYwMCwiaHR0cHM6Ly9hcGkub3BlbmFpLmNvbS9hdXRoIjp7ImNoYXRncHRfYWNjb3VudF9pZCI6ImFjY3QtMTIzIiwiY2hhdGdwdF9wbGFuX3R5cGUiOiJwbHVzIiwiY2hhdGdwdF91c2VyX2lkIjoidXNlci1BbGljZTAxIn0sImlhdCI6MTc2NDYzMzYwMCwiaXNzIjoiaHR0cHM6Ly9hdXRoLm9wZW5haS5jb20iLCJzdWIiOiJhdXRoMHxhbGljZTAxIn0." +
+		"0r7XVh7b2A8qi5CbcibpD0AAW-1OCxxBZGqsK-FRoniepPkMN-HLo4fImaPRUaVQMsXwTUBD88vlTvKWdwRDTg"
+	// Gemini (Google): email bob@example.com.
+	geminiIDTokenFixture = "eyJhbGciOiJSUzI1NiIsImtpZCI6IjA3YjgwYTM2NTQyODUyNWY4YmY3Y2QwODQ2ZDc0YThlZTRlZjM2MjUiLCJ0eXAiOiJKV1QifQ." +
+		"eyJpc3MiOiJodHRwczovL2FjY291bnRzLmdvb2dsZS5jb20iLCJhenAiOiI2ODEyNTU4MDkzOTUtb284ZnQyb3ByZHJucDllM2FxZjZhdjNobWRpYjEzNWouYXBwcy5nb29nbGV1c2VyY29udGVudC5jb20iLCJhdWQiOiI2ODEyNTU4MDkzOTUtb284ZnQyb3ByZHJucDllM2FxZjZhdjNobWRpYjEzNWouYXBwcy5nb29nbGV1c2VyY29udGVudC5jb20iLCJzdWIiOiIxMDk4NzY1NDMyMTA5ODc2NTQzMjEiLCJlbWFpbCI6ImJvYkBleGFtcGxlLmNvbSIsImVtYWlsX3ZlcmlmaWVkIjp0cnVlLCJhdF9oYXNoIjoiWmszVm4wYjNRbTF5WHcycEw4c1Q0ZyIsImlhdCI6MTc2NDYzMzYwMCwiZXhwIjoxNzY0NjM3MjAwfQ." +
+		"4BMKbvmsv9-vlmjmVFUg2LUUaWwWPUucFD42Fmvz_FogTFUtoeymubn2wFyFS3AKyAXQ3Sh1EZg__D9fLP616w"
+)
+
+func TestEnsureIdentity(t *testing.T) {
+	cases := []struct {
+		name      string
+		provider  string
+		metadata  map[string]any
+		wantEmail string
+		wantPlan  string
+	}{
+		{
+			name:      "codex id token",
+			provider:  "codex",
+			metadata:  map[string]any{"type": "codex", "id_token": codexIDTokenFixture},
+			wantEmail: "alice@example.com",
+			wantPlan:  "plus",
+		},
+		{
+			name:      "gemini nested id token",
+			provider:  "gemini-cli",
+			metadata:  map[string]any{"type": "gemini-cli", "token": map[string]any{"access_token": "ya29.x", "id_token": geminiIDTokenFixture}},
+			wantEmail: "bob@example.com",
+		},
+		{
+			name:      "gemini userinfo email without id token",
+			provider:  "gemini-cli",
+			metadata:  map[string]any{"type": "gemini-cli", "email": "carol@example.com"},
+			wantEmail: "carol@example.com",
+		},
+		{
+			name:     "malformed token stays empty",
+			provider: "codex",
+			metadata: map[string]any{"type": "codex", "id_token": "not.a-jwt!.token"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &Auth{Provider: tc.provider, Metadata: tc.metadata}
+			a.EnsureIdentity()
+			if got, _ := a.Metadata[MetadataAccountEmail].(string); got != tc.wantEmail {
+				t.Fatalf("account email = %q, want %q", got, tc.wantEmail)
+			}
+			if got, _ := a.Metadata[MetadataAccountPlan].(string); got != tc.wantPlan {
+				t.Fatalf("account plan = %q, want %q", got, tc.wantPlan)
+			}
+		})
+	}
+}
+
+func TestManagerRegisterPopulatesIdentity(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	registered, err := m.Register(context.Background(), &Auth{
+		ID:       "codex-17.json",
+		Provider: "codex",
+		Metadata: map[string]any{"type": "codex", "id_token": codexIDTokenFixture},
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if registered.Metadata[MetadataAccountEmail] != "alice@example.com" || registered.Metadata[MetadataAccountPlan] != "plus" {
+		t.Fatalf("identity not populated on register: %+v", registered.Metadata)
+	}
+}
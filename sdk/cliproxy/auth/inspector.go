@@ -0,0 +1,1366 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Metadata keys recording the outcome of the last token verification.
+const (
+	// MetadataTokenInvalid is true when the last verification rejected the token.
+	MetadataTokenInvalid = "token_invalid"
+	// MetadataTokenInvalidReason holds the probe's explanation, if any.
+	MetadataTokenInvalidReason = "token_invalid_reason"
+	// MetadataTokenInvalidAt holds the RFC 3339 time the token was marked invalid.
+	MetadataTokenInvalidAt = "token_invalid_at"
+	// MetadataProbeLatencyMs holds the round trip of the last probe in milliseconds.
+	MetadataProbeLatencyMs = "last_probe_latency_ms"
+	// MetadataProbeLatencyAvgMs holds a moving average of the probe round
+	// trips in milliseconds, weighting each new probe by probeLatencyWeight.
+	MetadataProbeLatencyAvgMs = "probe_latency_avg_ms"
+	// MetadataLastVerifiedAt holds the RFC 3339 time of the last completed probe.
+	MetadataLastVerifiedAt = "last_verified_at"
+	// MetadataLastVerifiedOutcome holds that probe's OutcomeValid or OutcomeInvalid.
+	MetadataLastVerifiedOutcome = "last_verified_outcome"
+	// MetadataQuotaExhaustedUntil holds the RFC 3339 time a throttled probe
+	// was told to retry at.
+	MetadataQuotaExhaustedUntil = "quota_exhausted_until"
+	// MetadataProbeFailureCount counts the probes in a row that failed
+	// without a verdict for reasons other than throttling.
+	MetadataProbeFailureCount = "probe_failure_count"
+	// MetadataLastProbeErrorAt holds the RFC 3339 time of the last of them.
+	MetadataLastProbeErrorAt = "last_probe_error_at"
+)
+
+// Outcomes of a verification, as reported in VerifyResult.Outcome.
+const (
+	OutcomeValid     = "valid"
+	OutcomeInvalid   = "invalid"
+	OutcomeError     = "error"
+	OutcomeThrottled = "throttled"
+)
+
+// TokenInvalidState reports whether auth is marked invalid and why.
+func TokenInvalidState(auth *Auth) (bool, string) {
+	if auth == nil || len(auth.Metadata) == 0 {
+		return false, ""
+	}
+	invalid := metadataTruthy(auth.Metadata[MetadataTokenInvalid])
+	reason, _ := auth.Metadata[MetadataTokenInvalidReason].(string)
+	return invalid, strings.TrimSpace(reason)
+}
+
+// SetTokenInvalidState marks auth invalid with reason, or clears the mark.
+func SetTokenInvalidState(auth *Auth, invalid bool, reason string) {
+	if auth == nil {
+		return
+	}
+	if auth.Metadata == nil {
+		auth.Metadata = make(map[string]any)
+	}
+	if invalid {
+		auth.Metadata[MetadataTokenInvalid] = true
+		auth.Metadata[MetadataTokenInvalidAt] = time.Now().UTC().Format(time.RFC3339)
+		trimmedReason := strings.TrimSpace(reason)
+		if trimmedReason != "" {
+			auth.Metadata[MetadataTokenInvalidReason] = trimmedReason
+		} else {
+			delete(auth.Metadata, MetadataTokenInvalidReason)
+		}
+		return
+	}
+	delete(auth.Metadata, MetadataTokenInvalid)
+	delete(auth.Metadata, MetadataTokenInvalidReason)
+	delete(auth.Metadata, MetadataTokenInvalidAt)
+}
+
+// LastVerification returns when auth's last probe completed and its outcome,
+// or the zero time and "" when it was never verified.
+func LastVerification(auth *Auth) (time.Time, string) {
+	if auth == nil || len(auth.Metadata) == 0 {
+		return time.Time{}, ""
+	}
+	raw, _ := auth.Metadata[MetadataLastVerifiedAt].(string)
+	at, err := time.Parse(time.RFC3339, strings.TrimSpace(raw))
+	if err != nil {
+		return time.Time{}, ""
+	}
+	outcome, _ := auth.Metadata[MetadataLastVerifiedOutcome].(string)
+	return at, strings.TrimSpace(outcome)
+}
+
+// probeLatencyWeight is the share of the latest probe in the moving average.
+const probeLatencyWeight = 0.3
+
+// legacyMetadataProbeLatencyMs is the key the last latency was once kept under.
+const legacyMetadataProbeLatencyMs = "probe_latency_ms"
+
+// ProbeLatency returns the round trip of auth's last probe and the moving
+// average of its probes, for selectors that prefer responsive auths. ok is
+// false when auth was never probed.
+func ProbeLatency(auth *Auth) (last, avg time.Duration, ok bool) {
+	if auth == nil || len(auth.Metadata) == 0 {
+		return 0, 0, false
+	}
+	lastMs, ok := metadataMillis(auth.Metadata[MetadataProbeLatencyMs])
+	if !ok {
+		return 0, 0, false
+	}
+	avgMs, hasAvg := metadataMillis(auth.Metadata[MetadataProbeLatencyAvgMs])
+	if !hasAvg {
+		avgMs = lastMs
+	}
+	return time.Duration(lastMs) * time.Millisecond, time.Duration(avgMs) * time.Millisecond, true
+}
+
+// recordProbeLatency saves latency as auth's last probe round trip and folds
+// it into the moving average.
+func recordProbeLatency(auth *Auth, latency time.Duration) {
+	ms := latency.Milliseconds()
+	avg := float64(ms)
+	if _, prev, ok := ProbeLatency(auth); ok {
+		avg = float64(prev.Milliseconds())*(1-probeLatencyWeight) + float64(ms)*probeLatencyWeight
+	}
+	auth.Metadata[MetadataProbeLatencyMs] = ms
+	auth.Metadata[MetadataProbeLatencyAvgMs] = int64(math.Round(avg))
+	delete(auth.Metadata, legacyMetadataProbeLatencyMs)
+}
+
+// metadataMillis reads a millisecond count stored as a Go or JSON number.
+func metadataMillis(raw any) (int64, bool) {
+	switch typed := raw.(type) {
+	case int:
+		return int64(typed), true
+	case int64:
+		return typed, true
+	case float64:
+		return int64(typed), true
+	case json.Number:
+		n, err := typed.Int64()
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// ProbeFailures returns how many probes of auth in a row failed without a
+// verdict, throttling aside, and when the last of them did. It returns zero
+// values once a probe reached a verdict.
+func ProbeFailures(auth *Auth) (int, time.Time) {
+	if auth == nil || len(auth.Metadata) == 0 {
+		return 0, time.Time{}
+	}
+	count, ok := metadataMillis(auth.Metadata[MetadataProbeFailureCount])
+	if !ok || count <= 0 {
+		return 0, time.Time{}
+	}
+	raw, _ := auth.Metadata[MetadataLastProbeErrorAt].(string)
+	at, _ := time.Parse(time.RFC3339, strings.TrimSpace(raw))
+	return int(count), at
+}
+
+// ProbeBackoff holds back the auths whose probes keep failing without a
+// verdict. Past Threshold failures in a row, an auth is left unprobed for
+// Base after its last failure, doubling with each further failure up to Max.
+type ProbeBackoff struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+}
+
+// backingOff reports whether auth is within its backoff window at now.
+func (b *ProbeBackoff) backingOff(auth *Auth, now time.Time) bool {
+	if b == nil || b.Base <= 0 {
+		return false
+	}
+	count, last := ProbeFailures(auth)
+	if count <= b.Threshold || last.IsZero() {
+		return false
+	}
+	window := b.Base
+	for extra := count - b.Threshold - 1; extra > 0 && (b.Max <= 0 || window < b.Max); extra-- {
+		window *= 2
+	}
+	if b.Max > 0 && window > b.Max {
+		window = b.Max
+	}
+	return now.Sub(last) < window
+}
+
+// verifiedRecently reports whether auth was last verified valid less than ttl
+// before now.
+func verifiedRecently(auth *Auth, now time.Time, ttl time.Duration) bool {
+	if ttl <= 0 {
+		return false
+	}
+	at, outcome := LastVerification(auth)
+	return outcome == OutcomeValid && now.Sub(at) < ttl
+}
+
+func metadataTruthy(raw any) bool {
+	switch typed := raw.(type) {
+	case bool:
+		return typed
+	case string:
+		switch strings.ToLower(strings.TrimSpace(typed)) {
+		case "1", "true", "yes", "on", "*":
+			return true
+		}
+		return false
+	case int:
+		return typed != 0
+	case int64:
+		return typed != 0
+	case float64:
+		return typed != 0
+	default:
+		return false
+	}
+}
+
+func isRuntimeOnly(auth *Auth) bool {
+	if auth == nil || len(auth.Attributes) == 0 {
+		return false
+	}
+	return strings.EqualFold(strings.TrimSpace(auth.Attributes["runtime_only"]), "true")
+}
+
+// ErrProbeInconclusive marks a probe error after which the auth's recorded
+// state is left alone. Unlike other errors it does not abort the batch; the
+// auth is counted under VerifyBatchResult.Errors.
+var ErrProbeInconclusive = errors.New("probe inconclusive")
+
+// ErrProbeThrottled marks an inconclusive probe whose upstream is rate
+// limiting or out of quota for the auth. The auth's invalid state is left
+// alone as for ErrProbeInconclusive, the time given to RecordProbeRetryAt is
+// saved under MetadataQuotaExhaustedUntil and the auth is counted under
+// VerifyBatchResult.Throttled instead of Errors.
+var ErrProbeThrottled = fmt.Errorf("%w: throttled", ErrProbeInconclusive)
+
+// Probe checks whether an auth's credentials are still accepted upstream.
+// A definitive rejection returns invalid=true with a short reason. An error
+// means the check could not run at all and aborts the batch, unless it wraps
+// ErrProbeInconclusive; inconclusive answers such as rate limits may instead
+// return the auth's current state. Metadata the probe sets on auth is saved
+// along with a conclusive outcome.
+type Probe interface {
+	Probe(ctx context.Context, auth *Auth) (invalid bool, reason string, err error)
+}
+
+type probeStatusKey struct{}
+
+// RecordProbeStatus lets a probe report the HTTP status its check received;
+// VerifyBatch passes it on in VerifyResult.StatusCode. Outside a verification
+// it does nothing.
+func RecordProbeStatus(ctx context.Context, statusCode int) {
+	if ctx == nil {
+		return
+	}
+	if status, ok := ctx.Value(probeStatusKey{}).(*int); ok {
+		*status = statusCode
+	}
+}
+
+type probeErrorCodeKey struct{}
+
+// RecordProbeErrorCode lets a probe report the error code its upstream
+// response carried, such as "invalid_grant"; VerifyBatch passes it on in
+// VerifyResult.ErrorCode. Outside a verification it does nothing.
+func RecordProbeErrorCode(ctx context.Context, code string) {
+	if ctx == nil {
+		return
+	}
+	if errorCode, ok := ctx.Value(probeErrorCodeKey{}).(*string); ok {
+		*errorCode = code
+	}
+}
+
+type probeSourceKey struct{}
+
+// RecordProbeSource lets a probe with several targets report which one
+// produced its outcome, such as "fallback"; VerifyBatch passes it on in
+// VerifyResult.Probe. Outside a verification it does nothing.
+func RecordProbeSource(ctx context.Context, source string) {
+	if ctx == nil {
+		return
+	}
+	if probe, ok := ctx.Value(probeSourceKey{}).(*string); ok {
+		*probe = source
+	}
+}
+
+type probeRetryAtKey struct{}
+
+// RecordProbeRetryAt lets a throttled probe report when its upstream allows
+// the auth again, as read from Retry-After or the response body. Outside a
+// verification it does nothing.
+func RecordProbeRetryAt(ctx context.Context, retryAt time.Time) {
+	if ctx == nil {
+		return
+	}
+	if at, ok := ctx.Value(probeRetryAtKey{}).(*time.Time); ok {
+		*at = retryAt
+	}
+}
+
+// ProbeFunc adapts an ordinary function to Probe.
+type ProbeFunc func(ctx context.Context, auth *Auth) (bool, string, error)
+
+// Probe implements Probe.
+func (f ProbeFunc) Probe(ctx context.Context, auth *Auth) (bool, string, error) {
+	return f(ctx, auth)
+}
+
+// InspectionEventType identifies an InspectionEvent.
+type InspectionEventType string
+
+const (
+	// InspectionStarted fires when Run begins.
+	InspectionStarted InspectionEventType = "inspection_started"
+	// InspectionAuthChecked fires after an auth is verified and its state recorded.
+	InspectionAuthChecked InspectionEventType = "auth_checked"
+	// InspectionAuthDeleted fires after DeleteInvalid removes an auth.
+	InspectionAuthDeleted InspectionEventType = "auth_deleted"
+	// InspectionFinished fires when Run returns.
+	InspectionFinished InspectionEventType = "inspection_finished"
+)
+
+// InspectionEvent describes one step of the auth lifecycle driven by an Inspector.
+type InspectionEvent struct {
+	Type InspectionEventType
+	Time time.Time
+	// Auth is a copy of the affected auth for AuthChecked and AuthDeleted.
+	Auth *Auth
+	// Invalid and Reason carry the verification outcome for AuthChecked.
+	Invalid bool
+	Reason  string
+	// Report is set for InspectionFinished, together with Err if the run failed.
+	Report *InspectionReport
+	Err    error
+}
+
+// VerifyOptions controls one VerifyBatch call. Zero values mean one worker,
+// a batch of one and a cursor at the start.
+type VerifyOptions struct {
+	Concurrency int
+	BatchSize   int
+	Cursor      int
+	// Filter, when set, leaves out the auths it rejects; they are counted in
+	// VerifyBatchResult.Filtered. It may depend on state the probes change.
+	Filter func(auth *Auth) bool
+	// Throttle, when set, is called before every probe and may block to
+	// limit the probe rate. An error aborts the batch like a probe error.
+	Throttle func(ctx context.Context) error
+	// MinReverify, when positive, leaves the auths of the batch last verified
+	// valid less than this long ago unprobed; they are counted in
+	// VerifyBatchResult.Fresh. They stay candidates, so cursors remain stable.
+	MinReverify time.Duration
+	// Backoff, when set, leaves the auths of the batch it holds back
+	// unprobed; they are counted in VerifyBatchResult.BackedOff and,
+	// like fresh auths, stay candidates.
+	Backoff *ProbeBackoff
+	// Cached, when set, is asked for each auth of the batch that would be
+	// probed. An auth it returns a result for is not probed; the result is
+	// reported with Cached set and counted like a probed one.
+	Cached func(auth *Auth) (VerifyResult, bool)
+	// OnResult, when set, is called with each result of the batch as soon as
+	// it is known, cached ones first, so callers can stream them. Probes run
+	// concurrently, and so may the calls. Probes that fail the batch or are
+	// cut short by ctx are not reported.
+	OnResult func(result VerifyResult)
+	// Pool, when set, must hand out a slot for every probe, so batches sharing
+	// it share its concurrency; Concurrency then bounds only this batch's
+	// share.
+	Pool *ProbePool
+}
+
+// ProbePool caps the probes in flight across the batches sharing it, e.g.
+// the providers of one inspection run, whose probes then interleave instead
+// of each provider running at full concurrency.
+type ProbePool struct {
+	slots chan struct{}
+}
+
+// NewProbePool returns a pool of size slots, at least one.
+func NewProbePool(size int) *ProbePool {
+	return &ProbePool{slots: make(chan struct{}, max(size, 1))}
+}
+
+// Size returns the number of probes the pool lets run at once.
+func (p *ProbePool) Size() int {
+	return cap(p.slots)
+}
+
+// acquire waits for a free slot; a nil pool never waits.
+func (p *ProbePool) acquire(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *ProbePool) release() {
+	if p != nil {
+		<-p.slots
+	}
+}
+
+// VerifyResult is the verification outcome for one auth.
+type VerifyResult struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+	Invalid  bool   `json:"invalid"`
+	Reason   string `json:"reason,omitempty"`
+	// ReasonCode classifies Reason for invalid results; see InvalidReasonCode.
+	ReasonCode string `json:"reason_code,omitempty"`
+	// Error holds the inconclusive probe error, in which case Invalid is unset.
+	Error string `json:"error,omitempty"`
+	// Outcome is OutcomeValid, OutcomeInvalid, OutcomeError or
+	// OutcomeThrottled.
+	Outcome string `json:"outcome"`
+	// QuotaExhaustedUntil is the RFC 3339 retry time of a throttled probe, if
+	// it reported one.
+	QuotaExhaustedUntil string `json:"quota_exhausted_until,omitempty"`
+	// StatusCode is the HTTP status reported with RecordProbeStatus, if any.
+	StatusCode int `json:"status_code,omitempty"`
+	// ErrorCode is the upstream error code reported with
+	// RecordProbeErrorCode, if any.
+	ErrorCode string `json:"error_code,omitempty"`
+	// Probe names the probe target reported with RecordProbeSource. It is
+	// empty when the probe's primary target produced the outcome.
+	Probe string `json:"probe,omitempty"`
+	// LatencyMs is the probe's round trip in milliseconds.
+	LatencyMs int64 `json:"latency_ms"`
+	// Recovered is set when the valid outcome reactivated a failed auth.
+	Recovered bool `json:"recovered,omitempty"`
+	// Cached is set when the result was served by VerifyOptions.Cached
+	// instead of a probe; CachedAt is then when it was probed, in RFC 3339.
+	Cached   bool   `json:"cached,omitempty"`
+	CachedAt string `json:"cached_at,omitempty"`
+	// VerifiedAt is when the probe completed, in RFC 3339.
+	VerifiedAt string `json:"verified_at,omitempty"`
+}
+
+var httpStatusInReason = regexp.MustCompile(`\b([45]\d\d)\b`)
+
+// InvalidReasonCode maps a probe's free-text reason to a short code so runs
+// can be grouped by cause: "invalid_grant", "token_empty", "http_<status>"
+// for the first 4xx/5xx status mentioned, "refresh_failed" for other refresh
+// errors, "unknown" for an empty reason and "other" otherwise.
+func InvalidReasonCode(reason string) string {
+	lower := strings.ToLower(strings.TrimSpace(reason))
+	switch {
+	case lower == "":
+		return "unknown"
+	case strings.Contains(lower, "invalid_grant"):
+		return "invalid_grant"
+	case lower == "token is empty":
+		return "token_empty"
+	}
+	if m := httpStatusInReason.FindStringSubmatch(lower); m != nil {
+		return "http_" + m[1]
+	}
+	if strings.HasPrefix(lower, "token refresh failed") {
+		return "refresh_failed"
+	}
+	return "other"
+}
+
+// VerifyBatchResult summarises one VerifyBatch call. Candidates are ordered by
+// ID; pass NextCursor back as VerifyOptions.Cursor until Done.
+type VerifyBatchResult struct {
+	Provider    string
+	Concurrency int
+	BatchSize   int
+	Cursor      int
+	NextCursor  int
+	Total       int
+	Done        bool
+	Checked     int
+	Valid       int
+	Invalid     int
+	Skipped     int
+	// Errors counts the auths whose probe was inconclusive, except throttled.
+	Errors int
+	// Throttled counts the auths whose probe failed with ErrProbeThrottled.
+	Throttled int
+	// Frozen counts the provider's frozen auths, which are left out of Total.
+	Frozen int
+	// Filtered counts the auths rejected by VerifyOptions.Filter.
+	Filtered int
+	// Recovered counts the valid auths that were reactivated.
+	Recovered int
+	// Fresh counts the auths of the batch left unprobed under
+	// VerifyOptions.MinReverify; they are included in Skipped, not Checked.
+	Fresh int
+	// BackedOff counts the auths of the batch left unprobed under
+	// VerifyOptions.Backoff; they are included in Skipped, not Checked.
+	BackedOff int
+	// Cached counts the results served by VerifyOptions.Cached; they are
+	// included in Checked and the outcome counts.
+	Cached int
+	// Left counts the probed auths that VerifyOptions.Filter rejects after
+	// their probe, such as invalid auths found valid again. They drop out of
+	// the candidates, so NextCursor is moved back by as many.
+	Left int
+	// Unsupported lists the providers of auths left out because no probe is
+	// registered for them, sorted. Their auths are included in Skipped.
+	Unsupported []string
+	Results     []VerifyResult
+}
+
+// RunOptions controls Run.
+type RunOptions struct {
+	// Provider limits the run to one provider; "", "all" and "*" check every
+	// provider with a registered probe.
+	Provider    string
+	Concurrency int
+	BatchSize   int
+	// MaxRounds bounds the number of batches; zero means no bound.
+	MaxRounds int
+	// DeleteInvalid removes the auths marked invalid once every batch passed.
+	DeleteInvalid bool
+	// Delete configures the removal when DeleteInvalid is set.
+	Delete DeleteOptions
+	// Filter, when set, leaves the auths it rejects out of the run.
+	Filter func(auth *Auth) bool
+	// Throttle, MinReverify, Backoff and Pool are passed on as in
+	// VerifyOptions.
+	Throttle    func(ctx context.Context) error
+	MinReverify time.Duration
+	Backoff     *ProbeBackoff
+	Pool        *ProbePool
+	// OnBatch, when set, is called after each batch with its 1-based round.
+	OnBatch func(res VerifyBatchResult, round int)
+}
+
+// InspectionReport summarises a Run.
+type InspectionReport struct {
+	Provider   string         `json:"provider"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Total      int            `json:"total"`
+	Checked    int            `json:"checked"`
+	Valid      int            `json:"valid"`
+	Invalid    int            `json:"invalid"`
+	Errors     int            `json:"errors"`
+	Throttled  int            `json:"throttled"`
+	Matched    int            `json:"matched"`
+	Deleted    int            `json:"deleted"`
+	Frozen     int            `json:"frozen"`
+	Filtered   int            `json:"filtered"`
+	Recovered  int            `json:"recovered"`
+	Results    []VerifyResult `json:"results"`
+}
+
+// DeleteOptions controls DeleteInvalid.
+type DeleteOptions struct {
+	// Providers limits deletion to these providers; empty means all.
+	Providers []string
+	// Key groups auths that share one backing record so the record is removed
+	// once; ok=false skips the auth. Nil keys by auth ID.
+	Key func(auth *Auth) (key string, ok bool)
+	// Remove deletes one auth. Nil deletes it from the manager's store and
+	// disables it in the manager.
+	Remove func(ctx context.Context, auth *Auth) error
+	// DryRun selects the auths without removing them; they are returned in
+	// DeleteResult.Candidates.
+	DryRun bool
+	// Filter, when set, keeps the auths it rejects.
+	Filter func(auth *Auth) bool
+}
+
+// DeleteResult summarises a DeleteInvalid call.
+type DeleteResult struct {
+	Matched int
+	Deleted int
+	// ByProvider breaks Matched and Deleted down by lower-cased provider.
+	ByProvider map[string]DeleteCounts
+	// Candidates holds the matched auths of a dry run.
+	Candidates []*Auth
+}
+
+// DeleteCounts are the auths of one provider a DeleteInvalid call matched
+// and deleted.
+type DeleteCounts struct {
+	Matched int `json:"matched"`
+	Deleted int `json:"deleted"`
+}
+
+// Inspector verifies the auths held by a Manager with per-provider probes,
+// records the outcome in their metadata and removes the ones marked invalid.
+// Probes and subscribers may be registered at any time.
+type Inspector struct {
+	manager *Manager
+
+	mu          sync.RWMutex
+	probes      map[string]Probe
+	subscribers []func(InspectionEvent)
+}
+
+// NewInspector returns an Inspector over manager with no probes registered.
+func NewInspector(manager *Manager) *Inspector {
+	return &Inspector{manager: manager, probes: make(map[string]Probe)}
+}
+
+// Manager returns the manager the inspector operates on.
+func (i *Inspector) Manager() *Manager {
+	if i == nil {
+		return nil
+	}
+	return i.manager
+}
+
+// RegisterProbe sets the probe for provider, replacing any previous one. A
+// nil probe removes it.
+func (i *Inspector) RegisterProbe(provider string, probe Probe) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if i == nil || provider == "" {
+		return
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if probe == nil {
+		delete(i.probes, provider)
+		return
+	}
+	i.probes[provider] = probe
+}
+
+// HasProbe reports whether provider has a registered probe.
+func (i *Inspector) HasProbe(provider string) bool {
+	return i.probe(provider) != nil
+}
+
+// Providers returns the providers with a registered probe, sorted.
+func (i *Inspector) Providers() []string {
+	if i == nil {
+		return nil
+	}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	out := make([]string, 0, len(i.probes))
+	for provider := range i.probes {
+		out = append(out, provider)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (i *Inspector) probe(provider string) Probe {
+	if i == nil {
+		return nil
+	}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.probes[strings.ToLower(strings.TrimSpace(provider))]
+}
+
+// Subscribe registers fn for every later event. Events are delivered
+// synchronously, possibly from several goroutines at once, so fn must be
+// safe for concurrent use and must not block.
+func (i *Inspector) Subscribe(fn func(InspectionEvent)) {
+	if i == nil || fn == nil {
+		return
+	}
+	i.mu.Lock()
+	i.subscribers = append(i.subscribers, fn)
+	i.mu.Unlock()
+}
+
+func (i *Inspector) emit(event InspectionEvent) {
+	i.mu.RLock()
+	subscribers := i.subscribers
+	i.mu.RUnlock()
+	if len(subscribers) == 0 {
+		return
+	}
+	event.Time = time.Now()
+	for _, fn := range subscribers {
+		fn(event)
+	}
+}
+
+// Verify probes auth and records the outcome in the manager, together with
+// the probe's latency under MetadataProbeLatencyMs and
+// MetadataProbeLatencyAvgMs. A valid outcome reactivates an auth the runtime
+// marked failed or DisableInvalid sidelined.
+// Auths without a probe, disabled, admin-disabled, frozen and runtime-only
+// auths are left alone and reported valid.
+// A cancelled ctx or a probe error, inconclusive ones included, returns the
+// error without recording anything.
+func (i *Inspector) Verify(ctx context.Context, auth *Auth) (bool, string, error) {
+	res := i.verify(ctx, auth, nil, nil)
+	return res.invalid, res.reason, res.err
+}
+
+// ErrNotProbed is returned by VerifyOne for auths that are never probed:
+// those without a registered probe and disabled, admin-disabled, frozen or
+// runtime-only ones.
+var ErrNotProbed = errors.New("auth is not probed")
+
+// VerifyOne probes auth on its own and records the outcome like Verify,
+// returning it in the form of a VerifyBatch entry. An inconclusive probe is
+// reported with OutcomeError; other probe errors are returned.
+func (i *Inspector) VerifyOne(ctx context.Context, auth *Auth, throttle func(context.Context) error) (VerifyResult, error) {
+	if i == nil || auth == nil || !i.HasProbe(auth.Provider) || auth.Disabled || auth.Status == StatusDisabled || isRuntimeOnly(auth) || IsFrozen(auth) || IsAdminDisabled(auth) {
+		return VerifyResult{}, ErrNotProbed
+	}
+	res := i.verify(ctx, auth, throttle, nil)
+	if res.err != nil && !errors.Is(res.err, ErrProbeInconclusive) {
+		return VerifyResult{}, fmt.Errorf("failed to verify token for %s: %w", auth.ID, res.err)
+	}
+	return newVerifyResult(auth, res), nil
+}
+
+// newVerifyResult reports res, the outcome of a conclusive or inconclusive
+// probe of auth.
+func newVerifyResult(auth *Auth, res probeResult) VerifyResult {
+	name := strings.TrimSpace(auth.FileName)
+	if name == "" {
+		name = strings.TrimSpace(auth.ID)
+	}
+	entry := VerifyResult{
+		ID:         auth.ID,
+		Name:       name,
+		Provider:   strings.ToLower(strings.TrimSpace(auth.Provider)),
+		Invalid:    res.invalid,
+		Reason:     strings.TrimSpace(res.reason),
+		StatusCode: res.statusCode,
+		ErrorCode:  res.errorCode,
+		Probe:      res.probe,
+		LatencyMs:  res.latency.Milliseconds(),
+		Recovered:  res.recovered,
+		VerifiedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	switch {
+	case errors.Is(res.err, ErrProbeThrottled):
+		entry.Outcome = OutcomeThrottled
+		entry.Error = res.err.Error()
+		if !res.retryAt.IsZero() {
+			entry.QuotaExhaustedUntil = res.retryAt.UTC().Format(time.RFC3339)
+		}
+	case res.err != nil:
+		entry.Outcome = OutcomeError
+		entry.Error = res.err.Error()
+	case res.invalid:
+		entry.Outcome = OutcomeInvalid
+		entry.ReasonCode = InvalidReasonCode(entry.Reason)
+	default:
+		entry.Outcome = OutcomeValid
+	}
+	return entry
+}
+
+// probeResult is the outcome of one verify call.
+type probeResult struct {
+	invalid    bool
+	reason     string
+	statusCode int
+	errorCode  string
+	probe      string
+	latency    time.Duration
+	recovered  bool
+	retryAt    time.Time
+	err        error
+}
+
+func (i *Inspector) verify(ctx context.Context, auth *Auth, throttle func(context.Context) error, pool *ProbePool) probeResult {
+	if i == nil || auth == nil {
+		return probeResult{}
+	}
+	probe := i.probe(auth.Provider)
+	if probe == nil {
+		return probeResult{}
+	}
+	if auth.Disabled || auth.Status == StatusDisabled || isRuntimeOnly(auth) || IsFrozen(auth) || IsAdminDisabled(auth) {
+		return probeResult{}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return probeResult{err: err}
+	}
+
+	if throttle != nil {
+		if err := throttle(ctx); err != nil {
+			return probeResult{err: err}
+		}
+	}
+	if err := pool.acquire(ctx); err != nil {
+		return probeResult{err: err}
+	}
+	defer pool.release()
+	var res probeResult
+	started := time.Now()
+	probeCtx := context.WithValue(ctx, probeStatusKey{}, &res.statusCode)
+	probeCtx = context.WithValue(probeCtx, probeErrorCodeKey{}, &res.errorCode)
+	probeCtx = context.WithValue(probeCtx, probeSourceKey{}, &res.probe)
+	probeCtx = context.WithValue(probeCtx, probeRetryAtKey{}, &res.retryAt)
+	before := auth.Clone()
+	res.invalid, res.reason, res.err = probe.Probe(probeCtx, auth)
+	res.latency = time.Since(started)
+	if res.err != nil {
+		res.invalid, res.reason = false, ""
+		throttled := errors.Is(res.err, ErrProbeThrottled)
+		if throttled && !res.retryAt.IsZero() && ctx.Err() == nil {
+			i.recordRetryAt(ctx, before, auth, res.retryAt)
+		} else if !throttled && ctx.Err() == nil {
+			i.recordProbeFailure(ctx, before, auth)
+		}
+		return res
+	}
+	// A cancelled run must not record failures caused by the cancellation.
+	if errCtx := ctx.Err(); errCtx != nil {
+		return probeResult{err: errCtx}
+	}
+
+	if res.invalid {
+		MarkTokenInvalid(auth, res.reason)
+	} else {
+		SetTokenInvalidState(auth, false, "")
+	}
+	recordProbeLatency(auth, res.latency)
+	delete(auth.Metadata, MetadataProbeFailureCount)
+	delete(auth.Metadata, MetadataLastProbeErrorAt)
+	auth.Metadata[MetadataLastVerifiedAt] = time.Now().UTC().Format(time.RFC3339)
+	auth.Metadata[MetadataLastVerifiedOutcome] = OutcomeValid
+	if res.invalid {
+		auth.Metadata[MetadataLastVerifiedOutcome] = OutcomeInvalid
+	}
+	auth.UpdatedAt = time.Now()
+	checked := auth
+	if i.manager != nil {
+		// Only the keys the probe and the verdict changed are written, onto the
+		// auth as it is now: it may have been edited, disabled or deleted while
+		// the probe ran.
+		stored, wrote, errRecord := i.recordProbe(ctx, before, auth, func(stored *Auth) {
+			if res.invalid {
+				MarkTokenInvalid(stored, res.reason)
+			}
+		})
+		if errRecord != nil {
+			return probeResult{err: errRecord}
+		}
+		if !wrote {
+			return res
+		}
+		checked = stored
+		if !res.invalid {
+			reactivated, recovered, errReactivate := i.manager.Reactivate(ctx, auth.ID)
+			if errReactivate != nil {
+				return probeResult{err: errReactivate}
+			}
+			checked, res.recovered = reactivated, recovered
+		}
+	}
+	i.emit(InspectionEvent{Type: InspectionAuthChecked, Auth: checked.Clone(), Invalid: res.invalid, Reason: strings.TrimSpace(res.reason)})
+	return res
+}
+
+// recordProbe writes to the stored auth the metadata, and refresh time, that
+// changed from before to after, the snapshot a probe ran on, then applies
+// change. An auth removed, disabled or frozen since the snapshot was taken is
+// left alone and wrote is false.
+func (i *Inspector) recordProbe(ctx context.Context, before, after *Auth, change func(stored *Auth)) (*Auth, bool, error) {
+	m := i.manager
+	stored, wrote, err := m.setRuntimeState(ctx, after.ID, func(stored *Auth) bool {
+		if m.isRemovedLocked(stored.ID) || stored.Disabled || stored.Status == StatusDisabled || IsFrozen(stored) || IsAdminDisabled(stored) {
+			return false
+		}
+		if stored.Metadata == nil {
+			stored.Metadata = make(map[string]any)
+		}
+		for key, value := range after.Metadata {
+			if previous, ok := before.Metadata[key]; !ok || !reflect.DeepEqual(previous, value) {
+				stored.Metadata[key] = value
+			}
+		}
+		for key := range before.Metadata {
+			if _, ok := after.Metadata[key]; !ok {
+				delete(stored.Metadata, key)
+			}
+		}
+		if !after.LastRefreshedAt.Equal(before.LastRefreshedAt) {
+			stored.LastRefreshedAt = after.LastRefreshedAt
+		}
+		if change != nil {
+			change(stored)
+		}
+		return true
+	})
+	var notFound *Error
+	if errors.As(err, &notFound) && notFound.Code == "auth_not_found" {
+		return nil, false, nil
+	}
+	return stored, wrote, err
+}
+
+// recordRetryAt saves when a throttled auth may be used again. A failed save
+// only loses the hint, so it is logged rather than failing the probe.
+func (i *Inspector) recordRetryAt(ctx context.Context, before, after *Auth, retryAt time.Time) {
+	if i.manager == nil {
+		return
+	}
+	if _, _, errRecord := i.recordProbe(ctx, before, after, func(stored *Auth) {
+		stored.Metadata[MetadataQuotaExhaustedUntil] = retryAt.UTC().Format(time.RFC3339)
+	}); errRecord != nil {
+		log.Warnf("auth inspector: save quota_exhausted_until of %s: %v", after.ID, errRecord)
+	}
+}
+
+// recordProbeFailure counts a probe that failed without a verdict towards
+// VerifyOptions.Backoff, on the stored count so concurrent runs add up. Like
+// recordRetryAt, a failed save is only logged.
+func (i *Inspector) recordProbeFailure(ctx context.Context, before, after *Auth) {
+	if i.manager == nil {
+		return
+	}
+	if _, _, errRecord := i.recordProbe(ctx, before, after, func(stored *Auth) {
+		count, _ := ProbeFailures(stored)
+		stored.Metadata[MetadataProbeFailureCount] = count + 1
+		stored.Metadata[MetadataLastProbeErrorAt] = time.Now().UTC().Format(time.RFC3339)
+	}); errRecord != nil {
+		log.Warnf("auth inspector: save probe_failure_count of %s: %v", after.ID, errRecord)
+	}
+}
+
+// candidates returns the auths VerifyBatch would check for provider, ordered
+// by ID, the numbers of auths skipped, left out as frozen and rejected by
+// filter, and the providers skipped for having no probe.
+func (i *Inspector) candidates(provider string, filter func(*Auth) bool) ([]*Auth, int, int, int, []string) {
+	var auths []*Auth
+	if i.manager != nil {
+		auths = i.manager.List()
+	}
+	now := time.Now()
+	skippedCount, frozenCount, filteredCount := 0, 0, 0
+	candidates := make([]*Auth, 0, len(auths))
+	var unsupported []string
+	for _, auth := range auths {
+		if auth == nil {
+			skippedCount++
+			continue
+		}
+		authProvider := strings.ToLower(strings.TrimSpace(auth.Provider))
+		if provider != "" && authProvider != provider {
+			continue
+		}
+		if !i.HasProbe(authProvider) {
+			skippedCount++
+			if !slices.Contains(unsupported, authProvider) {
+				unsupported = append(unsupported, authProvider)
+			}
+			continue
+		}
+		if auth.Disabled || auth.Status == StatusDisabled || isRuntimeOnly(auth) || IsAdminDisabled(auth) {
+			skippedCount++
+			continue
+		}
+		if frozen, _ := FrozenState(auth, now); frozen {
+			frozenCount++
+			continue
+		}
+		if filter != nil && !filter(auth) {
+			filteredCount++
+			continue
+		}
+		candidates = append(candidates, auth)
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		return strings.Compare(strings.TrimSpace(candidates[a].ID), strings.TrimSpace(candidates[b].ID)) < 0
+	})
+	sort.Strings(unsupported)
+	return candidates, skippedCount, frozenCount, filteredCount, unsupported
+}
+
+// VerifyBatch verifies the next batch of candidates for provider ("" for all)
+// starting at opts.Cursor, running opts.Concurrency probes at a time. It fails
+// with the first probe or update error. When ctx ends mid-batch no further
+// probes start; the auths verified by then are returned with ctx's error and
+// NextCursor left at opts.Cursor.
+func (i *Inspector) VerifyBatch(ctx context.Context, provider string, opts VerifyOptions) (VerifyBatchResult, error) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	concurrency, batchSize, cursor := opts.Concurrency, opts.BatchSize, opts.Cursor
+	candidates, skippedCount, frozenCount, filteredCount, unsupported := i.candidates(provider, opts.Filter)
+	total := len(candidates)
+	if total == 0 || cursor >= total {
+		return VerifyBatchResult{
+			Provider:    provider,
+			Concurrency: concurrency,
+			BatchSize:   batchSize,
+			Cursor:      cursor,
+			NextCursor:  cursor,
+			Total:       total,
+			Done:        true,
+			Skipped:     skippedCount,
+			Frozen:      frozenCount,
+			Filtered:    filteredCount,
+			Unsupported: unsupported,
+			Results:     []VerifyResult{},
+		}, nil
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	end := cursor + batchSize
+	if end > total {
+		end = total
+	}
+	now := time.Now()
+	freshCount, backoffCount := 0, 0
+	currentBatch := make([]*Auth, 0, end-cursor)
+	var cachedEntries []VerifyResult
+	for _, auth := range candidates[cursor:end] {
+		if verifiedRecently(auth, now, opts.MinReverify) {
+			freshCount++
+			continue
+		}
+		if opts.Backoff.backingOff(auth, now) {
+			backoffCount++
+			continue
+		}
+		if opts.Cached != nil {
+			if entry, ok := opts.Cached(auth); ok {
+				entry.Cached = true
+				cachedEntries = append(cachedEntries, entry)
+				continue
+			}
+		}
+		currentBatch = append(currentBatch, auth)
+	}
+	if concurrency > len(currentBatch) {
+		concurrency = max(len(currentBatch), 1)
+	}
+	if opts.OnResult != nil {
+		for _, entry := range cachedEntries {
+			opts.OnResult(entry)
+		}
+	}
+
+	// interrupted is set for probes cut short by ctx; entry holds the result
+	// of the others unless their error fails the batch.
+	type verifyOutcome struct {
+		auth *Auth
+		probeResult
+		interrupted bool
+		entry       VerifyResult
+	}
+
+	jobs := make(chan *Auth)
+	outcomes := make(chan verifyOutcome, len(currentBatch))
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for auth := range jobs {
+				out := verifyOutcome{auth: auth, probeResult: i.verify(ctx, auth, opts.Throttle, opts.Pool)}
+				out.interrupted = out.err != nil && ctx.Err() != nil
+				if !out.interrupted && (out.err == nil || errors.Is(out.err, ErrProbeInconclusive)) {
+					out.entry = newVerifyResult(auth, out.probeResult)
+					if opts.OnResult != nil {
+						opts.OnResult(out.entry)
+					}
+				}
+				outcomes <- out
+			}
+		}()
+	}
+dispatch:
+	for _, auth := range currentBatch {
+		select {
+		case jobs <- auth:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	close(outcomes)
+
+	validCount := 0
+	invalidCount := 0
+	errorCount := 0
+	throttledCount := 0
+	recoveredCount := 0
+	entries := make([]VerifyResult, 0, len(currentBatch)+len(cachedEntries))
+	var firstErr error
+	// Auths never handed out and probes cut short by ctx verified nothing.
+	interrupted := len(currentBatch)
+	for res := range outcomes {
+		interrupted--
+		if res.interrupted {
+			interrupted++
+			continue
+		}
+		inconclusive := res.err != nil && errors.Is(res.err, ErrProbeInconclusive)
+		if res.err != nil && !inconclusive {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to verify token for %s: %w", res.auth.ID, res.err)
+			}
+			continue
+		}
+		entries = append(entries, res.entry)
+	}
+	if firstErr != nil {
+		return VerifyBatchResult{}, firstErr
+	}
+	entries = append(entries, cachedEntries...)
+	for _, entry := range entries {
+		switch entry.Outcome {
+		case OutcomeThrottled:
+			throttledCount++
+		case OutcomeError:
+			errorCount++
+		case OutcomeInvalid:
+			invalidCount++
+		default:
+			validCount++
+			if entry.Recovered {
+				recoveredCount++
+			}
+		}
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return strings.Compare(strings.TrimSpace(entries[a].ID), strings.TrimSpace(entries[b].ID)) < 0
+	})
+	if errCtx := ctx.Err(); errCtx != nil && interrupted > 0 {
+		return VerifyBatchResult{
+			Provider:    provider,
+			Concurrency: concurrency,
+			BatchSize:   batchSize,
+			Cursor:      cursor,
+			NextCursor:  cursor,
+			Total:       total,
+			Checked:     len(entries),
+			Valid:       validCount,
+			Invalid:     invalidCount,
+			Errors:      errorCount,
+			Throttled:   throttledCount,
+			Skipped:     skippedCount + freshCount + backoffCount,
+			Frozen:      frozenCount,
+			Filtered:    filteredCount,
+			Recovered:   recoveredCount,
+			Fresh:       freshCount,
+			BackedOff:   backoffCount,
+			Cached:      len(cachedEntries),
+			Unsupported: unsupported,
+			Results:     entries,
+		}, errCtx
+	}
+	leftCount := 0
+	if opts.Filter != nil && i.manager != nil {
+		for _, auth := range currentBatch {
+			if current, ok := i.manager.GetByID(auth.ID); ok && !opts.Filter(current) {
+				leftCount++
+			}
+		}
+	}
+
+	return VerifyBatchResult{
+		Provider:    provider,
+		Concurrency: concurrency,
+		BatchSize:   batchSize,
+		Cursor:      cursor,
+		NextCursor:  end - leftCount,
+		Total:       total,
+		Done:        end >= total,
+		Checked:     len(currentBatch) + len(cachedEntries),
+		Valid:       validCount,
+		Invalid:     invalidCount,
+		Errors:      errorCount,
+		Throttled:   throttledCount,
+		Skipped:     skippedCount + freshCount + backoffCount,
+		Frozen:      frozenCount,
+		Filtered:    filteredCount,
+		Recovered:   recoveredCount,
+		Fresh:       freshCount,
+		BackedOff:   backoffCount,
+		Cached:      len(cachedEntries),
+		Left:        leftCount,
+		Unsupported: unsupported,
+		Results:     entries,
+	}, nil
+}
+
+// Run verifies every candidate for opts.Provider batch by batch and, when
+// opts.DeleteInvalid is set, then removes the auths marked invalid. It stops
+// at the first batch error. The report is populated as far as the run got,
+// even on error.
+func (i *Inspector) Run(ctx context.Context, opts RunOptions) (InspectionReport, error) {
+	provider := strings.ToLower(strings.TrimSpace(opts.Provider))
+	if provider == "all" || provider == "*" {
+		provider = ""
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	report := InspectionReport{Provider: provider, StartedAt: time.Now().UTC(), Results: []VerifyResult{}}
+	if i == nil || i.manager == nil {
+		report.FinishedAt = time.Now().UTC()
+		return report, fmt.Errorf("auth manager unavailable")
+	}
+	i.emit(InspectionEvent{Type: InspectionStarted})
+
+	err := i.walk(ctx, provider, opts, func(res VerifyBatchResult, round int) {
+		report.Total = res.Total
+		report.Frozen = res.Frozen
+		report.Filtered = res.Filtered
+		report.Checked += res.Checked
+		report.Valid += res.Valid
+		report.Invalid += res.Invalid
+		report.Errors += res.Errors
+		report.Throttled += res.Throttled
+		report.Recovered += res.Recovered
+		report.Results = append(report.Results, res.Results...)
+		if opts.OnBatch != nil {
+			opts.OnBatch(res, round)
+		}
+	})
+	if err == nil && opts.DeleteInvalid {
+		var deleted DeleteResult
+		deleted, err = i.DeleteInvalid(ctx, opts.Delete)
+		report.Matched, report.Deleted = deleted.Matched, deleted.Deleted
+		if err != nil {
+			err = fmt.Errorf("delete invalid failed: %w", err)
+		}
+	}
+	report.FinishedAt = time.Now().UTC()
+	finished := report
+	i.emit(InspectionEvent{Type: InspectionFinished, Report: &finished, Err: err})
+	return report, err
+}
+
+func (i *Inspector) walk(ctx context.Context, provider string, opts RunOptions, onBatch func(res VerifyBatchResult, round int)) error {
+	cursor := 0
+	for round := 1; opts.MaxRounds <= 0 || round <= opts.MaxRounds; round++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		res, errBatch := i.VerifyBatch(ctx, provider, VerifyOptions{Concurrency: opts.Concurrency, BatchSize: opts.BatchSize, Cursor: cursor, Filter: opts.Filter, Throttle: opts.Throttle, MinReverify: opts.MinReverify, Backoff: opts.Backoff, Pool: opts.Pool})
+		if errBatch != nil {
+			return errBatch
+		}
+		onBatch(res, round)
+		cursor = res.NextCursor
+		// A batch whose auths all left the candidates repeats the cursor.
+		if res.Done || (cursor <= res.Cursor && res.Left == 0) || (res.Total > 0 && cursor >= res.Total) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// DeleteInvalid removes every auth marked invalid, except runtime-only,
+// frozen, admin-disabled and already removed ones and those outside
+// opts.Providers or rejected by opts.Filter. It stops at the first removal error.
+func (i *Inspector) DeleteInvalid(ctx context.Context, opts DeleteOptions) (DeleteResult, error) {
+	result := DeleteResult{ByProvider: make(map[string]DeleteCounts)}
+	if i == nil || i.manager == nil {
+		return result, fmt.Errorf("auth manager unavailable")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	remove := opts.Remove
+	if remove == nil {
+		remove = i.removeFromStore
+	}
+	var providers map[string]struct{}
+	if len(opts.Providers) > 0 {
+		providers = make(map[string]struct{}, len(opts.Providers))
+		for _, provider := range opts.Providers {
+			providers[strings.ToLower(strings.TrimSpace(provider))] = struct{}{}
+		}
+	}
+	seen := make(map[string]struct{})
+	for _, auth := range i.manager.List() {
+		if auth == nil || isRuntimeOnly(auth) || IsFrozen(auth) || IsAdminDisabled(auth) || i.manager.Removed(auth.ID) {
+			continue
+		}
+		provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+		if providers != nil {
+			if _, ok := providers[provider]; !ok {
+				continue
+			}
+		}
+		if invalid, _ := TokenInvalidState(auth); !invalid {
+			continue
+		}
+		if opts.Filter != nil && !opts.Filter(auth) {
+			continue
+		}
+		key := auth.ID
+		if opts.Key != nil {
+			var ok bool
+			if key, ok = opts.Key(auth); !ok {
+				continue
+			}
+		}
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		counts := result.ByProvider[provider]
+		counts.Matched++
+		result.ByProvider[provider] = counts
+		result.Matched++
+		if opts.DryRun {
+			result.Candidates = append(result.Candidates, auth.Clone())
+			continue
+		}
+		if err := remove(ctx, auth); err != nil {
+			return result, err
+		}
+		counts.Deleted++
+		result.ByProvider[provider] = counts
+		result.Deleted++
+		i.emit(InspectionEvent{Type: InspectionAuthDeleted, Auth: auth.Clone()})
+	}
+	return result, nil
+}
+
+// removeFromStore deletes auth from the manager's store and disables it
+// without writing it back.
+func (i *Inspector) removeFromStore(ctx context.Context, auth *Auth) error {
+	i.manager.mu.RLock()
+	store := i.manager.store
+	i.manager.mu.RUnlock()
+	if store != nil {
+		if err := store.Delete(ctx, auth.ID); err != nil {
+			return err
+		}
+	}
+	i.manager.MarkRemoved(WithSkipPersist(ctx), auth.ID, "removed as invalid")
+	return nil
+}
@@ -0,0 +1,559 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	mu    sync.Mutex
+	items map[string]*Auth
+}
+
+func (s *memoryStore) List(context.Context) ([]*Auth, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*Auth, 0, len(s.items))
+	for _, a := range s.items {
+		out = append(out, a.Clone())
+	}
+	return out, nil
+}
+
+func (s *memoryStore) Save(_ context.Context, auth *Auth) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.items == nil {
+		s.items = make(map[string]*Auth)
+	}
+	s.items[auth.ID] = auth.Clone()
+	return auth.ID, nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, id)
+	return nil
+}
+
+func (s *memoryStore) has(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.items[id]
+	return ok
+}
+
+// newInspectorFixture registers auths whose "token" metadata decides the
+// outcome of the "custom" probe: "bad" is invalid, anything else valid.
+func newInspectorFixture(t *testing.T) (*Inspector, *Manager, *memoryStore) {
+	t.Helper()
+	store := &memoryStore{}
+	manager := NewManager(store, nil, nil)
+	ctx := context.Background()
+	for _, a := range []*Auth{
+		{ID: "a-good", Provider: "custom", Metadata: map[string]any{"token": "ok"}},
+		{ID: "b-bad", Provider: "custom", Metadata: map[string]any{"token": "bad"}},
+		{ID: "c-bad", Provider: "custom", Metadata: map[string]any{"token": "bad"}},
+		{ID: "d-disabled", Provider: "custom", Disabled: true, Status: StatusDisabled, Metadata: map[string]any{"token": "bad"}},
+		{ID: "e-runtime", Provider: "custom", Attributes: map[string]string{"runtime_only": "true"}, Metadata: map[string]any{"token": "bad"}},
+		{ID: "f-other", Provider: "unprobed", Metadata: map[string]any{"token": "bad"}},
+	} {
+		if _, err := manager.Register(ctx, a); err != nil {
+			t.Fatalf("register %s: %v", a.ID, err)
+		}
+	}
+	inspector := NewInspector(manager)
+	inspector.RegisterProbe("Custom", ProbeFunc(func(_ context.Context, auth *Auth) (bool, string, error) {
+		if auth.Metadata["token"] == "bad" {
+			return true, "rejected", nil
+		}
+		return false, "", nil
+	}))
+	return inspector, manager, store
+}
+
+func TestInspectorVerifyBatch(t *testing.T) {
+	inspector, manager, _ := newInspectorFixture(t)
+	ctx := context.Background()
+
+	first, err := inspector.VerifyBatch(ctx, "custom", VerifyOptions{Concurrency: 4, BatchSize: 2})
+	if err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	if first.Total != 3 || first.Skipped != 2 || first.Checked != 2 || first.Done || first.NextCursor != 2 {
+		t.Fatalf("first batch = %+v", first)
+	}
+	if first.Results[0].ID != "a-good" || first.Results[0].Invalid || !first.Results[1].Invalid || first.Results[1].Reason != "rejected" {
+		t.Fatalf("first batch results = %+v", first.Results)
+	}
+
+	second, err := inspector.VerifyBatch(ctx, "custom", VerifyOptions{Concurrency: 4, BatchSize: 2, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	if !second.Done || second.Checked != 1 || second.Invalid != 1 {
+		t.Fatalf("second batch = %+v", second)
+	}
+
+	for id, want := range map[string]bool{"a-good": false, "b-bad": true, "c-bad": true, "d-disabled": false, "e-runtime": false, "f-other": false} {
+		auth, _ := manager.GetByID(id)
+		if invalid, _ := TokenInvalidState(auth); invalid != want {
+			t.Fatalf("%s invalid = %v, want %v", id, invalid, want)
+		}
+	}
+}
+
+func TestInspectorRunDeletesInvalidAndEmitsEvents(t *testing.T) {
+	inspector, manager, store := newInspectorFixture(t)
+	var (
+		mu     sync.Mutex
+		counts = map[InspectionEventType]int{}
+	)
+	inspector.Subscribe(func(ev InspectionEvent) {
+		mu.Lock()
+		counts[ev.Type]++
+		mu.Unlock()
+	})
+
+	report, err := inspector.Run(context.Background(), RunOptions{Provider: "all", Concurrency: 2, BatchSize: 1, DeleteInvalid: true})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Total != 3 || report.Checked != 3 || report.Valid != 1 || report.Invalid != 2 || report.Matched != 2 || report.Deleted != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	for _, id := range []string{"b-bad", "c-bad"} {
+		if store.has(id) {
+			t.Fatalf("%s still in store", id)
+		}
+		if auth, _ := manager.GetByID(id); !auth.Disabled {
+			t.Fatalf("%s not disabled", id)
+		}
+	}
+	if !store.has("a-good") {
+		t.Fatal("valid auth removed from store")
+	}
+	want := map[InspectionEventType]int{InspectionStarted: 1, InspectionAuthChecked: 3, InspectionAuthDeleted: 2, InspectionFinished: 1}
+	for typ, n := range want {
+		if counts[typ] != n {
+			t.Fatalf("%s events = %d, want %d (all: %v)", typ, counts[typ], n, counts)
+		}
+	}
+}
+
+func TestInspectorDeleteInvalidDryRun(t *testing.T) {
+	inspector, manager, store := newInspectorFixture(t)
+	if _, err := inspector.Run(context.Background(), RunOptions{Provider: "all", Concurrency: 2}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	deleted := 0
+	inspector.Subscribe(func(ev InspectionEvent) {
+		if ev.Type == InspectionAuthDeleted {
+			deleted++
+		}
+	})
+
+	res, err := inspector.DeleteInvalid(context.Background(), DeleteOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("DeleteInvalid: %v", err)
+	}
+	if res.Matched != 2 || res.Deleted != 0 || len(res.Candidates) != 2 || deleted != 0 {
+		t.Fatalf("dry run = %+v, deleted events %d", res, deleted)
+	}
+	for _, id := range []string{"b-bad", "c-bad"} {
+		if auth, _ := manager.GetByID(id); !store.has(id) || auth.Disabled {
+			t.Fatalf("dry run removed %s", id)
+		}
+	}
+}
+
+func TestInspectorRunFilter(t *testing.T) {
+	inspector, manager, store := newInspectorFixture(t)
+	keep := func(auth *Auth) bool { return auth.ID != "c-bad" }
+	report, err := inspector.Run(context.Background(), RunOptions{Provider: "custom", Concurrency: 2, DeleteInvalid: true, Filter: keep, Delete: DeleteOptions{Filter: keep}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Total != 2 || report.Checked != 2 || report.Filtered != 1 || report.Invalid != 1 || report.Deleted != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	filtered, _ := manager.GetByID("c-bad")
+	if invalid, _ := TokenInvalidState(filtered); invalid || !store.has("c-bad") {
+		t.Fatal("filtered auth was probed or deleted")
+	}
+	if store.has("b-bad") {
+		t.Fatal("b-bad not deleted")
+	}
+}
+
+func TestInspectorVerifyBatchAllProviders(t *testing.T) {
+	inspector, _, _ := newInspectorFixture(t)
+
+	res, err := inspector.VerifyBatch(context.Background(), "", VerifyOptions{Concurrency: 2, BatchSize: 10})
+	if err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	if res.Total != 3 || res.Checked != 3 || res.Skipped != 3 || !res.Done {
+		t.Fatalf("batch = %+v", res)
+	}
+	if len(res.Unsupported) != 1 || res.Unsupported[0] != "unprobed" {
+		t.Fatalf("unsupported = %v", res.Unsupported)
+	}
+}
+
+func TestInspectorVerifyBatchInconclusive(t *testing.T) {
+	inspector, manager, _ := newInspectorFixture(t)
+	b, _ := manager.GetByID("b-bad")
+	SetTokenInvalidState(b, true, "earlier")
+	if _, err := manager.Update(context.Background(), b); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	inspector.RegisterProbe("custom", ProbeFunc(func(_ context.Context, auth *Auth) (bool, string, error) {
+		if auth.Metadata["token"] == "bad" {
+			return false, "", fmt.Errorf("%w: upstream 503", ErrProbeInconclusive)
+		}
+		return false, "", nil
+	}))
+
+	res, err := inspector.VerifyBatch(context.Background(), "custom", VerifyOptions{Concurrency: 2, BatchSize: 10})
+	if err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	if res.Checked != 3 || res.Valid != 1 || res.Invalid != 0 || res.Errors != 2 || len(res.Results) != 3 {
+		t.Fatalf("batch = %+v", res)
+	}
+	if res.Results[1].Error == "" || res.Results[1].Invalid {
+		t.Fatalf("inconclusive result = %+v", res.Results[1])
+	}
+	if invalid, reason := TokenInvalidState(mustAuth(t, manager, "b-bad")); !invalid || reason != "earlier" {
+		t.Fatalf("b-bad state = %v %q, want untouched", invalid, reason)
+	}
+	if invalid, _ := TokenInvalidState(mustAuth(t, manager, "c-bad")); invalid {
+		t.Fatal("c-bad marked invalid by an inconclusive probe")
+	}
+}
+
+func TestInspectorVerifyBatchProbeDetail(t *testing.T) {
+	inspector, manager, _ := newInspectorFixture(t)
+	inspector.RegisterProbe("custom", ProbeFunc(func(ctx context.Context, auth *Auth) (bool, string, error) {
+		time.Sleep(5 * time.Millisecond)
+		switch auth.ID {
+		case "b-bad":
+			RecordProbeStatus(ctx, 401)
+			return true, "401 revoked", nil
+		case "c-bad":
+			RecordProbeStatus(ctx, 503)
+			return false, "", fmt.Errorf("%w: upstream 503", ErrProbeInconclusive)
+		}
+		RecordProbeStatus(ctx, 200)
+		return false, "", nil
+	}))
+
+	res, err := inspector.VerifyBatch(context.Background(), "custom", VerifyOptions{Concurrency: 3, BatchSize: 10})
+	if err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	want := []struct {
+		outcome string
+		status  int
+	}{{OutcomeValid, 200}, {OutcomeInvalid, 401}, {OutcomeError, 503}}
+	if len(res.Results) != len(want) {
+		t.Fatalf("results = %+v", res.Results)
+	}
+	for idx, w := range want {
+		got := res.Results[idx]
+		if got.Outcome != w.outcome || got.StatusCode != w.status || got.LatencyMs < 5 {
+			t.Fatalf("result %d = %+v, want %s %d", idx, got, w.outcome, w.status)
+		}
+	}
+	if latency, ok := mustAuth(t, manager, "b-bad").Metadata[MetadataProbeLatencyMs].(int64); !ok || latency < 5 {
+		t.Fatalf("recorded latency = %v", mustAuth(t, manager, "b-bad").Metadata[MetadataProbeLatencyMs])
+	}
+	if _, ok := mustAuth(t, manager, "c-bad").Metadata[MetadataProbeLatencyMs]; ok {
+		t.Fatal("inconclusive probe recorded a latency")
+	}
+}
+
+func TestProbeLatencyMovingAverage(t *testing.T) {
+	auth := &Auth{ID: "slow", Metadata: map[string]any{legacyMetadataProbeLatencyMs: int64(9)}}
+	if _, _, ok := ProbeLatency(auth); ok {
+		t.Fatal("never probed auth reported a latency")
+	}
+	recordProbeLatency(auth, 1000*time.Millisecond)
+	if last, avg, ok := ProbeLatency(auth); !ok || last != time.Second || avg != time.Second {
+		t.Fatalf("first probe: last %v avg %v ok %v", last, avg, ok)
+	}
+	if _, ok := auth.Metadata[legacyMetadataProbeLatencyMs]; ok {
+		t.Fatal("legacy latency key kept")
+	}
+	recordProbeLatency(auth, 5000*time.Millisecond)
+	if last, avg, _ := ProbeLatency(auth); last != 5*time.Second || avg != 2200*time.Millisecond {
+		t.Fatalf("second probe: last %v avg %v", last, avg)
+	}
+	// Values read back from a JSON auth file are float64.
+	auth.Metadata[MetadataProbeLatencyMs] = float64(300)
+	auth.Metadata[MetadataProbeLatencyAvgMs] = float64(2200)
+	if last, avg, ok := ProbeLatency(auth); !ok || last != 300*time.Millisecond || avg != 2200*time.Millisecond {
+		t.Fatalf("decoded: last %v avg %v ok %v", last, avg, ok)
+	}
+}
+
+func TestInspectorVerifyBatchThrottle(t *testing.T) {
+	inspector, _, _ := newInspectorFixture(t)
+	var mu sync.Mutex
+	throttled := 0
+	throttle := func(context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		throttled++
+		return nil
+	}
+	res, err := inspector.VerifyBatch(context.Background(), "custom", VerifyOptions{Concurrency: 2, BatchSize: 10, Throttle: throttle})
+	if err != nil || res.Checked != 3 || throttled != 3 {
+		t.Fatalf("batch = %+v (%v), throttled %d", res, err, throttled)
+	}
+
+	// A throttle error aborts the batch before anything is probed.
+	blocked := func(context.Context) error { return context.DeadlineExceeded }
+	if _, err = inspector.VerifyBatch(context.Background(), "custom", VerifyOptions{BatchSize: 10, Throttle: blocked}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("throttled batch error = %v", err)
+	}
+}
+
+func TestInspectorVerifyBatchMinReverify(t *testing.T) {
+	inspector, manager, _ := newInspectorFixture(t)
+	ctx := context.Background()
+	if _, err := inspector.VerifyBatch(ctx, "custom", VerifyOptions{BatchSize: 10}); err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	if at, outcome := LastVerification(mustAuth(t, manager, "a-good")); at.IsZero() || outcome != OutcomeValid {
+		t.Fatalf("a-good last verification = %v %q", at, outcome)
+	}
+	if _, outcome := LastVerification(mustAuth(t, manager, "b-bad")); outcome != OutcomeInvalid {
+		t.Fatalf("b-bad last outcome = %q", outcome)
+	}
+
+	// Only the recently valid auth is left out; invalid ones are probed again.
+	res, err := inspector.VerifyBatch(ctx, "custom", VerifyOptions{BatchSize: 10, MinReverify: time.Hour})
+	if err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	if res.Total != 3 || res.Checked != 2 || res.Fresh != 1 || res.Invalid != 2 || len(res.Results) != 2 {
+		t.Fatalf("batch = %+v", res)
+	}
+	// Skipped adds the fresh auth to the disabled and runtime-only ones.
+	if res.Skipped != 3 || res.NextCursor != 3 {
+		t.Fatalf("skipped %d next cursor %d", res.Skipped, res.NextCursor)
+	}
+}
+
+func TestInspectorVerifyBatchBackoff(t *testing.T) {
+	inspector, manager, _ := newInspectorFixture(t)
+	ctx := context.Background()
+	failing := true
+	inspector.RegisterProbe("custom", ProbeFunc(func(_ context.Context, auth *Auth) (bool, string, error) {
+		switch {
+		case auth.ID == "b-bad" && failing:
+			return false, "", fmt.Errorf("%w: upstream returned 503", ErrProbeInconclusive)
+		case auth.ID == "c-bad":
+			return false, "", fmt.Errorf("%w: upstream returned 429", ErrProbeThrottled)
+		}
+		return false, "", nil
+	}))
+	opts := VerifyOptions{BatchSize: 10, Backoff: &ProbeBackoff{Threshold: 1, Base: time.Hour}}
+	stale := mustAuth(t, manager, "b-bad")
+
+	// The first failure past the threshold starts the backoff.
+	for round := 1; round <= 2; round++ {
+		res, err := inspector.VerifyBatch(ctx, "custom", opts)
+		if err != nil || res.Checked != 3 || res.BackedOff != 0 {
+			t.Fatalf("round %d = %+v, %v", round, res, err)
+		}
+	}
+	if count, at := ProbeFailures(mustAuth(t, manager, "b-bad")); count != 2 || at.IsZero() {
+		t.Fatalf("b-bad failures = %d at %v", count, at)
+	}
+	// A failure probed on an older snapshot still adds to the stored count.
+	if _, err := inspector.VerifyOne(ctx, stale, nil); err != nil {
+		t.Fatalf("VerifyOne stale: %v", err)
+	}
+	if count, _ := ProbeFailures(mustAuth(t, manager, "b-bad")); count != 3 {
+		t.Fatalf("b-bad failures after a stale probe = %d", count)
+	}
+	if count, _ := ProbeFailures(mustAuth(t, manager, "c-bad")); count != 0 {
+		t.Fatalf("throttled probes counted as failures: %d", count)
+	}
+	res, err := inspector.VerifyBatch(ctx, "custom", opts)
+	if err != nil || res.Checked != 2 || res.BackedOff != 1 || res.Skipped != 3 || res.NextCursor != 3 {
+		t.Fatalf("backed off batch = %+v, %v", res, err)
+	}
+
+	// Verifying the auth by ID probes it anyway; a verdict resets the count.
+	failing = false
+	if _, err = inspector.VerifyOne(ctx, mustAuth(t, manager, "b-bad"), nil); err != nil {
+		t.Fatalf("VerifyOne: %v", err)
+	}
+	if count, at := ProbeFailures(mustAuth(t, manager, "b-bad")); count != 0 || !at.IsZero() {
+		t.Fatalf("failures after a verdict = %d at %v", count, at)
+	}
+}
+
+func TestProbeBackoffWindow(t *testing.T) {
+	now := time.Now()
+	failedAgo := func(count int, ago time.Duration) *Auth {
+		return &Auth{Metadata: map[string]any{
+			MetadataProbeFailureCount: float64(count),
+			MetadataLastProbeErrorAt:  now.Add(-ago).UTC().Format(time.RFC3339),
+		}}
+	}
+	backoff := &ProbeBackoff{Threshold: 3, Base: time.Hour, Max: 6 * time.Hour}
+	tests := []struct {
+		auth *Auth
+		want bool
+	}{
+		{failedAgo(3, time.Minute), false},
+		{failedAgo(4, 59*time.Minute), true},
+		{failedAgo(4, 61*time.Minute), false},
+		{failedAgo(6, 3*time.Hour), true},
+		{failedAgo(6, 5*time.Hour), false},
+		{failedAgo(20, 5*time.Hour), true},
+		{failedAgo(20, 7*time.Hour), false},
+	}
+	for i, tt := range tests {
+		if got := backoff.backingOff(tt.auth, now); got != tt.want {
+			count, _ := ProbeFailures(tt.auth)
+			t.Errorf("case %d (%d failures): backingOff = %v, want %v", i, count, got, tt.want)
+		}
+	}
+}
+
+func TestInspectorVerifyBatchCached(t *testing.T) {
+	inspector, _, _ := newInspectorFixture(t)
+	var probed []string
+	inspector.RegisterProbe("custom", ProbeFunc(func(_ context.Context, auth *Auth) (bool, string, error) {
+		probed = append(probed, auth.ID)
+		return false, "", nil
+	}))
+	cached := func(auth *Auth) (VerifyResult, bool) {
+		if auth.ID != "b-bad" {
+			return VerifyResult{}, false
+		}
+		return VerifyResult{ID: auth.ID, Provider: "custom", Invalid: true, Reason: "rejected", Outcome: OutcomeInvalid}, true
+	}
+
+	var streamed []string
+	onResult := func(result VerifyResult) { streamed = append(streamed, result.ID) }
+
+	res, err := inspector.VerifyBatch(context.Background(), "custom", VerifyOptions{Concurrency: 1, BatchSize: 10, Cached: cached, OnResult: onResult})
+	if err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	// Cached results are streamed before the probes start.
+	if strings.Join(streamed, ",") != "b-bad,a-good,c-bad" {
+		t.Fatalf("streamed = %v", streamed)
+	}
+	if res.Checked != 3 || res.Cached != 1 || res.Valid != 2 || res.Invalid != 1 || len(res.Results) != 3 {
+		t.Fatalf("batch = %+v", res)
+	}
+	if len(probed) != 2 || probed[0] == "b-bad" || probed[1] == "b-bad" {
+		t.Fatalf("probed = %v, want b-bad served from the cache", probed)
+	}
+	if got := res.Results[1]; got.ID != "b-bad" || !got.Cached || got.Outcome != OutcomeInvalid {
+		t.Fatalf("cached result = %+v", got)
+	}
+}
+
+func TestInspectorRunFilterDependingOnProbes(t *testing.T) {
+	inspector, manager, _ := newInspectorFixture(t)
+	ctx := context.Background()
+	if _, err := inspector.VerifyBatch(ctx, "custom", VerifyOptions{BatchSize: 10}); err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	good := mustAuth(t, manager, "a-good")
+	SetTokenInvalidState(good, true, "stale")
+	if _, err := manager.Update(ctx, good); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	onlyInvalid := func(auth *Auth) bool {
+		invalid, _ := TokenInvalidState(auth)
+		return invalid
+	}
+
+	// a-good is found valid and drops out of the filter, so the next batch
+	// repeats the cursor instead of skipping b-bad.
+	first, err := inspector.VerifyBatch(ctx, "custom", VerifyOptions{BatchSize: 1, Filter: onlyInvalid})
+	if err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	if first.Total != 3 || first.Left != 1 || first.NextCursor != 0 || first.Done {
+		t.Fatalf("first batch = %+v", first)
+	}
+
+	SetTokenInvalidState(good, true, "stale")
+	if _, err = manager.Update(ctx, good); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	var probed []string
+	report, err := inspector.Run(ctx, RunOptions{Provider: "custom", BatchSize: 1, Filter: onlyInvalid, OnBatch: func(res VerifyBatchResult, _ int) {
+		for _, result := range res.Results {
+			probed = append(probed, result.ID)
+		}
+	}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Checked != 3 || strings.Join(probed, ",") != "a-good,b-bad,c-bad" {
+		t.Fatalf("checked %d, probed %v", report.Checked, probed)
+	}
+}
+
+func mustAuth(t *testing.T, manager *Manager, id string) *Auth {
+	t.Helper()
+	auth, ok := manager.GetByID(id)
+	if !ok {
+		t.Fatalf("auth %s missing", id)
+	}
+	return auth
+}
+
+func TestInspectorVerifyCancelledDoesNotRecord(t *testing.T) {
+	inspector, manager, _ := newInspectorFixture(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	inspector.RegisterProbe("custom", ProbeFunc(func(context.Context, *Auth) (bool, string, error) {
+		cancel()
+		return true, "cancelled mid-probe", nil
+	}))
+
+	auth, _ := manager.GetByID("a-good")
+	if _, _, err := inspector.Verify(ctx, auth); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Verify error = %v, want context.Canceled", err)
+	}
+	stored, _ := manager.GetByID("a-good")
+	if invalid, _ := TokenInvalidState(stored); invalid {
+		t.Fatal("cancelled verification recorded an invalid token")
+	}
+}
+
+func TestInvalidReasonCode(t *testing.T) {
+	cases := map[string]string{
+		"":                                     "unknown",
+		"token is empty":                       "token_empty",
+		"401 {\"error\":\"token expired\"}":    "http_401",
+		"token refresh failed: status 403":     "http_403",
+		"token refresh failed: invalid_grant":  "invalid_grant",
+		"token refresh failed: dial tcp: EOF":  "refresh_failed",
+		"external: email notice":               "other",
+		"token refresh failed: port 40312 ...": "refresh_failed",
+	}
+	for reason, want := range cases {
+		if got := InvalidReasonCode(reason); got != want {
+			t.Fatalf("InvalidReasonCode(%q) = %q, want %q", reason, got, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// MetadataInvalidDisabled is true while an auth is sidelined because its
+// token was found invalid. A sidelined auth keeps its record but is marked
+// unavailable with StatusError and is not routed to; a verification that
+// finds the token valid again restores it.
+const MetadataInvalidDisabled = "invalid_disabled"
+
+// IsInvalidDisabled reports whether auth is sidelined by DisableInvalid.
+func IsInvalidDisabled(auth *Auth) bool {
+	return auth != nil && len(auth.Metadata) > 0 && metadataTruthy(auth.Metadata[MetadataInvalidDisabled])
+}
+
+// MarkTokenInvalid marks auth's token invalid for reason and records the
+// failure the way a failing request does: StatusError with reason as the
+// status message, and unavailable. A disabled auth keeps its status. It only
+// changes auth; Manager.MarkInvalid also writes it to the store.
+func MarkTokenInvalid(auth *Auth, reason string) {
+	if auth == nil {
+		return
+	}
+	SetTokenInvalidState(auth, true, reason)
+	if !auth.Disabled {
+		auth.Status = StatusError
+		auth.StatusMessage = strings.TrimSpace(reason)
+	}
+	auth.Unavailable = true
+}
+
+// ClearTokenInvalid clears auth's invalid mark, DisableInvalid's included,
+// and the failure MarkTokenInvalid recorded with it. Quota cooldowns and
+// AdminDisable are kept.
+// It reports whether auth was marked.
+func ClearTokenInvalid(auth *Auth) bool {
+	if invalid, _ := TokenInvalidState(auth); !invalid && !IsInvalidDisabled(auth) {
+		return false
+	}
+	SetTokenInvalidState(auth, false, "")
+	delete(auth.Metadata, MetadataInvalidDisabled)
+	if auth.Status == StatusError {
+		auth.Status = StatusActive
+		auth.StatusMessage = ""
+		auth.LastError = nil
+	}
+	if !auth.Quota.Exceeded && !IsAdminDisabled(auth) {
+		auth.Unavailable = false
+		auth.NextRetryAfter = time.Time{}
+	}
+	return true
+}
+
+// MarkInvalid applies MarkTokenInvalid to the auth with the given ID and
+// writes the result to the store once.
+func (m *Manager) MarkInvalid(ctx context.Context, id, reason string) (*Auth, error) {
+	auth, _, err := m.setRuntimeState(ctx, id, func(auth *Auth) bool {
+		MarkTokenInvalid(auth, reason)
+		return true
+	})
+	return auth, err
+}
+
+// ClearInvalid applies ClearTokenInvalid to the auth with the given ID and,
+// when it was marked, writes the result to the store once. It reports
+// whether the auth was marked.
+func (m *Manager) ClearInvalid(ctx context.Context, id string) (*Auth, bool, error) {
+	return m.setRuntimeState(ctx, id, ClearTokenInvalid)
+}
+
+// DisableInvalid sidelines the auth with the given ID: its token is marked
+// invalid with MarkTokenInvalid, the sideline mark is set, and both are
+// written to the store.
+func (m *Manager) DisableInvalid(ctx context.Context, id, reason string) (*Auth, error) {
+	auth, _, err := m.setRuntimeState(ctx, id, func(auth *Auth) bool {
+		MarkTokenInvalid(auth, reason)
+		auth.Metadata[MetadataInvalidDisabled] = true
+		return true
+	})
+	return auth, err
+}
+
+// Reactivate returns an auth whose token was verified valid to service: the
+// DisableInvalid mark is cleared, the status set to StatusActive and the
+// unavailability, quota and cooldown state of the auth and its models reset.
+// It reports whether anything needed resetting; healthy auths are returned
+// unchanged.
+func (m *Manager) Reactivate(ctx context.Context, id string) (*Auth, bool, error) {
+	return m.setRuntimeState(ctx, id, func(auth *Auth) bool {
+		if !needsReactivation(auth) {
+			return false
+		}
+		now := time.Now()
+		delete(auth.Metadata, MetadataInvalidDisabled)
+		clearAuthStateOnSuccess(auth, now)
+		for _, state := range auth.ModelStates {
+			resetModelState(state, now)
+		}
+		return true
+	})
+}
+
+// needsReactivation reports whether auth carries failure state that a
+// successful verification should clear.
+func needsReactivation(auth *Auth) bool {
+	if IsInvalidDisabled(auth) || auth.Status == StatusError || auth.Unavailable || auth.Quota.Exceeded {
+		return true
+	}
+	for _, state := range auth.ModelStates {
+		if state != nil && (state.Status == StatusError || state.Unavailable || state.Quota.Exceeded) {
+			return true
+		}
+	}
+	return false
+}
+
+// setRuntimeState applies change to the auth with the given ID and, when it
+// reports a change, writes the result to the store. Update keeps the runtime
+// state, Unavailable included, so it cannot be used for this.
+func (m *Manager) setRuntimeState(ctx context.Context, id string, change func(auth *Auth) bool) (*Auth, bool, error) {
+	m.mu.Lock()
+	existing, ok := m.auths[id]
+	if !ok || existing == nil {
+		m.mu.Unlock()
+		return nil, false, &Error{Code: "auth_not_found", Message: "auth not found"}
+	}
+	auth := existing.Clone()
+	if !change(auth) {
+		m.mu.Unlock()
+		return auth, false, nil
+	}
+	auth.UpdatedAt = time.Now()
+	m.auths[id] = auth.Clone()
+	m.mu.Unlock()
+	if err := m.persist(ctx, auth); err != nil {
+		return auth.Clone(), true, err
+	}
+	m.hook.OnAuthUpdated(ctx, auth.Clone())
+	return auth.Clone(), true, nil
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDisableInvalidSidelinesUntilVerifiedValid(t *testing.T) {
+	inspector, manager, store := newInspectorFixture(t)
+	ctx := context.Background()
+
+	disabled, err := manager.DisableInvalid(ctx, "b-bad", "401 revoked")
+	if err != nil {
+		t.Fatalf("DisableInvalid: %v", err)
+	}
+	if disabled.Status != StatusError || !disabled.Unavailable || disabled.StatusMessage != "401 revoked" {
+		t.Fatalf("disabled auth = status %s unavailable %v message %q", disabled.Status, disabled.Unavailable, disabled.StatusMessage)
+	}
+	store.mu.Lock()
+	persisted := store.items["b-bad"].Clone()
+	store.mu.Unlock()
+	if !IsInvalidDisabled(persisted) {
+		t.Fatalf("mark not persisted: %v", persisted.Metadata)
+	}
+
+	// A sidelined auth is still probed; a valid outcome restores it.
+	auth := mustAuth(t, manager, "b-bad")
+	auth.Metadata["token"] = "ok"
+	if _, err = manager.Update(ctx, auth); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if auth = mustAuth(t, manager, "b-bad"); !auth.Unavailable {
+		t.Fatal("Update cleared the sideline")
+	}
+	if _, err = inspector.VerifyBatch(ctx, "custom", VerifyOptions{BatchSize: 10}); err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	restored := mustAuth(t, manager, "b-bad")
+	if IsInvalidDisabled(restored) || restored.Unavailable || restored.Status != StatusActive {
+		t.Fatalf("restored auth = status %s unavailable %v metadata %v", restored.Status, restored.Unavailable, restored.Metadata)
+	}
+	if _, err = manager.DisableInvalid(ctx, "missing", ""); err == nil {
+		t.Fatal("DisableInvalid of an unknown auth succeeded")
+	}
+}
+
+func TestVerifyMarksInvalidAuthFailedInOneWrite(t *testing.T) {
+	inspector, manager, store := newInspectorFixture(t)
+	ctx := context.Background()
+
+	if _, err := inspector.VerifyBatch(ctx, "custom", VerifyOptions{BatchSize: 10}); err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	store.mu.Lock()
+	persisted := store.items["b-bad"].Clone()
+	store.mu.Unlock()
+	for _, auth := range []*Auth{mustAuth(t, manager, "b-bad"), persisted} {
+		invalid, reason := TokenInvalidState(auth)
+		if !invalid || reason != "rejected" || auth.Status != StatusError || auth.StatusMessage != "rejected" {
+			t.Fatalf("invalid auth = status %s message %q metadata %v", auth.Status, auth.StatusMessage, auth.Metadata)
+		}
+		if _, outcome := LastVerification(auth); outcome != OutcomeInvalid {
+			t.Fatalf("last outcome = %q", outcome)
+		}
+	}
+	if !mustAuth(t, manager, "b-bad").Unavailable {
+		t.Fatal("invalid auth not marked unavailable")
+	}
+
+	cleared, changed, err := manager.ClearInvalid(ctx, "b-bad")
+	if err != nil || !changed || cleared.Status != StatusActive || cleared.Unavailable || cleared.StatusMessage != "" {
+		t.Fatalf("ClearInvalid = status %s unavailable %v changed %v err %v", cleared.Status, cleared.Unavailable, changed, err)
+	}
+	if invalid, _ := TokenInvalidState(cleared); invalid {
+		t.Fatalf("mark not cleared: %v", cleared.Metadata)
+	}
+	if _, changed, err = manager.ClearInvalid(ctx, "a-good"); err != nil || changed {
+		t.Fatalf("clearing a valid auth = %v, %v", changed, err)
+	}
+}
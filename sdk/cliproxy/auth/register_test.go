@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRegisterConcurrentSameID(t *testing.T) {
+	manager := NewManager(&memoryStore{}, nil, nil)
+	ctx := context.Background()
+
+	const workers = 32
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+		conflicts int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := manager.Register(ctx, &Auth{ID: "same.json", Provider: "custom", Metadata: map[string]any{"writer": i}})
+			mu.Lock()
+			defer mu.Unlock()
+			var conflict *ErrAlreadyRegistered
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.As(err, &conflict) && conflict.Existing != nil && conflict.Existing.ID == "same.json":
+				conflicts++
+			default:
+				t.Errorf("Register error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if succeeded != 1 || conflicts != workers-1 {
+		t.Fatalf("succeeded=%d conflicts=%d, want 1 and %d", succeeded, conflicts, workers-1)
+	}
+	if n := len(manager.List()); n != 1 {
+		t.Fatalf("entries = %d, want 1", n)
+	}
+	if _, err := manager.Register(ctx, &Auth{ID: "same.json"}); !errors.Is(err, &ErrAlreadyRegistered{}) {
+		t.Fatalf("errors.Is(ErrAlreadyRegistered) failed for %v", err)
+	}
+}
+
+func TestRegisterOrUpdateConcurrentKeepsRuntimeState(t *testing.T) {
+	manager := NewManager(&memoryStore{}, nil, nil)
+	ctx := context.Background()
+	if _, err := manager.Register(ctx, &Auth{ID: "same.json", Provider: "custom", Metadata: map[string]any{"token": "v0"}}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	current, _ := manager.GetByID("same.json")
+	recoverAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	current.Disabled = true
+	current.Status = StatusDisabled
+	current.StatusMessage = "disabled by operator"
+	current.Unavailable = true
+	current.NextRetryAfter = recoverAt
+	current.Quota = QuotaState{Exceeded: true, NextRecoverAt: recoverAt, BackoffLevel: 3}
+	current.ModelStates = map[string]*ModelState{"m1": {Status: StatusError, Unavailable: true, NextRetryAfter: recoverAt}}
+	if _, err := manager.Update(ctx, current); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	index := current.Index
+
+	const workers = 32
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Each record is what the watcher would build from disk.
+			disk := &Auth{ID: "same.json", Provider: "custom", Status: StatusActive, Metadata: map[string]any{"token": fmt.Sprintf("v%d", i+1)}}
+			if _, err := manager.RegisterOrUpdate(ctx, disk); err != nil {
+				t.Errorf("RegisterOrUpdate: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := len(manager.List()); n != 1 {
+		t.Fatalf("entries = %d, want 1", n)
+	}
+	got, _ := manager.GetByID("same.json")
+	if got.Metadata["token"] == "v0" {
+		t.Fatal("metadata from disk was not applied")
+	}
+	if !got.Disabled || got.Status != StatusDisabled || got.StatusMessage != "disabled by operator" {
+		t.Fatalf("disabled state lost: disabled=%v status=%s message=%q", got.Disabled, got.Status, got.StatusMessage)
+	}
+	if !got.Unavailable || !got.NextRetryAfter.Equal(recoverAt) || !got.Quota.Exceeded || got.Quota.BackoffLevel != 3 {
+		t.Fatalf("cooldown state lost: %+v", got)
+	}
+	if state := got.ModelStates["m1"]; state == nil || !state.Unavailable || !state.NextRetryAfter.Equal(recoverAt) {
+		t.Fatalf("model state lost: %+v", got.ModelStates)
+	}
+	if got.Index != index {
+		t.Fatalf("index = %q, want %q", got.Index, index)
+	}
+}
+
+func TestRegisterReplacesRemovedEntry(t *testing.T) {
+	manager := NewManager(&memoryStore{}, nil, nil)
+	ctx := context.Background()
+	if _, err := manager.Register(ctx, &Auth{ID: "gone.json", Provider: "custom", Quota: QuotaState{Exceeded: true}}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, ok := manager.MarkRemoved(ctx, "gone.json", "file deleted"); !ok {
+		t.Fatal("MarkRemoved did not find the auth")
+	}
+	if _, err := manager.RegisterOrUpdate(ctx, &Auth{ID: "gone.json", Provider: "custom", Status: StatusActive}); err != nil {
+		t.Fatalf("RegisterOrUpdate: %v", err)
+	}
+	got, _ := manager.GetByID("gone.json")
+	if got.Disabled || got.Status != StatusActive || got.Quota.Exceeded {
+		t.Fatalf("re-added auth kept tombstone state: %+v", got)
+	}
+	if _, err := manager.Register(ctx, &Auth{ID: "gone.json"}); err == nil {
+		t.Fatal("Register over a live entry should fail")
+	}
+}
+
+func TestRenameMovesEntryAndKeepsState(t *testing.T) {
+	manager := NewManager(&memoryStore{}, nil, nil)
+	ctx := context.Background()
+	for _, id := range []string{"old.json", "taken.json", "gone.json"} {
+		if _, err := manager.Register(ctx, &Auth{ID: id, Provider: "codex", Metadata: map[string]any{"type": "codex"}}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	if _, err := manager.MarkInvalid(ctx, "old.json", "revoked"); err != nil {
+		t.Fatalf("mark invalid: %v", err)
+	}
+	manager.MarkRemoved(ctx, "gone.json", "removed")
+
+	var conflict *ErrAlreadyRegistered
+	if _, err := manager.Rename(ctx, "old.json", "taken.json", nil); !errors.As(err, &conflict) {
+		t.Fatalf("rename onto a live auth: %v", err)
+	}
+	if _, err := manager.Rename(ctx, "missing.json", "new.json", nil); err == nil {
+		t.Fatalf("rename of an unknown auth succeeded")
+	}
+	renamed, err := manager.Rename(ctx, "old.json", "gone.json", func(auth *Auth) { auth.FileName = "gone.json" })
+	if err != nil {
+		t.Fatalf("rename onto a removed entry: %v", err)
+	}
+	if renamed.ID != "gone.json" || renamed.FileName != "gone.json" || manager.Removed("gone.json") {
+		t.Fatalf("renamed = %+v", renamed)
+	}
+	if _, ok := manager.GetByID("old.json"); ok {
+		t.Fatalf("old entry still registered")
+	}
+	if invalid, _ := TokenInvalidState(renamed); !invalid {
+		t.Fatalf("rename dropped the invalid mark")
+	}
+}
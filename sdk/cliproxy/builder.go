@@ -48,6 +48,12 @@ type Builder struct {
 
 	// serverOptions contains additional server configuration options.
 	serverOptions []api.ServerOption
+
+	// inspectionProbes verify auths of custom providers during inspections.
+	inspectionProbes map[string]coreauth.Probe
+
+	// inspectionHandlers receive inspector lifecycle events.
+	inspectionHandlers []func(coreauth.InspectionEvent)
 }
 
 // Hooks allows callers to plug into service lifecycle stages.
@@ -153,6 +159,64 @@ func (b *Builder) WithLocalManagementPassword(password string) *Builder {
 	return b
 }
 
+// WithInspectionProbe registers the probe that auth inspections use to verify
+// auths of provider. It replaces the built-in probe for codex, gemini-cli and
+// antigravity when provider is one of them.
+func (b *Builder) WithInspectionProbe(provider string, probe coreauth.Probe) *Builder {
+	if probe == nil {
+		return b
+	}
+	if b.inspectionProbes == nil {
+		b.inspectionProbes = make(map[string]coreauth.Probe)
+	}
+	b.inspectionProbes[provider] = probe
+	return b
+}
+
+// WithInspectionEventHandler subscribes fn to the events of every inspection,
+// whether scheduled, started via the management API or via Service.Inspector.
+func (b *Builder) WithInspectionEventHandler(fn func(coreauth.InspectionEvent)) *Builder {
+	if fn == nil {
+		return b
+	}
+	b.inspectionHandlers = append(b.inspectionHandlers, fn)
+	return b
+}
+
+// NewCoreAuthManager builds the core auth manager the service uses by default:
+// backed by the registered token store rooted at cfg.AuthDir, with the
+// configured routing strategy. Offline tools use it to see the same auths as
+// the server. Call Load on the result before use.
+func NewCoreAuthManager(cfg *config.Config) *coreauth.Manager {
+	tokenStore := sdkAuth.GetTokenStore()
+	if dirSetter, ok := tokenStore.(interface{ SetBaseDir(string) }); ok && cfg != nil {
+		dirSetter.SetBaseDir(cfg.AuthDir)
+	}
+
+	strategy := ""
+	if cfg != nil {
+		strategy = strings.ToLower(strings.TrimSpace(cfg.Routing.Strategy))
+	}
+	var selector coreauth.Selector
+	switch strategy {
+	case "fill-first", "fillfirst", "ff":
+		selector = &coreauth.FillFirstSelector{}
+	default:
+		selector = &coreauth.RoundRobinSelector{}
+	}
+
+	coreManager := coreauth.NewManager(tokenStore, selector, nil)
+	configureCoreAuthManager(coreManager, cfg)
+	return coreManager
+}
+
+func configureCoreAuthManager(coreManager *coreauth.Manager, cfg *config.Config) {
+	// Attach a default RoundTripper provider so providers can opt-in per-auth transports.
+	coreManager.SetRoundTripperProvider(newDefaultRoundTripperProvider())
+	coreManager.SetConfig(cfg)
+	coreManager.SetOAuthModelAlias(cfg.OAuthModelAlias)
+}
+
 // Build validates inputs, applies defaults, and returns a ready-to-run service.
 func (b *Builder) Build() (*Service, error) {
 	if b.cfg == nil {
@@ -192,29 +256,18 @@ func (b *Builder) Build() (*Service, error) {
 
 	coreManager := b.coreManager
 	if coreManager == nil {
-		tokenStore := sdkAuth.GetTokenStore()
-		if dirSetter, ok := tokenStore.(interface{ SetBaseDir(string) }); ok && b.cfg != nil {
-			dirSetter.SetBaseDir(b.cfg.AuthDir)
-		}
-
-		strategy := ""
-		if b.cfg != nil {
-			strategy = strings.ToLower(strings.TrimSpace(b.cfg.Routing.Strategy))
-		}
-		var selector coreauth.Selector
-		switch strategy {
-		case "fill-first", "fillfirst", "ff":
-			selector = &coreauth.FillFirstSelector{}
-		default:
-			selector = &coreauth.RoundRobinSelector{}
-		}
-
-		coreManager = coreauth.NewManager(tokenStore, selector, nil)
+		coreManager = NewCoreAuthManager(b.cfg)
+	} else {
+		configureCoreAuthManager(coreManager, b.cfg)
+	}
+
+	inspector := coreauth.NewInspector(coreManager)
+	for provider, probe := range b.inspectionProbes {
+		inspector.RegisterProbe(provider, probe)
+	}
+	for _, fn := range b.inspectionHandlers {
+		inspector.Subscribe(fn)
 	}
-	// Attach a default RoundTripper provider so providers can opt-in per-auth transports.
-	coreManager.SetRoundTripperProvider(newDefaultRoundTripperProvider())
-	coreManager.SetConfig(b.cfg)
-	coreManager.SetOAuthModelAlias(b.cfg.OAuthModelAlias)
 
 	service := &Service{
 		cfg:            b.cfg,
@@ -226,7 +279,8 @@ func (b *Builder) Build() (*Service, error) {
 		authManager:    authManager,
 		accessManager:  accessManager,
 		coreManager:    coreManager,
-		serverOptions:  append([]api.ServerOption(nil), b.serverOptions...),
+		inspector:      inspector,
+		serverOptions:  append(append([]api.ServerOption(nil), b.serverOptions...), api.WithInspector(inspector)),
 	}
 	return service, nil
 }
@@ -13,8 +13,10 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/serverlock"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
@@ -69,6 +71,9 @@ type Service struct {
 	// watcherCancel cancels the watcher context.
 	watcherCancel context.CancelFunc
 
+	// releaseServerMarker removes the auth-dir marker that tells offline tools a server is running.
+	releaseServerMarker func()
+
 	// authUpdates channel for authentication updates.
 	authUpdates chan watcher.AuthUpdate
 
@@ -84,6 +89,9 @@ type Service struct {
 	// coreManager handles core authentication and execution.
 	coreManager *coreauth.Manager
 
+	// inspector verifies and deletes auths held by coreManager.
+	inspector *coreauth.Inspector
+
 	// shutdownOnce ensures shutdown is called only once.
 	shutdownOnce sync.Once
 
@@ -100,6 +108,16 @@ func (s *Service) RegisterUsagePlugin(plugin usage.Plugin) {
 	usage.RegisterPlugin(plugin)
 }
 
+// Inspector returns the auth inspector shared with the management API. Its
+// probes for codex, gemini-cli and antigravity are registered once the server
+// is constructed in Run; custom probes registered earlier take precedence.
+func (s *Service) Inspector() *coreauth.Inspector {
+	if s == nil {
+		return nil
+	}
+	return s.inspector
+}
+
 // newDefaultAuthManager creates a default authentication manager with all supported providers.
 func newDefaultAuthManager() *sdkAuth.Manager {
 	return sdkAuth.NewManager(
@@ -284,19 +302,10 @@ func (s *Service) applyCoreAuthAddOrUpdate(ctx context.Context, auth *coreauth.A
 	// immediately for API calls, rather than waiting for model registration to complete.
 	// Model registration may involve network calls (e.g., FetchAntigravityModels) that
 	// could timeout if the new proxy_url is unreachable.
-	op := "register"
-	var err error
-	if existing, ok := s.coreManager.GetByID(auth.ID); ok {
-		auth.CreatedAt = existing.CreatedAt
-		auth.LastRefreshedAt = existing.LastRefreshedAt
-		auth.NextRefreshAfter = existing.NextRefreshAfter
-		op = "update"
-		_, err = s.coreManager.Update(ctx, auth)
-	} else {
-		_, err = s.coreManager.Register(ctx, auth)
-	}
-	if err != nil {
-		log.Errorf("failed to %s auth %s: %v", op, auth.ID, err)
+	// RegisterOrUpdate keeps the runtime state (cooldowns, quota, disabled
+	// flag) of an auth that is already managed.
+	if _, err := s.coreManager.RegisterOrUpdate(ctx, auth); err != nil {
+		log.Errorf("failed to register auth %s: %v", auth.ID, err)
 		current, ok := s.coreManager.GetByID(auth.ID)
 		if !ok || current.Disabled {
 			GlobalModelRegistry().UnregisterClient(auth.ID)
@@ -319,13 +328,7 @@ func (s *Service) applyCoreAuthRemoval(ctx context.Context, id string) {
 		return
 	}
 	GlobalModelRegistry().UnregisterClient(id)
-	if existing, ok := s.coreManager.GetByID(id); ok && existing != nil {
-		existing.Disabled = true
-		existing.Status = coreauth.StatusDisabled
-		if _, err := s.coreManager.Update(ctx, existing); err != nil {
-			log.Errorf("failed to disable auth %s: %v", id, err)
-		}
-	}
+	s.coreManager.MarkRemoved(ctx, id, "auth source removed")
 }
 
 func (s *Service) applyRetryConfig(cfg *config.Config) {
@@ -439,9 +442,12 @@ func (s *Service) Run(ctx context.Context) error {
 
 	usage.StartDefault(ctx)
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer shutdownCancel()
 	defer func() {
+		_, hard := shutdownTimings(s.cfg)
+		disarm := armHardDeadline(hard)
+		defer disarm()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), hard)
+		defer shutdownCancel()
 		if err := s.Shutdown(shutdownCtx); err != nil {
 			log.Errorf("service shutdown returned error: %v", err)
 		}
@@ -521,6 +527,12 @@ func (s *Service) Run(ctx context.Context) error {
 	time.Sleep(100 * time.Millisecond)
 	fmt.Printf("API server started successfully on: %s:%d\n", s.cfg.Host, s.cfg.Port)
 
+	if release, errMarker := serverlock.Write(s.cfg.AuthDir, fmt.Sprintf("%s:%d", s.cfg.Host, s.cfg.Port)); errMarker != nil {
+		log.Warnf("failed to record running server in auth directory: %v", errMarker)
+	} else {
+		s.releaseServerMarker = release
+	}
+
 	s.applyPprofConfig(s.cfg)
 
 	if s.hooks.OnAfterStart != nil {
@@ -616,15 +628,18 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 }
 
-// Shutdown gracefully stops background workers and the HTTP server.
-// It ensures all resources are properly cleaned up and connections are closed.
+// Shutdown stops the service in order: the HTTP listeners close and in-flight
+// requests drain for the configured grace period, then running inspections and
+// backups are cancelled and waited for, background workers stop, queued usage
+// records and request logs are flushed, and failed auth state writes are
+// retried. Each phase is logged with its duration.
 // The shutdown is idempotent and can be called multiple times safely.
 //
 // Parameters:
-//   - ctx: The context for controlling the shutdown timeout
+//   - ctx: The context bounding the whole shutdown
 //
 // Returns:
-//   - error: An error if shutdown fails
+//   - error: The joined errors of the phases that failed
 func (s *Service) Shutdown(ctx context.Context) error {
 	if s == nil {
 		return nil
@@ -634,57 +649,63 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		if ctx == nil {
 			ctx = context.Background()
 		}
-
-		// legacy refresh loop removed; only stopping core auth manager below
-
-		if s.watcherCancel != nil {
-			s.watcherCancel()
-		}
-		if s.coreManager != nil {
-			s.coreManager.StopAutoRefresh()
-		}
-		if s.watcher != nil {
-			if err := s.watcher.Stop(); err != nil {
-				log.Errorf("failed to stop file watcher: %v", err)
-				shutdownErr = err
-			}
-		}
-		if s.wsGateway != nil {
-			if err := s.wsGateway.Stop(ctx); err != nil {
-				log.Errorf("failed to stop websocket gateway: %v", err)
-				if shutdownErr == nil {
-					shutdownErr = err
-				}
-			}
-		}
-		if s.authQueueStop != nil {
-			s.authQueueStop()
-			s.authQueueStop = nil
+		grace, _ := shutdownTimings(s.cfg)
+		shutdownErr = runShutdownPhases(ctx, s.shutdownPhases(grace))
+		if s.releaseServerMarker != nil {
+			s.releaseServerMarker()
 		}
+	})
+	return shutdownErr
+}
 
-		if errShutdownPprof := s.shutdownPprof(ctx); errShutdownPprof != nil {
-			log.Errorf("failed to stop pprof server: %v", errShutdownPprof)
-			if shutdownErr == nil {
-				shutdownErr = errShutdownPprof
+func (s *Service) shutdownPhases(grace time.Duration) []shutdownPhase {
+	return []shutdownPhase{
+		{name: "draining HTTP server", run: func(ctx context.Context) error {
+			if s.server == nil {
+				return nil
 			}
-		}
-
-		// no legacy clients to persist
-
-		if s.server != nil {
-			shutdownCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			drainCtx, cancel := context.WithTimeout(ctx, grace)
 			defer cancel()
-			if err := s.server.Stop(shutdownCtx); err != nil {
-				log.Errorf("error stopping API server: %v", err)
-				if shutdownErr == nil {
-					shutdownErr = err
-				}
+			return s.server.Stop(drainCtx)
+		}},
+		{name: "stopping management workers", run: func(ctx context.Context) error {
+			if s.server == nil {
+				return nil
 			}
-		}
-
-		usage.StopDefault()
-	})
-	return shutdownErr
+			return s.server.StopWorkers(ctx)
+		}},
+		{name: "stopping watcher and auth refresh", run: func(context.Context) error {
+			if s.watcherCancel != nil {
+				s.watcherCancel()
+			}
+			if s.coreManager != nil {
+				s.coreManager.StopAutoRefresh()
+			}
+			if s.authQueueStop != nil {
+				s.authQueueStop()
+				s.authQueueStop = nil
+			}
+			if s.watcher != nil {
+				return s.watcher.Stop()
+			}
+			return nil
+		}},
+		{name: "stopping websocket gateway", run: func(ctx context.Context) error {
+			if s.wsGateway == nil {
+				return nil
+			}
+			return s.wsGateway.Stop(ctx)
+		}},
+		{name: "stopping pprof server", run: s.shutdownPprof},
+		{name: "flushing usage accounting", run: usage.StopDefaultAndWait},
+		{name: "flushing request logs", run: logging.WaitForStreamingWriters},
+		{name: "persisting auth state", run: func(ctx context.Context) error {
+			if s.coreManager == nil {
+				return nil
+			}
+			return s.coreManager.Flush(ctx)
+		}},
+	}
 }
 
 func (s *Service) ensureAuthDir() error {
@@ -0,0 +1,72 @@
+package cliproxy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultShutdownGracePeriod  = 30 * time.Second
+	defaultShutdownHardDeadline = 60 * time.Second
+	minShutdownHardMargin       = 10 * time.Second
+)
+
+// shutdownExit ends the process when the hard deadline passes. Tests replace it.
+var shutdownExit = func() { os.Exit(1) }
+
+// shutdownPhase is one ordered step of Service.Shutdown.
+type shutdownPhase struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// shutdownTimings returns the drain grace period and the hard deadline.
+func shutdownTimings(cfg *config.Config) (grace, hard time.Duration) {
+	grace, hard = defaultShutdownGracePeriod, defaultShutdownHardDeadline
+	if cfg != nil {
+		if cfg.Shutdown.GracePeriodSeconds > 0 {
+			grace = time.Duration(cfg.Shutdown.GracePeriodSeconds) * time.Second
+		}
+		if cfg.Shutdown.HardDeadlineSeconds > 0 {
+			hard = time.Duration(cfg.Shutdown.HardDeadlineSeconds) * time.Second
+		}
+	}
+	if hard < grace+minShutdownHardMargin {
+		hard = grace + minShutdownHardMargin
+	}
+	return grace, hard
+}
+
+// runShutdownPhases runs phases in order, logging each with its duration. A
+// failing phase does not stop the later ones; all errors are joined.
+func runShutdownPhases(ctx context.Context, phases []shutdownPhase) error {
+	started := time.Now()
+	var errs []error
+	for _, phase := range phases {
+		phaseStart := time.Now()
+		log.Infof("shutdown: %s...", phase.name)
+		if err := phase.run(ctx); err != nil {
+			log.Errorf("shutdown: %s failed after %s: %v", phase.name, time.Since(phaseStart).Round(time.Millisecond), err)
+			errs = append(errs, err)
+			continue
+		}
+		log.Infof("shutdown: %s done in %s", phase.name, time.Since(phaseStart).Round(time.Millisecond))
+	}
+	log.Infof("shutdown: completed in %s", time.Since(started).Round(time.Millisecond))
+	return errors.Join(errs...)
+}
+
+// armHardDeadline forces the process to exit if shutdown has not finished
+// after d. The returned function disarms it.
+func armHardDeadline(d time.Duration) func() {
+	timer := time.AfterFunc(d, func() {
+		log.Errorf("shutdown: hard deadline of %s exceeded, forcing exit", d)
+		shutdownExit()
+	})
+	return func() { timer.Stop() }
+}
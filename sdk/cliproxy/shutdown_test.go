@@ -0,0 +1,147 @@
+package cliproxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestRunShutdownPhases_DrainsStreamBeforeStoppingInspection(t *testing.T) {
+	const chunks = 5
+	firstChunk := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		for i := 0; i < chunks; i++ {
+			_, _ = io.WriteString(w, "data: chunk\n\n")
+			flusher.Flush()
+			if i == 0 {
+				close(firstChunk)
+			}
+			time.Sleep(40 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	type streamResult struct {
+		body string
+		err  error
+	}
+	streamDone := make(chan streamResult, 1)
+	go func() {
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			streamDone <- streamResult{err: err}
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		streamDone <- streamResult{body: string(body), err: err}
+	}()
+	<-firstChunk
+
+	// A fake inspection that only finishes once its context is cancelled.
+	inspectionCtx, cancelInspection := context.WithCancel(context.Background())
+	inspectionFinished := make(chan struct{})
+	go func() {
+		<-inspectionCtx.Done()
+		time.Sleep(20 * time.Millisecond)
+		close(inspectionFinished)
+	}()
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) {
+		mu.Lock()
+		order = append(order, name)
+		mu.Unlock()
+	}
+	var streamAtInspectionStop streamResult
+	phases := []shutdownPhase{
+		{name: "http", run: func(ctx context.Context) error {
+			err := srv.Config.Shutdown(ctx)
+			record("http")
+			return err
+		}},
+		{name: "inspection", run: func(ctx context.Context) error {
+			// The handler has returned; the client only has to read the tail.
+			select {
+			case streamAtInspectionStop = <-streamDone:
+			case <-time.After(time.Second):
+				t.Error("stream still open after the HTTP drain")
+			}
+			cancelInspection()
+			select {
+			case <-inspectionFinished:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			record("inspection")
+			return nil
+		}},
+		{name: "flush", run: func(context.Context) error {
+			record("flush")
+			return nil
+		}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := runShutdownPhases(ctx, phases); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	if streamAtInspectionStop.err != nil {
+		t.Fatalf("stream failed: %v", streamAtInspectionStop.err)
+	}
+	if got := len(streamAtInspectionStop.body); got != chunks*len("data: chunk\n\n") {
+		t.Fatalf("stream was cut: got %d bytes", got)
+	}
+	if len(order) != 3 || order[0] != "http" || order[1] != "inspection" || order[2] != "flush" {
+		t.Fatalf("unexpected phase order %v", order)
+	}
+	if _, err := http.Get(srv.URL); err == nil {
+		t.Fatal("server still accepts connections after shutdown")
+	}
+}
+
+func TestArmHardDeadline_ForcesExit(t *testing.T) {
+	exited := make(chan struct{})
+	prev := shutdownExit
+	shutdownExit = func() { close(exited) }
+	defer func() { shutdownExit = prev }()
+
+	disarm := armHardDeadline(50 * time.Millisecond)
+	defer disarm()
+	select {
+	case <-exited:
+	case <-time.After(2 * time.Second):
+		t.Fatal("hard deadline did not force exit")
+	}
+
+	stopped := make(chan struct{})
+	shutdownExit = func() { close(stopped) }
+	armHardDeadline(50 * time.Millisecond)()
+	select {
+	case <-stopped:
+		t.Fatal("disarmed deadline still fired")
+	case <-time.After(150 * time.Millisecond):
+	}
+}
+
+func TestShutdownTimings(t *testing.T) {
+	grace, hard := shutdownTimings(nil)
+	if grace != 30*time.Second || hard != 60*time.Second {
+		t.Fatalf("defaults = %s/%s", grace, hard)
+	}
+	cfg := &config.Config{}
+	cfg.Shutdown.GracePeriodSeconds = 120
+	cfg.Shutdown.HardDeadlineSeconds = 60
+	if grace, hard = shutdownTimings(cfg); grace != 120*time.Second || hard != 130*time.Second {
+		t.Fatalf("hard deadline not raised above grace: %s/%s", grace, hard)
+	}
+}
@@ -45,6 +45,7 @@ type Manager struct {
 	once     sync.Once
 	stopOnce sync.Once
 	cancel   context.CancelFunc
+	done     chan struct{} // closed when the dispatcher returns
 
 	mu     sync.Mutex
 	cond   *sync.Cond
@@ -73,7 +74,14 @@ func (m *Manager) Start(ctx context.Context) {
 		}
 		var workerCtx context.Context
 		workerCtx, m.cancel = context.WithCancel(ctx)
-		go m.run(workerCtx)
+		done := make(chan struct{})
+		m.mu.Lock()
+		m.done = done
+		m.mu.Unlock()
+		go func() {
+			defer close(done)
+			m.run(workerCtx)
+		}()
 	})
 }
 
@@ -93,6 +101,27 @@ func (m *Manager) Stop() {
 	})
 }
 
+// StopAndWait stops the dispatcher and waits until the queued records have
+// been delivered, or ctx is done.
+func (m *Manager) StopAndWait(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	m.Stop()
+	m.mu.Lock()
+	done := m.done
+	m.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Register appends a plugin to the delivery list.
 func (m *Manager) Register(plugin Plugin) {
 	if m == nil || plugin == nil {
@@ -179,3 +208,6 @@ func StartDefault(ctx context.Context) { DefaultManager().Start(ctx) }
 
 // StopDefault stops the default manager's dispatcher.
 func StopDefault() { DefaultManager().Stop() }
+
+// StopDefaultAndWait stops the default manager and waits for queued records.
+func StopDefaultAndWait(ctx context.Context) error { return DefaultManager().StopAndWait(ctx) }
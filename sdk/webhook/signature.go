@@ -0,0 +1,111 @@
+// Package webhook provides helpers for receivers of CLIProxyAPI webhook notifications.
+//
+// When a webhook target has a signing-secret configured, every delivery carries:
+//
+//	X-CLIProxy-Event:     the event type, e.g. "alert.firing"
+//	X-CLIProxy-Delivery:  a unique delivery ID, reused across retries of the same delivery
+//	X-CLIProxy-Signature: t=<unix seconds>,v1=<hex HMAC-SHA256>
+//
+// The v1 signature is HMAC-SHA256 keyed with the signing secret over the string
+// "<t>.<body>", where body is the exact request body as received. To verify a
+// delivery, a receiver should:
+//
+//  1. Read the raw body before decoding it.
+//  2. Parse t and every v1 value from X-CLIProxy-Signature.
+//  3. Recompute the HMAC over "<t>.<body>" and compare it in constant time
+//     against each v1 value.
+//  4. Reject the request when t is further than a chosen tolerance from the
+//     current time, which limits replay of captured requests.
+//  5. Optionally de-duplicate on X-CLIProxy-Delivery, since retries reuse it.
+//
+// VerifySignature performs steps 2 to 4.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HeaderSignature carries the timestamp and signature of a delivery.
+	HeaderSignature = "X-CLIProxy-Signature"
+	// HeaderEvent carries the event type.
+	HeaderEvent = "X-CLIProxy-Event"
+	// HeaderDelivery carries the delivery ID used for idempotency.
+	HeaderDelivery = "X-CLIProxy-Delivery"
+)
+
+var (
+	// ErrInvalidHeader is returned when the signature header cannot be parsed.
+	ErrInvalidHeader = errors.New("webhook: invalid signature header")
+	// ErrTimestampOutOfTolerance is returned when the signed timestamp is too old or too far in the future.
+	ErrTimestampOutOfTolerance = errors.New("webhook: timestamp outside tolerance")
+	// ErrSignatureMismatch is returned when no v1 signature matches the body.
+	ErrSignatureMismatch = errors.New("webhook: signature mismatch")
+)
+
+func computeSignature(secret string, timestamp int64, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// Sign returns the X-CLIProxy-Signature header value for body signed at t.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := t.Unix()
+	return fmt.Sprintf("t=%d,v1=%s", ts, hex.EncodeToString(computeSignature(secret, ts, body)))
+}
+
+// VerifySignature checks header against body. A non-positive tolerance disables
+// the timestamp check.
+func VerifySignature(secret, header string, body []byte, tolerance time.Duration) error {
+	var (
+		timestamp  int64
+		haveTS     bool
+		signatures [][]byte
+	)
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			ts, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrInvalidHeader
+			}
+			timestamp, haveTS = ts, true
+		case "v1":
+			sig, err := hex.DecodeString(value)
+			if err != nil {
+				continue
+			}
+			signatures = append(signatures, sig)
+		}
+	}
+	if !haveTS || len(signatures) == 0 {
+		return ErrInvalidHeader
+	}
+	if tolerance > 0 {
+		age := time.Since(time.Unix(timestamp, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrTimestampOutOfTolerance
+		}
+	}
+	expected := computeSignature(secret, timestamp, body)
+	for _, sig := range signatures {
+		if hmac.Equal(expected, sig) {
+			return nil
+		}
+	}
+	return ErrSignatureMismatch
+}
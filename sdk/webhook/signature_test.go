@@ -0,0 +1,40 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"type":"alert.firing"}`)
+	header := Sign("s3cret", time.Now(), body)
+
+	if err := VerifySignature("s3cret", header, body, 5*time.Minute); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := VerifySignature("other", header, body, 5*time.Minute); !errors.Is(err, ErrSignatureMismatch) {
+		t.Fatalf("wrong secret: got %v", err)
+	}
+	if err := VerifySignature("s3cret", header, []byte(`{"type":"alert.resolved"}`), 5*time.Minute); !errors.Is(err, ErrSignatureMismatch) {
+		t.Fatalf("tampered body: got %v", err)
+	}
+	if err := VerifySignature("s3cret", "v1=abcd", body, 0); !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("missing timestamp: got %v", err)
+	}
+}
+
+func TestVerifySignatureTolerance(t *testing.T) {
+	body := []byte("payload")
+	old := Sign("s3cret", time.Now().Add(-10*time.Minute), body)
+	if err := VerifySignature("s3cret", old, body, 5*time.Minute); !errors.Is(err, ErrTimestampOutOfTolerance) {
+		t.Fatalf("stale timestamp: got %v", err)
+	}
+	future := Sign("s3cret", time.Now().Add(10*time.Minute), body)
+	if err := VerifySignature("s3cret", future, body, 5*time.Minute); !errors.Is(err, ErrTimestampOutOfTolerance) {
+		t.Fatalf("future timestamp: got %v", err)
+	}
+	if err := VerifySignature("s3cret", old, body, 0); err != nil {
+		t.Fatalf("tolerance disabled should accept stale timestamp: %v", err)
+	}
+}